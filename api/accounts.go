@@ -1,6 +1,7 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"encoding/json"
@@ -49,6 +50,14 @@ func (a *API) enableAccountHandlers() error {
 	); err != nil {
 		return err
 	}
+	if err := a.endpoint.RegisterMethod(
+		"/accounts/search",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.organizationSearchHandler,
+	); err != nil {
+		return err
+	}
 	if err := a.endpoint.RegisterMethod(
 		"/accounts/{organizationID}/elections/count",
 		"GET",
@@ -57,6 +66,14 @@ func (a *API) enableAccountHandlers() error {
 	); err != nil {
 		return err
 	}
+	if err := a.endpoint.RegisterMethod(
+		"/accounts/{organizationID}/elections",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.organizationElectionPageHandler,
+	); err != nil {
+		return err
+	}
 	if err := a.endpoint.RegisterMethod(
 		"/accounts/{organizationID}/elections/status/{status}/page/{page}",
 		"GET",
@@ -81,6 +98,30 @@ func (a *API) enableAccountHandlers() error {
 	); err != nil {
 		return err
 	}
+	if err := a.endpoint.RegisterMethod(
+		"/accounts/{accountID}/transfers",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.tokenTransferPageHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/accounts/{address}/sponsorLimits/{scope}",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.sponsorLimitHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/accounts/{address}/vesting",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.tokenVestingsHandler,
+	); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -116,15 +157,43 @@ func (a *API) accountHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext)
 		}
 	}
 
-	var data []byte
-	if data, err = json.Marshal(Account{
+	locked, err := a.vocapp.State.LockedBalance(addr, true)
+	if err != nil {
+		return ErrCantFetchVestingSchedules.WithErr(err)
+	}
+	account := Account{
 		Address:       addr.Bytes(),
 		Nonce:         acc.GetNonce(),
 		Balance:       acc.GetBalance(),
 		ElectionIndex: acc.GetProcessIndex(),
 		InfoURL:       acc.GetInfoURI(),
 		Metadata:      accMetadata,
-	}); err != nil {
+		LockedBalance: locked,
+	}
+	// the state only keeps the locked amount, the schedules are indexed
+	if locked > 0 && a.indexer != nil {
+		vestings, err := a.indexer.TokenVestingsByAccount(addr.Bytes())
+		if err != nil {
+			return ErrCantFetchVestingSchedules.WithErr(err)
+		}
+		for _, v := range vestings {
+			if !bytes.Equal(v.To, addr.Bytes()) || v.Released >= v.Amount {
+				continue
+			}
+			account.Vesting = append(account.Vesting, &VestingSchedule{
+				TxHash:      types.HexBytes(v.TxHash),
+				From:        v.From,
+				Amount:      v.Amount,
+				Released:    v.Released,
+				StartHeight: v.StartHeight,
+				CliffHeight: v.CliffHeight,
+				EndHeight:   v.EndHeight,
+			})
+		}
+	}
+
+	var data []byte
+	if data, err = json.Marshal(account); err != nil {
 		return err
 	}
 	return ctx.Send(data, apirest.HTTPstatusOK)
@@ -223,6 +292,69 @@ func (a *API) accountSetHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContex
 	return ctx.Send(data, apirest.HTTPstatusOK)
 }
 
+// sponsorLimitHandler
+//
+//	@Summary		Get sponsor limit
+//	@Description	Get the spending limit of a sponsor for the transactions of an election or signed by an account.
+//	@Description	The scope is the election ID or the account address.
+//	@Success		200	{object}	SponsorLimit
+//	@Router			/accounts/{address}/sponsorLimits/{scope} [get]
+func (a *API) sponsorLimitHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	if len(util.TrimHex(ctx.URLParam("address"))) != common.AddressLength*2 {
+		return ErrAddressMalformed
+	}
+	sponsor := common.HexToAddress(ctx.URLParam("address"))
+	scope, err := hex.DecodeString(util.TrimHex(ctx.URLParam("scope")))
+	if err != nil || (len(scope) != types.ProcessIDsize && len(scope) != common.AddressLength) {
+		return ErrSponsorScopeMalformed
+	}
+	limit, err := a.vocapp.State.SponsorLimit(sponsor, scope, true)
+	if err != nil {
+		return ErrCantFetchSponsorLimit.WithErr(err)
+	}
+	if limit == nil {
+		return ErrSponsorLimitNotFound
+	}
+	data, err := json.Marshal(&SponsorLimit{
+		Sponsor: sponsor.Bytes(),
+		Scope:   scope,
+		Limit:   limit.Limit,
+		Spent:   limit.Spent,
+	})
+	if err != nil {
+		return ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
+// tokenVestingsHandler
+//
+//	@Summary		Vesting schedules history
+//	@Description	Returns the vesting schedules sent or received by an account, including the ones
+//	@Description	completely released, with the amount of their tokens unlocked so far.
+//	@Param			address	path		string	true	"Account address"
+//	@Success		200		{object}	object
+//	@Router			/accounts/{address}/vesting [get]
+func (a *API) tokenVestingsHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	if len(util.TrimHex(ctx.URLParam("address"))) != common.AddressLength*2 {
+		return ErrAddressMalformed
+	}
+	addr := common.HexToAddress(ctx.URLParam("address"))
+	vestings, err := a.indexer.TokenVestingsByAccount(addr.Bytes())
+	if err != nil {
+		return ErrCantFetchVestingSchedules.WithErr(err)
+	}
+	data, err := json.Marshal(
+		struct {
+			Vestings []*indexertypes.TokenVestingMeta `json:"vestings"`
+		}{Vestings: vestings},
+	)
+	if err != nil {
+		return ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
 // treasurerHandler
 //
 //	@Summary		Get treasurer address
@@ -318,6 +450,50 @@ func (a *API) electionListHandler(msg *apirest.APIdata, ctx *httprouter.HTTPCont
 	return ctx.Send(data, apirest.HTTPstatusOK)
 }
 
+// organizationSearchHandler
+//
+//	@Summary		Search organizations
+//	@Description	Full-text search over the organization metadata (names and descriptions in all languages).
+//	@Description	The search terms are given with the query parameter q, the optional parameter lang restricts
+//	@Description	the search to one metadata language and page is used for pagination. Results are ordered by relevance.
+//	@Success		200	{object}	object
+//	@Router			/accounts/search [get]
+func (a *API) organizationSearchHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	if a.indexer == nil {
+		return ErrSearchNotAvailable
+	}
+	query, lang, page, err := searchParams(ctx)
+	if err != nil {
+		return err
+	}
+	matches, err := a.indexer.SearchAccounts(query, lang, page*MaxPageSize, MaxPageSize)
+	if err != nil {
+		return ErrCantSearchMetadata.WithErr(err)
+	}
+	list := []OrganizationSearchResult{}
+	for _, m := range matches {
+		count, err := a.indexer.EntityProcessCount(m.ID)
+		if err != nil {
+			return ErrCantFetchElectionList.WithErr(err)
+		}
+		list = append(list, OrganizationSearchResult{
+			OrganizationList: OrganizationList{
+				OrganizationID: m.ID,
+				ElectionCount:  uint64(count),
+			},
+			Language: m.Language,
+			Rank:     m.Rank,
+		})
+	}
+	data, err := json.Marshal(struct {
+		Organizations []OrganizationSearchResult `json:"organizations"`
+	}{list})
+	if err != nil {
+		return ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
 // electionCountHandler
 //
 //	@Summary		Elections count
@@ -387,3 +563,77 @@ func (a *API) tokenTransfersHandler(msg *apirest.APIdata, ctx *httprouter.HTTPCo
 	}
 	return ctx.Send(data, apirest.HTTPstatusOK)
 }
+
+// organizationElectionPageHandler
+//
+//	@Summary		List organization elections
+//	@Description	Returns a page of the elections of an organization, in the order they were created.
+//	@Description	The optional status query parameter filters the elections by status: ready, paused,
+//	@Description	canceled or ended (results). Use the cursor and limit query parameters to paginate,
+//	@Description	see Pagination.
+//	@Param			organizationID	path		string	true	"Organization id"
+//	@Success		200				{object}	ElectionPage
+//	@Router			/accounts/{organizationID}/elections [get]
+func (a *API) organizationElectionPageHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	organizationID, err := hex.DecodeString(util.TrimHex(ctx.URLParam("organizationID")))
+	if err != nil || organizationID == nil {
+		return ErrCantParseOrgID.Withf("%q", ctx.URLParam("organizationID"))
+	}
+	var statuses []string
+	switch status := ctx.Request.URL.Query().Get("status"); status {
+	case "ready", "paused", "canceled":
+		statuses = []string{strings.ToUpper(status)}
+	case "ended", "results":
+		statuses = []string{"RESULTS", "ENDED"}
+	case "":
+	default:
+		return ErrParamStatusMissing.With(status)
+	}
+	cursor, limit, err := cursorParams(ctx)
+	if err != nil {
+		return err
+	}
+	pids, page, err := a.indexer.ProcessPage(organizationID, statuses, cursor, limit)
+	if err != nil {
+		return ErrCantFetchElectionList.WithErr(err)
+	}
+	return a.sendElectionPage(ctx, pids, page, limit)
+}
+
+// tokenTransferPageHandler
+//
+//	@Summary		List account transfers
+//	@Description	Returns a page of the token transfers sent by an account, in the order they were
+//	@Description	included in the chain. Use the cursor and limit query parameters to paginate, see Pagination.
+//	@Param			accountID	path		string	true	"Account address"
+//	@Success		200			{object}	TransferPage
+//	@Router			/accounts/{accountID}/transfers [get]
+func (a *API) tokenTransferPageHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	accountID, err := hex.DecodeString(util.TrimHex(ctx.URLParam("accountID")))
+	if err != nil || accountID == nil {
+		return ErrCantParseAccountID.Withf("%q", ctx.URLParam("accountID"))
+	}
+	acc, err := a.vocapp.State.GetAccount(common.BytesToAddress(accountID), true)
+	if acc == nil {
+		return ErrAccountNotFound
+	}
+	if err != nil {
+		return err
+	}
+	cursor, limit, err := cursorParams(ctx)
+	if err != nil {
+		return err
+	}
+	transfers, page, err := a.indexer.TokenTransferPage(accountID, cursor, limit)
+	if err != nil {
+		return ErrCantFetchTokenTransfers.WithErr(err)
+	}
+	data, err := json.Marshal(&TransferPage{
+		Transfers:  transfers,
+		Pagination: newPagination(page, limit),
+	})
+	if err != nil {
+		return ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
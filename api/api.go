@@ -22,6 +22,13 @@ import (
 // MaxPageSize defines the maximum number of results returned by the paginated endpoints
 const MaxPageSize = 10
 
+// MaxListLimit is the maximum number of items per page of the lists paginated
+// with cursors. By default, MaxPageSize items are returned.
+const MaxListLimit = 100
+
+// MaxStatsBuckets is the maximum number of buckets returned by the stats endpoints.
+const MaxStatsBuckets = 1000
+
 var (
 	ErrMissingModulesForHandler = fmt.Errorf("missing modules attached for enabling handler")
 	ErrHandlerUnknown           = fmt.Errorf("handler unknown")
@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
 	"go.vocdoni.io/proto/build/go/models"
 	"google.golang.org/protobuf/encoding/protojson"
 )
@@ -32,6 +33,24 @@ type ElectionSummary struct {
 	Results        [][]*types.BigInt `json:"result,omitempty"`
 }
 
+// ElectionSearchResult is an election matching a full-text metadata search.
+type ElectionSearchResult struct {
+	ElectionSummary
+	// Language is the metadata language which matched best the search terms
+	Language string `json:"language"`
+	// Rank is the relevance of the match, lower is better
+	Rank float64 `json:"rank"`
+}
+
+// OrganizationSearchResult is an organization matching a full-text metadata search.
+type OrganizationSearchResult struct {
+	OrganizationList
+	// Language is the metadata language which matched best the search terms
+	Language string `json:"language"`
+	// Rank is the relevance of the match, lower is better
+	Rank float64 `json:"rank"`
+}
+
 // ElectionResults is the struct used to wrap the results of an election
 type ElectionResults struct {
 	// ABIEncoded is the abi encoded election results
@@ -57,6 +76,11 @@ type Election struct {
 	ElectionMode ElectionMode      `json:"electionMode,omitempty"`
 	TallyMode    TallyMode         `json:"tallyMode,omitempty"`
 	Metadata     *ElectionMetadata `json:"metadata,omitempty"`
+	// MetadataValid is true if the metadata matches its JSON schema, in which
+	// case Metadata is normalised. Otherwise, MetadataErrors holds the reasons,
+	// and Metadata is the invalid metadata as is, if it could be decoded.
+	MetadataValid  bool     `json:"metadataValid"`
+	MetadataErrors []string `json:"metadataErrors,omitempty"`
 }
 
 type ElectionKeys struct {
@@ -186,6 +210,56 @@ type ChainInfo struct {
 	MaxCensusSize           uint64    `json:"maxCensusSize"`
 }
 
+// Pagination holds the cursors to fetch the next and previous pages of a list
+// paginated with cursors, if there are any, and the total number of items of the list,
+// which is only set in the first page.
+type Pagination struct {
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Total uint64 `json:"total,omitempty"`
+	Limit int    `json:"limit"`
+}
+
+// ElectionPage is a page of a list of elections paginated with cursors.
+type ElectionPage struct {
+	Elections  []*ElectionSummary `json:"elections"`
+	Pagination *Pagination        `json:"pagination"`
+}
+
+// OrganizationPage is a page of the list of organizations paginated with cursors.
+type OrganizationPage struct {
+	Organizations []*OrganizationList `json:"organizations"`
+	Pagination    *Pagination         `json:"pagination"`
+}
+
+// VotePage is a page of the list of votes of an election paginated with cursors.
+type VotePage struct {
+	Votes      []*Vote     `json:"votes"`
+	Pagination *Pagination `json:"pagination"`
+}
+
+// TransactionPage is a page of the list of transactions paginated with cursors.
+type TransactionPage struct {
+	Transactions []*indexertypes.TxReference `json:"transactions"`
+	Pagination   *Pagination                 `json:"pagination"`
+}
+
+// TransferPage is a page of the list of token transfers of an account paginated with cursors.
+type TransferPage struct {
+	Transfers  []*indexertypes.TokenTransferMeta `json:"transfers"`
+	Pagination *Pagination                       `json:"pagination"`
+}
+
+// ChainStats is a time series of stats aggregated by bucket, between From and To.
+// Only one of Stats or Turnout is set, depending on the requested stats.
+type ChainStats struct {
+	Bucket  string                       `json:"bucket"`
+	From    time.Time                    `json:"from"`
+	To      time.Time                    `json:"to"`
+	Stats   []*indexertypes.StatsPoint   `json:"stats,omitempty"`
+	Turnout []*indexertypes.TurnoutPoint `json:"turnout,omitempty"`
+}
+
 type Account struct {
 	Address       types.HexBytes   `json:"address"`
 	Nonce         uint32           `json:"nonce"`
@@ -194,6 +268,33 @@ type Account struct {
 	InfoURL       string           `json:"infoURL,omitempty"`
 	Token         *uuid.UUID       `json:"token,omitempty"`
 	Metadata      *AccountMetadata `json:"metadata,omitempty"`
+	// LockedBalance is the amount of tokens locked by the vesting schedules,
+	// which is not part of the spendable balance.
+	LockedBalance uint64             `json:"lockedBalance"`
+	Vesting       []*VestingSchedule `json:"vesting,omitempty"`
+}
+
+// VestingSchedule holds the tokens of a vesting transfer locked on an account.
+// Nothing is unlocked before the cliff height, and then the amount vests
+// linearly from the start height to the end height.
+type VestingSchedule struct {
+	TxHash      types.HexBytes `json:"txHash"`
+	From        types.HexBytes `json:"from"`
+	Amount      uint64         `json:"amount"`
+	Released    uint64         `json:"released"`
+	StartHeight uint32         `json:"startHeight"`
+	CliffHeight uint32         `json:"cliffHeight"`
+	EndHeight   uint32         `json:"endHeight"`
+}
+
+// SponsorLimit is the maximum amount a sponsor pays for the transactions of an
+// election or signed by an account.
+type SponsorLimit struct {
+	Sponsor types.HexBytes `json:"sponsor"`
+	// Scope is the election ID or the account address the limit applies to.
+	Scope types.HexBytes `json:"scope"`
+	Limit uint64         `json:"limit"`
+	Spent uint64         `json:"spent"`
 }
 
 type AccountSet struct {
@@ -215,6 +316,87 @@ type Census struct {
 	Valid    bool           `json:"valid,omitempty"`
 	URI      string         `json:"uri,omitempty"`
 	Siblings []string       `json:"siblings,omitempty"`
+	// ParentRoot is the root of the census published before this one from
+	// the same census.
+	ParentRoot types.HexBytes `json:"parentRoot,omitempty"`
+}
+
+// CensusProofsRequest is the list of keys to generate the census proofs.
+type CensusProofsRequest struct {
+	Keys []types.HexBytes `json:"keys"`
+}
+
+// CensusBatchProof is a census proof generated in a batch. Siblings are only
+// included for zkweighted censuses, and Error is set if the proof could not be
+// generated.
+type CensusBatchProof struct {
+	Key      types.HexBytes `json:"key"`
+	Proof    types.HexBytes `json:"proof,omitempty"`
+	Value    types.HexBytes `json:"value,omitempty"`
+	Weight   *types.BigInt  `json:"weight,omitempty"`
+	Siblings []string       `json:"siblings,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// CensusHistory is the lineage of a census, from the newest published root to
+// the first one.
+type CensusHistory struct {
+	Versions []*CensusVersion `json:"versions"`
+}
+
+type CensusVersion struct {
+	Root       types.HexBytes `json:"root"`
+	URI        string         `json:"uri,omitempty"`
+	ParentRoot types.HexBytes `json:"parentRoot,omitempty"`
+}
+
+// CensusDiff holds the keys added, removed and reweighted in a census since
+// the root FromRoot. The keys are the census tree keys, so they are hashed for
+// the weighted censuses.
+type CensusDiff struct {
+	FromRoot   types.HexBytes     `json:"fromRoot"`
+	ToRoot     types.HexBytes     `json:"toRoot"`
+	Added      []*CensusDiffEntry `json:"added"`
+	Removed    []*CensusDiffEntry `json:"removed"`
+	Reweighted []*CensusDiffEntry `json:"reweighted"`
+}
+
+type CensusDiffEntry struct {
+	Key       types.HexBytes `json:"key"`
+	Weight    *types.BigInt  `json:"weight,omitempty"`
+	OldWeight *types.BigInt  `json:"oldWeight,omitempty"`
+}
+
+// CensusMerge are the parameters to build a new census from the union or the
+// intersection of existing censuses. WeightPolicy resolves the weight of the
+// keys found in more than one census: sum, max, min, first or fail.
+type CensusMerge struct {
+	CensusIDs    []types.HexBytes `json:"censusIDs"`
+	Operation    string           `json:"operation"`
+	WeightPolicy string           `json:"weightPolicy"`
+}
+
+// CensusJob is the status of a census job, which uploads a large census in chunks.
+// Total is the number of participants, or bytes of the census dump for import jobs,
+// uploaded in the chunks, and Processed those already processed.
+type CensusJob struct {
+	JobID     string         `json:"jobID,omitempty"`
+	CensusID  types.HexBytes `json:"censusID,omitempty"`
+	Type      string         `json:"type"`
+	Status    string         `json:"status,omitempty"`
+	Chunks    uint32         `json:"chunks"`
+	Total     uint64         `json:"total"`
+	Processed uint64         `json:"processed"`
+	Invalid   uint64         `json:"invalid"`
+	Progress  float64        `json:"progress"`
+	RootHash  types.HexBytes `json:"rootHash,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Updated   time.Time      `json:"updated"`
+}
+
+// CensusJobChunk is a chunk of the compressed census dump uploaded to an import job.
+type CensusJobChunk struct {
+	Data []byte `json:"data"`
 }
 
 type File struct {
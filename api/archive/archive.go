@@ -0,0 +1,138 @@
+package archive
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/apirest"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain/processarchive"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+const (
+	ArchiveHandler = "archive"
+)
+
+// ArchiveAPI is an httprouter/apirest handler for serving the elections of
+// the process archive, which are available even after they are pruned from
+// the indexer.
+type ArchiveAPI struct {
+	storage processarchive.Storage
+}
+
+// AttachArchiveAPI attaches the public endpoints of the process archive to the
+// given http apirest router.
+// The path prefix is used to define the base path in which the endpoint methods will be registered.
+// For example, if the pathPrefix is "/archive", the resulting endpoints are /archive/elections/{electionID},
+// /archive/elections/page/{page} and /archive/organizations/{organizationID}/elections/page/{page}.
+func AttachArchiveAPI(storage processarchive.Storage, a *apirest.API, pathPrefix string) error {
+	ar := &ArchiveAPI{storage: storage}
+	if err := a.RegisterMethod(
+		fmt.Sprintf("%s/elections/{electionID}", pathPrefix),
+		"GET",
+		apirest.MethodAccessTypePublic,
+		ar.electionHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.RegisterMethod(
+		fmt.Sprintf("%s/elections/page/{page}", pathPrefix),
+		"GET",
+		apirest.MethodAccessTypePublic,
+		ar.electionListHandler,
+	); err != nil {
+		return err
+	}
+	return a.RegisterMethod(
+		fmt.Sprintf("%s/organizations/{organizationID}/elections/page/{page}", pathPrefix),
+		"GET",
+		apirest.MethodAccessTypePublic,
+		ar.electionListHandler,
+	)
+}
+
+// electionHandler
+//
+//	@Summary		Archived election
+//	@Description	Returns the archived election, with its indexer information and results.
+//	@Tags			Archive
+//	@Produce		json
+//	@Param			electionID	path		string	true	"Election id"
+//	@Success		200			{object}	processarchive.Process
+//	@Router			/archive/elections/{electionID} [get]
+func (ar *ArchiveAPI) electionHandler(_ *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	electionID, err := hex.DecodeString(util.TrimHex(ctx.URLParam("electionID")))
+	if err != nil || len(electionID) != types.ProcessIDsize {
+		return api.ErrCantParseElectionID.Withf("(%s): %v", ctx.URLParam("electionID"), err)
+	}
+	process, err := ar.storage.GetProcess(electionID)
+	if err != nil {
+		if errors.Is(err, processarchive.ErrProcessNotFound) {
+			return api.ErrArchivedElectionNotFound.With(hex.EncodeToString(electionID))
+		}
+		return api.ErrCantFetchArchive.WithErr(err)
+	}
+	data, err := json.Marshal(process)
+	if err != nil {
+		return api.ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
+// electionListHandler
+//
+//	@Summary		Archived elections
+//	@Description	Returns a page of the archived elections, sorted by id, or the archived elections of an organization,
+//	@Description	in the order they were archived.
+//	@Tags			Archive
+//	@Produce		json
+//	@Param			organizationID	path		string	false	"Organization id"
+//	@Param			page			path		number	true	"Page number, starting at 0"
+//	@Success		200				{object}	archive.ElectionListResponse
+//	@Router			/archive/elections/page/{page} [get]
+//	@Router			/archive/organizations/{organizationID}/elections/page/{page} [get]
+func (ar *ArchiveAPI) electionListHandler(_ *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	page, err := strconv.Atoi(ctx.URLParam("page"))
+	if err != nil || page < 0 {
+		return api.ErrCantParsePageNumber.With(ctx.URLParam("page"))
+	}
+	var organizationID []byte
+	if ctx.URLParam("organizationID") != "" {
+		organizationID, err = hex.DecodeString(util.TrimHex(ctx.URLParam("organizationID")))
+		if err != nil {
+			return api.ErrCantParseOrgID.Withf("(%s): %v", ctx.URLParam("organizationID"), err)
+		}
+	}
+	pids, err := ar.storage.ProcessList(organizationID, page*api.MaxPageSize, api.MaxPageSize)
+	if err != nil {
+		return api.ErrCantFetchArchive.WithErr(err)
+	}
+	list := &ElectionListResponse{Elections: []*ElectionSummary{}}
+	for _, pid := range pids {
+		p, err := ar.storage.GetProcess(pid)
+		if err != nil {
+			return api.ErrCantFetchArchive.Withf("(%x): %v", pid, err)
+		}
+		list.Elections = append(list.Elections, &ElectionSummary{
+			ElectionID:     pid,
+			OrganizationID: p.ProcessInfo.EntityID,
+			Status:         models.ProcessStatus_name[p.ProcessInfo.Status],
+			StartDate:      p.StartDate,
+			EndDate:        p.EndDate,
+			FinalResults:   p.ProcessInfo.FinalResults,
+			ChainID:        p.ChainID,
+		})
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return api.ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
@@ -0,0 +1,88 @@
+package archive
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/apirest"
+	"go.vocdoni.io/dvote/test/testcommon/testutil"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+	"go.vocdoni.io/dvote/vochain/processarchive"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+func TestArchiveAPI(t *testing.T) {
+	storage, err := processarchive.NewFSStorage(t.TempDir())
+	qt.Assert(t, err, qt.IsNil)
+	orgID := util.RandomBytes(20)
+	endDate := time.Unix(1700000000, 0).UTC()
+	pids := []types.HexBytes{}
+	for i := 0; i < api.MaxPageSize+1; i++ {
+		pid := util.RandomBytes(types.ProcessIDsize)
+		entityID := util.RandomBytes(20)
+		if i == 0 {
+			entityID = orgID
+		}
+		qt.Assert(t, storage.AddProcess(&processarchive.Process{
+			ProcessInfo: &indexertypes.Process{
+				ID:           pid,
+				EntityID:     entityID,
+				Status:       int32(models.ProcessStatus_RESULTS),
+				FinalResults: true,
+			},
+			EndDate: &endDate,
+			ChainID: "test",
+		}), qt.IsNil)
+		pids = append(pids, pid)
+	}
+
+	router := httprouter.HTTProuter{}
+	qt.Assert(t, router.Init("127.0.0.1", 0), qt.IsNil)
+	a, err := apirest.NewAPI(&router, "/")
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, AttachArchiveAPI(storage, a, "/archive"), qt.IsNil)
+	addr, err := url.Parse("http://" + router.Address().String() + "/")
+	qt.Assert(t, err, qt.IsNil)
+	c := testutil.NewTestHTTPclient(t, addr, nil)
+
+	resp, code := c.Request("GET", nil, "archive", "elections", pids[0].String())
+	qt.Assert(t, code, qt.Equals, 200)
+	process := &processarchive.Process{}
+	qt.Assert(t, json.Unmarshal(resp, process), qt.IsNil)
+	qt.Assert(t, process.ProcessInfo.ID, qt.DeepEquals, pids[0])
+	qt.Assert(t, process.EndDate.Equal(endDate), qt.IsTrue)
+
+	_, code = c.Request("GET", nil, "archive", "elections", util.RandomHex(types.ProcessIDsize))
+	qt.Assert(t, code, qt.Equals, api.ErrArchivedElectionNotFound.HTTPstatus)
+	_, code = c.Request("GET", nil, "archive", "elections", "invalid")
+	qt.Assert(t, code, qt.Equals, api.ErrCantParseElectionID.HTTPstatus)
+
+	// the elections are paginated
+	list := &ElectionListResponse{}
+	resp, code = c.Request("GET", nil, "archive", "elections", "page", "0")
+	qt.Assert(t, code, qt.Equals, 200)
+	qt.Assert(t, json.Unmarshal(resp, list), qt.IsNil)
+	qt.Assert(t, list.Elections, qt.HasLen, api.MaxPageSize)
+	qt.Assert(t, list.Elections[0].Status, qt.Equals, "RESULTS")
+	qt.Assert(t, list.Elections[0].ChainID, qt.Equals, "test")
+	resp, code = c.Request("GET", nil, "archive", "elections", "page", "1")
+	qt.Assert(t, code, qt.Equals, 200)
+	qt.Assert(t, json.Unmarshal(resp, list), qt.IsNil)
+	qt.Assert(t, list.Elections, qt.HasLen, 1)
+
+	resp, code = c.Request("GET", nil, "archive", "organizations", hex.EncodeToString(orgID),
+		"elections", "page", "0")
+	qt.Assert(t, code, qt.Equals, 200)
+	qt.Assert(t, json.Unmarshal(resp, list), qt.IsNil)
+	qt.Assert(t, list.Elections, qt.HasLen, 1)
+	qt.Assert(t, list.Elections[0].ElectionID, qt.DeepEquals, pids[0])
+	qt.Assert(t, list.Elections[0].OrganizationID, qt.DeepEquals, types.HexBytes(orgID))
+}
@@ -0,0 +1,23 @@
+package archive
+
+import (
+	"time"
+
+	"go.vocdoni.io/dvote/types"
+)
+
+// ElectionSummary is the summary of an archived election.
+type ElectionSummary struct {
+	ElectionID     types.HexBytes `json:"electionId"`
+	OrganizationID types.HexBytes `json:"organizationId"`
+	Status         string         `json:"status"`
+	StartDate      *time.Time     `json:"startDate,omitempty"`
+	EndDate        *time.Time     `json:"endDate,omitempty"`
+	FinalResults   bool           `json:"finalResults"`
+	ChainID        string         `json:"chainId,omitempty"`
+}
+
+// ElectionListResponse is a page of the archived elections.
+type ElectionListResponse struct {
+	Elections []*ElectionSummary `json:"elections"`
+}
@@ -2,7 +2,12 @@ package api
 
 import (
 	"encoding/hex"
+	"errors"
 
+	"go.vocdoni.io/dvote/api/censusdb"
+	"go.vocdoni.io/dvote/censustree"
+	"go.vocdoni.io/dvote/tree/arbo"
+	"go.vocdoni.io/dvote/types"
 	"go.vocdoni.io/dvote/util"
 	"go.vocdoni.io/proto/build/go/models"
 )
@@ -49,3 +54,91 @@ func censusKeyParse(key string) ([]byte, error) {
 	key = util.TrimHex(key)
 	return hex.DecodeString(key)
 }
+
+// censusLeaves returns the keys and values of the census tree leaves for the
+// participants. The keys are hashed, except for the zkweighted censuses.
+func censusLeaves(ref *censusdb.CensusRef, participants []CensusParticipant) ([][]byte, [][]byte, error) {
+	keys := [][]byte{}
+	values := [][]byte{}
+	for i, p := range participants {
+		if p.Key == nil {
+			return nil, nil, ErrParticipantKeyMissing.Withf("number %d", i)
+		}
+		// check the weight parameter
+		// TODO: (lucasmenendez) remove that check, now all census are weighted
+		if p.Weight == nil {
+			p.Weight = new(types.BigInt).SetUint64(1)
+		}
+
+		leafKey := p.Key
+		if len(leafKey) > censustree.DefaultMaxKeyLen {
+			return nil, nil, ErrInvalidCensusKeyLength.Withf("the census key cannot be longer than %d bytes", censustree.DefaultMaxKeyLen)
+		}
+
+		if ref.CensusType != int32(models.Census_ARBO_POSEIDON) {
+			// compute the hash, we use it as key for the merkle tree
+			var err error
+			leafKey, err = ref.Tree().Hash(p.Key)
+			if err != nil {
+				return nil, nil, ErrCantComputeKeyHash.WithErr(err)
+			}
+			leafKey = leafKey[:censustree.DefaultMaxKeyLen]
+		}
+
+		keys = append(keys, leafKey)
+		values = append(values, ref.Tree().BigIntToBytes(p.Weight.MathBigInt()))
+	}
+	return keys, values, nil
+}
+
+// censusCheckParticipants checks that all the keys are already in the census
+// tree, so a batch of changes is not partially applied because of a missing
+// participant.
+func censusCheckParticipants(ref *censusdb.CensusRef, keys [][]byte) error {
+	for i, key := range keys {
+		if _, err := ref.Tree().Get(key); err != nil {
+			if errors.Is(err, arbo.ErrKeyNotFound) {
+				return ErrCensusParticipantNotFound.Withf("number %d", i)
+			}
+			return ErrCantUpdateTree.WithErr(err)
+		}
+	}
+	return nil
+}
+
+// newCensusDiffEntries returns the API representation of the census diff entries.
+func newCensusDiffEntries(entries []censusdb.DiffEntry) []*CensusDiffEntry {
+	list := []*CensusDiffEntry{}
+	for _, e := range entries {
+		entry := &CensusDiffEntry{Key: e.Key}
+		if e.Value != nil {
+			entry.Weight = (*types.BigInt)(arbo.BytesToBigInt(e.Value))
+		}
+		if e.OldValue != nil {
+			entry.OldWeight = (*types.BigInt)(arbo.BytesToBigInt(e.OldValue))
+		}
+		list = append(list, entry)
+	}
+	return list
+}
+
+// newCensusJob returns the API representation of the census job.
+func newCensusJob(job *censusdb.Job) *CensusJob {
+	cj := &CensusJob{
+		JobID:     job.ID.String(),
+		CensusID:  job.CensusID,
+		Type:      job.Type,
+		Status:    job.Status,
+		Chunks:    job.Chunks,
+		Total:     job.Total,
+		Processed: job.Processed,
+		Invalid:   job.Invalid,
+		RootHash:  job.RootHash,
+		Error:     job.Error,
+		Updated:   job.Updated,
+	}
+	if job.Total > 0 {
+		cj.Progress = float64(job.Processed) * 100 / float64(job.Total)
+	}
+	return cj
+}
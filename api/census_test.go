@@ -1,6 +1,7 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"math/big"
@@ -92,6 +93,35 @@ func TestCensus(t *testing.T) {
 	qt.Assert(t, json.Unmarshal(resp, censusData), qt.IsNil)
 	qt.Assert(t, censusData.Size, qt.Equals, index)
 
+	// change the weight of the first participant and delete the second one
+	cparts.Participants[0].Weight = (*types.BigInt)(big.NewInt(31))
+	_, code = c.Request("PUT", &CensusParticipants{
+		Participants: cparts.Participants[:1],
+	}, id1, "participants")
+	qt.Assert(t, code, qt.Equals, 200)
+	_, code = c.Request("DELETE", &CensusParticipants{
+		Participants: cparts.Participants[1:2],
+	}, id1, "participants")
+	qt.Assert(t, code, qt.Equals, 200)
+	weight += 30 - 2
+	index--
+
+	// deleting a missing participant must fail
+	_, code = c.Request("DELETE", &CensusParticipants{
+		Participants: cparts.Participants[1:2],
+	}, id1, "participants")
+	qt.Assert(t, code, qt.Equals, 404)
+
+	resp, code = c.Request("GET", nil, id1, "weight")
+	qt.Assert(t, code, qt.Equals, 200)
+	qt.Assert(t, json.Unmarshal(resp, censusData), qt.IsNil)
+	qt.Assert(t, censusData.Weight.String(), qt.Equals, fmt.Sprintf("%d", weight))
+
+	resp, code = c.Request("GET", nil, id1, "size")
+	qt.Assert(t, code, qt.Equals, 200)
+	qt.Assert(t, json.Unmarshal(resp, censusData), qt.IsNil)
+	qt.Assert(t, censusData.Size, qt.Equals, index)
+
 	// add one more key and check proof
 	key := rnd.RandomBytes(20)
 	keyWeight := (*types.BigInt)(big.NewInt(100))
@@ -106,6 +136,12 @@ func TestCensus(t *testing.T) {
 	qt.Assert(t, censusData.CensusID, qt.IsNotNil)
 	id2 := censusData.CensusID.String()
 
+	// the published census can not be modified
+	_, code = c.Request("DELETE", &CensusParticipants{
+		Participants: []CensusParticipant{{Key: key}},
+	}, id2, "participants")
+	qt.Assert(t, code, qt.Equals, 400)
+
 	resp, code = c.Request("GET", nil, id2, "proof", fmt.Sprintf("%x", key))
 	qt.Assert(t, code, qt.Equals, 200)
 	qt.Assert(t, json.Unmarshal(resp, censusData), qt.IsNil)
@@ -142,6 +178,49 @@ func TestCensus(t *testing.T) {
 	resp, code = c.Request("POST", censusDump, id3, "import")
 	qt.Assert(t, code, qt.Equals, 200, qt.Commentf("response: %s", resp))
 
+	// add a key and publish again, the previous root must be the parent
+	key2 := rnd.RandomBytes(20)
+	_, code = c.Request("POST", &CensusParticipants{
+		Participants: []CensusParticipant{{Key: key2, Weight: keyWeight}},
+	}, id1, "participants")
+	qt.Assert(t, code, qt.Equals, 200)
+	resp, code = c.Request("POST", nil, id1, "publish")
+	qt.Assert(t, code, qt.Equals, 200)
+	qt.Assert(t, json.Unmarshal(resp, censusData), qt.IsNil)
+	qt.Assert(t, censusData.ParentRoot.String(), qt.Equals, id2)
+	id4 := censusData.CensusID.String()
+
+	history := &CensusHistory{}
+	resp, code = c.Request("GET", nil, id1, "history")
+	qt.Assert(t, code, qt.Equals, 200)
+	qt.Assert(t, json.Unmarshal(resp, history), qt.IsNil)
+	qt.Assert(t, history.Versions, qt.HasLen, 2)
+	qt.Assert(t, history.Versions[0].Root.String(), qt.Equals, id4)
+	qt.Assert(t, history.Versions[1].Root.String(), qt.Equals, id2)
+
+	// diff between both published roots
+	diff := &CensusDiff{}
+	resp, code = c.Request("GET", nil, id4, "diff", id2)
+	qt.Assert(t, code, qt.Equals, 200, qt.Commentf("response: %s", resp))
+	qt.Assert(t, json.Unmarshal(resp, diff), qt.IsNil)
+	qt.Assert(t, diff.Added, qt.HasLen, 1)
+	qt.Assert(t, diff.Removed, qt.HasLen, 0)
+	qt.Assert(t, diff.Reweighted, qt.HasLen, 0)
+
+	// merge the imported census (same keys than id2) with the last published
+	resp, code = c.Request("POST", &CensusMerge{
+		CensusIDs:    []types.HexBytes{types.HexStringToHexBytes(id3), types.HexStringToHexBytes(id4)},
+		Operation:    censusdb.MergeIntersection,
+		WeightPolicy: censusdb.WeightPolicySum,
+	}, "merge")
+	qt.Assert(t, code, qt.Equals, 200, qt.Commentf("response: %s", resp))
+	qt.Assert(t, json.Unmarshal(resp, censusData), qt.IsNil)
+	qt.Assert(t, censusData.Size, qt.Equals, index+1)
+	resp, code = c.Request("GET", nil, censusData.CensusID.String(), "weight")
+	qt.Assert(t, code, qt.Equals, 200)
+	qt.Assert(t, json.Unmarshal(resp, censusData), qt.IsNil)
+	qt.Assert(t, censusData.Weight.String(), qt.Equals, fmt.Sprintf("%d", 2*(weight+100)))
+
 	// delete the first census
 	_, code = c.Request("DELETE", nil, id1)
 	qt.Assert(t, code, qt.Equals, 200)
@@ -315,4 +394,31 @@ func TestCensusZk(t *testing.T) {
 	qt.Assert(t, code, qt.Equals, 200)
 	qt.Assert(t, json.Unmarshal(resp, censusData), qt.IsNil)
 	qt.Assert(t, censusData.Weight.String(), qt.Equals, "1")
+
+	// generate the proofs of all the keys and a missing one in a batch
+	req := CensusProofsRequest{}
+	for _, k := range keys {
+		req.Keys = append(req.Keys, k)
+	}
+	zkAddr, err := zk.NewRandAddress()
+	qt.Assert(t, err, qt.IsNil)
+	req.Keys = append(req.Keys, zkAddr.Bytes())
+	resp, code = c.Request("POST", &req, root, "proofs")
+	qt.Assert(t, code, qt.Equals, 200, qt.Commentf("response: %s", resp))
+	dec := json.NewDecoder(bytes.NewReader(resp))
+	for i := range req.Keys {
+		proof := &CensusBatchProof{}
+		qt.Assert(t, dec.Decode(proof), qt.IsNil)
+		qt.Assert(t, proof.Key, qt.DeepEquals, req.Keys[i])
+		if i == len(keys) {
+			qt.Assert(t, proof.Error, qt.Not(qt.Equals), "")
+			break
+		}
+		qt.Assert(t, proof.Error, qt.Equals, "")
+		qt.Assert(t, proof.Weight.String(), qt.Equals, fmt.Sprintf("%d", i+1))
+		if i == 0 {
+			qt.Assert(t, proof.Proof, qt.DeepEquals, censusData.Proof)
+			qt.Assert(t, proof.Siblings, qt.DeepEquals, censusData.Siblings)
+		}
+	}
 }
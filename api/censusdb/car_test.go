@@ -0,0 +1,40 @@
+package censusdb
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/data"
+	"go.vocdoni.io/dvote/db/metadb"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+func TestImportCAR(t *testing.T) {
+	c := NewCensusDB(metadb.NewTest(t))
+	ref := newTestCensus(t, c, map[byte]int64{1: 10, 2: 20, 3: 30})
+	root, err := ref.Tree().Root()
+	qt.Assert(t, err, qt.IsNil)
+	dumpData, err := ref.Tree().Dump()
+	qt.Assert(t, err, qt.IsNil)
+	dump, err := BuildExportDump(root, dumpData, models.Census_ARBO_BLAKE2B, 160)
+	qt.Assert(t, err, qt.IsNil)
+
+	// the archive contains other files which are ignored
+	car := &bytes.Buffer{}
+	qt.Assert(t, data.WriteCAR(car, nil, []byte(`{"title":"metadata"}`), dump), qt.IsNil)
+
+	c2 := NewCensusDB(metadb.NewTest(t))
+	roots, err := c2.ImportCAR(bytes.NewReader(car.Bytes()))
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, roots, qt.DeepEquals, [][]byte{root})
+	imported, err := c2.Load(root, nil)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, censusWeights(t, imported), qt.DeepEquals, map[byte]int64{1: 10, 2: 20, 3: 30})
+	qt.Assert(t, imported.URI, qt.Equals, "ipfs://"+data.CalculateIPFSCIDv1json(dump))
+
+	// importing again is not an error
+	roots, err = c2.ImportCAR(bytes.NewReader(car.Bytes()))
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, roots, qt.HasLen, 1)
+}
@@ -7,6 +7,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"sync"
 
 	"github.com/google/uuid"
 	"go.vocdoni.io/dvote/censustree"
@@ -43,6 +45,14 @@ type CensusRef struct {
 	// MaxLevels is required to load the census with the original size because
 	// it could be different according to the election (and census) type.
 	MaxLevels int
+	// ParentRoot is the root of the census published before this one from
+	// the same census, nil if it is the first publish or not published.
+	ParentRoot []byte
+	// LastPublishedRoot is the root of the last census published from this
+	// one, used as parent of the next publish.
+	LastPublishedRoot []byte
+	// MergedFrom holds the IDs of the censuses merged to build this one.
+	MergedFrom [][]byte
 }
 
 // Tree returns the censustree.Tree object of the census reference.
@@ -70,11 +80,21 @@ type CensusDump struct {
 // authentication control over the census if a UUID token is provided.
 type CensusDB struct {
 	db db.Database
+
+	// jobsLock serializes the updates of the census jobs, and runningJobs
+	// holds the jobs being run.
+	jobsLock    sync.Mutex
+	runningJobs map[uuid.UUID]bool
 }
 
-// NewCensusDB creates a new CensusDB object.
+// NewCensusDB creates a new CensusDB object. The census import jobs interrupted
+// by a restart of the node are marked as failed.
 func NewCensusDB(db db.Database) *CensusDB {
-	return &CensusDB{db: db}
+	c := &CensusDB{db: db, runningJobs: make(map[uuid.UUID]bool)}
+	if err := c.failInterruptedJobs(); err != nil {
+		log.Warnw("cannot fail the interrupted census jobs", "err", err)
+	}
+	return c
 }
 
 // New creates a new census and adds it to the database.
@@ -204,27 +224,57 @@ func (c *CensusDB) ImportAsPublic(data []byte) error {
 	return nil
 }
 
+// ImportCAR imports the census dumps of a CAR v1 archive and makes them
+// public. The files are verified against their CIDs while reading the
+// archive, so it can be imported without network access. The files that are
+// not census dumps are ignored. It returns the roots of the census dumps
+// found, including the ones that already existed.
+func (c *CensusDB) ImportCAR(r io.Reader) ([][]byte, error) {
+	_, blocks, err := storagelayer.ReadCAR(r)
+	if err != nil {
+		return nil, err
+	}
+	roots := [][]byte{}
+	for _, block := range blocks {
+		cdata := CensusDump{}
+		if err := json.Unmarshal(block.Data, &cdata); err != nil ||
+			cdata.Data == nil || cdata.RootHash == nil {
+			continue
+		}
+		if err := c.ImportAsPublic(block.Data); err != nil && !errors.Is(err, ErrCensusAlreadyExists) {
+			return nil, fmt.Errorf("cannot import census %s: %w", block.CID, err)
+		}
+		roots = append(roots, cdata.RootHash)
+	}
+	return roots, nil
+}
+
 // addCensusRefToDB adds a censusRef to the database.
 func (c *CensusDB) addCensusRefToDB(censusID []byte, authToken *uuid.UUID,
 	t models.Census_Type, uri string, maxLevels int) (*CensusRef, error) {
-	wtx := c.db.WriteTx()
-	defer wtx.Discard()
-	refData := bytes.Buffer{}
-	enc := gob.NewEncoder(&refData)
 	ref := &CensusRef{
 		AuthToken:  authToken,
 		CensusType: int32(t),
 		URI:        uri,
 		MaxLevels:  maxLevels,
 	}
+	return ref, c.setCensusRef(censusID, ref)
+}
+
+// setCensusRef stores the censusRef in the database.
+func (c *CensusDB) setCensusRef(censusID []byte, ref *CensusRef) error {
+	wtx := c.db.WriteTx()
+	defer wtx.Discard()
+	refData := bytes.Buffer{}
+	enc := gob.NewEncoder(&refData)
 	if err := enc.Encode(ref); err != nil {
-		return nil, err
+		return err
 	}
 	if err := wtx.Set(append([]byte(censusDBreferencePrefix), censusID...),
 		refData.Bytes()); err != nil {
-		return nil, err
+		return err
 	}
-	return ref, wtx.Commit()
+	return wtx.Commit()
 }
 
 // getCensusRefFromDB returns the censusRef from the database.
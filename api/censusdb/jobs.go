@@ -0,0 +1,379 @@
+package censusdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"go.vocdoni.io/dvote/data/compressor"
+	"go.vocdoni.io/dvote/db"
+	"go.vocdoni.io/dvote/log"
+)
+
+const (
+	censusDBjobPrefix      = "cj_"
+	censusDBjobChunkPrefix = "cc_"
+)
+
+// The types of census jobs. A participants job adds the participants uploaded in
+// each chunk to the census, while an import job imports a census dump compressed
+// with zstd, uploaded in chunks, into an empty census.
+const (
+	JobTypeParticipants = "participants"
+	JobTypeImport       = "import"
+)
+
+// The statuses of a census job. A job accepts chunks while uploading, and once
+// started it runs asynchronously until it is done or fails.
+const (
+	JobStatusUploading = "uploading"
+	JobStatusRunning   = "running"
+	JobStatusDone      = "done"
+	JobStatusFailed    = "failed"
+)
+
+var (
+	// ErrJobNotFound is returned when a census job is not found in the database.
+	ErrJobNotFound = fmt.Errorf("census job not found")
+	// ErrJobTypeUnknown is returned by NewJob if the job type is unknown.
+	ErrJobTypeUnknown = fmt.Errorf("unknown census job type")
+	// ErrJobNotUploading is returned when uploading chunks to a job which was already started.
+	ErrJobNotUploading = fmt.Errorf("census job is not accepting chunks")
+	// ErrJobChunkOutOfOrder is returned when a chunk is uploaded before the previous ones.
+	ErrJobChunkOutOfOrder = fmt.Errorf("census job chunk out of order")
+	// ErrJobAlreadyStarted is returned by StartJob if the job is running or has finished.
+	ErrJobAlreadyStarted = fmt.Errorf("census job already started")
+	// ErrJobInterrupted is the error of the import jobs interrupted by a restart of the node.
+	ErrJobInterrupted = fmt.Errorf("census import job interrupted, the census must be imported again into an empty census")
+)
+
+// Job is a census job, which is uploaded in chunks and then run asynchronously.
+// The uploads are resumable: chunks must be uploaded in order, but uploading an
+// already received chunk is a no-op, so the upload can continue at Chunks.
+type Job struct {
+	ID       uuid.UUID
+	CensusID []byte
+	Type     string
+	Status   string
+	// Chunks is the number of chunks uploaded, and Total the number of items
+	// uploaded in them: participants, or bytes of the dump for import jobs.
+	Chunks uint32
+	Total  uint64
+	// ProcessedChunks and Processed are the chunks and items already processed,
+	// and Invalid the number of participants which could not be added.
+	ProcessedChunks uint32
+	Processed       uint64
+	Invalid         uint64
+	// RootHash is the expected root of the census once an import job is done.
+	RootHash []byte
+	Error    string
+	Updated  time.Time
+}
+
+// ParticipantsChunk is a chunk of a participants job, with the keys and values
+// to add to the census tree.
+type ParticipantsChunk struct {
+	Keys   [][]byte
+	Values [][]byte
+}
+
+// EncodeParticipantsChunk serializes the participants to be uploaded as a job chunk.
+func EncodeParticipantsChunk(keys, values [][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&ParticipantsChunk{Keys: keys, Values: values}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// NewJob creates a new job of the given type for the census.
+func (c *CensusDB) NewJob(censusID []byte, jobType string) (*Job, error) {
+	if jobType != JobTypeParticipants && jobType != JobTypeImport {
+		return nil, fmt.Errorf("%w: %s", ErrJobTypeUnknown, jobType)
+	}
+	job := &Job{
+		ID:       uuid.New(),
+		CensusID: censusID,
+		Type:     jobType,
+		Status:   JobStatusUploading,
+	}
+	wtx := c.db.WriteTx()
+	defer wtx.Discard()
+	if err := setJob(wtx, job); err != nil {
+		return nil, err
+	}
+	return job, wtx.Commit()
+}
+
+// Job returns the census job from the database.
+func (c *CensusDB) Job(jobID uuid.UUID) (*Job, error) {
+	rtx := c.db.ReadTx()
+	defer rtx.Discard()
+	return getJob(rtx, jobID)
+}
+
+// AddJobChunk stores the chunk with the given index of the job, which holds the
+// given number of items. Chunks must be added in order, starting at zero; adding
+// a chunk which was already added is a no-op, so uploads can be resumed.
+func (c *CensusDB) AddJobChunk(jobID uuid.UUID, index uint32, chunk []byte, items uint64) (*Job, error) {
+	c.jobsLock.Lock()
+	defer c.jobsLock.Unlock()
+	wtx := c.db.WriteTx()
+	defer wtx.Discard()
+	job, err := getJob(wtx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != JobStatusUploading {
+		return nil, ErrJobNotUploading
+	}
+	if index < job.Chunks {
+		return job, nil
+	}
+	if index > job.Chunks {
+		return nil, fmt.Errorf("%w: expected chunk %d, got %d", ErrJobChunkOutOfOrder, job.Chunks, index)
+	}
+	if err := wtx.Set(jobChunkKey(jobID, index), chunk); err != nil {
+		return nil, err
+	}
+	job.Chunks++
+	job.Total += items
+	if err := setJob(wtx, job); err != nil {
+		return nil, err
+	}
+	return job, wtx.Commit()
+}
+
+// StartJob starts running the job asynchronously over the census of ref, once all
+// its chunks have been uploaded. For import jobs, rootHash is the expected root of
+// the census once imported; the job fails if it does not match. A participants job
+// interrupted by a restart of the node can be started again, and continues at the
+// first chunk not processed; the participants of the chunk being processed are
+// added again, and counted as invalid if they were already added. Import jobs
+// can't be resumed, so the interrupted ones are marked as failed, see
+// failInterruptedJobs.
+func (c *CensusDB) StartJob(ref *CensusRef, jobID uuid.UUID, rootHash []byte) (*Job, error) {
+	c.jobsLock.Lock()
+	defer c.jobsLock.Unlock()
+	wtx := c.db.WriteTx()
+	defer wtx.Discard()
+	job, err := getJob(wtx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != JobStatusUploading && (job.Status != JobStatusRunning || c.runningJobs[jobID]) {
+		return nil, ErrJobAlreadyStarted
+	}
+	job.Status = JobStatusRunning
+	if rootHash != nil {
+		job.RootHash = rootHash
+	}
+	if err := setJob(wtx, job); err != nil {
+		return nil, err
+	}
+	if err := wtx.Commit(); err != nil {
+		return nil, err
+	}
+	c.runningJobs[jobID] = true
+	go c.runJob(ref, job)
+	return job, nil
+}
+
+// runJob runs the job, saving its progress as the chunks are processed.
+func (c *CensusDB) runJob(ref *CensusRef, job *Job) {
+	var err error
+	switch job.Type {
+	case JobTypeParticipants:
+		err = c.runParticipantsJob(ref, job)
+	case JobTypeImport:
+		err = c.runImportJob(ref, job)
+	}
+	job.Status = JobStatusDone
+	if err != nil {
+		log.Warnw("census job failed", "job", job.ID.String(), "census", fmt.Sprintf("%x", job.CensusID), "err", err)
+		job.Status = JobStatusFailed
+		job.Error = err.Error()
+	}
+	c.jobsLock.Lock()
+	defer c.jobsLock.Unlock()
+	delete(c.runningJobs, job.ID)
+	wtx := c.db.WriteTx()
+	defer wtx.Discard()
+	// the chunks are no longer needed, even if the job failed
+	deleteJobChunks(wtx, job)
+	if err := setJob(wtx, job); err != nil {
+		log.Warnw("cannot save census job", "job", job.ID.String(), "err", err)
+		return
+	}
+	if err := wtx.Commit(); err != nil {
+		log.Warnw("cannot save census job", "job", job.ID.String(), "err", err)
+	}
+}
+
+// failInterruptedJobs marks as failed the import jobs which were running when
+// the node stopped, since a partial import can't be resumed: the dump must be
+// imported into an empty census. Their chunks are deleted.
+func (c *CensusDB) failInterruptedJobs() error {
+	var interrupted []*Job
+	if err := c.db.Iterate([]byte(censusDBjobPrefix), func(_, value []byte) bool {
+		job := &Job{}
+		if err := gob.NewDecoder(bytes.NewReader(value)).Decode(job); err != nil {
+			log.Warnw("cannot decode census job", "err", err)
+			return true
+		}
+		if job.Type == JobTypeImport && job.Status == JobStatusRunning {
+			interrupted = append(interrupted, job)
+		}
+		return true
+	}); err != nil {
+		return err
+	}
+	if len(interrupted) == 0 {
+		return nil
+	}
+	wtx := c.db.WriteTx()
+	defer wtx.Discard()
+	for _, job := range interrupted {
+		log.Warnw("census import job interrupted", "job", job.ID.String(), "census", fmt.Sprintf("%x", job.CensusID))
+		job.Status = JobStatusFailed
+		job.Error = ErrJobInterrupted.Error()
+		deleteJobChunks(wtx, job)
+		if err := setJob(wtx, job); err != nil {
+			return err
+		}
+	}
+	return wtx.Commit()
+}
+
+// saveJobProgress stores the job, to report its progress.
+func (c *CensusDB) saveJobProgress(job *Job) error {
+	wtx := c.db.WriteTx()
+	defer wtx.Discard()
+	if err := setJob(wtx, job); err != nil {
+		return err
+	}
+	return wtx.Commit()
+}
+
+// runParticipantsJob adds the participants of each chunk not yet processed.
+func (c *CensusDB) runParticipantsJob(ref *CensusRef, job *Job) error {
+	for ; job.ProcessedChunks < job.Chunks; job.ProcessedChunks++ {
+		data, err := c.jobChunk(job.ID, job.ProcessedChunks)
+		if err != nil {
+			return fmt.Errorf("cannot get chunk %d: %w", job.ProcessedChunks, err)
+		}
+		chunk := ParticipantsChunk{}
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&chunk); err != nil {
+			return fmt.Errorf("cannot decode chunk %d: %w", job.ProcessedChunks, err)
+		}
+		invalid, err := ref.Tree().AddBatch(chunk.Keys, chunk.Values)
+		if err != nil {
+			return err
+		}
+		job.Processed += uint64(len(chunk.Keys))
+		job.Invalid += uint64(len(invalid))
+		if err := c.saveJobProgress(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runImportJob imports the compressed census dump read from the chunks.
+func (c *CensusDB) runImportJob(ref *CensusRef, job *Job) error {
+	r, err := compressor.NewReader(&jobChunkReader{c: c, job: job})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := ref.Tree().ImportDumpReader(r); err != nil {
+		return err
+	}
+	root, err := ref.Tree().Root()
+	if err != nil {
+		return err
+	}
+	if job.RootHash != nil && !bytes.Equal(root, job.RootHash) {
+		return fmt.Errorf("root hash %x does not match the expected %x after importing dump", root, job.RootHash)
+	}
+	return nil
+}
+
+// jobChunkReader reads the chunks of a job in order, saving the job progress as
+// each chunk is read.
+type jobChunkReader struct {
+	c     *CensusDB
+	job   *Job
+	chunk []byte
+}
+
+func (r *jobChunkReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		if r.job.ProcessedChunks >= r.job.Chunks {
+			return 0, io.EOF
+		}
+		chunk, err := r.c.jobChunk(r.job.ID, r.job.ProcessedChunks)
+		if err != nil {
+			return 0, fmt.Errorf("cannot get chunk %d: %w", r.job.ProcessedChunks, err)
+		}
+		r.chunk = chunk
+		r.job.ProcessedChunks++
+		r.job.Processed += uint64(len(chunk))
+		if err := r.c.saveJobProgress(r.job); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
+
+// jobChunk returns the chunk with the given index of the job from the database.
+func (c *CensusDB) jobChunk(jobID uuid.UUID, index uint32) ([]byte, error) {
+	rtx := c.db.ReadTx()
+	defer rtx.Discard()
+	return rtx.Get(jobChunkKey(jobID, index))
+}
+
+// deleteJobChunks deletes the chunks of the job from the database.
+func deleteJobChunks(wtx db.WriteTx, job *Job) {
+	for i := uint32(0); i < job.Chunks; i++ {
+		if err := wtx.Delete(jobChunkKey(job.ID, i)); err != nil {
+			log.Warnw("cannot delete census job chunk", "job", job.ID.String(), "err", err)
+		}
+	}
+}
+
+// setJob stores the job in the database.
+func setJob(wtx db.WriteTx, job *Job) error {
+	job.Updated = time.Now()
+	data := bytes.Buffer{}
+	if err := gob.NewEncoder(&data).Encode(job); err != nil {
+		return err
+	}
+	return wtx.Set(append([]byte(censusDBjobPrefix), job.ID[:]...), data.Bytes())
+}
+
+// getJob returns the job from the database.
+func getJob(rtx db.ReadTx, jobID uuid.UUID) (*Job, error) {
+	b, err := rtx.Get(append([]byte(censusDBjobPrefix), jobID[:]...))
+	if err != nil {
+		if errors.Is(err, db.ErrKeyNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	job := &Job{}
+	return job, gob.NewDecoder(bytes.NewReader(b)).Decode(job)
+}
+
+// jobChunkKey returns the database key of the chunk of a job.
+func jobChunkKey(jobID uuid.UUID, index uint32) []byte {
+	key := append([]byte(censusDBjobChunkPrefix), jobID[:]...)
+	return binary.BigEndian.AppendUint32(key, index)
+}
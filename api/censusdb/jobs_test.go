@@ -0,0 +1,168 @@
+package censusdb
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/google/uuid"
+	"go.vocdoni.io/dvote/data/compressor"
+	"go.vocdoni.io/dvote/db/metadb"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+func waitJob(t *testing.T, c *CensusDB, jobID uuid.UUID) *Job {
+	for i := 0; i < 100; i++ {
+		job, err := c.Job(jobID)
+		qt.Assert(t, err, qt.IsNil)
+		if job.Status != JobStatusRunning {
+			return job
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("census job did not finish")
+	return nil
+}
+
+func TestJobs(t *testing.T) {
+	c := NewCensusDB(metadb.NewTest(t))
+	token := uuid.New()
+	censusID := util.RandomBytes(32)
+	ref, err := c.New(censusID, models.Census_ARBO_BLAKE2B, "", &token, 160)
+	qt.Assert(t, err, qt.IsNil)
+
+	_, err = c.NewJob(censusID, "unknown")
+	qt.Assert(t, err, qt.ErrorIs, ErrJobTypeUnknown)
+
+	// upload the participants in three chunks
+	job, err := c.NewJob(censusID, JobTypeParticipants)
+	qt.Assert(t, err, qt.IsNil)
+	var chunks [][]byte
+	for i := 0; i < 3; i++ {
+		var keys, values [][]byte
+		for j := 0; j < 10; j++ {
+			keys = append(keys, util.RandomBytes(20))
+			values = append(values, ref.Tree().BigIntToBytes(big.NewInt(2)))
+		}
+		chunk, err := EncodeParticipantsChunk(keys, values)
+		qt.Assert(t, err, qt.IsNil)
+		chunks = append(chunks, chunk)
+	}
+	_, err = c.AddJobChunk(job.ID, 1, chunks[1], 10)
+	qt.Assert(t, err, qt.ErrorIs, ErrJobChunkOutOfOrder)
+	for i, chunk := range chunks {
+		job, err = c.AddJobChunk(job.ID, uint32(i), chunk, 10)
+		qt.Assert(t, err, qt.IsNil)
+	}
+	// resending a chunk is a no-op
+	job, err = c.AddJobChunk(job.ID, 0, chunks[0], 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, job.Chunks, qt.Equals, uint32(3))
+	qt.Assert(t, job.Total, qt.Equals, uint64(30))
+
+	_, err = c.StartJob(ref, job.ID, nil)
+	qt.Assert(t, err, qt.IsNil)
+	job = waitJob(t, c, job.ID)
+	qt.Assert(t, job.Status, qt.Equals, JobStatusDone, qt.Commentf("error: %s", job.Error))
+	qt.Assert(t, job.Processed, qt.Equals, uint64(30))
+	size, err := ref.Tree().Size()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, size, qt.Equals, uint64(30))
+	_, err = c.AddJobChunk(job.ID, 3, chunks[0], 10)
+	qt.Assert(t, err, qt.ErrorIs, ErrJobNotUploading)
+	_, err = c.StartJob(ref, job.ID, nil)
+	qt.Assert(t, err, qt.ErrorIs, ErrJobAlreadyStarted)
+
+	// import the compressed dump of the census into another one, in small chunks
+	var dump bytes.Buffer
+	w, err := compressor.NewWriter(&dump)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, ref.Tree().DumpWriter(w), qt.IsNil)
+	qt.Assert(t, w.Close(), qt.IsNil)
+	root, err := ref.Tree().Root()
+	qt.Assert(t, err, qt.IsNil)
+
+	censusID2 := util.RandomBytes(32)
+	ref2, err := c.New(censusID2, models.Census_ARBO_BLAKE2B, "", &token, 160)
+	qt.Assert(t, err, qt.IsNil)
+	job, err = c.NewJob(censusID2, JobTypeImport)
+	qt.Assert(t, err, qt.IsNil)
+	for i := uint32(0); dump.Len() > 0; i++ {
+		chunk := dump.Next(100)
+		_, err = c.AddJobChunk(job.ID, i, chunk, uint64(len(chunk)))
+		qt.Assert(t, err, qt.IsNil)
+	}
+	_, err = c.StartJob(ref2, job.ID, root)
+	qt.Assert(t, err, qt.IsNil)
+	job = waitJob(t, c, job.ID)
+	qt.Assert(t, job.Status, qt.Equals, JobStatusDone, qt.Commentf("error: %s", job.Error))
+	qt.Assert(t, job.Processed, qt.Equals, job.Total)
+	root2, err := ref2.Tree().Root()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, root2, qt.DeepEquals, root)
+	weight, err := ref2.Tree().GetCensusWeight()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, weight.Int64(), qt.Equals, int64(60))
+}
+
+func TestJobsInterrupted(t *testing.T) {
+	database := metadb.NewTest(t)
+	c := NewCensusDB(database)
+	token := uuid.New()
+	censusID := util.RandomBytes(32)
+	ref, err := c.New(censusID, models.Census_ARBO_BLAKE2B, "", &token, 160)
+	qt.Assert(t, err, qt.IsNil)
+
+	// a participants job interrupted after processing its first chunk
+	participants, err := c.NewJob(censusID, JobTypeParticipants)
+	qt.Assert(t, err, qt.IsNil)
+	for i := uint32(0); i < 2; i++ {
+		keys := [][]byte{util.RandomBytes(20)}
+		values := [][]byte{ref.Tree().BigIntToBytes(big.NewInt(1))}
+		chunk, err := EncodeParticipantsChunk(keys, values)
+		qt.Assert(t, err, qt.IsNil)
+		if i == 0 {
+			_, err = ref.Tree().AddBatch(keys, values)
+			qt.Assert(t, err, qt.IsNil)
+		}
+		participants, err = c.AddJobChunk(participants.ID, i, chunk, 1)
+		qt.Assert(t, err, qt.IsNil)
+	}
+	participants.Status = JobStatusRunning
+	participants.ProcessedChunks, participants.Processed = 1, 1
+
+	// an import job interrupted while importing
+	imp, err := c.NewJob(util.RandomBytes(32), JobTypeImport)
+	qt.Assert(t, err, qt.IsNil)
+	imp, err = c.AddJobChunk(imp.ID, 0, []byte("dump"), 4)
+	qt.Assert(t, err, qt.IsNil)
+	imp.Status = JobStatusRunning
+
+	wtx := database.WriteTx()
+	qt.Assert(t, setJob(wtx, participants), qt.IsNil)
+	qt.Assert(t, setJob(wtx, imp), qt.IsNil)
+	qt.Assert(t, wtx.Commit(), qt.IsNil)
+
+	// restart
+	c = NewCensusDB(database)
+	imp, err = c.Job(imp.ID)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, imp.Status, qt.Equals, JobStatusFailed)
+	qt.Assert(t, imp.Error, qt.Equals, ErrJobInterrupted.Error())
+	_, err = c.jobChunk(imp.ID, 0)
+	qt.Assert(t, err, qt.Not(qt.IsNil))
+
+	// the participants job continues at its second chunk
+	_, err = c.StartJob(ref, participants.ID, nil)
+	qt.Assert(t, err, qt.IsNil)
+	participants = waitJob(t, c, participants.ID)
+	qt.Assert(t, participants.Status, qt.Equals, JobStatusDone, qt.Commentf("error: %s", participants.Error))
+	qt.Assert(t, participants.Processed, qt.Equals, uint64(2))
+	qt.Assert(t, participants.Invalid, qt.Equals, uint64(0))
+	size, err := ref.Tree().Size()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, size, qt.Equals, uint64(2))
+}
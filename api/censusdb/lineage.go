@@ -0,0 +1,360 @@
+package censusdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"go.vocdoni.io/dvote/censustree"
+	"go.vocdoni.io/dvote/tree/arbo"
+)
+
+// The operations to merge censuses. The union contains the keys found in any of
+// the censuses, while the intersection contains the keys found in all of them.
+const (
+	MergeUnion        = "union"
+	MergeIntersection = "intersection"
+)
+
+// The policies to resolve the weight of a key found in more than one of the
+// merged censuses. The first policy keeps the weight of the first census in
+// the list of merged censuses, and the fail policy aborts the merge if the
+// weights are different.
+const (
+	WeightPolicySum   = "sum"
+	WeightPolicyMax   = "max"
+	WeightPolicyMin   = "min"
+	WeightPolicyFirst = "first"
+	WeightPolicyFail  = "fail"
+)
+
+// mergeBatchSize is the number of keys added at once to the merged census.
+const mergeBatchSize = 1024
+
+var (
+	// ErrMergeOperationUnknown is returned by Merge if the operation is unknown.
+	ErrMergeOperationUnknown = fmt.Errorf("unknown census merge operation")
+	// ErrWeightPolicyUnknown is returned by Merge if the weight policy is unknown.
+	ErrWeightPolicyUnknown = fmt.Errorf("unknown census weight policy")
+	// ErrWeightConflict is returned by Merge, using the fail policy, if a key
+	// has different weights in the merged censuses.
+	ErrWeightConflict = fmt.Errorf("census key with conflicting weights")
+)
+
+// CensusVersion is a published census in the lineage of a census.
+type CensusVersion struct {
+	Root       []byte
+	URI        string
+	ParentRoot []byte
+}
+
+// DiffEntry is a key that differs between two censuses. Value is the value
+// of the key in the newer census, and OldValue the value in the older one.
+type DiffEntry struct {
+	Key      []byte
+	Value    []byte
+	OldValue []byte
+}
+
+// Diff holds the keys added, removed and reweighted between two censuses.
+type Diff struct {
+	Added      []DiffEntry
+	Removed    []DiffEntry
+	Reweighted []DiffEntry
+}
+
+// RecordPublish records that the census identified by root has been published
+// from the census identified by censusID. The root last published from censusID
+// is recorded as parent of root, which becomes the last published root. Returns
+// the parent root, nil if this is the first publish.
+func (c *CensusDB) RecordPublish(censusID, root []byte) ([]byte, error) {
+	ref, err := c.getCensusRefFromDB(censusID)
+	if err != nil {
+		return nil, err
+	}
+	published, err := c.getCensusRefFromDB(root)
+	if err != nil {
+		return nil, err
+	}
+	published.ParentRoot = ref.LastPublishedRoot
+	if err := c.setCensusRef(root, published); err != nil {
+		return nil, err
+	}
+	ref.LastPublishedRoot = root
+	if err := c.setCensusRef(censusID, ref); err != nil {
+		return nil, err
+	}
+	return published.ParentRoot, nil
+}
+
+// RecordMerge records the IDs of the censuses merged to build the census
+// identified by censusID.
+func (c *CensusDB) RecordMerge(censusID []byte, sources [][]byte) error {
+	ref, err := c.getCensusRefFromDB(censusID)
+	if err != nil {
+		return err
+	}
+	ref.MergedFrom = sources
+	return c.setCensusRef(censusID, ref)
+}
+
+// History returns the lineage of the census, from the newest published version
+// to the first one. If the census is not published, the history starts at the
+// last root published from it.
+func (c *CensusDB) History(censusID []byte) ([]*CensusVersion, error) {
+	ref, err := c.getCensusRefFromDB(censusID)
+	if err != nil {
+		return nil, err
+	}
+	root := censusID
+	if ref.URI == "" {
+		root = ref.LastPublishedRoot
+	}
+	versions := []*CensusVersion{}
+	visited := make(map[string]bool)
+	for root != nil && !visited[string(root)] {
+		visited[string(root)] = true
+		ref, err := c.getCensusRefFromDB(root)
+		if err != nil {
+			if errors.Is(err, ErrCensusNotFound) {
+				// the parent census might have been deleted
+				break
+			}
+			return nil, err
+		}
+		versions = append(versions, &CensusVersion{
+			Root:       root,
+			URI:        ref.URI,
+			ParentRoot: ref.ParentRoot,
+		})
+		root = ref.ParentRoot
+	}
+	return versions, nil
+}
+
+// DiffTrees returns the keys added, removed and reweighted in the census tree
+// to compared with the census tree from.
+func DiffTrees(from, to *censustree.Tree) (*Diff, error) {
+	diff := &Diff{}
+	var cbErr error
+	// the keys of to that are not in from are added, and the ones with a
+	// different value are reweighted
+	if err := to.IterateLeaves(func(key, value []byte) bool {
+		if cbErr != nil {
+			// the iteration does not stop at leaves, skip the remaining ones
+			return true
+		}
+		oldValue, err := from.Get(key)
+		if errors.Is(err, arbo.ErrKeyNotFound) {
+			diff.Added = append(diff.Added, DiffEntry{
+				Key:   bytes.Clone(key),
+				Value: bytes.Clone(value),
+			})
+			return false
+		}
+		if err != nil {
+			cbErr = err
+			return true
+		}
+		if arbo.BytesToBigInt(value).Cmp(arbo.BytesToBigInt(oldValue)) != 0 {
+			diff.Reweighted = append(diff.Reweighted, DiffEntry{
+				Key:      bytes.Clone(key),
+				Value:    bytes.Clone(value),
+				OldValue: oldValue,
+			})
+		}
+		return false
+	}); err != nil {
+		return nil, err
+	}
+	if cbErr != nil {
+		return nil, cbErr
+	}
+	// the keys of from that are not in to are removed
+	if err := from.IterateLeaves(func(key, value []byte) bool {
+		if cbErr != nil {
+			// the iteration does not stop at leaves, skip the remaining ones
+			return true
+		}
+		_, err := to.Get(key)
+		if errors.Is(err, arbo.ErrKeyNotFound) {
+			diff.Removed = append(diff.Removed, DiffEntry{
+				Key:      bytes.Clone(key),
+				OldValue: bytes.Clone(value),
+			})
+			return false
+		}
+		if err != nil {
+			cbErr = err
+			return true
+		}
+		return false
+	}); err != nil {
+		return nil, err
+	}
+	return diff, cbErr
+}
+
+// Merge adds to the census tree dst the union or the intersection of the keys
+// of the source census trees, resolving the weight of the keys found in more
+// than one census with the given policy. The census tree dst is expected to be
+// empty, and all the census trees to be of the same type.
+func Merge(dst *censustree.Tree, sources []*censustree.Tree, operation, policy string) error {
+	switch policy {
+	case WeightPolicySum, WeightPolicyMax, WeightPolicyMin, WeightPolicyFirst, WeightPolicyFail:
+	default:
+		return ErrWeightPolicyUnknown
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+	switch operation {
+	case MergeUnion:
+		for _, src := range sources {
+			if err := mergeUnion(dst, src, policy); err != nil {
+				return err
+			}
+		}
+		return nil
+	case MergeIntersection:
+		return mergeIntersection(dst, sources, policy)
+	default:
+		return ErrMergeOperationUnknown
+	}
+}
+
+// mergeUnion adds the keys of src to dst. The keys already in dst are updated
+// with the weight resolved by the policy.
+func mergeUnion(dst, src *censustree.Tree, policy string) error {
+	batch := &mergeBatch{dst: dst}
+	var cbErr error
+	if err := src.IterateLeaves(func(key, value []byte) bool {
+		if cbErr != nil {
+			// the iteration does not stop at leaves, skip the remaining ones
+			return true
+		}
+		oldValue, err := dst.Get(key)
+		if errors.Is(err, arbo.ErrKeyNotFound) {
+			// the keys of a tree are unique, so the keys pending to be
+			// added are never found in dst
+			cbErr = batch.add(key, arbo.BytesToBigInt(value))
+			return cbErr != nil
+		}
+		if err != nil {
+			cbErr = err
+			return true
+		}
+		oldWeight := arbo.BytesToBigInt(oldValue)
+		weight, err := mergeWeights(policy, key, oldWeight, arbo.BytesToBigInt(value))
+		if err != nil {
+			cbErr = err
+			return true
+		}
+		if weight.Cmp(oldWeight) != 0 {
+			cbErr = dst.Update(key, dst.BigIntToBytes(weight))
+		}
+		return cbErr != nil
+	}); err != nil {
+		return err
+	}
+	if cbErr != nil {
+		return cbErr
+	}
+	return batch.flush()
+}
+
+// mergeIntersection adds to dst the keys of the first source census found in
+// all the other ones, with the weight resolved by the policy.
+func mergeIntersection(dst *censustree.Tree, sources []*censustree.Tree, policy string) error {
+	batch := &mergeBatch{dst: dst}
+	var cbErr error
+	if err := sources[0].IterateLeaves(func(key, value []byte) bool {
+		if cbErr != nil {
+			// the iteration does not stop at leaves, skip the remaining ones
+			return true
+		}
+		weight := arbo.BytesToBigInt(value)
+		for _, src := range sources[1:] {
+			srcValue, err := src.Get(key)
+			if errors.Is(err, arbo.ErrKeyNotFound) {
+				return false
+			}
+			if err != nil {
+				cbErr = err
+				return true
+			}
+			if weight, err = mergeWeights(policy, key, weight, arbo.BytesToBigInt(srcValue)); err != nil {
+				cbErr = err
+				return true
+			}
+		}
+		cbErr = batch.add(key, weight)
+		return cbErr != nil
+	}); err != nil {
+		return err
+	}
+	if cbErr != nil {
+		return cbErr
+	}
+	return batch.flush()
+}
+
+// mergeWeights returns the weight of a key found in two censuses with weights
+// a and b, being a the weight of the census first in the merge list.
+func mergeWeights(policy string, key []byte, a, b *big.Int) (*big.Int, error) {
+	switch policy {
+	case WeightPolicySum:
+		return new(big.Int).Add(a, b), nil
+	case WeightPolicyMax:
+		if b.Cmp(a) > 0 {
+			return b, nil
+		}
+		return a, nil
+	case WeightPolicyMin:
+		if b.Cmp(a) < 0 {
+			return b, nil
+		}
+		return a, nil
+	case WeightPolicyFirst:
+		return a, nil
+	case WeightPolicyFail:
+		if a.Cmp(b) != 0 {
+			return nil, fmt.Errorf("%w: key %x has weights %s and %s", ErrWeightConflict, key, a, b)
+		}
+		return a, nil
+	}
+	return nil, ErrWeightPolicyUnknown
+}
+
+// mergeBatch accumulates the keys to add to the merged census, adding them in
+// batches of mergeBatchSize.
+type mergeBatch struct {
+	dst    *censustree.Tree
+	keys   [][]byte
+	values [][]byte
+}
+
+func (b *mergeBatch) add(key []byte, weight *big.Int) error {
+	b.keys = append(b.keys, bytes.Clone(key))
+	b.values = append(b.values, b.dst.BigIntToBytes(weight))
+	if len(b.keys) < mergeBatchSize {
+		return nil
+	}
+	return b.flush()
+}
+
+func (b *mergeBatch) flush() error {
+	if len(b.keys) == 0 {
+		return nil
+	}
+	invalid, err := b.dst.AddBatch(b.keys, b.values)
+	if err != nil {
+		return err
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("could not add %d keys to the merged census", len(invalid))
+	}
+	b.keys, b.values = nil, nil
+	return nil
+}
@@ -0,0 +1,146 @@
+package censusdb
+
+import (
+	"math/big"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/google/uuid"
+	"go.vocdoni.io/dvote/censustree"
+	"go.vocdoni.io/dvote/db/metadb"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+// newTestCensus creates a census with the given keys and weights.
+func newTestCensus(t *testing.T, c *CensusDB, weights map[byte]int64) *CensusRef {
+	token := uuid.New()
+	ref, err := c.New(util.RandomBytes(32), models.Census_ARBO_BLAKE2B, "", &token, 160)
+	qt.Assert(t, err, qt.IsNil)
+	for k, w := range weights {
+		qt.Assert(t, ref.Tree().Add([]byte{k}, ref.Tree().BigIntToBytes(big.NewInt(w))), qt.IsNil)
+	}
+	return ref
+}
+
+// censusWeights returns the keys and weights of the census.
+func censusWeights(t *testing.T, ref *CensusRef) map[byte]int64 {
+	weights := make(map[byte]int64)
+	err := ref.Tree().IterateLeaves(func(key, value []byte) bool {
+		weights[key[0]] = ref.Tree().BytesToBigInt(value).Int64()
+		return false
+	})
+	qt.Assert(t, err, qt.IsNil)
+	return weights
+}
+
+func TestHistory(t *testing.T) {
+	c := NewCensusDB(metadb.NewTest(t))
+	token := uuid.New()
+	censusID := util.RandomBytes(32)
+	_, err := c.New(censusID, models.Census_ARBO_BLAKE2B, "", &token, 160)
+	qt.Assert(t, err, qt.IsNil)
+
+	versions, err := c.History(censusID)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, versions, qt.HasLen, 0)
+
+	// publish three versions of the census
+	var roots [][]byte
+	for i := 0; i < 3; i++ {
+		root := util.RandomBytes(32)
+		_, err := c.New(root, models.Census_ARBO_BLAKE2B, "ipfs://test", nil, 160)
+		qt.Assert(t, err, qt.IsNil)
+		parent, err := c.RecordPublish(censusID, root)
+		qt.Assert(t, err, qt.IsNil)
+		if i == 0 {
+			qt.Assert(t, parent, qt.IsNil)
+		} else {
+			qt.Assert(t, parent, qt.DeepEquals, roots[i-1])
+		}
+		roots = append(roots, root)
+	}
+
+	versions, err = c.History(censusID)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, versions, qt.HasLen, 3)
+	for i, v := range versions {
+		qt.Assert(t, v.Root, qt.DeepEquals, roots[2-i])
+		qt.Assert(t, v.URI, qt.Equals, "ipfs://test")
+	}
+
+	// the history of a published census starts at it
+	versions, err = c.History(roots[1])
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, versions, qt.HasLen, 2)
+	qt.Assert(t, versions[0].ParentRoot, qt.DeepEquals, roots[0])
+}
+
+func TestDiffTrees(t *testing.T) {
+	c := NewCensusDB(metadb.NewTest(t))
+	from := newTestCensus(t, c, map[byte]int64{1: 1, 2: 2, 3: 3})
+	to := newTestCensus(t, c, map[byte]int64{2: 2, 3: 5, 4: 4})
+
+	diff, err := DiffTrees(from.Tree(), to.Tree())
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, diff.Added, qt.HasLen, 1)
+	qt.Assert(t, diff.Added[0].Key, qt.DeepEquals, []byte{4})
+	qt.Assert(t, diff.Removed, qt.HasLen, 1)
+	qt.Assert(t, diff.Removed[0].Key, qt.DeepEquals, []byte{1})
+	qt.Assert(t, diff.Reweighted, qt.HasLen, 1)
+	qt.Assert(t, diff.Reweighted[0].Key, qt.DeepEquals, []byte{3})
+	qt.Assert(t, from.Tree().BytesToBigInt(diff.Reweighted[0].OldValue).Int64(), qt.Equals, int64(3))
+	qt.Assert(t, from.Tree().BytesToBigInt(diff.Reweighted[0].Value).Int64(), qt.Equals, int64(5))
+
+	// the diff against an older root of the same tree
+	oldRoot, err := to.Tree().Root()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, to.Tree().Delete([]byte{2}), qt.IsNil)
+	old, err := to.Tree().FromRoot(oldRoot)
+	qt.Assert(t, err, qt.IsNil)
+	diff, err = DiffTrees(old, to.Tree())
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, diff.Added, qt.HasLen, 0)
+	qt.Assert(t, diff.Reweighted, qt.HasLen, 0)
+	qt.Assert(t, diff.Removed, qt.HasLen, 1)
+	qt.Assert(t, diff.Removed[0].Key, qt.DeepEquals, []byte{2})
+}
+
+func TestMerge(t *testing.T) {
+	c := NewCensusDB(metadb.NewTest(t))
+	a := newTestCensus(t, c, map[byte]int64{1: 1, 2: 2, 3: 3})
+	b := newTestCensus(t, c, map[byte]int64{2: 5, 3: 3, 4: 4})
+
+	merge := func(operation, policy string) (map[byte]int64, error) {
+		dst := newTestCensus(t, c, nil)
+		if err := Merge(dst.Tree(), []*censustree.Tree{a.Tree(), b.Tree()}, operation, policy); err != nil {
+			return nil, err
+		}
+		return censusWeights(t, dst), nil
+	}
+
+	weights, err := merge(MergeUnion, WeightPolicySum)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, weights, qt.DeepEquals, map[byte]int64{1: 1, 2: 7, 3: 6, 4: 4})
+
+	weights, err = merge(MergeUnion, WeightPolicyMin)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, weights, qt.DeepEquals, map[byte]int64{1: 1, 2: 2, 3: 3, 4: 4})
+
+	weights, err = merge(MergeIntersection, WeightPolicyMax)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, weights, qt.DeepEquals, map[byte]int64{2: 5, 3: 3})
+
+	weights, err = merge(MergeIntersection, WeightPolicyFirst)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, weights, qt.DeepEquals, map[byte]int64{2: 2, 3: 3})
+
+	_, err = merge(MergeUnion, WeightPolicyFail)
+	qt.Assert(t, err, qt.ErrorIs, ErrWeightConflict)
+
+	_, err = merge("unknown", WeightPolicySum)
+	qt.Assert(t, err, qt.ErrorIs, ErrMergeOperationUnknown)
+
+	_, err = merge(MergeUnion, "unknown")
+	qt.Assert(t, err, qt.ErrorIs, ErrWeightPolicyUnknown)
+}
@@ -6,6 +6,9 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -29,6 +32,12 @@ const (
 	CensusTypeUnknown    = "unknown"
 
 	MaxCensusAddBatchSize = 8192
+	// MaxCensusProofsBatchSize is the maximum number of keys to generate the
+	// census proofs at once.
+	MaxCensusProofsBatchSize = 8192
+	// MaxCensusJobChunkSize is the maximum size of a chunk of a census dump
+	// uploaded to an import job.
+	MaxCensusJobChunkSize = 4 << 20
 
 	censusIDsize = 32
 )
@@ -42,6 +51,14 @@ func (a *API) enableCensusHandlers() error {
 	); err != nil {
 		return err
 	}
+	if err := a.endpoint.RegisterMethod(
+		"/censuses/merge",
+		"POST",
+		apirest.MethodAccessTypePublic,
+		a.censusMergeHandler,
+	); err != nil {
+		return err
+	}
 	if err := a.endpoint.RegisterMethod(
 		"/censuses/{censusID}/participants",
 		"POST",
@@ -50,6 +67,22 @@ func (a *API) enableCensusHandlers() error {
 	); err != nil {
 		return err
 	}
+	if err := a.endpoint.RegisterMethod(
+		"/censuses/{censusID}/participants",
+		"PUT",
+		apirest.MethodAccessTypePublic,
+		a.censusUpdateHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/censuses/{censusID}/participants",
+		"DELETE",
+		apirest.MethodAccessTypePublic,
+		a.censusDeleteParticipantsHandler,
+	); err != nil {
+		return err
+	}
 	if err := a.endpoint.RegisterMethod(
 		"/censuses/{censusID}/type",
 		"GET",
@@ -122,6 +155,22 @@ func (a *API) enableCensusHandlers() error {
 	); err != nil {
 		return err
 	}
+	if err := a.endpoint.RegisterMethod(
+		"/censuses/{censusID}/history",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.censusHistoryHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/censuses/{censusID}/diff/{root}",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.censusDiffHandler,
+	); err != nil {
+		return err
+	}
 	if err := a.endpoint.RegisterMethod(
 		"/censuses/{censusID}/proof/{key}",
 		"GET",
@@ -130,6 +179,14 @@ func (a *API) enableCensusHandlers() error {
 	); err != nil {
 		return err
 	}
+	if err := a.endpoint.RegisterMethod(
+		"/censuses/{censusID}/proofs",
+		"POST",
+		apirest.MethodAccessTypePublic,
+		a.censusProofsHandler,
+	); err != nil {
+		return err
+	}
 	if err := a.endpoint.RegisterMethod(
 		"/censuses/{censusID}/verify",
 		"POST",
@@ -138,6 +195,46 @@ func (a *API) enableCensusHandlers() error {
 	); err != nil {
 		return err
 	}
+	if err := a.endpoint.RegisterMethod(
+		"/censuses/{censusID}/export/stream",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.censusDumpStreamHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/censuses/{censusID}/jobs",
+		"POST",
+		apirest.MethodAccessTypePublic,
+		a.censusJobCreateHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/censuses/{censusID}/jobs/{jobID}",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.censusJobHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/censuses/{censusID}/jobs/{jobID}/chunks/{index}",
+		"POST",
+		apirest.MethodAccessTypePublic,
+		a.censusJobChunkHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/censuses/{censusID}/jobs/{jobID}/start",
+		"POST",
+		apirest.MethodAccessTypePublic,
+		a.censusJobStartHandler,
+	); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -214,34 +311,9 @@ func (a *API) censusAddHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext
 	var deprecationMsg []byte
 
 	// build the list of keys and values that will be added to the tree
-	keys := [][]byte{}
-	values := [][]byte{}
-	for i, p := range cdata.Participants {
-		if p.Key == nil {
-			return ErrParticipantKeyMissing.Withf("number %d", i)
-		}
-		// check the weight parameter
-		// TODO: (lucasmenendez) remove that check, now all census are weighted
-		if p.Weight == nil {
-			p.Weight = new(types.BigInt).SetUint64(1)
-		}
-
-		leafKey := p.Key
-		if len(leafKey) > censustree.DefaultMaxKeyLen {
-			return ErrInvalidCensusKeyLength.Withf("the census key cannot be longer than %d bytes", censustree.DefaultMaxKeyLen)
-		}
-
-		if ref.CensusType != int32(models.Census_ARBO_POSEIDON) {
-			// compute the hash, we use it as key for the merkle tree
-			leafKey, err = ref.Tree().Hash(p.Key)
-			if err != nil {
-				return ErrCantComputeKeyHash.WithErr(err)
-			}
-			leafKey = leafKey[:censustree.DefaultMaxKeyLen]
-		}
-
-		keys = append(keys, leafKey)
-		values = append(values, ref.Tree().BigIntToBytes(p.Weight.MathBigInt()))
+	keys, values, err := censusLeaves(ref, cdata.Participants)
+	if err != nil {
+		return err
 	}
 
 	// add the keys and values to the tree in a single transaction
@@ -257,6 +329,58 @@ func (a *API) censusAddHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext
 	return ctx.Send(deprecationMsg, apirest.HTTPstatusOK)
 }
 
+// censusUpdateHandler
+//
+//	@Summary		Update participants of census
+//	@Description	Changes the weight of one or multiple keys already in the census, either all of them or none. Fails if the census is published.
+//	@Success		200	"(empty body)"
+//	@Router			/censuses/{censusID}/participants [put]
+func (a *API) censusUpdateHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	ref, participants, err := a.loadEditableCensus(msg, ctx)
+	if err != nil {
+		return err
+	}
+	keys, values, err := censusLeaves(ref, participants)
+	if err != nil {
+		return err
+	}
+	if err := censusCheckParticipants(ref, keys); err != nil {
+		return err
+	}
+	// the keys are updated in a single transaction, so either all or none are
+	if err := ref.Tree().UpdateBatch(keys, values); err != nil {
+		return ErrCantUpdateTree.WithErr(err)
+	}
+	log.Infof("updated %d keys of census %s", len(keys), ctx.URLParam("censusID"))
+	return ctx.Send(nil, apirest.HTTPstatusOK)
+}
+
+// censusDeleteParticipantsHandler
+//
+//	@Summary		Delete participants from census
+//	@Description	Removes one or multiple keys from the census, either all of them or none, subtracting their weight. Fails if the census is published.
+//	@Success		200	"(empty body)"
+//	@Router			/censuses/{censusID}/participants [delete]
+func (a *API) censusDeleteParticipantsHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	ref, participants, err := a.loadEditableCensus(msg, ctx)
+	if err != nil {
+		return err
+	}
+	keys, _, err := censusLeaves(ref, participants)
+	if err != nil {
+		return err
+	}
+	if err := censusCheckParticipants(ref, keys); err != nil {
+		return err
+	}
+	// the keys are deleted in a single transaction, so either all or none are
+	if err := ref.Tree().DeleteBatch(keys); err != nil {
+		return ErrCantUpdateTree.WithErr(err)
+	}
+	log.Infof("deleted %d keys from census %s", len(keys), ctx.URLParam("censusID"))
+	return ctx.Send(nil, apirest.HTTPstatusOK)
+}
+
 // censusTypeHandler
 //
 //	@Summary		TODO
@@ -559,8 +683,9 @@ func (a *API) censusPublishHandler(msg *apirest.APIdata, ctx *httprouter.HTTPCon
 		}
 		var data []byte
 		if data, err = json.Marshal(&Census{
-			CensusID: root,
-			URI:      ref.URI,
+			CensusID:   root,
+			URI:        ref.URI,
+			ParentRoot: ref.ParentRoot,
 		}); err != nil {
 			return err
 		}
@@ -600,11 +725,16 @@ func (a *API) censusPublishHandler(msg *apirest.APIdata, ctx *httprouter.HTTPCon
 		return err
 	}
 	newRef.Tree().Publish()
+	parentRoot, err := a.censusdb.RecordPublish(censusID, root)
+	if err != nil {
+		return err
+	}
 
 	var data []byte
 	if data, err = json.Marshal(&Census{
-		CensusID: root,
-		URI:      uri,
+		CensusID:   root,
+		URI:        uri,
+		ParentRoot: parentRoot,
 	}); err != nil {
 		return err
 	}
@@ -612,6 +742,181 @@ func (a *API) censusPublishHandler(msg *apirest.APIdata, ctx *httprouter.HTTPCon
 	return ctx.Send(data, apirest.HTTPstatusOK)
 }
 
+// censusHistoryHandler
+//
+//	@Summary		Census history
+//	@Description	Returns the roots published from the census, from the newest to the first one, each one with its parent root
+//	@Success		200	{object}	CensusHistory
+//	@Router			/censuses/{censusID}/history [get]
+func (a *API) censusHistoryHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	censusID, err := censusIDparse(ctx.URLParam("censusID"))
+	if err != nil {
+		return err
+	}
+	versions, err := a.censusdb.History(censusID)
+	if err != nil {
+		if errors.Is(err, censusdb.ErrCensusNotFound) {
+			return ErrCensusNotFound
+		}
+		return err
+	}
+	history := CensusHistory{Versions: []*CensusVersion{}}
+	for _, v := range versions {
+		history.Versions = append(history.Versions, &CensusVersion{
+			Root:       v.Root,
+			URI:        v.URI,
+			ParentRoot: v.ParentRoot,
+		})
+	}
+	data, err := json.Marshal(history)
+	if err != nil {
+		return ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
+// censusDiffHandler
+//
+//	@Summary		Census diff
+//	@Description	Returns the keys added, removed and reweighted in the census since the given root, which can be a published census or a previous root of the census
+//	@Success		200	{object}	CensusDiff
+//	@Router			/censuses/{censusID}/diff/{root} [get]
+func (a *API) censusDiffHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	censusID, err := censusIDparse(ctx.URLParam("censusID"))
+	if err != nil {
+		return err
+	}
+	fromRoot, err := hex.DecodeString(util.TrimHex(ctx.URLParam("root")))
+	if err != nil {
+		return ErrParamRootInvalid
+	}
+	ref, err := a.censusdb.Load(censusID, nil)
+	if err != nil {
+		if errors.Is(err, censusdb.ErrCensusNotFound) {
+			return ErrCensusNotFound
+		}
+		return err
+	}
+	// the root is the ID of a published census, or a previous root of the
+	// census tree
+	var from *censustree.Tree
+	if a.censusdb.Exists(fromRoot) {
+		fromRef, err := a.censusdb.Load(fromRoot, nil)
+		if err != nil {
+			return err
+		}
+		if fromRef.CensusType != ref.CensusType {
+			return ErrCensusTypeMismatch
+		}
+		from = fromRef.Tree()
+	} else if from, err = ref.Tree().FromRoot(fromRoot); err != nil {
+		return ErrParamRootInvalid.WithErr(err)
+	}
+	toRoot, err := ref.Tree().Root()
+	if err != nil {
+		return err
+	}
+	diff, err := censusdb.DiffTrees(from, ref.Tree())
+	if err != nil {
+		return ErrCantDiffCensus.WithErr(err)
+	}
+	data, err := json.Marshal(&CensusDiff{
+		FromRoot:   fromRoot,
+		ToRoot:     toRoot,
+		Added:      newCensusDiffEntries(diff.Added),
+		Removed:    newCensusDiffEntries(diff.Removed),
+		Reweighted: newCensusDiffEntries(diff.Reweighted),
+	})
+	if err != nil {
+		return ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
+// censusMergeHandler
+//
+//	@Summary		Merge censuses
+//	@Description	Creates a new census with the union or the intersection of the given censuses, resolving the weight of the keys found in more than one census with the weight policy
+//	@Success		200	{object}	Census
+//	@Router			/censuses/merge [post]
+func (a *API) censusMergeHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	token, err := uuid.Parse(msg.AuthToken)
+	if err != nil {
+		return err
+	}
+	params := CensusMerge{}
+	if err := json.Unmarshal(msg.Data, &params); err != nil {
+		return ErrCantParseDataAsJSON.WithErr(err)
+	}
+	if len(params.CensusIDs) < 2 {
+		return ErrParamCensusIDsMissing
+	}
+	switch params.Operation {
+	case censusdb.MergeUnion, censusdb.MergeIntersection:
+	default:
+		return ErrCensusMergeOperationUnknown
+	}
+	switch params.WeightPolicy {
+	case censusdb.WeightPolicySum, censusdb.WeightPolicyMax, censusdb.WeightPolicyMin,
+		censusdb.WeightPolicyFirst, censusdb.WeightPolicyFail:
+	default:
+		return ErrCensusWeightPolicyUnknown
+	}
+
+	sources := []*censustree.Tree{}
+	sourceIDs := [][]byte{}
+	var first *censusdb.CensusRef
+	for _, id := range params.CensusIDs {
+		ref, err := a.censusdb.Load(id, nil)
+		if err != nil {
+			if errors.Is(err, censusdb.ErrCensusNotFound) {
+				return ErrCensusNotFound.Withf("%x", id)
+			}
+			return err
+		}
+		if first == nil {
+			first = ref
+		} else if ref.CensusType != first.CensusType {
+			return ErrCensusTypeMismatch
+		}
+		sources = append(sources, ref.Tree())
+		sourceIDs = append(sourceIDs, id)
+	}
+
+	censusID := util.RandomBytes(32)
+	ref, err := a.censusdb.New(censusID, models.Census_Type(first.CensusType), "", &token, first.MaxLevels)
+	if err != nil {
+		return err
+	}
+	if err := censusdb.Merge(ref.Tree(), sources, params.Operation, params.WeightPolicy); err != nil {
+		if err := a.censusdb.Del(censusID); err != nil {
+			log.Warnf("could not delete census %x: %v", censusID, err)
+		}
+		if errors.Is(err, censusdb.ErrWeightConflict) {
+			return ErrCensusWeightConflict.WithErr(err)
+		}
+		return ErrCantMergeCensus.WithErr(err)
+	}
+	if err := a.censusdb.RecordMerge(censusID, sourceIDs); err != nil {
+		return err
+	}
+	size, err := ref.Tree().Size()
+	if err != nil {
+		return err
+	}
+	log.Infow("merged censuses", "censusID", fmt.Sprintf("%x", censusID),
+		"operation", params.Operation, "sources", len(sources), "size", size)
+
+	data, err := json.Marshal(Census{
+		CensusID: censusID,
+		Size:     size,
+	})
+	if err != nil {
+		return ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
 // censusProofHandler
 //
 //	@Summary		TODO
@@ -684,6 +989,77 @@ func (a *API) censusProofHandler(msg *apirest.APIdata, ctx *httprouter.HTTPConte
 	return ctx.Send(data, apirest.HTTPstatusOK)
 }
 
+// censusProofsHandler
+//
+//	@Summary		Batch census proofs
+//	@Description	Generates the census proofs of a list of keys, all under the same census root, which is returned in the X-Census-Root header. The proofs are streamed back as JSON lines in the same order than the keys, a proof that could not be generated has the error field set.
+//	@Success		200	{object}	CensusBatchProof
+//	@Router			/censuses/{censusID}/proofs [post]
+func (a *API) censusProofsHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	censusID, err := censusIDparse(ctx.URLParam("censusID"))
+	if err != nil {
+		return err
+	}
+	req := CensusProofsRequest{}
+	if err := json.Unmarshal(msg.Data, &req); err != nil {
+		return ErrCantParseDataAsJSON.WithErr(err)
+	}
+	if len(req.Keys) == 0 {
+		return ErrParamKeysMissing
+	}
+	if len(req.Keys) > MaxCensusProofsBatchSize {
+		return ErrParamKeysTooBig.Withf("expected %d, got %d", MaxCensusProofsBatchSize, len(req.Keys))
+	}
+	ref, err := a.censusdb.Load(censusID, nil)
+	if err != nil {
+		if errors.Is(err, censusdb.ErrCensusNotFound) {
+			return ErrCensusNotFound
+		}
+		return err
+	}
+	// as in censusProofHandler, the keys are hashed except for zkweighted
+	// censuses, which include the circom siblings
+	zkCensus := ref.CensusType == int32(models.Census_ARBO_POSEIDON)
+	leafKeys := make([][]byte, len(req.Keys))
+	for i, key := range req.Keys {
+		leafKeys[i] = key
+		if !zkCensus {
+			if leafKeys[i], err = ref.Tree().Hash(key); err != nil {
+				return ErrCantComputeKeyHash.WithErr(err)
+			}
+		}
+	}
+	root, err := ref.Tree().Root()
+	if err != nil {
+		return err
+	}
+	ctx.Writer.Header().Set("X-Census-Root", hex.EncodeToString(root))
+	ctx.Writer.Header().Set("X-Census-Type", encodeCensusType(models.Census_Type(ref.CensusType)))
+	// the response can't be replaced by an error once streaming, so any errors are just logged
+	if err := ctx.Stream("application/jsonl", func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		i := 0
+		return ref.Tree().GenProofs(root, leafKeys, zkCensus, func(proof *censustree.BatchProof) error {
+			response := CensusBatchProof{Key: req.Keys[i]}
+			i++
+			if proof.Err != nil {
+				response.Error = proof.Err.Error()
+				return enc.Encode(&response)
+			}
+			response.Proof = proof.Siblings
+			response.Value = proof.Value
+			response.Siblings = proof.CircomSiblings
+			if len(proof.Value) > 0 {
+				response.Weight = (*types.BigInt)(ref.Tree().BytesToBigInt(proof.Value))
+			}
+			return enc.Encode(&response)
+		})
+	}); err != nil {
+		log.Warnw("cannot stream census proofs", "census", hex.EncodeToString(censusID), "err", err)
+	}
+	return nil
+}
+
 // censusVerifyHandler
 //
 //	@Summary		TODO
@@ -742,3 +1118,305 @@ func (a *API) censusVerifyHandler(msg *apirest.APIdata, ctx *httprouter.HTTPCont
 	}
 	return ctx.Send(data, apirest.HTTPstatusOK)
 }
+
+// censusDumpStreamHandler
+//
+//	@Summary		Export census dump as a stream
+//	@Description	Streams the census dump compressed with zstd, without building it in memory, which
+//	@Description	allows to export very large censuses. The root, type and max levels of the census
+//	@Description	are sent in the X-Census-Root, X-Census-Type and X-Census-Max-Levels headers.
+//	@Description	The dump can be imported with an import job.
+//	@Success		200	"(compressed census dump)"
+//	@Router			/censuses/{censusID}/export/stream [get]
+func (a *API) censusDumpStreamHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	token, err := uuid.Parse(msg.AuthToken)
+	if err != nil {
+		return err
+	}
+	censusID, err := censusIDparse(ctx.URLParam("censusID"))
+	if err != nil {
+		return err
+	}
+	ref, err := a.censusdb.Load(censusID, &token)
+	if err != nil {
+		if errors.Is(err, censusdb.ErrCensusNotFound) {
+			return ErrCensusNotFound
+		}
+		return err
+	}
+	root, err := ref.Tree().Root()
+	if err != nil {
+		return err
+	}
+	ctx.Writer.Header().Set("X-Census-Root", hex.EncodeToString(root))
+	ctx.Writer.Header().Set("X-Census-Type", encodeCensusType(models.Census_Type(ref.CensusType)))
+	ctx.Writer.Header().Set("X-Census-Max-Levels", strconv.Itoa(ref.MaxLevels))
+	// the response can't be replaced by an error once streaming, so any errors are just logged
+	if err := ctx.Stream("application/zstd", func(w io.Writer) error {
+		zw, err := compressor.NewWriter(w)
+		if err != nil {
+			return err
+		}
+		if err := ref.Tree().DumpWriter(zw); err != nil {
+			return err
+		}
+		return zw.Close()
+	}); err != nil {
+		log.Warnw("cannot stream census dump", "census", hex.EncodeToString(censusID), "err", err)
+	}
+	return nil
+}
+
+// loadEditableCensus loads the census of the request, checking the auth token
+// and that the census is not published, and decodes the list of participants
+// of the request body.
+func (a *API) loadEditableCensus(msg *apirest.APIdata, ctx *httprouter.HTTPContext,
+) (*censusdb.CensusRef, []CensusParticipant, error) {
+	token, err := uuid.Parse(msg.AuthToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	censusID, err := censusIDparse(ctx.URLParam("censusID"))
+	if err != nil {
+		return nil, nil, err
+	}
+	cdata := CensusParticipants{}
+	if err := json.Unmarshal(msg.Data, &cdata); err != nil {
+		return nil, nil, err
+	}
+	if len(cdata.Participants) == 0 {
+		return nil, nil, ErrParamParticipantsMissing
+	}
+	if len(cdata.Participants) > MaxCensusAddBatchSize {
+		return nil, nil, ErrParamParticipantsTooBig.Withf("expected %d, got %d",
+			MaxCensusAddBatchSize, len(cdata.Participants))
+	}
+	ref, err := a.censusdb.Load(censusID, &token)
+	if err != nil {
+		if errors.Is(err, censusdb.ErrCensusNotFound) {
+			return nil, nil, ErrCensusNotFound
+		}
+		// published censuses are stored without auth token
+		if errors.Is(err, censusdb.ErrCensusIsLocked) {
+			return nil, nil, ErrCensusPublished
+		}
+		return nil, nil, err
+	}
+	if ref.Tree().IsPublic() {
+		return nil, nil, ErrCensusPublished
+	}
+	return ref, cdata.Participants, nil
+}
+
+// loadCensusJob loads the census of the request, checking the auth token, and
+// the census job of the request, which must belong to the census.
+func (a *API) loadCensusJob(msg *apirest.APIdata, ctx *httprouter.HTTPContext,
+) (*censusdb.CensusRef, *censusdb.Job, error) {
+	token, err := uuid.Parse(msg.AuthToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	censusID, err := censusIDparse(ctx.URLParam("censusID"))
+	if err != nil {
+		return nil, nil, err
+	}
+	jobID, err := uuid.Parse(ctx.URLParam("jobID"))
+	if err != nil {
+		return nil, nil, ErrParamCensusJobIDInvalid.WithErr(err)
+	}
+	ref, err := a.censusdb.Load(censusID, &token)
+	if err != nil {
+		if errors.Is(err, censusdb.ErrCensusNotFound) {
+			return nil, nil, ErrCensusNotFound
+		}
+		return nil, nil, err
+	}
+	job, err := a.censusdb.Job(jobID)
+	if err != nil {
+		if errors.Is(err, censusdb.ErrJobNotFound) {
+			return nil, nil, ErrCensusJobNotFound
+		}
+		return nil, nil, err
+	}
+	if !bytes.Equal(job.CensusID, censusID) {
+		return nil, nil, ErrCensusJobNotFound
+	}
+	return ref, job, nil
+}
+
+// sendCensusJob sends the status of the census job.
+func sendCensusJob(ctx *httprouter.HTTPContext, job *censusdb.Job) error {
+	data, err := json.Marshal(newCensusJob(job))
+	if err != nil {
+		return ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
+// censusJobCreateHandler
+//
+//	@Summary		Create a census job
+//	@Description	Creates a job to upload a large census in chunks, which are then processed asynchronously.
+//	@Description	The type of a job is either participants, to add participants to the census, or import,
+//	@Description	to import a census dump compressed with zstd into an empty census. Upload the chunks in
+//	@Description	order, start the job and check its status until it is done.
+//	@Param			censusID	path		string		true	"Census id"
+//	@Param			job			body		CensusJob	true	"The type of the job"
+//	@Success		200			{object}	CensusJob
+//	@Router			/censuses/{censusID}/jobs [post]
+func (a *API) censusJobCreateHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	token, err := uuid.Parse(msg.AuthToken)
+	if err != nil {
+		return err
+	}
+	censusID, err := censusIDparse(ctx.URLParam("censusID"))
+	if err != nil {
+		return err
+	}
+	req := &CensusJob{}
+	if err := json.Unmarshal(msg.Data, req); err != nil {
+		return ErrCantParseDataAsJSON.WithErr(err)
+	}
+	if _, err := a.censusdb.Load(censusID, &token); err != nil {
+		if errors.Is(err, censusdb.ErrCensusNotFound) {
+			return ErrCensusNotFound
+		}
+		return err
+	}
+	job, err := a.censusdb.NewJob(censusID, req.Type)
+	if err != nil {
+		if errors.Is(err, censusdb.ErrJobTypeUnknown) {
+			return ErrCensusJobTypeUnknown.With(req.Type)
+		}
+		return err
+	}
+	return sendCensusJob(ctx, job)
+}
+
+// censusJobHandler
+//
+//	@Summary		Get a census job
+//	@Description	Returns the status and progress of a census job. If the upload of the chunks was
+//	@Description	interrupted, it can be resumed at the chunk index given by chunks.
+//	@Param			censusID	path		string	true	"Census id"
+//	@Param			jobID		path		string	true	"Job id"
+//	@Success		200			{object}	CensusJob
+//	@Router			/censuses/{censusID}/jobs/{jobID} [get]
+func (a *API) censusJobHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	_, job, err := a.loadCensusJob(msg, ctx)
+	if err != nil {
+		return err
+	}
+	return sendCensusJob(ctx, job)
+}
+
+// censusJobChunkHandler
+//
+//	@Summary		Upload a census job chunk
+//	@Description	Uploads the chunk with the given index to a census job. The chunks must be uploaded in
+//	@Description	order starting at zero, and uploading an already received chunk does nothing.
+//	@Description	The chunks of a participants job are lists of up to 8192 participants, and the chunks of
+//	@Description	an import job are parts of up to 4MiB of the compressed census dump.
+//	@Param			censusID	path		string	true	"Census id"
+//	@Param			jobID		path		string	true	"Job id"
+//	@Param			index		path		int		true	"Chunk index"
+//	@Success		200			{object}	CensusJob
+//	@Router			/censuses/{censusID}/jobs/{jobID}/chunks/{index} [post]
+func (a *API) censusJobChunkHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	ref, job, err := a.loadCensusJob(msg, ctx)
+	if err != nil {
+		return err
+	}
+	index, err := strconv.ParseUint(ctx.URLParam("index"), 10, 32)
+	if err != nil {
+		return ErrParamChunkIndexInvalid.WithErr(err)
+	}
+	var chunk []byte
+	var items uint64
+	switch job.Type {
+	case censusdb.JobTypeParticipants:
+		cdata := CensusParticipants{}
+		if err := json.Unmarshal(msg.Data, &cdata); err != nil {
+			return ErrCantParseDataAsJSON.WithErr(err)
+		}
+		if len(cdata.Participants) == 0 {
+			return ErrParamParticipantsMissing
+		}
+		if len(cdata.Participants) > MaxCensusAddBatchSize {
+			return ErrParamParticipantsTooBig.Withf("expected %d, got %d", MaxCensusAddBatchSize, len(cdata.Participants))
+		}
+		keys, values, err := censusLeaves(ref, cdata.Participants)
+		if err != nil {
+			return err
+		}
+		if chunk, err = censusdb.EncodeParticipantsChunk(keys, values); err != nil {
+			return err
+		}
+		items = uint64(len(keys))
+	case censusdb.JobTypeImport:
+		cdata := CensusJobChunk{}
+		if err := json.Unmarshal(msg.Data, &cdata); err != nil {
+			return ErrCantParseDataAsJSON.WithErr(err)
+		}
+		if len(cdata.Data) == 0 || len(cdata.Data) > MaxCensusJobChunkSize {
+			return ErrCensusJobChunkSizeInvalid.Withf("got %d bytes, maximum %d", len(cdata.Data), MaxCensusJobChunkSize)
+		}
+		chunk, items = cdata.Data, uint64(len(cdata.Data))
+	}
+	job, err = a.censusdb.AddJobChunk(job.ID, uint32(index), chunk, items)
+	if err != nil {
+		switch {
+		case errors.Is(err, censusdb.ErrJobNotUploading):
+			return ErrCensusJobAlreadyStarted
+		case errors.Is(err, censusdb.ErrJobChunkOutOfOrder):
+			return ErrCensusJobChunkOutOfOrder.WithErr(err)
+		}
+		return err
+	}
+	return sendCensusJob(ctx, job)
+}
+
+// censusJobStartHandler
+//
+//	@Summary		Start a census job
+//	@Description	Starts processing asynchronously the chunks uploaded to a census job. For import jobs, the
+//	@Description	census must be empty, and the optional rootHash is checked against the imported census.
+//	@Description	A participants job interrupted by a restart of the node can be started again, and continues
+//	@Description	at its first chunk not processed. Interrupted import jobs fail, since the census they were
+//	@Description	importing into is no longer empty.
+//	@Param			censusID	path		string		true	"Census id"
+//	@Param			jobID		path		string		true	"Job id"
+//	@Param			job			body		CensusJob	false	"The expected root hash of the census"
+//	@Success		200			{object}	CensusJob
+//	@Router			/censuses/{censusID}/jobs/{jobID}/start [post]
+func (a *API) censusJobStartHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	ref, job, err := a.loadCensusJob(msg, ctx)
+	if err != nil {
+		return err
+	}
+	req := &CensusJob{}
+	if len(msg.Data) > 0 {
+		if err := json.Unmarshal(msg.Data, req); err != nil {
+			return ErrCantParseDataAsJSON.WithErr(err)
+		}
+	}
+	if job.Type == censusdb.JobTypeImport && job.Status == censusdb.JobStatusUploading {
+		size, err := ref.Tree().Size()
+		if err != nil {
+			return err
+		}
+		if size > 0 {
+			return ErrCensusNotEmpty
+		}
+	}
+	job, err = a.censusdb.StartJob(ref, job.ID, req.RootHash)
+	if err != nil {
+		if errors.Is(err, censusdb.ErrJobAlreadyStarted) {
+			return ErrCensusJobAlreadyStarted
+		}
+		return err
+	}
+	log.Infow("started census job", "job", job.ID.String(), "type", job.Type,
+		"census", fmt.Sprintf("%x", job.CensusID), "chunks", job.Chunks)
+	return sendCensusJob(ctx, job)
+}
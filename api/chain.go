@@ -16,6 +16,7 @@ import (
 	"go.vocdoni.io/dvote/vochain/genesis"
 	"go.vocdoni.io/dvote/vochain/indexer"
 	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+	"go.vocdoni.io/dvote/vochain/state"
 )
 
 const (
@@ -31,6 +32,14 @@ func (a *API) enableChainHandlers() error {
 	); err != nil {
 		return err
 	}
+	if err := a.endpoint.RegisterMethod(
+		"/chain/organizations",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.organizationPageHandler,
+	); err != nil {
+		return err
+	}
 	if err := a.endpoint.RegisterMethod(
 		"/chain/organizations/count",
 		"GET",
@@ -111,6 +120,14 @@ func (a *API) enableChainHandlers() error {
 	); err != nil {
 		return err
 	}
+	if err := a.endpoint.RegisterMethod(
+		"/chain/transactions",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.chainTxPageHandler,
+	); err != nil {
+		return err
+	}
 	if err := a.endpoint.RegisterMethod(
 		"/chain/validators",
 		"GET",
@@ -143,6 +160,62 @@ func (a *API) enableChainHandlers() error {
 	); err != nil {
 		return err
 	}
+	if err := a.endpoint.RegisterMethod(
+		"/chain/stats/votes",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.chainVoteStatsHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/chain/stats/elections",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.chainElectionStatsHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/chain/stats/transactions",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.chainTxStatsHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/chain/stats/accounts",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.chainAccountStatsHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/chain/stats/transfers",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.chainTransferStatsHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/chain/stats/elections/{electionID}/votes",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.electionVoteStatsHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/chain/stats/elections/{electionID}/turnout",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.electionTurnoutStatsHandler,
+	); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -183,6 +256,39 @@ func (a *API) organizationListHandler(msg *apirest.APIdata, ctx *httprouter.HTTP
 	return ctx.Send(data, apirest.HTTPstatusOK)
 }
 
+// organizationPageHandler
+//
+//	@Summary		List organizations
+//	@Description	Returns a page of the organizations, in the order they created their first election.
+//	@Description	Use the cursor and limit query parameters to paginate, see Pagination.
+//	@Success		200	{object}	OrganizationPage
+//	@Router			/chain/organizations [get]
+func (a *API) organizationPageHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	cursor, limit, err := cursorParams(ctx)
+	if err != nil {
+		return err
+	}
+	orgIDs, page, err := a.indexer.EntityPage(cursor, limit)
+	if err != nil {
+		return ErrCantFetchOrganizationList.WithErr(err)
+	}
+	organizations := []*OrganizationList{}
+	for _, orgID := range orgIDs {
+		organizations = append(organizations, &OrganizationList{
+			OrganizationID: orgID,
+			ElectionCount:  a.indexer.ProcessCount(orgID),
+		})
+	}
+	data, err := json.Marshal(&OrganizationPage{
+		Organizations: organizations,
+		Pagination:    newPagination(page, limit),
+	})
+	if err != nil {
+		return ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
 // organizationCountHandler
 //
 //	@Summary		Organizations count
@@ -331,10 +437,15 @@ func (a *API) chainTxCostHandler(msg *apirest.APIdata, ctx *httprouter.HTTPConte
 	}
 	var err error
 	for k, v := range genesis.TxCostNameToTxTypeMap {
-		txCosts.Costs[k], err = a.vocapp.State.TxCost(v, true)
+		cost, err := a.vocapp.State.TxCost(v, true)
 		if err != nil {
+			// the costs of the newer transactions may not be set yet
+			if errors.Is(err, state.ErrTxCostNotFound) {
+				continue
+			}
 			return err
 		}
+		txCosts.Costs[k] = cost
 	}
 	var data []byte
 	if data, err = json.Marshal(txCosts); err != nil {
@@ -377,6 +488,32 @@ func (a *API) chainTxListPaginated(msg *apirest.APIdata, ctx *httprouter.HTTPCon
 	return ctx.Send(data, apirest.HTTPstatusOK)
 }
 
+// chainTxPageHandler
+//
+//	@Summary		List transactions
+//	@Description	Returns a page of the transactions, the newest first.
+//	@Description	Use the cursor and limit query parameters to paginate, see Pagination.
+//	@Success		200	{object}	TransactionPage
+//	@Router			/chain/transactions [get]
+func (a *API) chainTxPageHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	cursor, limit, err := cursorParams(ctx)
+	if err != nil {
+		return err
+	}
+	refs, page, err := a.indexer.TxReferencePage(cursor, limit)
+	if err != nil {
+		return ErrCantFetchTransactions.WithErr(err)
+	}
+	data, err := json.Marshal(&TransactionPage{
+		Transactions: refs,
+		Pagination:   newPagination(page, limit),
+	})
+	if err != nil {
+		return ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
 // chainTxbyHashHandler
 //
 //	@Summary		TODO
@@ -564,3 +701,144 @@ func (a *API) chainOrganizationsFilterPaginatedHandler(msg *apirest.APIdata, ctx
 	}
 	return ctx.Send(data, apirest.HTTPstatusOK)
 }
+
+// chainVoteStatsHandler
+//
+//	@Summary		Votes stats
+//	@Description	Returns the number of votes cast on all the elections, aggregated by time buckets.
+//	@Description	The bucket can be hour, day (default) or week. The time range is defined by from and to,
+//	@Description	as unix timestamps or RFC3339 dates, and defaults to the last 30 days.
+//	@Success		200	{object}	ChainStats
+//	@Router			/chain/stats/votes [get]
+func (a *API) chainVoteStatsHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	return a.sendStats(ctx, func(bucket time.Duration, stats *ChainStats) (err error) {
+		stats.Stats, err = a.indexer.VoteStats(nil, bucket, stats.From, stats.To)
+		return err
+	})
+}
+
+// chainElectionStatsHandler
+//
+//	@Summary		Elections stats
+//	@Description	Returns the number of elections created, aggregated by time buckets.
+//	@Description	See /chain/stats/votes for the bucket and time range parameters.
+//	@Success		200	{object}	ChainStats
+//	@Router			/chain/stats/elections [get]
+func (a *API) chainElectionStatsHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	return a.sendStats(ctx, func(bucket time.Duration, stats *ChainStats) (err error) {
+		stats.Stats, err = a.indexer.ElectionStats(bucket, stats.From, stats.To)
+		return err
+	})
+}
+
+// chainTxStatsHandler
+//
+//	@Summary		Transactions stats
+//	@Description	Returns the number of transactions, in total and by type, aggregated by time buckets.
+//	@Description	See /chain/stats/votes for the bucket and time range parameters.
+//	@Success		200	{object}	ChainStats
+//	@Router			/chain/stats/transactions [get]
+func (a *API) chainTxStatsHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	return a.sendStats(ctx, func(bucket time.Duration, stats *ChainStats) (err error) {
+		stats.Stats, err = a.indexer.TransactionStats(bucket, stats.From, stats.To)
+		return err
+	})
+}
+
+// chainAccountStatsHandler
+//
+//	@Summary		Active accounts stats
+//	@Description	Returns the number of accounts which signed at least one transaction, aggregated by time buckets.
+//	@Description	See /chain/stats/votes for the bucket and time range parameters.
+//	@Success		200	{object}	ChainStats
+//	@Router			/chain/stats/accounts [get]
+func (a *API) chainAccountStatsHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	return a.sendStats(ctx, func(bucket time.Duration, stats *ChainStats) (err error) {
+		stats.Stats, err = a.indexer.AccountStats(bucket, stats.From, stats.To)
+		return err
+	})
+}
+
+// chainTransferStatsHandler
+//
+//	@Summary		Token transfers stats
+//	@Description	Returns the number of token transfers and the transferred volume, aggregated by time buckets.
+//	@Description	See /chain/stats/votes for the bucket and time range parameters.
+//	@Success		200	{object}	ChainStats
+//	@Router			/chain/stats/transfers [get]
+func (a *API) chainTransferStatsHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	return a.sendStats(ctx, func(bucket time.Duration, stats *ChainStats) (err error) {
+		stats.Stats, err = a.indexer.TokenTransferStats(bucket, stats.From, stats.To)
+		return err
+	})
+}
+
+// electionVoteStatsHandler
+//
+//	@Summary		Election votes stats
+//	@Description	Returns the number of votes cast on an election, aggregated by time buckets.
+//	@Description	See /chain/stats/votes for the bucket and time range parameters.
+//	@Param			electionID	path		string	true	"Election id"
+//	@Success		200			{object}	ChainStats
+//	@Router			/chain/stats/elections/{electionID}/votes [get]
+func (a *API) electionVoteStatsHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	electionID, err := a.statsElectionID(ctx)
+	if err != nil {
+		return err
+	}
+	return a.sendStats(ctx, func(bucket time.Duration, stats *ChainStats) (err error) {
+		stats.Stats, err = a.indexer.VoteStats(electionID, bucket, stats.From, stats.To)
+		return err
+	})
+}
+
+// electionTurnoutStatsHandler
+//
+//	@Summary		Election turnout curve
+//	@Description	Returns the votes cast on an election by time buckets, with the accumulated turnout
+//	@Description	as a percentage of the census size at the end of each bucket.
+//	@Description	See /chain/stats/votes for the bucket and time range parameters.
+//	@Param			electionID	path		string	true	"Election id"
+//	@Success		200			{object}	ChainStats
+//	@Router			/chain/stats/elections/{electionID}/turnout [get]
+func (a *API) electionTurnoutStatsHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	electionID, err := a.statsElectionID(ctx)
+	if err != nil {
+		return err
+	}
+	return a.sendStats(ctx, func(bucket time.Duration, stats *ChainStats) (err error) {
+		stats.Turnout, err = a.indexer.TurnoutStats(electionID, bucket, stats.From, stats.To)
+		return err
+	})
+}
+
+// statsElectionID parses the electionID URL parameter and checks that the election exists.
+func (a *API) statsElectionID(ctx *httprouter.HTTPContext) ([]byte, error) {
+	electionID, err := hex.DecodeString(util.TrimHex(ctx.URLParam("electionID")))
+	if err != nil || electionID == nil {
+		return nil, ErrCantParseElectionID.Withf("(%s): %v", ctx.URLParam("electionID"), err)
+	}
+	if _, err := a.indexer.ProcessInfo(electionID); err != nil {
+		if errors.Is(err, indexer.ErrProcessNotFound) {
+			return nil, ErrElectionNotFound
+		}
+		return nil, ErrCantFetchElection.WithErr(err)
+	}
+	return electionID, nil
+}
+
+// sendStats parses the stats parameters, fetches the stats via fetch and sends them.
+func (a *API) sendStats(ctx *httprouter.HTTPContext, fetch func(time.Duration, *ChainStats) error) error {
+	stats, err := statsParams(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fetch(statsBuckets[stats.Bucket], stats); err != nil {
+		return ErrCantFetchStats.WithErr(err)
+	}
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
@@ -0,0 +1,208 @@
+package csp
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"go.vocdoni.io/dvote/log"
+)
+
+const (
+	// AuthHandlerSharedSecret is the name of the SharedSecretAuth handler
+	AuthHandlerSharedSecret = "sharedSecret"
+	// AuthHandlerChallenge is the name of the ChallengeAuth handler
+	AuthHandlerChallenge = "challenge"
+
+	// challengeCodeDigits is the number of digits of the challenge codes
+	challengeCodeDigits = 6
+	// challengeTimeout is the time a challenge code is valid
+	challengeTimeout = 10 * time.Minute
+	// challengeMaxAttempts is the number of wrong codes allowed per challenge
+	challengeMaxAttempts = 3
+)
+
+// ErrAuthFailed is returned by the AuthHandler when the voter data is not valid.
+var ErrAuthFailed = errors.New("authentication failed")
+
+// AuthHandler authenticates the voters before they can get a signature from
+// the CSP. The authentication can take several steps, and each voter is
+// identified by a user ID, which can sign once per election.
+type AuthHandler interface {
+	// Name returns the name of the handler
+	Name() string
+	// Steps returns the number of authentication steps
+	Steps() int
+	// Auth performs the authentication step for the election. The userID is
+	// empty on the first step, and the one returned by the previous step on
+	// the next ones. Returns the user ID and the data to send back to the
+	// voter, or ErrAuthFailed if the authentication data is not valid.
+	Auth(electionID []byte, step int, userID string, data []string) (string, []string, error)
+}
+
+// SharedSecretAuth is a one step AuthHandler that authenticates the voters
+// with a list of user IDs and their secrets. The auth data is the user ID and
+// the secret.
+type SharedSecretAuth struct {
+	secrets map[string]string
+}
+
+// NewSharedSecretAuth returns a SharedSecretAuth for the given user IDs and
+// secrets.
+func NewSharedSecretAuth(secrets map[string]string) *SharedSecretAuth {
+	return &SharedSecretAuth{secrets: secrets}
+}
+
+// Name implements the AuthHandler.Name method.
+func (*SharedSecretAuth) Name() string { return AuthHandlerSharedSecret }
+
+// Steps implements the AuthHandler.Steps method.
+func (*SharedSecretAuth) Steps() int { return 1 }
+
+// Auth implements the AuthHandler.Auth method.
+func (a *SharedSecretAuth) Auth(_ []byte, _ int, _ string, data []string) (string, []string, error) {
+	if len(data) != 2 {
+		return "", nil, fmt.Errorf("%w: user ID and secret expected", ErrAuthFailed)
+	}
+	secret, ok := a.secrets[data[0]]
+	if !ok || subtle.ConstantTimeCompare([]byte(secret), []byte(data[1])) != 1 {
+		return "", nil, ErrAuthFailed
+	}
+	return data[0], nil, nil
+}
+
+// Sender sends the challenge messages to the voters.
+type Sender interface {
+	// Send sends the message to the contact (phone, email...) of a voter
+	Send(contact, message string) error
+}
+
+// StubSender is a Sender that logs the messages instead of sending them, and
+// keeps the last one sent to each contact. Meant for testing purposes.
+type StubSender struct {
+	lock     sync.Mutex
+	messages map[string]string
+}
+
+// NewStubSender returns a new StubSender.
+func NewStubSender() *StubSender {
+	return &StubSender{messages: make(map[string]string)}
+}
+
+// Send implements the Sender.Send method.
+func (s *StubSender) Send(contact, message string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.messages[contact] = message
+	log.Infow("CSP challenge message", "contact", contact, "message", message)
+	return nil
+}
+
+// LastMessage returns the last message sent to the contact.
+func (s *StubSender) LastMessage(contact string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.messages[contact]
+}
+
+// challenge is a code sent to a voter, pending to be solved.
+type challenge struct {
+	code     string
+	expires  time.Time
+	attempts int
+}
+
+// ChallengeAuth is a two steps AuthHandler that sends a code to the contact
+// (phone number, email...) of the voter. On the first step the auth data is
+// the user ID, and on the second one the code received.
+type ChallengeAuth struct {
+	contacts map[string]string
+	sender   Sender
+
+	lock       sync.Mutex
+	challenges map[string]*challenge
+}
+
+// NewChallengeAuth returns a ChallengeAuth for the given user IDs and their
+// contacts, which sends the codes using the sender.
+func NewChallengeAuth(contacts map[string]string, sender Sender) *ChallengeAuth {
+	return &ChallengeAuth{
+		contacts:   contacts,
+		sender:     sender,
+		challenges: make(map[string]*challenge),
+	}
+}
+
+// Name implements the AuthHandler.Name method.
+func (*ChallengeAuth) Name() string { return AuthHandlerChallenge }
+
+// Steps implements the AuthHandler.Steps method.
+func (*ChallengeAuth) Steps() int { return 2 }
+
+// Auth implements the AuthHandler.Auth method.
+func (a *ChallengeAuth) Auth(electionID []byte, step int, userID string, data []string) (string, []string, error) {
+	if len(data) != 1 {
+		return "", nil, fmt.Errorf("%w: one auth data field expected", ErrAuthFailed)
+	}
+	switch step {
+	case 0:
+		userID = data[0]
+		contact, ok := a.contacts[userID]
+		if !ok {
+			return "", nil, ErrAuthFailed
+		}
+		code, err := newChallengeCode()
+		if err != nil {
+			return "", nil, err
+		}
+		a.lock.Lock()
+		a.challenges[challengeKey(electionID, userID)] = &challenge{
+			code:    code,
+			expires: time.Now().Add(challengeTimeout),
+		}
+		a.lock.Unlock()
+		if err := a.sender.Send(contact, fmt.Sprintf("Your vocdoni voting code is %s", code)); err != nil {
+			return "", nil, fmt.Errorf("cannot send challenge: %w", err)
+		}
+		return userID, nil, nil
+	case 1:
+		key := challengeKey(electionID, userID)
+		a.lock.Lock()
+		defer a.lock.Unlock()
+		c, ok := a.challenges[key]
+		if !ok || time.Now().After(c.expires) {
+			delete(a.challenges, key)
+			return "", nil, fmt.Errorf("%w: challenge not found or expired", ErrAuthFailed)
+		}
+		if subtle.ConstantTimeCompare([]byte(c.code), []byte(data[0])) != 1 {
+			c.attempts++
+			if c.attempts >= challengeMaxAttempts {
+				delete(a.challenges, key)
+			}
+			return "", nil, ErrAuthFailed
+		}
+		delete(a.challenges, key)
+		return userID, nil, nil
+	default:
+		return "", nil, fmt.Errorf("%w: invalid step %d", ErrAuthFailed, step)
+	}
+}
+
+// challengeKey returns the key of the challenge of a voter for an election.
+func challengeKey(electionID []byte, userID string) string {
+	return fmt.Sprintf("%x/%s", electionID, userID)
+}
+
+// newChallengeCode returns a random numeric code of challengeCodeDigits.
+func newChallengeCode() (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(challengeCodeDigits), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", challengeCodeDigits, n), nil
+}
@@ -0,0 +1,493 @@
+package csp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	blind "github.com/arnaucube/go-blindsecp256k1"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/crypto/saltedkey"
+	"go.vocdoni.io/dvote/db"
+	"go.vocdoni.io/dvote/db/prefixeddb"
+	"go.vocdoni.io/proto/build/go/models"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// SignatureTypeBlind is the signature type for blind signatures, the CSP
+	// does not know the voter address it signs. The proofs are of type
+	// models.ProofCA_ECDSA_BLIND_PIDSALTED.
+	SignatureTypeBlind = "blind"
+	// SignatureTypeECDSA is the signature type for plain ECDSA signatures of
+	// the CA bundle. The proofs are of type models.ProofCA_ECDSA_PIDSALTED.
+	SignatureTypeECDSA = "ecdsa"
+
+	// AuthTokenTimeout is the time an auth session is valid since its first step
+	AuthTokenTimeout = 30 * time.Minute
+	// MaxSessions is the maximum number of auth sessions stored at once. The
+	// expired sessions are pruned every AuthTokenTimeout, or when the maximum
+	// is reached; if there are still too many, new sessions are rejected.
+	MaxSessions = 10000
+)
+
+var (
+	sessionsPrefix = []byte("s/")
+	ledgerPrefix   = []byte("u/")
+
+	// ErrAuthTokenInvalid is returned when the auth token does not belong to a
+	// valid session.
+	ErrAuthTokenInvalid = errors.New("auth token invalid or expired")
+	// ErrAuthStepInvalid is returned when the auth step is not the expected one.
+	ErrAuthStepInvalid = errors.New("auth step invalid")
+	// ErrAlreadySigned is returned when the user has already got a signature for
+	// the election.
+	ErrAlreadySigned = errors.New("already signed for this user and election")
+	// ErrSignatureTypeInvalid is returned for unknown signature types.
+	ErrSignatureTypeInvalid = errors.New("signature type invalid")
+	// ErrPayloadInvalid is returned when the payload to sign is not valid.
+	ErrPayloadInvalid = errors.New("payload invalid")
+	// ErrTooManySessions is returned on the first auth step if there are
+	// MaxSessions sessions not expired.
+	ErrTooManySessions = errors.New("too many auth sessions")
+)
+
+// ProofType returns the models.ProofCA type of the proofs signed with the
+// signature type.
+func ProofType(signatureType string) (models.ProofCA_Type, error) {
+	switch signatureType {
+	case SignatureTypeBlind:
+		return models.ProofCA_ECDSA_BLIND_PIDSALTED, nil
+	case SignatureTypeECDSA:
+		return models.ProofCA_ECDSA_PIDSALTED, nil
+	default:
+		return 0, ErrSignatureTypeInvalid
+	}
+}
+
+// session is the state of the authentication of a voter for an election.
+type session struct {
+	ElectionID    []byte    `json:"electionId"`
+	SignatureType string    `json:"signatureType"`
+	UserID        string    `json:"userId"`
+	Step          int       `json:"step"`
+	Authenticated bool      `json:"authenticated"`
+	K             []byte    `json:"k,omitempty"`
+	Expires       time.Time `json:"expires"`
+}
+
+// CSP is a credential service provider, which authenticates the voters of an
+// election and signs their CA bundles, so they can vote in elections with
+// census origin OFF_CHAIN_CA. The census root of the elections is the CSP
+// public key, and the key used to sign is salted with the election ID, so the
+// signatures of an election are not valid for the others.
+type CSP struct {
+	signer *ethereum.SignKeys
+	auth   AuthHandler
+
+	sessions db.Database
+	ledger   db.Database
+	// signLock makes the ledger check and update atomic
+	signLock sync.Mutex
+
+	// sessionsLock protects the number of sessions stored and the time they
+	// were last pruned.
+	sessionsLock  sync.Mutex
+	sessionsCount int
+	maxSessions   int
+	lastPrune     time.Time
+}
+
+// NewCSP returns a new CSP that signs with the signer key, authenticating the
+// voters with the auth handler. The auth sessions and the ledger of the users
+// that already got a signature are stored in the database.
+func NewCSP(signer *ethereum.SignKeys, auth AuthHandler, database db.Database) (*CSP, error) {
+	if signer == nil || signer.Private.D == nil {
+		return nil, fmt.Errorf("CSP signer key is missing")
+	}
+	if auth == nil {
+		return nil, fmt.Errorf("CSP auth handler is missing")
+	}
+	c := &CSP{
+		signer:      signer,
+		auth:        auth,
+		sessions:    prefixeddb.NewPrefixedDatabase(database, sessionsPrefix),
+		ledger:      prefixeddb.NewPrefixedDatabase(database, ledgerPrefix),
+		maxSessions: MaxSessions,
+	}
+	// prune the sessions expired while stopped, and count the others
+	if err := c.pruneSessions(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// PublicKey returns the compressed public key of the CSP, to be used as the
+// census root of the elections.
+func (c *CSP) PublicKey() []byte {
+	return c.signer.PublicKey()
+}
+
+// Info returns the public information of the CSP.
+func (c *CSP) Info() *Info {
+	return &Info{
+		PublicKey:      c.PublicKey(),
+		AuthHandler:    c.auth.Name(),
+		AuthSteps:      c.auth.Steps(),
+		SignatureTypes: []string{SignatureTypeBlind, SignatureTypeECDSA},
+	}
+}
+
+// Auth performs an authentication step of a voter for the election. On the
+// first step the token must be nil, and a new session is created. Once the
+// last step succeeds, the session is authenticated and, for blind signatures,
+// the R point to blind the message is returned.
+func (c *CSP) Auth(electionID []byte, signatureType string, step int, token *uuid.UUID,
+	data []string) (*AuthResponse, error) {
+	if len(electionID) < saltedkey.SaltSize {
+		return nil, fmt.Errorf("election ID too short")
+	}
+	if _, err := ProofType(signatureType); err != nil {
+		return nil, err
+	}
+	var s *session
+	if step == 0 {
+		if token != nil {
+			return nil, ErrAuthStepInvalid
+		}
+		// check there is room for the session before running the auth step,
+		// which may send a challenge to the user
+		if err := c.checkSessionsRoom(); err != nil {
+			return nil, err
+		}
+		newToken := uuid.New()
+		token = &newToken
+		s = &session{
+			ElectionID:    electionID,
+			SignatureType: signatureType,
+			Expires:       time.Now().Add(AuthTokenTimeout),
+		}
+	} else {
+		if token == nil {
+			return nil, ErrAuthTokenInvalid
+		}
+		var err error
+		if s, err = c.session(*token); err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(s.ElectionID, electionID) || s.SignatureType != signatureType {
+			return nil, ErrAuthTokenInvalid
+		}
+	}
+	if s.Authenticated || s.Step != step || step >= c.auth.Steps() {
+		return nil, ErrAuthStepInvalid
+	}
+
+	userID, response, err := c.auth.Auth(electionID, step, s.UserID, data)
+	if err != nil {
+		return nil, err
+	}
+	if step == 0 {
+		signed, err := c.Signed(electionID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if signed {
+			return nil, ErrAlreadySigned
+		}
+	}
+	s.UserID = userID
+	s.Step++
+	resp := &AuthResponse{
+		AuthToken: token,
+		Response:  response,
+	}
+	if s.Step == c.auth.Steps() {
+		s.Authenticated = true
+		resp.Authenticated = true
+		if signatureType == SignatureTypeBlind {
+			k, r, err := newRequestParameters()
+			if err != nil {
+				return nil, err
+			}
+			s.K = k.Bytes()
+			resp.TokenR = r.Bytes()
+		}
+	}
+	if step == 0 {
+		err = c.addSession(*token, s)
+	} else {
+		err = c.setSession(*token, s)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Sign signs the payload for an authenticated session, and records the user
+// in the ledger, so it can't get another signature for the election. For blind
+// signatures the payload is the blinded hash of the CA bundle, while for ECDSA
+// it is the protobuf encoded CA bundle.
+func (c *CSP) Sign(electionID []byte, signatureType string, token uuid.UUID,
+	payload []byte) ([]byte, error) {
+	s, err := c.session(token)
+	if err != nil {
+		return nil, err
+	}
+	if !s.Authenticated || !bytes.Equal(s.ElectionID, electionID) ||
+		s.SignatureType != signatureType {
+		return nil, ErrAuthTokenInvalid
+	}
+
+	c.signLock.Lock()
+	defer c.signLock.Unlock()
+	signed, err := c.Signed(electionID, s.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if signed {
+		return nil, ErrAlreadySigned
+	}
+
+	var signature []byte
+	switch signatureType {
+	case SignatureTypeBlind:
+		signature, err = c.signBlind(electionID, new(big.Int).SetBytes(s.K), payload)
+	case SignatureTypeECDSA:
+		signature, err = c.signECDSA(electionID, payload)
+	default:
+		err = ErrSignatureTypeInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// record the user and remove the session, so the token and the R point
+	// can't be used again
+	wTx := c.ledger.WriteTx()
+	defer wTx.Discard()
+	t, err := time.Now().MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if err := wTx.Set(ledgerKey(electionID, s.UserID), t); err != nil {
+		return nil, err
+	}
+	if err := wTx.Commit(); err != nil {
+		return nil, err
+	}
+	if err := c.deleteSession(token); err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+// signBlind blind signs the message with the key of the election and the
+// secret k of the R point given to the voter.
+func (c *CSP) signBlind(electionID []byte, k *big.Int, payload []byte) ([]byte, error) {
+	if len(payload) == 0 || len(payload) > 32 {
+		return nil, fmt.Errorf("%w: blinded message must be 32 bytes", ErrPayloadInvalid)
+	}
+	sk := blind.PrivateKey(*c.saltedKey(electionID))
+	sBlind, err := sk.BlindSign(new(big.Int).SetBytes(payload), k)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPayloadInvalid, err)
+	}
+	return sBlind.Bytes(), nil
+}
+
+// signECDSA signs the CA bundle with the key of the election, checking it
+// belongs to the election.
+func (c *CSP) signECDSA(electionID []byte, payload []byte) ([]byte, error) {
+	bundle := &models.CAbundle{}
+	if err := proto.Unmarshal(payload, bundle); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPayloadInvalid, err)
+	}
+	if !bytes.Equal(bundle.ProcessId, electionID) {
+		return nil, fmt.Errorf("%w: bundle election ID does not match", ErrPayloadInvalid)
+	}
+	if len(bundle.Address) != ethcommon.AddressLength {
+		return nil, fmt.Errorf("%w: bundle address invalid", ErrPayloadInvalid)
+	}
+	bundleBytes, err := proto.Marshal(bundle)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ethcrypto.ToECDSA(c.saltedKey(electionID).FillBytes(make([]byte, 32)))
+	if err != nil {
+		return nil, err
+	}
+	signer := ethereum.NewSignKeys()
+	signer.Private = *key
+	signer.Public = key.PublicKey
+	return signer.SignEthereum(bundleBytes)
+}
+
+// saltedKey returns the private key of the election, which is the CSP private
+// key salted with the election ID, matching the public key salted with
+// saltedkey.
+func (c *CSP) saltedKey(electionID []byte) *big.Int {
+	salt := new(big.Int).SetBytes(electionID[:saltedkey.SaltSize])
+	sk := new(big.Int).Add(c.signer.Private.D, salt)
+	return sk.Mod(sk, blind.N)
+}
+
+// Signed returns true if the user already got a signature for the election.
+func (c *CSP) Signed(electionID []byte, userID string) (bool, error) {
+	rTx := c.ledger.ReadTx()
+	defer rTx.Discard()
+	_, err := rTx.Get(ledgerKey(electionID, userID))
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// session returns the session of the token, if it is not expired.
+func (c *CSP) session(token uuid.UUID) (*session, error) {
+	rTx := c.sessions.ReadTx()
+	defer rTx.Discard()
+	data, err := rTx.Get(token[:])
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return nil, ErrAuthTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &session{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if time.Now().After(s.Expires) {
+		return nil, ErrAuthTokenInvalid
+	}
+	return s, nil
+}
+
+// checkSessionsRoomLocked returns ErrTooManySessions if a new session can't be added,
+// pruning the expired sessions if it's time to do so or there are too many.
+// The caller must hold sessionsLock.
+func (c *CSP) checkSessionsRoomLocked() error {
+	if c.sessionsCount >= c.maxSessions || time.Since(c.lastPrune) > AuthTokenTimeout {
+		if err := c.pruneSessions(); err != nil {
+			return err
+		}
+	}
+	if c.sessionsCount >= c.maxSessions {
+		return ErrTooManySessions
+	}
+	return nil
+}
+
+// checkSessionsRoom returns ErrTooManySessions if a new session can't be added.
+func (c *CSP) checkSessionsRoom() error {
+	c.sessionsLock.Lock()
+	defer c.sessionsLock.Unlock()
+	return c.checkSessionsRoomLocked()
+}
+
+// addSession stores a new session, if there is room for it.
+func (c *CSP) addSession(token uuid.UUID, s *session) error {
+	c.sessionsLock.Lock()
+	defer c.sessionsLock.Unlock()
+	if err := c.checkSessionsRoomLocked(); err != nil {
+		return err
+	}
+	if err := c.setSession(token, s); err != nil {
+		return err
+	}
+	c.sessionsCount++
+	return nil
+}
+
+// pruneSessions deletes the expired sessions and counts the remaining ones.
+// The caller must hold sessionsLock, unless the CSP is being created.
+func (c *CSP) pruneSessions() error {
+	now := time.Now()
+	var expired [][]byte
+	count := 0
+	if err := c.sessions.Iterate(nil, func(key, value []byte) bool {
+		s := &session{}
+		if err := json.Unmarshal(value, s); err != nil || now.After(s.Expires) {
+			expired = append(expired, bytes.Clone(key))
+			return true
+		}
+		count++
+		return true
+	}); err != nil {
+		return err
+	}
+	wTx := c.sessions.WriteTx()
+	defer wTx.Discard()
+	for _, key := range expired {
+		if err := wTx.Delete(key); err != nil {
+			return err
+		}
+	}
+	if err := wTx.Commit(); err != nil {
+		return err
+	}
+	c.sessionsCount, c.lastPrune = count, now
+	return nil
+}
+
+func (c *CSP) setSession(token uuid.UUID, s *session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	wTx := c.sessions.WriteTx()
+	defer wTx.Discard()
+	if err := wTx.Set(token[:], data); err != nil {
+		return err
+	}
+	return wTx.Commit()
+}
+
+func (c *CSP) deleteSession(token uuid.UUID) error {
+	c.sessionsLock.Lock()
+	defer c.sessionsLock.Unlock()
+	wTx := c.sessions.WriteTx()
+	defer wTx.Discard()
+	if err := wTx.Delete(token[:]); err != nil {
+		return err
+	}
+	if err := wTx.Commit(); err != nil {
+		return err
+	}
+	if c.sessionsCount > 0 {
+		c.sessionsCount--
+	}
+	return nil
+}
+
+// ledgerKey returns the ledger key of the user for the election.
+func ledgerKey(electionID []byte, userID string) []byte {
+	return append(append(append([]byte{}, electionID...), '/'), userID...)
+}
+
+// newRequestParameters returns a new secret k and its R point. The k value
+// is required to be 32 bytes long by blind.BlindSign, so smaller ones are
+// discarded.
+func newRequestParameters() (*big.Int, *blind.Point, error) {
+	for {
+		k, r, err := blind.NewRequestParameters()
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(k.Bytes()) == 32 {
+			return k, r, nil
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package csp
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	blind "github.com/arnaucube/go-blindsecp256k1"
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/db/metadb"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain/state"
+	"go.vocdoni.io/dvote/vochain/transaction"
+	"go.vocdoni.io/proto/build/go/models"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestCSP(t *testing.T, auth AuthHandler) *CSP {
+	signer := ethereum.NewSignKeys()
+	qt.Assert(t, signer.Generate(), qt.IsNil)
+	c, err := NewCSP(signer, auth, metadb.NewTest(t))
+	qt.Assert(t, err, qt.IsNil)
+	return c
+}
+
+// genProof gets the CSP signature of the voter for an authenticated session,
+// as the voter would do, and returns the CSP proof.
+func genProof(t *testing.T, c *CSP, electionID []byte, signType string,
+	auth *AuthResponse, voter *ethereum.SignKeys) (*models.ProofCA, error) {
+	proofType, err := ProofType(signType)
+	qt.Assert(t, err, qt.IsNil)
+	bundle := &models.CAbundle{ProcessId: electionID, Address: voter.Address().Bytes()}
+	bundleBytes, err := proto.Marshal(bundle)
+	qt.Assert(t, err, qt.IsNil)
+
+	var signature []byte
+	if signType == SignatureTypeBlind {
+		signerR, err := blind.NewPointFromBytes(auth.TokenR)
+		qt.Assert(t, err, qt.IsNil)
+		m := new(big.Int).SetBytes(ethereum.HashRaw(bundleBytes))
+		var mBlinded *big.Int
+		var secret *blind.UserSecretData
+		for mBlinded == nil || len(mBlinded.Bytes()) != 32 {
+			mBlinded, secret, err = blind.Blind(m, signerR)
+			qt.Assert(t, err, qt.IsNil)
+		}
+		sBlind, err := c.Sign(electionID, signType, *auth.AuthToken, mBlinded.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		signature = blind.Unblind(new(big.Int).SetBytes(sBlind), secret).BytesUncompressed()
+	} else {
+		if signature, err = c.Sign(electionID, signType, *auth.AuthToken, bundleBytes); err != nil {
+			return nil, err
+		}
+	}
+	return &models.ProofCA{Type: proofType, Bundle: bundle, Signature: signature}, nil
+}
+
+// verifyProof verifies the CSP proof as the vochain does.
+func verifyProof(t *testing.T, c *CSP, electionID []byte, voter *ethereum.SignKeys,
+	proof *models.ProofCA) bool {
+	valid, _, err := transaction.VerifyProofOffChainCSP(nil,
+		&models.Proof{Payload: &models.Proof_Ca{Ca: proof}},
+		models.CensusOrigin_OFF_CHAIN_CA, c.PublicKey(), electionID,
+		state.NewVoterID(state.VoterIDTypeECDSA, voter.PublicKey()))
+	return err == nil && valid
+}
+
+func TestSharedSecretAuth(t *testing.T) {
+	c := newTestCSP(t, NewSharedSecretAuth(map[string]string{
+		"alice": "secret1",
+		"bob":   "secret2",
+	}))
+	electionID := util.RandomBytes(32)
+
+	for _, signType := range []string{SignatureTypeBlind, SignatureTypeECDSA} {
+		electionID := util.RandomBytes(32)
+		voter := ethereum.NewSignKeys()
+		qt.Assert(t, voter.Generate(), qt.IsNil)
+
+		auth, err := c.Auth(electionID, signType, 0, nil, []string{"alice", "secret1"})
+		qt.Assert(t, err, qt.IsNil)
+		qt.Assert(t, auth.Authenticated, qt.IsTrue)
+		qt.Assert(t, auth.TokenR != nil, qt.Equals, signType == SignatureTypeBlind)
+
+		proof, err := genProof(t, c, electionID, signType, auth, voter)
+		qt.Assert(t, err, qt.IsNil)
+		qt.Assert(t, verifyProof(t, c, electionID, voter, proof), qt.IsTrue)
+
+		// the proof is not valid for another election
+		otherElectionID := util.RandomBytes(32)
+		proof.Bundle.ProcessId = otherElectionID
+		qt.Assert(t, verifyProof(t, c, otherElectionID, voter, proof), qt.IsFalse)
+
+		// the token can't be reused, and the user can't sign again
+		_, err = genProof(t, c, electionID, signType, auth, voter)
+		qt.Assert(t, err, qt.ErrorIs, ErrAuthTokenInvalid)
+		_, err = c.Auth(electionID, signType, 0, nil, []string{"alice", "secret1"})
+		qt.Assert(t, err, qt.ErrorIs, ErrAlreadySigned)
+		signed, err := c.Signed(electionID, "alice")
+		qt.Assert(t, err, qt.IsNil)
+		qt.Assert(t, signed, qt.IsTrue)
+	}
+
+	_, err := c.Auth(electionID, SignatureTypeBlind, 0, nil, []string{"bob", "secret1"})
+	qt.Assert(t, err, qt.ErrorIs, ErrAuthFailed)
+	_, err = c.Auth(electionID, SignatureTypeBlind, 0, nil, []string{"carol", "secret1"})
+	qt.Assert(t, err, qt.ErrorIs, ErrAuthFailed)
+	_, err = c.Auth(electionID, "unknown", 0, nil, []string{"bob", "secret2"})
+	qt.Assert(t, err, qt.ErrorIs, ErrSignatureTypeInvalid)
+
+	// the ECDSA bundle must belong to the election
+	auth, err := c.Auth(electionID, SignatureTypeECDSA, 0, nil, []string{"bob", "secret2"})
+	qt.Assert(t, err, qt.IsNil)
+	bundle, err := proto.Marshal(&models.CAbundle{ProcessId: util.RandomBytes(32), Address: util.RandomBytes(20)})
+	qt.Assert(t, err, qt.IsNil)
+	_, err = c.Sign(electionID, SignatureTypeECDSA, *auth.AuthToken, bundle)
+	qt.Assert(t, err, qt.ErrorIs, ErrPayloadInvalid)
+}
+
+func TestChallengeAuth(t *testing.T) {
+	sender := NewStubSender()
+	c := newTestCSP(t, NewChallengeAuth(map[string]string{
+		"alice": "alice@example.com",
+	}, sender))
+	electionID := util.RandomBytes(32)
+	voter := ethereum.NewSignKeys()
+	qt.Assert(t, voter.Generate(), qt.IsNil)
+
+	auth, err := c.Auth(electionID, SignatureTypeBlind, 0, nil, []string{"alice"})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, auth.Authenticated, qt.IsFalse)
+	msg := sender.LastMessage("alice@example.com")
+	code := msg[strings.LastIndex(msg, " ")+1:]
+	qt.Assert(t, code, qt.HasLen, challengeCodeDigits)
+
+	// can't sign before the last step
+	_, err = c.Sign(electionID, SignatureTypeBlind, *auth.AuthToken, util.RandomBytes(32))
+	qt.Assert(t, err, qt.ErrorIs, ErrAuthTokenInvalid)
+	// wrong step
+	_, err = c.Auth(electionID, SignatureTypeBlind, 2, auth.AuthToken, []string{code})
+	qt.Assert(t, err, qt.ErrorIs, ErrAuthStepInvalid)
+	// wrong code
+	_, err = c.Auth(electionID, SignatureTypeBlind, 1, auth.AuthToken, []string{"wrong"})
+	qt.Assert(t, err, qt.ErrorIs, ErrAuthFailed)
+
+	auth, err = c.Auth(electionID, SignatureTypeBlind, 1, auth.AuthToken, []string{code})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, auth.Authenticated, qt.IsTrue)
+
+	proof, err := genProof(t, c, electionID, SignatureTypeBlind, auth, voter)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, verifyProof(t, c, electionID, voter, proof), qt.IsTrue)
+}
+
+func TestSessionsLimit(t *testing.T) {
+	database := metadb.NewTest(t)
+	signer := ethereum.NewSignKeys()
+	qt.Assert(t, signer.Generate(), qt.IsNil)
+	auth := NewSharedSecretAuth(map[string]string{"alice": "secret1", "bob": "secret2"})
+	c, err := NewCSP(signer, auth, database)
+	qt.Assert(t, err, qt.IsNil)
+	c.maxSessions = 1
+	electionID := util.RandomBytes(32)
+
+	resp, err := c.Auth(electionID, SignatureTypeECDSA, 0, nil, []string{"alice", "secret1"})
+	qt.Assert(t, err, qt.IsNil)
+	_, err = c.Auth(electionID, SignatureTypeECDSA, 0, nil, []string{"bob", "secret2"})
+	qt.Assert(t, err, qt.ErrorIs, ErrTooManySessions)
+
+	// once the session expires, it is pruned to make room for a new one
+	s, err := c.session(*resp.AuthToken)
+	qt.Assert(t, err, qt.IsNil)
+	s.Expires = time.Now().Add(-time.Second)
+	qt.Assert(t, c.setSession(*resp.AuthToken, s), qt.IsNil)
+	_, err = c.Auth(electionID, SignatureTypeECDSA, 0, nil, []string{"bob", "secret2"})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, c.sessionsCount, qt.Equals, 1)
+
+	// the sessions are counted when the CSP is created again
+	c, err = NewCSP(signer, auth, database)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, c.sessionsCount, qt.Equals, 1)
+}
@@ -0,0 +1,162 @@
+package csp
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/apirest"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/util"
+)
+
+const (
+	CSPHandler = "csp"
+)
+
+// AttachCSPAPI attaches the CSP API to the given http apirest router.
+// The path prefix is used to define the base path in which the endpoint methods will be registered.
+// For example, if the pathPrefix is "/csp", the resulting endpoints are /csp/info,
+// /csp/elections/{electionID}/{signType}/auth/{step} and /csp/elections/{electionID}/{signType}/sign.
+func AttachCSPAPI(c *CSP, a *apirest.API, pathPrefix string) error {
+	if err := a.RegisterMethod(
+		fmt.Sprintf("%s/info", pathPrefix),
+		"GET",
+		apirest.MethodAccessTypePublic,
+		c.infoHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.RegisterMethod(
+		fmt.Sprintf("%s/elections/{electionID}/{signType}/auth/{step}", pathPrefix),
+		"POST",
+		apirest.MethodAccessTypePublic,
+		c.authHandler,
+	); err != nil {
+		return err
+	}
+	return a.RegisterMethod(
+		fmt.Sprintf("%s/elections/{electionID}/{signType}/sign", pathPrefix),
+		"POST",
+		apirest.MethodAccessTypePublic,
+		c.signHandler,
+	)
+}
+
+// infoHandler
+//
+//	@Summary		CSP information
+//	@Description	Returns the CSP public key, which is the census root of its elections, and the authentication handler.
+//	@Tags			CSP
+//	@Produce		json
+//	@Success		200	{object}	csp.Info
+//	@Router			/csp/info [get]
+func (c *CSP) infoHandler(_ *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	data, err := json.Marshal(c.Info())
+	if err != nil {
+		return api.ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
+// authHandler
+//
+//	@Summary		CSP authentication step
+//	@Description	Performs an authentication step of a voter for an election. The auth token returned must be sent on the next steps.
+//	@Description	Once authenticated, for blind signatures the R point to blind the message is returned.
+//	@Tags			CSP
+//	@Accept			json
+//	@Produce		json
+//	@Param			electionID	path		string			true	"Election id in hex format"
+//	@Param			signType	path		string			true	"Signature type (blind or ecdsa)"
+//	@Param			step		path		number			true	"Authentication step, starting at 0"
+//	@Param			transaction	body		csp.AuthRequest	true	"Auth token and data"
+//	@Success		200			{object}	csp.AuthResponse
+//	@Router			/csp/elections/{electionID}/{signType}/auth/{step} [post]
+func (c *CSP) authHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	electionID, err := hex.DecodeString(util.TrimHex(ctx.URLParam("electionID")))
+	if err != nil {
+		return api.ErrCantParseElectionID.WithErr(err)
+	}
+	step, err := strconv.Atoi(ctx.URLParam("step"))
+	if err != nil || step < 0 {
+		return api.ErrCSPAuthStepInvalid
+	}
+	req := &AuthRequest{}
+	if err := json.Unmarshal(msg.Data, req); err != nil {
+		return api.ErrCantParseDataAsJSON.WithErr(err)
+	}
+	resp, err := c.Auth(electionID, ctx.URLParam("signType"), step, req.AuthToken, req.AuthData)
+	if err != nil {
+		return cspAPIError(err)
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return api.ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
+// signHandler
+//
+//	@Summary		CSP sign
+//	@Description	Signs the payload for an authenticated voter. For blind signatures the payload is the blinded hash of the CA bundle,
+//	@Description	and the signature must be unblinded. For ECDSA signatures the payload is the protobuf encoded CA bundle.
+//	@Description	Each voter can get only one signature per election.
+//	@Tags			CSP
+//	@Accept			json
+//	@Produce		json
+//	@Param			electionID	path		string			true	"Election id in hex format"
+//	@Param			signType	path		string			true	"Signature type (blind or ecdsa)"
+//	@Param			transaction	body		csp.SignRequest	true	"Auth token and payload to sign"
+//	@Success		200			{object}	csp.SignResponse
+//	@Router			/csp/elections/{electionID}/{signType}/sign [post]
+func (c *CSP) signHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	electionID, err := hex.DecodeString(util.TrimHex(ctx.URLParam("electionID")))
+	if err != nil {
+		return api.ErrCantParseElectionID.WithErr(err)
+	}
+	req := &SignRequest{}
+	if err := json.Unmarshal(msg.Data, req); err != nil {
+		return api.ErrCantParseDataAsJSON.WithErr(err)
+	}
+	if req.AuthToken == nil {
+		return api.ErrCSPAuthTokenInvalid
+	}
+	signature, err := c.Sign(electionID, ctx.URLParam("signType"), *req.AuthToken, req.Payload)
+	if err != nil {
+		return cspAPIError(err)
+	}
+	data, err := json.Marshal(&SignResponse{Signature: signature})
+	if err != nil {
+		return api.ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
+// cspAPIError returns the API error for the errors of the CSP methods.
+func cspAPIError(err error) error {
+	switch {
+	case errors.Is(err, ErrAuthFailed):
+		return api.ErrCSPAuthFailed.WithErr(err)
+	case errors.Is(err, ErrAuthTokenInvalid):
+		return api.ErrCSPAuthTokenInvalid
+	case errors.Is(err, ErrAuthStepInvalid):
+		return api.ErrCSPAuthStepInvalid
+	case errors.Is(err, ErrAlreadySigned):
+		return api.ErrCSPAlreadySigned
+	case errors.Is(err, ErrSignatureTypeInvalid):
+		return api.ErrCSPSignTypeInvalid
+	case errors.Is(err, ErrPayloadInvalid):
+		return api.ErrCSPPayloadInvalid.WithErr(err)
+	case errors.Is(err, ErrTooManySessions):
+		return api.ErrCSPTooManySessions
+	default:
+		log.Warnw("CSP error", "err", err)
+		return api.ErrCantSignCSP.WithErr(err)
+	}
+}
@@ -0,0 +1,57 @@
+package csp
+
+import (
+	"github.com/google/uuid"
+	"go.vocdoni.io/dvote/types"
+)
+
+// Info represents the public information of the CSP
+type Info struct {
+	// PublicKey is the compressed public key of the CSP, which is the census
+	// root of the elections using it
+	PublicKey types.HexBytes `json:"publicKey"`
+	// AuthHandler is the name of the voter authentication handler
+	AuthHandler string `json:"authHandler"`
+	// AuthSteps is the number of authentication steps
+	AuthSteps int `json:"authSteps"`
+	// SignatureTypes are the signature types supported
+	SignatureTypes []string `json:"signatureTypes"`
+}
+
+// AuthRequest represents the message sent by the voter on each authentication step
+type AuthRequest struct {
+	// AuthToken is the token returned by the previous step, empty on the first one
+	AuthToken *uuid.UUID `json:"authToken,omitempty"`
+	// AuthData contains the data required by the authentication handler on this step
+	AuthData []string `json:"authData,omitempty"`
+}
+
+// AuthResponse represents the response to an authentication step
+type AuthResponse struct {
+	// AuthToken identifies the authentication session, it must be sent on the next
+	// step and, once authenticated, on the sign request
+	AuthToken *uuid.UUID `json:"authToken"`
+	// Response contains the data returned by the authentication handler
+	Response []string `json:"response,omitempty"`
+	// Authenticated is true once the last authentication step succeeded
+	Authenticated bool `json:"authenticated"`
+	// TokenR is the R point to use for blinding the message to sign, returned
+	// once authenticated for blind signatures
+	TokenR types.HexBytes `json:"tokenR,omitempty"`
+}
+
+// SignRequest represents the request to sign a payload
+type SignRequest struct {
+	// AuthToken is the token of an authenticated session
+	AuthToken *uuid.UUID `json:"authToken"`
+	// Payload is the blinded hash of the CA bundle for blind signatures, or
+	// the protobuf encoded CA bundle for ECDSA signatures
+	Payload types.HexBytes `json:"payload"`
+}
+
+// SignResponse represents the response to a sign request
+type SignResponse struct {
+	// Signature is the blinded signature for blind signatures (that must be
+	// unblinded by the voter), or the signature of the CA bundle for ECDSA
+	Signature types.HexBytes `json:"signature"`
+}
@@ -0,0 +1,103 @@
+package downloads
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/data/downloader"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/apirest"
+)
+
+const (
+	DownloadsHandler = "downloads"
+)
+
+// DownloadsAPI is an httprouter/apirest handler for inspecting and requeuing
+// the items of the offchain data download queue.
+type DownloadsAPI struct {
+	downloader *downloader.Downloader
+}
+
+// AttachDownloadsAPI attaches the admin endpoints of the download queue to the
+// given http apirest router, which must have an admin token configured.
+// The path prefix is used to define the base path in which the endpoint methods will be registered.
+// For example, if the pathPrefix is "/downloads", the resulting endpoints are /downloads/queue
+// and /downloads/queue/requeue.
+func AttachDownloadsAPI(d *downloader.Downloader, a *apirest.API, pathPrefix string) error {
+	dl := &DownloadsAPI{downloader: d}
+	if err := a.RegisterMethod(
+		fmt.Sprintf("%s/queue", pathPrefix),
+		"GET",
+		apirest.MethodAccessTypeAdmin,
+		dl.queueHandler,
+	); err != nil {
+		return err
+	}
+	return a.RegisterMethod(
+		fmt.Sprintf("%s/queue/requeue", pathPrefix),
+		"POST",
+		apirest.MethodAccessTypeAdmin,
+		dl.requeueHandler,
+	)
+}
+
+// queueHandler
+//
+//	@Summary		Download queue
+//	@Description	Returns the items of the offchain data download queue, sorted by the order they will be downloaded.
+//	@Description	Requires the admin bearer token.
+//	@Tags			Downloads
+//	@Produce		json
+//	@Success		200	{object}	downloads.QueueResponse
+//	@Router			/downloads/queue [get]
+func (dl *DownloadsAPI) queueHandler(_ *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	data, err := json.Marshal(&QueueResponse{Items: dl.downloader.Items()})
+	if err != nil {
+		return api.ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
+// requeueHandler
+//
+//	@Summary		Requeue downloads
+//	@Description	Resets the attempts of the download queue items, so they are downloaded as soon as possible.
+//	@Description	If no URIs are given, all the failed items are requeued. Requires the admin bearer token.
+//	@Tags			Downloads
+//	@Accept			json
+//	@Produce		json
+//	@Param			transaction	body		downloads.RequeueRequest	true	"URIs to requeue"
+//	@Success		200			{object}	downloads.RequeueResponse
+//	@Router			/downloads/queue/requeue [post]
+func (dl *DownloadsAPI) requeueHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	req := &RequeueRequest{}
+	if len(msg.Data) > 0 {
+		if err := json.Unmarshal(msg.Data, req); err != nil {
+			return api.ErrCantParseDataAsJSON.WithErr(err)
+		}
+	}
+	uris := req.URIs
+	if len(uris) == 0 {
+		for _, item := range dl.downloader.Items() {
+			if item.Failed {
+				uris = append(uris, item.URI)
+			}
+		}
+	}
+	for _, uri := range uris {
+		if err := dl.downloader.Requeue(uri); err != nil {
+			if errors.Is(err, downloader.ErrItemNotFound) {
+				return api.ErrDownloadItemNotFound.With(uri)
+			}
+			return api.ErrCantRequeueDownload.WithErr(err)
+		}
+	}
+	data, err := json.Marshal(&RequeueResponse{Requeued: len(uris)})
+	if err != nil {
+		return api.ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
@@ -0,0 +1,19 @@
+package downloads
+
+import "go.vocdoni.io/dvote/data/downloader"
+
+// QueueResponse is the list of items of the download queue.
+type QueueResponse struct {
+	Items []*downloader.DownloadItem `json:"items"`
+}
+
+// RequeueRequest is the list of URIs to requeue. If empty, all the failed
+// items are requeued.
+type RequeueRequest struct {
+	URIs []string `json:"uris,omitempty"`
+}
+
+// RequeueResponse is the number of items requeued.
+type RequeueResponse struct {
+	Requeued int `json:"requeued"`
+}
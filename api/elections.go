@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt" // required for evm encoding
+	"io"
 	"strconv"
 	"strings"
 	"time"
@@ -17,8 +18,11 @@ import (
 	"go.vocdoni.io/dvote/httprouter/apirest"
 	"go.vocdoni.io/dvote/log"
 	"go.vocdoni.io/dvote/statedb"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/types/metadata"
 	"go.vocdoni.io/dvote/util"
 	"go.vocdoni.io/dvote/vochain/indexer"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
 	"go.vocdoni.io/dvote/vochain/processid"
 	"go.vocdoni.io/dvote/vochain/state"
 	"go.vocdoni.io/proto/build/go/models"
@@ -27,7 +31,7 @@ import (
 
 const (
 	ElectionHandler     = "elections"
-	MaxOffchainFileSize = 1024 * 1024 * 1 // 1MB
+	MaxOffchainFileSize = metadata.MaxSize
 )
 
 func (a *API) enableElectionHandlers() error {
@@ -39,6 +43,22 @@ func (a *API) enableElectionHandlers() error {
 	); err != nil {
 		return err
 	}
+	if err := a.endpoint.RegisterMethod(
+		"/elections",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.electionPageHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/elections/search",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.electionSearchHandler,
+	); err != nil {
+		return err
+	}
 	if err := a.endpoint.RegisterMethod(
 		"/elections/{electionID}",
 		"GET",
@@ -71,6 +91,14 @@ func (a *API) enableElectionHandlers() error {
 	); err != nil {
 		return err
 	}
+	if err := a.endpoint.RegisterMethod(
+		"/elections/{electionID}/votes",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.electionVotePageHandler,
+	); err != nil {
+		return err
+	}
 	if err := a.endpoint.RegisterMethod(
 		"/elections/{electionID}/scrutiny",
 		"GET",
@@ -79,6 +107,22 @@ func (a *API) enableElectionHandlers() error {
 	); err != nil {
 		return err
 	}
+	if err := a.endpoint.RegisterMethod(
+		"/elections/{electionID}/votes/export/{format}",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.electionVotesExportHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.endpoint.RegisterMethod(
+		"/elections/{electionID}/results/export/{format}",
+		"GET",
+		apirest.MethodAccessTypePublic,
+		a.electionResultsExportHandler,
+	); err != nil {
+		return err
+	}
 	if err := a.endpoint.RegisterMethod(
 		"/elections",
 		"POST",
@@ -159,6 +203,90 @@ func (a *API) electionFullListHandler(msg *apirest.APIdata, ctx *httprouter.HTTP
 	return ctx.Send(data, apirest.HTTPstatusOK)
 }
 
+// electionPageHandler
+//
+//	@Summary		List elections
+//	@Description	Returns a page of the elections, in the order they were created.
+//	@Description	Use the cursor and limit query parameters to paginate, see Pagination.
+//	@Success		200	{object}	ElectionPage
+//	@Router			/elections [get]
+func (a *API) electionPageHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	cursor, limit, err := cursorParams(ctx)
+	if err != nil {
+		return err
+	}
+	pids, page, err := a.indexer.ProcessPage(nil, nil, cursor, limit)
+	if err != nil {
+		return ErrCantFetchElectionList.WithErr(err)
+	}
+	return a.sendElectionPage(ctx, pids, page, limit)
+}
+
+// sendElectionPage sends a page of a list of elections as an ElectionPage.
+func (a *API) sendElectionPage(ctx *httprouter.HTTPContext, pids []types.HexBytes,
+	page *indexertypes.ListPage, limit int,
+) error {
+	ids := make([][]byte, len(pids))
+	for i, pid := range pids {
+		ids[i] = pid
+	}
+	elections, err := a.electionSummaryList(ids...)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(&ElectionPage{
+		Elections:  elections,
+		Pagination: newPagination(page, limit),
+	})
+	if err != nil {
+		return ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
+// electionSearchHandler
+//
+//	@Summary		Search elections
+//	@Description	Full-text search over the election metadata (titles, descriptions and questions in all languages).
+//	@Description	The search terms are given with the query parameter q, the optional parameter lang restricts
+//	@Description	the search to one metadata language and page is used for pagination. Results are ordered by relevance.
+//	@Success		200	{object}	object
+//	@Router			/elections/search [get]
+func (a *API) electionSearchHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	query, lang, page, err := searchParams(ctx)
+	if err != nil {
+		return err
+	}
+	matches, err := a.indexer.SearchElections(query, lang, page*MaxPageSize, MaxPageSize)
+	if err != nil {
+		return ErrCantSearchMetadata.WithErr(err)
+	}
+	list := []ElectionSearchResult{}
+	for _, m := range matches {
+		// the metadata can be indexed before the election, so skip it
+		if _, err := a.indexer.ProcessInfo(m.ID); errors.Is(err, indexer.ErrProcessNotFound) {
+			log.Debugw("search match not found in the indexer", "electionID", m.ID.String())
+			continue
+		}
+		summary, err := a.electionSummaryList(m.ID)
+		if err != nil {
+			return err
+		}
+		list = append(list, ElectionSearchResult{
+			ElectionSummary: *summary[0],
+			Language:        m.Language,
+			Rank:            m.Rank,
+		})
+	}
+	data, err := json.Marshal(struct {
+		Elections []ElectionSearchResult `json:"elections"`
+	}{list})
+	if err != nil {
+		return ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
 // electionHandler
 //
 //	@Summary		Get election information
@@ -215,19 +343,30 @@ func (a *API) electionHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext)
 		election.Results = results.Votes
 	}
 
-	// Try to retrieve the election metadata
-	if a.storage != nil {
+	// Try to retrieve the election metadata, preferably already validated and
+	// normalised by the indexer. Invalid metadata is served as is, along with
+	// its validation errors.
+	if stored, err := a.indexer.ElectionMetadata(electionID); err == nil && stored.URI == election.MetadataURL {
+		election.MetadataValid = stored.Valid
+		election.MetadataErrors = stored.Errors
+		if len(stored.Data) > 0 {
+			election.Metadata = invalidElectionMetadata(stored.Data)
+			if stored.Valid && election.Metadata == nil {
+				return ErrCantParseMetadataAsJSON
+			}
+		}
+	} else if a.storage != nil && election.MetadataURL != "" {
 		stgCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 		defer cancel()
 		metadataBytes, err := a.storage.Retrieve(stgCtx, election.MetadataURL, MaxOffchainFileSize)
 		if err != nil {
 			log.Warnf("cannot get metadata from %s: %v", election.MetadataURL, err)
+		} else if election.Metadata, err = metadata.ParseElection(metadataBytes); err != nil {
+			log.Warnf("invalid metadata from %s: %v", election.MetadataURL, err)
+			election.Metadata = invalidElectionMetadata(metadataBytes)
+			election.MetadataErrors = metadata.Errors(err)
 		} else {
-			electionMetadata := ElectionMetadata{}
-			if err := json.Unmarshal(metadataBytes, &electionMetadata); err != nil {
-				log.Warnf("cannot unmarshal metadata from %s: %v", election.MetadataURL, err)
-			}
-			election.Metadata = &electionMetadata
+			election.MetadataValid = true
 		}
 	}
 	data, err := json.Marshal(election)
@@ -365,6 +504,123 @@ func (a *API) electionVotesHandler(msg *apirest.APIdata, ctx *httprouter.HTTPCon
 	return ctx.Send(data, apirest.HTTPstatusOK)
 }
 
+// electionVotePageHandler
+//
+//	@Summary		List election votes
+//	@Description	Returns a page of the votes of an election, in the order they were included in the chain.
+//	@Description	Use the cursor and limit query parameters to paginate, see Pagination.
+//	@Param			electionID	path		string	true	"Election id"
+//	@Success		200			{object}	VotePage
+//	@Router			/elections/{electionID}/votes [get]
+func (a *API) electionVotePageHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	electionID, err := hex.DecodeString(util.TrimHex(ctx.URLParam("electionID")))
+	if err != nil || electionID == nil {
+		return ErrCantParseElectionID.Withf("(%s): %v", ctx.URLParam("electionID"), err)
+	}
+	if _, err := getElection(electionID, a.vocapp.State); err != nil {
+		return err
+	}
+	cursor, limit, err := cursorParams(ctx)
+	if err != nil {
+		return err
+	}
+	envelopes, page, err := a.indexer.EnvelopePage(electionID, cursor, limit)
+	if err != nil {
+		return ErrCantFetchEnvelope.WithErr(err)
+	}
+	votes := []*Vote{}
+	for _, v := range envelopes {
+		votes = append(votes, &Vote{
+			VoteID:           v.Nullifier,
+			VoterID:          v.VoterID,
+			TxHash:           v.TxHash,
+			BlockHeight:      v.Height,
+			TransactionIndex: &v.TxIndex,
+		})
+	}
+	data, err := json.Marshal(&VotePage{
+		Votes:      votes,
+		Pagination: newPagination(page, limit),
+	})
+	if err != nil {
+		return ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
+
+// exportParams returns the election ID and export format of an export request,
+// checking that the election exists, and sets the content headers for the export.
+func (a *API) exportParams(ctx *httprouter.HTTPContext, name string) ([]byte, string, string, error) {
+	electionID, err := hex.DecodeString(util.TrimHex(ctx.URLParam("electionID")))
+	if err != nil || electionID == nil {
+		return nil, "", "", ErrCantParseElectionID.Withf("(%s): %v", ctx.URLParam("electionID"), err)
+	}
+	if _, err := getElection(electionID, a.vocapp.State); err != nil {
+		return nil, "", "", err
+	}
+	format := ctx.URLParam("format")
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		return nil, "", "", ErrParamExportFormatInvalid.With(format)
+	}
+	ctx.Writer.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=%x-%s.%s", electionID, name, format))
+	return electionID, format, contentType, nil
+}
+
+// electionVotesExportHandler
+//
+//	@Summary		Export election votes
+//	@Description	Streams all the votes of an election, in the order they were included in the chain, as
+//	@Description	CSV or JSON lines. Each vote includes the nullifier, voter ID, weight, height, transaction
+//	@Description	index, overwrite count and vote package, plus the decoded votes if the vote package is not
+//	@Description	encrypted or the election keys have been revealed.
+//	@Param			electionID	path		string	true	"Election id"
+//	@Param			format		path		string	true	"Export format: csv or jsonl"
+//	@Success		200			{object}	indexertypes.ExportedEnvelope
+//	@Router			/elections/{electionID}/votes/export/{format} [get]
+func (a *API) electionVotesExportHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	electionID, format, contentType, err := a.exportParams(ctx, "votes")
+	if err != nil {
+		return err
+	}
+	// the response can't be replaced by an error once streaming, so any errors are just logged
+	if err := ctx.Stream(contentType, func(w io.Writer) error {
+		return a.indexer.ExportEnvelopes(w, electionID, format)
+	}); err != nil {
+		log.Warnw("cannot export votes", "electionID", fmt.Sprintf("%x", electionID), "err", err)
+	}
+	return nil
+}
+
+// electionResultsExportHandler
+//
+//	@Summary		Export election results
+//	@Description	Returns the current results of an election as CSV or JSON lines, with one row per option
+//	@Description	of each question.
+//	@Param			electionID	path		string	true	"Election id"
+//	@Param			format		path		string	true	"Export format: csv or jsonl"
+//	@Success		200			{object}	indexertypes.ExportedResult
+//	@Router			/elections/{electionID}/results/export/{format} [get]
+func (a *API) electionResultsExportHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	electionID, format, contentType, err := a.exportParams(ctx, "results")
+	if err != nil {
+		return err
+	}
+	// the results are small, so they are exported before replying to be able to send any error
+	var buf bytes.Buffer
+	if err := a.indexer.ExportResults(&buf, electionID, format); err != nil {
+		if errors.Is(err, indexer.ErrNoResultsYet) {
+			return ErrElectionResultsNotYetAvailable
+		}
+		return ErrCantExportElection.WithErr(err)
+	}
+	return ctx.Stream(contentType, func(w io.Writer) error {
+		_, err := buf.WriteTo(w)
+		return err
+	})
+}
+
 // electionScrutinyHandler
 //
 //	@Summary		Election results
@@ -534,10 +790,9 @@ func (a *API) electionCreateHandler(msg *apirest.APIdata, ctx *httprouter.HTTPCo
 
 	var metadataCID string
 	if req.Metadata != nil {
-		// if election metadata defined, check the format
-		metadata := ElectionMetadata{}
-		if err := json.Unmarshal(req.Metadata, &metadata); err != nil {
-			return ErrCantParseMetadataAsJSON.WithErr(err)
+		// if election metadata defined, check the format and schema
+		if _, err := metadata.ParseElection(req.Metadata); err != nil {
+			return metadataError(req.Metadata, err)
 		}
 
 		// set metadataCID from metadata bytes
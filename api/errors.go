@@ -68,6 +68,42 @@ var (
 	ErrKeyNotFoundInCensus              = apirest.APIerror{Code: 4049, HTTPstatus: apirest.HTTPstatusNotFound, Err: fmt.Errorf("key not found in census")}
 	ErrInvalidStatus                    = apirest.APIerror{Code: 4050, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("invalid status")}
 	ErrInvalidCensusKeyLength           = apirest.APIerror{Code: 4051, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("invalid census key length")}
+	ErrParamSearchQueryMissing          = apirest.APIerror{Code: 4052, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("parameter (q) missing or without search terms")}
+	ErrParamStatsBucketInvalid          = apirest.APIerror{Code: 4053, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("parameter (bucket) invalid, must be hour, day or week")}
+	ErrParamStatsRangeInvalid           = apirest.APIerror{Code: 4054, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("parameters (from, to) invalid time range")}
+	ErrParamCursorInvalid               = apirest.APIerror{Code: 4055, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("parameter (cursor) invalid")}
+	ErrParamLimitInvalid                = apirest.APIerror{Code: 4056, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("parameter (limit) invalid")}
+	ErrParamExportFormatInvalid         = apirest.APIerror{Code: 4057, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("parameter (format) invalid, must be csv or jsonl")}
+	ErrCensusJobNotFound                = apirest.APIerror{Code: 4058, HTTPstatus: apirest.HTTPstatusNotFound, Err: fmt.Errorf("census job not found")}
+	ErrParamCensusJobIDInvalid          = apirest.APIerror{Code: 4059, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("parameter (jobID) invalid")}
+	ErrCensusJobTypeUnknown             = apirest.APIerror{Code: 4060, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("census job type is unknown, must be participants or import")}
+	ErrParamChunkIndexInvalid           = apirest.APIerror{Code: 4061, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("parameter (index) invalid")}
+	ErrCensusJobChunkOutOfOrder         = apirest.APIerror{Code: 4062, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("census job chunk out of order")}
+	ErrCensusJobChunkSizeInvalid        = apirest.APIerror{Code: 4063, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("census job chunk size invalid")}
+	ErrCensusJobAlreadyStarted          = apirest.APIerror{Code: 4064, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("census job already started")}
+	ErrCensusNotEmpty                   = apirest.APIerror{Code: 4065, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("census must be empty to import a dump")}
+	ErrCensusPublished                  = apirest.APIerror{Code: 4066, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("census is published and cannot be modified")}
+	ErrCensusParticipantNotFound        = apirest.APIerror{Code: 4067, HTTPstatus: apirest.HTTPstatusNotFound, Err: fmt.Errorf("participant not found in the census")}
+	ErrCensusMergeOperationUnknown      = apirest.APIerror{Code: 4068, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("census merge operation is unknown, must be union or intersection")}
+	ErrCensusWeightPolicyUnknown        = apirest.APIerror{Code: 4069, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("census weight policy is unknown, must be sum, max, min, first or fail")}
+	ErrCensusWeightConflict             = apirest.APIerror{Code: 4070, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("census key with conflicting weights")}
+	ErrParamCensusIDsMissing            = apirest.APIerror{Code: 4071, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("parameter (censusIDs) missing, at least two censuses are required")}
+	ErrParamKeysMissing                 = apirest.APIerror{Code: 4072, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("parameter (keys) missing")}
+	ErrParamKeysTooBig                  = apirest.APIerror{Code: 4073, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("parameter (keys) exceeds max length per call")}
+	ErrCSPAuthTokenInvalid              = apirest.APIerror{Code: 4074, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("CSP auth token invalid or expired")}
+	ErrCSPAuthStepInvalid               = apirest.APIerror{Code: 4075, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("CSP auth step invalid")}
+	ErrCSPAuthFailed                    = apirest.APIerror{Code: 4076, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("CSP authentication failed")}
+	ErrCSPAlreadySigned                 = apirest.APIerror{Code: 4077, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("CSP already signed for this user and election")}
+	ErrCSPSignTypeInvalid               = apirest.APIerror{Code: 4078, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("CSP signature type invalid")}
+	ErrCSPPayloadInvalid                = apirest.APIerror{Code: 4079, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("CSP payload to sign invalid")}
+	ErrDownloadItemNotFound             = apirest.APIerror{Code: 4080, HTTPstatus: apirest.HTTPstatusNotFound, Err: fmt.Errorf("download item not found")}
+	ErrMetadataInvalid                  = apirest.APIerror{Code: 4081, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("metadata does not match its schema")}
+	ErrArchivedElectionNotFound         = apirest.APIerror{Code: 4082, HTTPstatus: apirest.HTTPstatusNotFound, Err: fmt.Errorf("election not found in the archive")}
+	ErrInvalidBlockCount                = apirest.APIerror{Code: 4083, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("invalid number of blocks")}
+	ErrCantMoveClockBack                = apirest.APIerror{Code: 4084, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("the clock cannot be moved back")}
+	ErrSponsorLimitNotFound             = apirest.APIerror{Code: 4085, HTTPstatus: apirest.HTTPstatusNotFound, Err: fmt.Errorf("sponsor limit not found")}
+	ErrSponsorScopeMalformed            = apirest.APIerror{Code: 4086, HTTPstatus: apirest.HTTPstatusBadRequest, Err: fmt.Errorf("sponsor scope malformed, expected an election ID or an account address")}
+	ErrCSPTooManySessions               = apirest.APIerror{Code: 4087, HTTPstatus: apirest.HTTPstatusTooMany, Err: fmt.Errorf("CSP has too many auth sessions, try again later")}
 	ErrVochainEmptyReply                = apirest.APIerror{Code: 5000, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("vochain returned an empty reply")}
 	ErrVochainSendTxFailed              = apirest.APIerror{Code: 5001, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("vochain SendTx failed")}
 	ErrVochainGetTxFailed               = apirest.APIerror{Code: 5002, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("vochain GetTx failed")}
@@ -98,4 +134,18 @@ var (
 	ErrCantGetCircomSiblings            = apirest.APIerror{Code: 5027, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("cannot get circom siblings")}
 	ErrCensusProofVerificationFailed    = apirest.APIerror{Code: 5028, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("census proof verification failed")}
 	ErrCantCountVotes                   = apirest.APIerror{Code: 5029, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("cannot count votes")}
+	ErrSearchNotAvailable               = apirest.APIerror{Code: 5030, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("full-text search is not available on this node")}
+	ErrCantSearchMetadata               = apirest.APIerror{Code: 5031, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("cannot search metadata")}
+	ErrCantFetchStats                   = apirest.APIerror{Code: 5032, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("cannot fetch stats")}
+	ErrCantFetchOrganizationList        = apirest.APIerror{Code: 5033, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("cannot fetch organization list")}
+	ErrCantFetchTransactions            = apirest.APIerror{Code: 5034, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("cannot fetch transactions")}
+	ErrCantExportElection               = apirest.APIerror{Code: 5035, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("cannot export election")}
+	ErrCantUpdateTree                   = apirest.APIerror{Code: 5036, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("cannot update census tree")}
+	ErrCantDiffCensus                   = apirest.APIerror{Code: 5037, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("cannot diff censuses")}
+	ErrCantMergeCensus                  = apirest.APIerror{Code: 5038, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("cannot merge censuses")}
+	ErrCantSignCSP                      = apirest.APIerror{Code: 5039, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("cannot sign CSP payload")}
+	ErrCantRequeueDownload              = apirest.APIerror{Code: 5040, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("cannot requeue download item")}
+	ErrCantFetchArchive                 = apirest.APIerror{Code: 5041, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("cannot fetch from the process archive")}
+	ErrCantFetchSponsorLimit            = apirest.APIerror{Code: 5042, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("cannot fetch sponsor limit")}
+	ErrCantFetchVestingSchedules        = apirest.APIerror{Code: 5043, HTTPstatus: apirest.HTTPstatusInternalErr, Err: fmt.Errorf("cannot fetch vesting schedules")}
 )
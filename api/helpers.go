@@ -6,12 +6,17 @@ import (
 	"fmt" // required for evm encoding
 	"math/big"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/iancoleman/strcase"
+	"go.vocdoni.io/dvote/httprouter"
 	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/vochain/indexer"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
 	"go.vocdoni.io/proto/build/go/models"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
@@ -43,6 +48,112 @@ func (a *API) electionSummaryList(pids ...[]byte) ([]*ElectionSummary, error) {
 	return processes, nil
 }
 
+// searchParams parses the URL query parameters used by the full-text search
+// endpoints: q (the search terms, mandatory), lang (optional language filter)
+// and page (optional, zero by default).
+func searchParams(ctx *httprouter.HTTPContext) (query, lang string, page int, err error) {
+	params := ctx.Request.URL.Query()
+	query = strings.TrimSpace(params.Get("q"))
+	if query == "" {
+		return "", "", 0, ErrParamSearchQueryMissing
+	}
+	if p := params.Get("page"); p != "" {
+		page, err = strconv.Atoi(p)
+		if err != nil || page < 0 {
+			return "", "", 0, ErrCantParsePageNumber.With(p)
+		}
+	}
+	return query, params.Get("lang"), page, nil
+}
+
+// cursorParams parses the URL query parameters used by the lists paginated with
+// cursors: cursor (optional, the first page is returned by default) and limit
+// (optional, MaxPageSize by default, up to MaxListLimit).
+func cursorParams(ctx *httprouter.HTTPContext) (*indexertypes.ListCursor, int, error) {
+	params := ctx.Request.URL.Query()
+	var cursor *indexertypes.ListCursor
+	if c := params.Get("cursor"); c != "" {
+		cursor = &indexertypes.ListCursor{}
+		if err := cursor.UnmarshalText([]byte(c)); err != nil {
+			return nil, 0, ErrParamCursorInvalid.WithErr(err)
+		}
+	}
+	limit := MaxPageSize
+	if l := params.Get("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit <= 0 || limit > MaxListLimit {
+			return nil, 0, ErrParamLimitInvalid.Withf("%q, must be between 1 and %d", l, MaxListLimit)
+		}
+	}
+	return cursor, limit, nil
+}
+
+// newPagination returns the Pagination of a page of a list paginated with cursors.
+func newPagination(page *indexertypes.ListPage, limit int) *Pagination {
+	pagination := &Pagination{Total: page.Total, Limit: limit}
+	if page.Next != nil {
+		pagination.Next = page.Next.String()
+	}
+	if page.Prev != nil {
+		pagination.Prev = page.Prev.String()
+	}
+	return pagination
+}
+
+// exportContentTypes are the content types of the formats accepted by the export endpoints.
+var exportContentTypes = map[string]string{
+	indexer.ExportFormatCSV:   "text/csv",
+	indexer.ExportFormatJSONL: "application/jsonl",
+}
+
+// statsBuckets are the bucket sizes accepted by the stats endpoints.
+var statsBuckets = map[string]time.Duration{
+	"hour": indexer.StatsBucketHour,
+	"day":  indexer.StatsBucketDay,
+	"week": indexer.StatsBucketWeek,
+}
+
+// statsParams parses the URL query parameters used by the stats endpoints:
+// bucket (hour, day or week; day by default), and from and to, as unix timestamps
+// or RFC3339 dates. By default, the last 30 days are returned.
+func statsParams(ctx *httprouter.HTTPContext) (*ChainStats, error) {
+	params := ctx.Request.URL.Query()
+	stats := &ChainStats{Bucket: "day", To: time.Now()}
+	if b := params.Get("bucket"); b != "" {
+		stats.Bucket = b
+	}
+	bucket, ok := statsBuckets[stats.Bucket]
+	if !ok {
+		return nil, ErrParamStatsBucketInvalid.With(stats.Bucket)
+	}
+	parseTime := func(s string) (time.Time, error) {
+		if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Unix(unix, 0), nil
+		}
+		return time.Parse(time.RFC3339, s)
+	}
+	var err error
+	if to := params.Get("to"); to != "" {
+		if stats.To, err = parseTime(to); err != nil {
+			return nil, ErrParamStatsRangeInvalid.Withf("(to): %v", err)
+		}
+	}
+	stats.From = stats.To.Add(-30 * indexer.StatsBucketDay)
+	if from := params.Get("from"); from != "" {
+		if stats.From, err = parseTime(from); err != nil {
+			return nil, ErrParamStatsRangeInvalid.Withf("(from): %v", err)
+		}
+	}
+	if !stats.To.After(stats.From) {
+		return nil, ErrParamStatsRangeInvalid.With("to must be after from")
+	}
+	if stats.To.Sub(stats.From)/bucket >= MaxStatsBuckets {
+		return nil, ErrParamStatsRangeInvalid.Withf("too many buckets, maximum is %d", MaxStatsBuckets)
+	}
+	return stats, nil
+}
+
 func protoFormat(tx []byte) string {
 	ptx := models.Tx{}
 	if err := proto.Unmarshal(tx, &ptx); err != nil {
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+
+	"go.vocdoni.io/dvote/httprouter/apirest"
+	"go.vocdoni.io/dvote/types/metadata"
+)
+
+// metadataError returns the API error for the metadata that cannot be parsed,
+// either because it is not JSON or because it does not match its schema.
+func metadataError(data []byte, err error) apirest.APIerror {
+	if !json.Valid(data) {
+		return ErrCantParseMetadataAsJSON.WithErr(err)
+	}
+	return ErrMetadataInvalid.With(strings.Join(metadata.Errors(err), "; "))
+}
+
+// invalidElectionMetadata decodes the election metadata which does not match
+// its schema as is, so it can be served along with the validation errors.
+// It returns nil if the metadata can't be decoded into an ElectionMetadata.
+func invalidElectionMetadata(data []byte) *ElectionMetadata {
+	m := &ElectionMetadata{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/types/metadata"
+)
+
+func TestMetadataError(t *testing.T) {
+	_, err := metadata.ParseElection([]byte(`{"title": "Election"}`))
+	qt.Assert(t, metadataError([]byte(`{"title": "Election"}`), err).Code, qt.Equals, ErrMetadataInvalid.Code)
+	_, err = metadata.ParseElection([]byte(`{`))
+	qt.Assert(t, metadataError([]byte(`{`), err).Code, qt.Equals, ErrCantParseMetadataAsJSON.Code)
+}
+
+func TestInvalidElectionMetadata(t *testing.T) {
+	// metadata not matching the schema is decoded as is
+	m := invalidElectionMetadata([]byte(`{"title": {"en": " Election "}, "meta": [1, 2]}`))
+	qt.Assert(t, m, qt.Not(qt.IsNil))
+	qt.Assert(t, m.Title["en"], qt.Equals, " Election ")
+	qt.Assert(t, m.Meta, qt.DeepEquals, []interface{}{float64(1), float64(2)})
+	// unless it can't be decoded into the metadata type
+	qt.Assert(t, invalidElectionMetadata([]byte(`{"title": 1}`)), qt.IsNil)
+	qt.Assert(t, invalidElectionMetadata([]byte(`{`)), qt.IsNil)
+}
@@ -16,6 +16,7 @@ import (
 	"go.vocdoni.io/dvote/httprouter"
 	"go.vocdoni.io/dvote/httprouter/apirest"
 	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/types/metadata"
 	"go.vocdoni.io/dvote/util"
 	"go.vocdoni.io/proto/build/go/models"
 	"google.golang.org/protobuf/proto"
@@ -362,7 +363,7 @@ func (a *API) walletElectionHandler(msg *apirest.APIdata, ctx *httprouter.HTTPCo
 	}
 
 	// Prepare the election metadata information
-	metadata := ElectionMetadata{
+	electionMetadata := ElectionMetadata{
 		Description: description.Description,
 		Media: ProcessMedia{
 			Header:    description.Header,
@@ -394,7 +395,7 @@ func (a *API) walletElectionHandler(msg *apirest.APIdata, ctx *httprouter.HTTPCo
 				Value: choice.Value,
 			})
 		}
-		metadata.Questions = append(metadata.Questions, metaQuestion)
+		electionMetadata.Questions = append(electionMetadata.Questions, metaQuestion)
 	}
 
 	// TODO: respect maxCount and maxValue if specified
@@ -413,10 +414,13 @@ func (a *API) walletElectionHandler(msg *apirest.APIdata, ctx *httprouter.HTTPCo
 	}
 
 	// Publish the metadata to IPFS
-	metadataBytes, err := json.Marshal(&metadata)
+	metadataBytes, err := json.Marshal(&electionMetadata)
 	if err != nil {
 		return ErrCantMarshalMetadata.WithErr(err)
 	}
+	if _, err := metadata.ParseElection(metadataBytes); err != nil {
+		return metadataError(metadataBytes, err)
+	}
 	storageCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	metadataURI, err := a.storage.Publish(storageCtx, metadataBytes)
 	cancel()
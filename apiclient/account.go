@@ -123,8 +123,7 @@ func (c *HTTPclient) AccountBootstrap(faucetPkg *models.FaucetPackage, metadata
 	}
 
 	// Sign and send the transaction
-	stx.Signature, err = c.account.SignVocdoniTx(stx.Tx, c.ChainID())
-	if err != nil {
+	if err := c.signTx(&stx); err != nil {
 		return nil, err
 	}
 	stxb, err := proto.Marshal(&stx)
@@ -184,8 +183,7 @@ func (c *HTTPclient) AccountSetMetadata(metadata *api.AccountMetadata) (types.He
 	}
 
 	// Sign and send the transaction
-	stx.Signature, err = c.account.SignVocdoniTx(stx.Tx, c.ChainID())
-	if err != nil {
+	if err := c.signTx(&stx); err != nil {
 		return nil, err
 	}
 	stxb, err := proto.Marshal(&stx)
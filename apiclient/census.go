@@ -61,6 +61,33 @@ func (c *HTTPclient) CensusAddParticipants(censusID types.HexBytes, participants
 	return nil
 }
 
+// CensusUpdateParticipants changes the weight of one or several participants
+// already in an existing census. The census must not be published.
+func (c *HTTPclient) CensusUpdateParticipants(censusID types.HexBytes, participants *api.CensusParticipants) error {
+	resp, code, err := c.Request("PUT", &participants, "censuses", censusID.String(), "participants")
+	if err != nil {
+		return err
+	}
+	if code != 200 {
+		return fmt.Errorf("%s: %d (%s)", errCodeNot200, code, resp)
+	}
+	return nil
+}
+
+// CensusDeleteParticipants removes one or several participants from an
+// existing census, the weights of the participants are ignored. The census
+// must not be published.
+func (c *HTTPclient) CensusDeleteParticipants(censusID types.HexBytes, participants *api.CensusParticipants) error {
+	resp, code, err := c.Request("DELETE", &participants, "censuses", censusID.String(), "participants")
+	if err != nil {
+		return err
+	}
+	if code != 200 {
+		return fmt.Errorf("%s: %d (%s)", errCodeNot200, code, resp)
+	}
+	return nil
+}
+
 // CensusSize returns the number of participants in a census.
 func (c *HTTPclient) CensusSize(censusID types.HexBytes) (uint64, error) {
 	resp, code, err := c.Request("GET", nil, "censuses", censusID.String(), "size")
@@ -96,6 +123,63 @@ func (c *HTTPclient) CensusPublish(censusID types.HexBytes) (types.HexBytes, str
 	return censusData.CensusID, censusData.URI, nil
 }
 
+// CensusHistory returns the roots published from a census, from the newest to
+// the first one.
+func (c *HTTPclient) CensusHistory(censusID types.HexBytes) ([]*api.CensusVersion, error) {
+	resp, code, err := c.Request("GET", nil, "censuses", censusID.String(), "history")
+	if err != nil {
+		return nil, err
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("%s: %d (%s)", errCodeNot200, code, resp)
+	}
+	history := &api.CensusHistory{}
+	if err := json.Unmarshal(resp, history); err != nil {
+		return nil, fmt.Errorf("could not unmarshal response: %w", err)
+	}
+	return history.Versions, nil
+}
+
+// CensusDiff returns the keys added, removed and reweighted in a census since
+// the given root, which can be a published census or a previous root of the
+// census.
+func (c *HTTPclient) CensusDiff(censusID, fromRoot types.HexBytes) (*api.CensusDiff, error) {
+	resp, code, err := c.Request("GET", nil, "censuses", censusID.String(), "diff", fromRoot.String())
+	if err != nil {
+		return nil, err
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("%s: %d (%s)", errCodeNot200, code, resp)
+	}
+	diff := &api.CensusDiff{}
+	if err := json.Unmarshal(resp, diff); err != nil {
+		return nil, fmt.Errorf("could not unmarshal response: %w", err)
+	}
+	return diff, nil
+}
+
+// CensusMerge creates a new census from the union or intersection (operation)
+// of the given censuses, resolving the weight of the keys found in more than
+// one census with the weight policy. Returns the ID of the new census.
+func (c *HTTPclient) CensusMerge(censusIDs []types.HexBytes, operation, weightPolicy string) (types.HexBytes, error) {
+	resp, code, err := c.Request("POST", &api.CensusMerge{
+		CensusIDs:    censusIDs,
+		Operation:    operation,
+		WeightPolicy: weightPolicy,
+	}, "censuses", "merge")
+	if err != nil {
+		return nil, err
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("%s: %d (%s)", errCodeNot200, code, resp)
+	}
+	censusData := &api.Census{}
+	if err := json.Unmarshal(resp, censusData); err != nil {
+		return nil, fmt.Errorf("could not unmarshal response: %w", err)
+	}
+	return censusData.CensusID, nil
+}
+
 // CensusGenProof generates a proof for a voter in a census. The voterKey is the public key or address of the voter.
 func (c *HTTPclient) CensusGenProof(censusID, voterKey types.HexBytes) (*CensusProof, error) {
 	resp, code, err := c.Request("GET", nil, "censuses", censusID.String(), "proof", voterKey.String())
@@ -0,0 +1,215 @@
+package apiclient
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/api/censusdb"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/util"
+)
+
+// censusJobRequest performs a census job request and returns the job status.
+func (c *HTTPclient) censusJobRequest(method string, body any, urlPath ...string) (*api.CensusJob, error) {
+	resp, code, err := c.Request(method, body, urlPath...)
+	if err != nil {
+		return nil, err
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("%s: %d (%s)", errCodeNot200, code, resp)
+	}
+	job := &api.CensusJob{}
+	if err := json.Unmarshal(resp, job); err != nil {
+		return nil, fmt.Errorf("could not unmarshal response: %w", err)
+	}
+	return job, nil
+}
+
+// CensusNewJob creates a census job of the given type, censusdb.JobTypeParticipants
+// or censusdb.JobTypeImport.
+func (c *HTTPclient) CensusNewJob(censusID types.HexBytes, jobType string) (*api.CensusJob, error) {
+	return c.censusJobRequest("POST", &api.CensusJob{Type: jobType}, "censuses", censusID.String(), "jobs")
+}
+
+// CensusJob returns the status of a census job.
+func (c *HTTPclient) CensusJob(censusID types.HexBytes, jobID string) (*api.CensusJob, error) {
+	return c.censusJobRequest("GET", nil, "censuses", censusID.String(), "jobs", jobID)
+}
+
+// CensusStartJob starts a census job once its chunks are uploaded. For import jobs,
+// rootHash is the expected root of the imported census, if not nil.
+func (c *HTTPclient) CensusStartJob(censusID types.HexBytes, jobID string, rootHash types.HexBytes) (*api.CensusJob, error) {
+	return c.censusJobRequest("POST", &api.CensusJob{RootHash: rootHash},
+		"censuses", censusID.String(), "jobs", jobID, "start")
+}
+
+// CensusWaitJob waits until the census job is done or fails, or the timeout expires.
+// An error is returned if the job fails.
+func (c *HTTPclient) CensusWaitJob(censusID types.HexBytes, jobID string, timeout time.Duration) (*api.CensusJob, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		job, err := c.CensusJob(censusID, jobID)
+		if err != nil {
+			return nil, err
+		}
+		switch job.Status {
+		case censusdb.JobStatusDone:
+			return job, nil
+		case censusdb.JobStatusFailed:
+			return job, fmt.Errorf("census job failed: %s", job.Error)
+		}
+		if time.Now().After(deadline) {
+			return job, fmt.Errorf("timeout waiting for census job %s, progress %.1f%%", jobID, job.Progress)
+		}
+		time.Sleep(PollInterval)
+	}
+}
+
+// censusJobResume returns the census job to upload to: a new one of the given type
+// if jobID is empty, or the existing one to resume its upload.
+func (c *HTTPclient) censusJobResume(censusID types.HexBytes, jobType, jobID string) (*api.CensusJob, error) {
+	if jobID == "" {
+		return c.CensusNewJob(censusID, jobType)
+	}
+	job, err := c.CensusJob(censusID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Type != jobType {
+		return nil, fmt.Errorf("census job %s is of type %s", jobID, job.Type)
+	}
+	if job.Status != censusdb.JobStatusUploading {
+		return nil, fmt.Errorf("census job %s is not uploading, its status is %s", jobID, job.Status)
+	}
+	return job, nil
+}
+
+// CensusUploadCSV adds the participants read from r in CSV format to the census,
+// uploading them in chunks to a participants job, which is started once all of
+// them are uploaded. Each record holds the hexadecimal key of a participant and,
+// optionally, its weight; a first record with an invalid key is taken as a header.
+// If jobID is not empty, the upload of that job is resumed from the same CSV.
+// Use CensusWaitJob to wait for the participants to be added.
+func (c *HTTPclient) CensusUploadCSV(censusID types.HexBytes, r io.Reader, jobID string) (*api.CensusJob, error) {
+	job, err := c.censusJobResume(censusID, censusdb.JobTypeParticipants, jobID)
+	if err != nil {
+		return nil, err
+	}
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+	chunk := &api.CensusParticipants{}
+	for index, line := uint32(0), 0; ; line++ {
+		record, err := cr.Read()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if record != nil {
+			key, err := hex.DecodeString(util.TrimHex(record[0]))
+			if err != nil {
+				if line == 0 {
+					continue // header
+				}
+				return nil, fmt.Errorf("invalid key at line %d: %w", line+1, err)
+			}
+			participant := api.CensusParticipant{Key: key}
+			if len(record) > 1 && strings.TrimSpace(record[1]) != "" {
+				weight, ok := new(big.Int).SetString(strings.TrimSpace(record[1]), 10)
+				if !ok {
+					return nil, fmt.Errorf("invalid weight at line %d: %s", line+1, record[1])
+				}
+				participant.Weight = (*types.BigInt)(weight)
+			}
+			chunk.Participants = append(chunk.Participants, participant)
+		}
+		full := len(chunk.Participants) == api.MaxCensusAddBatchSize
+		if full || (record == nil && len(chunk.Participants) > 0) {
+			// the chunks already uploaded are skipped when resuming
+			if index >= job.Chunks {
+				if job, err = c.censusJobRequest("POST", chunk, "censuses", censusID.String(),
+					"jobs", job.JobID, "chunks", fmt.Sprint(index)); err != nil {
+					return nil, fmt.Errorf("cannot upload chunk %d: %w", index, err)
+				}
+			}
+			index++
+			chunk = &api.CensusParticipants{}
+		}
+		if record == nil {
+			break
+		}
+	}
+	return c.CensusStartJob(censusID, job.JobID, nil)
+}
+
+// CensusUploadCSVFile is like CensusUploadCSV, reading the participants from a CSV file.
+func (c *HTTPclient) CensusUploadCSVFile(censusID types.HexBytes, filePath string, jobID string) (*api.CensusJob, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return c.CensusUploadCSV(censusID, f, jobID)
+}
+
+// CensusExportStream writes the census dump compressed with zstd to w, streaming it
+// from the API, and returns the census root. The dump can be imported with CensusImportStream.
+func (c *HTTPclient) CensusExportStream(censusID types.HexBytes, w io.Writer) (types.HexBytes, error) {
+	resp, err := c.do("GET", nil, nil, "censuses", censusID.String(), "export", "stream")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s: %d (%s)", errCodeNot200, resp.StatusCode, body)
+	}
+	root, err := hex.DecodeString(resp.Header.Get("X-Census-Root"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid census root: %w", err)
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// CensusImportStream imports the census dump compressed with zstd read from r into
+// the census, which must be empty, uploading it in chunks to an import job which is
+// started once all of them are uploaded. If jobID is not empty, the upload of that
+// job is resumed from the same dump. Use CensusWaitJob to wait for the import.
+func (c *HTTPclient) CensusImportStream(censusID types.HexBytes, r io.Reader, rootHash types.HexBytes,
+	jobID string,
+) (*api.CensusJob, error) {
+	job, err := c.censusJobResume(censusID, censusdb.JobTypeImport, jobID)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, api.MaxCensusJobChunkSize)
+	for index := uint32(0); ; index++ {
+		n, err := io.ReadFull(r, buf)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, err
+		}
+		// the chunks already uploaded are skipped when resuming
+		if index >= job.Chunks {
+			if job, err = c.censusJobRequest("POST", &api.CensusJobChunk{Data: buf[:n]}, "censuses",
+				censusID.String(), "jobs", job.JobID, "chunks", fmt.Sprint(index)); err != nil {
+				return nil, fmt.Errorf("cannot upload chunk %d: %w", index, err)
+			}
+		}
+	}
+	return c.CensusStartJob(censusID, job.JobID, rootHash)
+}
@@ -0,0 +1,92 @@
+package apiclient
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/types"
+)
+
+// CensusGenProofs generates the census proofs of the voterKeys (the public key or
+// address of each voter), requesting them in batches of api.MaxCensusProofsBatchSize
+// keys. The callback is called with each proof in the same order than the keys, and
+// with a non-nil error if the proof of the key could not be generated, i.e. the key
+// is not in the census. If the callback returns an error, the generation stops and
+// the error is returned. Returns the census root all the proofs belong to.
+func (c *HTTPclient) CensusGenProofs(censusID types.HexBytes, voterKeys []types.HexBytes,
+	callback func(key types.HexBytes, proof *CensusProof, err error) error,
+) (types.HexBytes, error) {
+	var root types.HexBytes
+	for start := 0; start < len(voterKeys); start += api.MaxCensusProofsBatchSize {
+		end := start + api.MaxCensusProofsBatchSize
+		if end > len(voterKeys) {
+			end = len(voterKeys)
+		}
+		batchRoot, err := c.censusGenProofsBatch(censusID, voterKeys[start:end], callback)
+		if err != nil {
+			return nil, err
+		}
+		if root != nil && !bytes.Equal(root, batchRoot) {
+			return nil, fmt.Errorf("census root changed from %x to %x while generating the proofs", root, batchRoot)
+		}
+		root = batchRoot
+	}
+	return root, nil
+}
+
+// censusGenProofsBatch requests the census proofs of the keys, decoding them as
+// they are streamed back.
+func (c *HTTPclient) censusGenProofsBatch(censusID types.HexBytes, keys []types.HexBytes,
+	callback func(key types.HexBytes, proof *CensusProof, err error) error,
+) (types.HexBytes, error) {
+	resp, err := c.do("POST", &api.CensusProofsRequest{Keys: keys}, nil,
+		"censuses", censusID.String(), "proofs")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s: %d (%s)", errCodeNot200, resp.StatusCode, body)
+	}
+	root, err := hex.DecodeString(resp.Header.Get("X-Census-Root"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid census root: %w", err)
+	}
+	dec := json.NewDecoder(resp.Body)
+	for i := range keys {
+		proof := &api.CensusBatchProof{}
+		if err := dec.Decode(proof); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("missing census proofs, got %d of %d", i, len(keys))
+			}
+			return nil, fmt.Errorf("could not decode census proof: %w", err)
+		}
+		if proof.Error != "" {
+			if err := callback(keys[i], nil, errors.New(proof.Error)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		cp := &CensusProof{
+			Proof:     proof.Proof,
+			LeafValue: proof.Value,
+			Siblings:  proof.Siblings,
+		}
+		if proof.Weight != nil {
+			cp.LeafWeight = proof.Weight.MathBigInt()
+		} else {
+			cp.LeafWeight = new(big.Int).SetUint64(1)
+		}
+		if err := callback(keys[i], cp, nil); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
@@ -29,32 +29,31 @@ const (
 	HTTPDELETE = "DELETE"
 
 	errCodeNot200 = "API error"
+
+	// DefaultTxValidity is the default validity window of the transactions
+	// signed by the client, after which they cannot be included in a block.
+	DefaultTxValidity = time.Hour
 )
 
 // HTTPclient is the Vocdoni API HTTP client.
 type HTTPclient struct {
 	c       *http.Client
 	token   *uuid.UUID
-	addr    *url.URL
+	gw      *gatewayPool
+	cspAddr *url.URL
 	account *ethereum.SignKeys
 	chainID string
 	circuit circuit.ZkCircuitConfig
 	zkAddr  *zk.ZkAddress
+	// txValidity is the validity window of the signed transactions
+	txValidity time.Duration
 }
 
-// NewHTTPclient creates a new HTTP(s) API Vocdoni client.
+// NewHTTPclient creates a new HTTP(s) API Vocdoni client. The requests are not
+// retried, use NewHTTPclientWithGateways for a client with retries and failover.
 func NewHTTPclient(addr *url.URL, bearerToken *uuid.UUID) (*HTTPclient, error) {
-	tr := &http.Transport{
-		IdleConnTimeout:    10 * time.Second,
-		DisableCompression: false,
-		WriteBufferSize:    1 * 1024 * 1024, // 1 MiB
-		ReadBufferSize:     1 * 1024 * 1024, // 1 MiB
-	}
-	c := &HTTPclient{
-		c:     &http.Client{Transport: tr, Timeout: time.Second * 8},
-		token: bearerToken,
-		addr:  addr,
-	}
+	c := newHTTPclient(bearerToken)
+	c.gw = newGatewayPool([]*url.URL{addr}, GatewayOptions{})
 	data, status, err := c.Request(HTTPGET, nil, "chain", "info")
 	if err != nil {
 		return nil, err
@@ -66,16 +65,35 @@ func NewHTTPclient(addr *url.URL, bearerToken *uuid.UUID) (*HTTPclient, error) {
 	if err := json.Unmarshal(data, info); err != nil {
 		return nil, fmt.Errorf("cannot get chain ID from API server")
 	}
-	c.chainID = info.ID
+	if err := c.setChainInfo(info); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
 
-	// Get the default circuit config
+func newHTTPclient(bearerToken *uuid.UUID) *HTTPclient {
+	tr := &http.Transport{
+		IdleConnTimeout:    10 * time.Second,
+		DisableCompression: false,
+		WriteBufferSize:    1 * 1024 * 1024, // 1 MiB
+		ReadBufferSize:     1 * 1024 * 1024, // 1 MiB
+	}
+	return &HTTPclient{
+		c:          &http.Client{Transport: tr, Timeout: time.Second * 8},
+		token:      bearerToken,
+		txValidity: DefaultTxValidity,
+	}
+}
+
+// setChainInfo sets the chain ID and the default circuit config of the chain.
+func (c *HTTPclient) setChainInfo(info *api.ChainInfo) error {
+	c.chainID = info.ID
 	circuitConf, exists := circuit.CircuitsConfigurations[info.CircuitConfigurationTag]
 	if !exists {
-		return nil, fmt.Errorf("empty or wrong circui configuration tag provided")
+		return fmt.Errorf("empty or wrong circui configuration tag provided")
 	}
 	c.circuit = circuitConf
-
-	return c, nil
+	return nil
 }
 
 // ChainID returns the chain identifier name in which the API backend is connected.
@@ -83,6 +101,12 @@ func (c *HTTPclient) ChainID() string {
 	return c.chainID
 }
 
+// SetTxValidity sets the validity window of the transactions signed by the
+// client, DefaultTxValidity by default. Zero disables the expiry.
+func (c *HTTPclient) SetTxValidity(d time.Duration) {
+	c.txValidity = d
+}
+
 // SetAccount sets the Vocdoni account used for signing transactions and assign
 // a new ZkAddress based on the provided private key account.
 func (c *HTTPclient) SetAccount(accountPrivateKey string) error {
@@ -123,9 +147,10 @@ func (c *HTTPclient) SetAuthToken(token *uuid.UUID) {
 	c.token = token
 }
 
-// SetHostAddr configures the host address of the API server.
+// SetHostAddr configures the host address of the API server, replacing the
+// gateways of the client.
 func (c *HTTPclient) SetHostAddr(addr *url.URL) error {
-	c.addr = addr
+	c.gw = newGatewayPool([]*url.URL{addr}, c.gw.opts)
 	data, status, err := c.Request(HTTPGET, nil, "chain", "info")
 	if err != nil {
 		return err
@@ -145,15 +170,70 @@ func (c *HTTPclient) SetHostAddr(addr *url.URL) error {
 // Method is either GET or POST. If POST, a JSON struct should be attached.  Returns the response,
 // the status code and an error.
 func (c *HTTPclient) Request(method string, jsonBody any, urlPath ...string) ([]byte, int, error) {
-	body, err := json.Marshal(jsonBody)
+	return c.RequestWithQuery(method, jsonBody, nil, urlPath...)
+}
+
+// RequestWithQuery performs a request like Request, adding the query parameters to the URL.
+func (c *HTTPclient) RequestWithQuery(method string, jsonBody any, query url.Values,
+	urlPath ...string,
+) ([]byte, int, error) {
+	resp, err := c.do(method, jsonBody, query, urlPath...)
 	if err != nil {
 		return nil, 0, err
 	}
-	u, err := url.Parse(c.addr.String())
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, 0, err
 	}
+	return data, resp.StatusCode, nil
+}
+
+// do performs the request and returns the response, whose body must be closed by the caller.
+// The request is retried on the next gateway if the gateway in use fails, as configured
+// by the GatewayOptions of the client.
+func (c *HTTPclient) do(method string, jsonBody any, query url.Values, urlPath ...string) (*http.Response, error) {
+	backoff := c.gw.opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		index, addr := c.gw.currentGateway()
+		resp, err := c.doGateway(addr, method, jsonBody, query, urlPath...)
+		var failure error
+		switch {
+		case err != nil && (method == HTTPGET || isDialError(err)):
+			failure = err
+		case err == nil && method == HTTPGET && isUnavailableStatus(resp.StatusCode):
+			failure = fmt.Errorf("%s: %d", errCodeNot200, resp.StatusCode)
+		}
+		if failure == nil {
+			return resp, err
+		}
+		c.gw.fail(index, failure)
+		if attempt >= c.gw.opts.MaxRetries {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		log.Debugw("retrying request", "path", path.Join(urlPath...), "attempt", attempt+1, "backoff", backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// doGateway performs the request on the given gateway.
+func (c *HTTPclient) doGateway(addr *url.URL, method string, jsonBody any, query url.Values,
+	urlPath ...string,
+) (*http.Response, error) {
+	body, err := json.Marshal(jsonBody)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(addr.String())
+	if err != nil {
+		return nil, err
+	}
 	u.Path = path.Join(u.Path, path.Join(urlPath...))
+	u.RawQuery = query.Encode()
 	headers := http.Header{}
 	if c.token != nil {
 		headers = http.Header{
@@ -164,7 +244,7 @@ func (c *HTTPclient) Request(method string, jsonBody any, urlPath ...string) ([]
 	}
 
 	log.Debugw("http request", "type", method, "path", u.Path, "body", jsonBody)
-	resp, err := c.c.Do(&http.Request{
+	return c.c.Do(&http.Request{
 		Method: method,
 		URL:    u,
 		Header: headers,
@@ -175,12 +255,4 @@ func (c *HTTPclient) Request(method string, jsonBody any, urlPath ...string) ([]
 			return io.NopCloser(bytes.NewBuffer(body))
 		}(),
 	})
-	if err != nil {
-		return nil, 0, err
-	}
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, 0, err
-	}
-	return data, resp.StatusCode, nil
 }
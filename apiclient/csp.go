@@ -0,0 +1,185 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"strconv"
+
+	blind "github.com/arnaucube/go-blindsecp256k1"
+	"github.com/google/uuid"
+	"go.vocdoni.io/dvote/api/csp"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/proto/build/go/models"
+	"google.golang.org/protobuf/proto"
+)
+
+// SetCSPHostAddr configures the address of the API server that runs the CSP,
+// if it is not the same than the host address. The address must include the
+// API base path, as the host address does.
+func (c *HTTPclient) SetCSPHostAddr(addr *url.URL) {
+	c.cspAddr = addr
+}
+
+// cspRequest performs a request like Request, to the CSP host address.
+func (c *HTTPclient) cspRequest(method string, jsonBody any, urlPath ...string) ([]byte, int, error) {
+	if c.cspAddr == nil {
+		return c.Request(method, jsonBody, append([]string{"csp"}, urlPath...)...)
+	}
+	resp, err := c.doGateway(c.cspAddr, method, jsonBody, nil, append([]string{"csp"}, urlPath...)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, resp.StatusCode, nil
+}
+
+// CSPInfo returns the public information of the CSP, including its public key
+// which is the census root of its elections.
+func (c *HTTPclient) CSPInfo() (*csp.Info, error) {
+	resp, code, err := c.cspRequest("GET", nil, "info")
+	if err != nil {
+		return nil, err
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("%s: %d (%s)", errCodeNot200, code, resp)
+	}
+	info := &csp.Info{}
+	if err := json.Unmarshal(resp, info); err != nil {
+		return nil, fmt.Errorf("could not unmarshal response: %w", err)
+	}
+	return info, nil
+}
+
+// CSPAuth performs an authentication step with the CSP for the election. The
+// signType is csp.SignatureTypeBlind or csp.SignatureTypeECDSA. The authToken
+// must be nil on the first step, and the one returned by the previous step on
+// the next ones.
+func (c *HTTPclient) CSPAuth(electionID types.HexBytes, signType string, step int,
+	authToken *uuid.UUID, authData []string,
+) (*csp.AuthResponse, error) {
+	resp, code, err := c.cspRequest("POST", &csp.AuthRequest{
+		AuthToken: authToken,
+		AuthData:  authData,
+	}, "elections", electionID.String(), signType, "auth", strconv.Itoa(step))
+	if err != nil {
+		return nil, err
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("%s: %d (%s)", errCodeNot200, code, resp)
+	}
+	authResp := &csp.AuthResponse{}
+	if err := json.Unmarshal(resp, authResp); err != nil {
+		return nil, fmt.Errorf("could not unmarshal response: %w", err)
+	}
+	return authResp, nil
+}
+
+// CSPGenProof gets the CSP signature of the client account for the election,
+// using the response of the last authentication step, and returns the CSP
+// proof (a protobuf encoded models.ProofCA) to be used as VoteData.ProofCSP.
+func (c *HTTPclient) CSPGenProof(electionID types.HexBytes, signType string,
+	auth *csp.AuthResponse,
+) (types.HexBytes, error) {
+	if c.account == nil {
+		return nil, fmt.Errorf("no account configured")
+	}
+	if auth == nil || !auth.Authenticated {
+		return nil, fmt.Errorf("not authenticated with the CSP")
+	}
+	proofType, err := csp.ProofType(signType)
+	if err != nil {
+		return nil, err
+	}
+	bundle := &models.CAbundle{
+		ProcessId: electionID,
+		Address:   c.account.Address().Bytes(),
+	}
+	bundleBytes, err := proto.Marshal(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	var signature []byte
+	switch signType {
+	case csp.SignatureTypeBlind:
+		signerR, err := blind.NewPointFromBytes(auth.TokenR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSP R point: %w", err)
+		}
+		m := new(big.Int).SetBytes(ethereum.HashRaw(bundleBytes))
+		// the CSP requires the blinded message to be 32 bytes long
+		var mBlinded *big.Int
+		var secret *blind.UserSecretData
+		for mBlinded == nil || len(mBlinded.Bytes()) != 32 {
+			if mBlinded, secret, err = blind.Blind(m, signerR); err != nil {
+				return nil, err
+			}
+		}
+		sBlind, err := c.cspSign(electionID, signType, auth.AuthToken, mBlinded.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		signature = blind.Unblind(new(big.Int).SetBytes(sBlind), secret).BytesUncompressed()
+	default:
+		if signature, err = c.cspSign(electionID, signType, auth.AuthToken, bundleBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	return proto.Marshal(&models.ProofCA{
+		Type:      proofType,
+		Bundle:    bundle,
+		Signature: signature,
+	})
+}
+
+// CSPProof authenticates the client account with the CSP for the election,
+// sending the authData of each step, and returns the CSP proof (a protobuf
+// encoded models.ProofCA) to be used as VoteData.ProofCSP. If the data of a
+// step depends on the response of the previous one, CSPAuth and CSPGenProof
+// must be used instead.
+func (c *HTTPclient) CSPProof(electionID types.HexBytes, signType string,
+	authData ...[]string,
+) (types.HexBytes, error) {
+	var auth *csp.AuthResponse
+	for step, data := range authData {
+		var token *uuid.UUID
+		if auth != nil {
+			token = auth.AuthToken
+		}
+		var err error
+		if auth, err = c.CSPAuth(electionID, signType, step, token, data); err != nil {
+			return nil, err
+		}
+	}
+	return c.CSPGenProof(electionID, signType, auth)
+}
+
+// cspSign requests the CSP signature of the payload.
+func (c *HTTPclient) cspSign(electionID types.HexBytes, signType string,
+	authToken *uuid.UUID, payload []byte,
+) ([]byte, error) {
+	resp, code, err := c.cspRequest("POST", &csp.SignRequest{
+		AuthToken: authToken,
+		Payload:   payload,
+	}, "elections", electionID.String(), signType, "sign")
+	if err != nil {
+		return nil, err
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("%s: %d (%s)", errCodeNot200, code, resp)
+	}
+	signResp := &csp.SignResponse{}
+	if err := json.Unmarshal(resp, signResp); err != nil {
+		return nil, fmt.Errorf("could not unmarshal response: %w", err)
+	}
+	return signResp.Signature, nil
+}
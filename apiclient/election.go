@@ -53,15 +53,11 @@ func (c *HTTPclient) NewElectionRaw(process *models.Process) (types.HexBytes, er
 	if err != nil {
 		return nil, err
 	}
-	signedTxb, err := c.account.SignVocdoniTx(txb, c.chainID)
-	if err != nil {
+	signedTx := &models.SignedTx{Tx: txb}
+	if err := c.signTx(signedTx); err != nil {
 		return nil, err
 	}
-	stx, err := proto.Marshal(
-		&models.SignedTx{
-			Tx:        txb,
-			Signature: signedTxb,
-		})
+	stx, err := proto.Marshal(signedTx)
 	if err != nil {
 		return nil, err
 	}
@@ -227,15 +223,11 @@ func (c *HTTPclient) NewElection(description *api.ElectionDescription) (types.He
 	if err != nil {
 		return nil, err
 	}
-	signedTxb, err := c.account.SignVocdoniTx(txb, c.chainID)
-	if err != nil {
+	signedTx := &models.SignedTx{Tx: txb}
+	if err := c.signTx(signedTx); err != nil {
 		return nil, err
 	}
-	stx, err := proto.Marshal(
-		&models.SignedTx{
-			Tx:        txb,
-			Signature: signedTxb,
-		})
+	stx, err := proto.Marshal(signedTx)
 	if err != nil {
 		return nil, err
 	}
@@ -301,15 +293,11 @@ func (c *HTTPclient) SetElectionStatus(electionID types.HexBytes, status string)
 	if err != nil {
 		return nil, err
 	}
-	signedTxb, err := c.account.SignVocdoniTx(txb, c.chainID)
-	if err != nil {
+	signedTx := &models.SignedTx{Tx: txb}
+	if err := c.signTx(signedTx); err != nil {
 		return nil, err
 	}
-	stx, err := proto.Marshal(
-		&models.SignedTx{
-			Tx:        txb,
-			Signature: signedTxb,
-		})
+	stx, err := proto.Marshal(signedTx)
 	if err != nil {
 		return nil, err
 	}
@@ -350,9 +338,18 @@ func (c *HTTPclient) ElectionVoteCount(electionID types.HexBytes) (uint32, error
 	return votes.Count, nil
 }
 
-// ElectionResults returns the election results given its ID.
+// ElectionResults returns the election results given its ID. If the client
+// cross-checks the reads (GatewayOptions.CrossCheck), a *GatewayDisagreement
+// error is returned when the healthy gateways return different results.
 func (c *HTTPclient) ElectionResults(electionID types.HexBytes) (*api.ElectionResults, error) {
-	resp, code, err := c.Request("GET", nil, "elections", electionID.String(), "scrutiny")
+	var resp []byte
+	var code int
+	var err error
+	if c.gw.opts.CrossCheck {
+		resp, code, err = c.CrossCheckRequest("elections", electionID.String(), "scrutiny")
+	} else {
+		resp, code, err = c.Request("GET", nil, "elections", electionID.String(), "scrutiny")
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,192 @@
+package electionspec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/api/censusdb"
+	"go.vocdoni.io/dvote/apiclient"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/types"
+)
+
+// censusJobTimeout is the maximum time to wait for the participants of the
+// census CSV file to be added.
+const censusJobTimeout = 30 * time.Minute
+
+// State holds the identifiers of the census and the election created by
+// Apply. It is saved after each step, so Apply skips the steps already done
+// when it is run again.
+type State struct {
+	CensusID    types.HexBytes `json:"censusId,omitempty"`
+	CensusJobID string         `json:"censusJobId,omitempty"`
+	CensusRoot  types.HexBytes `json:"censusRoot,omitempty"`
+	CensusURI   string         `json:"censusUri,omitempty"`
+	CensusSize  uint64         `json:"censusSize,omitempty"`
+	ElectionID  types.HexBytes `json:"electionId,omitempty"`
+}
+
+// LoadState reads the state file at path. An empty state is returned if the
+// file does not exist.
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := &State{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("cannot decode state file %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// Save writes the state file at path, replacing the previous one atomically.
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Apply creates the election of the specification with the account of the
+// client: it creates and publishes the census, if needed, and sends the
+// NewProcess transaction along with the election metadata. The progress is
+// recorded in the state file at statePath after each step, and the steps
+// recorded by a previous run are skipped. Apply returns once the election
+// transaction is accepted; it can be waited for with WaitUntilElectionCreated.
+func Apply(cli *apiclient.HTTPclient, spec *Spec, statePath string) (*State, error) {
+	state, err := LoadState(statePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(state.ElectionID) > 0 {
+		log.Infow("election already created", "electionId", state.ElectionID)
+		return state, nil
+	}
+	save := func() error {
+		if err := state.Save(statePath); err != nil {
+			return fmt.Errorf("cannot save state file: %w", err)
+		}
+		return nil
+	}
+
+	census := api.CensusTypeDescription{
+		Type:      spec.Census.Type,
+		Size:      spec.Census.Size,
+		URL:       spec.Census.URL,
+		PublicKey: spec.Census.PublicKey,
+	}
+	if spec.Census.Source() != CensusSourceCSP {
+		if err := applyCensus(cli, spec, state, save); err != nil {
+			return state, err
+		}
+		census.RootHash = state.CensusRoot
+		census.URL = state.CensusURI
+		if census.Size == 0 {
+			census.Size = state.CensusSize
+		}
+	}
+
+	description, err := spec.ElectionDescription(census, time.Now())
+	if err != nil {
+		return state, err
+	}
+	electionID, err := cli.NewElection(description)
+	if len(electionID) > 0 {
+		// the election transaction was sent, even if the metadata could not be
+		// published, so it is recorded to not create the election twice
+		state.ElectionID = electionID
+		if err := save(); err != nil {
+			return state, err
+		}
+	}
+	if err != nil {
+		return state, fmt.Errorf("cannot create election: %w", err)
+	}
+	log.Infow("election created", "electionId", electionID)
+	return state, nil
+}
+
+// applyCensus creates and publishes the census of the specification, calling
+// save after each step.
+func applyCensus(cli *apiclient.HTTPclient, spec *Spec, state *State, save func() error) error {
+	if len(state.CensusRoot) > 0 {
+		return nil
+	}
+	if len(state.CensusID) == 0 {
+		if spec.Census.Source() == CensusSourceID {
+			state.CensusID = spec.Census.CensusID
+		} else {
+			censusID, err := cli.NewCensus(spec.Census.Type)
+			if err != nil {
+				return fmt.Errorf("cannot create census: %w", err)
+			}
+			state.CensusID = censusID
+			log.Infow("census created", "censusId", censusID)
+		}
+		if err := save(); err != nil {
+			return err
+		}
+	}
+
+	if spec.Census.Source() == CensusSourceCSV {
+		upload := true
+		if state.CensusJobID != "" {
+			job, err := cli.CensusJob(state.CensusID, state.CensusJobID)
+			if err != nil {
+				return fmt.Errorf("cannot get census job: %w", err)
+			}
+			// the upload is resumed, unless the job was already started
+			upload = job.Status == censusdb.JobStatusUploading
+		}
+		if upload {
+			job, err := cli.CensusUploadCSVFile(state.CensusID, spec.CSVPath(), state.CensusJobID)
+			if err != nil {
+				return fmt.Errorf("cannot upload census participants: %w", err)
+			}
+			state.CensusJobID = job.JobID
+			if err := save(); err != nil {
+				return err
+			}
+		}
+		if _, err := cli.CensusWaitJob(state.CensusID, state.CensusJobID, censusJobTimeout); err != nil {
+			return err
+		}
+	}
+
+	size, err := cli.CensusSize(state.CensusID)
+	if err != nil {
+		return fmt.Errorf("cannot get census size: %w", err)
+	}
+	if size == 0 {
+		return fmt.Errorf("census %s is empty", state.CensusID)
+	}
+	root, uri, err := cli.CensusPublish(state.CensusID)
+	if err != nil {
+		return fmt.Errorf("cannot publish census: %w", err)
+	}
+	state.CensusRoot, state.CensusURI, state.CensusSize = root, uri, size
+	log.Infow("census published", "censusId", state.CensusID, "root", root, "uri", uri, "size", size)
+	return save()
+}
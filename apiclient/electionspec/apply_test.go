@@ -0,0 +1,106 @@
+package electionspec
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/google/uuid"
+	"go.vocdoni.io/dvote/apiclient"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain/state"
+	"go.vocdoni.io/dvote/vocone"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+func TestPlanApply(t *testing.T) {
+	c := qt.New(t)
+	dir := t.TempDir()
+
+	keymng := ethereum.SignKeys{}
+	qt.Assert(t, keymng.Generate(), qt.IsNil)
+	account := ethereum.SignKeys{}
+	qt.Assert(t, account.Generate(), qt.IsNil)
+
+	vc, err := vocone.NewVocone(filepath.Join(dir, "vocone"), &keymng)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, vc.SetBulkTxCosts(10, true), qt.IsNil)
+	vc.SetBlockTimeTarget(time.Millisecond * 500)
+	go vc.Start()
+	port := 13000 + util.RandomInt(0, 2000)
+	_, err = vc.EnableAPI("127.0.0.1", port, "/api")
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, vc.CreateAccount(account.Address(), &state.Account{
+		Account: models.Account{Balance: 1000},
+	}), qt.IsNil)
+	time.Sleep(time.Second * 2)
+
+	u, err := url.Parse(fmt.Sprintf("http://127.0.0.1:%d/api", port))
+	qt.Assert(t, err, qt.IsNil)
+	token := uuid.New()
+	cli, err := apiclient.NewHTTPclient(u, &token)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, cli.SetAccount(fmt.Sprintf("%x", account.PrivateKey())), qt.IsNil)
+
+	// the specification starts the election once created
+	specPath := filepath.Join(dir, "election.yaml")
+	qt.Assert(t, os.WriteFile(specPath,
+		[]byte(strings.Replace(testSpecYAML, "startDate: 2030-01-01T10:00:00Z\n", "", 1)), 0o600), qt.IsNil)
+	var csv strings.Builder
+	csv.WriteString("key,weight\n")
+	for i := 0; i < 10; i++ {
+		voter := ethereum.SignKeys{}
+		qt.Assert(t, voter.Generate(), qt.IsNil)
+		fmt.Fprintf(&csv, "%s,%d\n", voter.Address().Hex(), i+1)
+	}
+	qt.Assert(t, os.WriteFile(filepath.Join(dir, "participants.csv"), []byte(csv.String()), 0o600), qt.IsNil)
+	spec, err := Load(specPath)
+	qt.Assert(t, err, qt.IsNil)
+	statePath := filepath.Join(dir, "election.state.json")
+
+	plan, err := NewPlan(cli, spec, nil, DefaultPriceCapacity)
+	c.Assert(err, qt.IsNil)
+	c.Assert(plan.Account, qt.Equals, account.Address())
+	c.Assert(plan.Balance, qt.Equals, uint64(1000))
+	c.Assert(plan.CensusSize, qt.Equals, uint64(10))
+	c.Assert(plan.NewProcessCost, qt.Equals, uint64(10))
+	c.Assert(plan.EstimatedPrice >= plan.NewProcessCost, qt.IsTrue)
+	c.Assert(plan.EndBlock > plan.StartBlock, qt.IsTrue)
+	c.Assert(plan.Steps, qt.HasLen, 5)
+	c.Assert(plan.Warnings, qt.HasLen, 0)
+
+	st, err := Apply(cli, spec, statePath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(st.CensusSize, qt.Equals, uint64(10))
+	c.Assert(st.CensusRoot, qt.Not(qt.HasLen), 0)
+	c.Assert(st.ElectionID, qt.Not(qt.HasLen), 0)
+	saved, err := LoadState(statePath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(saved, qt.DeepEquals, st)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+	election, err := cli.WaitUntilElectionCreated(ctx, st.ElectionID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(election.Census.CensusRoot, qt.DeepEquals, st.CensusRoot)
+	c.Assert(election.Census.MaxCensusSize, qt.Equals, uint64(10))
+	c.Assert(election.Metadata, qt.IsNotNil)
+	c.Assert(election.Metadata.Title["default"], qt.Equals, "Budget 2027")
+	c.Assert(election.Metadata.Questions[0].Choices, qt.HasLen, 3)
+
+	// applying the specification again does not create another election
+	again, err := Apply(cli, spec, statePath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(again.ElectionID, qt.DeepEquals, st.ElectionID)
+	plan, err = NewPlan(cli, spec, again, DefaultPriceCapacity)
+	c.Assert(err, qt.IsNil)
+	c.Assert(plan.Steps, qt.HasLen, 0)
+	c.Assert(plan.Warnings, qt.HasLen, 1)
+}
@@ -0,0 +1,144 @@
+package electionspec
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.vocdoni.io/dvote/apiclient"
+	"go.vocdoni.io/dvote/vochain/transaction/electionprice"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+// DefaultPriceCapacity is the chain capacity used to estimate the election
+// price with the electionprice calculator.
+const DefaultPriceCapacity = 2000
+
+// Plan describes the election that Apply would create with a specification,
+// the steps still to be done and their costs.
+type Plan struct {
+	Account      common.Address `json:"account"`
+	Balance      uint64         `json:"balance"`
+	CensusSource string         `json:"censusSource"`
+	CensusType   string         `json:"censusType"`
+	// CensusSize is the maximum census size of the election. If the census is
+	// not created yet, it is the number of participants of the CSV file.
+	CensusSize uint64    `json:"censusSize"`
+	StartDate  time.Time `json:"startDate,omitempty"`
+	EndDate    time.Time `json:"endDate"`
+	StartBlock uint32    `json:"startBlock"`
+	EndBlock   uint32    `json:"endBlock"`
+	// Steps are the pending steps of Apply.
+	Steps []string `json:"steps"`
+	// NewProcessCost is the current cost of the NewProcess transaction.
+	NewProcessCost uint64 `json:"newProcessCost"`
+	// EstimatedPrice is the price of the election computed by the electionprice
+	// calculator, with NewProcessCost as the base price.
+	EstimatedPrice uint64 `json:"estimatedPrice"`
+	// Warnings are the issues which would make Apply fail.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// NewPlan checks the specification against the chain and returns the plan to
+// create its election with the account of the client, given the state of a
+// previous Apply (which may be empty). The price is estimated with the given
+// chain capacity, such as DefaultPriceCapacity.
+func NewPlan(cli *apiclient.HTTPclient, spec *Spec, state *State, capacity int) (*Plan, error) {
+	if state == nil {
+		state = &State{}
+	}
+	plan := &Plan{
+		Account:      cli.MyAddress(),
+		CensusSource: spec.Census.Source(),
+		CensusType:   spec.Census.Type,
+	}
+	if len(state.ElectionID) > 0 {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("election %s already created", state.ElectionID))
+	}
+
+	// census
+	switch {
+	case spec.Census.Size > 0:
+		plan.CensusSize = spec.Census.Size
+	case state.CensusSize > 0:
+		plan.CensusSize = state.CensusSize
+	case plan.CensusSource == CensusSourceCSV:
+		size, err := countCSVParticipants(spec.CSVPath())
+		if err != nil {
+			return nil, err
+		}
+		plan.CensusSize = size
+	case plan.CensusSource == CensusSourceID:
+		size, err := cli.CensusSize(spec.Census.CensusID)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get the size of census %s: %w", spec.Census.CensusID, err)
+		}
+		plan.CensusSize = size
+	}
+	if plan.CensusSize == 0 {
+		plan.Warnings = append(plan.Warnings, "the census is empty")
+	}
+	if len(state.ElectionID) == 0 {
+		plan.Steps = pendingSteps(spec, state)
+	}
+
+	// dates
+	start, end, err := spec.Dates(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	plan.StartDate, plan.EndDate = start, end
+	info, err := cli.ChainInfo()
+	if err != nil {
+		return nil, err
+	}
+	plan.StartBlock = info.Height
+	if !start.IsZero() {
+		if plan.StartBlock, err = cli.DateToHeight(start); err != nil {
+			return nil, fmt.Errorf("unable to estimate startDate block height: %w", err)
+		}
+	}
+	if plan.EndBlock, err = cli.DateToHeight(end); err != nil {
+		return nil, fmt.Errorf("unable to estimate endDate block height: %w", err)
+	}
+
+	// costs
+	if plan.NewProcessCost, err = cli.TransactionCost(models.TxType_NEW_PROCESS); err != nil {
+		return nil, fmt.Errorf("cannot get the NewProcess transaction cost: %w", err)
+	}
+	calculator := electionprice.NewElectionPriceCalculator(uint32(plan.NewProcessCost), capacity,
+		electionprice.DefaultElectionPriceFactors)
+	plan.EstimatedPrice = calculator.Price(&electionprice.ElectionParameters{
+		MaxCensusSize:    int(plan.CensusSize),
+		ElectionDuration: int(plan.EndBlock - plan.StartBlock),
+		EncryptedVotes:   spec.ElectionType.SecretUntilTheEnd,
+		AnonymousVotes:   spec.ElectionType.Anonymous,
+		MaxVoteOverwrite: spec.VoteType.MaxVoteOverwrites,
+	})
+	acc, err := cli.Account("")
+	if err != nil {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("cannot get the account: %v", err))
+	} else {
+		plan.Balance = acc.Balance
+		if len(plan.Steps) > 0 && acc.Balance < plan.NewProcessCost {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+				"the account balance %d is lower than the NewProcess cost %d", acc.Balance, plan.NewProcessCost))
+		}
+	}
+	return plan, nil
+}
+
+// pendingSteps returns the steps of Apply which are not recorded in the state.
+func pendingSteps(spec *Spec, state *State) []string {
+	var steps []string
+	if spec.Census.Source() != CensusSourceCSP && len(state.CensusRoot) == 0 {
+		if len(state.CensusID) == 0 && spec.Census.Source() == CensusSourceCSV {
+			steps = append(steps, fmt.Sprintf("create %s census", spec.Census.Type))
+		}
+		if spec.Census.Source() == CensusSourceCSV {
+			steps = append(steps, fmt.Sprintf("add the participants of %s", spec.CSVPath()))
+		}
+		steps = append(steps, "publish census")
+	}
+	return append(steps, "upload election metadata", "send NewProcess transaction")
+}
@@ -0,0 +1,331 @@
+// Package electionspec defines declarative election specification files, which
+// describe an election and the source of its census in YAML or JSON, and
+// creates the elections they describe through the API client.
+//
+// A specification is checked and priced with NewPlan, and created with Apply,
+// which records the identifiers of the created census and election in a state
+// file, so an interrupted Apply can be run again to resume it.
+package electionspec
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/util"
+	"gopkg.in/yaml.v3"
+)
+
+// Census sources, exactly one of them must be given by a specification.
+const (
+	// CensusSourceCSV is a new census created with the participants of a CSV file.
+	CensusSourceCSV = "csv"
+	// CensusSourceID is an existing census of the API, which is published by Apply.
+	CensusSourceID = "censusId"
+	// CensusSourceCSP is a census of a credential service provider (CSP) key.
+	CensusSourceCSP = "publicKey"
+)
+
+// Spec is the declarative description of an election. Its fields follow the
+// ones of api.ElectionDescription, replacing the census description by the
+// source of the census.
+type Spec struct {
+	Title       api.LanguageString `json:"title"`
+	Description api.LanguageString `json:"description,omitempty"`
+	Header      string             `json:"header,omitempty"`
+	StreamURI   string             `json:"streamUri,omitempty"`
+	// StartDate is the date when the election starts. If empty, the election
+	// starts as soon as it is created.
+	StartDate time.Time `json:"startDate,omitempty"`
+	// EndDate is the date when the election ends. Either EndDate or Duration
+	// must be given.
+	EndDate time.Time `json:"endDate,omitempty"`
+	// Duration is the duration of the election from its start, as accepted by
+	// time.ParseDuration (such as "72h").
+	Duration     string           `json:"duration,omitempty"`
+	VoteType     api.VoteType     `json:"voteType"`
+	ElectionType api.ElectionType `json:"electionType"`
+	Questions    []api.Question   `json:"questions"`
+	Census       Census           `json:"census"`
+
+	// dir is the directory of the specification file, the relative CSV paths
+	// are resolved from it.
+	dir string
+}
+
+// Census is the census source of an election specification.
+type Census struct {
+	// Type is the census type: weighted (the default), zkweighted or csp. It
+	// is csp if PublicKey is given.
+	Type string `json:"type,omitempty"`
+	// CSV is the path of a CSV file with the participants of a new census, as
+	// accepted by apiclient.CensusUploadCSV. Relative paths are resolved from
+	// the directory of the specification file.
+	CSV string `json:"csv,omitempty"`
+	// CensusID is an existing census, which is published to create the election.
+	CensusID types.HexBytes `json:"censusId,omitempty"`
+	// PublicKey is the public key of the CSP.
+	PublicKey types.HexBytes `json:"publicKey,omitempty"`
+	// URL is the census URL of a CSP census.
+	URL string `json:"url,omitempty"`
+	// Size is the maximum census size of the election. If zero, the size of
+	// the census is used. It is required for CSP censuses.
+	Size uint64 `json:"size,omitempty"`
+}
+
+// Source returns the census source of the specification.
+func (c *Census) Source() string {
+	switch {
+	case c.CSV != "":
+		return CensusSourceCSV
+	case len(c.CensusID) > 0:
+		return CensusSourceID
+	case len(c.PublicKey) > 0:
+		return CensusSourceCSP
+	}
+	return ""
+}
+
+// Load reads and validates the specification file at path, in YAML or JSON.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	spec.dir = filepath.Dir(path)
+	return spec, nil
+}
+
+// Parse decodes and validates a specification in YAML or JSON. Relative CSV
+// paths are resolved from the current directory. The hexadecimal values must
+// be quoted in YAML, otherwise they are decoded as numbers.
+func Parse(data []byte) (*Spec, error) {
+	// JSON is a subset of YAML, so the document is decoded as YAML and
+	// converted to JSON, to decode it with the JSON tags of the API types
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("cannot decode specification: %w", err)
+	}
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode specification: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(jsonData))
+	dec.DisallowUnknownFields()
+	spec := &Spec{}
+	if err := dec.Decode(spec); err != nil {
+		return nil, fmt.Errorf("cannot decode specification: %w", err)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// Validate checks the specification and sets its defaults: the census type
+// and the choice values, which are the choice indexes if none is given.
+func (s *Spec) Validate() error {
+	if len(s.Title) == 0 {
+		return errors.New("missing title")
+	}
+	if s.EndDate.IsZero() == (s.Duration == "") {
+		return errors.New("either endDate or duration must be given")
+	}
+	if s.Duration != "" {
+		d, err := time.ParseDuration(s.Duration)
+		if err != nil {
+			return fmt.Errorf("invalid duration: %w", err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("invalid duration %s", s.Duration)
+		}
+	}
+	if !s.EndDate.IsZero() && !s.StartDate.IsZero() && !s.EndDate.After(s.StartDate) {
+		return errors.New("endDate must be after startDate")
+	}
+	if s.VoteType.MaxVoteOverwrites < 0 {
+		return errors.New("invalid voteType.maxVoteOverwrites")
+	}
+	if len(s.Questions) == 0 {
+		return errors.New("missing questions")
+	}
+	for i := range s.Questions {
+		q := &s.Questions[i]
+		if len(q.Title) == 0 {
+			return fmt.Errorf("missing title of question %d", i)
+		}
+		if len(q.Choices) < 2 {
+			return fmt.Errorf("question %d must have at least two choices", i)
+		}
+		explicitValues := false
+		for _, c := range q.Choices {
+			explicitValues = explicitValues || c.Value != 0
+		}
+		values := make(map[uint32]bool)
+		for j := range q.Choices {
+			if len(q.Choices[j].Title) == 0 {
+				return fmt.Errorf("missing title of choice %d of question %d", j, i)
+			}
+			if !explicitValues {
+				q.Choices[j].Value = uint32(j)
+			}
+			if values[q.Choices[j].Value] {
+				return fmt.Errorf("duplicated value %d in question %d", q.Choices[j].Value, i)
+			}
+			values[q.Choices[j].Value] = true
+		}
+	}
+	return s.validateCensus()
+}
+
+func (s *Spec) validateCensus() error {
+	c := &s.Census
+	sources := 0
+	for _, given := range []bool{c.CSV != "", len(c.CensusID) > 0, len(c.PublicKey) > 0} {
+		if given {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return errors.New("exactly one of census.csv, census.censusId or census.publicKey must be given")
+	}
+	if c.Type == "" {
+		c.Type = api.CensusTypeWeighted
+		if len(c.PublicKey) > 0 {
+			c.Type = api.CensusTypeCSP
+		}
+	}
+	switch c.Type {
+	case api.CensusTypeWeighted, api.CensusTypeZKWeighted:
+		if len(c.PublicKey) > 0 {
+			return fmt.Errorf("census.publicKey requires a %s census", api.CensusTypeCSP)
+		}
+	case api.CensusTypeCSP:
+		if len(c.PublicKey) == 0 {
+			return fmt.Errorf("a %s census requires census.publicKey", api.CensusTypeCSP)
+		}
+		if c.Size == 0 {
+			return fmt.Errorf("a %s census requires census.size", api.CensusTypeCSP)
+		}
+	default:
+		return fmt.Errorf("unknown census type %q", c.Type)
+	}
+	if c.URL != "" && c.Type != api.CensusTypeCSP {
+		return fmt.Errorf("census.url is only used by %s censuses", api.CensusTypeCSP)
+	}
+	if s.ElectionType.Anonymous && c.Type != api.CensusTypeZKWeighted {
+		return fmt.Errorf("anonymous elections require a %s census", api.CensusTypeZKWeighted)
+	}
+	return nil
+}
+
+// CSVPath returns the path of the census CSV file, resolved from the directory
+// of the specification file.
+func (s *Spec) CSVPath() string {
+	if s.Census.CSV == "" || filepath.IsAbs(s.Census.CSV) {
+		return s.Census.CSV
+	}
+	return filepath.Join(s.dir, s.Census.CSV)
+}
+
+// Dates returns the start and end dates of the election if it is created at
+// now. The start date is zero if the election starts once created. An error
+// is returned if the election would end in the past.
+func (s *Spec) Dates(now time.Time) (time.Time, time.Time, error) {
+	end := s.EndDate
+	if s.Duration != "" {
+		d, err := time.ParseDuration(s.Duration)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		start := now
+		if !s.StartDate.IsZero() {
+			start = s.StartDate
+		}
+		end = start.Add(d)
+	}
+	if !end.After(now) {
+		return time.Time{}, time.Time{}, fmt.Errorf("the election end date %s is in the past", end)
+	}
+	if !s.StartDate.IsZero() && !s.StartDate.After(now) {
+		return time.Time{}, time.Time{}, fmt.Errorf("the election start date %s is in the past", s.StartDate)
+	}
+	return s.StartDate, end, nil
+}
+
+// ElectionDescription returns the description of the election, to be created
+// at now with the given census.
+func (s *Spec) ElectionDescription(census api.CensusTypeDescription, now time.Time) (*api.ElectionDescription, error) {
+	start, end, err := s.Dates(now)
+	if err != nil {
+		return nil, err
+	}
+	return &api.ElectionDescription{
+		Title:        s.Title,
+		Description:  s.Description,
+		Header:       s.Header,
+		StreamURI:    s.StreamURI,
+		StartDate:    start,
+		EndDate:      end,
+		VoteType:     s.VoteType,
+		ElectionType: s.ElectionType,
+		Questions:    s.Questions,
+		Census:       census,
+	}, nil
+}
+
+// countCSVParticipants checks the census CSV file of a specification and
+// returns its number of participants. The records are parsed like
+// apiclient.CensusUploadCSV does.
+func countCSVParticipants(path string) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	cr := csv.NewReader(f)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+	count := uint64(0)
+	for line := 0; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", path, err)
+		}
+		if _, err := hex.DecodeString(util.TrimHex(record[0])); err != nil {
+			if line == 0 {
+				continue // header
+			}
+			return 0, fmt.Errorf("%s: invalid key at line %d: %w", path, line+1, err)
+		}
+		if len(record) > 1 && strings.TrimSpace(record[1]) != "" {
+			if _, ok := new(big.Int).SetString(strings.TrimSpace(record[1]), 10); !ok {
+				return 0, fmt.Errorf("%s: invalid weight at line %d: %s", path, line+1, record[1])
+			}
+		}
+		count++
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("%s: no participants found", path)
+	}
+	return count, nil
+}
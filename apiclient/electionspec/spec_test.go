@@ -0,0 +1,125 @@
+package electionspec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/api"
+)
+
+const testSpecYAML = `
+title:
+  default: Budget 2027
+description:
+  default: Choose the budget priorities
+startDate: 2030-01-01T10:00:00Z
+duration: 72h
+voteType:
+  maxVoteOverwrites: 2
+electionType:
+  autostart: true
+  interruptible: true
+questions:
+  - title:
+      default: First priority
+    choices:
+      - title: {default: Parks}
+      - title: {default: Schools}
+      - title: {default: Roads}
+census:
+  csv: participants.csv
+`
+
+func TestParse(t *testing.T) {
+	c := qt.New(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "election.yaml")
+	qt.Assert(t, os.WriteFile(path, []byte(testSpecYAML), 0o600), qt.IsNil)
+
+	spec, err := Load(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(spec.Title["default"], qt.Equals, "Budget 2027")
+	c.Assert(spec.VoteType.MaxVoteOverwrites, qt.Equals, 2)
+	c.Assert(spec.ElectionType.Interruptible, qt.IsTrue)
+	c.Assert(spec.Census.Source(), qt.Equals, CensusSourceCSV)
+	c.Assert(spec.Census.Type, qt.Equals, api.CensusTypeWeighted)
+	c.Assert(spec.CSVPath(), qt.Equals, filepath.Join(dir, "participants.csv"))
+	// the choice values are the indexes if none is given
+	for i, choice := range spec.Questions[0].Choices {
+		c.Assert(choice.Value, qt.Equals, uint32(i))
+	}
+	start, end, err := spec.Dates(time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC))
+	c.Assert(err, qt.IsNil)
+	c.Assert(start, qt.Equals, time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC))
+	c.Assert(end, qt.Equals, time.Date(2030, 1, 4, 10, 0, 0, 0, time.UTC))
+	_, _, err = spec.Dates(time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC))
+	c.Assert(err, qt.IsNotNil)
+
+	// JSON specifications are decoded the same way
+	jsonSpec, err := Parse([]byte(`{
+		"title": {"default": "Budget 2027"},
+		"endDate": "2030-01-04T10:00:00Z",
+		"questions": [{"title": {"default": "First priority"}, "choices": [
+			{"title": {"default": "Parks"}, "value": 1},
+			{"title": {"default": "Schools"}, "value": 2}
+		]}],
+		"census": {"publicKey": "0x02aabbcc", "size": 100}
+	}`))
+	c.Assert(err, qt.IsNil)
+	c.Assert(jsonSpec.Census.Source(), qt.Equals, CensusSourceCSP)
+	c.Assert(jsonSpec.Census.Type, qt.Equals, api.CensusTypeCSP)
+	c.Assert(jsonSpec.Questions[0].Choices[1].Value, qt.Equals, uint32(2))
+	_, end, err = jsonSpec.Dates(time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC))
+	c.Assert(err, qt.IsNil)
+	c.Assert(end, qt.Equals, time.Date(2030, 1, 4, 10, 0, 0, 0, time.UTC))
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		spec string
+	}{
+		{"unknown field", `{"title": {"default": "a"}, "duration": "1h", "census": {"csv": "a.csv"},
+			"questions": [{"title": {"default": "q"}, "choices": [{"title": {"default": "a"}}, {"title": {"default": "b"}}]}],
+			"unknown": true}`},
+		{"missing title", `{"duration": "1h", "census": {"csv": "a.csv"},
+			"questions": [{"title": {"default": "q"}, "choices": [{"title": {"default": "a"}}, {"title": {"default": "b"}}]}]}`},
+		{"end date and duration", `{"title": {"default": "a"}, "duration": "1h", "endDate": "2030-01-01T00:00:00Z",
+			"census": {"csv": "a.csv"},
+			"questions": [{"title": {"default": "q"}, "choices": [{"title": {"default": "a"}}, {"title": {"default": "b"}}]}]}`},
+		{"one choice", `{"title": {"default": "a"}, "duration": "1h", "census": {"csv": "a.csv"},
+			"questions": [{"title": {"default": "q"}, "choices": [{"title": {"default": "a"}}]}]}`},
+		{"duplicated value", `{"title": {"default": "a"}, "duration": "1h", "census": {"csv": "a.csv"},
+			"questions": [{"title": {"default": "q"}, "choices": [{"title": {"default": "a"}, "value": 1},
+			{"title": {"default": "b"}, "value": 1}]}]}`},
+		{"two census sources", `{"title": {"default": "a"}, "duration": "1h",
+			"census": {"csv": "a.csv", "censusId": "0xaabb"},
+			"questions": [{"title": {"default": "q"}, "choices": [{"title": {"default": "a"}}, {"title": {"default": "b"}}]}]}`},
+		{"csp without size", `{"title": {"default": "a"}, "duration": "1h", "census": {"publicKey": "0xaabb"},
+			"questions": [{"title": {"default": "q"}, "choices": [{"title": {"default": "a"}}, {"title": {"default": "b"}}]}]}`},
+		{"anonymous weighted", `{"title": {"default": "a"}, "duration": "1h", "census": {"csv": "a.csv"},
+			"electionType": {"anonymous": true},
+			"questions": [{"title": {"default": "q"}, "choices": [{"title": {"default": "a"}}, {"title": {"default": "b"}}]}]}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.spec))
+			qt.Assert(t, err, qt.IsNotNil)
+		})
+	}
+}
+
+func TestCountCSVParticipants(t *testing.T) {
+	c := qt.New(t)
+	path := filepath.Join(t.TempDir(), "participants.csv")
+	qt.Assert(t, os.WriteFile(path, []byte("key,weight\n# comment\n0xaabb,10\nccdd\n"), 0o600), qt.IsNil)
+	count, err := countCSVParticipants(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(count, qt.Equals, uint64(2))
+
+	qt.Assert(t, os.WriteFile(path, []byte("aabb,10\nzz,1\n"), 0o600), qt.IsNil)
+	_, err = countCSVParticipants(path)
+	c.Assert(err, qt.IsNotNil)
+}
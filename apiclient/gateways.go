@@ -0,0 +1,464 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/proto/build/go/models"
+	"google.golang.org/protobuf/proto"
+)
+
+// GatewayOptions configures how the client uses its API gateways.
+type GatewayOptions struct {
+	// MaxRetries is the number of times a request is retried on the next
+	// gateway when the gateway is unreachable or unavailable. Only the GET
+	// requests are retried, unless the gateway could not be connected to,
+	// in which case the request was never received.
+	MaxRetries int
+	// RetryBackoff is the time to wait before the first retry, which is
+	// doubled on each retry.
+	RetryBackoff time.Duration
+	// RebroadcastBlocks is the number of blocks to wait for a transaction sent
+	// with BroadcastSignedTx to be included before sending it to the next gateway.
+	RebroadcastBlocks uint32
+	// CrossCheck enables comparing the election results between the healthy
+	// gateways. See CrossCheckRequest.
+	CrossCheck bool
+	// OnDisagreement, if not nil, is called when the gateways return different
+	// responses to a cross-checked request.
+	OnDisagreement func(*GatewayDisagreement)
+}
+
+// DefaultGatewayOptions are the options of the clients created by
+// NewHTTPclientWithGateways without options.
+var DefaultGatewayOptions = GatewayOptions{
+	MaxRetries:        3,
+	RetryBackoff:      500 * time.Millisecond,
+	RebroadcastBlocks: 3,
+}
+
+// GatewayStatus is the status of an API gateway, as of the last health check
+// or request.
+type GatewayStatus struct {
+	URL     string `json:"url"`
+	Current bool   `json:"current"`
+	Healthy bool   `json:"healthy"`
+	Height  uint32 `json:"height"`
+	Error   string `json:"error,omitempty"`
+}
+
+// GatewayDisagreement is the error returned when the gateways return
+// different responses to a cross-checked request.
+type GatewayDisagreement struct {
+	Path string
+	// Responses holds the response body of each gateway, by its URL. The
+	// bodies of the non-200 responses are prefixed by the status code.
+	Responses map[string][]byte
+}
+
+func (d *GatewayDisagreement) Error() string {
+	urls := make([]string, 0, len(d.Responses))
+	for u := range d.Responses {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	return fmt.Sprintf("gateways disagree on %s: %s", d.Path, strings.Join(urls, ", "))
+}
+
+type gateway struct {
+	addr    *url.URL
+	healthy bool
+	// otherChain is set if the gateway is on another chain, so it is never used
+	otherChain bool
+	height     uint32
+	err        error
+}
+
+// gatewayPool holds the gateways of a client, which is shared by its clones.
+type gatewayPool struct {
+	mu       sync.RWMutex
+	gateways []*gateway
+	current  int
+	opts     GatewayOptions
+}
+
+func newGatewayPool(addrs []*url.URL, opts GatewayOptions) *gatewayPool {
+	p := &gatewayPool{opts: opts}
+	for _, addr := range addrs {
+		// the gateways are healthy until a health check or request fails
+		p.gateways = append(p.gateways, &gateway{addr: addr, healthy: true})
+	}
+	return p
+}
+
+// currentGateway returns the index and address of the gateway in use.
+func (p *gatewayPool) currentGateway() (int, *url.URL) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.current, p.gateways[p.current].addr
+}
+
+// fail marks the gateway as unhealthy and, if it is the one in use, switches
+// to the next healthy gateway, or the next one if none is healthy.
+func (p *gatewayPool) fail(index int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	g := p.gateways[index]
+	g.healthy, g.err = false, err
+	log.Warnw("API gateway failed", "url", g.addr.String(), "error", err)
+	if index == p.current {
+		p.next()
+	}
+}
+
+// next switches to the next healthy gateway, or the next one of the chain if
+// none is healthy. It must be called with the lock held.
+func (p *gatewayPool) next() {
+	n := len(p.gateways)
+	for i := 1; i <= n; i++ {
+		if p.gateways[(p.current+i)%n].healthy {
+			p.current = (p.current + i) % n
+			return
+		}
+	}
+	for i := 1; i <= n; i++ {
+		if !p.gateways[(p.current+i)%n].otherChain {
+			p.current = (p.current + i) % n
+			return
+		}
+	}
+}
+
+// rotate switches to the next healthy gateway and returns its index.
+func (p *gatewayPool) rotate() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.next()
+	return p.current
+}
+
+// healthyGateways returns the indexes and addresses of the healthy gateways.
+func (p *gatewayPool) healthyGateways() ([]int, []*url.URL) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var indexes []int
+	var addrs []*url.URL
+	for i, g := range p.gateways {
+		if g.healthy {
+			indexes = append(indexes, i)
+			addrs = append(addrs, g.addr)
+		}
+	}
+	return indexes, addrs
+}
+
+// NewHTTPclientWithGateways creates a Vocdoni API client which uses several
+// gateways of the same chain, switching to the next one when a gateway fails.
+// The gateways are checked with CheckGateways, and an error is returned if
+// none is healthy. If opts is nil, DefaultGatewayOptions are used.
+func NewHTTPclientWithGateways(addrs []*url.URL, bearerToken *uuid.UUID,
+	opts *GatewayOptions,
+) (*HTTPclient, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("no API gateways given")
+	}
+	if opts == nil {
+		opts = &DefaultGatewayOptions
+	}
+	c := newHTTPclient(bearerToken)
+	c.gw = newGatewayPool(addrs, *opts)
+	info, err := c.checkGateways()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.setChainInfo(info); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Gateways returns the status of the API gateways of the client.
+func (c *HTTPclient) Gateways() []GatewayStatus {
+	c.gw.mu.RLock()
+	defer c.gw.mu.RUnlock()
+	status := make([]GatewayStatus, len(c.gw.gateways))
+	for i, g := range c.gw.gateways {
+		status[i] = GatewayStatus{
+			URL:     g.addr.String(),
+			Current: i == c.gw.current,
+			Healthy: g.healthy,
+			Height:  g.height,
+		}
+		if g.err != nil {
+			status[i].Error = g.err.Error()
+		}
+	}
+	return status
+}
+
+// CheckGateways checks the health of the API gateways, which must reply to
+// the chain info request with the chain ID of the client. If the gateway in
+// use is not healthy, the healthy one with the highest block height is used.
+// An error is returned if no gateway is healthy.
+func (c *HTTPclient) CheckGateways() error {
+	_, err := c.checkGateways()
+	return err
+}
+
+// checkGateways checks the health of the gateways, and returns the chain info
+// of the gateway in use. If the client has no chain ID yet, it is taken from
+// the first healthy gateway.
+func (c *HTTPclient) checkGateways() (*api.ChainInfo, error) {
+	c.gw.mu.RLock()
+	addrs := make([]*url.URL, len(c.gw.gateways))
+	for i, g := range c.gw.gateways {
+		addrs[i] = g.addr
+	}
+	c.gw.mu.RUnlock()
+
+	infos := make([]*api.ChainInfo, len(addrs))
+	errs := make([]error, len(addrs))
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		wg.Add(1)
+		go func(i int, addr *url.URL) {
+			defer wg.Done()
+			infos[i], errs[i] = c.gatewayChainInfo(addr)
+		}(i, addr)
+	}
+	wg.Wait()
+
+	chainID := c.chainID
+	for i := range addrs {
+		if errs[i] == nil && chainID == "" {
+			chainID = infos[i].ID
+		}
+		if errs[i] == nil && infos[i].ID != chainID {
+			errs[i] = fmt.Errorf("gateway is on chain %s instead of %s", infos[i].ID, chainID)
+		}
+	}
+
+	c.gw.mu.Lock()
+	defer c.gw.mu.Unlock()
+	best := -1
+	for i, g := range c.gw.gateways {
+		g.healthy, g.err = errs[i] == nil, errs[i]
+		g.otherChain = infos[i] != nil && infos[i].ID != chainID
+		if g.healthy {
+			g.height = infos[i].Height
+			if best < 0 || g.height > c.gw.gateways[best].height {
+				best = i
+			}
+		}
+	}
+	if best < 0 {
+		return nil, fmt.Errorf("no healthy API gateway: %w", errs[c.gw.current])
+	}
+	if !c.gw.gateways[c.gw.current].healthy {
+		log.Infow("switching API gateway", "url", c.gw.gateways[best].addr.String())
+		c.gw.current = best
+	}
+	return infos[c.gw.current], nil
+}
+
+// gatewayChainInfo requests the chain info to the gateway, without retries.
+func (c *HTTPclient) gatewayChainInfo(addr *url.URL) (*api.ChainInfo, error) {
+	resp, err := c.doGateway(addr, HTTPGET, nil, nil, "chain", "info")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %d (%s)", errCodeNot200, resp.StatusCode, data)
+	}
+	info := &api.ChainInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, fmt.Errorf("cannot decode chain info: %w", err)
+	}
+	return info, nil
+}
+
+// StartHealthChecks runs CheckGateways periodically until the context is done.
+func (c *HTTPclient) StartHealthChecks(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.CheckGateways(); err != nil {
+					log.Warnw("API gateways health check failed", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// isDialError returns true if the connection to the gateway could not be
+// established, so the request was not received.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isUnavailableStatus returns true for the status codes of a gateway which is
+// restarting or overloaded, or whose backend is not reachable.
+func isUnavailableStatus(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable ||
+		status == http.StatusGatewayTimeout
+}
+
+// BroadcastSignedTx sends a signed transaction and waits until it is included
+// in a block. If the transaction is not included within RebroadcastBlocks, it
+// is sent again to the next gateway, until the context is done. Returns the
+// transaction hash and reference.
+func (c *HTTPclient) BroadcastSignedTx(ctx context.Context, stx *models.SignedTx) (
+	types.HexBytes, *api.TransactionReference, error,
+) {
+	txData, err := proto.Marshal(stx)
+	if err != nil {
+		return nil, nil, err
+	}
+	hash := sha256.Sum256(txData)
+	if _, _, err := c.SendSignedTx(stx); err != nil {
+		return nil, nil, err
+	}
+	info, err := c.ChainInfo()
+	if err != nil {
+		return hash[:], nil, err
+	}
+	deadline := info.Height + c.gw.opts.RebroadcastBlocks
+	for {
+		if ref, err := c.TransactionReference(hash[:]); err == nil {
+			return hash[:], ref, nil
+		}
+		select {
+		case <-time.After(PollInterval):
+		case <-ctx.Done():
+			return hash[:], nil, fmt.Errorf("transaction %x not included: %w", hash, ctx.Err())
+		}
+		if info, err = c.ChainInfo(); err != nil {
+			continue
+		}
+		if c.gw.opts.RebroadcastBlocks == 0 || info.Height < deadline {
+			continue
+		}
+		_, prev := c.gw.currentGateway()
+		c.gw.rotate()
+		_, addr := c.gw.currentGateway()
+		log.Warnw("transaction not included, sending it again", "hash", fmt.Sprintf("%x", hash),
+			"height", info.Height, "from", prev.String(), "to", addr.String())
+		// the transaction might be already known by the gateway mempool
+		if _, _, err := c.SendSignedTx(stx); err != nil {
+			log.Debugw("cannot send transaction again", "hash", fmt.Sprintf("%x", hash), "error", err)
+		}
+		deadline = info.Height + c.gw.opts.RebroadcastBlocks
+	}
+}
+
+// CrossCheckRequest performs a GET request on all the healthy gateways and
+// returns the response of the gateway in use. If the gateways return
+// different status codes or JSON bodies, OnDisagreement is called and a
+// *GatewayDisagreement error is returned along with the response. The
+// gateways which cannot be reached are skipped.
+func (c *HTTPclient) CrossCheckRequest(urlPath ...string) ([]byte, int, error) {
+	indexes, addrs := c.gw.healthyGateways()
+	if len(addrs) < 2 {
+		return c.Request(HTTPGET, nil, urlPath...)
+	}
+	type response struct {
+		data   []byte
+		status int
+		err    error
+	}
+	responses := make([]response, len(addrs))
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		wg.Add(1)
+		go func(i int, addr *url.URL) {
+			defer wg.Done()
+			resp, err := c.doGateway(addr, HTTPGET, nil, nil, urlPath...)
+			if err != nil {
+				responses[i].err = err
+				return
+			}
+			defer resp.Body.Close()
+			responses[i].data, responses[i].err = io.ReadAll(resp.Body)
+			responses[i].status = resp.StatusCode
+		}(i, addr)
+	}
+	wg.Wait()
+
+	current, _ := c.gw.currentGateway()
+	ref := -1
+	for i := range responses {
+		if responses[i].err != nil {
+			c.gw.fail(indexes[i], responses[i].err)
+			continue
+		}
+		if ref < 0 || indexes[i] == current {
+			ref = i
+		}
+	}
+	if ref < 0 {
+		return nil, 0, fmt.Errorf("no gateway replied: %w", responses[0].err)
+	}
+	disagreement := &GatewayDisagreement{
+		Path:      "/" + strings.Join(urlPath, "/"),
+		Responses: make(map[string][]byte),
+	}
+	disagree := false
+	for i, r := range responses {
+		if r.err != nil {
+			continue
+		}
+		body := r.data
+		if r.status != http.StatusOK {
+			body = append([]byte(fmt.Sprintf("%d ", r.status)), r.data...)
+		}
+		disagreement.Responses[addrs[i].String()] = body
+		if r.status != responses[ref].status || !jsonEqual(r.data, responses[ref].data) {
+			disagree = true
+		}
+	}
+	if !disagree {
+		return responses[ref].data, responses[ref].status, nil
+	}
+	log.Warnw("API gateways disagree", "path", disagreement.Path, "gateways", len(disagreement.Responses))
+	if c.gw.opts.OnDisagreement != nil {
+		c.gw.opts.OnDisagreement(disagreement)
+	}
+	return responses[ref].data, responses[ref].status, disagreement
+}
+
+// jsonEqual compares two JSON documents, or their bytes if they are not JSON.
+func jsonEqual(a, b []byte) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,194 @@
+package apiclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/crypto/zk/circuit"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+// testChain is the chain shared by the test gateways, which advances one
+// block on each chain info request.
+type testChain struct {
+	mu     sync.Mutex
+	height uint32
+	mined  map[string]uint32
+}
+
+// testGateway is a fake API gateway of a testChain.
+type testGateway struct {
+	*httptest.Server
+	chain   *testChain
+	chainID string
+	// down makes the gateway reply with 503 Service Unavailable
+	down atomic.Bool
+	// dropTxs makes the gateway accept the transactions without including them
+	dropTxs bool
+	results string
+	posts   atomic.Int32
+}
+
+func newTestGateway(t *testing.T, chain *testChain, chainID string) *testGateway {
+	g := &testGateway{chain: chain, chainID: chainID, results: `{"results":[["1","2"]],"status":"RESULTS"}`}
+	g.Server = httptest.NewServer(http.StripPrefix("/v2", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		g.chain.mu.Lock()
+		defer g.chain.mu.Unlock()
+		switch {
+		case r.URL.Path == "/chain/info":
+			g.chain.height++
+			_ = json.NewEncoder(w).Encode(&api.ChainInfo{
+				ID:                      g.chainID,
+				Height:                  g.chain.height,
+				CircuitConfigurationTag: circuit.DefaultCircuitConfigurationTag,
+			})
+		case r.URL.Path == "/chain/transactions" && r.Method == HTTPPOST:
+			g.posts.Add(1)
+			tx := &api.Transaction{}
+			if err := json.NewDecoder(r.Body).Decode(tx); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			hash := sha256.Sum256(tx.Payload)
+			if !g.dropTxs {
+				g.chain.mined[hex.EncodeToString(hash[:])] = g.chain.height
+			}
+			_ = json.NewEncoder(w).Encode(&api.Transaction{Hash: hash[:]})
+		case strings.HasPrefix(r.URL.Path, "/chain/transactions/reference/"):
+			height, ok := g.chain.mined[strings.TrimPrefix(r.URL.Path, "/chain/transactions/reference/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(&api.TransactionReference{Height: height})
+		case strings.HasSuffix(r.URL.Path, "/scrutiny"):
+			_, _ = w.Write([]byte(g.results))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})))
+	t.Cleanup(g.Close)
+	return g
+}
+
+func (g *testGateway) url(t *testing.T) *url.URL {
+	u, err := url.Parse(g.URL + "/v2")
+	qt.Assert(t, err, qt.IsNil)
+	return u
+}
+
+func TestGatewaysFailover(t *testing.T) {
+	c := qt.New(t)
+	chain := &testChain{mined: make(map[string]uint32)}
+	gw1 := newTestGateway(t, chain, "test")
+	gw2 := newTestGateway(t, chain, "test")
+	other := newTestGateway(t, chain, "other")
+	gw1.down.Store(true)
+
+	opts := DefaultGatewayOptions
+	opts.RetryBackoff = time.Millisecond
+	cli, err := NewHTTPclientWithGateways([]*url.URL{gw1.url(t), gw2.url(t), other.url(t)}, nil, &opts)
+	c.Assert(err, qt.IsNil)
+	c.Assert(cli.ChainID(), qt.Equals, "test")
+	status := cli.Gateways()
+	c.Assert(status[0].Healthy, qt.IsFalse)
+	c.Assert(status[1].Healthy, qt.IsTrue)
+	c.Assert(status[1].Current, qt.IsTrue)
+	// the gateway of another chain is never used
+	c.Assert(status[2].Healthy, qt.IsFalse)
+	c.Assert(status[2].Error, qt.Contains, "other")
+
+	// the GET requests are retried on the next gateway
+	gw1.down.Store(false)
+	gw2.down.Store(true)
+	_, err = cli.ChainInfo()
+	c.Assert(err, qt.IsNil)
+	status = cli.Gateways()
+	c.Assert(status[0].Current, qt.IsTrue)
+	c.Assert(status[1].Healthy, qt.IsFalse)
+
+	// while the other requests are not, since they might have been received
+	gw1.down.Store(true)
+	gw2.down.Store(false)
+	_, _, err = cli.SendSignedTx(&models.SignedTx{Tx: []byte{1}})
+	c.Assert(err, qt.IsNotNil)
+	c.Assert(gw1.posts.Load()+gw2.posts.Load(), qt.Equals, int32(0))
+
+	// the health check recovers the gateways
+	c.Assert(cli.CheckGateways(), qt.IsNil)
+	status = cli.Gateways()
+	c.Assert(status[0].Healthy, qt.IsFalse)
+	c.Assert(status[1].Healthy, qt.IsTrue)
+	c.Assert(status[1].Current, qt.IsTrue)
+	gw2.down.Store(true)
+	c.Assert(cli.CheckGateways(), qt.IsNotNil)
+}
+
+func TestGatewaysRebroadcast(t *testing.T) {
+	c := qt.New(t)
+	chain := &testChain{mined: make(map[string]uint32)}
+	gw1 := newTestGateway(t, chain, "test")
+	gw1.dropTxs = true
+	gw2 := newTestGateway(t, chain, "test")
+
+	opts := DefaultGatewayOptions
+	opts.RebroadcastBlocks = 2
+	cli, err := NewHTTPclientWithGateways([]*url.URL{gw1.url(t), gw2.url(t)}, nil, &opts)
+	c.Assert(err, qt.IsNil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	stx := &models.SignedTx{Tx: []byte("tx"), Signature: []byte("signature")}
+	hash, ref, err := cli.BroadcastSignedTx(ctx, stx)
+	c.Assert(err, qt.IsNil)
+	c.Assert(ref, qt.IsNotNil)
+	c.Assert(gw1.posts.Load(), qt.Equals, int32(1))
+	c.Assert(gw2.posts.Load(), qt.Equals, int32(1))
+	c.Assert(chain.mined[hash.String()], qt.Equals, ref.Height)
+}
+
+func TestGatewaysCrossCheck(t *testing.T) {
+	c := qt.New(t)
+	chain := &testChain{mined: make(map[string]uint32)}
+	gw1 := newTestGateway(t, chain, "test")
+	gw2 := newTestGateway(t, chain, "test")
+	// the same results, with other formatting
+	gw2.results = `{"status": "RESULTS", "results": [["1", "2"]]}`
+
+	var reported []*GatewayDisagreement
+	opts := DefaultGatewayOptions
+	opts.CrossCheck = true
+	opts.OnDisagreement = func(d *GatewayDisagreement) { reported = append(reported, d) }
+	cli, err := NewHTTPclientWithGateways([]*url.URL{gw1.url(t), gw2.url(t)}, nil, &opts)
+	c.Assert(err, qt.IsNil)
+
+	results, err := cli.ElectionResults([]byte{1})
+	c.Assert(err, qt.IsNil)
+	c.Assert(results.Results, qt.HasLen, 1)
+	c.Assert(reported, qt.HasLen, 0)
+
+	gw2.results = `{"results":[["2","1"]],"status":"RESULTS"}`
+	_, err = cli.ElectionResults([]byte{1})
+	var disagreement *GatewayDisagreement
+	c.Assert(errors.As(err, &disagreement), qt.IsTrue)
+	c.Assert(disagreement.Path, qt.Equals, "/elections/01/scrutiny")
+	c.Assert(disagreement.Responses, qt.HasLen, 2)
+	c.Assert(reported, qt.HasLen, 1)
+}
@@ -14,6 +14,7 @@ import (
 	"go.vocdoni.io/dvote/api/faucet"
 	"go.vocdoni.io/dvote/log"
 	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
 	"go.vocdoni.io/proto/build/go/models"
 	"google.golang.org/protobuf/proto"
 )
@@ -42,11 +43,45 @@ func (c *HTTPclient) DateToHeight(date time.Time) (uint32, error) {
 	return h.Height, nil
 }
 
+// SignAndSendTx signs the transaction with the account of the client and sends it.
+// Returns the transaction hash and the response data.
 func (c *HTTPclient) SignAndSendTx(stx *models.SignedTx) (types.HexBytes, []byte, error) {
-	var err error
-	if stx.Signature, err = c.account.SignVocdoniTx(stx.Tx, c.ChainID()); err != nil {
+	if err := c.signTx(stx); err != nil {
 		return nil, nil, err
 	}
+	return c.SendSignedTx(stx)
+}
+
+// signTx signs the transaction with the account of the client. Unless the
+// transaction already has an expiry, it expires after the validity window of
+// the client.
+func (c *HTTPclient) signTx(stx *models.SignedTx) error {
+	if c.account == nil {
+		return ErrAccountNotConfigured
+	}
+	if c.txValidity > 0 {
+		tx := &models.Tx{}
+		if err := proto.Unmarshal(stx.Tx, tx); err != nil {
+			return err
+		}
+		if vochaintx.GetTxExpiry(tx) == nil {
+			vochaintx.SetTxExpiry(tx, &vochaintx.Expiry{
+				Timestamp: uint64(time.Now().Add(c.txValidity).Unix()),
+			})
+			var err error
+			if stx.Tx, err = proto.Marshal(tx); err != nil {
+				return err
+			}
+		}
+	}
+	var err error
+	stx.Signature, err = c.account.SignVocdoniTx(stx.Tx, c.ChainID())
+	return err
+}
+
+// SendSignedTx sends an already signed transaction, such as one signed offline.
+// Returns the transaction hash and the response data.
+func (c *HTTPclient) SendSignedTx(stx *models.SignedTx) (types.HexBytes, []byte, error) {
 	txData, err := proto.Marshal(stx)
 	if err != nil {
 		return nil, nil, err
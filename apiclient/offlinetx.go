@@ -0,0 +1,303 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
+	"go.vocdoni.io/proto/build/go/models"
+	"google.golang.org/protobuf/proto"
+)
+
+// OfflineTxFileVersion is the version of the offline transactions file format.
+const OfflineTxFileVersion = 1
+
+// OfflineTxFile is a portable batch of transactions to be signed on a machine
+// without network access. The transactions are built online with
+// OfflineTxBuilder, signed offline with Sign and sent online with
+// SendOfflineTxs.
+type OfflineTxFile struct {
+	Version      int          `json:"version"`
+	ChainID      string       `json:"chainId"`
+	Transactions []*OfflineTx `json:"transactions"`
+}
+
+// OfflineTx is a transaction of an OfflineTxFile.
+type OfflineTx struct {
+	// Signer is the address of the account expected to sign the transaction.
+	Signer common.Address `json:"signer"`
+	// Type is the payload type of the transaction, for information only.
+	Type string `json:"type"`
+	// Tx is the protobuf encoded models.Tx.
+	Tx types.HexBytes `json:"tx"`
+	// Signature is the signature of the transaction, empty until it is signed.
+	Signature types.HexBytes `json:"signature,omitempty"`
+}
+
+// LoadOfflineTxFile reads an offline transactions file.
+func LoadOfflineTxFile(path string) (*OfflineTxFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &OfflineTxFile{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("cannot decode %s: %w", path, err)
+	}
+	if f.Version != OfflineTxFileVersion {
+		return nil, fmt.Errorf("unsupported offline transactions file version %d", f.Version)
+	}
+	if f.ChainID == "" {
+		return nil, fmt.Errorf("missing chain id in %s", path)
+	}
+	return f, nil
+}
+
+// Save writes the offline transactions file.
+func (f *OfflineTxFile) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// Decode returns the decoded transaction.
+func (t *OfflineTx) Decode() (*models.Tx, error) {
+	tx := &models.Tx{}
+	if err := proto.Unmarshal(t.Tx, tx); err != nil {
+		return nil, fmt.Errorf("cannot decode transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// Verify checks that the transaction is signed by its signer for the given
+// chain. It returns false if the transaction is not signed.
+func (t *OfflineTx) Verify(chainID string) (bool, error) {
+	if len(t.Signature) == 0 {
+		return false, nil
+	}
+	addr, err := ethereum.AddrFromSignature(ethereum.BuildVocdoniTransaction(t.Tx, chainID),
+		bytes.Clone(t.Signature))
+	if err != nil {
+		return false, fmt.Errorf("invalid signature: %w", err)
+	}
+	if addr != t.Signer {
+		return false, fmt.Errorf("transaction signed by %s instead of %s", addr, t.Signer)
+	}
+	return true, nil
+}
+
+// Sign signs the unsigned transactions of the file whose signer is the given
+// key, and returns the number of signed transactions. It does not require
+// network access.
+func (f *OfflineTxFile) Sign(key *ethereum.SignKeys) (int, error) {
+	signed := 0
+	for i, t := range f.Transactions {
+		if t.Signer != key.Address() || len(t.Signature) > 0 {
+			continue
+		}
+		// the transaction must be decodable, so it can be displayed before signing
+		if _, err := t.Decode(); err != nil {
+			return signed, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		signature, err := key.SignVocdoniTx(t.Tx, f.ChainID)
+		if err != nil {
+			return signed, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		t.Signature = signature
+		signed++
+	}
+	return signed, nil
+}
+
+// OfflineTxBuilder builds an OfflineTxFile, fetching the chain ID and the
+// nonces of the signers from the API. The transactions of the same signer
+// get consecutive nonces, so they must be sent in order.
+type OfflineTxBuilder struct {
+	c      *HTTPclient
+	file   *OfflineTxFile
+	nonces map[offlineNonceKey]uint32
+	expiry *vochaintx.Expiry
+}
+
+// offlineNonceKey identifies a nonce of a signer, since the treasurer has its
+// own nonce apart from the one of its account.
+type offlineNonceKey struct {
+	signer    common.Address
+	treasurer bool
+}
+
+// NewOfflineTxBuilder returns a builder of offline transactions for the chain
+// of the client. If file is not nil, the transactions are appended to it.
+func (c *HTTPclient) NewOfflineTxBuilder(file *OfflineTxFile) (*OfflineTxBuilder, error) {
+	if file == nil {
+		file = &OfflineTxFile{Version: OfflineTxFileVersion, ChainID: c.ChainID()}
+	}
+	if file.ChainID != c.ChainID() {
+		return nil, fmt.Errorf("the transactions are for chain %s, but the API is on chain %s",
+			file.ChainID, c.ChainID())
+	}
+	return &OfflineTxBuilder{
+		c:      c,
+		file:   file,
+		nonces: make(map[offlineNonceKey]uint32),
+	}, nil
+}
+
+// SetExpiry sets the expiry of the transactions added afterwards, unless they
+// already have one. By default the offline transactions do not expire, since
+// they may be signed long after being built.
+func (b *OfflineTxBuilder) SetExpiry(e *vochaintx.Expiry) {
+	b.expiry = e
+}
+
+// File returns the built offline transactions file.
+func (b *OfflineTxBuilder) File() *OfflineTxFile {
+	return b.file
+}
+
+// Add sets the nonce of the transaction, as the next one of the signer, and
+// the expiry of the builder, if any, and appends the unsigned transaction to
+// the file. The nonces of the
+// transactions already in the file are taken into account.
+func (b *OfflineTxBuilder) Add(signer common.Address, tx *models.Tx) error {
+	setNonce, treasurer, err := offlineTxNonce(tx)
+	if err != nil {
+		return err
+	}
+	if setNonce != nil {
+		key := offlineNonceKey{signer: signer, treasurer: treasurer}
+		nonce, ok := b.nonces[key]
+		if !ok {
+			if nonce, err = b.nextNonce(key); err != nil {
+				return err
+			}
+		}
+		setNonce(nonce)
+		b.nonces[key] = nonce + 1
+	}
+	if b.expiry != nil && vochaintx.GetTxExpiry(tx) == nil {
+		vochaintx.SetTxExpiry(tx, b.expiry)
+	}
+	txBytes, err := proto.Marshal(tx)
+	if err != nil {
+		return err
+	}
+	b.file.Transactions = append(b.file.Transactions, &OfflineTx{
+		Signer: signer,
+		Type:   offlineTxType(tx),
+		Tx:     txBytes,
+	})
+	return nil
+}
+
+// nextNonce returns the nonce of the signer on chain, skipping the nonces of
+// the transactions already in the file.
+func (b *OfflineTxBuilder) nextNonce(key offlineNonceKey) (uint32, error) {
+	var nonce uint32
+	if key.treasurer {
+		treasurer, err := b.c.Treasurer()
+		if err != nil {
+			return 0, fmt.Errorf("cannot get treasurer: %w", err)
+		}
+		if common.BytesToAddress(treasurer.Address) != key.signer {
+			return 0, fmt.Errorf("%s is not the treasurer", key.signer)
+		}
+		nonce = treasurer.Nonce
+	} else {
+		acc, err := b.c.Account(key.signer.Hex())
+		if err != nil {
+			return 0, fmt.Errorf("cannot get account %s: %w", key.signer, err)
+		}
+		nonce = acc.Nonce
+	}
+	for _, t := range b.file.Transactions {
+		if t.Signer != key.signer {
+			continue
+		}
+		tx, err := t.Decode()
+		if err != nil {
+			return 0, err
+		}
+		if setNonce, treasurer, err := offlineTxNonce(tx); err != nil || setNonce == nil || treasurer != key.treasurer {
+			continue
+		}
+		nonce++
+	}
+	return nonce, nil
+}
+
+// offlineTxType returns the payload type of the transaction.
+func offlineTxType(tx *models.Tx) string {
+	return string(tx.ProtoReflect().WhichOneof(tx.ProtoReflect().Descriptor().Oneofs().Get(0)).Name())
+}
+
+// offlineTxNonce returns the function to set the nonce of the transaction,
+// which is nil if the transaction has no nonce, and whether the nonce is the
+// one of the treasurer.
+func offlineTxNonce(tx *models.Tx) (func(uint32), bool, error) {
+	switch payload := tx.Payload.(type) {
+	case *models.Tx_NewProcess:
+		return func(n uint32) { payload.NewProcess.Nonce = n }, false, nil
+	case *models.Tx_SetProcess:
+		return func(n uint32) { payload.SetProcess.Nonce = n }, false, nil
+	case *models.Tx_SendTokens:
+		return func(n uint32) { payload.SendTokens.Nonce = n }, false, nil
+	case *models.Tx_CollectFaucet:
+		return func(n uint32) { payload.CollectFaucet.Nonce = n }, false, nil
+	case *models.Tx_SendVestingTokens:
+		return func(n uint32) { payload.SendVestingTokens.Nonce = n }, false, nil
+	case *models.Tx_SetAccount:
+		if payload.SetAccount.Txtype == models.TxType_CREATE_ACCOUNT {
+			return nil, false, nil
+		}
+		return func(n uint32) { payload.SetAccount.Nonce = &n }, false, nil
+	case *models.Tx_MintTokens:
+		return func(n uint32) { payload.MintTokens.Nonce = n }, true, nil
+	case *models.Tx_SetTransactionCosts:
+		return func(n uint32) { payload.SetTransactionCosts.Nonce = n }, true, nil
+	case *models.Tx_Admin:
+		if payload.Admin.Txtype == models.TxType_ADD_ORACLE || payload.Admin.Txtype == models.TxType_REMOVE_ORACLE {
+			return func(n uint32) { payload.Admin.Nonce = n }, true, nil
+		}
+	}
+	return nil, false, fmt.Errorf("transaction %T is not supported offline", tx.Payload)
+}
+
+// SendOfflineTxs sends the signed transactions of the file in order with
+// BroadcastSignedTx, waiting for each of them to be mined before sending the
+// next one, since the nonces of the same signer must be consecutive. The
+// unsigned transactions and the ones with a signature which does not match
+// their signer are not sent. It returns the hashes of the transactions sent,
+// which are the ones before the first error.
+func (c *HTTPclient) SendOfflineTxs(ctx context.Context, f *OfflineTxFile) ([]types.HexBytes, error) {
+	if f.ChainID != c.ChainID() {
+		return nil, fmt.Errorf("the transactions are for chain %s, but the API is on chain %s",
+			f.ChainID, c.ChainID())
+	}
+	for i, t := range f.Transactions {
+		signed, err := t.Verify(f.ChainID)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		if !signed {
+			return nil, fmt.Errorf("transaction %d is not signed", i)
+		}
+	}
+	var hashes []types.HexBytes
+	for i, t := range f.Transactions {
+		hash, _, err := c.BroadcastSignedTx(ctx, &models.SignedTx{Tx: t.Tx, Signature: t.Signature})
+		if err != nil {
+			return hashes, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
@@ -0,0 +1,236 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+)
+
+// requestPage fetches a page of a list paginated with cursors, unmarshaling it into page.
+// An empty cursor fetches the first page, and a zero limit uses the default of the API.
+func (c *HTTPclient) requestPage(page any, query url.Values, cursor string, limit int,
+	urlPath ...string,
+) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	resp, code, err := c.RequestWithQuery(HTTPGET, nil, query, urlPath...)
+	if err != nil {
+		return err
+	}
+	if code != 200 {
+		return fmt.Errorf("%s: %d (%s)", errCodeNot200, code, resp)
+	}
+	if err := json.Unmarshal(resp, page); err != nil {
+		return fmt.Errorf("could not unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// ElectionsPage returns a page of the elections, starting at the cursor.
+// An empty cursor returns the first page.
+func (c *HTTPclient) ElectionsPage(cursor string, limit int) ([]*api.ElectionSummary, *api.Pagination, error) {
+	page := &api.ElectionPage{}
+	if err := c.requestPage(page, nil, cursor, limit, "elections"); err != nil {
+		return nil, nil, err
+	}
+	return page.Elections, page.Pagination, nil
+}
+
+// OrganizationElectionsPage returns a page of the elections of an organization, starting
+// at the cursor. If status is not empty, only the elections with the status are listed.
+func (c *HTTPclient) OrganizationElectionsPage(organizationID types.HexBytes, status string,
+	cursor string, limit int,
+) ([]*api.ElectionSummary, *api.Pagination, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	page := &api.ElectionPage{}
+	if err := c.requestPage(page, query, cursor, limit,
+		"accounts", organizationID.String(), "elections"); err != nil {
+		return nil, nil, err
+	}
+	return page.Elections, page.Pagination, nil
+}
+
+// ElectionVotesPage returns a page of the votes of an election, starting at the cursor.
+func (c *HTTPclient) ElectionVotesPage(electionID types.HexBytes, cursor string, limit int,
+) ([]*api.Vote, *api.Pagination, error) {
+	page := &api.VotePage{}
+	if err := c.requestPage(page, nil, cursor, limit,
+		"elections", electionID.String(), "votes"); err != nil {
+		return nil, nil, err
+	}
+	return page.Votes, page.Pagination, nil
+}
+
+// TransactionsPage returns a page of the transactions, the newest first, starting at the cursor.
+func (c *HTTPclient) TransactionsPage(cursor string, limit int,
+) ([]*indexertypes.TxReference, *api.Pagination, error) {
+	page := &api.TransactionPage{}
+	if err := c.requestPage(page, nil, cursor, limit, "chain", "transactions"); err != nil {
+		return nil, nil, err
+	}
+	return page.Transactions, page.Pagination, nil
+}
+
+// TransfersPage returns a page of the token transfers sent by an account, starting at the cursor.
+func (c *HTTPclient) TransfersPage(accountID types.HexBytes, cursor string, limit int,
+) ([]*indexertypes.TokenTransferMeta, *api.Pagination, error) {
+	page := &api.TransferPage{}
+	if err := c.requestPage(page, nil, cursor, limit,
+		"accounts", accountID.String(), "transfers"); err != nil {
+		return nil, nil, err
+	}
+	return page.Transfers, page.Pagination, nil
+}
+
+// OrganizationsPage returns a page of the organizations, starting at the cursor.
+func (c *HTTPclient) OrganizationsPage(cursor string, limit int,
+) ([]*api.OrganizationList, *api.Pagination, error) {
+	page := &api.OrganizationPage{}
+	if err := c.requestPage(page, nil, cursor, limit, "chain", "organizations"); err != nil {
+		return nil, nil, err
+	}
+	return page.Organizations, page.Pagination, nil
+}
+
+// IterateElections calls fn for every election, fetching pages of up to limit elections.
+// The iteration stops at the first error returned by fn.
+func (c *HTTPclient) IterateElections(limit int, fn func(*api.ElectionSummary) error) error {
+	cursor := ""
+	for {
+		elections, pagination, err := c.ElectionsPage(cursor, limit)
+		if err != nil {
+			return err
+		}
+		for _, election := range elections {
+			if err := fn(election); err != nil {
+				return err
+			}
+		}
+		if pagination == nil || pagination.Next == "" {
+			return nil
+		}
+		cursor = pagination.Next
+	}
+}
+
+// IterateOrganizationElections calls fn for every election of an organization with the
+// status, or any status if empty. See IterateElections.
+func (c *HTTPclient) IterateOrganizationElections(organizationID types.HexBytes, status string,
+	limit int, fn func(*api.ElectionSummary) error,
+) error {
+	cursor := ""
+	for {
+		elections, pagination, err := c.OrganizationElectionsPage(organizationID, status, cursor, limit)
+		if err != nil {
+			return err
+		}
+		for _, election := range elections {
+			if err := fn(election); err != nil {
+				return err
+			}
+		}
+		if pagination == nil || pagination.Next == "" {
+			return nil
+		}
+		cursor = pagination.Next
+	}
+}
+
+// IterateElectionVotes calls fn for every vote of an election. See IterateElections.
+func (c *HTTPclient) IterateElectionVotes(electionID types.HexBytes, limit int,
+	fn func(*api.Vote) error,
+) error {
+	cursor := ""
+	for {
+		votes, pagination, err := c.ElectionVotesPage(electionID, cursor, limit)
+		if err != nil {
+			return err
+		}
+		for _, vote := range votes {
+			if err := fn(vote); err != nil {
+				return err
+			}
+		}
+		if pagination == nil || pagination.Next == "" {
+			return nil
+		}
+		cursor = pagination.Next
+	}
+}
+
+// IterateTransactions calls fn for every transaction, the newest first. See IterateElections.
+func (c *HTTPclient) IterateTransactions(limit int, fn func(*indexertypes.TxReference) error) error {
+	cursor := ""
+	for {
+		txs, pagination, err := c.TransactionsPage(cursor, limit)
+		if err != nil {
+			return err
+		}
+		for _, tx := range txs {
+			if err := fn(tx); err != nil {
+				return err
+			}
+		}
+		if pagination == nil || pagination.Next == "" {
+			return nil
+		}
+		cursor = pagination.Next
+	}
+}
+
+// IterateTransfers calls fn for every token transfer sent by an account. See IterateElections.
+func (c *HTTPclient) IterateTransfers(accountID types.HexBytes, limit int,
+	fn func(*indexertypes.TokenTransferMeta) error,
+) error {
+	cursor := ""
+	for {
+		transfers, pagination, err := c.TransfersPage(accountID, cursor, limit)
+		if err != nil {
+			return err
+		}
+		for _, transfer := range transfers {
+			if err := fn(transfer); err != nil {
+				return err
+			}
+		}
+		if pagination == nil || pagination.Next == "" {
+			return nil
+		}
+		cursor = pagination.Next
+	}
+}
+
+// IterateOrganizations calls fn for every organization. See IterateElections.
+func (c *HTTPclient) IterateOrganizations(limit int, fn func(*api.OrganizationList) error) error {
+	cursor := ""
+	for {
+		organizations, pagination, err := c.OrganizationsPage(cursor, limit)
+		if err != nil {
+			return err
+		}
+		for _, organization := range organizations {
+			if err := fn(organization); err != nil {
+				return err
+			}
+		}
+		if pagination == nil || pagination.Next == "" {
+			return nil
+		}
+		cursor = pagination.Next
+	}
+}
@@ -0,0 +1,91 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
+	"go.vocdoni.io/proto/build/go/models"
+	"google.golang.org/protobuf/proto"
+)
+
+// SponsorTx co-signs the transaction, already signed by its sender, with the
+// account of the client as sponsor, which pays the transaction cost. The
+// electionID is optional, and accounts the cost to the spending limit of the
+// sponsor for the election.
+func (c *HTTPclient) SponsorTx(stx *models.SignedTx, electionID types.HexBytes) error {
+	if c.account == nil {
+		return ErrAccountNotConfigured
+	}
+	return vochaintx.SponsorSignedTx(stx, c.account, electionID, c.ChainID())
+}
+
+// SendSponsoredTx co-signs the transaction as its sponsor with SponsorTx, and
+// sends it. Returns the transaction hash and the response data.
+func (c *HTTPclient) SendSponsoredTx(stx *models.SignedTx, electionID types.HexBytes) (types.HexBytes, []byte, error) {
+	if err := c.SponsorTx(stx, electionID); err != nil {
+		return nil, nil, err
+	}
+	return c.SendSignedTx(stx)
+}
+
+// SetSponsorLimit sets the maximum amount the account of the client pays, as
+// sponsor, for the transactions of an election or signed by an account. The
+// scope is the election ID or the account address. Returns the transaction
+// hash.
+func (c *HTTPclient) SetSponsorLimit(scope types.HexBytes, limit uint64) (types.HexBytes, error) {
+	return c.setSponsorLimits(scope, &models.SetSponsorLimitsTx{Limit: limit})
+}
+
+// RemoveSponsorLimit removes the spending limit of the account of the client,
+// as sponsor, for the scope, which is an election ID or an account address.
+// Returns the transaction hash.
+func (c *HTTPclient) RemoveSponsorLimit(scope types.HexBytes) (types.HexBytes, error) {
+	return c.setSponsorLimits(scope, &models.SetSponsorLimitsTx{Remove: true})
+}
+
+// setSponsorLimits sends the SetSponsorLimitsTx payload for the scope.
+func (c *HTTPclient) setSponsorLimits(scope types.HexBytes, payload *models.SetSponsorLimitsTx) (types.HexBytes, error) {
+	acc, err := c.Account("")
+	if err != nil {
+		return nil, err
+	}
+	payload.Txtype = models.TxType_SET_SPONSOR_LIMITS
+	payload.Nonce = acc.Nonce
+	switch len(scope) {
+	case types.ProcessIDsize:
+		payload.ElectionId = scope
+	case common.AddressLength:
+		payload.Account = scope
+	default:
+		return nil, fmt.Errorf("invalid scope %x, expected an election ID or an account address", scope)
+	}
+	stx := models.SignedTx{}
+	if stx.Tx, err = proto.Marshal(&models.Tx{
+		Payload: &models.Tx_SetSponsorLimits{SetSponsorLimits: payload},
+	}); err != nil {
+		return nil, err
+	}
+	txHash, _, err := c.SignAndSendTx(&stx)
+	return txHash, err
+}
+
+// SponsorLimit returns the spending limit of the sponsor for the scope, which
+// is an election ID or an account address.
+func (c *HTTPclient) SponsorLimit(sponsor common.Address, scope types.HexBytes) (*api.SponsorLimit, error) {
+	resp, code, err := c.Request(HTTPGET, nil, "accounts", sponsor.Hex(), "sponsorLimits", scope.String())
+	if err != nil {
+		return nil, err
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("%s: %d (%s)", errCodeNot200, code, resp)
+	}
+	limit := &api.SponsorLimit{}
+	if err := json.Unmarshal(resp, limit); err != nil {
+		return nil, err
+	}
+	return limit, nil
+}
@@ -0,0 +1,61 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+	"go.vocdoni.io/proto/build/go/models"
+	"google.golang.org/protobuf/proto"
+)
+
+// TransferVesting sends tokens from the account of the client that are locked
+// on the destination account until they vest: nothing is unlocked during the
+// first cliffBlocks, and then the tokens vest linearly until vestingBlocks
+// after the transaction is included. Returns the transaction hash.
+func (c *HTTPclient) TransferVesting(to common.Address, amount uint64,
+	cliffBlocks, vestingBlocks uint32) (types.HexBytes, error) {
+	acc, err := c.Account("")
+	if err != nil {
+		return nil, err
+	}
+	stx := models.SignedTx{}
+	stx.Tx, err = proto.Marshal(&models.Tx{
+		Payload: &models.Tx_SendVestingTokens{
+			SendVestingTokens: &models.SendVestingTokensTx{
+				Txtype:        models.TxType_SEND_VESTING_TOKENS,
+				Nonce:         acc.Nonce,
+				To:            to.Bytes(),
+				Value:         amount,
+				CliffBlocks:   cliffBlocks,
+				VestingBlocks: vestingBlocks,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	txHash, _, err := c.SignAndSendTx(&stx)
+	return txHash, err
+}
+
+// GetVestings returns the vesting schedules sent or received by the account,
+// including the ones completely released.
+func (c *HTTPclient) GetVestings(address common.Address) ([]*indexertypes.TokenVestingMeta, error) {
+	resp, code, err := c.Request(HTTPGET, nil, "accounts", address.Hex(), "vesting")
+	if err != nil {
+		return nil, err
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("%s: %d (%s)", errCodeNot200, code, resp)
+	}
+	vestings := &struct {
+		Vestings []*indexertypes.TokenVestingMeta `json:"vestings"`
+	}{}
+	if err := json.Unmarshal(resp, vestings); err != nil {
+		return nil, err
+	}
+	return vestings.Vestings, nil
+}
@@ -1,8 +1,11 @@
 package censustree
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"sync"
 	"sync/atomic"
@@ -205,6 +208,57 @@ func (t *Tree) GenProof(key []byte) ([]byte, []byte, error) {
 	return t.tree.GenProof(nil, leafKey)
 }
 
+// BatchProof is the census proof of a key generated by GenProofs. Err is set
+// if the proof could not be generated, i.e. the key is not in the census.
+type BatchProof struct {
+	Key            []byte
+	Value          []byte
+	Siblings       []byte
+	CircomSiblings []string
+	Err            error
+}
+
+// GenProofs generates the census proofs for the provided keys under the given
+// root (the current one if nil), calling the callback with each proof in the
+// same order than the keys. All the proofs are generated reusing the same read
+// transaction. If circomSiblings is true, the circom ready siblings are also
+// included. If the callback returns an error, the generation stops and the
+// error is returned.
+func (t *Tree) GenProofs(root []byte, keys [][]byte, circomSiblings bool,
+	callback func(*BatchProof) error) error {
+	rTx := t.tree.DB().ReadTx()
+	defer rTx.Discard()
+
+	var err error
+	if root == nil {
+		if root, err = t.tree.Root(rTx); err != nil {
+			return err
+		}
+	}
+	// the tree nodes are immutable, so fixing the root ensures all the proofs
+	// belong to it even if the tree is updated meanwhile
+	tree, err := t.tree.FromRoot(root)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		// If the provided key is longer than the defined maximum length truncate it
+		leafKey := key
+		if len(leafKey) > DefaultMaxKeyLen {
+			leafKey = leafKey[:DefaultMaxKeyLen]
+		}
+		proof := &BatchProof{Key: key}
+		proof.Value, proof.Siblings, proof.Err = tree.GenProof(rTx, leafKey)
+		if proof.Err == nil && circomSiblings {
+			proof.CircomSiblings, proof.Err = tree.CircomSiblings(proof.Siblings)
+		}
+		if err := callback(proof); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Size returns the census index (number of added leafs to the merkle tree).
 func (t *Tree) Size() (uint64, error) {
 	return t.tree.Size(t.tree.DB().ReadTx())
@@ -220,14 +274,19 @@ func (t *Tree) IterateLeaves(callback func(key, value []byte) bool) error {
 	return t.tree.IterateLeaves(nil, callback)
 }
 
-func (t *Tree) updateCensusWeight(wTx db.WriteTx, w []byte) error {
+// updateCensusWeight adds delta to the stored census weight. The delta is
+// negative when leaves are removed or their weight is decreased.
+func (t *Tree) updateCensusWeight(wTx db.WriteTx, delta *big.Int) error {
 	t.updatesLock.Lock()
 	defer t.updatesLock.Unlock()
 	weightBytes, err := wTx.Get(censusWeightKey)
 	if err != nil && !errors.Is(err, db.ErrKeyNotFound) {
 		return fmt.Errorf("could not get census weight: %w", err)
 	}
-	weight := new(big.Int).Add(t.BytesToBigInt(weightBytes), t.BytesToBigInt(w))
+	weight := new(big.Int).Add(t.BytesToBigInt(weightBytes), delta)
+	if weight.Sign() < 0 {
+		return fmt.Errorf("census weight can not be negative (%s)", weight)
+	}
 	if err := wTx.Set(censusWeightKey, t.BigIntToBytes(weight)); err != nil {
 		return fmt.Errorf("could not set census weight: %w", err)
 	}
@@ -259,7 +318,7 @@ func (t *Tree) AddBatch(keys, values [][]byte) ([]int, error) {
 			addedWeight = new(big.Int).Sub(addedWeight, t.BytesToBigInt(values[invalids[i]]))
 		}
 
-		if err := t.updateCensusWeight(wTx, t.BigIntToBytes(addedWeight)); err != nil {
+		if err := t.updateCensusWeight(wTx, addedWeight); err != nil {
 			return nil, err
 		}
 	}
@@ -287,7 +346,47 @@ func (t *Tree) Add(key, value []byte) error {
 	// The censusWeight update should be done only for the
 	// censuses that have weight.
 	if value != nil {
-		if err := t.updateCensusWeight(wTx, value); err != nil {
+		if err := t.updateCensusWeight(wTx, t.BytesToBigInt(value)); err != nil {
+			return err
+		}
+	}
+
+	return wTx.Commit()
+}
+
+// Update changes the value of an existing key of the census merkle tree,
+// adjusting the census weight by the difference between the new and the old
+// value. Same constraints than Add apply to the new value.
+func (t *Tree) Update(key, value []byte) error {
+	return t.UpdateBatch([][]byte{key}, [][]byte{value})
+}
+
+// UpdateBatch changes the values of existing keys of the census merkle tree
+// in a single transaction, adjusting the census weight as Update does. The
+// changes are only committed if all the keys are updated.
+func (t *Tree) UpdateBatch(keys, values [][]byte) error {
+	if len(keys) != len(values) {
+		return fmt.Errorf("cannot update census: %d keys but %d values", len(keys), len(values))
+	}
+	t.Lock()
+	defer t.Unlock()
+
+	wTx := t.tree.DB().WriteTx()
+	defer wTx.Discard()
+
+	delta := big.NewInt(0)
+	for i, key := range keys {
+		oldValue, err := t.tree.Get(wTx, key)
+		if err != nil {
+			return fmt.Errorf("cannot update (%x) in census: %w", key, err)
+		}
+		if err := t.tree.Update(wTx, key, values[i]); err != nil {
+			return fmt.Errorf("cannot update (%x) in census: %w", key, err)
+		}
+		delta.Add(delta, new(big.Int).Sub(t.BytesToBigInt(values[i]), t.BytesToBigInt(oldValue)))
+	}
+	if delta.Sign() != 0 {
+		if err := t.updateCensusWeight(wTx, delta); err != nil {
 			return err
 		}
 	}
@@ -295,12 +394,59 @@ func (t *Tree) Add(key, value []byte) error {
 	return wTx.Commit()
 }
 
+// Delete removes an existing key from the census merkle tree, subtracting its
+// value from the census weight.
+func (t *Tree) Delete(key []byte) error {
+	return t.DeleteBatch([][]byte{key})
+}
+
+// DeleteBatch removes existing keys from the census merkle tree in a single
+// transaction, subtracting their values from the census weight. The changes
+// are only committed if all the keys are removed.
+func (t *Tree) DeleteBatch(keys [][]byte) error {
+	t.Lock()
+	defer t.Unlock()
+
+	wTx := t.tree.DB().WriteTx()
+	defer wTx.Discard()
+
+	delta := big.NewInt(0)
+	for _, key := range keys {
+		oldValue, err := t.tree.Get(wTx, key)
+		if err != nil {
+			return fmt.Errorf("cannot delete (%x) from census: %w", key, err)
+		}
+		if err := t.tree.Delete(wTx, key); err != nil {
+			return fmt.Errorf("cannot delete (%x) from census: %w", key, err)
+		}
+		delta.Sub(delta, t.BytesToBigInt(oldValue))
+	}
+	if delta.Sign() != 0 {
+		if err := t.updateCensusWeight(wTx, delta); err != nil {
+			return err
+		}
+	}
+
+	return wTx.Commit()
+}
+
+// DumpWriter wraps t.tree.DumpWriter, writing the dump to w as the leaves are read.
+func (t *Tree) DumpWriter(w io.Writer) error {
+	return t.tree.DumpWriter(w)
+}
+
 // ImportDump wraps t.tree.ImportDump while acquiring the lock.
 func (t *Tree) ImportDump(b []byte) error {
+	return t.ImportDumpReader(bytes.NewReader(b))
+}
+
+// ImportDumpReader wraps t.tree.ImportDumpReader while acquiring the lock,
+// reading the dump from r.
+func (t *Tree) ImportDumpReader(r io.Reader) error {
 	t.Lock()
 	defer t.Unlock()
 
-	if err := t.tree.ImportDump(b); err != nil {
+	if err := t.tree.ImportDumpReader(bufio.NewReader(r)); err != nil {
 		return fmt.Errorf("could not import dump: %w", err)
 	}
 
@@ -319,7 +465,7 @@ func (t *Tree) ImportDump(b []byte) error {
 		return fmt.Errorf("could not add weight: %w", err)
 	}
 
-	if err := t.updateCensusWeight(wTx, t.BigIntToBytes(addedWeight)); err != nil {
+	if err := t.updateCensusWeight(wTx, addedWeight); err != nil {
 		return fmt.Errorf("could not update census weight: %w", err)
 	}
 
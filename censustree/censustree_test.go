@@ -1,11 +1,14 @@
 package censustree
 
 import (
+	"bytes"
+	"errors"
 	"math/big"
 	"strconv"
 	"testing"
 
 	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/data/compressor"
 	"go.vocdoni.io/dvote/db/metadb"
 	"go.vocdoni.io/dvote/test/testcommon/testutil"
 	"go.vocdoni.io/dvote/tree/arbo"
@@ -234,3 +237,180 @@ func TestGetCensusWeight(t *testing.T) {
 	qt.Assert(t, err, qt.IsNil)
 	qt.Assert(t, w.String(), qt.Equals, "11457") // same than in the original tree
 }
+
+func TestUpdateAndDelete(t *testing.T) {
+	db := metadb.NewTest(t)
+	tree, err := New(Options{Name: "test", ParentDB: db, MaxLevels: DefaultMaxLevels,
+		CensusType: models.Census_ARBO_BLAKE2B})
+	qt.Assert(t, err, qt.IsNil)
+
+	for i := 0; i < 10; i++ {
+		err = tree.Add([]byte{byte(i)}, tree.BigIntToBytes(big.NewInt(int64(i+1))))
+		qt.Assert(t, err, qt.IsNil)
+	}
+	w, err := tree.GetCensusWeight()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, w.String(), qt.Equals, "55") // = 1+2+...+10
+
+	// increase and decrease the weight of two keys
+	err = tree.Update([]byte{0}, tree.BigIntToBytes(big.NewInt(21)))
+	qt.Assert(t, err, qt.IsNil)
+	err = tree.Update([]byte{9}, tree.BigIntToBytes(big.NewInt(4)))
+	qt.Assert(t, err, qt.IsNil)
+	w, err = tree.GetCensusWeight()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, w.String(), qt.Equals, "69") // = 55 + 20 - 6
+
+	value, err := tree.Get([]byte{0})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, tree.BytesToBigInt(value).String(), qt.Equals, "21")
+
+	// delete a key
+	err = tree.Delete([]byte{5})
+	qt.Assert(t, err, qt.IsNil)
+	w, err = tree.GetCensusWeight()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, w.String(), qt.Equals, "63")
+	size, err := tree.Size()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, size, qt.Equals, uint64(9))
+	_, err = tree.Get([]byte{5})
+	qt.Assert(t, errors.Is(err, arbo.ErrKeyNotFound), qt.IsTrue)
+
+	// update and delete of missing keys must fail without changing the weight
+	err = tree.Update([]byte{5}, tree.BigIntToBytes(big.NewInt(1)))
+	qt.Assert(t, errors.Is(err, arbo.ErrKeyNotFound), qt.IsTrue)
+	err = tree.Delete([]byte{5})
+	qt.Assert(t, errors.Is(err, arbo.ErrKeyNotFound), qt.IsTrue)
+	w, err = tree.GetCensusWeight()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, w.String(), qt.Equals, "63")
+
+	// a batch with a missing key must not change any key
+	rootBefore, err := tree.Root()
+	qt.Assert(t, err, qt.IsNil)
+	err = tree.UpdateBatch([][]byte{{1}, {5}},
+		[][]byte{tree.BigIntToBytes(big.NewInt(30)), tree.BigIntToBytes(big.NewInt(1))})
+	qt.Assert(t, errors.Is(err, arbo.ErrKeyNotFound), qt.IsTrue)
+	err = tree.DeleteBatch([][]byte{{1}, {5}})
+	qt.Assert(t, errors.Is(err, arbo.ErrKeyNotFound), qt.IsTrue)
+	rootAfter, err := tree.Root()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, rootAfter, qt.DeepEquals, rootBefore)
+	value, err = tree.Get([]byte{1})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, tree.BytesToBigInt(value).String(), qt.Equals, "2")
+	w, err = tree.GetCensusWeight()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, w.String(), qt.Equals, "63")
+
+	// the root must be the same than the one of a census built with the
+	// remaining leaves
+	tree2, err := New(Options{Name: "test2", ParentDB: db, MaxLevels: DefaultMaxLevels,
+		CensusType: models.Census_ARBO_BLAKE2B})
+	qt.Assert(t, err, qt.IsNil)
+	err = tree.IterateLeaves(func(key, value []byte) bool {
+		qt.Assert(t, tree2.Add(key, value), qt.IsNil)
+		return false
+	})
+	qt.Assert(t, err, qt.IsNil)
+	root, err := tree.Root()
+	qt.Assert(t, err, qt.IsNil)
+	root2, err := tree2.Root()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, root, qt.DeepEquals, root2)
+}
+
+func TestDumpWriterCompressed(t *testing.T) {
+	db := metadb.NewTest(t)
+	censusTree, err := New(Options{Name: "test", ParentDB: db, MaxLevels: DefaultMaxLevels,
+		CensusType: models.Census_ARBO_BLAKE2B})
+	qt.Assert(t, err, qt.IsNil)
+
+	rnd := testutil.NewRandom(0)
+	keys, values := [][]byte{}, [][]byte{}
+	for i := 1; i < 1000; i++ {
+		keys = append(keys, rnd.RandomBytes(DefaultMaxKeyLen))
+		values = append(values, censusTree.BigIntToBytes(big.NewInt(int64(i))))
+	}
+	failed, err := censusTree.AddBatch(keys, values)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, failed, qt.HasLen, 0)
+
+	// stream the dump through the compressor
+	var buf bytes.Buffer
+	w, err := compressor.NewWriter(&buf)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, censusTree.DumpWriter(w), qt.IsNil)
+	qt.Assert(t, w.Close(), qt.IsNil)
+
+	censusTree2, err := New(Options{Name: "test2", ParentDB: db, MaxLevels: DefaultMaxLevels,
+		CensusType: models.Census_ARBO_BLAKE2B})
+	qt.Assert(t, err, qt.IsNil)
+	r, err := compressor.NewReader(&buf)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, censusTree2.ImportDumpReader(r), qt.IsNil)
+
+	r1, err := censusTree.Root()
+	qt.Assert(t, err, qt.IsNil)
+	r2, err := censusTree2.Root()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, r1, qt.DeepEquals, r2)
+	w1, err := censusTree.GetCensusWeight()
+	qt.Assert(t, err, qt.IsNil)
+	w2, err := censusTree2.GetCensusWeight()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, w1.Cmp(w2), qt.Equals, 0)
+}
+
+func TestGenProofs(t *testing.T) {
+	db := metadb.NewTest(t)
+	censusTree, err := New(Options{Name: "test", ParentDB: db, MaxLevels: DefaultMaxLevels,
+		CensusType: models.Census_ARBO_POSEIDON})
+	qt.Assert(t, err, qt.IsNil)
+
+	rnd := testutil.NewRandom(0)
+	var keys [][]byte
+	for i := 0; i < 20; i++ {
+		key := rnd.RandomBytes(DefaultMaxKeyLen)
+		keys = append(keys, key)
+		err = censusTree.Add(key, censusTree.BigIntToBytes(big.NewInt(int64(i+1))))
+		qt.Assert(t, err, qt.IsNil)
+	}
+	root, err := censusTree.Root()
+	qt.Assert(t, err, qt.IsNil)
+
+	// a key not in the census, and a key added after getting the root, which
+	// is not in the census under that root
+	keys = append(keys, rnd.RandomBytes(DefaultMaxKeyLen))
+	newKey := rnd.RandomBytes(DefaultMaxKeyLen)
+	qt.Assert(t, censusTree.Add(newKey, censusTree.BigIntToBytes(big.NewInt(1))), qt.IsNil)
+	keys = append(keys, newKey)
+
+	i := 0
+	err = censusTree.GenProofs(root, keys, true, func(proof *BatchProof) error {
+		qt.Assert(t, proof.Key, qt.DeepEquals, keys[i])
+		i++
+		if i > 20 {
+			qt.Assert(t, proof.Err, qt.IsNotNil)
+			return nil
+		}
+		qt.Assert(t, proof.Err, qt.IsNil)
+		verified, err := censusTree.VerifyProof(proof.Key, proof.Value, proof.Siblings, root)
+		qt.Assert(t, err, qt.IsNil)
+		qt.Assert(t, verified, qt.IsTrue)
+		qt.Assert(t, proof.CircomSiblings, qt.HasLen, DefaultMaxLevels+1)
+		return nil
+	})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, i, qt.Equals, len(keys))
+
+	// with the current root, the circom siblings match GetCircomSiblings
+	err = censusTree.GenProofs(nil, keys[:1], true, func(proof *BatchProof) error {
+		siblings, err := censusTree.GetCircomSiblings(proof.Key)
+		qt.Assert(t, err, qt.IsNil)
+		qt.Assert(t, proof.CircomSiblings, qt.DeepEquals, siblings)
+		return nil
+	})
+	qt.Assert(t, err, qt.IsNil)
+}
@@ -0,0 +1,640 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	flag "github.com/spf13/pflag"
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/apiclient"
+	"go.vocdoni.io/dvote/apiclient/electionspec"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+// Exit codes of the non-interactive mode.
+const (
+	// exitError is returned when the command fails, such as an API error or
+	// a rejected transaction.
+	exitError = 1
+	// exitUsage is returned for unknown commands and invalid flags or arguments.
+	exitUsage = 2
+	// exitTxNotMined is returned when a transaction is sent but it is not
+	// included in a block within the confirmation threshold.
+	exitTxNotMined = 3
+)
+
+const commandsUsage = `Commands (the result is printed to stdout as JSON):
+  network info
+  account set <privateKey> [--memo]
+  account gen [--memo]
+  account use <index|address>
+  account info [address]
+  account bootstrap [--faucetPackage|--faucetURL] [--wait]
+  account transfer --to --amount [--wait]
+  account setMetadata --file [--wait]
+  account election --file [--wait]
+  census create [--type]
+  census add <censusId> [--participant key[:weight]]... [--file]
+  census publish <censusId>
+  election plan --file [--state] [--capacity]
+  election apply --file [--state] [--wait]
+  vote --electionId --choices [--weight] [--cspProof]
+
+Use "-" as file to read from stdin. Run a command with --help for its flags.
+Exit codes: 0 success, 1 command failed, 2 invalid usage, 3 transaction not mined.
+`
+
+// errTxNotMined is returned when a transaction is not included in a block
+// within transactionConfirmationThreshold.
+var errTxNotMined = errors.New("transaction was not included")
+
+// usageError is returned when the command, its flags or its arguments are
+// not valid.
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+func usageErrorf(format string, args ...any) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// command is a non-interactive action of the cli, which parses its flags
+// and arguments and returns the result to print as JSON.
+type command func(cli *vocdoniCLI, args []string) (any, error)
+
+var commands = map[string]map[string]command{
+	"network": {
+		"info": networkInfoCmd,
+	},
+	"account": {
+		"set":         accountSetCmd,
+		"gen":         accountGenCmd,
+		"use":         accountUseCmd,
+		"info":        accountInfoCmd,
+		"bootstrap":   accountBootstrapCmd,
+		"transfer":    accountTransferCmd,
+		"setMetadata": accountSetMetadataCmd,
+		"election":    accountElectionCmd,
+	},
+	"census": {
+		"create":  censusCreateCmd,
+		"add":     censusAddCmd,
+		"publish": censusPublishCmd,
+	},
+	"election": {
+		"plan":  electionPlanCmd,
+		"apply": electionApplyCmd,
+	},
+}
+
+// runCommand runs a non-interactive command, printing its result as JSON to
+// stdout and the error, if any, as JSON to stderr. It returns the exit code.
+func runCommand(cli *vocdoniCLI, args []string) int {
+	result, err := dispatchCommand(cli, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		printJSON(os.Stderr, map[string]string{"error": err.Error()})
+		var uerr usageError
+		switch {
+		case errors.As(err, &uerr):
+			fmt.Fprint(os.Stderr, commandsUsage)
+			return exitUsage
+		case errors.Is(err, errTxNotMined):
+			return exitTxNotMined
+		default:
+			return exitError
+		}
+	}
+	if err := printJSON(os.Stdout, result); err != nil {
+		return exitError
+	}
+	return 0
+}
+
+func dispatchCommand(cli *vocdoniCLI, args []string) (any, error) {
+	if args[0] == "vote" {
+		return voteCmd(cli, args[1:])
+	}
+	subcommands, ok := commands[args[0]]
+	if !ok {
+		return nil, usageErrorf("unknown command %q", args[0])
+	}
+	if len(args) < 2 {
+		return nil, usageErrorf("missing %s subcommand", args[0])
+	}
+	cmd, ok := subcommands[args[1]]
+	if !ok {
+		return nil, usageErrorf("unknown command %q", args[0]+" "+args[1])
+	}
+	return cmd(cli, args[2:])
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// parseFlags parses the flags of a command and returns its positional
+// arguments, which must be between minArgs and maxArgs.
+func parseFlags(fs *flag.FlagSet, args []string, minArgs, maxArgs int) ([]string, error) {
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return nil, usageError{err}
+	}
+	if fs.NArg() < minArgs || fs.NArg() > maxArgs {
+		return nil, usageErrorf("expected between %d and %d arguments, got %d",
+			minArgs, maxArgs, fs.NArg())
+	}
+	return fs.Args(), nil
+}
+
+// readJSONFile decodes the JSON file into v. If the file name is "-" it is
+// read from stdin.
+func readJSONFile(file string, v any) error {
+	if file == "" {
+		return usageErrorf("missing --file")
+	}
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("cannot decode %s: %w", file, err)
+	}
+	return nil
+}
+
+// parseHex decodes a hexadecimal string, with or without the 0x prefix.
+func parseHex(s string) (types.HexBytes, error) {
+	return hex.DecodeString(util.TrimHex(s))
+}
+
+func requireAccount(cli *vocdoniCLI) error {
+	if !accountIsSet(cli) {
+		return errors.New(errAccountNotConfgirued)
+	}
+	return nil
+}
+
+// txResult is the result of the commands which send a transaction.
+type txResult struct {
+	TxHash     types.HexBytes `json:"txHash,omitempty"`
+	ElectionID types.HexBytes `json:"electionId,omitempty"`
+	Mined      bool           `json:"mined"`
+}
+
+// sentTx returns the result of a sent transaction, waiting until it is mined
+// if wait is true.
+func sentTx(cli *vocdoniCLI, txHash types.HexBytes, wait bool) (*txResult, error) {
+	res := &txResult{TxHash: txHash}
+	if !wait {
+		return res, nil
+	}
+	if !cli.waitForTransaction(txHash) {
+		return nil, fmt.Errorf("%w: %s", errTxNotMined, txHash)
+	}
+	res.Mined = true
+	return res, nil
+}
+
+// accountResult is the result of the commands which configure an account.
+type accountResult struct {
+	Index     int            `json:"index"`
+	Address   common.Address `json:"address"`
+	PublicKey types.HexBytes `json:"publicKey"`
+	Memo      string         `json:"memo"`
+}
+
+func currentAccountResult(cli *vocdoniCLI) *accountResult {
+	acc := cli.getCurrentAccount()
+	return &accountResult{
+		Index:     cli.currentAccount,
+		Address:   acc.Address,
+		PublicKey: acc.PublicKey,
+		Memo:      acc.Memo,
+	}
+}
+
+func networkInfoCmd(cli *vocdoniCLI, args []string) (any, error) {
+	if _, err := parseFlags(flag.NewFlagSet("network info", flag.ContinueOnError), args, 0, 0); err != nil {
+		return nil, err
+	}
+	return cli.api.ChainInfo()
+}
+
+func accountSetCmd(cli *vocdoniCLI, args []string) (any, error) {
+	fs := flag.NewFlagSet("account set", flag.ContinueOnError)
+	memo := fs.String("memo", "", "account memo note")
+	args, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	if err := cli.setAPIaccount(args[0], *memo); err != nil {
+		return nil, err
+	}
+	if err := cli.useAccount(cli.currentAccount); err != nil {
+		return nil, err
+	}
+	return currentAccountResult(cli), nil
+}
+
+func accountGenCmd(cli *vocdoniCLI, args []string) (any, error) {
+	fs := flag.NewFlagSet("account gen", flag.ContinueOnError)
+	memo := fs.String("memo", "", "account memo note")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return nil, err
+	}
+	if err := cli.setAPIaccount(fmt.Sprintf("%x", util.RandomBytes(32)), *memo); err != nil {
+		return nil, err
+	}
+	if err := cli.useAccount(cli.currentAccount); err != nil {
+		return nil, err
+	}
+	return currentAccountResult(cli), nil
+}
+
+func accountUseCmd(cli *vocdoniCLI, args []string) (any, error) {
+	args, err := parseFlags(flag.NewFlagSet("account use", flag.ContinueOnError), args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	index, err := strconv.Atoi(args[0])
+	if err != nil {
+		index = -1
+		for i, acc := range cli.config.Accounts {
+			if strings.EqualFold(acc.Address.Hex(), common.HexToAddress(args[0]).Hex()) {
+				index = i
+				break
+			}
+		}
+	}
+	if index < 0 || index >= len(cli.config.Accounts) {
+		return nil, usageErrorf("account %s not found", args[0])
+	}
+	if err := cli.useAccount(index); err != nil {
+		return nil, err
+	}
+	return currentAccountResult(cli), nil
+}
+
+func accountInfoCmd(cli *vocdoniCLI, args []string) (any, error) {
+	args, err := parseFlags(flag.NewFlagSet("account info", flag.ContinueOnError), args, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	address := ""
+	if len(args) > 0 {
+		address = args[0]
+	} else if err := requireAccount(cli); err != nil {
+		return nil, err
+	}
+	return cli.api.Account(address)
+}
+
+func accountBootstrapCmd(cli *vocdoniCLI, args []string) (any, error) {
+	fs := flag.NewFlagSet("account bootstrap", flag.ContinueOnError)
+	faucetPackage := fs.String("faucetPackage", "", "base64 faucet package")
+	faucetURL := fs.String("faucetURL", apiclient.DefaultDevelopmentFaucetURL,
+		"faucet service to fetch the faucet package from, if not given (empty for none)")
+	wait := fs.Bool("wait", true, "wait until the transaction is mined")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return nil, err
+	}
+	if err := requireAccount(cli); err != nil {
+		return nil, err
+	}
+	var faucetPkg *models.FaucetPackage
+	switch {
+	case *faucetPackage != "":
+		faucetPkgBytes, err := base64.StdEncoding.DecodeString(*faucetPackage)
+		if err != nil {
+			return nil, usageErrorf("invalid faucet package: %v", err)
+		}
+		if faucetPkg, err = apiclient.UnmarshalFaucetPackage(faucetPkgBytes); err != nil {
+			return nil, usageErrorf("invalid faucet package: %v", err)
+		}
+	case *faucetURL != "":
+		var err error
+		if faucetPkg, err = apiclient.GetFaucetPackageFromRemoteService(
+			*faucetURL+cli.api.MyAddress().Hex(),
+			apiclient.DefaultDevelopmentFaucetToken,
+		); err != nil {
+			return nil, err
+		}
+	}
+	txHash, err := cli.api.AccountBootstrap(faucetPkg, &api.AccountMetadata{
+		Name: map[string]string{"default": "vocdoni cli account " + cli.getCurrentAccount().Address.Hex()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sentTx(cli, txHash, *wait)
+}
+
+func accountTransferCmd(cli *vocdoniCLI, args []string) (any, error) {
+	fs := flag.NewFlagSet("account transfer", flag.ContinueOnError)
+	to := fs.String("to", "", "destination address")
+	amount := fs.Uint64("amount", 0, "amount of tokens to transfer")
+	wait := fs.Bool("wait", true, "wait until the transaction is mined")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return nil, err
+	}
+	if !common.IsHexAddress(*to) {
+		return nil, usageErrorf("invalid destination address %q", *to)
+	}
+	if *amount == 0 {
+		return nil, usageErrorf("missing --amount")
+	}
+	if err := requireAccount(cli); err != nil {
+		return nil, err
+	}
+	txHash, err := cli.api.Transfer(common.HexToAddress(*to), *amount)
+	if err != nil {
+		return nil, err
+	}
+	return sentTx(cli, txHash, *wait)
+}
+
+func accountSetMetadataCmd(cli *vocdoniCLI, args []string) (any, error) {
+	fs := flag.NewFlagSet("account setMetadata", flag.ContinueOnError)
+	file := fs.String("file", "", "JSON file with the account metadata")
+	wait := fs.Bool("wait", true, "wait until the transaction is mined")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return nil, err
+	}
+	accMeta := &api.AccountMetadata{}
+	if err := readJSONFile(*file, accMeta); err != nil {
+		return nil, err
+	}
+	if err := requireAccount(cli); err != nil {
+		return nil, err
+	}
+	txHash, err := cli.api.AccountSetMetadata(accMeta)
+	if err != nil {
+		return nil, err
+	}
+	return sentTx(cli, txHash, *wait)
+}
+
+func accountElectionCmd(cli *vocdoniCLI, args []string) (any, error) {
+	fs := flag.NewFlagSet("account election", flag.ContinueOnError)
+	file := fs.String("file", "", "JSON file with the election description")
+	wait := fs.Bool("wait", true, "wait until the election is created")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return nil, err
+	}
+	description := &api.ElectionDescription{}
+	if err := readJSONFile(*file, description); err != nil {
+		return nil, err
+	}
+	if err := requireAccount(cli); err != nil {
+		return nil, err
+	}
+	electionID, err := cli.api.NewElection(description)
+	if err != nil {
+		return nil, err
+	}
+	res := &txResult{ElectionID: electionID}
+	if !*wait {
+		return res, nil
+	}
+	if !cli.waitForElection(electionID) {
+		return nil, fmt.Errorf("%w: election %s", errTxNotMined, electionID)
+	}
+	res.Mined = true
+	return res, nil
+}
+
+func censusCreateCmd(cli *vocdoniCLI, args []string) (any, error) {
+	fs := flag.NewFlagSet("census create", flag.ContinueOnError)
+	censusType := fs.String("type", api.CensusTypeWeighted,
+		fmt.Sprintf("census type [%s,%s,%s]", api.CensusTypeWeighted, api.CensusTypeZKWeighted, api.CensusTypeCSP))
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return nil, err
+	}
+	censusID, err := cli.api.NewCensus(*censusType)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"censusId": censusID, "type": *censusType}, nil
+}
+
+func censusAddCmd(cli *vocdoniCLI, args []string) (any, error) {
+	fs := flag.NewFlagSet("census add", flag.ContinueOnError)
+	participantList := fs.StringArray("participant", nil,
+		"participant key (address or public key) with an optional weight, as key[:weight]")
+	file := fs.String("file", "", `JSON file with the participants, as {"participants":[{"key":"...","weight":"1"}]}`)
+	args, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	censusID, err := parseHex(args[0])
+	if err != nil {
+		return nil, usageErrorf("invalid census id %q", args[0])
+	}
+	participants := &api.CensusParticipants{}
+	if *file != "" {
+		if err := readJSONFile(*file, participants); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range *participantList {
+		key, weightStr, hasWeight := strings.Cut(p, ":")
+		participant := api.CensusParticipant{Weight: new(types.BigInt).SetUint64(1)}
+		if participant.Key, err = parseHex(key); err != nil {
+			return nil, usageErrorf("invalid participant key %q", key)
+		}
+		if hasWeight {
+			weight, ok := new(big.Int).SetString(weightStr, 10)
+			if !ok || weight.Sign() < 0 {
+				return nil, usageErrorf("invalid participant weight %q", weightStr)
+			}
+			participant.Weight = (*types.BigInt)(weight)
+		}
+		participants.Participants = append(participants.Participants, participant)
+	}
+	if len(participants.Participants) == 0 {
+		return nil, usageErrorf("no participants given")
+	}
+	if err := cli.api.CensusAddParticipants(censusID, participants); err != nil {
+		return nil, err
+	}
+	size, err := cli.api.CensusSize(censusID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"censusId": censusID, "added": len(participants.Participants), "size": size}, nil
+}
+
+func censusPublishCmd(cli *vocdoniCLI, args []string) (any, error) {
+	args, err := parseFlags(flag.NewFlagSet("census publish", flag.ContinueOnError), args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	censusID, err := parseHex(args[0])
+	if err != nil {
+		return nil, usageErrorf("invalid census id %q", args[0])
+	}
+	root, uri, err := cli.api.CensusPublish(censusID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"censusId": censusID, "root": root, "uri": uri}, nil
+}
+
+// specStatePath returns the state file of an election specification, which is
+// the given one or the specification path with the .state.json extension.
+func specStatePath(specFile, stateFile string) string {
+	if stateFile != "" {
+		return stateFile
+	}
+	return strings.TrimSuffix(specFile, filepath.Ext(specFile)) + ".state.json"
+}
+
+func electionPlanCmd(cli *vocdoniCLI, args []string) (any, error) {
+	fs := flag.NewFlagSet("election plan", flag.ContinueOnError)
+	file := fs.String("file", "", "YAML or JSON election specification file")
+	stateFile := fs.String("state", "", "state file of the specification (default <file>.state.json)")
+	capacity := fs.Int("capacity", electionspec.DefaultPriceCapacity, "chain capacity to estimate the election price")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return nil, err
+	}
+	if *file == "" {
+		return nil, usageErrorf("missing --file")
+	}
+	if *capacity <= 0 {
+		return nil, usageErrorf("invalid capacity %d", *capacity)
+	}
+	spec, err := electionspec.Load(*file)
+	if err != nil {
+		return nil, err
+	}
+	state, err := electionspec.LoadState(specStatePath(*file, *stateFile))
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAccount(cli); err != nil {
+		return nil, err
+	}
+	return electionspec.NewPlan(cli.api, spec, state, *capacity)
+}
+
+func electionApplyCmd(cli *vocdoniCLI, args []string) (any, error) {
+	fs := flag.NewFlagSet("election apply", flag.ContinueOnError)
+	file := fs.String("file", "", "YAML or JSON election specification file")
+	stateFile := fs.String("state", "", "state file of the specification (default <file>.state.json)")
+	wait := fs.Bool("wait", true, "wait until the election is created")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return nil, err
+	}
+	if *file == "" {
+		return nil, usageErrorf("missing --file")
+	}
+	spec, err := electionspec.Load(*file)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAccount(cli); err != nil {
+		return nil, err
+	}
+	statePath := specStatePath(*file, *stateFile)
+	state, err := electionspec.Apply(cli.api, spec, statePath)
+	if err != nil {
+		return nil, err
+	}
+	res := struct {
+		*electionspec.State
+		StateFile string `json:"stateFile"`
+		Mined     bool   `json:"mined"`
+	}{State: state, StateFile: statePath}
+	if !*wait {
+		return res, nil
+	}
+	if !cli.waitForElection(state.ElectionID) {
+		return nil, fmt.Errorf("%w: election %s", errTxNotMined, state.ElectionID)
+	}
+	res.Mined = true
+	return res, nil
+}
+
+func voteCmd(cli *vocdoniCLI, args []string) (any, error) {
+	fs := flag.NewFlagSet("vote", flag.ContinueOnError)
+	electionIDStr := fs.String("electionId", "", "election id")
+	choices := fs.IntSlice("choices", nil, "comma-separated list of choices, one per question")
+	weight := fs.String("weight", "", "voting weight (the full census weight if empty)")
+	cspProof := fs.String("cspProof", "", "hexadecimal CSP proof, for elections with a CSP census")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return nil, err
+	}
+	electionID, err := parseHex(*electionIDStr)
+	if err != nil || len(electionID) == 0 {
+		return nil, usageErrorf("invalid election id %q", *electionIDStr)
+	}
+	if len(*choices) == 0 {
+		return nil, usageErrorf("missing --choices")
+	}
+	if err := requireAccount(cli); err != nil {
+		return nil, err
+	}
+	vote := &apiclient.VoteData{
+		ElectionID: electionID,
+		Choices:    *choices,
+	}
+	if *weight != "" {
+		w, ok := new(big.Int).SetString(*weight, 10)
+		if !ok {
+			return nil, usageErrorf("invalid weight %q", *weight)
+		}
+		vote.VotingWeight = w
+	}
+	election, err := cli.api.Election(electionID)
+	if err != nil {
+		return nil, err
+	}
+	if *cspProof != "" {
+		if vote.ProofCSP, err = parseHex(*cspProof); err != nil {
+			return nil, usageErrorf("invalid CSP proof: %v", err)
+		}
+	} else {
+		// the census proof is generated by the API with the published census
+		voterKey := cli.api.MyAddress().Bytes()
+		if election.VoteMode.Anonymous {
+			voterKey = cli.api.MyZkAddress().Bytes()
+		}
+		if vote.ProofMkTree, err = cli.api.CensusGenProof(election.Census.CensusRoot, voterKey); err != nil {
+			return nil, fmt.Errorf("cannot get census proof: %w", err)
+		}
+		if !election.VoteMode.Anonymous {
+			vote.ProofMkTree.KeyType = models.ProofArbo_ADDRESS
+		}
+	}
+	voteID, err := cli.api.Vote(vote)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"electionId": electionID, "voteId": voteID}, nil
+}
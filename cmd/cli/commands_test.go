@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	qt "github.com/frankban/quicktest"
+	flag "github.com/spf13/pflag"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain/state"
+	"go.vocdoni.io/dvote/vocone"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+func TestDispatchUsage(t *testing.T) {
+	// the usage is checked before using the API, so no client is needed
+	cli := &vocdoniCLI{config: &Config{}, currentAccount: -1}
+	for _, args := range [][]string{
+		{"unknown"},
+		{"account"},
+		{"account", "unknown"},
+		{"network", "info", "extra"},
+		{"network", "info", "--unknown"},
+		{"account", "set"},
+		{"account", "use", "3"},
+		{"account", "transfer", "--to", "0xinvalid", "--amount", "1"},
+		{"account", "transfer", "--to", "0x0000000000000000000000000000000000000001"},
+		{"account", "setMetadata"},
+		{"census", "add", "not-hex", "--participant", "0x01"},
+		{"census", "add", "01"},
+		{"census", "add", "01", "--participant", "0x01:-2"},
+		{"election", "plan", "--file", "spec.yaml", "--capacity", "0"},
+		{"election", "apply"},
+		{"vote", "--choices", "1"},
+		{"vote", "--electionId", "01"},
+		{"vote", "--electionId", "01", "--choices", "x"},
+	} {
+		_, err := dispatchCommand(cli, args)
+		var uerr usageError
+		qt.Assert(t, errors.As(err, &uerr), qt.IsTrue, qt.Commentf("args: %q, err: %v", args, err))
+	}
+
+	// the commands requiring an account fail without one, but not with a usage error
+	_, err := dispatchCommand(cli, []string{"account", "transfer",
+		"--to", "0x0000000000000000000000000000000000000001", "--amount", "1"})
+	qt.Assert(t, err, qt.ErrorMatches, errAccountNotConfgirued)
+	var uerr usageError
+	qt.Assert(t, errors.As(err, &uerr), qt.IsFalse)
+
+	_, err = dispatchCommand(cli, []string{"account", "info", "--help"})
+	qt.Assert(t, err, qt.ErrorIs, flag.ErrHelp)
+	qt.Assert(t, runCommand(cli, []string{"account", "info", "--help"}), qt.Equals, 0)
+	qt.Assert(t, runCommand(cli, []string{"unknown"}), qt.Equals, exitUsage)
+	qt.Assert(t, runCommand(cli, []string{"account", "info"}), qt.Equals, exitError)
+}
+
+func TestSpecStatePath(t *testing.T) {
+	qt.Assert(t, specStatePath("dir/election.yaml", ""), qt.Equals, "dir/election.state.json")
+	qt.Assert(t, specStatePath("election", ""), qt.Equals, "election.state.json")
+	qt.Assert(t, specStatePath("election.yaml", "other.json"), qt.Equals, "other.json")
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	keymng := ethereum.SignKeys{}
+	qt.Assert(t, keymng.Generate(), qt.IsNil)
+	account := ethereum.SignKeys{}
+	qt.Assert(t, account.Generate(), qt.IsNil)
+
+	vc, err := vocone.NewVocone(filepath.Join(dir, "vocone"), &keymng)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, vc.SetBulkTxCosts(1, true), qt.IsNil)
+	vc.SetBlockTimeTarget(time.Millisecond * 500)
+	go vc.Start()
+	port := 13000 + util.RandomInt(0, 2000)
+	_, err = vc.EnableAPI("127.0.0.1", port, "/api")
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, vc.CreateAccount(account.Address(), &state.Account{
+		Account: models.Account{Balance: 1000},
+	}), qt.IsNil)
+	time.Sleep(time.Second * 2)
+
+	cli, err := NewVocdoniCLI(filepath.Join(dir, "cli.json"), fmt.Sprintf("http://127.0.0.1:%d/api", port))
+	qt.Assert(t, err, qt.IsNil)
+
+	// run executes a command and returns its result as printed JSON
+	run := func(args ...string) map[string]any {
+		t.Helper()
+		result, err := dispatchCommand(cli, args)
+		qt.Assert(t, err, qt.IsNil, qt.Commentf("args: %q", args))
+		var buf bytes.Buffer
+		qt.Assert(t, printJSON(&buf, result), qt.IsNil)
+		out := map[string]any{}
+		qt.Assert(t, json.Unmarshal(buf.Bytes(), &out), qt.IsNil, qt.Commentf("output: %s", buf.String()))
+		return out
+	}
+
+	out := run("network", "info")
+	qt.Assert(t, out["chainId"], qt.Not(qt.Equals), "")
+
+	out = run("account", "set", fmt.Sprintf("%x", account.PrivateKey()), "--memo", "main")
+	qt.Assert(t, out["index"], qt.Equals, 0.0)
+	qt.Assert(t, out["address"], qt.Equals, strings.ToLower(account.Address().Hex()))
+	qt.Assert(t, out["memo"], qt.Equals, "main")
+	qt.Assert(t, out["publicKey"], qt.Not(qt.Equals), "")
+
+	out = run("account", "gen")
+	qt.Assert(t, out["index"], qt.Equals, 1.0)
+	other := out["address"].(string)
+	// the transfer recipient must exist on the chain
+	qt.Assert(t, vc.CreateAccount(common.HexToAddress(other), &state.Account{}), qt.IsNil)
+	out = run("account", "use", account.Address().Hex())
+	qt.Assert(t, out["index"], qt.Equals, 0.0)
+
+	out = run("account", "info")
+	qt.Assert(t, out["balance"], qt.Equals, 1000.0)
+
+	out = run("account", "transfer", "--to", other, "--amount", "100")
+	qt.Assert(t, out["txHash"], qt.Not(qt.Equals), "")
+	qt.Assert(t, out["mined"], qt.Equals, true)
+	out = run("account", "info", other)
+	qt.Assert(t, out["balance"], qt.Equals, 100.0)
+
+	out = run("census", "create")
+	censusID := out["censusId"].(string)
+	qt.Assert(t, out["type"], qt.Equals, "weighted")
+	out = run("census", "add", censusID,
+		"--participant", account.Address().Hex()+":5", "--participant", other)
+	qt.Assert(t, out["added"], qt.Equals, 2.0)
+	qt.Assert(t, out["size"], qt.Equals, 2.0)
+	out = run("census", "publish", censusID)
+	qt.Assert(t, out["root"], qt.Not(qt.Equals), "")
+	qt.Assert(t, out["uri"], qt.Not(qt.Equals), "")
+
+	// the API errors are not usage errors
+	_, err = dispatchCommand(cli, []string{"census", "publish", "0123"})
+	qt.Assert(t, err, qt.Not(qt.IsNil))
+	var uerr usageError
+	qt.Assert(t, errors.As(err, &uerr), qt.IsFalse)
+}
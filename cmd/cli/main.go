@@ -43,14 +43,34 @@ func main() {
 	host := flag.String("host", "", "API host endpoint to connect with (such as http://localhost:9090/v2)")
 	logLevel := flag.String("logLevel", "error", "log level")
 	cfgFile := flag.String("config", filepath.Join(home, ".vocdoni-cli.json"), "config file")
+	// the flags after the command belong to the command
+	flag.CommandLine.SetInterspersed(false)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] [command]\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "Without a command, the interactive menu is started.\n\nFlags:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n%s", commandsUsage)
+	}
 	flag.Parse()
-	log.Init(*logLevel, "stdout")
+	// in non-interactive mode stdout is reserved for the command results
+	logOutput := "stdout"
+	if flag.NArg() > 0 {
+		logOutput = "stderr"
+	}
+	log.Init(*logLevel, logOutput)
 	log.Infow("starting "+filepath.Base(os.Args[0]), "version", internal.Version)
 
 	cli, err := NewVocdoniCLI(*cfgFile, *host)
 	if err != nil {
+		if flag.NArg() > 0 {
+			printJSON(os.Stderr, map[string]string{"error": err.Error()})
+			os.Exit(exitError)
+		}
 		log.Fatal(err)
 	}
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(cli, flag.Args()))
+	}
 
 	accountInfoHeader := func() string {
 		account := "account not configured"
@@ -85,27 +85,24 @@ func NewVocdoniCLI(configFile, host string) (*vocdoniCLI, error) {
 	if cfg.Host == nil {
 		return nil, fmt.Errorf("no API server host configured")
 	}
-	hostURL, err := url.Parse(host)
-	if err != nil {
-		return nil, err
-	}
-
-	api, err := apiclient.NewHTTPclient(hostURL, cfg.Token)
+	api, err := apiclient.NewHTTPclient(cfg.Host, cfg.Token)
 	if err != nil {
 		return nil, err
 	}
+	currentAccount := -1
 	if len(cfg.Accounts)-1 >= cfg.LastAccountUsed {
 		log.Infof("using account %d", cfg.LastAccountUsed)
 		if err := api.SetAccount(cfg.Accounts[cfg.LastAccountUsed].PrivKey.String()); err != nil {
 			return nil, err
 		}
+		currentAccount = cfg.LastAccountUsed
 	}
 	return &vocdoniCLI{
 		filepath:       configFile,
 		config:         &cfg,
 		api:            api,
 		chainID:        api.ChainID(),
-		currentAccount: cfg.LastAccountUsed,
+		currentAccount: currentAccount,
 	}, nil
 }
 
@@ -117,6 +114,7 @@ func (v *vocdoniCLI) setHost(host string) error {
 	if err := v.api.SetHostAddr(u); err != nil {
 		return err
 	}
+	v.config.Host = u
 
 	info, err := v.api.ChainInfo()
 	if err != nil {
@@ -221,6 +219,19 @@ func (v *vocdoniCLI) waitForTransaction(txHash types.HexBytes) bool {
 	return false
 }
 
+// waitForElection waits until the election is available on the API, which
+// means the transaction that created it was mined.
+func (v *vocdoniCLI) waitForElection(electionID types.HexBytes) bool {
+	startTime := time.Now()
+	for time.Now().Before(startTime.Add(transactionConfirmationThreshold)) {
+		if _, err := v.api.Election(electionID); err == nil {
+			return true
+		}
+		time.Sleep(3 * time.Second)
+	}
+	return false
+}
+
 func (v *vocdoniCLI) save() error {
 	return v.config.Save(v.filepath)
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	flag "github.com/spf13/pflag"
+	"go.vocdoni.io/dvote/api/csp"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/db"
+	"go.vocdoni.io/dvote/db/metadb"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/apirest"
+	"go.vocdoni.io/dvote/internal"
+	"go.vocdoni.io/dvote/log"
+)
+
+// csp runs a standalone credential service provider, which authenticates the
+// voters of the elections with census origin OFF_CHAIN_CA and signs their CA
+// bundles. The census root of the elections must be the CSP public key, which
+// is logged on startup and returned by the info endpoint.
+func main() {
+	// Report the version before loading the config or logger init, just in case something goes wrong.
+	// For the sake of including the version in the log, it's also included in a log line later on.
+	fmt.Fprintf(os.Stderr, "vocdoni version %q\n", internal.Version)
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Fatalf("cannot get user home directory with error: %v", err)
+	}
+	var dataDir, key, host, urlPath, logLevel, authHandler, authFile string
+	var port int
+	flag.StringVar(&dataDir, "dataDir", filepath.Join(home, ".vocdoni", "csp"), "data directory (absolute path)")
+	flag.StringVar(&key, "key", "", "CSP private key as hexadecimal string (if empty, it is loaded from or generated into the data directory)")
+	flag.StringVar(&host, "listenHost", "0.0.0.0", "API endpoint listen address")
+	flag.IntVar(&port, "listenPort", 5000, "API endpoint http port")
+	flag.StringVar(&urlPath, "urlPath", "/v2", "HTTP path for the API rest")
+	flag.StringVar(&logLevel, "logLevel", "info", "log level (debug, info, warn, error)")
+	flag.StringVar(&authHandler, "authHandler", csp.AuthHandlerSharedSecret,
+		fmt.Sprintf("voter authentication handler [%s,%s]", csp.AuthHandlerSharedSecret, csp.AuthHandlerChallenge))
+	flag.StringVar(&authFile, "authFile", "",
+		"CSV file with the user IDs and their secrets (sharedSecret) or contacts (challenge)")
+	flag.Parse()
+	log.Init(logLevel, "stdout")
+	log.Infow("starting "+filepath.Base(os.Args[0]), "version", internal.Version)
+
+	if err := os.MkdirAll(dataDir, 0o750); err != nil {
+		log.Fatal(err)
+	}
+	signer, err := loadKey(filepath.Join(dataDir, "key"), key)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	users, err := loadUsers(authFile)
+	if err != nil {
+		log.Fatalf("cannot load the auth file: %v", err)
+	}
+	var auth csp.AuthHandler
+	switch authHandler {
+	case csp.AuthHandlerSharedSecret:
+		auth = csp.NewSharedSecretAuth(users)
+	case csp.AuthHandlerChallenge:
+		// only the stub sender is available, which logs the challenge codes
+		auth = csp.NewChallengeAuth(users, csp.NewStubSender())
+	default:
+		log.Fatalf("unknown auth handler %q", authHandler)
+	}
+	log.Infow("loaded voters", "authHandler", auth.Name(), "users", len(users))
+
+	database, err := metadb.New(db.TypePebble, filepath.Join(dataDir, "db"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer database.Close()
+	c, err := csp.NewCSP(signer, auth, database)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	router := httprouter.HTTProuter{}
+	if err := router.Init(host, port); err != nil {
+		log.Fatal(err)
+	}
+	endpoint, err := apirest.NewAPI(&router, urlPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := csp.AttachCSPAPI(c, endpoint, "/csp"); err != nil {
+		log.Fatal(err)
+	}
+	log.Infow("CSP ready", "publicKey", hex.EncodeToString(c.PublicKey()),
+		"endpoint", fmt.Sprintf("%s:%d%s/csp", host, port, urlPath))
+
+	// close if interrupt received
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	<-ch
+	log.Warn("received SIGTERM, exiting")
+}
+
+// loadKey returns the CSP key from the hexKey if not empty, or from the key
+// file, generating and storing a new one if the file does not exist.
+func loadKey(keyFile, hexKey string) (*ethereum.SignKeys, error) {
+	signer := ethereum.NewSignKeys()
+	if hexKey != "" {
+		return signer, signer.AddHexKey(hexKey)
+	}
+	data, err := os.ReadFile(keyFile)
+	if err == nil {
+		return signer, signer.AddHexKey(strings.TrimSpace(string(data)))
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err := signer.Generate(); err != nil {
+		return nil, err
+	}
+	log.Infof("generated new CSP key, stored at %s", keyFile)
+	return signer, os.WriteFile(keyFile, []byte(hex.EncodeToString(signer.PrivateKey())), 0o600)
+}
+
+// loadUsers reads the CSV file with a user ID and a secret or contact on each
+// line.
+func loadUsers(file string) (map[string]string, error) {
+	if file == "" {
+		return nil, fmt.Errorf("no auth file provided")
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+	users := make(map[string]string)
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return users, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		users[record[0]] = record[1]
+	}
+}
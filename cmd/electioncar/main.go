@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	"go.vocdoni.io/dvote/api/censusdb"
+	"go.vocdoni.io/dvote/apiclient"
+	"go.vocdoni.io/dvote/data"
+	"go.vocdoni.io/dvote/db"
+	"go.vocdoni.io/dvote/db/metadb"
+	"go.vocdoni.io/dvote/internal"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/util"
+)
+
+// fetchTimeout is the maximum duration to download a file from the gateways.
+const fetchTimeout = 2 * time.Minute
+
+// electionBundle is the root file of the archive, which references the
+// files of the election by their CID.
+type electionBundle struct {
+	ElectionID types.HexBytes `json:"electionId"`
+	Metadata   string         `json:"metadata,omitempty"`
+	Census     string         `json:"census,omitempty"`
+	Results    string         `json:"results,omitempty"`
+}
+
+// electioncar packs the metadata, census dump and results of an election into
+// a CAR (Content Addressable aRchive) v1 file, which can be moved to another
+// IPFS cluster or an offline environment. With --import, the files of a CAR
+// are verified and imported into a local storage and census database,
+// without network access.
+func main() {
+	// Report the version before loading the config or logger init, just in case something goes wrong.
+	// For the sake of including the version in the log, it's also included in a log line later on.
+	fmt.Fprintf(os.Stderr, "vocdoni version %q\n", internal.Version)
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Fatalf("cannot get user home directory with error: %v", err)
+	}
+	var host, electionID, archiveDir, output, importFile, dataDir, logLevel string
+	var gateways []string
+	flag.StringVar(&host, "host", "https://api-dev.vocdoni.net/v2", "API host to fetch the election from")
+	flag.StringSliceVar(&gateways, "gateways", []string{"https://ipfs.io"},
+		"IPFS HTTP gateways to fetch the metadata and census dump from")
+	flag.StringVar(&electionID, "electionId", "", "election id as hexadecimal string")
+	flag.StringVar(&archiveDir, "archiveDir", "",
+		"process archive directory to take the results from (if empty, they are fetched from the API)")
+	flag.StringVarP(&output, "output", "o", "", "output CAR file (election id if empty)")
+	flag.StringVar(&importFile, "import", "", "CAR file to import instead of packing an election")
+	flag.StringVar(&dataDir, "dataDir", filepath.Join(home, ".vocdoni", "dev"),
+		"node data directory to import the CAR file into (the node must be stopped)")
+	flag.StringVar(&logLevel, "logLevel", "info", "log level [error,warn,info,debug]")
+	flag.Parse()
+	log.Init(logLevel, "stderr")
+
+	if importFile != "" {
+		if err := importCAR(importFile, dataDir); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	pid, err := hex.DecodeString(util.TrimHex(electionID))
+	if err != nil || len(pid) == 0 {
+		log.Fatalf("invalid election id %q", electionID)
+	}
+	if output == "" {
+		output = hex.EncodeToString(pid) + ".car"
+	}
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cli, err := apiclient.NewHTTPclient(hostURL, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := packElection(cli, pid, gateways, archiveDir, output); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// packElection writes the CAR file of the election, with the bundle as root.
+func packElection(cli *apiclient.HTTPclient, pid types.HexBytes, gateways []string,
+	archiveDir, output string) error {
+	election, err := cli.Election(pid)
+	if err != nil {
+		return fmt.Errorf("cannot get election: %w", err)
+	}
+	bundle := &electionBundle{ElectionID: pid}
+	files := [][]byte{}
+	add := func(content []byte) string {
+		files = append(files, content)
+		return data.CalculateIPFSCIDv1json(content)
+	}
+
+	if election.MetadataURL != "" {
+		metadata, err := fetch(gateways, election.MetadataURL)
+		if err != nil {
+			return fmt.Errorf("cannot fetch metadata: %w", err)
+		}
+		bundle.Metadata = add(metadata)
+	}
+	if election.Census != nil && strings.HasPrefix(election.Census.CensusURL, "ipfs://") {
+		dump, err := fetch(gateways, election.Census.CensusURL)
+		if err != nil {
+			return fmt.Errorf("cannot fetch census dump: %w", err)
+		}
+		bundle.Census = add(dump)
+	} else {
+		log.Warnw("the election census is not published, skipping it")
+	}
+	results, err := electionResults(cli, pid, archiveDir)
+	if err != nil {
+		log.Warnw("cannot get election results, skipping them", "error", err)
+	} else {
+		bundle.Results = add(results)
+	}
+
+	bundleData, err := json.Marshal(bundle)
+	if err != nil {
+		return err
+	}
+	root := add(bundleData)
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := data.WriteCAR(f, []string{root}, files...); err != nil {
+		return err
+	}
+	log.Infow("election packed", "output", output, "root", root, "metadata", bundle.Metadata,
+		"census", bundle.Census, "results", bundle.Results)
+	return nil
+}
+
+// electionResults returns the archived results of the election, from the
+// process archive directory if not empty, or from the API.
+func electionResults(cli *apiclient.HTTPclient, pid types.HexBytes, archiveDir string) ([]byte, error) {
+	if archiveDir != "" {
+		return os.ReadFile(filepath.Join(archiveDir, pid.String()))
+	}
+	results, err := cli.ElectionResults(pid)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(results)
+}
+
+// fetch downloads the file of the IPFS URI from the first gateway that
+// returns it.
+func fetch(gateways []string, uri string) ([]byte, error) {
+	cid := strings.TrimPrefix(uri, "ipfs://")
+	client := &http.Client{Timeout: fetchTimeout}
+	var lastErr error
+	for _, gw := range gateways {
+		resp, err := client.Get(strings.TrimSuffix(gw, "/") + "/ipfs/" + cid)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		content, err := io.ReadAll(io.LimitReader(resp.Body, data.MaxFileSizeBytes+1))
+		resp.Body.Close()
+		if err == nil && resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("gateway %s returned status %d", gw, resp.StatusCode)
+		}
+		if err == nil && len(content) > data.MaxFileSizeBytes {
+			err = fmt.Errorf("file too big: (size>%d)", data.MaxFileSizeBytes)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if !data.IPFSCIDequals(data.CalculateIPFSCIDv1json(content), cid) {
+			// the file might be chunked by IPFS, so the archive CID will be
+			// the hash of the whole content
+			log.Warnw("downloaded file does not match its CID", "uri", uri, "gateway", gw)
+		}
+		return content, nil
+	}
+	return nil, fmt.Errorf("cannot fetch %s: %w", uri, lastErr)
+}
+
+// importCAR imports the files of the CAR into the local storage and the
+// census dumps into the census database of the node data directory.
+func importCAR(file, dataDir string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	storage, err := data.Init(data.LOCAL, data.IPFSNewConfig(filepath.Join(dataDir, "ipfs", "local")))
+	if err != nil {
+		return err
+	}
+	defer storage.Stop()
+	cids, err := data.ImportCAR(context.Background(), storage, f)
+	if err != nil {
+		return err
+	}
+	log.Infow("files imported", "cids", cids)
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	database, err := metadb.New(db.TypePebble, filepath.Join(dataDir, "vochain", "censusdb"))
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+	roots, err := censusdb.NewCensusDB(database).ImportCAR(f)
+	if err != nil {
+		return err
+	}
+	for _, root := range roots {
+		log.Infow("census imported", "root", hex.EncodeToString(root))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	flag "github.com/spf13/pflag"
+	"go.vocdoni.io/dvote/db"
+	"go.vocdoni.io/dvote/internal"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain"
+	"go.vocdoni.io/dvote/vochain/indexer"
+)
+
+// electionexport exports the votes or results of an election directly from the data
+// directory of a node, producing the same files as the API export endpoints.
+// The node must be stopped, since its databases can't be opened twice.
+func main() {
+	// Report the version before loading the config or logger init, just in case something goes wrong.
+	// For the sake of including the version in the log, it's also included in a log line later on.
+	fmt.Fprintf(os.Stderr, "vocdoni version %q\n", internal.Version)
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Fatalf("cannot get user home directory with error: %v", err)
+	}
+	var dataDir, dbType, logLevel, electionID, format, output, what string
+	flag.StringVar(&dataDir, "dataDir", filepath.Join(home, ".vocdoni", "dev", "vochain"),
+		"vochain data directory of the node (absolute path)")
+	flag.StringVar(&dbType, "dbType", db.TypePebble, "database type of the vochain state")
+	flag.StringVar(&logLevel, "logLevel", "error", "log level [error,warn,info,debug]")
+	flag.StringVar(&electionID, "electionId", "", "election id as hexadecimal string")
+	flag.StringVar(&what, "export", "votes", "what to export [votes,results]")
+	flag.StringVar(&format, "format", indexer.ExportFormatCSV, "export format [csv,jsonl]")
+	flag.StringVarP(&output, "output", "o", "", "output file (stdout if empty)")
+	flag.Parse()
+	// log to stderr, since the export may be written to stdout
+	log.Init(logLevel, "stderr")
+
+	pid, err := hex.DecodeString(util.TrimHex(electionID))
+	if err != nil || len(pid) == 0 {
+		log.Fatalf("invalid election id %q", electionID)
+	}
+	app, err := vochain.NewBaseApplication(dbType, filepath.Join(dataDir, "data"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer app.State.Close()
+	idx, err := indexer.NewIndexer(filepath.Join(dataDir, "indexer"), app, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer idx.Close()
+
+	var out io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	switch what {
+	case "votes":
+		err = idx.ExportEnvelopes(w, pid, format)
+	case "results":
+		err = idx.ExportResults(w, pid, format)
+	default:
+		err = fmt.Errorf("unknown export %q", what)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		log.Fatalf("cannot export the election %x: %v", pid, err)
+	}
+}
@@ -18,6 +18,8 @@ import (
 	"github.com/spf13/viper"
 
 	urlapi "go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/api/archive"
+	"go.vocdoni.io/dvote/api/downloads"
 	"go.vocdoni.io/dvote/api/faucet"
 	"go.vocdoni.io/dvote/config"
 	"go.vocdoni.io/dvote/crypto/ethereum"
@@ -109,12 +111,20 @@ func newConfig() (*config.Config, config.Error) {
 		"enable TLS-secure domain with LetsEncrypt (listenPort=443 is required)")
 	globalCfg.EnableFaucetWithAmount = *flag.Uint64("enableFaucetWithAmount", 0,
 		"enable faucet for the current network and the specified amount (testing purposes only)")
+	globalCfg.AdminToken = *flag.String("adminToken", "",
+		"bearer token for the admin API endpoints (disabled if empty)")
 
 	// ipfs
 	globalCfg.Ipfs.ConnectKey = *flag.StringP("ipfsConnectKey", "i", "",
 		"enable IPFS group synchronization using the given secret key")
 	globalCfg.Ipfs.ConnectPeers = *flag.StringSlice("ipfsConnectPeers", []string{},
 		"use custom ipfsconnect peers/bootnodes for accessing the DHT (comma-separated)")
+	globalCfg.Ipfs.StorageType = *flag.String("storageType", "IPFS",
+		"data storage type [IPFS,LOCAL], LOCAL stores the files on disk without running an IPFS node")
+	globalCfg.Ipfs.Gateways = *flag.StringSlice("storageGateways", []string{},
+		"HTTP gateways used by the LOCAL storage to retrieve the files not stored locally (comma-separated)")
+	globalCfg.Ipfs.MaxSize = *flag.Int64("storageMaxSize", 0,
+		"maximum size in bytes of the files kept by the LOCAL storage (0 for no limit)")
 
 	// vochain
 	globalCfg.Vochain.P2PListen = *flag.String("vochainP2PListen", "0.0.0.0:26656",
@@ -139,14 +149,30 @@ func newConfig() (*config.Config, config.Error) {
 		"do not wait for Vochain to synchronize (for testing only)")
 	globalCfg.Vochain.MempoolSize = *flag.Int("vochainMempoolSize", 20000,
 		"vochain mempool size")
+	globalCfg.Vochain.MempoolRecheck = *flag.Bool("vochainMempoolRecheck", false,
+		"recheck the vochain mempool transactions after each block, evicting the expired ones and the ones whose nonce has been used")
 	globalCfg.Vochain.MinerTargetBlockTimeSeconds = *flag.Int("vochainBlockTime", 10,
 		"vochain consensus block time target (in seconds)")
 	globalCfg.Vochain.SkipPreviousOffchainData = *flag.Bool("skipPreviousOffchainData", false,
 		"if enabled the census downloader will import all existing census")
+	globalCfg.Vochain.OffchainDataMaxAttempts = *flag.Int("offchainDataMaxAttempts", 0,
+		"number of download attempts before giving up on an off-chain data file (0 retries forever)")
 	globalCfg.Vochain.ProcessArchive = *flag.Bool("processArchive", false,
 		"enables the process archiver component")
 	globalCfg.Vochain.ProcessArchiveKey = *flag.String("processArchiveKey", "",
 		"IPFS base64 encoded private key for process archive IPNS")
+	globalCfg.Vochain.ProcessArchiveS3.Endpoint = *flag.String("processArchiveS3Endpoint", "",
+		"S3-compatible endpoint URL for storing the process archive instead of the data directory")
+	globalCfg.Vochain.ProcessArchiveS3.Region = *flag.String("processArchiveS3Region", "us-east-1",
+		"region of the process archive S3 bucket")
+	globalCfg.Vochain.ProcessArchiveS3.Bucket = *flag.String("processArchiveS3Bucket", "",
+		"S3 bucket for storing the process archive (if empty, the data directory is used)")
+	globalCfg.Vochain.ProcessArchiveS3.Prefix = *flag.String("processArchiveS3Prefix", "archive/",
+		"prefix of the process archive objects within the S3 bucket")
+	globalCfg.Vochain.ProcessArchiveS3.AccessKey = *flag.String("processArchiveS3AccessKey", "",
+		"access key of the process archive S3 bucket")
+	globalCfg.Vochain.ProcessArchiveS3.SecretKey = *flag.String("processArchiveS3SecretKey", "",
+		"secret key of the process archive S3 bucket")
 	globalCfg.Vochain.OffChainDataDownloader = *flag.Bool("offChainDataDownload", true,
 		"enables the off-chain data downloader component")
 	flag.StringVar(&createVochainGenesisFile, "vochainCreateGenesis", "",
@@ -197,6 +223,7 @@ func newConfig() (*config.Config, config.Error) {
 	viper.BindPFlag("enableAPI", flag.Lookup("enableAPI"))
 	viper.BindPFlag("enableRPC", flag.Lookup("enableRPC"))
 	viper.BindPFlag("enableFaucetWithAmount", flag.Lookup("enableFaucetWithAmount"))
+	viper.BindPFlag("adminToken", flag.Lookup("adminToken"))
 	viper.Set("TLS.DirCert", globalCfg.DataDir+"/tls")
 	viper.BindPFlag("TLS.Domain", flag.Lookup("tlsDomain"))
 
@@ -204,6 +231,9 @@ func newConfig() (*config.Config, config.Error) {
 	viper.Set("ipfs.ConfigPath", globalCfg.DataDir+"/ipfs")
 	viper.BindPFlag("ipfs.ConnectKey", flag.Lookup("ipfsConnectKey"))
 	viper.BindPFlag("ipfs.ConnectPeers", flag.Lookup("ipfsConnectPeers"))
+	viper.BindPFlag("ipfs.StorageType", flag.Lookup("storageType"))
+	viper.BindPFlag("ipfs.Gateways", flag.Lookup("storageGateways"))
+	viper.BindPFlag("ipfs.MaxSize", flag.Lookup("storageMaxSize"))
 
 	// vochain
 	viper.Set("vochain.DataDir", globalCfg.DataDir+"/vochain")
@@ -220,11 +250,19 @@ func newConfig() (*config.Config, config.Error) {
 	viper.BindPFlag("vochain.NodeKey", flag.Lookup("vochainNodeKey"))
 	viper.BindPFlag("vochain.NoWaitSync", flag.Lookup("vochainNoWaitSync"))
 	viper.BindPFlag("vochain.MempoolSize", flag.Lookup("vochainMempoolSize"))
+	viper.BindPFlag("vochain.MempoolRecheck", flag.Lookup("vochainMempoolRecheck"))
 	viper.BindPFlag("vochain.MinerTargetBlockTimeSeconds", flag.Lookup("vochainBlockTime"))
 	viper.BindPFlag("vochain.SkipPreviousOffchainData", flag.Lookup("skipPreviousOffchainData"))
+	viper.BindPFlag("vochain.OffchainDataMaxAttempts", flag.Lookup("offchainDataMaxAttempts"))
 	viper.Set("vochain.ProcessArchiveDataDir", globalCfg.DataDir+"/archive")
 	viper.BindPFlag("vochain.ProcessArchive", flag.Lookup("processArchive"))
 	viper.BindPFlag("vochain.ProcessArchiveKey", flag.Lookup("processArchiveKey"))
+	viper.BindPFlag("vochain.ProcessArchiveS3.Endpoint", flag.Lookup("processArchiveS3Endpoint"))
+	viper.BindPFlag("vochain.ProcessArchiveS3.Region", flag.Lookup("processArchiveS3Region"))
+	viper.BindPFlag("vochain.ProcessArchiveS3.Bucket", flag.Lookup("processArchiveS3Bucket"))
+	viper.BindPFlag("vochain.ProcessArchiveS3.Prefix", flag.Lookup("processArchiveS3Prefix"))
+	viper.BindPFlag("vochain.ProcessArchiveS3.AccessKey", flag.Lookup("processArchiveS3AccessKey"))
+	viper.BindPFlag("vochain.ProcessArchiveS3.SecretKey", flag.Lookup("processArchiveS3SecretKey"))
 	viper.BindPFlag("vochain.OffChainDataDownload", flag.Lookup("offChainDataDownload"))
 
 	// metrics
@@ -574,6 +612,25 @@ func main() {
 					log.Fatal(err)
 				}
 			}
+			// attach the process archive endpoints if the archiver is enabled
+			if srv.ProcessArchive != nil {
+				if err := archive.AttachArchiveAPI(srv.ProcessArchive,
+					uAPI.RouterHandler(),
+					"/archive",
+				); err != nil {
+					log.Fatal(err)
+				}
+			}
+			// attach the admin endpoints of the download queue if an admin token is set
+			if globalCfg.AdminToken != "" && srv.DataDownloader != nil {
+				uAPI.RouterHandler().SetAdminToken(globalCfg.AdminToken)
+				if err := downloads.AttachDownloadsAPI(srv.DataDownloader,
+					uAPI.RouterHandler(),
+					"/downloads",
+				); err != nil {
+					log.Fatal(err)
+				}
+			}
 		}
 	}
 
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	flag "github.com/spf13/pflag"
+	"go.vocdoni.io/dvote/apiclient"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/internal"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
+	"go.vocdoni.io/proto/build/go/models"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const usage = `Usage: %s <command> [flags]
+
+Offline transaction signing, for keys kept on machines without network access:
+  build    build unsigned transactions online, fetching the chain id and nonces
+  inspect  display the decoded transactions of a file and their signatures
+  sign     sign the transactions of a file offline, after displaying them
+  submit   send the signed transactions of a file online, in order
+
+Run a command with --help for its flags.
+`
+
+// offlinetx splits sending a transaction into building it online, signing it
+// on an air-gapped machine and sending it online, with a portable JSON file
+// (apiclient.OfflineTxFile) holding a batch of transactions between the steps.
+func main() {
+	// Report the version before loading the config or logger init, just in case something goes wrong.
+	// For the sake of including the version in the log, it's also included in a log line later on.
+	fmt.Fprintf(os.Stderr, "vocdoni version %q\n", internal.Version)
+
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") {
+		fmt.Fprintf(os.Stderr, usage, filepath.Base(os.Args[0]))
+		os.Exit(2)
+	}
+	commands := map[string]func(fs *flag.FlagSet, args []string) error{
+		"build":   buildCmd,
+		"inspect": inspectCmd,
+		"sign":    signCmd,
+		"submit":  submitCmd,
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, usage, filepath.Base(os.Args[0]))
+		os.Exit(2)
+	}
+	fs := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	if err := cmd(fs, os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseFlags adds the common flags, parses the command flags and initializes the logger.
+func parseFlags(fs *flag.FlagSet, args []string) {
+	logLevel := fs.String("logLevel", "error", "log level [error,warn,info,debug]")
+	if err := fs.Parse(args); err != nil {
+		log.Fatal(err)
+	}
+	log.Init(*logLevel, "stderr")
+}
+
+func newClient(host string) (*apiclient.HTTPclient, error) {
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+	return apiclient.NewHTTPclient(hostURL, nil)
+}
+
+// splitPair splits a value of the form key:value.
+func splitPair(s string) (string, string, error) {
+	k, v, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", "", fmt.Errorf("invalid value %q, expected key:value", s)
+	}
+	return k, v, nil
+}
+
+func buildCmd(fs *flag.FlagSet, args []string) error {
+	host := fs.String("host", "https://api-dev.vocdoni.net/v2", "API host to fetch the chain id and nonces from")
+	signer := fs.String("signer", "", "address of the account that will sign the transactions")
+	output := fs.StringP("output", "o", "txs.json", "offline transactions file, appended to if it exists")
+	transfers := fs.StringArray("transfer", nil, "send tokens, as address:amount")
+	mints := fs.StringArray("mint", nil, "mint tokens (treasurer), as address:amount")
+	vestings := fs.StringArray("vestingTransfer", nil,
+		"send tokens locked by a vesting schedule, as address:amount:cliffBlocks:vestingBlocks")
+	statuses := fs.StringArray("electionStatus", nil,
+		"set the status of an election, as electionId:status [READY,ENDED,CANCELED,PAUSED]")
+	txFiles := fs.StringArray("tx", nil, "file with a transaction (models.Tx) in protobuf JSON format")
+	validUntilHeight := fs.Uint32("validUntilHeight", 0, "last block height the transactions can be included in")
+	validFor := fs.Duration("validFor", 0, "time the transactions can be included in a block for, from now")
+	parseFlags(fs, args)
+	if !common.IsHexAddress(*signer) {
+		return fmt.Errorf("invalid signer address %q", *signer)
+	}
+	from := common.HexToAddress(*signer)
+
+	var txs []*models.Tx
+	for _, t := range *transfers {
+		to, amount, err := splitPair(t)
+		if err != nil {
+			return err
+		}
+		value, err := strconv.ParseUint(amount, 10, 64)
+		if err != nil || !common.IsHexAddress(to) {
+			return fmt.Errorf("invalid transfer %q", t)
+		}
+		txs = append(txs, &models.Tx{Payload: &models.Tx_SendTokens{SendTokens: &models.SendTokensTx{
+			Txtype: models.TxType_SEND_TOKENS,
+			From:   from.Bytes(),
+			To:     common.HexToAddress(to).Bytes(),
+			Value:  value,
+		}}})
+	}
+	for _, v := range *vestings {
+		fields := strings.Split(v, ":")
+		if len(fields) != 4 || !common.IsHexAddress(fields[0]) {
+			return fmt.Errorf("invalid vesting transfer %q", v)
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid vesting transfer %q", v)
+		}
+		cliff, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid vesting transfer %q", v)
+		}
+		vesting, err := strconv.ParseUint(fields[3], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid vesting transfer %q", v)
+		}
+		txs = append(txs, &models.Tx{
+			Payload: &models.Tx_SendVestingTokens{
+				SendVestingTokens: &models.SendVestingTokensTx{
+					Txtype:        models.TxType_SEND_VESTING_TOKENS,
+					To:            common.HexToAddress(fields[0]).Bytes(),
+					Value:         value,
+					CliffBlocks:   uint32(cliff),
+					VestingBlocks: uint32(vesting),
+				},
+			},
+		})
+	}
+	for _, m := range *mints {
+		to, amount, err := splitPair(m)
+		if err != nil {
+			return err
+		}
+		value, err := strconv.ParseUint(amount, 10, 64)
+		if err != nil || !common.IsHexAddress(to) {
+			return fmt.Errorf("invalid mint %q", m)
+		}
+		txs = append(txs, &models.Tx{Payload: &models.Tx_MintTokens{MintTokens: &models.MintTokensTx{
+			Txtype: models.TxType_MINT_TOKENS,
+			To:     common.HexToAddress(to).Bytes(),
+			Value:  value,
+		}}})
+	}
+	for _, s := range *statuses {
+		id, status, err := splitPair(s)
+		if err != nil {
+			return err
+		}
+		electionID, err := hex.DecodeString(util.TrimHex(id))
+		if err != nil {
+			return fmt.Errorf("invalid election id %q", id)
+		}
+		statusValue, ok := models.ProcessStatus_value[strings.ToUpper(status)]
+		if !ok {
+			return fmt.Errorf("invalid election status %q", status)
+		}
+		processStatus := models.ProcessStatus(statusValue)
+		txs = append(txs, &models.Tx{Payload: &models.Tx_SetProcess{SetProcess: &models.SetProcessTx{
+			Txtype:    models.TxType_SET_PROCESS_STATUS,
+			ProcessId: electionID,
+			Status:    &processStatus,
+		}}})
+	}
+	for _, file := range *txFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		tx := &models.Tx{}
+		if err := protojson.Unmarshal(data, tx); err != nil {
+			return fmt.Errorf("cannot decode %s: %w", file, err)
+		}
+		txs = append(txs, tx)
+	}
+	if len(txs) == 0 {
+		return errors.New("no transactions given")
+	}
+
+	var file *apiclient.OfflineTxFile
+	if _, err := os.Stat(*output); err == nil {
+		if file, err = apiclient.LoadOfflineTxFile(*output); err != nil {
+			return err
+		}
+	}
+	cli, err := newClient(*host)
+	if err != nil {
+		return err
+	}
+	builder, err := cli.NewOfflineTxBuilder(file)
+	if err != nil {
+		return err
+	}
+	if *validUntilHeight > 0 || *validFor > 0 {
+		expiry := &vochaintx.Expiry{Height: *validUntilHeight}
+		if *validFor > 0 {
+			expiry.Timestamp = uint64(time.Now().Add(*validFor).Unix())
+		}
+		builder.SetExpiry(expiry)
+	}
+	for _, tx := range txs {
+		if err := builder.Add(from, tx); err != nil {
+			return err
+		}
+	}
+	if err := builder.File().Save(*output); err != nil {
+		return err
+	}
+	fmt.Printf("added %d transactions to %s (%d in total) for chain %s\n",
+		len(txs), *output, len(builder.File().Transactions), builder.File().ChainID)
+	return nil
+}
+
+// printTx displays the decoded transaction and the status of its signature.
+func printTx(index int, file *apiclient.OfflineTxFile, t *apiclient.OfflineTx) error {
+	tx, err := t.Decode()
+	if err != nil {
+		return fmt.Errorf("transaction %d: %w", index, err)
+	}
+	status := "unsigned"
+	if signed, err := t.Verify(file.ChainID); err != nil {
+		status = "INVALID SIGNATURE: " + err.Error()
+	} else if signed {
+		status = "signed"
+	}
+	txJSON, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(tx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("#%d %s by %s (%s)\n", index, t.Type, t.Signer, status)
+	if summary := txSummary(tx); summary != "" {
+		fmt.Println(summary)
+	}
+	if expiry := vochaintx.GetTxExpiry(tx); expiry != nil {
+		fmt.Println(expirySummary(expiry))
+	}
+	fmt.Printf("%s\n\n", txJSON)
+	return nil
+}
+
+// expirySummary describes the validity window of a transaction.
+func expirySummary(e *vochaintx.Expiry) string {
+	var limits []string
+	if e.Height > 0 {
+		limits = append(limits, fmt.Sprintf("height %d", e.Height))
+	}
+	if e.Timestamp > 0 {
+		limits = append(limits, time.Unix(int64(e.Timestamp), 0).UTC().Format(time.RFC3339))
+	}
+	return "valid until " + strings.Join(limits, " and ")
+}
+
+// txSummary describes the common transactions with hexadecimal addresses and
+// ids, since the protobuf JSON format encodes them in base64.
+func txSummary(tx *models.Tx) string {
+	switch payload := tx.Payload.(type) {
+	case *models.Tx_SendTokens:
+		t := payload.SendTokens
+		return fmt.Sprintf("send %d tokens from %s to %s (nonce %d)",
+			t.Value, common.BytesToAddress(t.From), common.BytesToAddress(t.To), t.Nonce)
+	case *models.Tx_MintTokens:
+		t := payload.MintTokens
+		return fmt.Sprintf("mint %d tokens to %s (nonce %d)", t.Value, common.BytesToAddress(t.To), t.Nonce)
+	case *models.Tx_SetProcess:
+		t := payload.SetProcess
+		return fmt.Sprintf("%s of election %x to %s (nonce %d)", t.Txtype, t.ProcessId, t.GetStatus(), t.Nonce)
+	case *models.Tx_SendVestingTokens:
+		t := payload.SendVestingTokens
+		return fmt.Sprintf("send %d vesting tokens to %s, with a cliff of %d and vesting of %d blocks (nonce %d)",
+			t.Value, common.BytesToAddress(t.To), t.CliffBlocks, t.VestingBlocks, t.Nonce)
+	}
+	return ""
+}
+
+func inspectCmd(fs *flag.FlagSet, args []string) error {
+	input := fs.StringP("file", "f", "txs.json", "offline transactions file")
+	parseFlags(fs, args)
+	file, err := apiclient.LoadOfflineTxFile(*input)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("chain %s, %d transactions\n\n", file.ChainID, len(file.Transactions))
+	for i, t := range file.Transactions {
+		if err := printTx(i, file, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func signCmd(fs *flag.FlagSet, args []string) error {
+	input := fs.StringP("file", "f", "txs.json", "offline transactions file, updated with the signatures")
+	keyFile := fs.String("keyFile", "", "file with the hexadecimal private key of the signer")
+	yes := fs.Bool("yes", false, "sign without asking for confirmation")
+	parseFlags(fs, args)
+	file, err := apiclient.LoadOfflineTxFile(*input)
+	if err != nil {
+		return err
+	}
+	if *keyFile == "" {
+		return errors.New("missing --keyFile")
+	}
+	keyHex, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	key := ethereum.NewSignKeys()
+	if err := key.AddHexKey(strings.TrimSpace(string(keyHex))); err != nil {
+		return fmt.Errorf("invalid private key: %w", err)
+	}
+
+	pending := 0
+	for i, t := range file.Transactions {
+		if t.Signer != key.Address() || len(t.Signature) > 0 {
+			continue
+		}
+		if err := printTx(i, file, t); err != nil {
+			return err
+		}
+		pending++
+	}
+	if pending == 0 {
+		return fmt.Errorf("no unsigned transactions for %s", key.Address())
+	}
+	if !*yes {
+		fmt.Printf("sign %d transactions for chain %s with %s? [y/N] ", pending, file.ChainID, key.Address())
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			return errors.New("signing aborted")
+		}
+	}
+	signed, err := file.Sign(key)
+	if err != nil {
+		return err
+	}
+	if err := file.Save(*input); err != nil {
+		return err
+	}
+	fmt.Printf("signed %d transactions in %s\n", signed, *input)
+	return nil
+}
+
+func submitCmd(fs *flag.FlagSet, args []string) error {
+	host := fs.String("host", "https://api-dev.vocdoni.net/v2", "API host to send the transactions to")
+	input := fs.StringP("file", "f", "txs.json", "offline transactions file")
+	timeout := fs.Duration("timeout", 2*time.Minute, "maximum time to wait for each transaction to be mined")
+	parseFlags(fs, args)
+	file, err := apiclient.LoadOfflineTxFile(*input)
+	if err != nil {
+		return err
+	}
+	cli, err := newClient(*host)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout*time.Duration(len(file.Transactions)))
+	defer cancel()
+	hashes, err := cli.SendOfflineTxs(ctx, file)
+	for i, hash := range hashes {
+		fmt.Printf("#%d %s mined\n", i, hash.String())
+	}
+	return err
+}
@@ -23,12 +23,14 @@ import (
 
 // VoconeConfig contains the basic configuration for the voconed
 type VoconeConfig struct {
-	logLevel, dir, keymanager, path, treasurer, chainID string
-	port, blockSeconds, blockSize                       int
-	txCosts                                             uint64
-	disableIpfs                                         bool
-	fundedAccounts                                      []string
-	enableFaucetWithAmount                              uint64
+	logLevel, dir, keymanager, path, treasurer, chainID, adminToken string
+	forkSnapshot, forkDataDir                                       string
+	port, blockSeconds, blockSize                                   int
+	forkHeight                                                      uint32
+	txCosts                                                         uint64
+	disableIpfs, manualBlocks                                       bool
+	fundedAccounts                                                  []string
+	enableFaucetWithAmount                                          uint64
 }
 
 func main() {
@@ -56,6 +58,16 @@ func main() {
 	flag.BoolVar(&config.disableIpfs, "disableIpfs", false, "disable built-in IPFS node")
 	flag.StringSliceVar(&config.fundedAccounts, "fundedAccounts", []string{},
 		"list of pre-funded accounts (address:balance,address:balance,...)")
+	flag.StringVar(&config.adminToken, "adminToken", "",
+		"bearer token for the admin API, which allows to commit blocks and move the clock forward")
+	flag.BoolVar(&config.manualBlocks, "manualBlocks", false,
+		"if true, blocks are only produced on demand via the admin API (requires adminToken)")
+	flag.StringVar(&config.forkSnapshot, "forkSnapshot", "",
+		"state snapshot file to fork the vocone state from, on a new data directory")
+	flag.StringVar(&config.forkDataDir, "forkDataDir", "",
+		"copy of a node data directory to fork the vocone state from, on a new data directory")
+	flag.Uint32Var(&config.forkHeight, "forkHeight", 0,
+		"height of the state forked from forkDataDir, the last one if zero")
 	flag.CommandLine.SortFlags = false
 	flag.Parse()
 
@@ -125,6 +137,31 @@ func main() {
 	}
 	*setTxCosts = pviper.GetBool("setTxCosts")
 
+	if err := pviper.BindPFlag("adminToken", flag.Lookup("adminToken")); err != nil {
+		panic(err)
+	}
+	config.adminToken = pviper.GetString("adminToken")
+
+	if err := pviper.BindPFlag("manualBlocks", flag.Lookup("manualBlocks")); err != nil {
+		panic(err)
+	}
+	config.manualBlocks = pviper.GetBool("manualBlocks")
+
+	if err := pviper.BindPFlag("forkSnapshot", flag.Lookup("forkSnapshot")); err != nil {
+		panic(err)
+	}
+	config.forkSnapshot = pviper.GetString("forkSnapshot")
+
+	if err := pviper.BindPFlag("forkDataDir", flag.Lookup("forkDataDir")); err != nil {
+		panic(err)
+	}
+	config.forkDataDir = pviper.GetString("forkDataDir")
+
+	if err := pviper.BindPFlag("forkHeight", flag.Lookup("forkHeight")); err != nil {
+		panic(err)
+	}
+	config.forkHeight = pviper.GetUint32("forkHeight")
+
 	_, err = os.Stat(filepath.Join(config.dir, "voconed.yml"))
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -168,7 +205,16 @@ func main() {
 		log.Fatal(err)
 	}
 
-	vc, err := vocone.NewVocone(config.dir, &mngKey)
+	var vc *vocone.Vocone
+	if config.forkSnapshot != "" || config.forkDataDir != "" {
+		vc, err = vocone.NewForkedVocone(config.dir, &mngKey, &vocone.ForkConfig{
+			Snapshot: config.forkSnapshot,
+			DataDir:  config.forkDataDir,
+			Height:   config.forkHeight,
+		})
+	} else {
+		vc, err = vocone.NewVocone(config.dir, &mngKey)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -215,12 +261,27 @@ func main() {
 
 	vc.SetBlockTimeTarget(time.Second * time.Duration(config.blockSeconds))
 	vc.SetBlockSize(config.blockSize)
-	go vc.Start()
+	if config.manualBlocks {
+		if config.adminToken == "" {
+			log.Fatal("manualBlocks requires an adminToken")
+		}
+		log.Infof("blocks are only produced on demand via the admin API")
+	} else {
+		go vc.Start()
+	}
 	uAPI, err := vc.EnableAPI("0.0.0.0", config.port, config.path)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// enable the admin API for committing blocks and moving the clock forward
+	if config.adminToken != "" {
+		uAPI.RouterHandler().SetAdminToken(config.adminToken)
+		if err := vc.AttachAdminAPI(uAPI.RouterHandler(), "/vocone"); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	// enable faucet if requested, this will create a new account and attach the faucet API to the vocone API
 	if config.enableFaucetWithAmount > 0 {
 		faucetAccount := ethereum.SignKeys{}
@@ -46,6 +46,9 @@ type Config struct {
 	EnableRPC bool
 	// EnableFaucet enables the faucet API service for the given amounts
 	EnableFaucetWithAmount uint64
+	// AdminToken is the bearer token of the admin API endpoints, which are
+	// disabled if empty
+	AdminToken string
 }
 
 // ValidMode checks if the configured mode is valid
@@ -93,6 +96,14 @@ type IPFSCfg struct {
 	ConnectKey string
 	// ConnectPeers is the list of ipfsConnect peers
 	ConnectPeers []string
+	// StorageType is the data storage used (IPFS or LOCAL)
+	StorageType string
+	// Gateways are the HTTP gateways used by the LOCAL storage to retrieve
+	// the files not stored locally
+	Gateways []string
+	// MaxSize is the maximum size in bytes of the files kept by the LOCAL
+	// storage, zero means no limit
+	MaxSize int64
 }
 
 // VochainCfg includes all possible config params needed by the Vochain
@@ -132,8 +143,15 @@ type VochainCfg struct {
 	NoWaitSync bool
 	// MempoolSize is the size of the mempool
 	MempoolSize int
+	// MempoolRecheck if enabled, the mempool transactions are checked again after
+	// each block, so the ones that are no longer valid (i.e. expired) are evicted
+	MempoolRecheck bool
 	// SkipPreviousOffchainData if enabled, the node will skip downloading the previous off-chain data to the current block
 	SkipPreviousOffchainData bool
+	// OffchainDataMaxAttempts is the number of download attempts before an
+	// off-chain data file is marked as failed, and removed after a while.
+	// If zero, the downloads are retried forever.
+	OffchainDataMaxAttempts int
 	// Enable Prometheus metrics from tendermint
 	TendermintMetrics bool
 	// Target block time in seconds (only for miners)
@@ -144,6 +162,9 @@ type VochainCfg struct {
 	ProcessArchiveKey string
 	// Data directory for storing the process archive
 	ProcessArchiveDataDir string
+	// S3-compatible bucket for storing the process archive, used instead of
+	// the data directory if the bucket is set
+	ProcessArchiveS3 ArchiveS3Cfg
 	// Indexer holds the configuration regarding the indexer component
 	Indexer IndexerCfg
 	// IsSeedNode specifies if the node is configured to act as a seed node
@@ -160,6 +181,21 @@ type IndexerCfg struct {
 	IgnoreLiveResults bool
 }
 
+// ArchiveS3Cfg holds the parameters to access an S3-compatible bucket
+type ArchiveS3Cfg struct {
+	// Endpoint is the URL of the S3 service
+	Endpoint string
+	// Region of the bucket
+	Region string
+	// Bucket name
+	Bucket string
+	// Prefix of the archive objects within the bucket
+	Prefix string
+	// AccessKey and SecretKey are the credentials to sign the requests
+	AccessKey string
+	SecretKey string
+}
+
 // OracleCfg includes all possible config params needed by the Oracle
 type OracleCfg struct {
 	// Dev indicates we use the development mode
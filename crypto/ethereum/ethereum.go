@@ -170,6 +170,19 @@ func (k *SignKeys) SignVocdoniTx(txData []byte, chainID string) ([]byte, error)
 	return signature, nil
 }
 
+// SignVocdoniSponsoredTx signs a vocdoni transaction as its sponsor, who pays its cost.
+// ElectionID is the optional election the cost is accounted to.
+func (k *SignKeys) SignVocdoniSponsoredTx(txData, electionID []byte, chainID string) ([]byte, error) {
+	if k.Private.D == nil {
+		return nil, errors.New("no private key available")
+	}
+	signature, err := ethcrypto.Sign(Hash(BuildVocdoniSponsoredTransaction(txData, electionID, chainID)), &k.Private)
+	if err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
 // SignVocdoniMsg signs a vocdoni message. Message is the full payload (no HexString nor a Hash)
 func (k *SignKeys) SignVocdoniMsg(message []byte) ([]byte, error) {
 	if k.Private.D == nil {
@@ -272,6 +285,14 @@ func BuildVocdoniTransaction(txData []byte, chainID string) []byte {
 	return buf.Bytes()
 }
 
+// BuildVocdoniSponsoredTransaction builds the payload of a vocdoni transaction
+// ready to be signed by its sponsor
+func BuildVocdoniSponsoredTransaction(txData, electionID []byte, chainID string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Vocdoni sponsored transaction:\n%s\n%x\n%x", chainID, HashRaw(txData), electionID)
+	return buf.Bytes()
+}
+
 // BuildVocdoniMessage builds the payload of a vocdoni message
 // ready to be signed
 func BuildVocdoniMessage(message []byte) []byte {
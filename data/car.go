@@ -0,0 +1,107 @@
+package data
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	ipfscid "github.com/ipfs/go-cid"
+	car "github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	"go.vocdoni.io/dvote/log"
+)
+
+// CARBlock is a file stored in a CAR (Content Addressable aRchive) v1.
+type CARBlock struct {
+	CID  ipfscid.Cid
+	Data []byte
+}
+
+// WriteCAR writes a CAR v1 archive with the files. Each file is stored as a
+// single block identified by its CIDv1 with JSON codec, the one returned by
+// CalculateIPFSCIDv1json, so they can be imported without rebuilding any DAG.
+// If no roots are given, all the files are roots of the archive.
+func WriteCAR(w io.Writer, roots []string, files ...[]byte) error {
+	cids := make([]ipfscid.Cid, len(files))
+	for i, file := range files {
+		cid, err := ipfscid.Decode(CalculateIPFSCIDv1json(file))
+		if err != nil {
+			return fmt.Errorf("cannot calculate CID: %w", err)
+		}
+		cids[i] = cid
+	}
+	header := &car.CarHeader{Version: 1, Roots: cids}
+	if len(roots) > 0 {
+		header.Roots = make([]ipfscid.Cid, len(roots))
+		for i, root := range roots {
+			cid, err := parseCID(root)
+			if err != nil {
+				return err
+			}
+			header.Roots[i] = cid
+		}
+	}
+	if len(header.Roots) == 0 {
+		return fmt.Errorf("cannot write an empty CAR")
+	}
+	bw := bufio.NewWriter(w)
+	if err := car.WriteHeader(header, bw); err != nil {
+		return err
+	}
+	for i, file := range files {
+		if err := carutil.LdWrite(bw, cids[i].Bytes(), file); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadCAR reads a CAR v1 archive, returning its roots and blocks. The content
+// of each block is verified against its CID, so no network access is required
+// to trust the archive contents.
+func ReadCAR(r io.Reader) ([]ipfscid.Cid, []*CARBlock, error) {
+	cr, err := car.NewCarReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read CAR header: %w", err)
+	}
+	blocks := []*CARBlock{}
+	for {
+		block, err := cr.Next()
+		if errors.Is(err, io.EOF) {
+			return cr.Header.Roots, blocks, nil
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot read CAR block: %w", err)
+		}
+		if len(block.RawData()) > MaxFileSizeBytes {
+			return nil, nil, fmt.Errorf("file %s too big: (size:%d)", block.Cid(), len(block.RawData()))
+		}
+		blocks = append(blocks, &CARBlock{CID: block.Cid(), Data: block.RawData()})
+	}
+}
+
+// ImportCAR reads a CAR v1 archive and publishes its verified blocks into the
+// storage, which pins them. It returns the CIDs of the imported files, with
+// the JSON codec used by the storage.
+func ImportCAR(ctx context.Context, storage Storage, r io.Reader) ([]string, error) {
+	_, blocks, err := ReadCAR(r)
+	if err != nil {
+		return nil, err
+	}
+	cids := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		cid, err := storage.Publish(ctx, block.Data)
+		if err != nil {
+			return nil, fmt.Errorf("cannot import file %s: %w", block.CID, err)
+		}
+		if c, err := parseCID(cid); err != nil || !IPFSCIDv1json(c).Equals(IPFSCIDv1json(block.CID)) {
+			// i.e. the file is chunked by the storage as a DAG
+			log.Warnw("imported file CID differs from the archive one",
+				"archive", block.CID.String(), "storage", cid)
+		}
+		cids = append(cids, cid)
+	}
+	return cids, nil
+}
@@ -0,0 +1,63 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/types"
+)
+
+func TestCAR(t *testing.T) {
+	files := [][]byte{[]byte(`{"a":1}`), []byte(`{"b":2}`), []byte(`{"c":3}`)}
+	car := &bytes.Buffer{}
+	qt.Assert(t, WriteCAR(car, nil, files...), qt.IsNil)
+
+	roots, blocks, err := ReadCAR(bytes.NewReader(car.Bytes()))
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, roots, qt.HasLen, 3)
+	qt.Assert(t, blocks, qt.HasLen, 3)
+	for i, block := range blocks {
+		qt.Assert(t, block.CID.String(), qt.Equals, CalculateIPFSCIDv1json(files[i]))
+		qt.Assert(t, roots[i].Equals(block.CID), qt.IsTrue)
+		qt.Assert(t, block.Data, qt.DeepEquals, files[i])
+	}
+
+	// with explicit roots
+	car.Reset()
+	root := CalculateIPFSCIDv1json(files[1])
+	qt.Assert(t, WriteCAR(car, []string{"ipfs://" + root}, files...), qt.IsNil)
+	roots, _, err = ReadCAR(bytes.NewReader(car.Bytes()))
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, roots, qt.HasLen, 1)
+	qt.Assert(t, roots[0].String(), qt.Equals, root)
+
+	// a tampered block does not match its CID
+	tampered := bytes.Replace(car.Bytes(), []byte(`{"c":3}`), []byte(`{"c":4}`), 1)
+	_, _, err = ReadCAR(bytes.NewReader(tampered))
+	qt.Assert(t, err, qt.IsNotNil)
+
+	qt.Assert(t, WriteCAR(car, nil), qt.IsNotNil)
+}
+
+func TestImportCAR(t *testing.T) {
+	files := [][]byte{[]byte(`{"a":1}`), []byte(`{"b":2}`)}
+	car := &bytes.Buffer{}
+	qt.Assert(t, WriteCAR(car, nil, files...), qt.IsNil)
+
+	l := newTestLocalStorage(t, &types.DataStore{})
+	ctx := context.Background()
+	cids, err := ImportCAR(ctx, l, car)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, cids, qt.HasLen, 2)
+	for i, cid := range cids {
+		qt.Assert(t, cid, qt.Equals, CalculateIPFSCIDv1json(files[i]))
+		content, err := l.Retrieve(ctx, cid, 0)
+		qt.Assert(t, err, qt.IsNil)
+		qt.Assert(t, content, qt.DeepEquals, files[i])
+	}
+	pins, err := l.ListPins(ctx)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, pins, qt.HasLen, 2)
+}
@@ -1,6 +1,7 @@
 package compressor
 
 import (
+	"io"
 	"time"
 
 	"github.com/klauspost/compress/zstd"
@@ -82,3 +83,19 @@ func (c Compressor) DecompressBytes(src []byte) []byte {
 		float64(len(dst)*100)/float64(len(src)))
 	return dst
 }
+
+// NewWriter returns a writer which compresses the written data via zstd and writes
+// it to w. It must be closed to flush the compressed data.
+func NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w)
+}
+
+// NewReader returns a reader which decompresses via zstd the data read from r.
+// Unlike DecompressBytes, the input must be compressed.
+func NewReader(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
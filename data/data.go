@@ -1,4 +1,5 @@
-// Package data provides an abstraction layer for distributed data storage providers (currently only IPFS)
+// Package data provides an abstraction layer for distributed data storage providers (currently IPFS
+// and a local content-addressed storage)
 package data
 
 import (
@@ -29,6 +30,7 @@ type StorageID int
 const (
 	IPFS StorageID = iota + 1
 	BZZ
+	LOCAL
 )
 
 func StorageIDFromString(i string) StorageID {
@@ -37,6 +39,8 @@ func StorageIDFromString(i string) StorageID {
 		return IPFS
 	case "BZZ":
 		return BZZ
+	case "LOCAL":
+		return LOCAL
 	default:
 		return -1
 	}
@@ -56,6 +60,10 @@ func Init(t StorageID, d *types.DataStore) (Storage, error) {
 		s := new(IPFSHandle)
 		err := s.Init(d)
 		return s, err
+	case LOCAL:
+		s := new(LocalStorage)
+		err := s.Init(d)
+		return s, err
 	default:
 		return nil, errors.New("bad storage type or DataStore specification")
 	}
@@ -1,17 +1,20 @@
 package downloader
 
 import (
+	"container/heap"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"golang.org/x/exp/maps"
-
 	"go.vocdoni.io/dvote/data"
+	"go.vocdoni.io/dvote/db"
 	"go.vocdoni.io/dvote/log"
 )
 
@@ -27,39 +30,166 @@ const (
 	ImportPinTimeout = 3 * time.Minute
 	// MaxFileSize is the maximum size of a file that can be imported.
 	MaxFileSize = 100 * 1024 * 1024 // 100MB
+	// DefaultRetryDelay is the delay before the first retry of a failed download,
+	// which is doubled on each attempt.
+	DefaultRetryDelay = 10 * time.Second
+	// DefaultMaxRetryDelay is the maximum delay between two download attempts.
+	DefaultMaxRetryDelay = time.Hour
+	// DefaultFailedItemsTTL is the time the failed items are kept in the queue,
+	// so they can be inspected and requeued, before they are removed.
+	DefaultFailedItemsTTL = 7 * 24 * time.Hour
+
+	// ContentTypeJSON is the content type of the files that are valid JSON.
+	ContentTypeJSON = "application/json"
+
+	// pollInterval is the maximum time the queue daemons wait before looking
+	// for items whose retry delay has expired.
+	pollInterval = time.Second
+)
+
+// Priorities of the download items, the items with lower values are
+// downloaded first.
+const (
+	PriorityHigh = iota
+	PriorityNormal
+	PriorityLow
+)
 
-	importQueueBuffer = 32
+var (
+	// ErrHandlerUnknown is returned when enqueuing an item for a handler that
+	// is not registered.
+	ErrHandlerUnknown = errors.New("download handler unknown")
+	// ErrItemNotFound is returned when the URI is not in the queue.
+	ErrItemNotFound = errors.New("download item not found")
+	// ErrFileTooBig is returned when the file is bigger than the maximum size
+	// allowed by the handlers of the item.
+	ErrFileTooBig = errors.New("file too big")
+	// ErrContentTypeNotAllowed is returned when the content type of the file
+	// is not allowed by the handlers of the item.
+	ErrContentTypeNotAllowed = errors.New("content type not allowed")
 )
 
-// Downloader is a remote file downloader that uses queues.
+// Handler processes the downloaded files of the items enqueued for it.
+type Handler struct {
+	// Priority of the items of the handler, PriorityHigh, PriorityNormal or
+	// PriorityLow.
+	Priority int
+	// MaxSize is the maximum size of the files, MaxFileSize if zero.
+	MaxSize int64
+	// ContentTypes is the list of allowed content types of the files, as
+	// returned by ContentType. If empty, any content type is allowed.
+	ContentTypes []string
+	// Callback is called with the URI, the reference given when enqueuing the
+	// item and the file contents once the file is downloaded.
+	Callback func(uri string, ref []byte, data []byte)
+}
+
+// Downloader is a remote file downloader that uses a persistent queue.
 type Downloader struct {
 	RemoteStorage data.Storage
+	// RetryDelay is the delay before the first retry of a failed download,
+	// which is doubled on each attempt up to MaxRetryDelay.
+	RetryDelay time.Duration
+	// MaxRetryDelay is the maximum delay between two download attempts.
+	MaxRetryDelay time.Duration
+	// MaxAttempts is the number of download attempts before an item is
+	// marked as failed. If zero, the default, the downloads are retried
+	// forever; only the files rejected by the handlers are marked as failed.
+	MaxAttempts int
+	// FailedItemsTTL is the time the failed items are kept in the queue
+	// before they are removed, unless they are requeued.
+	FailedItemsTTL time.Duration
+
+	db        db.Database
+	queueLock sync.Mutex
+	queue     map[string]*DownloadItem
+	// pending holds the items waiting to be downloaded for each priority,
+	// ordered by their next attempt, and failedItems the failed items ordered by
+	// the time they expire. The items in flight are in none of them.
+	pending     [PriorityLow + 1]itemHeap
+	failedItems itemHeap
+	inFlight    map[string]bool
+	callbacks   map[string][]func(string, []byte)
+	handlers    map[string]*Handler
+	wakeup      chan struct{}
 
-	importQueue     chan DownloadItem
-	queueSize       atomic.Int32
-	failedQueueLock sync.RWMutex
-	failedQueue     map[string]*DownloadItem
-	cancel          context.CancelFunc
-	wgQueueDaemons  sync.WaitGroup
-	addedItems      atomic.Int32
+	cancel         context.CancelFunc
+	wgQueueDaemons sync.WaitGroup
+	addedItems     atomic.Int32
 }
 
 // DownloadItem is a remote file to be downloaded.
 type DownloadItem struct {
-	URI      string
-	Callback func(URI string, data []byte)
-	Pin      bool
+	URI         string           `json:"uri"`
+	Priority    int              `json:"priority"`
+	Pin         bool             `json:"pin"`
+	Targets     []DownloadTarget `json:"targets,omitempty"`
+	Added       time.Time        `json:"added"`
+	Attempts    int              `json:"attempts"`
+	NextAttempt time.Time        `json:"nextAttempt"`
+	LastError   string           `json:"lastError,omitempty"`
+	Failed      bool             `json:"failed"`
+	// Expires is the time a failed item is removed from the queue.
+	Expires time.Time `json:"expires"`
+
+	// index is the position of the item in its heap, or -1 if it's in none.
+	index int
+}
+
+// DownloadTarget is a handler to be called once the file of the item is
+// downloaded, along with its reference (i.e. the election ID of a metadata).
+type DownloadTarget struct {
+	Handler string `json:"handler"`
+	Ref     []byte `json:"ref,omitempty"`
 }
 
-// NewDownloader returns a new Downloader. After creating a new instance,
-// the process should be started by calling "Start()"
-func NewDownloader(remoteStorage data.Storage) *Downloader {
+// NewDownloader returns a new Downloader whose queue is stored in the
+// database, so the pending items are not lost on restarts. If the database is
+// nil, the queue is kept only in memory. Handlers must be registered before
+// calling "Start()".
+func NewDownloader(remoteStorage data.Storage, database db.Database) (*Downloader, error) {
 	d := &Downloader{
-		RemoteStorage: remoteStorage,
-		importQueue:   make(chan DownloadItem, importQueueBuffer),
-		failedQueue:   make(map[string]*DownloadItem),
+		RemoteStorage:  remoteStorage,
+		RetryDelay:     DefaultRetryDelay,
+		MaxRetryDelay:  DefaultMaxRetryDelay,
+		FailedItemsTTL: DefaultFailedItemsTTL,
+		db:             database,
+		queue:          make(map[string]*DownloadItem),
+		inFlight:       make(map[string]bool),
+		callbacks:      make(map[string][]func(string, []byte)),
+		handlers:       make(map[string]*Handler),
+		wakeup:         make(chan struct{}, ImportQueueRoutines),
 	}
-	return d
+	if database == nil {
+		return d, nil
+	}
+	var itemErr error
+	if err := database.Iterate(nil, func(key, value []byte) bool {
+		item := &DownloadItem{}
+		if itemErr = json.Unmarshal(value, item); itemErr != nil {
+			return false
+		}
+		if item.Failed && item.Expires.IsZero() {
+			item.Expires = time.Now().Add(d.FailedItemsTTL)
+		}
+		d.queue[item.URI] = item
+		d.push(item)
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	if itemErr != nil {
+		return nil, fmt.Errorf("cannot load download queue: %w", itemErr)
+	}
+	log.Infow("download queue loaded", "items", len(d.queue))
+	return d, nil
+}
+
+// RegisterHandler registers the handler of the items enqueued with its name.
+func (d *Downloader) RegisterHandler(name string, h *Handler) {
+	d.queueLock.Lock()
+	defer d.queueLock.Unlock()
+	d.handlers[name] = h
 }
 
 // Start starts the import queue daemons. This is a non-blocking method.
@@ -70,8 +200,6 @@ func (d *Downloader) Start() {
 		d.wgQueueDaemons.Add(1)
 		go d.importQueueDaemon(ctx)
 	}
-	d.wgQueueDaemons.Add(1)
-	go d.importFailedQueueDaemon(ctx)
 }
 
 // PrintLogInfo prints the current status of the downloader. This method is blocking.
@@ -94,20 +222,131 @@ func (d *Downloader) Stop() {
 
 // AddToQueue adds a new URI to the queue for being imported remotely. Once
 // the file is downloaded, the callback is called with the URI as argument.
+// The callback is not persisted, so after a restart the file is only pinned.
+// Use Enqueue with a registered handler for persistent callbacks.
 func (d *Downloader) AddToQueue(URI string, callback func(string, []byte), pin bool) {
-	d.importQueue <- DownloadItem{URI: URI, Callback: callback, Pin: pin}
+	d.queueLock.Lock()
+	if callback != nil {
+		d.callbacks[URI] = append(d.callbacks[URI], callback)
+	}
+	d.queueLock.Unlock()
+	if err := d.enqueue(URI, PriorityNormal, nil, pin); err != nil {
+		log.Warnw("cannot enqueue file", "uri", URI, "error", err)
+	}
+}
+
+// Enqueue adds a new URI to the queue for being imported remotely. Once the
+// file is downloaded, the callback of the handler is called with the given
+// reference. If the URI is already enqueued, the handler is added to the
+// existing item, so the file is downloaded only once.
+func (d *Downloader) Enqueue(URI, handler string, ref []byte, pin bool) error {
+	d.queueLock.Lock()
+	h, ok := d.handlers[handler]
+	d.queueLock.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrHandlerUnknown, handler)
+	}
+	return d.enqueue(URI, h.Priority, &DownloadTarget{Handler: handler, Ref: ref}, pin)
+}
+
+func (d *Downloader) enqueue(URI string, priority int, target *DownloadTarget, pin bool) error {
+	d.queueLock.Lock()
+	defer d.queueLock.Unlock()
+	item, ok := d.queue[URI]
+	if !ok {
+		item = &DownloadItem{
+			URI:         URI,
+			Priority:    priority,
+			Added:       time.Now(),
+			NextAttempt: time.Now(),
+			index:       -1,
+		}
+		d.addedItems.Add(1)
+	}
+	if priority < item.Priority {
+		// move the item to the heap of its new priority
+		queued := d.remove(item)
+		item.Priority = priority
+		if queued {
+			d.push(item)
+		}
+	}
+	item.Pin = item.Pin || pin
+	if target != nil && !item.hasTarget(target) {
+		item.Targets = append(item.Targets, *target)
+	}
+	if err := d.storeItem(item); err != nil {
+		return err
+	}
+	if !ok {
+		d.queue[URI] = item
+		d.push(item)
+		d.notify()
+	}
+	return nil
+}
+
+// Items returns a copy of the items in the queue, sorted by the order they
+// will be downloaded.
+func (d *Downloader) Items() []*DownloadItem {
+	d.queueLock.Lock()
+	defer d.queueLock.Unlock()
+	items := make([]*DownloadItem, 0, len(d.queue))
+	for _, item := range d.queue {
+		c := *item
+		c.Targets = append([]DownloadTarget(nil), item.Targets...)
+		items = append(items, &c)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].before(items[j])
+	})
+	return items
+}
+
+// Requeue resets the attempts of the item, so it is downloaded as soon as
+// possible, even if it was marked as failed.
+func (d *Downloader) Requeue(URI string) error {
+	d.queueLock.Lock()
+	defer d.queueLock.Unlock()
+	item, ok := d.queue[URI]
+	if !ok {
+		return ErrItemNotFound
+	}
+	queued := d.remove(item)
+	item.Attempts = 0
+	item.Failed = false
+	item.Expires = time.Time{}
+	item.NextAttempt = time.Now()
+	if queued {
+		d.push(item)
+	}
+	if err := d.storeItem(item); err != nil {
+		return err
+	}
+	d.notify()
+	return nil
 }
 
-// QueueSize returns the size of the import census queue.
+// QueueSize returns the number of items pending to be downloaded, not
+// including the failed ones.
 func (d *Downloader) QueueSize() int32 {
-	return d.queueSize.Load()
+	d.queueLock.Lock()
+	defer d.queueLock.Unlock()
+	return int32(len(d.queue) - d.failedItems.Len())
 }
 
-// ImportFailedQueueSize is the size of the list of remote census imported that failed.
+// ImportFailedQueueSize is the number of items whose download failed at least
+// once, including the ones marked as failed.
 func (d *Downloader) ImportFailedQueueSize() int {
-	d.failedQueueLock.RLock()
-	defer d.failedQueueLock.RUnlock()
-	return len(d.failedQueue)
+	d.queueLock.Lock()
+	defer d.queueLock.Unlock()
+	size := 0
+	for _, item := range d.queue {
+		if item.Attempts > 0 {
+			size++
+		}
+	}
+	return size
 }
 
 // TotalItemsAdded is the number of items that has been added to the queue on this instance.
@@ -115,102 +354,361 @@ func (d *Downloader) TotalItemsAdded() int32 {
 	return d.addedItems.Load()
 }
 
-// handleImport fetches and imports a remote file. If the download fails, the file
-// is added to a secondary queue for retrying.
-func (d *Downloader) handleImport(item *DownloadItem) {
-	log.Debugw("pining remote file", "uri", item.URI)
-	d.queueAddDelta(1)
-	defer d.queueAddDelta(-1)
-	ctx, cancel := context.WithTimeout(context.Background(), ImportRetrieveTimeout)
-	err := d.RemoteStorage.Pin(ctx, item.URI)
-	cancel()
-	if err != nil {
-		if os.IsTimeout(err) {
-			log.Warnw("timeout importing file adding it to failed queue for retry", "uri", item.URI)
-			d.failedQueueLock.Lock()
-			d.failedQueue[item.URI] = item
-			d.failedQueueLock.Unlock()
-		} else {
-			log.Warnw("could not pin file", "uri", item.URI, "error", fmt.Sprintf("%v", err))
-		}
-		return
-	}
-	ctx, cancel = context.WithTimeout(context.Background(), ImportPinTimeout)
-	defer cancel()
-	data, err := d.RemoteStorage.Retrieve(ctx, item.URI, 0)
-	if err != nil {
-		log.Warnw("could not retrieve file", "uri", item.URI, "error", fmt.Sprintf("%v", err))
-	} else {
-		if item.Callback != nil {
-			go item.Callback(item.URI, data)
-		}
+// ContentType returns the content type of the data, ContentTypeJSON if it is
+// valid JSON or the MIME type detected by http.DetectContentType otherwise.
+func ContentType(data []byte) string {
+	if json.Valid(data) {
+		return ContentTypeJSON
 	}
+	ctype, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	return ctype
 }
 
-// importQueueDaemon fetches and imports remote files added via importQueue.
+// importQueueDaemon fetches and imports the remote files of the queue.
 func (d *Downloader) importQueueDaemon(ctx context.Context) {
+	defer d.wgQueueDaemons.Done()
 	for {
+		if item := d.next(); item != nil {
+			d.handleImport(ctx, item)
+			continue
+		}
 		select {
-		case item := <-d.importQueue:
-			d.handleImport(&item)
+		case <-d.wakeup:
+		case <-time.After(pollInterval):
 		case <-ctx.Done():
-			d.wgQueueDaemons.Done()
 			return
 		}
 	}
 }
 
-// queueAddDelta adds or substracts a delta to the queue size.
-func (d *Downloader) queueAddDelta(i int32) {
-	d.queueSize.Add(i)
-	if i > 0 {
-		d.addedItems.Add(i)
+// next returns a copy of the next item to be downloaded, marking it as in
+// flight, or nil if there is none. The expired failed items are removed.
+func (d *Downloader) next() *DownloadItem {
+	d.queueLock.Lock()
+	defer d.queueLock.Unlock()
+	now := time.Now()
+	for d.failedItems.Len() > 0 && !d.failedItems[0].Expires.After(now) {
+		item := heap.Pop(&d.failedItems).(*DownloadItem)
+		log.Debugw("removing failed download", "uri", item.URI, "error", item.LastError)
+		delete(d.queue, item.URI)
+		delete(d.callbacks, item.URI)
+		if err := d.deleteItem(item.URI); err != nil {
+			log.Warnw("cannot delete failed item", "uri", item.URI, "error", err)
+		}
+	}
+	var next *DownloadItem
+	for i := range d.pending {
+		if d.pending[i].Len() > 0 && !d.pending[i][0].NextAttempt.After(now) {
+			next = heap.Pop(&d.pending[i]).(*DownloadItem)
+			break
+		}
 	}
+	if next == nil {
+		return nil
+	}
+	d.inFlight[next.URI] = true
+	c := *next
+	c.Targets = append([]DownloadTarget(nil), next.Targets...)
+	return &c
 }
 
-// importFailedQueue is the list of remote census imported that failed. Returns a safe copy.
-func (d *Downloader) importFailedQueue() map[string]*DownloadItem {
-	d.failedQueueLock.RLock()
-	defer d.failedQueueLock.RUnlock()
-	return maps.Clone(d.failedQueue)
+// handleImport fetches and imports a remote file. If the download fails, the
+// item is retried with an exponential backoff.
+func (d *Downloader) handleImport(ctx context.Context, item *DownloadItem) {
+	log.Debugw("importing remote file", "uri", item.URI, "priority", item.Priority, "attempts", item.Attempts)
+	data, err := d.download(ctx, item)
+	if err != nil {
+		if ctx.Err() != nil {
+			// stopping, the item is retried on the next start
+			d.release(item.URI)
+			return
+		}
+		d.failed(item.URI, err)
+		return
+	}
+
+	d.queueLock.Lock()
+	targets := d.queue[item.URI].Targets
+	callbacks := d.callbacks[item.URI]
+	delete(d.queue, item.URI)
+	delete(d.inFlight, item.URI)
+	delete(d.callbacks, item.URI)
+	if err := d.deleteItem(item.URI); err != nil {
+		log.Warnw("cannot delete downloaded item", "uri", item.URI, "error", err)
+	}
+	handlers := make([]*Handler, len(targets))
+	for i, t := range targets {
+		handlers[i] = d.handlers[t.Handler]
+	}
+	d.queueLock.Unlock()
+
+	for i, t := range targets {
+		if h := handlers[i]; h != nil && h.Callback != nil && checkHandler(h, data) == nil {
+			go h.Callback(item.URI, t.Ref, data)
+		}
+	}
+	for _, callback := range callbacks {
+		go callback(item.URI, data)
+	}
 }
 
-// handleImportFailedQueue tries to import files that failed.
-func (d *Downloader) handleImportFailedQueue() {
-	for cid, item := range d.importFailedQueue() {
-		log.Debugf("retrying download %s", cid)
-		ctx, cancel := context.WithTimeout(context.Background(), ImportRetrieveTimeout)
-		err := d.RemoteStorage.Pin(ctx, strings.TrimPrefix(item.URI, d.RemoteStorage.URIprefix()))
-		cancel()
-		if err != nil {
+// download retrieves the file of the item, checking it is allowed by at least
+// one of its handlers, and pins it if required.
+func (d *Downloader) download(ctx context.Context, item *DownloadItem) ([]byte, error) {
+	d.queueLock.Lock()
+	maxSize := int64(0)
+	handlers := []*Handler{}
+	for _, t := range item.Targets {
+		h, ok := d.handlers[t.Handler]
+		if !ok {
 			continue
 		}
-		d.failedQueueLock.Lock()
-		delete(d.failedQueue, cid)
-		d.failedQueueLock.Unlock()
-		//item := item // copy the range variable as it is continuously modified
-		ctx, cancel = context.WithTimeout(context.Background(), ImportPinTimeout)
-		defer cancel()
-		data, err := d.RemoteStorage.Retrieve(ctx, strings.TrimPrefix(item.URI, d.RemoteStorage.URIprefix()), 0)
+		handlers = append(handlers, h)
+		if size := h.maxSize(); size > maxSize {
+			maxSize = size
+		}
+	}
+	// items with callbacks or unknown handlers are only limited by MaxFileSize
+	if maxSize == 0 || len(d.callbacks[item.URI]) > 0 || len(handlers) < len(item.Targets) {
+		maxSize = MaxFileSize
+	}
+	d.queueLock.Unlock()
+
+	rctx, cancel := context.WithTimeout(ctx, ImportRetrieveTimeout)
+	data, err := d.RemoteStorage.Retrieve(rctx, item.URI, maxSize)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%w: (size:%d)", ErrFileTooBig, len(data))
+	}
+	// at least one of the handlers must accept the file
+	if len(handlers) > 0 && len(handlers) == len(item.Targets) {
+		for _, h := range handlers {
+			if err = checkHandler(h, data); err == nil {
+				break
+			}
+		}
 		if err != nil {
-			log.Warnf("could not pin file %q: %v", item.URI, err)
+			return nil, err
 		}
-		if item.Callback != nil {
-			go item.Callback(item.URI, data)
+	}
+	if item.Pin {
+		pctx, cancel := context.WithTimeout(ctx, ImportPinTimeout)
+		defer cancel()
+		if err := d.RemoteStorage.Pin(pctx, item.URI); err != nil {
+			return nil, fmt.Errorf("cannot pin file: %w", err)
 		}
 	}
+	return data, nil
 }
 
-// importFailedQueueDaemon is a daemon that retries to import files that failed.
-func (d *Downloader) importFailedQueueDaemon(ctx context.Context) {
-	d.handleImportFailedQueue()
-	for {
-		select {
-		case <-time.NewTimer(1 * time.Second).C:
-			d.handleImportFailedQueue()
-		case <-ctx.Done():
-			d.wgQueueDaemons.Done()
-			return
+// failed records the failed download attempt of the item, scheduling the next
+// one or marking the item as failed. Files rejected by the size and content
+// type guards are not retried.
+func (d *Downloader) failed(URI string, err error) {
+	d.queueLock.Lock()
+	defer d.queueLock.Unlock()
+	delete(d.inFlight, URI)
+	item, ok := d.queue[URI]
+	if !ok {
+		return
+	}
+	item.Attempts++
+	item.LastError = err.Error()
+	item.Failed = (d.MaxAttempts > 0 && item.Attempts >= d.MaxAttempts) ||
+		errors.Is(err, ErrFileTooBig) || errors.Is(err, ErrContentTypeNotAllowed)
+	item.NextAttempt = time.Now().Add(d.retryDelay(item.Attempts))
+	if item.Failed {
+		item.Expires = time.Now().Add(d.FailedItemsTTL)
+		log.Warnw("download failed", "uri", URI, "attempts", item.Attempts, "error", err)
+	} else {
+		log.Debugw("download failed, retrying later", "uri", URI, "attempts", item.Attempts,
+			"next", item.NextAttempt, "error", err)
+	}
+	d.push(item)
+	if err := d.storeItem(item); err != nil {
+		log.Warnw("cannot store download item", "uri", URI, "error", err)
+	}
+}
+
+// release marks the item as not in flight, without recording an attempt.
+func (d *Downloader) release(URI string) {
+	d.queueLock.Lock()
+	defer d.queueLock.Unlock()
+	delete(d.inFlight, URI)
+	if item, ok := d.queue[URI]; ok {
+		d.push(item)
+	}
+}
+
+// push adds the item to the heap of the failed items or to the heap of the
+// pending items of its priority. The queue lock must be held.
+func (d *Downloader) push(item *DownloadItem) {
+	if item.Failed {
+		heap.Push(&d.failedItems, item)
+		return
+	}
+	heap.Push(&d.pending[priorityIndex(item.Priority)], item)
+}
+
+// remove removes the item from its heap, returning false if it was in none,
+// i.e. it is in flight. The queue lock must be held.
+func (d *Downloader) remove(item *DownloadItem) bool {
+	if item.index < 0 {
+		return false
+	}
+	if item.Failed {
+		heap.Remove(&d.failedItems, item.index)
+	} else {
+		heap.Remove(&d.pending[priorityIndex(item.Priority)], item.index)
+	}
+	return true
+}
+
+// priorityIndex returns the index of the pending heap of the priority, the
+// closest valid one for unknown priorities.
+func priorityIndex(priority int) int {
+	if priority < PriorityHigh {
+		return PriorityHigh
+	}
+	if priority > PriorityLow {
+		return PriorityLow
+	}
+	return priority
+}
+
+// retryDelay returns the delay before the next attempt, which is doubled on
+// each attempt up to MaxRetryDelay.
+func (d *Downloader) retryDelay(attempts int) time.Duration {
+	delay := d.RetryDelay
+	for i := 1; i < attempts && delay < d.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.MaxRetryDelay {
+		delay = d.MaxRetryDelay
+	}
+	return delay
+}
+
+// notify wakes up a queue daemon, if any is waiting.
+func (d *Downloader) notify() {
+	select {
+	case d.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// storeItem persists the item. The queue lock must be held.
+func (d *Downloader) storeItem(item *DownloadItem) error {
+	if d.db == nil {
+		return nil
+	}
+	value, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	wTx := d.db.WriteTx()
+	defer wTx.Discard()
+	if err := wTx.Set([]byte(item.URI), value); err != nil {
+		return err
+	}
+	return wTx.Commit()
+}
+
+// deleteItem removes the persisted item. The queue lock must be held.
+func (d *Downloader) deleteItem(URI string) error {
+	if d.db == nil {
+		return nil
+	}
+	wTx := d.db.WriteTx()
+	defer wTx.Discard()
+	if err := wTx.Delete([]byte(URI)); err != nil {
+		return err
+	}
+	return wTx.Commit()
+}
+
+// checkHandler returns an error if the file is not allowed by the handler.
+func checkHandler(h *Handler, data []byte) error {
+	if int64(len(data)) > h.maxSize() {
+		return fmt.Errorf("%w: (size:%d)", ErrFileTooBig, len(data))
+	}
+	if len(h.ContentTypes) == 0 {
+		return nil
+	}
+	ctype := ContentType(data)
+	for _, t := range h.ContentTypes {
+		if t == ctype {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrContentTypeNotAllowed, ctype)
+}
+
+func (h *Handler) maxSize() int64 {
+	if h.MaxSize > 0 {
+		return h.MaxSize
+	}
+	return MaxFileSize
+}
+
+// before returns true if the item must be downloaded before the other one,
+// which are sorted by priority, next attempt time and addition time.
+func (item *DownloadItem) before(other *DownloadItem) bool {
+	if item.Priority != other.Priority {
+		return item.Priority < other.Priority
+	}
+	if !item.NextAttempt.Equal(other.NextAttempt) {
+		return item.NextAttempt.Before(other.NextAttempt)
+	}
+	return item.Added.Before(other.Added)
+}
+
+// itemHeap is a heap of items, ordered by the time they expire if failed, or
+// by their next attempt otherwise, and then by addition time. It implements
+// heap.Interface.
+type itemHeap []*DownloadItem
+
+func (h itemHeap) Len() int { return len(h) }
+
+func (h itemHeap) Less(i, j int) bool {
+	ti, tj := h[i].NextAttempt, h[j].NextAttempt
+	if h[i].Failed {
+		ti, tj = h[i].Expires, h[j].Expires
+	}
+	if !ti.Equal(tj) {
+		return ti.Before(tj)
+	}
+	return h[i].Added.Before(h[j].Added)
+}
+
+func (h itemHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+
+func (h *itemHeap) Push(x any) {
+	item := x.(*DownloadItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *itemHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	item.index = -1
+	*h = old[:len(old)-1]
+	return item
+}
+
+func (item *DownloadItem) hasTarget(target *DownloadTarget) bool {
+	for _, t := range item.Targets {
+		if t.Handler == target.Handler && string(t.Ref) == string(target.Ref) {
+			return true
 		}
 	}
+	return false
 }
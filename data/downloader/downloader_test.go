@@ -1,16 +1,22 @@
 package downloader
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	qt "github.com/frankban/quicktest"
 	"go.vocdoni.io/dvote/data"
+	"go.vocdoni.io/dvote/db/metadb"
 )
 
 func TestDownloader(t *testing.T) {
 	stg := data.DataMockTest{}
 	stg.Init(nil)
-	d := NewDownloader(&stg)
+	d, err := NewDownloader(&stg, nil)
+	qt.Assert(t, err, qt.IsNil)
+	d.RetryDelay = 10 * time.Millisecond
 	d.Start()
 	qt.Assert(t, d.QueueSize(), qt.Equals, int32(0))
 
@@ -34,3 +40,179 @@ func TestDownloader(t *testing.T) {
 	qt.Assert(t, d.QueueSize(), qt.Equals, int32(0))
 	d.Stop()
 }
+
+func TestDownloaderPersistence(t *testing.T) {
+	stg := data.DataMockTest{}
+	stg.Init(nil)
+	database := metadb.NewTest(t)
+	census, err := stg.Publish(context.Background(), []byte(`{"census":true}`))
+	qt.Assert(t, err, qt.IsNil)
+	metadata, err := stg.Publish(context.Background(), []byte(`{"title":"election"}`))
+	qt.Assert(t, err, qt.IsNil)
+	census, metadata = census[len(stg.URIprefix()):], metadata[len(stg.URIprefix()):]
+
+	type download struct {
+		uri string
+		ref string
+	}
+	downloads := make(chan download, 10)
+	register := func(d *Downloader) {
+		for _, h := range []struct {
+			name     string
+			priority int
+		}{{"census", PriorityHigh}, {"metadata", PriorityNormal}} {
+			d.RegisterHandler(h.name, &Handler{
+				Priority:     h.priority,
+				ContentTypes: []string{ContentTypeJSON},
+				Callback: func(uri string, ref []byte, data []byte) {
+					downloads <- download{uri: uri, ref: string(ref)}
+				},
+			})
+		}
+	}
+
+	d, err := NewDownloader(&stg, database)
+	qt.Assert(t, err, qt.IsNil)
+	register(d)
+	qt.Assert(t, d.Enqueue(metadata, "metadata", []byte("election1"), true), qt.IsNil)
+	qt.Assert(t, d.Enqueue(census, "census", nil, true), qt.IsNil)
+	// the same URI is downloaded once, calling all its handlers
+	qt.Assert(t, d.Enqueue(metadata, "metadata", []byte("election2"), true), qt.IsNil)
+	qt.Assert(t, d.Enqueue(metadata, "metadata", []byte("election2"), true), qt.IsNil)
+	qt.Assert(t, d.Enqueue(metadata, "unknown", nil, true), qt.ErrorIs, ErrHandlerUnknown)
+
+	// census data is downloaded before metadata
+	items := d.Items()
+	qt.Assert(t, items, qt.HasLen, 2)
+	qt.Assert(t, items[0].URI, qt.Equals, census)
+	qt.Assert(t, items[1].URI, qt.Equals, metadata)
+	qt.Assert(t, items[1].Targets, qt.HasLen, 2)
+
+	// the queue is loaded from the database on restart
+	d, err = NewDownloader(&stg, database)
+	qt.Assert(t, err, qt.IsNil)
+	register(d)
+	qt.Assert(t, d.QueueSize(), qt.Equals, int32(2))
+	d.Start()
+	defer d.Stop()
+	got := map[download]bool{}
+	for i := 0; i < 3; i++ {
+		got[<-downloads] = true
+	}
+	qt.Assert(t, got, qt.DeepEquals, map[download]bool{
+		{uri: census}:                     true,
+		{uri: metadata, ref: "election1"}: true,
+		{uri: metadata, ref: "election2"}: true,
+	})
+	qt.Assert(t, d.QueueSize(), qt.Equals, int32(0))
+	d, err = NewDownloader(&stg, database)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, d.Items(), qt.HasLen, 0)
+}
+
+func TestDownloaderGuards(t *testing.T) {
+	stg := data.DataMockTest{}
+	stg.Init(nil)
+	notJSON, err := stg.Publish(context.Background(), []byte("<html><body>not json</body></html>"))
+	qt.Assert(t, err, qt.IsNil)
+	notJSON = notJSON[len(stg.URIprefix()):]
+	big, err := stg.Publish(context.Background(), []byte(`{"big":"0123456789"}`))
+	qt.Assert(t, err, qt.IsNil)
+	big = big[len(stg.URIprefix()):]
+
+	d, err := NewDownloader(&stg, metadb.NewTest(t))
+	qt.Assert(t, err, qt.IsNil)
+	d.RegisterHandler("json", &Handler{
+		MaxSize:      16,
+		ContentTypes: []string{ContentTypeJSON},
+		Callback: func(uri string, ref []byte, data []byte) {
+			t.Errorf("unexpected download of %s", uri)
+		},
+	})
+	qt.Assert(t, d.Enqueue(notJSON, "json", nil, false), qt.IsNil)
+	qt.Assert(t, d.Enqueue(big, "json", nil, false), qt.IsNil)
+
+	// files rejected by the guards are marked as failed without retries
+	ctx := context.Background()
+	for item := d.next(); item != nil; item = d.next() {
+		d.handleImport(ctx, item)
+	}
+	items := d.Items()
+	qt.Assert(t, items, qt.HasLen, 2)
+	for _, item := range items {
+		qt.Assert(t, item.Failed, qt.IsTrue)
+		qt.Assert(t, item.Attempts, qt.Equals, 1)
+	}
+	qt.Assert(t, d.QueueSize(), qt.Equals, int32(0))
+	qt.Assert(t, d.ImportFailedQueueSize(), qt.Equals, 2)
+
+	qt.Assert(t, d.Requeue(big), qt.IsNil)
+	qt.Assert(t, d.QueueSize(), qt.Equals, int32(1))
+	qt.Assert(t, d.Requeue("unknown"), qt.ErrorIs, ErrItemNotFound)
+}
+
+// failingStorage is a storage whose downloads always fail.
+type failingStorage struct {
+	data.DataMockTest
+}
+
+func (*failingStorage) Retrieve(context.Context, string, int64) ([]byte, error) {
+	return nil, errors.New("not found")
+}
+
+func TestDownloaderFailedItems(t *testing.T) {
+	stg := &failingStorage{}
+	stg.Init(nil)
+	database := metadb.NewTest(t)
+	d, err := NewDownloader(stg, database)
+	qt.Assert(t, err, qt.IsNil)
+	d.RetryDelay = time.Millisecond
+	d.MaxRetryDelay = time.Millisecond
+	d.RegisterHandler("json", &Handler{})
+	qt.Assert(t, d.Enqueue("file", "json", nil, false), qt.IsNil)
+	ctx := context.Background()
+	importNext := func() bool {
+		time.Sleep(2 * time.Millisecond)
+		item := d.next()
+		if item == nil {
+			return false
+		}
+		d.handleImport(ctx, item)
+		return true
+	}
+
+	// the downloads are retried forever by default
+	for i := 0; i < 30; i++ {
+		qt.Assert(t, importNext(), qt.IsTrue)
+	}
+	items := d.Items()
+	qt.Assert(t, items, qt.HasLen, 1)
+	qt.Assert(t, items[0].Failed, qt.IsFalse)
+	qt.Assert(t, items[0].Attempts, qt.Equals, 30)
+
+	// with MaxAttempts, the item is marked as failed and removed once expired
+	d.MaxAttempts = 31
+	d.FailedItemsTTL = 50 * time.Millisecond
+	qt.Assert(t, importNext(), qt.IsTrue)
+	items = d.Items()
+	qt.Assert(t, items, qt.HasLen, 1)
+	qt.Assert(t, items[0].Failed, qt.IsTrue)
+	qt.Assert(t, importNext(), qt.IsFalse)
+	qt.Assert(t, d.QueueSize(), qt.Equals, int32(0))
+
+	time.Sleep(d.FailedItemsTTL)
+	qt.Assert(t, importNext(), qt.IsFalse)
+	qt.Assert(t, d.Items(), qt.HasLen, 0)
+	d, err = NewDownloader(stg, database)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, d.Items(), qt.HasLen, 0)
+}
+
+func TestRetryDelay(t *testing.T) {
+	d := &Downloader{RetryDelay: time.Second, MaxRetryDelay: 10 * time.Second}
+	qt.Assert(t, d.retryDelay(1), qt.Equals, time.Second)
+	qt.Assert(t, d.retryDelay(2), qt.Equals, 2*time.Second)
+	qt.Assert(t, d.retryDelay(4), qt.Equals, 8*time.Second)
+	qt.Assert(t, d.retryDelay(5), qt.Equals, 10*time.Second)
+	qt.Assert(t, d.retryDelay(100), qt.Equals, 10*time.Second)
+}
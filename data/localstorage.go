@@ -0,0 +1,416 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	ipfscid "github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"go.vocdoni.io/dvote/db"
+	"go.vocdoni.io/dvote/db/lru"
+	"go.vocdoni.io/dvote/db/metadb"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/metrics"
+	"go.vocdoni.io/dvote/types"
+)
+
+const (
+	// localPinType is the pin type reported by LocalStorage.ListPins, the
+	// same used by IPFS for the pins of published files
+	localPinType = "recursive"
+	// gatewayTimeout is the timeout of each request to an HTTP gateway
+	gatewayTimeout = 30 * time.Second
+)
+
+var (
+	// ErrStorageFull is returned when storing a file would exceed the size
+	// limit of the storage.
+	ErrStorageFull = errors.New("storage size limit reached")
+	// ErrNotPinned is returned when unpinning a file that is not pinned.
+	ErrNotPinned = errors.New("not pinned")
+	// ErrCIDMismatch is returned when the content retrieved from a gateway
+	// does not match its CID.
+	ErrCIDMismatch = errors.New("content does not match the CID")
+)
+
+// LocalStorage is a Storage that keeps the files on disk, keyed by the same
+// CIDv1 calculated by CalculateIPFSCIDv1json, without running an IPFS node.
+// The files are kept while pinned, counting the pins of each one, and the
+// files not found locally can be retrieved from HTTP gateways.
+type LocalStorage struct {
+	// DataDir is the directory where the files are stored
+	DataDir string
+	// MaxSize is the maximum size in bytes of all the files stored, zero
+	// means no limit
+	MaxSize int64
+	// Gateways are the URLs of the HTTP gateways used to retrieve the files
+	// not found locally, e.g. https://ipfs.io
+	Gateways []string
+
+	lock          sync.Mutex
+	pins          db.Database
+	size          int64
+	client        *http.Client
+	retrieveCache *lru.Cache
+}
+
+// Init initializes the LocalStorage in the data directory.
+func (l *LocalStorage) Init(d *types.DataStore) error {
+	l.DataDir = d.Datadir
+	if d.MaxSize > 0 {
+		l.MaxSize = d.MaxSize
+	}
+	if len(d.Gateways) > 0 {
+		l.Gateways = d.Gateways
+	}
+	if err := os.MkdirAll(l.blobsDir(), 0o750); err != nil {
+		return err
+	}
+	var err error
+	l.pins, err = metadb.New(db.TypePebble, filepath.Join(l.DataDir, "pins"))
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(l.blobsDir())
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			return err
+		}
+		l.size += info.Size()
+	}
+	l.client = &http.Client{Timeout: gatewayTimeout}
+	l.retrieveCache = lru.New(RetrievedFileCacheSize)
+	log.Infow("local storage initialization", "dataDir", l.DataDir, "size", l.size,
+		"maxSize", l.MaxSize, "gateways", l.Gateways)
+	return nil
+}
+
+// Stop closes the pins database.
+func (l *LocalStorage) Stop() error {
+	return l.pins.Close()
+}
+
+// URIprefix returns the URI prefix which identifies the protocol
+func (*LocalStorage) URIprefix() string {
+	return "ipfs://"
+}
+
+// Publish stores and pins the file, returning its CIDv1.
+func (l *LocalStorage) Publish(_ context.Context, msg []byte) (string, error) {
+	if len(msg) > MaxFileSizeBytes {
+		return "", fmt.Errorf("file too big: (size:%d)", len(msg))
+	}
+	cid := CalculateIPFSCIDv1json(msg)
+	if cid == "" {
+		return "", fmt.Errorf("cannot calculate CID")
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if err := l.store(cid, msg); err != nil {
+		return "", err
+	}
+	if err := l.addPins(cid, 1); err != nil {
+		return "", err
+	}
+	log.Infof("published file: %s", cid)
+	return cid, nil
+}
+
+// Retrieve returns the file from the local storage or, if not found, from
+// the first HTTP gateway that returns content matching the CID.
+func (l *LocalStorage) Retrieve(ctx context.Context, path string, maxSize int64) ([]byte, error) {
+	cid, err := parseCID(path)
+	if err != nil {
+		return nil, err
+	}
+	if maxSize == 0 {
+		maxSize = MaxFileSizeBytes
+	}
+	content, err := os.ReadFile(l.blobPath(cid))
+	if err == nil {
+		if int64(len(content)) > maxSize {
+			return nil, fmt.Errorf("file too big: (size:%d)", len(content))
+		}
+		return content, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if ccontent := l.retrieveCache.Get(cid.String()); ccontent != nil {
+		log.Debugf("retrieved file %s from cache", cid)
+		return ccontent.([]byte), nil
+	}
+	content, err = l.fetch(ctx, cid, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	l.retrieveCache.Add(cid.String(), content)
+	return content, nil
+}
+
+// Pin pins the file, retrieving it from the HTTP gateways if it is not stored
+// locally. Each pin must be removed with Unpin before the file is deleted.
+func (l *LocalStorage) Pin(ctx context.Context, path string) error {
+	cid, err := parseCID(path)
+	if err != nil {
+		return err
+	}
+	key := cidKey(cid)
+	var content []byte
+	if _, err := os.Stat(l.blobPath(cid)); errors.Is(err, os.ErrNotExist) {
+		if content, err = l.fetch(ctx, cid, MaxFileSizeBytes); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if content != nil {
+		if err := l.store(key, content); err != nil {
+			return err
+		}
+	}
+	log.Debugf("adding pin %s", key)
+	return l.addPins(key, 1)
+}
+
+// Unpin removes a pin of the file, deleting it when no pins are left.
+func (l *LocalStorage) Unpin(_ context.Context, path string) error {
+	cid, err := parseCID(path)
+	if err != nil {
+		return err
+	}
+	key := cidKey(cid)
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	pins, err := l.pinCount(key)
+	if err != nil {
+		return err
+	}
+	if pins == 0 {
+		return fmt.Errorf("%w: %s", ErrNotPinned, key)
+	}
+	log.Debugf("removing pin %s", key)
+	if err := l.addPins(key, -1); err != nil {
+		return err
+	}
+	if pins > 1 {
+		return nil
+	}
+	info, err := os.Stat(l.blobPath(cid))
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(l.blobPath(cid)); err != nil {
+		return err
+	}
+	l.size -= info.Size()
+	return nil
+}
+
+// ListPins returns the pinned files, with the same format than IPFS.
+func (l *LocalStorage) ListPins(_ context.Context) (map[string]string, error) {
+	pins := make(map[string]string)
+	if err := l.pins.Iterate(nil, func(key, _ []byte) bool {
+		pins["/ipfs/"+string(key)] = localPinType
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	return pins, nil
+}
+
+// Stats returns the number of pins and the size of the files stored.
+func (l *LocalStorage) Stats(ctx context.Context) map[string]interface{} {
+	pins, err := l.ListPins(ctx)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return map[string]interface{}{"pins": len(pins), "size": l.size, "maxSize": l.MaxSize}
+}
+
+// CollectMetrics constantly updates the metric values for prometheus
+// The function is blocking, should be called in a go routine
+// If the metrics Agent is nil, do nothing
+func (l *LocalStorage) CollectMetrics(ctx context.Context, ma *metrics.Agent) error {
+	if ma != nil {
+		ma.Register(FilePins)
+		for {
+			time.Sleep(ma.RefreshInterval)
+			pins, err := l.ListPins(ctx)
+			if err != nil {
+				return err
+			}
+			FilePins.Set(float64(len(pins)))
+		}
+	}
+	return nil
+}
+
+// store writes the content of the file, if not already stored, checking the
+// size limit. Must be called with the lock held.
+func (l *LocalStorage) store(cid string, content []byte) error {
+	path := filepath.Join(l.blobsDir(), cid)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if l.MaxSize > 0 && l.size+int64(len(content)) > l.MaxSize {
+		return fmt.Errorf("%w: cannot store %d bytes", ErrStorageFull, len(content))
+	}
+	// write to a temporary file first, so a partial write is never seen as
+	// the content of the CID
+	tmp, err := os.CreateTemp(l.DataDir, "blob-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	l.size += int64(len(content))
+	return nil
+}
+
+// addPins adds delta to the pins count of the file, removing the count when
+// it reaches zero. Must be called with the lock held.
+func (l *LocalStorage) addPins(cid string, delta int) error {
+	pins, err := l.pinCount(cid)
+	if err != nil {
+		return err
+	}
+	wTx := l.pins.WriteTx()
+	defer wTx.Discard()
+	if pins+delta <= 0 {
+		if err := wTx.Delete([]byte(cid)); err != nil {
+			return err
+		}
+	} else {
+		count := make([]byte, 4)
+		binary.LittleEndian.PutUint32(count, uint32(pins+delta))
+		if err := wTx.Set([]byte(cid), count); err != nil {
+			return err
+		}
+	}
+	return wTx.Commit()
+}
+
+// pinCount returns the number of pins of the file.
+func (l *LocalStorage) pinCount(cid string) (int, error) {
+	rTx := l.pins.ReadTx()
+	defer rTx.Discard()
+	count, err := rTx.Get([]byte(cid))
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int(binary.LittleEndian.Uint32(count)), nil
+}
+
+// fetch retrieves the file from the first HTTP gateway that returns content
+// matching the CID.
+func (l *LocalStorage) fetch(ctx context.Context, cid ipfscid.Cid, maxSize int64) ([]byte, error) {
+	if len(l.Gateways) == 0 {
+		return nil, fmt.Errorf("file %s not found", cid)
+	}
+	var lastErr error
+	for _, gw := range l.Gateways {
+		content, err := l.fetchFromGateway(ctx, gw, cid, maxSize)
+		if err == nil {
+			return content, nil
+		}
+		log.Debugw("cannot retrieve file from gateway", "gateway", gw, "cid", cid.String(), "err", err)
+		lastErr = err
+	}
+	return nil, fmt.Errorf("cannot retrieve file %s: %w", cid, lastErr)
+}
+
+func (l *LocalStorage) fetchFromGateway(ctx context.Context, gateway string,
+	cid ipfscid.Cid, maxSize int64) ([]byte, error) {
+	url := strings.TrimSuffix(gateway, "/") + "/ipfs/" + cid.String()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gateway returned status %d", resp.StatusCode)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxSize {
+		return nil, fmt.Errorf("file too big: (size>%d)", maxSize)
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("retrieved file is empty")
+	}
+	// the CID must be the hash of the content, as the ones calculated by
+	// CalculateIPFSCIDv1json
+	hash, err := multihash.Sum(content, cid.Prefix().MhType, -1)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(hash, cid.Hash()) {
+		return nil, ErrCIDMismatch
+	}
+	return content, nil
+}
+
+func (l *LocalStorage) blobsDir() string {
+	return filepath.Join(l.DataDir, "blobs")
+}
+
+// blobPath returns the path of the file of the CID, which is stored with the
+// CIDv1 json codec regardless of the codec of the given CID.
+func (l *LocalStorage) blobPath(cid ipfscid.Cid) string {
+	return filepath.Join(l.blobsDir(), cidKey(cid))
+}
+
+// cidKey returns the CIDv1 with JSON codec of the CID as a string, which is
+// used as the key of the files.
+func cidKey(cid ipfscid.Cid) string {
+	return IPFSCIDv1json(cid).String()
+}
+
+// parseCID decodes the CID of an IPFS path or URI (ipfs://<cid>,
+// /ipfs/<cid> or <cid>).
+func parseCID(path string) (ipfscid.Cid, error) {
+	path = strings.TrimPrefix(path, "ipfs://")
+	path = strings.TrimPrefix(path, "/ipfs/")
+	cid, err := ipfscid.Decode(path)
+	if err != nil {
+		return ipfscid.Cid{}, fmt.Errorf("invalid CID %q: %w", path, err)
+	}
+	return cid, nil
+}
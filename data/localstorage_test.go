@@ -0,0 +1,114 @@
+package data
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/db/lru"
+	"go.vocdoni.io/dvote/types"
+)
+
+func newTestLocalStorage(t *testing.T, d *types.DataStore) *LocalStorage {
+	d.Datadir = t.TempDir()
+	l := new(LocalStorage)
+	qt.Assert(t, l.Init(d), qt.IsNil)
+	t.Cleanup(func() { _ = l.Stop() })
+	return l
+}
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLocalStorage(t, &types.DataStore{})
+	msg := []byte(`{"test": { "hello": "world" }}`)
+
+	cid, err := l.Publish(ctx, msg)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, cid, qt.Equals, CalculateIPFSCIDv1json(msg))
+
+	for _, path := range []string{cid, "ipfs://" + cid, "/ipfs/" + cid} {
+		content, err := l.Retrieve(ctx, path, 0)
+		qt.Assert(t, err, qt.IsNil)
+		qt.Assert(t, content, qt.DeepEquals, msg)
+	}
+	_, err = l.Retrieve(ctx, cid, 5)
+	qt.Assert(t, err, qt.IsNotNil)
+	_, err = l.Retrieve(ctx, CalculateIPFSCIDv1json([]byte("missing")), 0)
+	qt.Assert(t, err, qt.IsNotNil)
+
+	pins, err := l.ListPins(ctx)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, pins, qt.DeepEquals, map[string]string{"/ipfs/" + cid: "recursive"})
+
+	// publishing the same file again adds a pin, so two unpins are required
+	_, err = l.Publish(ctx, msg)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, l.Unpin(ctx, cid), qt.IsNil)
+	_, err = l.Retrieve(ctx, cid, 0)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, l.Unpin(ctx, "ipfs://"+cid), qt.IsNil)
+	_, err = l.Retrieve(ctx, cid, 0)
+	qt.Assert(t, err, qt.IsNotNil)
+	qt.Assert(t, l.Unpin(ctx, cid), qt.ErrorIs, ErrNotPinned)
+	pins, err = l.ListPins(ctx)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, pins, qt.HasLen, 0)
+	qt.Assert(t, l.size, qt.Equals, int64(0))
+}
+
+func TestLocalStorageMaxSize(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLocalStorage(t, &types.DataStore{MaxSize: 10})
+
+	cid, err := l.Publish(ctx, []byte(`{"a":1}`))
+	qt.Assert(t, err, qt.IsNil)
+	_, err = l.Publish(ctx, []byte(`{"b":2}`))
+	qt.Assert(t, err, qt.ErrorIs, ErrStorageFull)
+
+	// space is released once the file is unpinned
+	qt.Assert(t, l.Unpin(ctx, cid), qt.IsNil)
+	_, err = l.Publish(ctx, []byte(`{"b":2}`))
+	qt.Assert(t, err, qt.IsNil)
+}
+
+func TestLocalStorageGateway(t *testing.T) {
+	ctx := context.Background()
+	msg := []byte(`{"hello":"gateway"}`)
+	cid := CalculateIPFSCIDv1json(msg)
+	other := CalculateIPFSCIDv1json([]byte(`{"other":true}`))
+
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimPrefix(r.URL.Path, "/ipfs/") {
+		case cid:
+			_, _ = w.Write(msg)
+		case other:
+			// returns content that does not match the CID
+			_, _ = w.Write(msg)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer gw.Close()
+
+	l := newTestLocalStorage(t, &types.DataStore{Gateways: []string{gw.URL}})
+
+	content, err := l.Retrieve(ctx, "ipfs://"+cid, 0)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, content, qt.DeepEquals, msg)
+	_, err = l.Retrieve(ctx, other, 0)
+	qt.Assert(t, err, qt.ErrorIs, ErrCIDMismatch)
+	_, err = l.Retrieve(ctx, CalculateIPFSCIDv1json([]byte(`{"missing":1}`)), 0)
+	qt.Assert(t, err, qt.IsNotNil)
+
+	// pinning stores the file fetched from the gateway
+	qt.Assert(t, l.Pin(ctx, other), qt.ErrorIs, ErrCIDMismatch)
+	qt.Assert(t, l.Pin(ctx, cid), qt.IsNil)
+	gw.Close()
+	l.retrieveCache = lru.New(RetrievedFileCacheSize)
+	content, err = l.Retrieve(ctx, cid, 0)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, content, qt.DeepEquals, msg)
+}
@@ -0,0 +1,48 @@
+package db
+
+import "bytes"
+
+// KeyValue is a key-value pair stored in a Database.
+type KeyValue struct {
+	Key   []byte
+	Value []byte
+}
+
+// ReadAll returns a copy of all the key-value pairs stored in the database,
+// ordered by key.
+func ReadAll(database Database) ([]KeyValue, error) {
+	kvs := []KeyValue{}
+	if err := database.Iterate(nil, func(key, value []byte) bool {
+		kvs = append(kvs, KeyValue{Key: bytes.Clone(key), Value: bytes.Clone(value)})
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	return kvs, nil
+}
+
+// ReplaceAll deletes all the key-value pairs stored in the database and
+// writes the given ones, in a single write transaction. Along with ReadAll,
+// it allows to rollback a database to a previous copy without reopening it.
+func ReplaceAll(database Database, kvs []KeyValue) error {
+	keys := [][]byte{}
+	if err := database.Iterate(nil, func(key, _ []byte) bool {
+		keys = append(keys, bytes.Clone(key))
+		return true
+	}); err != nil {
+		return err
+	}
+	wTx := database.WriteTx()
+	defer wTx.Discard()
+	for _, k := range keys {
+		if err := wTx.Delete(k); err != nil {
+			return err
+		}
+	}
+	for _, kv := range kvs {
+		if err := wTx.Set(kv.Key, kv.Value); err != nil {
+			return err
+		}
+	}
+	return wTx.Commit()
+}
@@ -209,3 +209,29 @@ func TestWriteTxApplyBatch(t *testing.T, d db.Database) {
 	err = wTx.Apply(batch)
 	qt.Assert(t, err, qt.IsNil)
 }
+
+func TestReadAllReplaceAll(t *testing.T, d db.Database) {
+	wTx := d.WriteTx()
+	qt.Assert(t, wTx.Set([]byte("a"), []byte("a")), qt.IsNil)
+	qt.Assert(t, wTx.Set([]byte("b"), []byte("b")), qt.IsNil)
+	qt.Assert(t, wTx.Commit(), qt.IsNil)
+
+	kvs, err := db.ReadAll(d)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, kvs, qt.DeepEquals, []db.KeyValue{
+		{Key: []byte("a"), Value: []byte("a")},
+		{Key: []byte("b"), Value: []byte("b")},
+	})
+
+	// update, delete and add some keys, then go back to the copy
+	wTx = d.WriteTx()
+	qt.Assert(t, wTx.Set([]byte("a"), []byte("x")), qt.IsNil)
+	qt.Assert(t, wTx.Delete([]byte("b")), qt.IsNil)
+	qt.Assert(t, wTx.Set([]byte("c"), []byte("c")), qt.IsNil)
+	qt.Assert(t, wTx.Commit(), qt.IsNil)
+
+	qt.Assert(t, db.ReplaceAll(d, kvs), qt.IsNil)
+	restored, err := db.ReadAll(d)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, restored, qt.DeepEquals, kvs)
+}
@@ -74,3 +74,10 @@ func (l *AtomicCache) GetAndUpdate(key interface{},
 	entry.value = updateValue(nil)
 	return entry.value
 }
+
+// Purge removes all the entries of the cache.
+func (l *AtomicCache) Purge() {
+	l.lruMu.Lock()
+	defer l.lruMu.Unlock()
+	l.lru.Purge()
+}
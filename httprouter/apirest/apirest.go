@@ -35,6 +35,7 @@ const (
 	HTTPstatusBadRequest  = http.StatusBadRequest
 	HTTPstatusInternalErr = http.StatusInternalServerError
 	HTTPstatusNotFound    = http.StatusNotFound
+	HTTPstatusTooMany     = http.StatusTooManyRequests
 )
 
 // API is a namespace handler for the httpRouter with Bearer authorization
@@ -2,6 +2,7 @@ package httprouter
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
@@ -68,3 +69,24 @@ func (h *HTTPContext) Send(msg []byte, httpStatusCode int) error {
 	_, err := h.Writer.Write([]byte("\n"))
 	return err
 }
+
+// Stream replies the request with the body written by the write func, without
+// buffering it, which is useful for large responses. The status and headers are sent
+// before calling write, so any error returned by it can only abort the response.
+func (h *HTTPContext) Stream(contentType string, write func(w io.Writer) error) error {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Warnf("recovered http stream panic: %v", r)
+		}
+	}()
+	defer close(h.sent)
+	defer h.Request.Body.Close()
+
+	if h.Request.Context().Err() != nil {
+		// The connection was closed, so don't try to write to it.
+		return fmt.Errorf("connection is closed")
+	}
+	h.Writer.Header().Set("Content-Type", contentType)
+	h.Writer.WriteHeader(http.StatusOK)
+	return write(h.Writer)
+}
@@ -21,5 +21,7 @@ func (vs *VocdoniService) VochainIndexer() error {
 	}
 	// launch the indexer after sync routine (executed when the blockchain is ready)
 	go vs.Indexer.AfterSyncBootstrap()
+	// aggregate the stats of the transactions indexed by older versions
+	go vs.Indexer.BackfillStats()
 	return nil
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"time"
 
 	"go.vocdoni.io/dvote/config"
@@ -12,10 +13,21 @@ import (
 )
 
 func (vs *VocdoniService) IPFS(ipfsconfig *config.IPFSCfg) (storage data.Storage, err error) {
-	log.Info("creating ipfs service")
-	os.Setenv("IPFS_FD_MAX", "1024")
+	storageType := data.IPFS
+	if ipfsconfig.StorageType != "" {
+		storageType = data.StorageIDFromString(ipfsconfig.StorageType)
+	}
 	ipfsStore := data.IPFSNewConfig(ipfsconfig.ConfigPath)
-	storage, err = data.Init(data.StorageIDFromString("IPFS"), ipfsStore)
+	if storageType == data.LOCAL {
+		log.Info("creating local storage service")
+		ipfsStore.Datadir = filepath.Join(ipfsconfig.ConfigPath, "local")
+		ipfsStore.Gateways = ipfsconfig.Gateways
+		ipfsStore.MaxSize = ipfsconfig.MaxSize
+	} else {
+		log.Info("creating ipfs service")
+		os.Setenv("IPFS_FD_MAX", "1024")
+	}
+	storage, err = data.Init(storageType, ipfsStore)
 	if err != nil {
 		return
 	}
@@ -31,7 +43,7 @@ func (vs *VocdoniService) IPFS(ipfsconfig *config.IPFSCfg) (storage data.Storage
 
 	go storage.CollectMetrics(context.Background(), vs.MetricsAgent)
 
-	if len(ipfsconfig.ConnectKey) > 0 {
+	if len(ipfsconfig.ConnectKey) > 0 && storageType == data.IPFS {
 		log.Info("enabling ipfsconnect cluster")
 		_, priv := vs.Signer.HexString()
 		ipfsconn := ipfsconnect.New(
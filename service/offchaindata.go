@@ -15,10 +15,17 @@ import (
 // OffChainDataHandler creates the offchain data downloader handler service and a censusDB.
 func (vs *VocdoniService) OffChainDataHandler() error {
 	log.Infof("creating offchain data downloader service")
+	startDownloader := false
 	if vs.DataDownloader == nil {
-		vs.DataDownloader = downloader.NewDownloader(vs.Storage)
-		vs.DataDownloader.Start()
-		go vs.DataDownloader.PrintLogInfo(time.Second * 120)
+		ddb, err := metadb.New(db.TypePebble, filepath.Join(vs.Config.DataDir, "downloader"))
+		if err != nil {
+			return err
+		}
+		if vs.DataDownloader, err = downloader.NewDownloader(vs.Storage, ddb); err != nil {
+			return err
+		}
+		vs.DataDownloader.MaxAttempts = vs.Config.OffchainDataMaxAttempts
+		startDownloader = true
 	}
 	if vs.CensusDB == nil {
 		db, err := metadb.New(db.TypePebble, filepath.Join(vs.Config.DataDir, "censusdb"))
@@ -31,7 +38,14 @@ func (vs *VocdoniService) OffChainDataHandler() error {
 		vs.App,
 		vs.DataDownloader,
 		vs.CensusDB,
+		vs.Indexer,
 		vs.Config.SkipPreviousOffchainData,
 	)
+	// the downloader is started once the handlers of the persisted queue
+	// items are registered
+	if startDownloader {
+		vs.DataDownloader.Start()
+		go vs.DataDownloader.PrintLogInfo(time.Second * 120)
+	}
 	return nil
 }
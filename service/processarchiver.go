@@ -13,16 +13,39 @@ func (vs *VocdoniService) ProcessArchiver() error {
 	if vs.Indexer == nil {
 		return fmt.Errorf("process archive needs indexer enabled")
 	}
+	var storage processarchive.Storage
+	var err error
+	if s3cfg := vs.Config.ProcessArchiveS3; s3cfg.Bucket != "" {
+		log.Infof("starting process archiver on S3 bucket %s", s3cfg.Bucket)
+		storage, err = processarchive.NewS3Storage(&processarchive.S3Config{
+			Endpoint:  s3cfg.Endpoint,
+			Region:    s3cfg.Region,
+			Bucket:    s3cfg.Bucket,
+			Prefix:    s3cfg.Prefix,
+			AccessKey: s3cfg.AccessKey,
+			SecretKey: s3cfg.SecretKey,
+		})
+	} else {
+		log.Infof("starting process archiver on %s", vs.Config.ProcessArchiveDataDir)
+		storage, err = processarchive.NewFSStorage(vs.Config.ProcessArchiveDataDir)
+	}
+	if err != nil {
+		return fmt.Errorf("could not create process archive: %w", err)
+	}
+	// the archive is only published via IPNS if the storage is IPFS
 	ipfs, ok := vs.Storage.(*data.IPFSHandle)
 	if !ok {
-		return fmt.Errorf("ipfsStorage is not IPFS")
+		log.Warnw("storage is not IPFS, the process archive will not be published")
 	}
-	log.Infof("starting process archiver on %s", vs.Config.ProcessArchiveDataDir)
-	processarchive.NewProcessArchive(
+	if _, err := processarchive.NewProcessArchive(
 		vs.Indexer,
 		ipfs,
-		vs.Config.ProcessArchiveDataDir,
+		storage,
 		vs.Config.ProcessArchiveKey,
-	)
+	); err != nil {
+		return err
+	}
+	vs.Indexer.SetArchive(storage)
+	vs.ProcessArchive = storage
 	return nil
 }
@@ -11,6 +11,7 @@ import (
 	"go.vocdoni.io/dvote/vochain"
 	"go.vocdoni.io/dvote/vochain/indexer"
 	"go.vocdoni.io/dvote/vochain/offchaindatahandler"
+	"go.vocdoni.io/dvote/vochain/processarchive"
 	"go.vocdoni.io/dvote/vochain/vochaininfo"
 )
 
@@ -24,6 +25,7 @@ type VocdoniService struct {
 	DataDownloader *downloader.Downloader
 	CensusDB       *censusdb.CensusDB
 	Indexer        *indexer.Indexer
+	ProcessArchive processarchive.Storage
 	Stats          *vochaininfo.VochainInfo
 	Storage        data.Storage
 	Signer         *ethereum.SignKeys
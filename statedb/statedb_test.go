@@ -310,6 +310,41 @@ func TestSubTree(t *testing.T) {
 	}
 }
 
+func TestDeepDel(t *testing.T) {
+	sdb := NewStateDB(metadb.NewTest(t))
+
+	mainTree, err := sdb.BeginTx()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, mainTree.Add(singleCfg.Key(), emptyHash), qt.IsNil)
+	qt.Assert(t, mainTree.DeepAdd([]byte("key0"), []byte("value0"), singleCfg), qt.IsNil)
+	qt.Assert(t, mainTree.Commit(1), qt.IsNil)
+
+	mainTree, err = sdb.BeginTx()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, mainTree.DeepAdd([]byte("key1"), []byte("value1"), singleCfg), qt.IsNil)
+	qt.Assert(t, mainTree.DeepDel([]byte("key0"), singleCfg), qt.IsNil)
+	// deleting a missing key fails
+	qt.Assert(t, mainTree.DeepDel([]byte("key2"), singleCfg), qt.IsNotNil)
+	qt.Assert(t, mainTree.Commit(2), qt.IsNil)
+
+	mainTreeView, err := sdb.TreeView(nil)
+	qt.Assert(t, err, qt.IsNil)
+	_, err = mainTreeView.DeepGet([]byte("key0"), singleCfg)
+	qt.Assert(t, err, qt.ErrorIs, arbo.ErrKeyNotFound)
+	v1, err := mainTreeView.DeepGet([]byte("key1"), singleCfg)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, v1, qt.DeepEquals, []byte("value1"))
+
+	// the root in the parent leaf is updated
+	single, err := mainTreeView.SubTree(singleCfg)
+	qt.Assert(t, err, qt.IsNil)
+	singleRoot, err := single.Root()
+	qt.Assert(t, err, qt.IsNil)
+	singleParentLeaf, err := mainTreeView.Get(singleCfg.Key())
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, singleParentLeaf, qt.DeepEquals, singleRoot)
+}
+
 func TestNoState(t *testing.T) {
 	sdb := NewStateDB(metadb.NewTest(t))
 
@@ -1,7 +1,10 @@
 package statedb
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/binary"
+	"fmt"
 	"io"
 	"path"
 	"sync"
@@ -92,20 +95,98 @@ func (u *TreeUpdate) GenProof(key []byte) ([]byte, []byte, error) {
 	return u.tree.GenProof(u.tree.tx, key)
 }
 
+// GenMultiproof generates a proof of existence of all the given keys for this
+// tree.  The returned values are the leaf values and the proof itself.
+func (u *TreeUpdate) GenMultiproof(keys [][]byte) ([][]byte, []byte, error) {
+	return u.tree.GenMultiproof(u.tree.tx, keys)
+}
+
 // Dump exports all the tree leafs.
 // Unimplemented because arbo.Tree.Dump doesn't take db.ReadTx as input.
 func (u *TreeUpdate) Dump(w io.Writer) error {
 	return u.tree.DumpWriter(w)
 }
 
-// Import writes the content exported with Dump.
-// TODO: use io.Reader once implemented in Arbo.
+// Import writes the content exported with Dump, adding the leafs or updating
+// their values.
 func (u *TreeUpdate) Import(r io.Reader) error {
-	var buf bytes.Buffer
-	if _, err := buf.ReadFrom(r); err != nil {
+	return readDump(r, func(key, value []byte) error {
+		return u.Set(key, value)
+	})
+}
+
+// ImportSubTree writes the content exported with Dump into a subTree whose
+// root is already set in its parent leaf but whose nodes are missing, as
+// happens when restoring the trees of a state snapshot.  The subTree must be
+// empty, and the root of the imported leafs must match the root of the
+// parent leaf.  The parent leaf is not modified, so the subTree is not
+// opened in this TreeUpdate.
+func (u *TreeUpdate) ImportSubTree(cfg TreeConfig, r io.Reader) error {
+	parentLeaf, err := u.tree.Get(u.tree.tx, cfg.parentLeafKey)
+	if err != nil {
 		return err
 	}
-	return u.tree.ImportDump(buf.Bytes())
+	root, err := cfg.parentLeafGetRoot(parentLeaf)
+	if err != nil {
+		return err
+	}
+	tx := subWriteTx(u.tx, path.Join(subKeySubTree, cfg.prefix))
+	txTree := subWriteTx(tx, subKeyTree)
+	tree, err := tree.New(txTree,
+		tree.Options{DB: nil, MaxLevels: cfg.maxLevels, HashFunc: cfg.hashFunc})
+	if err != nil {
+		return err
+	}
+	var keys, values [][]byte
+	if err := readDump(r, func(key, value []byte) error {
+		keys = append(keys, key)
+		values = append(values, value)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		invalids, err := tree.AddBatch(txTree, keys, values)
+		if err != nil {
+			return err
+		}
+		if len(invalids) > 0 {
+			return fmt.Errorf("cannot import %d leafs", len(invalids))
+		}
+	}
+	newRoot, err := tree.Root(txTree)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(root, newRoot) {
+		return fmt.Errorf("imported root %x does not match the parent leaf root %x", newRoot, root)
+	}
+	return nil
+}
+
+// readDump reads the leafs exported with Dump, calling fn for each one.  The
+// format of each leaf is [len(key) 1 byte][len(value) 2 bytes LE][key][value].
+func readDump(r io.Reader, fn func(key, value []byte) error) error {
+	br := bufio.NewReader(r)
+	l := make([]byte, 3)
+	for {
+		if _, err := io.ReadFull(br, l); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		key := make([]byte, int(l[0]))
+		if _, err := io.ReadFull(br, key); err != nil {
+			return err
+		}
+		value := make([]byte, int(binary.LittleEndian.Uint16(l[1:3])))
+		if _, err := io.ReadFull(br, value); err != nil {
+			return err
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
 }
 
 // NoState returns a key-value database associated with this tree that doesn't
@@ -129,6 +210,12 @@ func (u *TreeUpdate) Set(key, value []byte) error {
 	return u.tree.Set(u.tree.tx, key, value)
 }
 
+// Del removes a key-value from this tree.  `key` is the path of the leaf.
+func (u *TreeUpdate) Del(key []byte) error {
+	u.dirtyTree = true
+	return u.tree.Delete(u.tree.tx, key)
+}
+
 // SubTree is used to open the subTree (singleton and non-singleton) as a
 // TreeUpdate.  The treeUpdate.tx is created from u.tx appending the prefix
 // `subKeySubTree | cfg.prefix`.  In turn the treeUpdate.tree uses the
@@ -216,6 +303,16 @@ func (u *TreeUpdate) DeepSet(key, value []byte, cfgs ...TreeConfig) error {
 	return tree.Set(key, value)
 }
 
+// DeepDel allows performing a Del on a nested subTree by passing the list
+// of tree configurations and the key to delete on the last subTree.
+func (u *TreeUpdate) DeepDel(key []byte, cfgs ...TreeConfig) error {
+	tree, err := u.DeepSubTree(cfgs...)
+	if err != nil {
+		return err
+	}
+	return tree.Del(key)
+}
+
 // TreeTx is a wrapper over TreeUpdate that includes the Commit and Discard
 // methods to control the transaction used to update the StateDB.  It contains
 // the mainTree opened in the wrapped TreeUpdate.  The TreeTx is not safe for
@@ -388,6 +485,11 @@ func (v *treeUpdateView) GenProof(key []byte) ([]byte, []byte, error) {
 	return (*TreeUpdate)(v).GenProof(key)
 }
 
+// GenMultiproof implements the TreeViewer.GenMultiproof method.
+func (v *treeUpdateView) GenMultiproof(keys [][]byte) ([][]byte, []byte, error) {
+	return (*TreeUpdate)(v).GenMultiproof(keys)
+}
+
 // Dump exports all the tree leafs.
 func (v *treeUpdateView) Dump(w io.Writer) error {
 	return (*TreeUpdate)(v).Dump(w)
@@ -57,6 +57,10 @@ type TreeViewer interface {
 	// GenProof generates a proof of existence of the given key for this tree.  The
 	// returned values are the leaf value and the proof itself.
 	GenProof(key []byte) ([]byte, []byte, error)
+	// GenMultiproof generates a proof of existence of all the given keys for
+	// this tree, sharing the common siblings.  The returned values are the leaf
+	// values, in the same order than the keys, and the proof itself.
+	GenMultiproof(keys [][]byte) ([][]byte, []byte, error)
 	// SubTree is used to open the subTree (singleton and non-singleton) as a
 	// TreeView.
 	SubTree(c TreeConfig) (TreeViewer, error)
@@ -134,6 +138,12 @@ func (v *TreeView) GenProof(key []byte) ([]byte, []byte, error) {
 	return v.tree.GenProof(nil, key)
 }
 
+// GenMultiproof generates a proof of existence of all the given keys for this
+// tree.  The returned values are the leaf values and the proof itself.
+func (v *TreeView) GenMultiproof(keys [][]byte) ([][]byte, []byte, error) {
+	return v.tree.GenMultiproof(nil, keys)
+}
+
 // Dump exports all the tree leafs.
 func (v *TreeView) Dump(w io.Writer) error {
 	return v.tree.DumpWriter(w)
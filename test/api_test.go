@@ -115,6 +115,13 @@ func TestAPIcensusAndVote(t *testing.T) {
 	stxb, err := proto.Marshal(&stx)
 	qt.Assert(t, err, qt.IsNil)
 
+	// metadata not matching its schema is rejected
+	_, code = c.Request("POST", api.ElectionCreate{
+		TxPayload: stxb,
+		Metadata:  []byte(`{"version":"1.0","description":{"default":"no title"}}`),
+	}, "elections")
+	qt.Assert(t, code, qt.Equals, api.ErrMetadataInvalid.HTTPstatus)
+
 	election := api.ElectionCreate{
 		TxPayload: stxb,
 		Metadata:  metadataBytes,
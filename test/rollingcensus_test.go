@@ -25,7 +25,7 @@ package test
 //	qt.Assert(t, err, qt.IsNil)
 //	cdb := censusdb.NewCensusDB(db)
 //	defer func() { qt.Assert(t, db.Close(), qt.IsNil) }()
-//	dataHandler := offchaindatahandler.NewOffChainDataHandler(app, nil, cdb, false)
+//	dataHandler := offchaindatahandler.NewOffChainDataHandler(app, nil, cdb, nil, false)
 //
 //	const numKeys = 128
 //	pid := rng.RandomBytes(32)
@@ -0,0 +1,327 @@
+package arbo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"go.vocdoni.io/dvote/db"
+)
+
+const (
+	// multiproofFlagSibling is used for a node whose subtree does not
+	// contain any of the proven keys, so its hash is in the siblings
+	multiproofFlagSibling = 0
+	// multiproofFlagEmpty is used for an empty node, which hash is not
+	// included in the siblings
+	multiproofFlagEmpty = 1
+	// multiproofFlagLeaf is used for the leaf of one of the proven keys
+	multiproofFlagLeaf = 2
+	// multiproofFlagIntermediate is used for an intermediate node in the
+	// path of at least one of the proven keys, followed by the flags of its
+	// left and right childs
+	multiproofFlagIntermediate = 3
+)
+
+// Multiproof contains the nodes needed to prove several keys at once under the
+// same root. The nodes are in depth-first order (left child first), and the
+// siblings shared by the paths of the keys are included only once.
+type Multiproof struct {
+	// Flags defines the type of each node (one of the multiproofFlag*)
+	Flags []byte
+	// Siblings contains the hashes of the nodes flagged as sibling, in the
+	// same order than the flags
+	Siblings [][]byte
+}
+
+// GenMultiproof generates a proof of existence of all the given keys under the
+// current root. Returns the values of the keys, in the same order than the
+// keys, and the Multiproof. If any of the keys is not in the tree, returns
+// ErrKeyNotFound.
+func (t *Tree) GenMultiproof(keys [][]byte) ([][]byte, *Multiproof, error) {
+	rTx := t.db.ReadTx()
+	defer rTx.Discard()
+
+	return t.GenMultiproofWithTx(rTx, keys)
+}
+
+// GenMultiproofWithTx does the same than the GenMultiproof method, but
+// allowing to pass the db.ReadTx that is used.
+func (t *Tree) GenMultiproofWithTx(rTx db.ReadTx, keys [][]byte) ([][]byte, *Multiproof, error) {
+	if len(keys) == 0 {
+		return nil, nil, fmt.Errorf("no keys to prove")
+	}
+	paths := make([][]bool, len(keys))
+	indexes := make([]int, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for i, k := range keys {
+		keyPath, err := keyPathFromKey(t.maxLevels, k)
+		if err != nil {
+			return nil, nil, err
+		}
+		if seen[string(keyPath)] {
+			return nil, nil, fmt.Errorf("duplicated key %x", k)
+		}
+		seen[string(keyPath)] = true
+		paths[i] = getPath(t.maxLevels, keyPath)
+		indexes[i] = i
+	}
+
+	root, err := t.RootWithTx(rTx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := make([][]byte, len(keys))
+	mp := &Multiproof{}
+	if err := t.genMultiproof(rTx, root, keys, paths, indexes, values, mp, 0); err != nil {
+		return nil, nil, err
+	}
+	return values, mp, nil
+}
+
+// genMultiproof goes down from the node currKey, which is in the path of the
+// keys pointed by indexes, appending the nodes to the Multiproof and storing
+// the values of the proven keys.
+func (t *Tree) genMultiproof(rTx db.ReadTx, currKey []byte, keys [][]byte,
+	paths [][]bool, indexes []int, values [][]byte, mp *Multiproof, currLvl int) error {
+	if currLvl > t.maxLevels {
+		return ErrMaxLevel
+	}
+	if bytes.Equal(currKey, t.emptyHash) {
+		return ErrKeyNotFound
+	}
+	currValue, err := rTx.Get(currKey)
+	if err != nil {
+		return err
+	}
+
+	switch currValue[0] {
+	case PrefixValueLeaf:
+		leafK, leafV := ReadLeafValue(currValue)
+		if len(indexes) != 1 || !bytes.Equal(keys[indexes[0]], leafK) {
+			return ErrKeyNotFound
+		}
+		values[indexes[0]] = leafV
+		mp.Flags = append(mp.Flags, multiproofFlagLeaf)
+		return nil
+	case PrefixValueIntermediate:
+		if len(currValue) != PrefixValueLen+t.hashFunction.Len()*2 {
+			return fmt.Errorf("intermediate value invalid length (expected: %d, actual: %d)",
+				PrefixValueLen+t.hashFunction.Len()*2, len(currValue))
+		}
+		if currLvl >= t.maxLevels {
+			return ErrMaxLevel
+		}
+		mp.Flags = append(mp.Flags, multiproofFlagIntermediate)
+		left, right := splitMultiproofIndexes(indexes, func(i int) bool {
+			return paths[i][currLvl]
+		})
+		lChild, rChild := ReadIntermediateChilds(currValue)
+		for _, child := range []struct {
+			key     []byte
+			indexes []int
+		}{{lChild, left}, {rChild, right}} {
+			if len(child.indexes) > 0 {
+				if err := t.genMultiproof(rTx, child.key, keys, paths, child.indexes,
+					values, mp, currLvl+1); err != nil {
+					return err
+				}
+				continue
+			}
+			if bytes.Equal(child.key, t.emptyHash) {
+				mp.Flags = append(mp.Flags, multiproofFlagEmpty)
+				continue
+			}
+			mp.Flags = append(mp.Flags, multiproofFlagSibling)
+			mp.Siblings = append(mp.Siblings, child.key)
+		}
+		return nil
+	default:
+		return ErrInvalidValuePrefix
+	}
+}
+
+// splitMultiproofIndexes splits the indexes in the ones going to the left
+// (right returns false) and the ones going to the right.
+func splitMultiproofIndexes(indexes []int, right func(int) bool) ([]int, []int) {
+	var l, r []int
+	for _, i := range indexes {
+		if right(i) {
+			r = append(r, i)
+		} else {
+			l = append(l, i)
+		}
+	}
+	return l, r
+}
+
+// PackMultiproof packs the Multiproof into a byte array.
+// [   4 bytes   |  ceil(N/4) bytes  |   S * M bytes  ]
+// [ N nodes     | 2-bit node flags  | M siblings     ]
+// Where S is the size of the output of the hash function used for the Tree.
+// The number of nodes is encoded in little-endian.
+func PackMultiproof(hashFunc HashFunction, mp *Multiproof) ([]byte, error) {
+	if uint64(len(mp.Flags)) > uint64(^uint32(0)) {
+		return nil, fmt.Errorf("PackMultiproof: too many nodes (%d)", len(mp.Flags))
+	}
+	flagsLen := (len(mp.Flags) + 3) / 4
+	res := make([]byte, 4+flagsLen, 4+flagsLen+len(mp.Siblings)*hashFunc.Len())
+	binary.LittleEndian.PutUint32(res[0:4], uint32(len(mp.Flags)))
+	for i, f := range mp.Flags {
+		if f > multiproofFlagIntermediate {
+			return nil, fmt.Errorf("PackMultiproof: invalid node flag %d", f)
+		}
+		res[4+i/4] |= f << (2 * (i % 4))
+	}
+	for _, s := range mp.Siblings {
+		if len(s) != hashFunc.Len() {
+			return nil, fmt.Errorf("PackMultiproof: invalid sibling length %d", len(s))
+		}
+		res = append(res, s...)
+	}
+	return res, nil
+}
+
+// UnpackMultiproof unpacks the Multiproof from a byte array.
+func UnpackMultiproof(hashFunc HashFunction, b []byte) (*Multiproof, error) {
+	if len(b) < 4 {
+		return nil, fmt.Errorf("multiproof too short: %d", len(b))
+	}
+	n := uint64(binary.LittleEndian.Uint32(b[0:4]))
+	flagsLen := (n + 3) / 4
+	if uint64(len(b)) < 4+flagsLen {
+		return nil, fmt.Errorf("expected at least len: %d, current len: %d",
+			4+flagsLen, len(b))
+	}
+	mp := &Multiproof{Flags: make([]byte, n)}
+	nSiblings := 0
+	for i := range mp.Flags {
+		mp.Flags[i] = (b[4+i/4] >> (2 * (i % 4))) & 0x03
+		if mp.Flags[i] == multiproofFlagSibling {
+			nSiblings++
+		}
+	}
+	siblingsBytes := b[4+flagsLen:]
+	if len(siblingsBytes) != nSiblings*hashFunc.Len() {
+		return nil, fmt.Errorf("expected siblings len: %d, current len: %d",
+			nSiblings*hashFunc.Len(), len(siblingsBytes))
+	}
+	for i := 0; i < nSiblings; i++ {
+		mp.Siblings = append(mp.Siblings, siblingsBytes[i*hashFunc.Len():(i+1)*hashFunc.Len()])
+	}
+	return mp, nil
+}
+
+// multiproofVerifier keeps the state of the nodes consumed while computing
+// the root of a Multiproof.
+type multiproofVerifier struct {
+	hashFunc  HashFunction
+	mp        *Multiproof
+	keys      [][]byte
+	values    [][]byte
+	maxLevels int
+	iFlag     int
+	iSibling  int
+}
+
+// CheckMultiproof verifies the packed Multiproof of the given keys & values
+// against the root. The keys and values can be in any order, as long as each
+// value is at the same position than its key.
+func CheckMultiproof(hashFunc HashFunction, keys, values [][]byte, root, packedMultiproof []byte) (bool, error) {
+	if len(keys) == 0 {
+		return false, fmt.Errorf("no keys to verify")
+	}
+	if len(keys) != len(values) {
+		return false, fmt.Errorf("len(keys)!=len(values) (%d!=%d)", len(keys), len(values))
+	}
+	mp, err := UnpackMultiproof(hashFunc, packedMultiproof)
+	if err != nil {
+		return false, err
+	}
+
+	v := &multiproofVerifier{
+		hashFunc: hashFunc,
+		mp:       mp,
+		keys:     keys,
+		values:   values,
+	}
+	indexes := make([]int, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for i, k := range keys {
+		if seen[string(k)] {
+			return false, fmt.Errorf("duplicated key %x", k)
+		}
+		seen[string(k)] = true
+		if len(k)*8 > v.maxLevels {
+			v.maxLevels = len(k) * 8
+		}
+		indexes[i] = i
+	}
+
+	computed, ok, err := v.verify(indexes, 0)
+	if err != nil || !ok {
+		return false, err
+	}
+	if v.iFlag != len(mp.Flags) || v.iSibling != len(mp.Siblings) {
+		// not all the nodes of the proof have been used
+		return false, nil
+	}
+	return bytes.Equal(computed, root), nil
+}
+
+// verify computes the hash of the next node of the Multiproof, which is in the
+// path of the keys pointed by indexes. Returns false if the proof nodes don't
+// match the keys.
+func (v *multiproofVerifier) verify(indexes []int, currLvl int) ([]byte, bool, error) {
+	if v.iFlag >= len(v.mp.Flags) {
+		return nil, false, nil
+	}
+	flag := v.mp.Flags[v.iFlag]
+	v.iFlag++
+
+	switch flag {
+	case multiproofFlagSibling:
+		if len(indexes) != 0 {
+			return nil, false, nil
+		}
+		s := v.mp.Siblings[v.iSibling]
+		v.iSibling++
+		return s, true, nil
+	case multiproofFlagEmpty:
+		if len(indexes) != 0 {
+			return nil, false, nil
+		}
+		return make([]byte, v.hashFunc.Len()), true, nil
+	case multiproofFlagLeaf:
+		if len(indexes) != 1 {
+			return nil, false, nil
+		}
+		key, _, err := newLeafValue(v.hashFunc, v.keys[indexes[0]], v.values[indexes[0]])
+		if err != nil {
+			return nil, false, err
+		}
+		return key, true, nil
+	default: // multiproofFlagIntermediate
+		if len(indexes) == 0 || currLvl >= v.maxLevels {
+			return nil, false, nil
+		}
+		left, right := splitMultiproofIndexes(indexes, func(i int) bool {
+			k := v.keys[i]
+			return currLvl/8 < len(k) && k[currLvl/8]&(1<<(currLvl%8)) != 0
+		})
+		l, ok, err := v.verify(left, currLvl+1)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		r, ok, err := v.verify(right, currLvl+1)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		key, _, err := newIntermediate(v.hashFunc, l, r)
+		if err != nil {
+			return nil, false, err
+		}
+		return key, true, nil
+	}
+}
@@ -0,0 +1,114 @@
+package arbo
+
+import (
+	"math/big"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/db/metadb"
+)
+
+func TestMultiproof(t *testing.T) {
+	c := qt.New(t)
+	c.Run("HashSha256", func(c *qt.C) {
+		testMultiproof(c, HashFunctionSha256)
+	})
+	c.Run("HashPoseidon", func(c *qt.C) {
+		testMultiproof(c, HashFunctionPoseidon)
+	})
+	c.Run("HashBlake2b", func(c *qt.C) {
+		testMultiproof(c, HashFunctionBlake2b)
+	})
+}
+
+func testMultiproof(c *qt.C, hashFunc HashFunction) {
+	database := metadb.NewTest(c)
+	tree, err := NewTree(Config{Database: database, MaxLevels: 256,
+		HashFunction: hashFunc})
+	c.Assert(err, qt.IsNil)
+
+	bLen := 32
+	nLeafs := 100
+	for i := 0; i < nLeafs; i++ {
+		k := BigIntToBytes(bLen, big.NewInt(int64(i)))
+		v := BigIntToBytes(bLen, big.NewInt(int64(i*2)))
+		c.Assert(tree.Add(k, v), qt.IsNil)
+	}
+	root, err := tree.Root()
+	c.Assert(err, qt.IsNil)
+
+	var keys, expected [][]byte
+	for _, i := range []int{42, 7, 8, 99, 0, 15} {
+		keys = append(keys, BigIntToBytes(bLen, big.NewInt(int64(i))))
+		expected = append(expected, BigIntToBytes(bLen, big.NewInt(int64(i*2))))
+	}
+	values, mp, err := tree.GenMultiproof(keys)
+	c.Assert(err, qt.IsNil)
+	c.Assert(values, qt.DeepEquals, expected)
+
+	packed, err := PackMultiproof(hashFunc, mp)
+	c.Assert(err, qt.IsNil)
+	unpacked, err := UnpackMultiproof(hashFunc, packed)
+	c.Assert(err, qt.IsNil)
+	c.Assert(unpacked.Flags, qt.DeepEquals, mp.Flags)
+	c.Assert(unpacked.Siblings, qt.DeepEquals, mp.Siblings)
+
+	verif, err := CheckMultiproof(hashFunc, keys, values, root, packed)
+	c.Assert(err, qt.IsNil)
+	c.Assert(verif, qt.IsTrue)
+
+	// the shared siblings are included only once, so the multiproof has
+	// less siblings than the single proofs together
+	nSiblings := 0
+	for _, k := range keys {
+		_, _, s, _, err := tree.GenProof(k)
+		c.Assert(err, qt.IsNil)
+		siblings, err := UnpackSiblings(hashFunc, s)
+		c.Assert(err, qt.IsNil)
+		nSiblings += len(siblings)
+	}
+	c.Assert(len(mp.Siblings) < nSiblings, qt.IsTrue)
+
+	// a single key multiproof
+	v, mp1, err := tree.GenMultiproof(keys[:1])
+	c.Assert(err, qt.IsNil)
+	packed1, err := PackMultiproof(hashFunc, mp1)
+	c.Assert(err, qt.IsNil)
+	verif, err = CheckMultiproof(hashFunc, keys[:1], v, root, packed1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(verif, qt.IsTrue)
+
+	// the keys order does not matter when verifying
+	verif, err = CheckMultiproof(hashFunc,
+		[][]byte{keys[1], keys[0], keys[2], keys[3], keys[4], keys[5]},
+		[][]byte{values[1], values[0], values[2], values[3], values[4], values[5]},
+		root, packed)
+	c.Assert(err, qt.IsNil)
+	c.Assert(verif, qt.IsTrue)
+
+	// wrong value
+	wrongValues := append([][]byte{}, values...)
+	wrongValues[2] = BigIntToBytes(bLen, big.NewInt(1))
+	verif, err = CheckMultiproof(hashFunc, keys, wrongValues, root, packed)
+	c.Assert(err, qt.IsNil)
+	c.Assert(verif, qt.IsFalse)
+
+	// missing key
+	verif, err = CheckMultiproof(hashFunc, keys[1:], values[1:], root, packed)
+	c.Assert(err, qt.IsNil)
+	c.Assert(verif, qt.IsFalse)
+
+	// wrong root
+	verif, err = CheckMultiproof(hashFunc, keys, values, make([]byte, hashFunc.Len()), packed)
+	c.Assert(err, qt.IsNil)
+	c.Assert(verif, qt.IsFalse)
+
+	// duplicated key
+	_, _, err = tree.GenMultiproof([][]byte{keys[0], keys[0]})
+	c.Assert(err, qt.IsNotNil)
+
+	// key not in the tree
+	_, _, err = tree.GenMultiproof([][]byte{keys[0],
+		BigIntToBytes(bLen, big.NewInt(int64(nLeafs)))})
+	c.Assert(err, qt.ErrorIs, ErrKeyNotFound)
+}
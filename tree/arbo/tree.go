@@ -928,6 +928,106 @@ func (t *Tree) UpdateWithTx(wTx db.WriteTx, k, v []byte) error {
 	return nil
 }
 
+// Delete removes the leaf for the given key from the Tree. If the given key
+// does not exist, returns an error. When the deleted leaf leaves a single leaf
+// as the only remaining node of its subtree, that leaf is moved up to the
+// shallowest level where its path does not collide with other leafs, so the
+// resulting root is the same than the one of a Tree where the key was never
+// added.
+func (t *Tree) Delete(k []byte) error {
+	wTx := t.db.WriteTx()
+	defer wTx.Discard()
+
+	if err := t.DeleteWithTx(wTx, k); err != nil {
+		return err
+	}
+	return wTx.Commit()
+}
+
+// DeleteWithTx does the same than the Delete method, but allowing to pass the
+// db.WriteTx that is used. The db.WriteTx will not be committed inside this
+// method.
+func (t *Tree) DeleteWithTx(wTx db.WriteTx, k []byte) error {
+	t.Lock()
+	defer t.Unlock()
+
+	if !t.editable() {
+		return ErrSnapshotNotEditable
+	}
+
+	keyPath, err := keyPathFromKey(t.maxLevels, k)
+	if err != nil {
+		return err
+	}
+	path := getPath(t.maxLevels, keyPath)
+
+	root, err := t.RootWithTx(wTx)
+	if err != nil {
+		return err
+	}
+
+	var siblings [][]byte
+	_, valueAtBottom, siblings, err := t.down(wTx, k, root, siblings, path, 0, true)
+	if err != nil {
+		return err
+	}
+	oldKey, _ := ReadLeafValue(valueAtBottom)
+	if !bytes.Equal(oldKey, k) {
+		return ErrKeyNotFound
+	}
+
+	// the deleted leaf is replaced by an empty node, then go up collapsing
+	// the path while the node at the current level is empty or a leaf that
+	// has an empty sibling
+	node := t.emptyHash
+	lvl := len(siblings) - 1
+	for ; lvl >= 0; lvl-- {
+		if bytes.Equal(siblings[lvl], t.emptyHash) {
+			continue
+		}
+		if !bytes.Equal(node, t.emptyHash) {
+			break
+		}
+		isLeaf, err := t.isLeaf(wTx, siblings[lvl])
+		if err != nil {
+			return err
+		}
+		if !isLeaf {
+			break
+		}
+		// the sibling is a leaf and the current node is empty, the
+		// sibling leaf goes up one level
+		node = siblings[lvl]
+	}
+
+	if lvl >= 0 {
+		root, err = t.up(wTx, node, siblings, path, lvl, 0)
+		if err != nil {
+			return err
+		}
+	} else {
+		root = node
+	}
+
+	if err := t.setRoot(wTx, root); err != nil {
+		return err
+	}
+	// update nLeafs
+	return t.incNLeafs(wTx, -1)
+}
+
+// isLeaf returns true if the node stored under the given key is a leaf
+func (t *Tree) isLeaf(rTx db.ReadTx, key []byte) (bool, error) {
+	if bytes.Equal(key, t.emptyHash) {
+		return false, nil
+	}
+	v, err := rTx.Get(key)
+	if err != nil {
+		return false, err
+	}
+	return len(v) > 0 && v[0] == PrefixValueLeaf, nil
+}
+
 // GenProof generates a MerkleTree proof for the given key. The leaf value is
 // returned, together with the packed siblings of the proof, and a boolean
 // parameter that indicates if the proof is of existence (true) or not (false).
@@ -246,6 +246,98 @@ func TestUpdate(t *testing.T) {
 	c.Check(gettedValue, qt.DeepEquals, BigIntToBytes(bLen, big.NewInt(11)))
 }
 
+func TestDelete(t *testing.T) {
+	c := qt.New(t)
+	testDelete(c, HashFunctionPoseidon)
+	testDelete(c, HashFunctionSha256)
+	testDelete(c, HashFunctionBlake2b)
+}
+
+func testDelete(c *qt.C, hashFunc HashFunction) {
+	bLen := 32
+	nLeafs := 64
+	newTree := func() *Tree {
+		tree, err := NewTree(Config{Database: metadb.NewTest(c), MaxLevels: 256,
+			HashFunction: hashFunc})
+		c.Assert(err, qt.IsNil)
+		return tree
+	}
+
+	tree := newTree()
+	// deleting from an empty tree must fail
+	err := tree.Delete(BigIntToBytes(bLen, big.NewInt(1)))
+	c.Assert(err, qt.Equals, ErrKeyNotFound)
+
+	for i := 0; i < nLeafs; i++ {
+		k := BigIntToBytes(bLen, big.NewInt(int64(i)))
+		v := BigIntToBytes(bLen, big.NewInt(int64(i*2)))
+		c.Assert(tree.Add(k, v), qt.IsNil)
+	}
+
+	// deleting a key not in the tree must fail and keep the root
+	rootBefore, err := tree.Root()
+	c.Assert(err, qt.IsNil)
+	err = tree.Delete(BigIntToBytes(bLen, big.NewInt(int64(nLeafs+1))))
+	c.Assert(err, qt.Equals, ErrKeyNotFound)
+	root, err := tree.Root()
+	c.Assert(err, qt.IsNil)
+	c.Assert(root, qt.DeepEquals, rootBefore)
+
+	// delete the even keys, after each deletion the root must be equal to
+	// the root of a tree built from scratch with the remaining leafs
+	deleted := make(map[int]bool)
+	for i := 0; i < nLeafs; i += 2 {
+		c.Assert(tree.Delete(BigIntToBytes(bLen, big.NewInt(int64(i)))), qt.IsNil)
+		deleted[i] = true
+
+		expected := newTree()
+		for j := 0; j < nLeafs; j++ {
+			if deleted[j] {
+				continue
+			}
+			k := BigIntToBytes(bLen, big.NewInt(int64(j)))
+			v := BigIntToBytes(bLen, big.NewInt(int64(j*2)))
+			c.Assert(expected.Add(k, v), qt.IsNil)
+		}
+		expectedRoot, err := expected.Root()
+		c.Assert(err, qt.IsNil)
+		root, err := tree.Root()
+		c.Assert(err, qt.IsNil)
+		c.Assert(root, qt.DeepEquals, expectedRoot)
+
+		n, err := tree.GetNLeafs()
+		c.Assert(err, qt.IsNil)
+		c.Assert(n, qt.Equals, nLeafs-len(deleted))
+	}
+
+	// the deleted keys can not be found, the remaining ones keep their values
+	for i := 0; i < nLeafs; i++ {
+		k := BigIntToBytes(bLen, big.NewInt(int64(i)))
+		_, v, err := tree.Get(k)
+		if deleted[i] {
+			c.Assert(err, qt.Equals, ErrKeyNotFound)
+			continue
+		}
+		c.Assert(err, qt.IsNil)
+		c.Assert(v, qt.DeepEquals, BigIntToBytes(bLen, big.NewInt(int64(i*2))))
+	}
+
+	// delete the remaining keys, the tree must end up empty
+	for i := 1; i < nLeafs; i += 2 {
+		c.Assert(tree.Delete(BigIntToBytes(bLen, big.NewInt(int64(i)))), qt.IsNil)
+	}
+	root, err = tree.Root()
+	c.Assert(err, qt.IsNil)
+	c.Assert(root, qt.DeepEquals, tree.emptyHash)
+
+	// the tree can be filled again after being emptied
+	k := BigIntToBytes(bLen, big.NewInt(int64(7)))
+	c.Assert(tree.Add(k, k), qt.IsNil)
+	_, v, err := tree.Get(k)
+	c.Assert(err, qt.IsNil)
+	c.Assert(v, qt.DeepEquals, k)
+}
+
 func TestAux(t *testing.T) { // TODO split in proper tests
 	c := qt.New(t)
 	database := metadb.NewTest(t)
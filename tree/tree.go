@@ -121,6 +121,43 @@ func (t *Tree) Add(wTx db.WriteTx, key, value []byte) error {
 	return nil
 }
 
+// Update updates the value of an existing leaf, if the key does not exist will
+// return error
+func (t *Tree) Update(wTx db.WriteTx, key, value []byte) error {
+	givenTx := wTx != nil
+	if !givenTx {
+		wTx = t.DB().WriteTx()
+		defer wTx.Discard()
+	}
+	if err := t.tree.UpdateWithTx(wTx, key, value); err != nil {
+		return err
+	}
+	if !givenTx {
+		if err := wTx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes an existing leaf, if the key does not exist will return error
+func (t *Tree) Delete(wTx db.WriteTx, key []byte) error {
+	givenTx := wTx != nil
+	if !givenTx {
+		wTx = t.DB().WriteTx()
+		defer wTx.Discard()
+	}
+	if err := t.tree.DeleteWithTx(wTx, key); err != nil {
+		return err
+	}
+	if !givenTx {
+		if err := wTx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // AddBatch adds a batch of key-values to the Tree. Returns an array containing
 // the indexes of the keys failed to add. Supports empty values as input
 // parameters, which is equivalent to 0 valued byte array.
@@ -228,6 +265,37 @@ func (t *Tree) VerifyProof(key, value, proof, root []byte) (bool, error) {
 	return VerifyProof(t.tree.HashFunction(), key, value, proof, root)
 }
 
+// GenMultiproof returns the values of the given keys, in the same order, and
+// a byte array with the data to verify that all of them are in leafs under the
+// current root. The siblings shared by the keys are included only once.
+func (t *Tree) GenMultiproof(rTx db.ReadTx, keys [][]byte) ([][]byte, []byte, error) {
+	if rTx == nil {
+		rTx = t.DB().ReadTx()
+		defer rTx.Discard()
+	}
+	values, mp, err := t.tree.GenMultiproofWithTx(rTx, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, err := arbo.PackMultiproof(t.tree.HashFunction(), mp)
+	if err != nil {
+		return nil, nil, err
+	}
+	return values, proof, nil
+}
+
+// VerifyMultiproof checks the multiproof for the given keys, values and root,
+// using the passed hash function
+func VerifyMultiproof(hashFunc arbo.HashFunction, keys, values [][]byte, proof, root []byte) (bool, error) {
+	return arbo.CheckMultiproof(hashFunc, keys, values, root, proof)
+}
+
+// VerifyMultiproof checks the multiproof for the given keys, values and root,
+// using the hash function of the Tree
+func (t *Tree) VerifyMultiproof(keys, values [][]byte, proof, root []byte) (bool, error) {
+	return VerifyMultiproof(t.tree.HashFunction(), keys, values, proof, root)
+}
+
 // FromRoot returns a new read-only Tree for the given root, that uses the same
 // underlying db.
 func (t *Tree) FromRoot(root []byte) (*Tree, error) {
@@ -274,6 +342,12 @@ func (t *Tree) ImportDump(b []byte) error {
 	return t.tree.ImportDump(b)
 }
 
+// ImportDumpReader imports the leafs (that have been exported with the Dump or
+// DumpWriter methods) in the Tree, reading them from r.
+func (t *Tree) ImportDumpReader(r io.Reader) error {
+	return t.tree.ImportDumpReader(r)
+}
+
 func (t *Tree) PrintGraphviz() error {
 	return t.tree.PrintGraphviz(nil)
 }
@@ -292,3 +366,18 @@ func (t *Tree) GetCircomSiblings(k []byte) ([]string, error) {
 	}
 	return siblings, nil
 }
+
+// CircomSiblings returns the circom ready siblings from the packed siblings
+// returned by GenProof, encoded in the same way than GetCircomSiblings. It
+// allows to get both kinds of siblings going down the tree only once.
+func (t *Tree) CircomSiblings(packedSiblings []byte) ([]string, error) {
+	unpacked, err := arbo.UnpackSiblings(t.tree.HashFunction(), packedSiblings)
+	if err != nil {
+		return nil, err
+	}
+	siblings := []string{}
+	for _, bSibling := range t.tree.FillMissingEmptySiblings(unpacked) {
+		siblings = append(siblings, arbo.BytesToBigInt(bSibling).String())
+	}
+	return siblings, nil
+}
@@ -92,6 +92,40 @@ func TestGenProof(t *testing.T) {
 	qt.Assert(t, err, qt.IsNil)
 }
 
+func TestGenMultiproof(t *testing.T) {
+	database := metadb.NewTest(t)
+
+	tree, err := New(nil, Options{DB: database, MaxLevels: 100, HashFunc: arbo.HashFunctionBlake2b})
+	qt.Assert(t, err, qt.IsNil)
+
+	wTx := tree.DB().WriteTx()
+	for i := 0; i < 10; i++ {
+		k := []byte("key" + strconv.Itoa(i))
+		v := []byte("value" + strconv.Itoa(i))
+		err := tree.Add(wTx, k, v)
+		qt.Assert(t, err, qt.IsNil)
+	}
+	keys := [][]byte{[]byte("key3"), []byte("key7"), []byte("key1")}
+	values, proof, err := tree.GenMultiproof(wTx, keys)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, values, qt.DeepEquals, [][]byte{[]byte("value3"), []byte("value7"), []byte("value1")})
+
+	root, err := tree.Root(wTx)
+	qt.Assert(t, err, qt.IsNil)
+
+	verif, err := tree.VerifyMultiproof(keys, values, proof, root)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, verif, qt.IsTrue)
+
+	values[1] = []byte("value8")
+	verif, err = tree.VerifyMultiproof(keys, values, proof, root)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, verif, qt.IsFalse)
+
+	err = wTx.Commit()
+	qt.Assert(t, err, qt.IsNil)
+}
+
 func TestFromRoot(t *testing.T) {
 	database := metadb.NewTest(t)
 
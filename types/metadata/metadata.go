@@ -0,0 +1,113 @@
+// Package metadata defines the election and account metadata documents stored
+// on the offchain storage, and validates them against their JSON schemas.
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"go.vocdoni.io/dvote/types/metadata/schema"
+)
+
+const (
+	// DefaultLanguage is the key of the LanguageString used if there is no
+	// translation for the requested language.
+	DefaultLanguage = "default"
+	// MaxSize is the maximum size of a metadata document.
+	MaxSize = 1024 * 1024 // 1MB
+)
+
+// ParseElection validates the election metadata against the JSON schema of
+// its version and returns it normalised. If the metadata does not match the
+// schema, the error is a *schema.ValidationError.
+func ParseElection(data []byte) (*Election, error) {
+	version, err := schema.ValidateMetadata(schema.MetadataElection, data)
+	if err != nil {
+		return nil, err
+	}
+	m := &Election{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	m.Version = version
+	m.Normalize()
+	return m, nil
+}
+
+// ParseAccount validates the account metadata against the JSON schema of its
+// version and returns it normalised. If the metadata does not match the
+// schema, the error is a *schema.ValidationError.
+func ParseAccount(data []byte) (*Account, error) {
+	version, err := schema.ValidateMetadata(schema.MetadataAccount, data)
+	if err != nil {
+		return nil, err
+	}
+	m := &Account{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	m.Version = version
+	m.Normalize()
+	return m, nil
+}
+
+// Errors returns the list of errors of the metadata validation error.
+func Errors(err error) []string {
+	verr := &schema.ValidationError{}
+	if errors.As(err, &verr) {
+		return verr.Errors
+	}
+	return []string{err.Error()}
+}
+
+// Normalize trims the texts, removes the empty ones and sets the default
+// language of every LanguageString of the election metadata.
+func (m *Election) Normalize() {
+	m.Title = m.Title.normalize()
+	m.Description = m.Description.normalize()
+	for i := range m.Questions {
+		m.Questions[i].Title = m.Questions[i].Title.normalize()
+		m.Questions[i].Description = m.Questions[i].Description.normalize()
+		for j := range m.Questions[i].Choices {
+			m.Questions[i].Choices[j].Title = m.Questions[i].Choices[j].Title.normalize()
+		}
+	}
+}
+
+// Normalize trims the texts, removes the empty ones and sets the default
+// language of every LanguageString of the account metadata.
+func (m *Account) Normalize() {
+	m.Name = m.Name.normalize()
+	m.Description = m.Description.normalize()
+	m.NewsFeed = m.NewsFeed.normalize()
+}
+
+// normalize returns a copy of the LanguageString without empty texts and with
+// the default language set, taken from English or else the first language in
+// alphabetical order. It returns nil if there are no texts.
+func (ls LanguageString) normalize() LanguageString {
+	norm := make(LanguageString, len(ls))
+	for lang, text := range ls {
+		if text = strings.TrimSpace(text); text != "" {
+			norm[strings.TrimSpace(lang)] = text
+		}
+	}
+	if len(norm) == 0 {
+		return nil
+	}
+	if _, ok := norm[DefaultLanguage]; !ok {
+		if text, ok := norm["en"]; ok {
+			norm[DefaultLanguage] = text
+		} else {
+			langs := make([]string, 0, len(norm))
+			for lang := range norm {
+				langs = append(langs, lang)
+			}
+			sort.Strings(langs)
+			norm[DefaultLanguage] = norm[langs[0]]
+		}
+	}
+	return norm
+}
@@ -0,0 +1,31 @@
+package metadata
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestParseElection(t *testing.T) {
+	m, err := ParseElection([]byte(`{
+  "title": {"es": " Elección ", "en": "Election", "ca": ""},
+  "description": {"ca": "Descripció", "es": "  "},
+  "questions": [{"title": {"default": "Question"}, "choices": [{"title": {"en": "Yes"}, "value": 0}]}]
+}`))
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, m.Version, qt.Equals, "1.0")
+	qt.Assert(t, m.Title, qt.DeepEquals, LanguageString{
+		"default": "Election", "en": "Election", "es": "Elección",
+	})
+	qt.Assert(t, m.Description, qt.DeepEquals, LanguageString{
+		"default": "Descripció", "ca": "Descripció",
+	})
+	qt.Assert(t, m.Questions[0].Title, qt.DeepEquals, LanguageString{"default": "Question"})
+	qt.Assert(t, m.Questions[0].Description, qt.IsNil)
+	qt.Assert(t, m.Questions[0].Choices[0].Title, qt.DeepEquals, LanguageString{
+		"default": "Yes", "en": "Yes",
+	})
+
+	_, err = ParseElection([]byte(`{"title": "Election"}`))
+	qt.Assert(t, Errors(err), qt.DeepEquals, []string{"$.title: expected object, but got string"})
+}
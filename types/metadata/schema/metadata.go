@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Kinds of metadata with a JSON schema.
+const (
+	MetadataElection = "election"
+	MetadataAccount  = "account"
+)
+
+// LatestMetadataVersion is the version of the metadata schemas used when the
+// metadata does not specify any.
+const LatestMetadataVersion = "1.0"
+
+// ErrVersionUnsupported is returned if there is no schema for the version of
+// the metadata.
+var ErrVersionUnsupported = errors.New("metadata version not supported")
+
+// metadataSchemas holds the versioned metadata schemas, stored as
+// metadata/<kind>/v<major version>.json. Changes that are not backwards
+// compatible require a new major version.
+//
+//go:embed metadata
+var metadataSchemas embed.FS
+
+var (
+	compiledLock sync.Mutex
+	compiled     = make(map[string]*Schema)
+)
+
+// MetadataSchema returns the schema of the kind of metadata for the version,
+// which only depends on its major number (i.e. 1.0 and 1.2 share the schema).
+// If the version is empty, the latest one is returned.
+func MetadataSchema(kind, version string) (*Schema, error) {
+	if version == "" {
+		version = LatestMetadataVersion
+	}
+	major, _, _ := strings.Cut(version, ".")
+	name := fmt.Sprintf("metadata/%s/v%s.json", kind, major)
+	compiledLock.Lock()
+	defer compiledLock.Unlock()
+	if s, ok := compiled[name]; ok {
+		return s, nil
+	}
+	data, err := metadataSchemas.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrVersionUnsupported, kind, version)
+	}
+	s, err := Compile(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot compile schema %s: %w", name, err)
+	}
+	compiled[name] = s
+	return s, nil
+}
+
+// ValidateMetadata validates the metadata against the schema of its kind and
+// version, returning the version. If the metadata is valid JSON but does not
+// match the schema, the error is a *ValidationError.
+func ValidateMetadata(kind string, data []byte) (string, error) {
+	v := struct {
+		Version interface{} `json:"version"`
+	}{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", fmt.Errorf("cannot parse metadata: %w", err)
+	}
+	version, ok := v.Version.(string)
+	if !ok && v.Version != nil {
+		return "", &ValidationError{Errors: []string{"$.version: expected string"}}
+	}
+	s, err := MetadataSchema(kind, version)
+	if err != nil {
+		return "", err
+	}
+	if version == "" {
+		version = LatestMetadataVersion
+	}
+	return version, s.Validate(data)
+}
@@ -0,0 +1,99 @@
+// Package schema validates JSON documents, such as the election and account
+// metadata, against JSON schemas (draft-07).
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// ValidationError holds the list of errors found while validating a document.
+type ValidationError struct {
+	Errors []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid document: %s", strings.Join(e.Errors, "; "))
+}
+
+// Schema is a compiled JSON schema.
+type Schema struct {
+	schema *jsonschema.Schema
+}
+
+// Compile parses and compiles a JSON schema.
+func Compile(data []byte) (*Schema, error) {
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft7
+	if err := c.AddResource("schema.json", bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("cannot parse schema: %w", err)
+	}
+	s, err := c.Compile("schema.json")
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{schema: s}, nil
+}
+
+// Validate validates the JSON document against the schema. If the document
+// is valid JSON but does not match the schema, the error is a
+// *ValidationError, whose errors are sorted by the path of the value.
+func (s *Schema) Validate(data []byte) error {
+	// decode the numbers as json.Number, so integers are not mistaken for floats
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("cannot parse document: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("cannot parse document: unexpected data after the JSON value")
+	}
+	err := s.schema.Validate(v)
+	verr := &jsonschema.ValidationError{}
+	if !errors.As(err, &verr) {
+		return err
+	}
+	var errs []string
+	leafErrors(verr, &errs)
+	sort.Strings(errs)
+	return &ValidationError{Errors: errs}
+}
+
+// leafErrors appends the errors without causes of the validation error, as
+// the JSON path of the value followed by the message.
+func leafErrors(verr *jsonschema.ValidationError, errs *[]string) {
+	if len(verr.Causes) == 0 {
+		*errs = append(*errs, jsonPath(verr.InstanceLocation)+": "+verr.Message)
+		return
+	}
+	for _, cause := range verr.Causes {
+		leafErrors(cause, errs)
+	}
+}
+
+// jsonPath converts a JSON pointer, such as /questions/0/title, into a JSON
+// path, such as $.questions[0].title.
+func jsonPath(pointer string) string {
+	path := "$"
+	if pointer == "" {
+		return path
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		if _, err := strconv.ParseUint(token, 10, 64); err == nil {
+			path += "[" + token + "]"
+		} else {
+			path += "." + token
+		}
+	}
+	return path
+}
@@ -0,0 +1,111 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestSchema(t *testing.T) {
+	// the schema covers the keywords used by the metadata schemas
+	s, err := Compile([]byte(`{
+  "type": "object",
+  "required": ["name", "tags"],
+  "properties": {
+    "name": {"type": "string", "minLength": 2, "maxLength": 4, "pattern": "^[a-z]+$"},
+    "age": {"type": ["integer", "null"], "minimum": 0, "maximum": 10},
+    "tags": {"type": "array", "minItems": 1, "maxItems": 2, "items": {"$ref": "#/definitions/tag"}},
+    "map": {"type": "object", "minProperties": 1}
+  },
+  "additionalProperties": false,
+  "definitions": {
+    "tag": {"type": "object", "additionalProperties": {"type": "string"}}
+  }
+}`))
+	qt.Assert(t, err, qt.IsNil)
+
+	qt.Assert(t, s.Validate([]byte(`{"name":"ab","age":3,"tags":[{"x":"y"}],"map":{"a":1}}`)), qt.IsNil)
+	qt.Assert(t, s.Validate([]byte(`{"name":"abcd","age":null,"tags":[{},{}]}`)), qt.IsNil)
+
+	for _, tc := range []struct {
+		keyword string
+		doc     string
+		want    []string
+	}{
+		{"type", `[]`, []string{`$: expected object, but got array`}},
+		{"type list", `{"name":"ab","tags":[{}],"age":1.5}`, []string{`$.age: expected integer or null, but got number`}},
+		{"required", `{"tags":[{}]}`, []string{`$: missing properties: 'name'`}},
+		{"minLength", `{"name":"a","tags":[{}]}`, []string{`$.name: length must be >= 2, but got 1`}},
+		{"maxLength", `{"name":"abcde","tags":[{}]}`, []string{`$.name: length must be <= 4, but got 5`}},
+		{"pattern", `{"name":"AB","tags":[{}]}`, []string{`$.name: does not match pattern '^[a-z]+$'`}},
+		{"minimum", `{"name":"ab","tags":[{}],"age":-1}`, []string{`$.age: must be >= 0 but found -1`}},
+		{"maximum", `{"name":"ab","tags":[{}],"age":11}`, []string{`$.age: must be <= 10 but found 11`}},
+		{"minItems", `{"name":"ab","tags":[]}`, []string{`$.tags: minimum 1 items required, but found 0 items`}},
+		{"maxItems", `{"name":"ab","tags":[{},{},{}]}`, []string{`$.tags: maximum 2 items required, but found 3 items`}},
+		{"items and $ref", `{"name":"ab","tags":[{},{"x":1}]}`, []string{`$.tags[1].x: expected string, but got number`}},
+		{"minProperties", `{"name":"ab","tags":[{}],"map":{}}`, []string{`$.map: minimum 1 properties allowed, but found 0 properties`}},
+		{"additionalProperties", `{"name":"ab","tags":[{}],"other":1}`, []string{`$: additionalProperties 'other' not allowed`}},
+		{"sorted errors", `{"name":"1","tags":[{"x":1},{},{}]}`, []string{
+			`$.name: does not match pattern '^[a-z]+$'`,
+			`$.name: length must be >= 2, but got 1`,
+			`$.tags: maximum 2 items required, but found 3 items`,
+			`$.tags[0].x: expected string, but got number`,
+		}},
+	} {
+		err := s.Validate([]byte(tc.doc))
+		verr := &ValidationError{}
+		qt.Assert(t, errors.As(err, &verr), qt.IsTrue, qt.Commentf("%s", tc.keyword))
+		qt.Assert(t, verr.Errors, qt.DeepEquals, tc.want, qt.Commentf("%s", tc.keyword))
+	}
+
+	// invalid JSON is not a validation error
+	for _, doc := range []string{`{`, `{} {}`} {
+		err := s.Validate([]byte(doc))
+		qt.Assert(t, err, qt.IsNotNil)
+		qt.Assert(t, errors.As(err, new(*ValidationError)), qt.IsFalse)
+	}
+
+	_, err = Compile([]byte(`{"$ref": "#/definitions/missing"}`))
+	qt.Assert(t, err, qt.IsNotNil)
+}
+
+func TestValidateMetadata(t *testing.T) {
+	version, err := ValidateMetadata(MetadataElection, []byte(`{
+  "version": "1.0",
+  "title": {"default": "election"},
+  "description": {"default": "description", "es": "descripción"},
+  "media": {"header": "https://example.com/header.png"},
+  "meta": {"custom": [1, 2]},
+  "questions": [{"title": {"default": "question"}, "choices": [
+    {"title": {"default": "yes"}, "value": 0},
+    {"title": {"default": "no"}, "value": 1}
+  ]}],
+  "results": {"aggregation": "discrete-values", "display": "multiple-choice"}
+}`))
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, version, qt.Equals, "1.0")
+
+	// the latest version is used if not specified
+	version, err = ValidateMetadata(MetadataElection, []byte(`{"title": {"default": "election"}}`))
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, version, qt.Equals, LatestMetadataVersion)
+
+	_, err = ValidateMetadata(MetadataElection, []byte(`{"title": {}, "questions": [{"title": {"default": "q"}, "choices": [{"value": -1}]}]}`))
+	verr := &ValidationError{}
+	qt.Assert(t, errors.As(err, &verr), qt.IsTrue)
+	qt.Assert(t, verr.Errors, qt.DeepEquals, []string{
+		`$.questions[0].choices[0].value: must be >= 0 but found -1`,
+		`$.questions[0].choices[0]: missing properties: 'title'`,
+		`$.title: minimum 1 properties allowed, but found 0 properties`,
+	})
+
+	_, err = ValidateMetadata(MetadataElection, []byte(`{"version": "2.0", "title": {"default": "election"}}`))
+	qt.Assert(t, err, qt.ErrorIs, ErrVersionUnsupported)
+
+	_, err = ValidateMetadata(MetadataAccount, []byte(`{"version": "1.0", "name": {"default": "org"}, "languages": ["en", "pt-BR"], "actions": [{"type": "browser"}]}`))
+	qt.Assert(t, err, qt.IsNil)
+	_, err = ValidateMetadata(MetadataAccount, []byte(`{"meta": "text", "actions": {}}`))
+	qt.Assert(t, errors.As(err, &verr), qt.IsTrue)
+	qt.Assert(t, verr.Errors, qt.HasLen, 2)
+}
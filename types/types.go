@@ -2,6 +2,12 @@ package types
 
 type DataStore struct {
 	Datadir string
+	// MaxSize is the maximum size in bytes of the stored files, zero means
+	// no limit (only used by the local storage)
+	MaxSize int64
+	// Gateways are the HTTP gateways used to retrieve the files not stored
+	// locally (only used by the local storage)
+	Gateways []string
 }
 
 // TODO: use an array, and possibly declare methods to encode/decode as hex.
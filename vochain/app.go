@@ -59,6 +59,7 @@ type BaseApplication struct {
 	fnMempoolSize      func() int
 	fnBeginBlock       func(req abcitypes.RequestBeginBlock) abcitypes.ResponseBeginBlock
 	fnEndBlock         func(req abcitypes.RequestEndBlock) abcitypes.ResponseEndBlock
+	fnTime             func() time.Time
 
 	blockCache *lru.AtomicCache
 	// height of the last ended block
@@ -105,6 +106,7 @@ func NewBaseApplication(dbType, dbpath string) (*BaseApplication, error) {
 		chainID:            "test",
 		circuitConfigTag:   circuit.DefaultCircuitConfigurationTag,
 		genesisInfo:        &tmtypes.GenesisDoc{},
+		fnTime:             time.Now,
 	}, nil
 }
 
@@ -323,6 +325,12 @@ func (app *BaseApplication) GetBlockByHeight(height int64) *tmtypes.Block {
 	return cachedBlock.(*tmtypes.Block)
 }
 
+// PurgeBlockCache removes the cached blocks, so they are fetched again from
+// the block store. It must be called if the block store is rolled back.
+func (app *BaseApplication) PurgeBlockCache() {
+	app.blockCache.Purge()
+}
+
 // GetBlockByHash retreies a full Tendermint block indexed by its Hash
 func (app *BaseApplication) GetBlockByHash(hash []byte) *tmtypes.Block {
 	if app.fnGetBlockByHash == nil {
@@ -520,6 +528,7 @@ func (app *BaseApplication) fnBeginBlockDefault(
 	app.startBlockTimestamp.Store(req.Header.GetTime().Unix())
 	height := uint32(req.Header.GetHeight())
 	app.State.SetHeight(height)
+	app.State.SetTimestamp(req.Header.GetTime().Unix())
 	go app.State.CachePurge(height)
 
 	return abcitypes.ResponseBeginBlock{}
@@ -542,14 +551,27 @@ func (app *BaseApplication) AdvanceTestBlock() {
 	}})
 }
 
-// CheckTx unmarshals req.Tx and checks its validity
+// CheckTx unmarshals req.Tx and checks its validity. The mempool rechecks
+// after each block, if enabled, only check the expiry and the nonce of the
+// transactions, so the expired ones and the ones whose nonce has been used
+// are evicted without running the full checks again.
 func (app *BaseApplication) CheckTx(req abcitypes.RequestCheckTx) abcitypes.ResponseCheckTx {
 	var response *transaction.TransactionResponse
 	var err error
+	tx := new(vochaintx.VochainTx)
 	if req.Type == abcitypes.CheckTxType_Recheck {
+		if err = tx.Unmarshal(req.Tx, app.ChainID()); err != nil {
+			return abcitypes.ResponseCheckTx{Code: 1, Data: []byte("unmarshalTx " + err.Error())}
+		}
+		if err = app.TransactionHandler.CheckTxExpiry(tx, false); err == nil {
+			err = app.TransactionHandler.CheckTxNonce(tx)
+		}
+		if err != nil {
+			log.Debugw("evicting tx from mempool", "hash", fmt.Sprintf("%x", tx.TxID), "error", err)
+			return abcitypes.ResponseCheckTx{Code: 1, Data: []byte("recheckTx " + err.Error())}
+		}
 		return abcitypes.ResponseCheckTx{Code: 0}
 	}
-	tx := new(vochaintx.VochainTx)
 	if err = tx.Unmarshal(req.Tx, app.ChainID()); err == nil {
 		if response, err = app.TransactionHandler.CheckTx(tx, false); err != nil {
 			if errors.Is(err, transaction.ErrorAlreadyExistInCache) {
@@ -628,7 +650,7 @@ func (app *BaseApplication) Query(req abcitypes.RequestQuery) abcitypes.Response
 
 // fnEndBlockDefault updates the app height and timestamp at the end of the current block
 func (app *BaseApplication) fnEndBlockDefault(req abcitypes.RequestEndBlock) abcitypes.ResponseEndBlock {
-	app.endBlock(req.Height, time.Now())
+	app.endBlock(req.Height, app.fnTime())
 	return abcitypes.ResponseEndBlock{}
 }
 
@@ -696,6 +718,12 @@ func (app *BaseApplication) SetFnEndBlock(fn func(req abcitypes.RequestEndBlock)
 	app.fnEndBlock = fn
 }
 
+// SetFnTime sets the clock used for the block end timestamp, which is the
+// local time by default.
+func (app *BaseApplication) SetFnTime(fn func() time.Time) {
+	app.fnTime = fn
+}
+
 // SetChainID sets the app and state chainID
 func (app *BaseApplication) SetChainID(chainId string) {
 	app.chainID = chainId
@@ -0,0 +1,101 @@
+package vochain
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	abcitypes "github.com/tendermint/tendermint/abci/types"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain/state"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
+	"go.vocdoni.io/proto/build/go/models"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTxExpiry(t *testing.T) {
+	app, signers, err := setupTestBaseApplicationAndSigners(t, 1)
+	qt.Assert(t, err, qt.IsNil)
+	signer := signers[0]
+	qt.Assert(t, app.State.SetAccount(signer.Address(), &state.Account{
+		Account: models.Account{Balance: 1000},
+	}), qt.IsNil)
+	app.AdvanceTestBlock()
+	height := app.State.CurrentHeight()
+
+	// the transaction can be included until the next block
+	stx := testExpiringSetAccountTx(t, app, signer, 0, &vochaintx.Expiry{Height: height + 1})
+	qt.Assert(t, app.CheckTx(abcitypes.RequestCheckTx{Tx: stx}).Code, qt.Equals, uint32(0))
+	// the mempool recheck keeps it while it can be included
+	qt.Assert(t, app.CheckTx(abcitypes.RequestCheckTx{
+		Tx: stx, Type: abcitypes.CheckTxType_Recheck,
+	}).Code, qt.Equals, uint32(0))
+	// and evicts it once it cannot be included in the next block, although
+	// it can still be included in the current one
+	app.AdvanceTestBlock()
+	resp := app.CheckTx(abcitypes.RequestCheckTx{Tx: stx, Type: abcitypes.CheckTxType_Recheck})
+	qt.Assert(t, resp.Code, qt.Equals, uint32(1))
+	qt.Assert(t, string(resp.Data), qt.Matches, ".*transaction expired.*")
+	qt.Assert(t, app.CheckTx(abcitypes.RequestCheckTx{Tx: stx}).Code, qt.Equals, uint32(1))
+	qt.Assert(t, app.DeliverTx(abcitypes.RequestDeliverTx{Tx: stx}).Code, qt.Equals, uint32(0))
+	app.AdvanceTestBlock()
+	testCheckBalance(t, app, signer, 900, 1)
+
+	// the blocks reject the expired transactions
+	stx = testExpiringSetAccountTx(t, app, signer, 1, &vochaintx.Expiry{Height: height + 1})
+	qt.Assert(t, app.DeliverTx(abcitypes.RequestDeliverTx{Tx: stx}).Code, qt.Equals, uint32(1))
+
+	// the timestamp is compared with the one of the block
+	stx = testExpiringSetAccountTx(t, app, signer, 1, &vochaintx.Expiry{
+		Timestamp: uint64(time.Now().Add(-time.Minute).Unix()),
+	})
+	resp = app.CheckTx(abcitypes.RequestCheckTx{Tx: stx})
+	qt.Assert(t, resp.Code, qt.Equals, uint32(1))
+	qt.Assert(t, string(resp.Data), qt.Matches, ".*transaction expired.*")
+	stx = testExpiringSetAccountTx(t, app, signer, 1, &vochaintx.Expiry{
+		Height:    height + 100,
+		Timestamp: uint64(time.Now().Add(time.Hour).Unix()),
+	})
+	qt.Assert(t, app.CheckTx(abcitypes.RequestCheckTx{Tx: stx}).Code, qt.Equals, uint32(0))
+	qt.Assert(t, app.DeliverTx(abcitypes.RequestDeliverTx{Tx: stx}).Code, qt.Equals, uint32(0))
+	app.AdvanceTestBlock()
+	testCheckBalance(t, app, signer, 800, 2)
+
+	// the recheck also evicts the transactions whose nonce has been used
+	resp = app.CheckTx(abcitypes.RequestCheckTx{Tx: stx, Type: abcitypes.CheckTxType_Recheck})
+	qt.Assert(t, resp.Code, qt.Equals, uint32(1))
+	qt.Assert(t, string(resp.Data), qt.Matches, ".*nonce.*")
+
+	// but it does not run the whole check
+	stx = testExpiringSetAccountTx(t, app, signer, 2, nil)
+	qt.Assert(t, app.State.SetAccount(signer.Address(), &state.Account{
+		Account: models.Account{Balance: 0, Nonce: 2},
+	}), qt.IsNil)
+	qt.Assert(t, app.CheckTx(abcitypes.RequestCheckTx{Tx: stx}).Code, qt.Equals, uint32(1))
+	qt.Assert(t, app.CheckTx(abcitypes.RequestCheckTx{
+		Tx: stx, Type: abcitypes.CheckTxType_Recheck,
+	}).Code, qt.Equals, uint32(0))
+}
+
+// testExpiringSetAccountTx returns a signed SetAccountInfoURI transaction
+// with the given expiry.
+func testExpiringSetAccountTx(t *testing.T, app *BaseApplication, signer *ethereum.SignKeys,
+	nonce uint32, expiry *vochaintx.Expiry) []byte {
+	infoURI := "ipfs://" + util.RandomHex(16)
+	tx := &models.Tx{Payload: &models.Tx_SetAccount{SetAccount: &models.SetAccountTx{
+		Nonce:   &nonce,
+		Txtype:  models.TxType_SET_ACCOUNT_INFO_URI,
+		InfoURI: &infoURI,
+	}}}
+	vochaintx.SetTxExpiry(tx, expiry)
+	var err error
+	stx := &models.SignedTx{}
+	stx.Tx, err = proto.Marshal(tx)
+	qt.Assert(t, err, qt.IsNil)
+	stx.Signature, err = signer.SignVocdoniTx(stx.Tx, app.chainID)
+	qt.Assert(t, err, qt.IsNil)
+	stxBytes, err := proto.Marshal(stx)
+	qt.Assert(t, err, qt.IsNil)
+	return stxBytes
+}
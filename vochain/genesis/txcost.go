@@ -2,6 +2,7 @@ package genesis
 
 import (
 	"reflect"
+	"strings"
 
 	"go.vocdoni.io/proto/build/go/models"
 )
@@ -21,6 +22,10 @@ type TransactionCosts struct {
 	AddDelegateForAccount   uint32 `json:"Tx_AddDelegateForAccount"`
 	DelDelegateForAccount   uint32 `json:"Tx_DelDelegateForAccount"`
 	CollectFaucet           uint32 `json:"Tx_CollectFaucet"`
+	// The costs below are omitted when unset, so the genesis and the initial
+	// state of the chains launched before they were added stay the same.
+	SetSponsorLimits  uint32 `json:"Tx_SetSponsorLimits,omitempty"`
+	SendVestingTokens uint32 `json:"Tx_SendVestingTokens,omitempty"`
 }
 
 // AsMap returns the contents of TransactionCosts as a map. Its purpose
@@ -32,8 +37,12 @@ func (t *TransactionCosts) AsMap() map[models.TxType]uint64 {
 	tType := reflect.TypeOf(*t)
 	tValue := reflect.ValueOf(*t)
 	for i := 0; i < tType.NumField(); i++ {
+		value := tValue.Field(i).Uint()
+		if value == 0 && strings.HasSuffix(tType.Field(i).Tag.Get("json"), ",omitempty") {
+			continue
+		}
 		key := TxCostNameToTxType(tType.Field(i).Name)
-		b[key] = tValue.Field(i).Uint()
+		b[key] = value
 	}
 	return b
 }
@@ -52,6 +61,8 @@ var TxCostNameToTxTypeMap = map[string]models.TxType{
 	"AddDelegateForAccount":   models.TxType_ADD_DELEGATE_FOR_ACCOUNT,
 	"DelDelegateForAccount":   models.TxType_DEL_DELEGATE_FOR_ACCOUNT,
 	"CollectFaucet":           models.TxType_COLLECT_FAUCET,
+	"SetSponsorLimits":        models.TxType_SET_SPONSOR_LIMITS,
+	"SendVestingTokens":       models.TxType_SEND_VESTING_TOKENS,
 }
 
 // TxCostNameToTxType converts a valid string to a txType
@@ -76,6 +87,8 @@ var TxTypeToCostNameMap = map[models.TxType]string{
 	models.TxType_ADD_DELEGATE_FOR_ACCOUNT:   "AddDelegateForAccount",
 	models.TxType_DEL_DELEGATE_FOR_ACCOUNT:   "DelDelegateForAccount",
 	models.TxType_COLLECT_FAUCET:             "CollectFaucet",
+	models.TxType_SET_SPONSOR_LIMITS:         "SetSponsorLimits",
+	models.TxType_SEND_VESTING_TOKENS:        "SendVestingTokens",
 }
 
 // TxTypeToCostName converts a valid txType to a string
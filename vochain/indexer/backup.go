@@ -0,0 +1,140 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.vocdoni.io/dvote/log"
+	indexerdb "go.vocdoni.io/dvote/vochain/indexer/db"
+)
+
+// Backup writes a copy of the indexer database to the given file path, which
+// must not exist. The asynchronous work started by Commit is awaited, so the
+// copy contains all the indexed blocks.
+func (idx *Indexer) Backup(path string) error {
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("backup file %s already exists", path)
+	}
+	idx.waitGoroutines()
+	idx.lockPool.Lock()
+	defer idx.lockPool.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := idx.sqlDB.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("cannot backup indexer database: %w", err)
+	}
+	return nil
+}
+
+// RestoreBackup replaces the contents of the indexer database with the ones of
+// a backup created by Backup. The database is restored in place, since it is
+// shared with the running queries, and the pending data of the current block
+// is discarded.
+func (idx *Indexer) RestoreBackup(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("cannot open backup: %w", err)
+	}
+	idx.waitGoroutines()
+	idx.Rollback()
+	idx.lockPool.Lock()
+	defer idx.lockPool.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	// ATTACH cannot run inside a transaction, and the attached database is
+	// only visible on the connection which attached it.
+	conn, err := idx.sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS backup", path); err != nil {
+		return fmt.Errorf("cannot attach backup: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, "DETACH DATABASE backup"); err != nil {
+			log.Warnw("cannot detach indexer backup", "err", err)
+		}
+	}()
+	rows, err := conn.QueryContext(ctx,
+		"SELECT name, sql FROM backup.sqlite_master WHERE type = 'table' ORDER BY name")
+	if err != nil {
+		return err
+	}
+	var tables, virtualTables []string
+	for rows.Next() {
+		var name, schema string
+		if err := rows.Scan(&name, &schema); err != nil {
+			rows.Close()
+			return err
+		}
+		tables = append(tables, name)
+		if strings.HasPrefix(strings.ToUpper(schema), "CREATE VIRTUAL TABLE") {
+			virtualTables = append(virtualTables, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+tables:
+	for _, table := range tables {
+		// the virtual (full-text search) tables and their shadow tables are
+		// updated by the triggers of the tables they index
+		for _, vt := range virtualTables {
+			if table == vt || strings.HasPrefix(table, vt+"_") {
+				continue tables
+			}
+		}
+		if strings.HasPrefix(table, "sqlite_") && table != "sqlite_sequence" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM main.%q", table)); err != nil {
+			return fmt.Errorf("cannot clear table %s: %w", table, err)
+		}
+		if _, err := tx.ExecContext(ctx,
+			fmt.Sprintf("INSERT INTO main.%q SELECT * FROM backup.%q", table, table)); err != nil {
+			return fmt.Errorf("cannot restore table %s: %w", table, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// the processes without final results are counted live again
+	idx.liveResultsProcs.Range(func(key, _ any) bool {
+		idx.liveResultsProcs.Delete(key)
+		return true
+	})
+	if !idx.ignoreLiveResults {
+		pids, err := indexerdb.New(conn).GetProcessIDsByFinalResults(ctx, false)
+		if err != nil {
+			return err
+		}
+		for _, pid := range pids {
+			idx.addProcessToLiveResults(pid)
+		}
+	}
+	return nil
+}
+
+// waitGoroutines waits until there are no live asynchronous goroutines. Unlike
+// WaitIdle, it returns immediately if there are none.
+func (idx *Indexer) waitGoroutines() {
+	for i := 0; idx.liveGoroutines.Load() > 0; i++ {
+		if i == 1000 {
+			log.Warnf("giving up on waiting for the indexer goroutines after 10s")
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,59 @@
+package indexer
+
+import (
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+func TestBackupRestore(t *testing.T) {
+	app := vochain.TestBaseApplication(t)
+	idx := newTestIndexer(t, app, true)
+
+	addProcess := func() []byte {
+		pid := util.RandomBytes(32)
+		qt.Assert(t, app.State.AddProcess(&models.Process{
+			ProcessId:     pid,
+			EntityId:      util.RandomBytes(20),
+			BlockCount:    10,
+			VoteOptions:   &models.ProcessVoteOptions{MaxCount: 8, MaxValue: 3},
+			EnvelopeType:  &models.EnvelopeType{},
+			MaxCensusSize: 1000,
+		}), qt.IsNil)
+		app.AdvanceTestBlock()
+		return pid
+	}
+	pid1 := addProcess()
+	qt.Assert(t, idx.IndexElectionMetadata(pid1, map[string]*indexertypes.MetadataText{
+		"default": {Title: "Budget 2026"},
+	}), qt.IsNil)
+
+	backup := filepath.Join(t.TempDir(), "backup.sqlite")
+	qt.Assert(t, idx.Backup(backup), qt.IsNil)
+	// the backup file is never overwritten
+	qt.Assert(t, idx.Backup(backup), qt.IsNotNil)
+
+	pid2 := addProcess()
+	qt.Assert(t, idx.IndexElectionMetadata(pid2, map[string]*indexertypes.MetadataText{
+		"default": {Title: "Budget 2027"},
+	}), qt.IsNil)
+	qt.Assert(t, idx.ProcessCount(nil), qt.Equals, uint64(2))
+
+	qt.Assert(t, idx.RestoreBackup(backup), qt.IsNil)
+	qt.Assert(t, idx.ProcessCount(nil), qt.Equals, uint64(1))
+	_, err := idx.ProcessInfo(pid1)
+	qt.Assert(t, err, qt.IsNil)
+	_, err = idx.ProcessInfo(pid2)
+	qt.Assert(t, err, qt.ErrorIs, ErrProcessNotFound)
+	qt.Assert(t, idx.isProcessLiveResults(pid1), qt.IsTrue)
+	qt.Assert(t, idx.isProcessLiveResults(pid2), qt.IsFalse)
+	results, err := idx.SearchElections("budget", "", 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, results, qt.HasLen, 1)
+	qt.Assert(t, []byte(results[0].ID), qt.DeepEquals, pid1)
+}
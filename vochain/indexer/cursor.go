@@ -0,0 +1,305 @@
+package indexer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+	"go.vocdoni.io/dvote/vochain/state"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+// The lists paginated with cursors use keyset pagination: each item of a list
+// has a unique (key, index) position, and a page holds the items after (or
+// before) the position of the cursor. Unlike offset pagination, this does not
+// skip nor duplicate items if new items are indexed while paginating.
+//
+// The processes and token transfers are ordered by their seq column, which
+// holds their insertion order and, unlike the implicit rowid, is not
+// renumbered by VACUUM. Note that these queries don't use sqlc, since they are built from
+// the listQuery of each list.
+
+// listQuery describes a list which can be paginated with cursors.
+type listQuery struct {
+	// columns are the selected columns; the key and index are selected after them.
+	columns string
+	// from is the table or subquery to list, and where is an optional filter
+	// for it, with its args.
+	from  string
+	where string
+	args  []any
+	// key and index are the expressions the list is ordered by.
+	// The index is optional, for the lists ordered by a unique key.
+	key, index string
+	// descending lists the items from the greatest position to the smallest.
+	descending bool
+}
+
+// listPage fetches a page of up to limit items of a list, starting at the cursor
+// position or at the beginning of the list if the cursor is nil. The scan func
+// must scan each row into the returned item, plus the key and index.
+// The total number of items is only counted for the first page, since counting
+// them is as expensive as scanning the whole list.
+func (idx *Indexer) listPage(q *listQuery, cursor *indexertypes.ListCursor, limit int,
+	scan func(rows *sql.Rows, key, index *int64) (any, error),
+) ([]any, *indexertypes.ListPage, error) {
+	if limit <= 0 {
+		return nil, nil, fmt.Errorf("listPage: invalid value: limit is invalid value %d", limit)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	where := "1"
+	if q.where != "" {
+		where = q.where
+	}
+	page := &indexertypes.ListPage{}
+	if cursor == nil {
+		if err := idx.sqlDB.QueryRowContext(ctx,
+			fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", q.from, where), q.args...,
+		).Scan(&page.Total); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// A reverse cursor fetches the items before it in the inverse order,
+	// which are reversed once fetched.
+	forward := cursor == nil || !cursor.Reverse
+	ascending := forward != q.descending
+	args := append([]any{}, q.args...)
+	op, order := ">", "ASC"
+	if !ascending {
+		op, order = "<", "DESC"
+	}
+	index, orderBy := q.index, fmt.Sprintf("%s %s, %s %s", q.key, order, q.index, order)
+	if q.index == "" {
+		index, orderBy = "0", fmt.Sprintf("%s %s", q.key, order)
+	}
+	if cursor != nil {
+		where += fmt.Sprintf(" AND (%s, %s) %s (?, ?)", q.key, index, op)
+		args = append(args, cursor.Key, cursor.Index)
+	}
+	// Fetch an extra item to know if there are more items after the page.
+	args = append(args, limit+1)
+	rows, err := idx.sqlDB.QueryContext(ctx, fmt.Sprintf(
+		"SELECT %s, %s, %s FROM %s WHERE %s ORDER BY %s LIMIT ?",
+		q.columns, q.key, index, q.from, where, orderBy), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var items []any
+	var positions []indexertypes.ListCursor
+	for rows.Next() {
+		var position indexertypes.ListCursor
+		item, err := scan(rows, &position.Key, &position.Index)
+		if err != nil {
+			return nil, nil, err
+		}
+		items = append(items, item)
+		positions = append(positions, position)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	more := len(items) > limit
+	if more {
+		items, positions = items[:limit], positions[:limit]
+	}
+	if !forward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+			positions[i], positions[j] = positions[j], positions[i]
+		}
+	}
+	if len(items) == 0 {
+		return items, page, nil
+	}
+	first, last := positions[0], positions[len(positions)-1]
+	first.Reverse = true
+	// When paginating forward, there are items before the page if we started
+	// at a cursor, and the other way around when paginating backwards.
+	if (forward && more) || !forward {
+		page.Next = &last
+	}
+	if (!forward && more) || (forward && cursor != nil) {
+		page.Prev = &first
+	}
+	return items, page, nil
+}
+
+// ProcessPage returns a page of the list of processes indexed, in the order
+// they were created. If entityID is not empty, only the processes of the entity
+// are listed; and if any statuses are given, only the processes with one of them.
+// See ListCursor for the pagination.
+func (idx *Indexer) ProcessPage(entityID []byte, statuses []string,
+	cursor *indexertypes.ListCursor, limit int,
+) ([]types.HexBytes, *indexertypes.ListPage, error) {
+	q := &listQuery{columns: "id", from: "processes", key: "seq"}
+	var where []string
+	if len(entityID) > 0 {
+		where = append(where, "entity_id = ?")
+		q.args = append(q.args, entityID)
+	}
+	if len(statuses) > 0 {
+		for _, status := range statuses {
+			statusnum, ok := models.ProcessStatus_value[status]
+			if !ok {
+				return nil, nil, fmt.Errorf("processPage: status %s is unknown", status)
+			}
+			q.args = append(q.args, statusnum)
+		}
+		where = append(where, "status IN (?"+strings.Repeat(", ?", len(statuses)-1)+")")
+	}
+	q.where = strings.Join(where, " AND ")
+	items, page, err := idx.listPage(q, cursor, limit,
+		func(rows *sql.Rows, key, index *int64) (any, error) {
+			var id types.HexBytes
+			if err := rows.Scan(&id, key, index); err != nil {
+				return nil, err
+			}
+			return id, nil
+		})
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]types.HexBytes, len(items))
+	for i, item := range items {
+		ids[i] = item.(types.HexBytes)
+	}
+	return ids, page, nil
+}
+
+// EntityPage returns a page of the list of entities which created a process,
+// in the order of their first process. See ListCursor for the pagination.
+func (idx *Indexer) EntityPage(cursor *indexertypes.ListCursor, limit int,
+) ([]types.HexBytes, *indexertypes.ListPage, error) {
+	q := &listQuery{
+		columns: "entity_id",
+		from:    "(SELECT entity_id, MIN(seq) AS first_seq FROM processes GROUP BY entity_id)",
+		key:     "first_seq",
+	}
+	items, page, err := idx.listPage(q, cursor, limit,
+		func(rows *sql.Rows, key, index *int64) (any, error) {
+			var id types.HexBytes
+			if err := rows.Scan(&id, key, index); err != nil {
+				return nil, err
+			}
+			return id, nil
+		})
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]types.HexBytes, len(items))
+	for i, item := range items {
+		ids[i] = item.(types.HexBytes)
+	}
+	return ids, page, nil
+}
+
+// EnvelopePage returns a page of the list of envelopes of a process, in the
+// order they were included in the chain, by height and transaction index.
+// See ListCursor for the pagination.
+func (idx *Indexer) EnvelopePage(processID []byte, cursor *indexertypes.ListCursor, limit int,
+) ([]*indexertypes.EnvelopeMetadata, *indexertypes.ListPage, error) {
+	q := &listQuery{
+		columns: "process_id, nullifier, voter_id",
+		from:    "vote_references",
+		where:   "process_id = ?",
+		args:    []any{processID},
+		key:     "height",
+		index:   "tx_index",
+	}
+	items, page, err := idx.listPage(q, cursor, limit,
+		func(rows *sql.Rows, key, index *int64) (any, error) {
+			envelope := &indexertypes.EnvelopeMetadata{}
+			var voterID state.VoterID
+			if err := rows.Scan(&envelope.ProcessId, &envelope.Nullifier, &voterID, key, index); err != nil {
+				return nil, err
+			}
+			envelope.Height = uint32(*key)
+			envelope.TxIndex = int32(*index)
+			if len(voterID) > 0 {
+				envelope.VoterID = voterID.Address()
+			}
+			return envelope, nil
+		})
+	if err != nil {
+		return nil, nil, err
+	}
+	envelopes := make([]*indexertypes.EnvelopeMetadata, len(items))
+	for i, item := range items {
+		envelopes[i] = item.(*indexertypes.EnvelopeMetadata)
+		_, txHash, err := idx.App.GetTxHash(envelopes[i].Height, envelopes[i].TxIndex)
+		if err != nil {
+			return nil, nil, err
+		}
+		envelopes[i].TxHash = txHash
+	}
+	return envelopes, page, nil
+}
+
+// TxReferencePage returns a page of the list of transactions indexed, the newest
+// first. See ListCursor for the pagination.
+func (idx *Indexer) TxReferencePage(cursor *indexertypes.ListCursor, limit int,
+) ([]*indexertypes.TxReference, *indexertypes.ListPage, error) {
+	q := &listQuery{
+		columns:    "hash, block_height, tx_block_index, tx_type",
+		from:       "tx_references",
+		key:        "id",
+		descending: true,
+	}
+	items, page, err := idx.listPage(q, cursor, limit,
+		func(rows *sql.Rows, key, index *int64) (any, error) {
+			ref := &indexertypes.TxReference{}
+			if err := rows.Scan(&ref.Hash, &ref.BlockHeight, &ref.TxBlockIndex, &ref.TxType, key, index); err != nil {
+				return nil, err
+			}
+			ref.Index = uint64(*key)
+			return ref, nil
+		})
+	if err != nil {
+		return nil, nil, err
+	}
+	refs := make([]*indexertypes.TxReference, len(items))
+	for i, item := range items {
+		refs[i] = item.(*indexertypes.TxReference)
+	}
+	return refs, page, nil
+}
+
+// TokenTransferPage returns a page of the list of token transfers made from an
+// account, in the order they were included in the chain.
+// See ListCursor for the pagination.
+func (idx *Indexer) TokenTransferPage(from []byte, cursor *indexertypes.ListCursor, limit int,
+) ([]*indexertypes.TokenTransferMeta, *indexertypes.ListPage, error) {
+	q := &listQuery{
+		columns: "tx_hash, from_account, to_account, amount, transfer_time",
+		from:    "token_transfers",
+		where:   "from_account = ?",
+		args:    []any{from},
+		key:     "height",
+		index:   "seq",
+	}
+	items, page, err := idx.listPage(q, cursor, limit,
+		func(rows *sql.Rows, key, index *int64) (any, error) {
+			tt := &indexertypes.TokenTransferMeta{}
+			if err := rows.Scan(&tt.TxHash, &tt.From, &tt.To, &tt.Amount, &tt.Timestamp, key, index); err != nil {
+				return nil, err
+			}
+			tt.Height = uint64(*key)
+			return tt, nil
+		})
+	if err != nil {
+		return nil, nil, err
+	}
+	transfers := make([]*indexertypes.TokenTransferMeta, len(items))
+	for i, item := range items {
+		transfers[i] = item.(*indexertypes.TokenTransferMeta)
+	}
+	return transfers, page, nil
+}
@@ -0,0 +1,249 @@
+package indexer
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/pressly/goose/v3"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain"
+	indexerdb "go.vocdoni.io/dvote/vochain/indexer/db"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
+	models "go.vocdoni.io/proto/build/go/models"
+)
+
+func TestListCursor(t *testing.T) {
+	for _, c := range []indexertypes.ListCursor{
+		{},
+		{Key: 123, Index: 4},
+		{Key: 1 << 40, Index: -1, Reverse: true},
+	} {
+		text, err := c.MarshalText()
+		qt.Assert(t, err, qt.IsNil)
+		var decoded indexertypes.ListCursor
+		qt.Assert(t, decoded.UnmarshalText(text), qt.IsNil)
+		qt.Assert(t, decoded, qt.Equals, c)
+	}
+	var c indexertypes.ListCursor
+	qt.Assert(t, c.UnmarshalText([]byte("not a cursor")), qt.Not(qt.IsNil))
+	qt.Assert(t, c.UnmarshalText([]byte("AgQC")), qt.Not(qt.IsNil))
+}
+
+func TestProcessPage(t *testing.T) {
+	app := vochain.TestBaseApplication(t)
+	idx := newTestIndexer(t, app, true)
+
+	eid := util.RandomBytes(20)
+	var pids []types.HexBytes
+	for i := 0; i < 7; i++ {
+		pid := util.RandomBytes(32)
+		qt.Assert(t, app.State.AddProcess(&models.Process{
+			ProcessId:     pid,
+			EntityId:      eid,
+			Status:        models.ProcessStatus_READY,
+			BlockCount:    10,
+			VoteOptions:   &models.ProcessVoteOptions{MaxCount: 8, MaxValue: 3},
+			EnvelopeType:  &models.EnvelopeType{},
+			MaxCensusSize: 1000,
+		}), qt.IsNil)
+		pids = append(pids, pid)
+	}
+	// a process from another entity
+	qt.Assert(t, app.State.AddProcess(&models.Process{
+		ProcessId:     util.RandomBytes(32),
+		EntityId:      util.RandomBytes(20),
+		BlockCount:    10,
+		VoteOptions:   &models.ProcessVoteOptions{MaxCount: 8, MaxValue: 3},
+		EnvelopeType:  &models.EnvelopeType{},
+		MaxCensusSize: 1000,
+	}), qt.IsNil)
+	app.AdvanceTestBlock()
+
+	// forward
+	list, page, err := idx.ProcessPage(eid, nil, nil, 3)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, list, qt.DeepEquals, pids[:3])
+	qt.Assert(t, page.Total, qt.Equals, uint64(7))
+	qt.Assert(t, page.Prev, qt.IsNil)
+	qt.Assert(t, page.Next, qt.Not(qt.IsNil))
+
+	list, page, err = idx.ProcessPage(eid, nil, page.Next, 3)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, list, qt.DeepEquals, pids[3:6])
+	qt.Assert(t, page.Total, qt.Equals, uint64(0)) // only counted in the first page
+	qt.Assert(t, page.Prev, qt.Not(qt.IsNil))
+	middle := page
+
+	list, page, err = idx.ProcessPage(eid, nil, page.Next, 3)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, list, qt.DeepEquals, pids[6:])
+	qt.Assert(t, page.Next, qt.IsNil)
+
+	// backwards
+	list, page, err = idx.ProcessPage(eid, nil, middle.Prev, 3)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, list, qt.DeepEquals, pids[:3])
+	qt.Assert(t, page.Prev, qt.IsNil)
+	qt.Assert(t, page.Next, qt.Not(qt.IsNil))
+
+	// filters
+	list, _, err = idx.ProcessPage(nil, nil, nil, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, list, qt.HasLen, 8)
+	list, _, err = idx.ProcessPage(eid, []string{"READY", "PAUSED"}, nil, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, list, qt.HasLen, 7)
+	_, _, err = idx.ProcessPage(eid, []string{"UNKNOWN"}, nil, 10)
+	qt.Assert(t, err, qt.Not(qt.IsNil))
+
+	entities, page, err := idx.EntityPage(nil, 1)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, entities, qt.DeepEquals, []types.HexBytes{eid})
+	qt.Assert(t, page.Total, qt.Equals, uint64(2))
+	entities, page, err = idx.EntityPage(page.Next, 1)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, entities, qt.HasLen, 1)
+	qt.Assert(t, page.Next, qt.IsNil)
+}
+
+func TestProcessPageBackup(t *testing.T) {
+	app := vochain.TestBaseApplication(t)
+	idx := newTestIndexer(t, app, true)
+
+	var pids []types.HexBytes
+	for i := 0; i < 5; i++ {
+		pid := util.RandomBytes(32)
+		qt.Assert(t, app.State.AddProcess(&models.Process{
+			ProcessId:     pid,
+			EntityId:      util.RandomBytes(20),
+			BlockCount:    10,
+			VoteOptions:   &models.ProcessVoteOptions{MaxCount: 8, MaxValue: 3},
+			EnvelopeType:  &models.EnvelopeType{},
+			MaxCensusSize: 1000,
+		}), qt.IsNil)
+		pids = append(pids, pid)
+	}
+	app.AdvanceTestBlock()
+	// leave a gap, which VACUUM would renumber if the list used the rowid
+	_, err := idx.sqlDB.Exec("DELETE FROM processes WHERE id = ?", []byte(pids[0]))
+	qt.Assert(t, err, qt.IsNil)
+
+	list, page, err := idx.ProcessPage(nil, nil, nil, 2)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, list, qt.DeepEquals, pids[1:3])
+
+	backup := filepath.Join(t.TempDir(), "backup.sqlite")
+	qt.Assert(t, idx.Backup(backup), qt.IsNil)
+	qt.Assert(t, idx.RestoreBackup(backup), qt.IsNil)
+
+	// the cursors taken before the backup still point to the same position
+	list, _, err = idx.ProcessPage(nil, nil, page.Next, 2)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, list, qt.DeepEquals, pids[3:])
+}
+
+func TestTxReferencePage(t *testing.T) {
+	app := vochain.TestBaseApplication(t)
+	idx := newTestIndexer(t, app, true)
+
+	var hashes []types.HexBytes
+	for i := 0; i < 5; i++ {
+		txID := util.Random32()
+		idx.OnNewTx(&vochaintx.VochainTx{TxID: txID, TxModelType: "setAccount"}, app.Height(), int32(i))
+		hashes = append(hashes, txID[:])
+	}
+	app.AdvanceTestBlock()
+	idx.WaitIdle()
+
+	// the newest transactions come first
+	refs, page, err := idx.TxReferencePage(nil, 2)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, refs, qt.HasLen, 2)
+	qt.Assert(t, refs[0].Hash, qt.DeepEquals, hashes[4])
+	qt.Assert(t, refs[1].Hash, qt.DeepEquals, hashes[3])
+	qt.Assert(t, page.Total, qt.Equals, uint64(5))
+
+	refs, page, err = idx.TxReferencePage(page.Next, 2)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, refs[0].Hash, qt.DeepEquals, hashes[2])
+	qt.Assert(t, refs[1].Hash, qt.DeepEquals, hashes[1])
+
+	refs, _, err = idx.TxReferencePage(page.Prev, 2)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, refs[0].Hash, qt.DeepEquals, hashes[4])
+	qt.Assert(t, refs[1].Hash, qt.DeepEquals, hashes[3])
+}
+
+func TestMigrationSeq(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "db-sqlite"))
+	qt.Assert(t, err, qt.IsNil)
+	defer db.Close()
+	qt.Assert(t, goose.SetDialect("sqlite3"), qt.IsNil)
+	goose.SetBaseFS(embedMigrations)
+	qt.Assert(t, goose.UpTo(db, "migrations", 10), qt.IsNil)
+
+	insert := func(hash byte) error {
+		_, err := db.Exec(`INSERT INTO token_transfers
+(tx_hash, height, from_account, to_account, amount, transfer_time) VALUES (?, 1, x'01', x'02', 10, ?)`,
+			[]byte{hash}, time.Now())
+		return err
+	}
+	for _, hash := range []byte{3, 2, 1} {
+		qt.Assert(t, insert(hash), qt.IsNil)
+	}
+	_, err = db.Exec("DELETE FROM token_transfers WHERE tx_hash = x'02'")
+	qt.Assert(t, err, qt.IsNil)
+
+	listSeq := func() (hashes []byte, seqs []int64) {
+		rows, err := db.Query("SELECT tx_hash, seq FROM token_transfers ORDER BY seq")
+		qt.Assert(t, err, qt.IsNil)
+		defer rows.Close()
+		for rows.Next() {
+			var hash []byte
+			var seq int64
+			qt.Assert(t, rows.Scan(&hash, &seq), qt.IsNil)
+			hashes, seqs = append(hashes, hash...), append(seqs, seq)
+		}
+		qt.Assert(t, rows.Err(), qt.IsNil)
+		return hashes, seqs
+	}
+	// the existing rows keep their order, and the new ones are added after them
+	qt.Assert(t, goose.UpTo(db, "migrations", 11), qt.IsNil)
+	create := func(hash byte) error {
+		_, err := indexerdb.New(db).CreateTokenTransfer(context.Background(), indexerdb.CreateTokenTransferParams{
+			TxHash:       []byte{hash},
+			Height:       2,
+			FromAccount:  []byte{1},
+			ToAccount:    []byte{2},
+			Amount:       10,
+			TransferTime: time.Now(),
+		})
+		return err
+	}
+	qt.Assert(t, create(4), qt.IsNil)
+	hashes, seqs := listSeq()
+	qt.Assert(t, hashes, qt.DeepEquals, []byte{3, 1, 4})
+	qt.Assert(t, seqs, qt.DeepEquals, []int64{1, 3, 4})
+	// the tx hash is still the primary key
+	qt.Assert(t, create(1), qt.ErrorMatches, ".*UNIQUE constraint failed.*")
+
+	// the rowid keeps the order when migrating down
+	qt.Assert(t, goose.DownTo(db, "migrations", 10), qt.IsNil)
+	rows, err := db.Query("SELECT tx_hash FROM token_transfers ORDER BY rowid")
+	qt.Assert(t, err, qt.IsNil)
+	defer rows.Close()
+	hashes = nil
+	for rows.Next() {
+		var hash []byte
+		qt.Assert(t, rows.Scan(&hash), qt.IsNil)
+		hashes = append(hashes, hash...)
+	}
+	qt.Assert(t, rows.Err(), qt.IsNil)
+	qt.Assert(t, hashes, qt.DeepEquals, []byte{3, 1, 4})
+}
@@ -42,6 +42,42 @@ type Process struct {
 	CreationTime          time.Time
 	SourceBlockHeight     int64
 	SourceNetworkID       int64
+	Seq                   int64
+}
+
+type StatsAccount struct {
+	Bucket  int64
+	Account types.AccountID
+}
+
+type StatsBackfill struct {
+	Name       string
+	NextHeight int64
+	LastHeight int64
+}
+
+type StatsElection struct {
+	Bucket    int64
+	Elections int64
+}
+
+type StatsTokenTransfer struct {
+	Bucket    int64
+	Transfers int64
+	Volume    int64
+}
+
+type StatsTransaction struct {
+	Bucket       int64
+	TxType       string
+	Transactions int64
+}
+
+type StatsVote struct {
+	ProcessID  types.ProcessID
+	Bucket     int64
+	Votes      int64
+	Overwrites int64
 }
 
 type TokenTransfer struct {
@@ -51,6 +87,7 @@ type TokenTransfer struct {
 	ToAccount    types.AccountID
 	Amount       int64
 	TransferTime time.Time
+	Seq          int64
 }
 
 type TxReference struct {
@@ -26,7 +26,9 @@ INSERT INTO processes (
 	source_block_height, source_network_id,
 
 	results_votes, results_weight, results_envelope_height,
-	results_signatures, results_block_height
+	results_signatures, results_block_height,
+
+	seq
 ) VALUES (
 	?, ?, ?, ?,
 	?, ?, ?,
@@ -39,7 +41,9 @@ INSERT INTO processes (
 	?, ?,
 
 	?, '0', 0,
-	'', 0
+	'', 0,
+
+	(SELECT IFNULL(MAX(seq), 0) + 1 FROM processes)
 )
 `
 
@@ -127,7 +131,7 @@ func (q *Queries) GetEntityProcessCount(ctx context.Context, entityID types.Enti
 }
 
 const getProcess = `-- name: GetProcess :one
-SELECT id, entity_id, start_block, end_block, results_height, have_results, final_results, results_votes, results_weight, results_envelope_height, results_signatures, results_block_height, census_root, rolling_census_root, rolling_census_size, max_census_size, census_uri, metadata, census_origin, status, namespace, envelope_pb, mode_pb, vote_opts_pb, private_keys, public_keys, question_index, creation_time, source_block_height, source_network_id FROM processes
+SELECT id, entity_id, start_block, end_block, results_height, have_results, final_results, results_votes, results_weight, results_envelope_height, results_signatures, results_block_height, census_root, rolling_census_root, rolling_census_size, max_census_size, census_uri, metadata, census_origin, status, namespace, envelope_pb, mode_pb, vote_opts_pb, private_keys, public_keys, question_index, creation_time, source_block_height, source_network_id, seq FROM processes
 WHERE id = ?
 LIMIT 1
 `
@@ -166,6 +170,7 @@ func (q *Queries) GetProcess(ctx context.Context, id types.ProcessID) (Process,
 		&i.CreationTime,
 		&i.SourceBlockHeight,
 		&i.SourceNetworkID,
+		&i.Seq,
 	)
 	return i, err
 }
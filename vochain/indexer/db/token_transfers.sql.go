@@ -15,9 +15,9 @@ import (
 
 const createTokenTransfer = `-- name: CreateTokenTransfer :execresult
 INSERT INTO token_transfers (
-	tx_hash, height, from_account, to_account, amount, transfer_time
+	tx_hash, height, from_account, to_account, amount, transfer_time, seq
 ) VALUES (
-	?, ?, ?, ?, ?, ?
+	?, ?, ?, ?, ?, ?, (SELECT IFNULL(MAX(seq), 0) + 1 FROM token_transfers)
 )
 `
 
@@ -42,7 +42,7 @@ func (q *Queries) CreateTokenTransfer(ctx context.Context, arg CreateTokenTransf
 }
 
 const getTokenTransfer = `-- name: GetTokenTransfer :one
-SELECT tx_hash, height, from_account, to_account, amount, transfer_time FROM token_transfers
+SELECT tx_hash, height, from_account, to_account, amount, transfer_time, seq FROM token_transfers
 WHERE tx_hash = ?
 LIMIT 1
 `
@@ -57,12 +57,13 @@ func (q *Queries) GetTokenTransfer(ctx context.Context, txHash types.Hash) (Toke
 		&i.ToAccount,
 		&i.Amount,
 		&i.TransferTime,
+		&i.Seq,
 	)
 	return i, err
 }
 
 const getTokenTransfersByFromAccount = `-- name: GetTokenTransfersByFromAccount :many
-SELECT tx_hash, height, from_account, to_account, amount, transfer_time FROM token_transfers
+SELECT tx_hash, height, from_account, to_account, amount, transfer_time, seq FROM token_transfers
 WHERE from_account = ?
 ORDER BY transfer_time ASC
 LIMIT ?
@@ -94,6 +95,7 @@ func (q *Queries) GetTokenTransfersByFromAccount(ctx context.Context, arg GetTok
 			&i.ToAccount,
 			&i.Amount,
 			&i.TransferTime,
+			&i.Seq,
 		); err != nil {
 			return nil, err
 		}
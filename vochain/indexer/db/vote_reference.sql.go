@@ -70,13 +70,30 @@ func (q *Queries) GetVoteReference(ctx context.Context, nullifier types.Nullifie
 	return i, err
 }
 
-const getVoteReferencesByProcessID = `-- name: GetVoteReferencesByProcessID :many
+const getVoteReferencesByProcessIDPage = `-- name: GetVoteReferencesByProcessIDPage :many
 SELECT nullifier, process_id, height, weight, tx_index, creation_time, voter_id, overwrite_count FROM vote_references
 WHERE process_id = ?
+	AND (height > ?
+		OR (height = ? AND tx_index > ?))
+ORDER BY height ASC, tx_index ASC
+LIMIT ?
 `
 
-func (q *Queries) GetVoteReferencesByProcessID(ctx context.Context, processID types.ProcessID) ([]VoteReference, error) {
-	rows, err := q.db.QueryContext(ctx, getVoteReferencesByProcessID, processID)
+type GetVoteReferencesByProcessIDPageParams struct {
+	ProcessID    types.ProcessID
+	AfterHeight  int64
+	AfterTxIndex int64
+	Limit        int32
+}
+
+func (q *Queries) GetVoteReferencesByProcessIDPage(ctx context.Context, arg GetVoteReferencesByProcessIDPageParams) ([]VoteReference, error) {
+	rows, err := q.db.QueryContext(ctx, getVoteReferencesByProcessIDPage,
+		arg.ProcessID,
+		arg.AfterHeight,
+		arg.AfterHeight,
+		arg.AfterTxIndex,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,204 @@
+package indexer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	indexerdb "go.vocdoni.io/dvote/vochain/indexer/db"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+// The formats supported by ExportEnvelopes and ExportResults.
+const (
+	ExportFormatCSV   = "csv"
+	ExportFormatJSONL = "jsonl"
+)
+
+// ErrExportFormatUnknown is returned if an export format is not supported.
+var ErrExportFormatUnknown = fmt.Errorf("unknown export format")
+
+// exportWriter writes the rows of an export in one of the supported formats.
+// The CSV header is written along with the first row.
+type exportWriter struct {
+	format string
+	header []string
+	csv    *csv.Writer
+	json   *json.Encoder
+	rows   int
+}
+
+func newExportWriter(w io.Writer, format string, header []string) (*exportWriter, error) {
+	ew := &exportWriter{format: format, header: header}
+	switch format {
+	case ExportFormatCSV:
+		ew.csv = csv.NewWriter(w)
+	case ExportFormatJSONL:
+		ew.json = json.NewEncoder(w)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrExportFormatUnknown, format)
+	}
+	return ew, nil
+}
+
+// write writes a row, given as the item for JSONL and its record for CSV.
+func (ew *exportWriter) write(item any, record func() []string) error {
+	ew.rows++
+	if ew.json != nil {
+		return ew.json.Encode(item)
+	}
+	if ew.rows == 1 {
+		if err := ew.csv.Write(ew.header); err != nil {
+			return err
+		}
+	}
+	return ew.csv.Write(record())
+}
+
+// flush writes any buffered data, including the CSV header if there were no rows.
+func (ew *exportWriter) flush() error {
+	if ew.csv == nil {
+		return nil
+	}
+	if ew.rows == 0 {
+		if err := ew.csv.Write(ew.header); err != nil {
+			return err
+		}
+	}
+	ew.csv.Flush()
+	return ew.csv.Error()
+}
+
+// flushPage writes the rows buffered so far and, if w can be flushed, such as
+// an http.ResponseWriter, flushes it so they are sent to the client.
+func (ew *exportWriter) flushPage(w io.Writer) error {
+	if ew.csv != nil {
+		ew.csv.Flush()
+		if err := ew.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if f, ok := w.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// WalkExportedEnvelopes executes callback for each envelope of the process, in the
+// order they were included in the chain. The votes are decoded when possible, see
+// ExportedEnvelope. The walk stops at the first error returned by callback.
+func (idx *Indexer) WalkExportedEnvelopes(processID []byte,
+	callback func(*indexertypes.ExportedEnvelope) error) error {
+	return idx.walkExportedEnvelopes(processID, callback, nil)
+}
+
+// walkExportedEnvelopes is like WalkExportedEnvelopes, calling pageDone after
+// each page of envelopes, see walkEnvelopes.
+func (idx *Indexer) walkExportedEnvelopes(processID []byte,
+	callback func(*indexertypes.ExportedEnvelope) error, pageDone func() error) error {
+	p, err := idx.ProcessInfo(processID)
+	if err != nil {
+		return err
+	}
+	// The votes can be decoded if not encrypted, or once the keys are revealed.
+	decode := !p.Envelope.EncryptedVotes || len(p.PrivateKeys) > 0
+	var callbackErr error
+	return idx.walkEnvelopes(processID, false,
+		func(ref *indexerdb.VoteReference, vote *models.StateDBVote) {
+			if callbackErr != nil {
+				return
+			}
+			envelope := &indexertypes.ExportedEnvelope{
+				Nullifier:      ref.Nullifier,
+				Height:         uint32(ref.Height),
+				TxIndex:        int32(ref.TxIndex),
+				OverwriteCount: uint32(ref.OverwriteCount),
+				VotePackage:    vote.VotePackage,
+			}
+			envelope.Weight = indexertypes.VoteReferenceFromDB(ref).Weight
+			if len(ref.VoterID) > 0 {
+				envelope.VoterID = ref.VoterID.Address()
+			}
+			if decode {
+				if vp, err := decryptVote(p, vote); err == nil {
+					envelope.Votes = vp.Votes
+				}
+			}
+			callbackErr = callback(envelope)
+		}, func() error {
+			if callbackErr != nil {
+				return callbackErr
+			}
+			if pageDone != nil {
+				return pageDone()
+			}
+			return nil
+		})
+}
+
+// ExportEnvelopes writes all the envelopes of the process to w, in the given format.
+// The envelopes are read and written in pages, and w is flushed after each page if
+// it can be, so the export does not hold them in memory.
+func (idx *Indexer) ExportEnvelopes(w io.Writer, processID []byte, format string) error {
+	ew, err := newExportWriter(w, format, []string{
+		"nullifier", "voterId", "weight", "height", "txIndex", "overwriteCount", "votePackage", "votes",
+	})
+	if err != nil {
+		return err
+	}
+	if err := idx.walkExportedEnvelopes(processID, func(e *indexertypes.ExportedEnvelope) error {
+		return ew.write(e, func() []string {
+			votes := ""
+			if e.Votes != nil {
+				// the JSON encoding of an []int does not fail
+				data, _ := json.Marshal(e.Votes)
+				votes = string(data)
+			}
+			return []string{
+				e.Nullifier.String(),
+				e.VoterID.String(),
+				e.Weight.String(),
+				strconv.FormatUint(uint64(e.Height), 10),
+				strconv.FormatInt(int64(e.TxIndex), 10),
+				strconv.FormatUint(uint64(e.OverwriteCount), 10),
+				e.VotePackage.String(),
+				votes,
+			}
+		})
+	}, func() error {
+		return ew.flushPage(w)
+	}); err != nil {
+		return err
+	}
+	return ew.flush()
+}
+
+// ExportResults writes the results of the process to w in the given format, with
+// one row per option of each question. Returns ErrNoResultsYet if the process has no results.
+func (idx *Indexer) ExportResults(w io.Writer, processID []byte, format string) error {
+	ew, err := newExportWriter(w, format, []string{"question", "option", "value"})
+	if err != nil {
+		return err
+	}
+	results, err := idx.GetResults(processID)
+	if err != nil {
+		return err
+	}
+	if results == nil || len(results.Votes) == 0 {
+		return ErrNoResultsYet
+	}
+	for q, question := range results.Votes {
+		for o, value := range question {
+			r := &indexertypes.ExportedResult{Question: q, Option: o, Value: value}
+			if err := ew.write(r, func() []string {
+				return []string{strconv.Itoa(r.Question), strconv.Itoa(r.Option), r.Value.String()}
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return ew.flush()
+}
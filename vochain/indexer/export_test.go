@@ -0,0 +1,112 @@
+package indexer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+	"go.vocdoni.io/dvote/vochain/state"
+	models "go.vocdoni.io/proto/build/go/models"
+)
+
+func TestExport(t *testing.T) {
+	app := vochain.TestBaseApplication(t)
+	idx := newTestIndexer(t, app, true)
+
+	pid := util.RandomBytes(32)
+	qt.Assert(t, app.State.AddProcess(&models.Process{
+		ProcessId:     pid,
+		EntityId:      util.RandomBytes(20),
+		Status:        models.ProcessStatus_READY,
+		Mode:          &models.ProcessMode{AutoStart: true},
+		BlockCount:    10,
+		VoteOptions:   &models.ProcessVoteOptions{MaxCount: 2, MaxValue: 2},
+		EnvelopeType:  &models.EnvelopeType{},
+		MaxCensusSize: 10,
+	}), qt.IsNil)
+	app.AdvanceTestBlock()
+
+	// three votes, in two blocks
+	vp, err := json.Marshal(vochain.VotePackage{Votes: []int{2, 1}})
+	qt.Assert(t, err, qt.IsNil)
+	var nullifiers [][]byte
+	for i := 0; i < 3; i++ {
+		if i == 2 {
+			app.AdvanceTestBlock()
+		}
+		nullifier := util.RandomBytes(32)
+		qt.Assert(t, app.State.AddVote(&state.Vote{
+			ProcessID:   pid,
+			Nullifier:   nullifier,
+			VotePackage: vp,
+			Weight:      big.NewInt(int64(i + 1)),
+		}), qt.IsNil)
+		nullifiers = append(nullifiers, nullifier)
+	}
+	app.AdvanceTestBlock()
+	idx.WaitIdle()
+
+	var buf bytes.Buffer
+	qt.Assert(t, idx.ExportEnvelopes(&buf, pid, ExportFormatJSONL), qt.IsNil)
+	scanner := bufio.NewScanner(&buf)
+	var envelopes []*indexertypes.ExportedEnvelope
+	for scanner.Scan() {
+		envelope := &indexertypes.ExportedEnvelope{}
+		qt.Assert(t, json.Unmarshal(scanner.Bytes(), envelope), qt.IsNil)
+		envelopes = append(envelopes, envelope)
+	}
+	qt.Assert(t, envelopes, qt.HasLen, 3)
+	qt.Assert(t, []byte(envelopes[2].Nullifier), qt.DeepEquals, nullifiers[2])
+	qt.Assert(t, envelopes[2].Height > envelopes[0].Height, qt.IsTrue)
+	qt.Assert(t, envelopes[2].Weight.String(), qt.Equals, "3")
+	qt.Assert(t, envelopes[0].Votes, qt.DeepEquals, []int{2, 1})
+
+	buf.Reset()
+	qt.Assert(t, idx.ExportEnvelopes(&buf, pid, ExportFormatCSV), qt.IsNil)
+	records, err := csv.NewReader(&buf).ReadAll()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, records, qt.HasLen, 4)
+	qt.Assert(t, records[0][0], qt.Equals, "nullifier")
+	qt.Assert(t, records[1][7], qt.Equals, "[2,1]")
+
+	// the envelopes are read in pages, flushing the writer after each one
+	defer func(size int) { walkEnvelopesPageSize = size }(walkEnvelopesPageSize)
+	walkEnvelopesPageSize = 2
+	fw := &flushWriter{}
+	qt.Assert(t, idx.ExportEnvelopes(fw, pid, ExportFormatCSV), qt.IsNil)
+	qt.Assert(t, fw.flushed, qt.DeepEquals, []int{3, 4})
+	paged, err := csv.NewReader(&fw.buf).ReadAll()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, paged, qt.DeepEquals, records)
+
+	buf.Reset()
+	qt.Assert(t, idx.ExportResults(&buf, pid, ExportFormatCSV), qt.IsNil)
+	records, err = csv.NewReader(&buf).ReadAll()
+	qt.Assert(t, err, qt.IsNil)
+	// the header, plus three options for each of the two questions
+	qt.Assert(t, records, qt.HasLen, 7)
+	qt.Assert(t, records[3], qt.DeepEquals, []string{"0", "2", "6"})
+	qt.Assert(t, records[5], qt.DeepEquals, []string{"1", "1", "6"})
+
+	qt.Assert(t, idx.ExportEnvelopes(&buf, pid, "xml"), qt.ErrorIs, ErrExportFormatUnknown)
+	qt.Assert(t, idx.ExportEnvelopes(&buf, util.RandomBytes(32), ExportFormatCSV), qt.ErrorIs, ErrProcessNotFound)
+}
+
+// flushWriter records the number of lines written each time it is flushed.
+type flushWriter struct {
+	buf     bytes.Buffer
+	flushed []int
+}
+
+func (w *flushWriter) Write(p []byte) (int, error) { return w.buf.Write(p) }
+
+func (w *flushWriter) Flush() {
+	w.flushed = append(w.flushed, bytes.Count(w.buf.Bytes(), []byte("\n")))
+}
@@ -78,6 +78,10 @@ type Indexer struct {
 	newTxPool []*indexertypes.TxReference
 	// tokenTransferPool is the list of token transfers to be indexed
 	tokenTransferPool []*indexertypes.TokenTransferMeta
+	// tokenVestingPool is the list of vesting schedules to be indexed
+	tokenVestingPool []*indexertypes.TokenVestingMeta
+	// blockStats accumulates the stats of the current block
+	blockStats *blockStats
 	// list of live processes (those on which the votes will be computed on arrival)
 	liveResultsProcs sync.Map // TODO: rethink with blockTx
 	// eventOnResults is the list of external callbacks that will be executed by the indexer
@@ -88,6 +92,8 @@ type Indexer struct {
 	recoveryBootLock sync.RWMutex
 	// ignoreLiveResults if true, partial/live results won't be calculated (only final results)
 	ignoreLiveResults bool
+	// archive is used to get the information of the processes not indexed
+	archive ProcessArchive
 
 	liveGoroutines atomic.Int64
 
@@ -102,6 +108,17 @@ type Indexer struct {
 	cancelFunc context.CancelFunc
 }
 
+// ProcessArchive is the storage of the archived processes, which is used as a
+// fallback for the processes that are not indexed.
+type ProcessArchive interface {
+	ProcessInfo(pid []byte) (*indexertypes.Process, error)
+}
+
+// SetArchive sets the process archive used by ProcessInfo as a fallback.
+func (idx *Indexer) SetArchive(archive ProcessArchive) {
+	idx.archive = archive
+}
+
 // VoteWithIndex holds a Vote and a txIndex. Model for the VotePool.
 type VoteWithIndex struct {
 	vote    *state.Vote
@@ -115,6 +132,7 @@ func NewIndexer(dbPath string, app *vochain.BaseApplication, countLiveResults bo
 	s := &Indexer{
 		App:               app,
 		ignoreLiveResults: !countLiveResults,
+		blockStats:        newBlockStats(),
 
 		cancelCtx:  cancelCtx,
 		cancelFunc: cancelFunc,
@@ -161,6 +179,15 @@ func NewIndexer(dbPath string, app *vochain.BaseApplication, countLiveResults bo
 	if err := goose.Up(s.sqlDB, "migrations"); err != nil {
 		return nil, fmt.Errorf("goose up: %w", err)
 	}
+	// index the texts of the metadata stored before the search in the
+	// background, as it can take a while on a large indexer
+	s.liveGoroutines.Add(1)
+	go func() {
+		defer s.liveGoroutines.Add(-1)
+		if err := s.backfillSearchTexts(s.cancelCtx); err != nil {
+			log.Warnw("cannot index the texts of the stored metadata, resuming on the next start", "err", err)
+		}
+	}()
 
 	// Subscribe to events
 	s.App.State.AddEventListener(s)
@@ -382,6 +409,18 @@ func (idx *Indexer) Commit(height uint32) error {
 		}
 	}
 	idx.tokenTransferPool = []*indexertypes.TokenTransferMeta{}
+	// index token vestings, in order since a schedule can be created and
+	// unlocked on the same block
+	for _, v := range idx.tokenVestingPool {
+		if err := idx.setTokenVesting(v); err != nil {
+			log.Errorw(err, "commit: cannot store token vesting")
+		}
+	}
+	idx.tokenVestingPool = []*indexertypes.TokenVestingMeta{}
+
+	// Aggregate the block stats
+	idx.liveGoroutines.Add(1)
+	go idx.commitStats(idx.blockStats, idx.App.TimestampStartBlock())
 
 	// Add votes collected by onVote (live results)
 	newVotes := 0
@@ -390,7 +429,7 @@ func (idx *Indexer) Commit(height uint32) error {
 
 	for pid, votes := range idx.votePool {
 		// Get the process information
-		proc, err := idx.ProcessInfo([]byte(pid))
+		proc, err := idx.indexedProcessInfo([]byte(pid))
 		if err != nil {
 			log.Warnf("cannot get process %x", []byte(pid))
 			continue
@@ -493,6 +532,8 @@ func (idx *Indexer) Rollback() {
 	idx.updateProcessPool = [][]byte{}
 	idx.newTxPool = []*indexertypes.TxReference{}
 	idx.tokenTransferPool = []*indexertypes.TokenTransferMeta{}
+	idx.tokenVestingPool = []*indexertypes.TokenVestingMeta{}
+	idx.blockStats = newBlockStats()
 }
 
 // OnProcess indexer stores the processID and entityID
@@ -502,6 +543,7 @@ func (idx *Indexer) OnProcess(pid, eid []byte, censusRoot, censusURI string, txI
 	if err := idx.newEmptyProcess(pid); err != nil {
 		log.Errorw(err, "commit: cannot create new empty process")
 	}
+	idx.blockStats.addElection()
 	if !idx.App.IsSynchronizing() {
 		idx.addProcessToLiveResults(pid)
 	}
@@ -519,6 +561,7 @@ func (idx *Indexer) OnVote(v *state.Vote, txIndex int32) {
 		idx.votePool[string(v.ProcessID)] = append(idx.votePool[string(v.ProcessID)], v)
 	}
 	idx.voteIndexPool = append(idx.voteIndexPool, &VoteWithIndex{vote: v, txIndex: txIndex})
+	idx.blockStats.addVote(v.ProcessID, v.Overwrites > 0)
 }
 
 // OnCancel indexer stores the processID and entityID
@@ -673,6 +716,7 @@ func (idx *Indexer) OnTransferTokens(tx *vochaintx.TokenTransfer) {
 		TxHash:    tx.TxHash,
 		Timestamp: time.Now(),
 	})
+	idx.blockStats.addTransfer(tx.Amount)
 }
 
 // newTokenTransfer creates a new token transfer and stores it in the database
@@ -19,6 +19,7 @@ import (
 	"go.vocdoni.io/dvote/types"
 	"go.vocdoni.io/dvote/util"
 	"go.vocdoni.io/dvote/vochain"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
 	"go.vocdoni.io/dvote/vochain/results"
 	"go.vocdoni.io/dvote/vochain/state"
 	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
@@ -1387,3 +1388,32 @@ func TestIndexerConcurrentDB(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+// testArchive is a ProcessArchive holding the processes in memory.
+type testArchive map[string]*indexertypes.Process
+
+func (a testArchive) ProcessInfo(pid []byte) (*indexertypes.Process, error) {
+	if p, ok := a[string(pid)]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("not archived")
+}
+
+func TestProcessInfoArchiveFallback(t *testing.T) {
+	app := vochain.TestBaseApplication(t)
+	idx := newTestIndexer(t, app, true)
+
+	pid := util.RandomBytes(32)
+	_, err := idx.ProcessInfo(pid)
+	qt.Assert(t, err, qt.Equals, ErrProcessNotFound)
+
+	archive := testArchive{string(pid): &indexertypes.Process{ID: pid, FinalResults: true}}
+	idx.SetArchive(archive)
+	proc, err := idx.ProcessInfo(pid)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, proc, qt.Equals, archive[string(pid)])
+
+	// the not found error is kept if the process is not archived either
+	_, err = idx.ProcessInfo(util.RandomBytes(32))
+	qt.Assert(t, err, qt.Equals, ErrProcessNotFound)
+}
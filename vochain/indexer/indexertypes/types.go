@@ -1,6 +1,8 @@
 package indexertypes
 
 import (
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -243,6 +245,27 @@ type EnvelopeMetadata struct {
 	TxHash    types.HexBytes `json:"txHash"`
 }
 
+// ExportedEnvelope is a vote of a process as exported in bulk. Votes holds the
+// decoded vote package, if it is not encrypted or the process keys are revealed.
+type ExportedEnvelope struct {
+	Nullifier      types.HexBytes `json:"nullifier"`
+	VoterID        types.HexBytes `json:"voterId,omitempty"`
+	Weight         *types.BigInt  `json:"weight"`
+	Height         uint32         `json:"height"`
+	TxIndex        int32          `json:"txIndex"`
+	OverwriteCount uint32         `json:"overwriteCount"`
+	VotePackage    types.HexBytes `json:"votePackage"`
+	Votes          []int          `json:"votes,omitempty"`
+}
+
+// ExportedResult is the value of an option of a question of the process results,
+// as exported in bulk.
+type ExportedResult struct {
+	Question int           `json:"question"`
+	Option   int           `json:"option"`
+	Value    *types.BigInt `json:"value"`
+}
+
 // EnvelopePackage contains a VoteEnvelope and auxiliary information for the Envelope api
 type EnvelopePackage struct {
 	EncryptionKeyIndexes []uint32         `json:"encryptionKeyIndexes"`
@@ -343,6 +366,123 @@ type TokenTransferMeta struct {
 	To        types.AccountID `json:"to"`
 }
 
+// TokenVestingMeta contains the information of a vesting schedule, created
+// by a vesting transfer, and the amount of its tokens unlocked so far.
+type TokenVestingMeta struct {
+	TxHash      types.Hash      `json:"txHash"`
+	From        types.AccountID `json:"from"`
+	To          types.AccountID `json:"to"`
+	Amount      uint64          `json:"amount"`
+	Released    uint64          `json:"released"`
+	StartHeight uint32          `json:"startHeight"`
+	CliffHeight uint32          `json:"cliffHeight"`
+	EndHeight   uint32          `json:"endHeight"`
+	Created     time.Time       `json:"created"`
+	Updated     time.Time       `json:"updated"`
+}
+
+// MetadataText holds the searchable texts of a metadata document (election or
+// organization) in a single language. Questions contains the question titles,
+// descriptions and choices joined together.
+type MetadataText struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Questions   string `json:"questions"`
+}
+
+// SearchResult is a single match of the full-text metadata search, ordered by Rank.
+// A lower rank means a better match.
+type SearchResult struct {
+	ID       types.HexBytes `json:"id"`
+	Language string         `json:"language"`
+	Rank     float64        `json:"rank"`
+}
+
+// StatsPoint holds the aggregated stats of a time bucket, starting at Time.
+// The meaning of Count depends on the stats; the rest of fields are only
+// set by the stats they apply to.
+type StatsPoint struct {
+	Time       time.Time         `json:"time"`
+	Count      uint64            `json:"count"`
+	Overwrites uint64            `json:"overwrites,omitempty"`
+	Volume     uint64            `json:"volume,omitempty"`
+	ByType     map[string]uint64 `json:"byType,omitempty"`
+}
+
+// TurnoutPoint holds the votes of an election on a time bucket, starting at Time,
+// and the accumulated turnout (as a percentage of the census size) at its end.
+type TurnoutPoint struct {
+	Time            time.Time `json:"time"`
+	Votes           uint64    `json:"votes"`
+	CumulativeVotes uint64    `json:"cumulativeVotes"`
+	Turnout         float64   `json:"turnout"`
+}
+
+// ListCursor is the position of an item within a list, used for cursor based
+// (keyset) pagination. The lists are ordered by (Key, Index), which is unique
+// for each item; lists ordered by a single key leave Index to zero.
+// Reverse means that the items before the position are requested instead of
+// the ones after it.
+//
+// Cursors are meant to be opaque to the API users, so they are encoded as
+// URL-safe strings via MarshalText.
+type ListCursor struct {
+	Key     int64
+	Index   int64
+	Reverse bool
+}
+
+// String returns the opaque encoding of the cursor.
+func (c *ListCursor) String() string {
+	buf := binary.AppendVarint(nil, c.Key)
+	buf = binary.AppendVarint(buf, c.Index)
+	if c.Reverse {
+		buf = append(buf, 1)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c *ListCursor) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *ListCursor) UnmarshalText(text []byte) error {
+	buf, err := base64.RawURLEncoding.DecodeString(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid cursor: %w", err)
+	}
+	var n int
+	if c.Key, n = binary.Varint(buf); n <= 0 {
+		return fmt.Errorf("invalid cursor key")
+	}
+	buf = buf[n:]
+	if c.Index, n = binary.Varint(buf); n <= 0 {
+		return fmt.Errorf("invalid cursor index")
+	}
+	buf = buf[n:]
+	switch {
+	case len(buf) == 0:
+		c.Reverse = false
+	case len(buf) == 1 && buf[0] == 1:
+		c.Reverse = true
+	default:
+		return fmt.Errorf("invalid cursor direction")
+	}
+	return nil
+}
+
+// ListPage holds the pagination details of a page of a list.
+// Next and Prev are the cursors to fetch the following and previous pages,
+// or nil if there are no more items in that direction.
+// Total is the number of items of the whole list, only set in the first page.
+type ListPage struct {
+	Next  *ListCursor `json:"next,omitempty"`
+	Prev  *ListCursor `json:"prev,omitempty"`
+	Total uint64      `json:"total,omitempty"`
+}
+
 // ________________________ CALLBACKS DATA STRUCTS ________________________
 
 // IndexerOnProcessData holds the required data for callbacks when
@@ -351,3 +491,15 @@ type IndexerOnProcessData struct {
 	EntityID  []byte
 	ProcessID []byte
 }
+
+// Metadata holds an election or account metadata downloaded from URI, and
+// the result of validating it against the JSON schema of its Version. If
+// valid, Data holds the normalised metadata; otherwise Errors holds the
+// validation errors, and Data the metadata as downloaded if it is JSON.
+type Metadata struct {
+	URI     string          `json:"uri"`
+	Version string          `json:"version,omitempty"`
+	Valid   bool            `json:"valid"`
+	Errors  []string        `json:"errors,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
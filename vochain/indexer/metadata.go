@@ -0,0 +1,85 @@
+package indexer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+)
+
+// ErrMetadataNotFound is returned if there is no metadata stored for the
+// election or account.
+var ErrMetadataNotFound = fmt.Errorf("metadata not found")
+
+// SetElectionMetadata stores the downloaded metadata of an election, replacing
+// any metadata previously stored for it.
+func (idx *Indexer) SetElectionMetadata(electionID []byte, metadata *indexertypes.Metadata) error {
+	return idx.setMetadata(searchKindElection, electionID, metadata)
+}
+
+// SetAccountMetadata stores the downloaded metadata of an account, replacing
+// any metadata previously stored for it.
+func (idx *Indexer) SetAccountMetadata(address []byte, metadata *indexertypes.Metadata) error {
+	return idx.setMetadata(searchKindAccount, address, metadata)
+}
+
+// ElectionMetadata returns the metadata stored for the election, or
+// ErrMetadataNotFound if it has not been downloaded yet.
+func (idx *Indexer) ElectionMetadata(electionID []byte) (*indexertypes.Metadata, error) {
+	return idx.metadata(searchKindElection, electionID)
+}
+
+// AccountMetadata returns the metadata stored for the account, or
+// ErrMetadataNotFound if it has not been downloaded yet.
+func (idx *Indexer) AccountMetadata(address []byte) (*indexertypes.Metadata, error) {
+	return idx.metadata(searchKindAccount, address)
+}
+
+func (idx *Indexer) setMetadata(kind string, id []byte, metadata *indexertypes.Metadata) error {
+	errs := metadata.Errors
+	if errs == nil {
+		errs = []string{}
+	}
+	errsJSON, err := json.Marshal(errs)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := idx.sqlDB.ExecContext(ctx, `REPLACE INTO metadata
+(kind, id, uri, version, valid, errors, metadata) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		kind, id, metadata.URI, metadata.Version, metadata.Valid, string(errsJSON), string(metadata.Data),
+	); err != nil {
+		return fmt.Errorf("cannot store metadata: %w", err)
+	}
+	return nil
+}
+
+func (idx *Indexer) metadata(kind string, id []byte) (*indexertypes.Metadata, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	metadata := &indexertypes.Metadata{}
+	var errsJSON, data string
+	if err := idx.sqlDB.QueryRowContext(ctx,
+		"SELECT uri, version, valid, errors, metadata FROM metadata WHERE kind = ? AND id = ?", kind, id,
+	).Scan(&metadata.URI, &metadata.Version, &metadata.Valid, &errsJSON, &data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMetadataNotFound
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(errsJSON), &metadata.Errors); err != nil {
+		return nil, fmt.Errorf("cannot decode metadata errors: %w", err)
+	}
+	if len(metadata.Errors) == 0 {
+		metadata.Errors = nil
+	}
+	if data != "" {
+		metadata.Data = json.RawMessage(data)
+	}
+	return metadata, nil
+}
@@ -0,0 +1,43 @@
+package indexer
+
+import (
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+)
+
+func TestMetadata(t *testing.T) {
+	app := vochain.TestBaseApplication(t)
+	idx := newTestIndexer(t, app, true)
+
+	pid := util.RandomBytes(32)
+	_, err := idx.ElectionMetadata(pid)
+	qt.Assert(t, err, qt.Equals, ErrMetadataNotFound)
+
+	invalid := &indexertypes.Metadata{
+		URI:    "ipfs://invalid",
+		Errors: []string{`$: missing required property "title"`},
+	}
+	qt.Assert(t, idx.SetElectionMetadata(pid, invalid), qt.IsNil)
+	metadata, err := idx.ElectionMetadata(pid)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, metadata, qt.DeepEquals, invalid)
+
+	// the metadata is replaced, and kept apart from the account metadata
+	valid := &indexertypes.Metadata{
+		URI:     "ipfs://valid",
+		Version: "1.0",
+		Valid:   true,
+		Data:    json.RawMessage(`{"title":{"default":"election"}}`),
+	}
+	qt.Assert(t, idx.SetElectionMetadata(pid, valid), qt.IsNil)
+	metadata, err = idx.ElectionMetadata(pid)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, metadata, qt.DeepEquals, valid)
+	_, err = idx.AccountMetadata(pid)
+	qt.Assert(t, err, qt.Equals, ErrMetadataNotFound)
+}
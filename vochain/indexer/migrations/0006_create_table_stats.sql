@@ -0,0 +1,90 @@
+-- +goose Up
+-- The stats_* tables hold aggregates over hourly buckets.
+-- A bucket is the unix timestamp (in seconds) of the start of the hour.
+
+CREATE TABLE stats_votes (
+  process_id BLOB NOT NULL,
+  bucket     INTEGER NOT NULL,
+  votes      INTEGER NOT NULL, -- new votes, not counting overwrites
+  overwrites INTEGER NOT NULL,
+  PRIMARY KEY (process_id, bucket)
+);
+
+CREATE INDEX index_stats_votes_bucket
+ON stats_votes(bucket);
+
+CREATE TABLE stats_elections (
+  bucket    INTEGER NOT NULL PRIMARY KEY,
+  elections INTEGER NOT NULL
+);
+
+CREATE TABLE stats_transactions (
+  bucket       INTEGER NOT NULL,
+  tx_type      TEXT NOT NULL,
+  transactions INTEGER NOT NULL,
+  PRIMARY KEY (bucket, tx_type)
+);
+
+CREATE TABLE stats_token_transfers (
+  bucket    INTEGER NOT NULL PRIMARY KEY,
+  transfers INTEGER NOT NULL,
+  volume    INTEGER NOT NULL
+);
+
+-- Accounts which signed at least one transaction during the bucket.
+CREATE TABLE stats_accounts (
+  bucket  INTEGER NOT NULL,
+  account BLOB NOT NULL,
+  PRIMARY KEY (bucket, account)
+);
+
+-- Pending backfills which cannot be done in SQL, such as the transactions,
+-- since tx_references does not hold the block time.
+-- Rows up to and including last_height are pending to be aggregated.
+CREATE TABLE stats_backfill (
+  name        TEXT NOT NULL PRIMARY KEY,
+  next_height INTEGER NOT NULL,
+  last_height INTEGER NOT NULL
+);
+
+-- Backfill the aggregates from the rows indexed before this migration.
+-- Vote references are replaced on overwrites, so the existing votes are
+-- bucketed by the time of their last overwrite.
+INSERT INTO stats_votes (process_id, bucket, votes, overwrites)
+SELECT process_id, CAST(strftime('%s', creation_time) AS INTEGER) / 3600 * 3600, COUNT(*), SUM(overwrite_count)
+FROM vote_references
+GROUP BY 1, 2;
+
+INSERT INTO stats_elections (bucket, elections)
+SELECT CAST(strftime('%s', creation_time) AS INTEGER) / 3600 * 3600, COUNT(*)
+FROM processes
+GROUP BY 1;
+
+INSERT INTO stats_token_transfers (bucket, transfers, volume)
+SELECT CAST(strftime('%s', transfer_time) AS INTEGER) / 3600 * 3600, COUNT(*), SUM(amount)
+FROM token_transfers
+GROUP BY 1;
+
+INSERT INTO stats_accounts (bucket, account)
+SELECT DISTINCT CAST(strftime('%s', transfer_time) AS INTEGER) / 3600 * 3600, from_account
+FROM token_transfers;
+
+INSERT INTO stats_backfill (name, next_height, last_height)
+SELECT 'transactions', MIN(block_height), MAX(block_height)
+FROM tx_references
+HAVING COUNT(*) > 0;
+
+-- +goose Down
+DROP TABLE stats_backfill
+
+DROP TABLE stats_accounts
+
+DROP TABLE stats_token_transfers
+
+DROP TABLE stats_transactions
+
+DROP TABLE stats_elections
+
+DROP INDEX index_stats_votes_bucket
+
+DROP TABLE stats_votes
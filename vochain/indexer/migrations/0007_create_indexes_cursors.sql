@@ -0,0 +1,12 @@
+-- +goose Up
+-- Indexes for the lists paginated with cursors, see cursor.go.
+CREATE INDEX index_vote_references_process_height
+ON vote_references(process_id, height, tx_index);
+
+CREATE INDEX index_token_transfers_from_height
+ON token_transfers(from_account, height);
+
+-- +goose Down
+DROP INDEX index_token_transfers_from_height
+
+DROP INDEX index_vote_references_process_height
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Election and account metadata downloaded from the offchain storage, with
+-- the result of validating it against its JSON schema. If valid, metadata
+-- holds the normalised document, otherwise errors holds the list of errors.
+CREATE TABLE metadata (
+  kind     TEXT NOT NULL, -- election or account
+  id       BLOB NOT NULL, -- election id or account address
+  uri      TEXT NOT NULL,
+  version  TEXT NOT NULL DEFAULT '',
+  valid    BOOLEAN NOT NULL,
+  errors   TEXT NOT NULL DEFAULT '[]', -- JSON list of strings
+  metadata TEXT NOT NULL DEFAULT '', -- JSON document
+  PRIMARY KEY (kind, id)
+);
+
+-- +goose Down
+DROP TABLE metadata
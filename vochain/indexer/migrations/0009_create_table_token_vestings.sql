@@ -0,0 +1,24 @@
+-- +goose Up
+-- Vesting schedules created by vesting transfers, whose released amount is
+-- updated each time their vested tokens are unlocked.
+CREATE TABLE token_vestings (
+  tx_hash      BLOB NOT NULL PRIMARY KEY,
+  from_account BLOB NOT NULL,
+  to_account   BLOB NOT NULL,
+  amount       INTEGER NOT NULL,
+  released     INTEGER NOT NULL,
+  start_height INTEGER NOT NULL,
+  cliff_height INTEGER NOT NULL,
+  end_height   INTEGER NOT NULL,
+  created_time DATETIME NOT NULL,
+  updated_time DATETIME NOT NULL
+);
+
+CREATE INDEX index_to_account_token_vestings
+ON token_vestings(to_account);
+
+CREATE INDEX index_from_account_token_vestings
+ON token_vestings(from_account);
+
+-- +goose Down
+DROP TABLE token_vestings
@@ -0,0 +1,60 @@
+-- +goose Up
+-- Searchable texts of the election and account metadata, one row per document
+-- and language.
+CREATE TABLE metadata_texts (
+  docid       INTEGER NOT NULL PRIMARY KEY,
+  kind        TEXT NOT NULL, -- election or account
+  id          TEXT NOT NULL, -- hex encoded election id or account address
+  language    TEXT NOT NULL,
+  title       TEXT NOT NULL DEFAULT '',
+  description TEXT NOT NULL DEFAULT '',
+  questions   TEXT NOT NULL DEFAULT '',
+  UNIQUE (kind, id, language)
+);
+
+-- Full-text index of the metadata texts. FTS4 is used since, unlike FTS5, it
+-- is always built into SQLite by mattn/go-sqlite3. The index has no copy of
+-- the texts, it reads them from metadata_texts, and the triggers below keep
+-- it in sync with it. Its docid is the explicit one of metadata_texts, since
+-- an implicit rowid may be renumbered by VACUUM.
+CREATE VIRTUAL TABLE metadata_search USING fts4(
+  content="metadata_texts",
+  title,
+  description,
+  questions,
+  tokenize=unicode61 "remove_diacritics=2"
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER metadata_texts_before_delete BEFORE DELETE ON metadata_texts BEGIN
+  DELETE FROM metadata_search WHERE docid = old.docid;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER metadata_texts_before_update BEFORE UPDATE ON metadata_texts BEGIN
+  DELETE FROM metadata_search WHERE docid = old.docid;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER metadata_texts_after_update AFTER UPDATE ON metadata_texts BEGIN
+  INSERT INTO metadata_search (docid, title, description, questions)
+  VALUES (new.docid, new.title, new.description, new.questions);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER metadata_texts_after_insert AFTER INSERT ON metadata_texts BEGIN
+  INSERT INTO metadata_search (docid, title, description, questions)
+  VALUES (new.docid, new.title, new.description, new.questions);
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER metadata_texts_after_insert;
+DROP TRIGGER metadata_texts_after_update;
+DROP TRIGGER metadata_texts_before_update;
+DROP TRIGGER metadata_texts_before_delete;
+DROP TABLE metadata_search;
+DROP TABLE metadata_texts
@@ -0,0 +1,196 @@
+-- +goose Up
+-- The lists paginated with cursors are ordered by the insertion order of the
+-- processes and token transfers, see cursor.go. The implicit rowid may be
+-- renumbered by VACUUM, such as the one done by the backups, since the tables
+-- have no INTEGER PRIMARY KEY, so the tables are recreated with an explicit
+-- seq column holding the insertion order. The existing rows keep their rowid
+-- as seq, preserving their order, and the new rows are given the next seq on
+-- insert. The primary keys are kept.
+CREATE TABLE processes_seq (
+  id           BLOB NOT NULL PRIMARY KEY,
+  entity_id    BLOB NOT NULL,
+  start_block  INTEGER NOT NULL,
+  end_block    INTEGER NOT NULL,
+
+  results_height          INTEGER NOT NULL,
+  have_results            BOOLEAN NOT NULL,
+  final_results           BOOLEAN NOT NULL,
+  results_votes           TEXT NOT NULL,
+  results_weight          TEXT NOT NULL,
+  results_envelope_height INTEGER NOT NULL,
+  results_signatures      TEXT NOT NULL,
+  results_block_height    INTEGER NOT NULL,
+
+  census_root         BLOB NOT NULL,
+  rolling_census_root BLOB NOT NULL,
+  rolling_census_size INTEGER NOT NULL,
+  max_census_size     INTEGER NOT NULL,
+  census_uri          TEXT NOT NULL,
+  metadata            TEXT NOT NULL,
+  census_origin       INTEGER NOT NULL,
+  status              INTEGER NOT NULL,
+  namespace           INTEGER NOT NULL,
+
+  envelope_pb  BLOB NOT NULL,
+  mode_pb      BLOB NOT NULL,
+  vote_opts_pb BLOB NOT NULL,
+
+  private_keys TEXT NOT NULL, -- comma-separated list of hex keys
+  public_keys  TEXT NOT NULL, -- comma-separated list of hex keys
+
+  question_index      INTEGER NOT NULL,
+  creation_time       DATETIME NOT NULL,
+  source_block_height INTEGER NOT NULL,
+  source_network_id   INTEGER NOT NULL,
+
+  seq INTEGER NOT NULL UNIQUE
+);
+
+INSERT INTO processes_seq (
+  id, entity_id, start_block, end_block, results_height, have_results,
+  final_results, results_votes, results_weight, results_envelope_height,
+  results_signatures, results_block_height, census_root, rolling_census_root,
+  rolling_census_size, max_census_size, census_uri, metadata, census_origin,
+  status, namespace, envelope_pb, mode_pb, vote_opts_pb, private_keys,
+  public_keys, question_index, creation_time, source_block_height,
+  source_network_id,
+  seq)
+SELECT
+  id, entity_id, start_block, end_block, results_height, have_results,
+  final_results, results_votes, results_weight, results_envelope_height,
+  results_signatures, results_block_height, census_root, rolling_census_root,
+  rolling_census_size, max_census_size, census_uri, metadata, census_origin,
+  status, namespace, envelope_pb, mode_pb, vote_opts_pb, private_keys,
+  public_keys, question_index, creation_time, source_block_height,
+  source_network_id,
+  rowid
+FROM processes;
+
+DROP TABLE processes;
+
+ALTER TABLE processes_seq RENAME TO processes;
+
+CREATE INDEX index_processes_entity_id
+ON processes(entity_id, seq);
+
+CREATE INDEX index_processes_namespace
+ON processes(namespace);
+
+CREATE TABLE token_transfers_seq (
+  tx_hash BLOB NOT NULL PRIMARY KEY,
+  height INTEGER NOT NULL,
+  from_account BLOB NOT NULL,
+  to_account BLOB NOT NULL,
+  amount INTEGER NOT NULL,
+  transfer_time DATETIME NOT NULL,
+  seq INTEGER NOT NULL UNIQUE
+);
+
+INSERT INTO token_transfers_seq (
+  tx_hash, height, from_account, to_account, amount, transfer_time, seq)
+SELECT
+  tx_hash, height, from_account, to_account, amount, transfer_time, rowid
+FROM token_transfers;
+
+DROP TABLE token_transfers;
+
+ALTER TABLE token_transfers_seq RENAME TO token_transfers;
+
+CREATE INDEX index_from_account_token_transfers
+ON token_transfers(from_account);
+
+CREATE INDEX index_token_transfers_from_height
+ON token_transfers(from_account, height, seq);
+
+-- +goose Down
+-- The tables are recreated without the seq column, which is kept as rowid.
+CREATE TABLE processes_rowid (
+  id           BLOB NOT NULL PRIMARY KEY,
+  entity_id    BLOB NOT NULL,
+  start_block  INTEGER NOT NULL,
+  end_block    INTEGER NOT NULL,
+
+  results_height          INTEGER NOT NULL,
+  have_results            BOOLEAN NOT NULL,
+  final_results           BOOLEAN NOT NULL,
+  results_votes           TEXT NOT NULL,
+  results_weight          TEXT NOT NULL,
+  results_envelope_height INTEGER NOT NULL,
+  results_signatures      TEXT NOT NULL,
+  results_block_height    INTEGER NOT NULL,
+
+  census_root         BLOB NOT NULL,
+  rolling_census_root BLOB NOT NULL,
+  rolling_census_size INTEGER NOT NULL,
+  max_census_size     INTEGER NOT NULL,
+  census_uri          TEXT NOT NULL,
+  metadata            TEXT NOT NULL,
+  census_origin       INTEGER NOT NULL,
+  status              INTEGER NOT NULL,
+  namespace           INTEGER NOT NULL,
+
+  envelope_pb  BLOB NOT NULL,
+  mode_pb      BLOB NOT NULL,
+  vote_opts_pb BLOB NOT NULL,
+
+  private_keys TEXT NOT NULL, -- comma-separated list of hex keys
+  public_keys  TEXT NOT NULL, -- comma-separated list of hex keys
+
+  question_index      INTEGER NOT NULL,
+  creation_time       DATETIME NOT NULL,
+  source_block_height INTEGER NOT NULL,
+  source_network_id   INTEGER NOT NULL
+);
+
+INSERT INTO processes_rowid (rowid,
+  id, entity_id, start_block, end_block, results_height, have_results,
+  final_results, results_votes, results_weight, results_envelope_height,
+  results_signatures, results_block_height, census_root, rolling_census_root,
+  rolling_census_size, max_census_size, census_uri, metadata, census_origin,
+  status, namespace, envelope_pb, mode_pb, vote_opts_pb, private_keys,
+  public_keys, question_index, creation_time, source_block_height,
+  source_network_id)
+SELECT seq,
+  id, entity_id, start_block, end_block, results_height, have_results,
+  final_results, results_votes, results_weight, results_envelope_height,
+  results_signatures, results_block_height, census_root, rolling_census_root,
+  rolling_census_size, max_census_size, census_uri, metadata, census_origin,
+  status, namespace, envelope_pb, mode_pb, vote_opts_pb, private_keys,
+  public_keys, question_index, creation_time, source_block_height,
+  source_network_id
+FROM processes;
+
+DROP TABLE processes;
+
+ALTER TABLE processes_rowid RENAME TO processes;
+
+CREATE INDEX index_processes_entity_id
+ON processes(entity_id);
+
+CREATE INDEX index_processes_namespace
+ON processes(namespace);
+
+CREATE TABLE token_transfers_rowid (
+  tx_hash BLOB NOT NULL PRIMARY KEY,
+  height INTEGER NOT NULL,
+  from_account BLOB NOT NULL,
+  to_account BLOB NOT NULL,
+  amount INTEGER NOT NULL,
+  transfer_time DATETIME NOT NULL
+);
+
+INSERT INTO token_transfers_rowid (rowid,
+  tx_hash, height, from_account, to_account, amount, transfer_time)
+SELECT seq,
+  tx_hash, height, from_account, to_account, amount, transfer_time
+FROM token_transfers;
+
+DROP TABLE token_transfers;
+
+ALTER TABLE token_transfers_rowid RENAME TO token_transfers;
+
+CREATE INDEX index_from_account_token_transfers
+ON token_transfers(from_account);
+
+CREATE INDEX index_token_transfers_from_height
+ON token_transfers(from_account, height);
@@ -64,8 +64,22 @@ func encodeVotes(votes [][]*types.BigInt) string {
 	return b.String()
 }
 
-// ProcessInfo returns the available information regarding an election process id
+// ProcessInfo returns the available information regarding an election process id.
+// If the process is not indexed (i.e. it was pruned or the indexer was rebuilt)
+// and an archive is set, the archived process information is returned.
 func (s *Indexer) ProcessInfo(pid []byte) (*indexertypes.Process, error) {
+	proc, err := s.indexedProcessInfo(pid)
+	if errors.Is(err, ErrProcessNotFound) && s.archive != nil {
+		if proc, err := s.archive.ProcessInfo(pid); err == nil {
+			return proc, nil
+		}
+	}
+	return proc, err
+}
+
+// indexedProcessInfo returns the information of the process from the indexer
+// database, without falling back to the archive.
+func (s *Indexer) indexedProcessInfo(pid []byte) (*indexertypes.Process, error) {
 	startTime := time.Now()
 
 	queries, ctx, cancel := s.timeoutQueries()
@@ -11,7 +11,9 @@ INSERT INTO processes (
 	source_block_height, source_network_id,
 
 	results_votes, results_weight, results_envelope_height,
-	results_signatures, results_block_height
+	results_signatures, results_block_height,
+
+	seq
 ) VALUES (
 	?, ?, ?, ?,
 	?, ?, ?,
@@ -24,7 +26,9 @@ INSERT INTO processes (
 	?, ?,
 
 	?, '0', 0,
-	'', 0
+	'', 0,
+
+	(SELECT IFNULL(MAX(seq), 0) + 1 FROM processes)
 );
 
 -- name: GetProcess :one
@@ -1,8 +1,8 @@
 -- name: CreateTokenTransfer :execresult
 INSERT INTO token_transfers (
-	tx_hash, height, from_account, to_account, amount, transfer_time
+	tx_hash, height, from_account, to_account, amount, transfer_time, seq
 ) VALUES (
-	?, ?, ?, ?, ?, ?
+	?, ?, ?, ?, ?, ?, (SELECT IFNULL(MAX(seq), 0) + 1 FROM token_transfers)
 );
 
 -- name: GetTokenTransfer :one
@@ -12,9 +12,13 @@ SELECT * FROM vote_references
 WHERE nullifier = ?
 LIMIT 1;
 
--- name: GetVoteReferencesByProcessID :many
+-- name: GetVoteReferencesByProcessIDPage :many
 SELECT * FROM vote_references
-WHERE process_id = ?;
+WHERE process_id = sqlc.arg(process_id)
+	AND (height > sqlc.arg(after_height)
+		OR (height = sqlc.arg(after_height) AND tx_index > sqlc.arg(after_tx_index)))
+ORDER BY height ASC, tx_index ASC
+LIMIT ?;
 
 -- name: SearchVoteReferences :many
 SELECT * FROM vote_references
@@ -0,0 +1,363 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+)
+
+const (
+	searchKindElection = "election"
+	searchKindAccount  = "account"
+
+	// searchBackfillBatch is the number of stored metadata documents read at
+	// once when indexing the texts of the metadata stored before the search.
+	searchBackfillBatch = 100
+)
+
+// searchColumnWeights weighs the matches on the title higher than the matches
+// on the description, and those higher than the matches on the questions.
+// They follow the order of the metadata_search columns.
+var searchColumnWeights = []float64{10, 4, 1}
+
+// backfillSearchTexts indexes the texts of the valid metadata stored before
+// the metadata search was available, so it can be searched too. The documents
+// are read in batches, and each one is indexed in its own transaction, so if
+// it is interrupted it resumes from the documents not indexed yet.
+func (idx *Indexer) backfillSearchTexts(ctx context.Context) error {
+	lastKind, lastID, indexed := "", []byte{}, 0
+	for {
+		rows, err := idx.sqlDB.QueryContext(ctx, `SELECT m.kind, m.id, m.metadata FROM metadata AS m
+WHERE m.valid AND (m.kind, m.id) > (?, ?) AND NOT EXISTS (
+  SELECT 1 FROM metadata_texts AS t WHERE t.kind = m.kind AND t.id = lower(hex(m.id))
+)
+ORDER BY m.kind, m.id LIMIT ?`, lastKind, lastID, searchBackfillBatch)
+		if err != nil {
+			return err
+		}
+		type document struct {
+			kind string
+			id   []byte
+			data string
+		}
+		var documents []document
+		for rows.Next() {
+			var d document
+			if err := rows.Scan(&d.kind, &d.id, &d.data); err != nil {
+				rows.Close()
+				return err
+			}
+			documents = append(documents, d)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, d := range documents {
+			var texts map[string]*indexertypes.MetadataText
+			var err error
+			switch d.kind {
+			case searchKindElection:
+				texts, err = ElectionMetadataTexts([]byte(d.data))
+			case searchKindAccount:
+				texts, err = AccountMetadataTexts([]byte(d.data))
+			default:
+				continue
+			}
+			if err != nil {
+				log.Warnw("cannot decode stored metadata", "kind", d.kind, "id", hex.EncodeToString(d.id), "err", err)
+				continue
+			}
+			// the texts indexed meanwhile come from newer metadata, so keep them
+			if err := idx.indexMetadata(ctx, d.kind, d.id, texts, false); err != nil {
+				return err
+			}
+			indexed++
+		}
+		if len(documents) < searchBackfillBatch {
+			break
+		}
+		lastKind, lastID = documents[len(documents)-1].kind, documents[len(documents)-1].id
+	}
+	if indexed > 0 {
+		log.Infow("indexed the texts of the stored metadata", "documents", indexed)
+	}
+	return nil
+}
+
+// searchElectionTexts holds the searchable fields of an election metadata.
+type searchElectionTexts struct {
+	Title       map[string]string `json:"title"`
+	Description map[string]string `json:"description"`
+	Questions   []struct {
+		Title       map[string]string `json:"title"`
+		Description map[string]string `json:"description"`
+		Choices     []struct {
+			Title map[string]string `json:"title"`
+		} `json:"choices"`
+	} `json:"questions"`
+}
+
+// searchAccountTexts holds the searchable fields of an account metadata.
+type searchAccountTexts struct {
+	Name        map[string]string `json:"name"`
+	Description map[string]string `json:"description"`
+}
+
+// ElectionMetadataTexts decodes the JSON election metadata and groups its title,
+// description and question texts by language.
+func ElectionMetadataTexts(data []byte) (map[string]*indexertypes.MetadataText, error) {
+	m := &searchElectionTexts{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	texts := make(map[string]*indexertypes.MetadataText)
+	langText := func(lang string) *indexertypes.MetadataText {
+		if texts[lang] == nil {
+			texts[lang] = &indexertypes.MetadataText{}
+		}
+		return texts[lang]
+	}
+	for lang, s := range m.Title {
+		langText(lang).Title = s
+	}
+	for lang, s := range m.Description {
+		langText(lang).Description = s
+	}
+	questions := make(map[string][]string)
+	for _, q := range m.Questions {
+		for lang, s := range q.Title {
+			questions[lang] = append(questions[lang], s)
+		}
+		for lang, s := range q.Description {
+			questions[lang] = append(questions[lang], s)
+		}
+		for _, c := range q.Choices {
+			for lang, s := range c.Title {
+				questions[lang] = append(questions[lang], s)
+			}
+		}
+	}
+	for lang, q := range questions {
+		langText(lang).Questions = strings.Join(q, "\n")
+	}
+	return texts, nil
+}
+
+// AccountMetadataTexts decodes the JSON account metadata and groups its name
+// and description by language.
+func AccountMetadataTexts(data []byte) (map[string]*indexertypes.MetadataText, error) {
+	m := &searchAccountTexts{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	texts := make(map[string]*indexertypes.MetadataText)
+	for lang, s := range m.Name {
+		texts[lang] = &indexertypes.MetadataText{Title: s}
+	}
+	for lang, s := range m.Description {
+		if texts[lang] == nil {
+			texts[lang] = &indexertypes.MetadataText{}
+		}
+		texts[lang].Description = s
+	}
+	return texts, nil
+}
+
+// IndexElectionMetadata stores the searchable texts of an election metadata,
+// indexed by language. Any texts previously stored for the election are replaced.
+func (idx *Indexer) IndexElectionMetadata(electionID []byte, texts map[string]*indexertypes.MetadataText) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	return idx.indexMetadata(ctx, searchKindElection, electionID, texts, true)
+}
+
+// IndexAccountMetadata stores the searchable texts of an account (organization)
+// metadata, indexed by language. Any texts previously stored for the account are replaced.
+func (idx *Indexer) IndexAccountMetadata(address []byte, texts map[string]*indexertypes.MetadataText) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	return idx.indexMetadata(ctx, searchKindAccount, address, texts, true)
+}
+
+// indexMetadata stores the texts of a document. If replace is false and the
+// document already has texts, they are kept.
+func (idx *Indexer) indexMetadata(ctx context.Context, kind string, id []byte,
+	texts map[string]*indexertypes.MetadataText, replace bool,
+) error {
+	tx, err := idx.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollbackTx(tx)
+	hexID := hex.EncodeToString(id)
+	if !replace {
+		var exists bool
+		if err := tx.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM metadata_texts WHERE kind = ? AND id = ?)", kind, hexID,
+		).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+	}
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM metadata_texts WHERE kind = ? AND id = ?", kind, hexID); err != nil {
+		return fmt.Errorf("cannot delete previous metadata texts: %w", err)
+	}
+	for lang, text := range texts {
+		if text == nil {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO metadata_texts (kind, id, language, title, description, questions) VALUES (?, ?, ?, ?, ?, ?)",
+			kind, hexID, lang, text.Title, text.Description, text.Questions); err != nil {
+			return fmt.Errorf("cannot insert metadata texts: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// SearchElections performs a full-text search over the indexed election metadata
+// (titles, descriptions and questions). If language is not empty, only the texts
+// in that language are considered. The results are ordered by relevance and
+// paginated by from and max.
+func (idx *Indexer) SearchElections(query, language string, from, max int) ([]*indexertypes.SearchResult, error) {
+	return idx.searchMetadata(searchKindElection, query, language, from, max)
+}
+
+// SearchAccounts performs a full-text search over the indexed account (organization)
+// metadata. See SearchElections.
+func (idx *Indexer) SearchAccounts(query, language string, from, max int) ([]*indexertypes.SearchResult, error) {
+	return idx.searchMetadata(searchKindAccount, query, language, from, max)
+}
+
+func (idx *Indexer) searchMetadata(kind, query, language string, from, max int) ([]*indexertypes.SearchResult, error) {
+	if from < 0 {
+		return nil, fmt.Errorf("searchMetadata: invalid value: from is invalid value %d", from)
+	}
+	words := searchWords(query)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("searchMetadata: empty search query")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// FTS4 has no ranking function, so the matches are ranked here from their
+	// matchinfo, see searchRank.
+	rows, err := idx.sqlDB.QueryContext(ctx, `SELECT t.id, t.language, matchinfo(metadata_search, 'pcx')
+FROM metadata_search JOIN metadata_texts AS t ON t.docid = metadata_search.docid
+WHERE metadata_search MATCH ? AND t.kind = ? AND (? = '' OR t.language = ?)`,
+		searchMatchExpression(words), kind, language, language)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	// a document can match in several languages, so only keep its best ranked one
+	best := make(map[string]*indexertypes.SearchResult)
+	for rows.Next() {
+		var hexID, lang string
+		var matchinfo []byte
+		if err := rows.Scan(&hexID, &lang, &matchinfo); err != nil {
+			return nil, err
+		}
+		rank := searchRank(matchinfo)
+		if r, ok := best[hexID]; ok && r.Rank <= rank {
+			continue
+		}
+		id, err := hex.DecodeString(hexID)
+		if err != nil {
+			return nil, err
+		}
+		best[hexID] = &indexertypes.SearchResult{ID: id, Language: lang, Rank: rank}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	results := make([]*indexertypes.SearchResult, 0, len(best))
+	for _, r := range best {
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank < results[j].Rank
+		}
+		return bytes.Compare(results[i].ID, results[j].ID) < 0
+	})
+	if from >= len(results) {
+		return []*indexertypes.SearchResult{}, nil
+	}
+	if to := from + max; to < len(results) {
+		return results[from:to], nil
+	}
+	return results[from:], nil
+}
+
+// searchRank ranks a match from its FTS4 matchinfo with the 'pcx' format,
+// following the example ranking function of the SQLite FTS4 documentation:
+// the hits of each query word in each column of the row, divided by the hits
+// of the word in that column across all the rows, and weighed by column.
+// The rank is negative, so that a lower rank means a better match.
+//
+// The matchinfo values are in the native byte order, which is little endian on
+// the architectures the node is built for.
+func searchRank(matchinfo []byte) float64 {
+	info := make([]uint32, len(matchinfo)/4)
+	for i := range info {
+		info[i] = binary.LittleEndian.Uint32(matchinfo[i*4:])
+	}
+	if len(info) < 2 {
+		return 0
+	}
+	phrases, columns := int(info[0]), int(info[1])
+	var score float64
+	for p := 0; p < phrases; p++ {
+		for c := 0; c < columns && c < len(searchColumnWeights); c++ {
+			hits := info[2+3*(p*columns+c):]
+			if len(hits) < 2 || hits[1] == 0 {
+				continue
+			}
+			score += searchColumnWeights[c] * float64(hits[0]) / float64(hits[1])
+		}
+	}
+	return -score
+}
+
+// searchWords splits a free-form user query in words, ignoring any
+// punctuation and syntax characters.
+func searchWords(query string) []string {
+	return strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// searchMatchExpression builds a FTS4 match expression from the query words.
+// Every word is quoted, so that words such as OR or NEAR are treated as text,
+// and used as a prefix, so that partial words also match. All words must match.
+func searchMatchExpression(words []string) string {
+	terms := make([]string, len(words))
+	for i, w := range words {
+		terms[i] = `"` + w + `*"`
+	}
+	return strings.Join(terms, " ")
+}
+
+// rollbackTx rolls back the transaction unless it was committed.
+func rollbackTx(tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		log.Warnw("could not rollback metadata search tx", "err", err)
+	}
+}
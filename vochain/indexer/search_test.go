@@ -0,0 +1,138 @@
+package indexer
+
+import (
+	"context"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+)
+
+func TestSearchMetadata(t *testing.T) {
+	app := vochain.TestBaseApplication(t)
+	idx := newTestIndexer(t, app, true)
+
+	budget := util.RandomBytes(32)
+	qt.Assert(t, idx.IndexElectionMetadata(budget, map[string]*indexertypes.MetadataText{
+		"default": {Title: "Budget 2026", Description: "Participatory budget of the city"},
+		"es":      {Title: "Presupuesto 2026", Description: "Presupuestos participativos de la ciudad"},
+	}), qt.IsNil)
+	board := util.RandomBytes(32)
+	qt.Assert(t, idx.IndexElectionMetadata(board, map[string]*indexertypes.MetadataText{
+		"default": {
+			Title:     "Board election",
+			Questions: "Do you approve the 2026 budget?\nYes\nNo",
+		},
+	}), qt.IsNil)
+
+	// both match, but the title match must rank first
+	results, err := idx.SearchElections("budget 2026", "", 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, results, qt.HasLen, 2)
+	qt.Assert(t, results[0].ID, qt.DeepEquals, types.HexBytes(budget))
+	qt.Assert(t, results[0].Language, qt.Equals, "default")
+	qt.Assert(t, results[1].ID, qt.DeepEquals, types.HexBytes(board))
+
+	// language filter, with prefix matching and diacritics removal
+	results, err = idx.SearchElections("presupuésto", "es", 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, results, qt.HasLen, 1)
+	qt.Assert(t, results[0].ID, qt.DeepEquals, types.HexBytes(budget))
+	results, err = idx.SearchElections("presupuest", "es", 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, results, qt.HasLen, 1)
+	qt.Assert(t, results[0].ID, qt.DeepEquals, types.HexBytes(budget))
+	results, err = idx.SearchElections("presupuesto", "default", 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, results, qt.HasLen, 0)
+
+	// the full-text syntax characters and operators are treated as plain text
+	results, err = idx.SearchElections(`"board" OR (NEAR*`, "", 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, results, qt.HasLen, 0)
+
+	// re-indexing replaces the previous texts
+	qt.Assert(t, idx.IndexElectionMetadata(budget, map[string]*indexertypes.MetadataText{
+		"default": {Title: "Renamed"},
+	}), qt.IsNil)
+	results, err = idx.SearchElections("presupuesto", "", 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, results, qt.HasLen, 0)
+
+	// accounts are searched separately
+	org := util.RandomBytes(20)
+	qt.Assert(t, idx.IndexAccountMetadata(org, map[string]*indexertypes.MetadataText{
+		"default": {Title: "Budget office"},
+	}), qt.IsNil)
+	results, err = idx.SearchAccounts("budget", "", 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, results, qt.HasLen, 1)
+	qt.Assert(t, results[0].ID, qt.DeepEquals, types.HexBytes(org))
+
+	_, err = idx.SearchElections("  ", "", 0, 10)
+	qt.Assert(t, err, qt.Not(qt.IsNil))
+}
+
+func TestSearchMetadataBackfill(t *testing.T) {
+	app := vochain.TestBaseApplication(t)
+	idx := newTestIndexer(t, app, true)
+	// wait for the backfill started by the indexer
+	idx.WaitIdle()
+
+	// valid metadata stored before its texts were indexed
+	pid := util.RandomBytes(32)
+	qt.Assert(t, idx.SetElectionMetadata(pid, &indexertypes.Metadata{
+		URI:   "ipfs://election",
+		Valid: true,
+		Data:  []byte(`{"title": {"default": "Board election"}, "description": {"default": "Yearly"}}`),
+	}), qt.IsNil)
+	qt.Assert(t, idx.SetElectionMetadata(util.RandomBytes(32), &indexertypes.Metadata{
+		URI:    "ipfs://invalid",
+		Errors: []string{"invalid"},
+	}), qt.IsNil)
+	results, err := idx.SearchElections("board", "", 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, results, qt.HasLen, 0)
+
+	qt.Assert(t, idx.backfillSearchTexts(context.Background()), qt.IsNil)
+	results, err = idx.SearchElections("board", "", 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, results, qt.HasLen, 1)
+	qt.Assert(t, results[0].ID, qt.DeepEquals, types.HexBytes(pid))
+
+	// the texts indexed since the metadata was read are not replaced
+	qt.Assert(t, idx.IndexElectionMetadata(pid, map[string]*indexertypes.MetadataText{
+		"default": {Title: "Assembly"},
+	}), qt.IsNil)
+	qt.Assert(t, idx.indexMetadata(context.Background(), searchKindElection, pid,
+		map[string]*indexertypes.MetadataText{"default": {Title: "Board election"}}, false), qt.IsNil)
+	results, err = idx.SearchElections("board", "", 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, results, qt.HasLen, 0)
+
+	// the matches on the title rank first
+	other := util.RandomBytes(32)
+	qt.Assert(t, idx.IndexElectionMetadata(other, map[string]*indexertypes.MetadataText{
+		"default": {Title: "Board", Description: "Yearly assembly"},
+	}), qt.IsNil)
+	results, err = idx.SearchElections("ASSEMBLY", "", 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, results, qt.HasLen, 2)
+	qt.Assert(t, results[0].ID, qt.DeepEquals, types.HexBytes(pid))
+	qt.Assert(t, results[1].ID, qt.DeepEquals, types.HexBytes(other))
+	results, err = idx.SearchElections("board yearly", "", 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, results, qt.HasLen, 1)
+
+	// pagination
+	results, err = idx.SearchElections("assembly", "", 1, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, results, qt.HasLen, 1)
+	qt.Assert(t, results[0].ID, qt.DeepEquals, types.HexBytes(other))
+	results, err = idx.SearchElections("assembly", "", 2, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, results, qt.HasLen, 0)
+}
@@ -0,0 +1,506 @@
+package indexer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
+)
+
+// The stats are aggregated by the indexer in hourly buckets, see the
+// 0006_create_table_stats.sql migration. The bucket sizes that can be queried
+// must be a multiple of the stored bucket size.
+//
+// Note that the stats queries don't use sqlc, since its MySQL engine cannot
+// parse the SQLite upserts used to maintain the aggregates.
+const (
+	StatsBucketHour = time.Hour
+	StatsBucketDay  = 24 * time.Hour
+	StatsBucketWeek = 7 * 24 * time.Hour
+
+	// statsBackfillChunk is the number of block heights aggregated on each
+	// iteration of the transactions backfill.
+	statsBackfillChunk = 1000
+)
+
+// blockStats accumulates the stats of the block being processed, which are
+// stored on Commit.
+type blockStats struct {
+	votes      map[string]*voteStats // indexed by process ID
+	elections  int64
+	txs        map[string]int64 // indexed by tx type
+	signedTxs  []*vochaintx.VochainTx
+	transfers  int64
+	volume     int64
+	hasUpdates bool
+}
+
+type voteStats struct {
+	votes      int64
+	overwrites int64
+}
+
+func newBlockStats() *blockStats {
+	return &blockStats{
+		votes: make(map[string]*voteStats),
+		txs:   make(map[string]int64),
+	}
+}
+
+func (bs *blockStats) addVote(pid []byte, overwrite bool) {
+	vs := bs.votes[string(pid)]
+	if vs == nil {
+		vs = &voteStats{}
+		bs.votes[string(pid)] = vs
+	}
+	if overwrite {
+		vs.overwrites++
+	} else {
+		vs.votes++
+	}
+	bs.hasUpdates = true
+}
+
+func (bs *blockStats) addElection() {
+	bs.elections++
+	bs.hasUpdates = true
+}
+
+func (bs *blockStats) addTx(tx *vochaintx.VochainTx) {
+	bs.txs[tx.TxModelType]++
+	if len(tx.Signature) > 0 {
+		bs.signedTxs = append(bs.signedTxs, tx)
+	}
+	bs.hasUpdates = true
+}
+
+func (bs *blockStats) addTransfer(amount uint64) {
+	bs.transfers++
+	bs.volume += int64(amount)
+	bs.hasUpdates = true
+}
+
+// statsBucket returns the start of the stored bucket for a unix timestamp.
+func statsBucket(timestamp int64) int64 {
+	const size = int64(StatsBucketHour / time.Second)
+	return timestamp / size * size
+}
+
+// commitStats stores the stats of a block, adding them to the aggregates of the
+// bucket of the block time. It is meant to be called on a goroutine by Commit.
+func (idx *Indexer) commitStats(bs *blockStats, blockTime int64) {
+	defer idx.liveGoroutines.Add(-1)
+	if !bs.hasUpdates {
+		return
+	}
+	if idx.cancelCtx.Err() != nil {
+		return // closing
+	}
+	bucket := statsBucket(blockTime)
+
+	// Recover the signers, which is expensive, out of the Commit path.
+	accounts := make(map[string]bool)
+	for _, tx := range bs.signedTxs {
+		addr, err := ethereum.AddrFromSignature(tx.SignedBody, tx.Signature)
+		if err != nil {
+			continue
+		}
+		accounts[string(addr.Bytes())] = true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	tx, err := idx.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Errorw(err, "cannot store block stats")
+		return
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Warnw("could not rollback stats tx", "err", err)
+		}
+	}()
+	if err := func() error {
+		for pid, vs := range bs.votes {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO stats_votes (process_id, bucket, votes, overwrites)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (process_id, bucket) DO UPDATE
+SET votes = votes + excluded.votes, overwrites = overwrites + excluded.overwrites`,
+				[]byte(pid), bucket, vs.votes, vs.overwrites); err != nil {
+				return fmt.Errorf("votes: %w", err)
+			}
+		}
+		if bs.elections > 0 {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO stats_elections (bucket, elections)
+VALUES (?, ?)
+ON CONFLICT (bucket) DO UPDATE
+SET elections = elections + excluded.elections`,
+				bucket, bs.elections); err != nil {
+				return fmt.Errorf("elections: %w", err)
+			}
+		}
+		if err := addTransactionStats(ctx, tx, bucket, bs.txs); err != nil {
+			return err
+		}
+		if bs.transfers > 0 {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO stats_token_transfers (bucket, transfers, volume)
+VALUES (?, ?, ?)
+ON CONFLICT (bucket) DO UPDATE
+SET transfers = transfers + excluded.transfers, volume = volume + excluded.volume`,
+				bucket, bs.transfers, bs.volume); err != nil {
+				return fmt.Errorf("token transfers: %w", err)
+			}
+		}
+		for account := range accounts {
+			if _, err := tx.ExecContext(ctx,
+				"INSERT OR IGNORE INTO stats_accounts (bucket, account) VALUES (?, ?)",
+				bucket, []byte(account)); err != nil {
+				return fmt.Errorf("accounts: %w", err)
+			}
+		}
+		return tx.Commit()
+	}(); err != nil {
+		log.Errorw(err, "cannot store block stats")
+	}
+}
+
+func addTransactionStats(ctx context.Context, tx *sql.Tx, bucket int64, txs map[string]int64) error {
+	for txType, count := range txs {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO stats_transactions (bucket, tx_type, transactions)
+VALUES (?, ?, ?)
+ON CONFLICT (bucket, tx_type) DO UPDATE
+SET transactions = transactions + excluded.transactions`,
+			bucket, txType, count); err != nil {
+			return fmt.Errorf("transactions: %w", err)
+		}
+	}
+	return nil
+}
+
+// BackfillStats aggregates the transactions indexed before the stats were
+// introduced, which could not be done by the database migration since the
+// block times are not stored in the indexer. The progress is stored, so the
+// backfill resumes where it was left if the node is restarted.
+// The rest of the stats are backfilled by the migration itself; note that the
+// active accounts can only be backfilled from the token transfers.
+// This method might be called on a goroutine after initializing the Indexer.
+func (idx *Indexer) BackfillStats() {
+	idx.liveGoroutines.Add(1)
+	defer idx.liveGoroutines.Add(-1)
+	for idx.cancelCtx.Err() == nil {
+		done, err := idx.backfillTransactionStats()
+		if err != nil {
+			log.Errorw(err, "cannot backfill transaction stats")
+			return
+		}
+		if done {
+			return
+		}
+	}
+}
+
+// backfillTransactionStats aggregates the next chunk of pending transactions.
+// It returns true once there is nothing left to backfill.
+func (idx *Indexer) backfillTransactionStats() (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	var next, last int64
+	if err := idx.sqlDB.QueryRowContext(ctx,
+		"SELECT next_height, last_height FROM stats_backfill WHERE name = 'transactions'",
+	).Scan(&next, &last); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return true, nil
+		}
+		return false, err
+	}
+	end := next + statsBackfillChunk - 1
+	if end > last {
+		end = last
+	}
+	startTime := time.Now()
+
+	rows, err := idx.sqlDB.QueryContext(ctx, `SELECT block_height, tx_type, COUNT(*) FROM tx_references
+WHERE block_height >= ? AND block_height <= ?
+GROUP BY block_height, tx_type`, next, end)
+	if err != nil {
+		return false, err
+	}
+	buckets := make(map[int64]map[string]int64)
+	for rows.Next() {
+		var height, count int64
+		var txType string
+		if err := rows.Scan(&height, &txType, &count); err != nil {
+			rows.Close()
+			return false, err
+		}
+		blockTime := idx.App.TimestampFromBlock(height)
+		if blockTime == nil {
+			log.Warnw("cannot backfill transaction stats, block not found", "height", height)
+			continue
+		}
+		bucket := statsBucket(blockTime.Unix())
+		if buckets[bucket] == nil {
+			buckets[bucket] = make(map[string]int64)
+		}
+		buckets[bucket][txType] += count
+	}
+	if err := rows.Close(); err != nil {
+		return false, err
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	tx, err := idx.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Warnw("could not rollback stats backfill tx", "err", err)
+		}
+	}()
+	for bucket, txs := range buckets {
+		if err := addTransactionStats(ctx, tx, bucket, txs); err != nil {
+			return false, err
+		}
+	}
+	done := end >= last
+	if done {
+		_, err = tx.ExecContext(ctx, "DELETE FROM stats_backfill WHERE name = 'transactions'")
+	} else {
+		_, err = tx.ExecContext(ctx,
+			"UPDATE stats_backfill SET next_height = ? WHERE name = 'transactions'", end+1)
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	log.Debugw("backfilled transaction stats", "from", next, "to", end, "last", last,
+		"took", time.Since(startTime))
+	return done, nil
+}
+
+// statsSeries holds a time series with a point for each bucket within a range.
+type statsSeries struct {
+	start  int64
+	size   int64
+	points []*indexertypes.StatsPoint
+}
+
+// newStatsSeries returns the empty series for the buckets of the given size
+// between from (inclusive) and to (exclusive). The first bucket starts at the
+// beginning of the bucket containing from; note that the buckets are aligned to
+// the unix epoch, so days start at 00:00 UTC and weeks start on Thursday.
+func newStatsSeries(bucket time.Duration, from, to time.Time) (*statsSeries, error) {
+	if bucket < StatsBucketHour || bucket%StatsBucketHour != 0 {
+		return nil, fmt.Errorf("invalid bucket size %s, must be a multiple of %s", bucket, StatsBucketHour)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("invalid time range, %s is not after %s", to, from)
+	}
+	s := &statsSeries{size: int64(bucket / time.Second)}
+	s.start = from.Unix() / s.size * s.size
+	for t := s.start; t < to.Unix(); t += s.size {
+		s.points = append(s.points, &indexertypes.StatsPoint{Time: time.Unix(t, 0).UTC()})
+	}
+	return s, nil
+}
+
+// end returns the unix timestamp where the series ends.
+func (s *statsSeries) end() int64 {
+	return s.start + int64(len(s.points))*s.size
+}
+
+// point returns the point of the series for the given (grouped) bucket.
+func (s *statsSeries) point(bucket int64) *indexertypes.StatsPoint {
+	i := (bucket - s.start) / s.size
+	if i < 0 || i >= int64(len(s.points)) {
+		return nil
+	}
+	return s.points[i]
+}
+
+// query runs a stats query which returns the bucket as the first column,
+// grouped by the series bucket size, and scans each row into its point.
+// The first arguments of the query are the bucket size, twice, followed by
+// the start and the end of the series, and then by any extra args.
+func (s *statsSeries) query(idx *Indexer, query string,
+	scan func(*sql.Rows, *indexertypes.StatsPoint) error, args ...any,
+) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	args = append([]any{s.size, s.size, s.start, s.end()}, args...)
+	rows, err := idx.sqlDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		point := &indexertypes.StatsPoint{}
+		if err := scan(rows, point); err != nil {
+			return err
+		}
+		if p := s.point(point.Time.Unix()); p != nil {
+			p.Count += point.Count
+			p.Overwrites += point.Overwrites
+			p.Volume += point.Volume
+			for txType, count := range point.ByType {
+				if p.ByType == nil {
+					p.ByType = make(map[string]uint64)
+				}
+				p.ByType[txType] += count
+			}
+		}
+	}
+	return rows.Err()
+}
+
+// scanBucket scans the bucket column and any other values of a stats row.
+func scanBucket(rows *sql.Rows, point *indexertypes.StatsPoint, dest ...any) error {
+	var bucket int64
+	if err := rows.Scan(append([]any{&bucket}, dest...)...); err != nil {
+		return err
+	}
+	point.Time = time.Unix(bucket, 0)
+	return nil
+}
+
+// VoteStats returns the number of votes cast on each bucket between from and to.
+// Count holds the new votes, and Overwrites the votes which replaced a previous one.
+// If processID is nil, the votes of all the elections are aggregated.
+func (idx *Indexer) VoteStats(processID []byte, bucket time.Duration, from, to time.Time) ([]*indexertypes.StatsPoint, error) {
+	s, err := newStatsSeries(bucket, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.query(idx, `SELECT bucket / ? * ?, SUM(votes), SUM(overwrites) FROM stats_votes
+WHERE bucket >= ? AND bucket < ? AND (? IS NULL OR process_id = ?)
+GROUP BY 1`, func(rows *sql.Rows, p *indexertypes.StatsPoint) error {
+		return scanBucket(rows, p, &p.Count, &p.Overwrites)
+	}, processID, processID); err != nil {
+		return nil, err
+	}
+	return s.points, nil
+}
+
+// ElectionStats returns the number of elections created on each bucket between from and to.
+func (idx *Indexer) ElectionStats(bucket time.Duration, from, to time.Time) ([]*indexertypes.StatsPoint, error) {
+	s, err := newStatsSeries(bucket, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.query(idx, `SELECT bucket / ? * ?, SUM(elections) FROM stats_elections
+WHERE bucket >= ? AND bucket < ?
+GROUP BY 1`, func(rows *sql.Rows, p *indexertypes.StatsPoint) error {
+		return scanBucket(rows, p, &p.Count)
+	}); err != nil {
+		return nil, err
+	}
+	return s.points, nil
+}
+
+// TransactionStats returns the number of transactions on each bucket between from
+// and to, in total and by transaction type.
+func (idx *Indexer) TransactionStats(bucket time.Duration, from, to time.Time) ([]*indexertypes.StatsPoint, error) {
+	s, err := newStatsSeries(bucket, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.query(idx, `SELECT bucket / ? * ?, tx_type, SUM(transactions) FROM stats_transactions
+WHERE bucket >= ? AND bucket < ?
+GROUP BY 1, 2`, func(rows *sql.Rows, p *indexertypes.StatsPoint) error {
+		var txType string
+		if err := scanBucket(rows, p, &txType, &p.Count); err != nil {
+			return err
+		}
+		p.ByType = map[string]uint64{txType: p.Count}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return s.points, nil
+}
+
+// AccountStats returns the number of distinct accounts which signed at least one
+// transaction on each bucket between from and to.
+func (idx *Indexer) AccountStats(bucket time.Duration, from, to time.Time) ([]*indexertypes.StatsPoint, error) {
+	s, err := newStatsSeries(bucket, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.query(idx, `SELECT bucket / ? * ?, COUNT(DISTINCT account) FROM stats_accounts
+WHERE bucket >= ? AND bucket < ?
+GROUP BY 1`, func(rows *sql.Rows, p *indexertypes.StatsPoint) error {
+		return scanBucket(rows, p, &p.Count)
+	}); err != nil {
+		return nil, err
+	}
+	return s.points, nil
+}
+
+// TokenTransferStats returns the number of token transfers on each bucket
+// between from and to, and the amount of tokens transferred as Volume.
+func (idx *Indexer) TokenTransferStats(bucket time.Duration, from, to time.Time) ([]*indexertypes.StatsPoint, error) {
+	s, err := newStatsSeries(bucket, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.query(idx, `SELECT bucket / ? * ?, SUM(transfers), SUM(volume) FROM stats_token_transfers
+WHERE bucket >= ? AND bucket < ?
+GROUP BY 1`, func(rows *sql.Rows, p *indexertypes.StatsPoint) error {
+		return scanBucket(rows, p, &p.Count, &p.Volume)
+	}); err != nil {
+		return nil, err
+	}
+	return s.points, nil
+}
+
+// TurnoutStats returns the turnout curve of an election between from and to.
+// The turnout is the percentage of the census which has voted at the end of each
+// bucket. It is zero if the census size of the election is unknown.
+func (idx *Indexer) TurnoutStats(processID []byte, bucket time.Duration, from, to time.Time) ([]*indexertypes.TurnoutPoint, error) {
+	proc, err := idx.ProcessInfo(processID)
+	if err != nil {
+		return nil, err
+	}
+	censusSize := proc.MaxCensusSize
+	if proc.RollingCensusSize > 0 {
+		censusSize = proc.RollingCensusSize
+	}
+	votes, err := idx.VoteStats(processID, bucket, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	var cumulative uint64
+	if err := idx.sqlDB.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(votes), 0) FROM stats_votes WHERE process_id = ? AND bucket < ?",
+		processID, votes[0].Time.Unix(),
+	).Scan(&cumulative); err != nil {
+		return nil, err
+	}
+	points := make([]*indexertypes.TurnoutPoint, len(votes))
+	for i, v := range votes {
+		cumulative += v.Count
+		points[i] = &indexertypes.TurnoutPoint{
+			Time:            v.Time,
+			Votes:           v.Count,
+			CumulativeVotes: cumulative,
+		}
+		if censusSize > 0 {
+			points[i].Turnout = float64(cumulative) * 100 / float64(censusSize)
+		}
+	}
+	return points, nil
+}
@@ -0,0 +1,180 @@
+package indexer
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain"
+	indexerdb "go.vocdoni.io/dvote/vochain/indexer/db"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+	"go.vocdoni.io/dvote/vochain/state"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
+	models "go.vocdoni.io/proto/build/go/models"
+)
+
+func sumStats(points []*indexertypes.StatsPoint) (count, overwrites, volume uint64) {
+	for _, p := range points {
+		count += p.Count
+		overwrites += p.Overwrites
+		volume += p.Volume
+	}
+	return count, overwrites, volume
+}
+
+func TestStats(t *testing.T) {
+	app := vochain.TestBaseApplication(t)
+	idx := newTestIndexer(t, app, true)
+	// the first block has no start time
+	app.AdvanceTestBlock()
+
+	pid := util.RandomBytes(32)
+	qt.Assert(t, app.State.AddProcess(&models.Process{
+		ProcessId:     pid,
+		EntityId:      util.RandomBytes(20),
+		BlockCount:    10,
+		VoteOptions:   &models.ProcessVoteOptions{MaxCount: 1, MaxValue: 1},
+		EnvelopeType:  &models.EnvelopeType{},
+		MaxCensusSize: 10,
+	}), qt.IsNil)
+	app.AdvanceTestBlock()
+
+	// three new votes and one overwrite
+	vp, err := json.Marshal(vochain.VotePackage{Votes: []int{1}})
+	qt.Assert(t, err, qt.IsNil)
+	nullifiers := [][]byte{util.RandomBytes(32), util.RandomBytes(32), util.RandomBytes(32)}
+	for _, nullifier := range nullifiers {
+		qt.Assert(t, app.State.AddVote(&state.Vote{ProcessID: pid, VotePackage: vp, Nullifier: nullifier}), qt.IsNil)
+	}
+	qt.Assert(t, app.State.AddVote(&state.Vote{
+		ProcessID: pid, VotePackage: vp, Nullifier: nullifiers[0], Overwrites: 1,
+	}), qt.IsNil)
+
+	// two signed txs from the same account, and an unsigned one
+	signer := ethereum.NewSignKeys()
+	qt.Assert(t, signer.Generate(), qt.IsNil)
+	for i := 0; i < 2; i++ {
+		body := util.RandomBytes(32)
+		signature, err := signer.SignVocdoniTx(body, app.ChainID())
+		qt.Assert(t, err, qt.IsNil)
+		idx.OnNewTx(&vochaintx.VochainTx{
+			TxID:        util.Random32(),
+			TxModelType: "setAccount",
+			SignedBody:  ethereum.BuildVocdoniTransaction(body, app.ChainID()),
+			Signature:   signature,
+		}, app.Height(), int32(i))
+	}
+	idx.OnNewTx(&vochaintx.VochainTx{TxID: util.Random32(), TxModelType: "vote"}, app.Height(), 2)
+	idx.OnTransferTokens(&vochaintx.TokenTransfer{
+		FromAddress: signer.Address(),
+		ToAddress:   common.BytesToAddress(util.RandomBytes(20)),
+		Amount:      50,
+		TxHash:      util.RandomBytes(32),
+	})
+	app.AdvanceTestBlock()
+	idx.WaitIdle()
+
+	from := time.Now().Truncate(time.Hour).Add(-2 * time.Hour)
+	to := from.Add(4 * time.Hour)
+
+	points, err := idx.VoteStats(nil, StatsBucketHour, from, to)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, points, qt.HasLen, 4)
+	count, overwrites, _ := sumStats(points)
+	qt.Assert(t, count, qt.Equals, uint64(3))
+	qt.Assert(t, overwrites, qt.Equals, uint64(1))
+
+	points, err = idx.VoteStats(util.RandomBytes(32), StatsBucketDay, from, to)
+	qt.Assert(t, err, qt.IsNil)
+	count, _, _ = sumStats(points)
+	qt.Assert(t, count, qt.Equals, uint64(0))
+
+	points, err = idx.ElectionStats(StatsBucketDay, from, to)
+	qt.Assert(t, err, qt.IsNil)
+	count, _, _ = sumStats(points)
+	qt.Assert(t, count, qt.Equals, uint64(1))
+
+	points, err = idx.TransactionStats(StatsBucketWeek, from, to)
+	qt.Assert(t, err, qt.IsNil)
+	count, _, _ = sumStats(points)
+	qt.Assert(t, count, qt.Equals, uint64(3))
+	byType := make(map[string]uint64)
+	for _, p := range points {
+		for txType, n := range p.ByType {
+			byType[txType] += n
+		}
+	}
+	qt.Assert(t, byType, qt.DeepEquals, map[string]uint64{"setAccount": 2, "vote": 1})
+
+	points, err = idx.AccountStats(StatsBucketDay, from, to)
+	qt.Assert(t, err, qt.IsNil)
+	count, _, _ = sumStats(points)
+	qt.Assert(t, count, qt.Equals, uint64(1))
+
+	points, err = idx.TokenTransferStats(StatsBucketHour, from, to)
+	qt.Assert(t, err, qt.IsNil)
+	count, _, volume := sumStats(points)
+	qt.Assert(t, count, qt.Equals, uint64(1))
+	qt.Assert(t, volume, qt.Equals, uint64(50))
+
+	turnout, err := idx.TurnoutStats(pid, StatsBucketHour, from, to)
+	qt.Assert(t, err, qt.IsNil)
+	last := turnout[len(turnout)-1]
+	qt.Assert(t, last.CumulativeVotes, qt.Equals, uint64(3))
+	qt.Assert(t, last.Turnout, qt.Equals, float64(30))
+
+	// the cumulative votes include the votes before the time range
+	turnout, err = idx.TurnoutStats(pid, StatsBucketHour, time.Now().Add(time.Hour), to)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, turnout[0].Votes, qt.Equals, uint64(0))
+	qt.Assert(t, turnout[0].CumulativeVotes, qt.Equals, uint64(3))
+
+	_, err = idx.VoteStats(nil, 90*time.Minute, from, to)
+	qt.Assert(t, err, qt.Not(qt.IsNil))
+	_, err = idx.VoteStats(nil, StatsBucketHour, to, from)
+	qt.Assert(t, err, qt.Not(qt.IsNil))
+}
+
+func TestStatsBackfill(t *testing.T) {
+	app := vochain.TestBaseApplication(t)
+	idx := newTestIndexer(t, app, true)
+	app.AdvanceTestBlock()
+
+	// simulate transactions indexed before the stats existed
+	queries, ctx, cancel := idx.timeoutQueries()
+	defer cancel()
+	height := int64(app.Height())
+	for i := 0; i < 3; i++ {
+		_, err := queries.CreateTxReference(ctx, indexerdb.CreateTxReferenceParams{
+			Hash:         util.RandomBytes(32),
+			BlockHeight:  height,
+			TxBlockIndex: int64(i),
+			TxType:       "newProcess",
+		})
+		qt.Assert(t, err, qt.IsNil)
+	}
+	_, err := idx.sqlDB.Exec(
+		"INSERT INTO stats_backfill (name, next_height, last_height) VALUES ('transactions', ?, ?)",
+		height, height)
+	qt.Assert(t, err, qt.IsNil)
+
+	idx.BackfillStats()
+	points, err := idx.TransactionStats(StatsBucketDay, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	qt.Assert(t, err, qt.IsNil)
+	count, _, _ := sumStats(points)
+	qt.Assert(t, count, qt.Equals, uint64(3))
+
+	// the backfill is done, so running it again is a no-op
+	var pending int
+	qt.Assert(t, idx.sqlDB.QueryRow("SELECT COUNT(*) FROM stats_backfill").Scan(&pending), qt.IsNil)
+	qt.Assert(t, pending, qt.Equals, 0)
+	idx.BackfillStats()
+	points, err = idx.TransactionStats(StatsBucketDay, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	qt.Assert(t, err, qt.IsNil)
+	count, _, _ = sumStats(points)
+	qt.Assert(t, count, qt.Equals, uint64(3))
+}
@@ -85,6 +85,7 @@ func (s *Indexer) OnNewTx(tx *vochaintx.VochainTx, blockHeight uint32, txIndex i
 		TxBlockIndex: txIndex,
 		TxType:       tx.TxModelType,
 	})
+	s.blockStats.addTx(tx)
 }
 
 // indexNewTxs indexes the txs pending in the newTxPool and updates the transaction count
@@ -0,0 +1,83 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
+)
+
+// OnTokenVesting adds the vesting schedule, created or updated, to the pool
+// of schedules stored on Commit.
+func (idx *Indexer) OnTokenVesting(v *vochaintx.TokenVesting) {
+	idx.lockPool.Lock()
+	defer idx.lockPool.Unlock()
+	idx.tokenVestingPool = append(idx.tokenVestingPool, &indexertypes.TokenVestingMeta{
+		TxHash:      v.TxHash,
+		From:        v.FromAddress.Bytes(),
+		To:          v.ToAddress.Bytes(),
+		Amount:      v.Amount,
+		Released:    v.Released,
+		StartHeight: v.StartHeight,
+		CliffHeight: v.CliffHeight,
+		EndHeight:   v.EndHeight,
+		Updated:     time.Now(),
+	})
+}
+
+// setTokenVesting stores a new vesting schedule, or updates the released
+// amount of an existing one.
+func (idx *Indexer) setTokenVesting(v *indexertypes.TokenVestingMeta) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := idx.sqlDB.ExecContext(ctx, `INSERT INTO token_vestings
+(tx_hash, from_account, to_account, amount, released, start_height, cliff_height, end_height,
+ created_time, updated_time)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (tx_hash) DO UPDATE
+SET released = excluded.released, updated_time = excluded.updated_time`,
+		v.TxHash, v.From, v.To, int64(v.Amount), int64(v.Released),
+		v.StartHeight, v.CliffHeight, v.EndHeight, v.Updated, v.Updated,
+	); err != nil {
+		return fmt.Errorf("cannot store token vesting: %w", err)
+	}
+	log.Debugw("token vesting",
+		"txHash", fmt.Sprintf("%x", v.TxHash),
+		"to", fmt.Sprintf("%x", v.To),
+		"released", fmt.Sprintf("%d/%d", v.Released, v.Amount),
+	)
+	return nil
+}
+
+// TokenVestingsByAccount returns the vesting schedules sent or received by the
+// account, including the ones completely released, ordered by their start
+// height.
+func (idx *Indexer) TokenVestingsByAccount(account []byte) ([]*indexertypes.TokenVestingMeta, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	rows, err := idx.sqlDB.QueryContext(ctx, `SELECT
+tx_hash, from_account, to_account, amount, released, start_height, cliff_height, end_height,
+created_time, updated_time
+FROM token_vestings
+WHERE to_account = ? OR from_account = ?
+ORDER BY start_height ASC, tx_hash ASC`, account, account)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	vestings := []*indexertypes.TokenVestingMeta{}
+	for rows.Next() {
+		v := &indexertypes.TokenVestingMeta{}
+		var amount, released int64
+		if err := rows.Scan(&v.TxHash, &v.From, &v.To, &amount, &released,
+			&v.StartHeight, &v.CliffHeight, &v.EndHeight, &v.Created, &v.Updated); err != nil {
+			return nil, err
+		}
+		v.Amount, v.Released = uint64(amount), uint64(released)
+		vestings = append(vestings, v)
+	}
+	return vestings, rows.Err()
+}
@@ -0,0 +1,65 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain"
+	"go.vocdoni.io/dvote/vochain/state"
+	models "go.vocdoni.io/proto/build/go/models"
+)
+
+func TestTokenVestings(t *testing.T) {
+	app := vochain.TestBaseApplication(t)
+	idx := newTestIndexer(t, app, true)
+
+	from := common.BytesToAddress(util.RandomBytes(20))
+	to := common.BytesToAddress(util.RandomBytes(20))
+	qt.Assert(t, app.State.SetAccount(from, &state.Account{Account: models.Account{Balance: 1000}}), qt.IsNil)
+	qt.Assert(t, app.State.SetAccount(to, &state.Account{}), qt.IsNil)
+	app.AdvanceTestBlock()
+
+	height := app.State.CurrentHeight()
+	txHash := util.RandomBytes(32)
+	qt.Assert(t, app.State.TransferVestingTokens(&state.VestingSchedule{
+		TxHash:      txHash,
+		From:        from,
+		To:          to,
+		Amount:      100,
+		StartHeight: height,
+		CliffHeight: height + 1,
+		EndHeight:   height + 4,
+	}), qt.IsNil)
+	app.AdvanceTestBlock()
+
+	vestings, err := idx.TokenVestingsByAccount(to.Bytes())
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, vestings, qt.HasLen, 1)
+	qt.Assert(t, []byte(vestings[0].TxHash), qt.DeepEquals, txHash)
+	qt.Assert(t, []byte(vestings[0].From), qt.DeepEquals, from.Bytes())
+	qt.Assert(t, vestings[0].Amount, qt.Equals, uint64(100))
+	qt.Assert(t, vestings[0].Released, qt.Equals, uint64(0))
+	qt.Assert(t, vestings[0].EndHeight, qt.Equals, height+4)
+
+	// the released amount is updated on each commit
+	app.AdvanceTestBlock()
+	vestings, err = idx.TokenVestingsByAccount(from.Bytes())
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, vestings, qt.HasLen, 1)
+	qt.Assert(t, vestings[0].Released, qt.Equals, uint64(25))
+
+	// and the schedule is kept once completely released
+	for app.State.CurrentHeight() <= height+4 {
+		app.AdvanceTestBlock()
+	}
+	vestings, err = idx.TokenVestingsByAccount(to.Bytes())
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, vestings, qt.HasLen, 1)
+	qt.Assert(t, vestings[0].Released, qt.Equals, uint64(100))
+
+	vestings, err = idx.TokenVestingsByAccount(util.RandomBytes(20))
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, vestings, qt.HasLen, 0)
+}
@@ -100,11 +100,28 @@ func (s *Indexer) GetEnvelope(nullifier []byte) (*indexertypes.EnvelopePackage,
 	return envelopePackage, nil
 }
 
+// walkEnvelopesPageSize is the number of vote references read at once by
+// walkEnvelopes. It is a variable so that the tests can lower it.
+var walkEnvelopesPageSize = 1000
+
 // WalkEnvelopes executes callback for each envelopes of the ProcessId.
 // The callback function is executed async (in a goroutine) if async=true.
 // The method will return once all goroutines have finished the work.
 func (s *Indexer) WalkEnvelopes(processId []byte, async bool,
 	callback func(*models.StateDBVote)) error {
+	return s.walkEnvelopes(processId, async, func(_ *indexerdb.VoteReference, vote *models.StateDBVote) {
+		callback(vote)
+	}, nil)
+}
+
+// walkEnvelopes is like WalkEnvelopes, also passing the vote reference to the callback.
+// If async is false, the envelopes are walked in the order they were included in the chain.
+//
+// The vote references are read in pages of walkEnvelopesPageSize, so they are
+// not all held in memory. If pageDone is not nil, it is called once the
+// envelopes of each page are walked, and the walk stops if it returns an error.
+func (s *Indexer) walkEnvelopes(processId []byte, async bool,
+	callback func(*indexerdb.VoteReference, *models.StateDBVote), pageDone func() error) error {
 	wg := sync.WaitGroup{}
 
 	// There might be tens of thousands of votes.
@@ -115,38 +132,54 @@ func (s *Indexer) WalkEnvelopes(processId []byte, async bool,
 	const limitConcurrentProcessing = 20
 	semaphore := make(chan bool, limitConcurrentProcessing)
 
-	queries, ctx, cancel := s.timeoutQueries()
-	defer cancel()
-	// TODO(sqlite): getting all votes as a single slice is not scalable.
-	txRefs, err := queries.GetVoteReferencesByProcessID(ctx, processId)
-	if err != nil {
-		return err
-	}
-	for _, txRef := range txRefs {
-		wg.Add(1)
-		txRef := txRef // do not reuse the range var in case async==true
-		processVote := func() {
-			defer wg.Done()
-			v, err := s.App.State.Vote(processId, txRef.Nullifier, true)
-			if err != nil {
-				log.Errorw(err, "cannot get vote from state")
-				return
-			}
-			callback(v)
+	// the page after (-1, -1) starts with the first vote
+	params := indexerdb.GetVoteReferencesByProcessIDPageParams{
+		ProcessID:    processId,
+		AfterHeight:  -1,
+		AfterTxIndex: -1,
+		Limit:        int32(walkEnvelopesPageSize),
+	}
+	for {
+		queries, ctx, cancel := s.timeoutQueries()
+		txRefs, err := queries.GetVoteReferencesByProcessIDPage(ctx, params)
+		cancel()
+		if err != nil {
+			return err
 		}
-		if async {
-			go func() {
-				semaphore <- true
+		for _, txRef := range txRefs {
+			wg.Add(1)
+			txRef := txRef // do not reuse the range var in case async==true
+			processVote := func() {
+				defer wg.Done()
+				v, err := s.App.State.Vote(processId, txRef.Nullifier, true)
+				if err != nil {
+					log.Errorw(err, "cannot get vote from state")
+					return
+				}
+				callback(&txRef, v)
+			}
+			if async {
+				go func() {
+					semaphore <- true
+					processVote()
+					<-semaphore
+				}()
+			} else {
 				processVote()
-				<-semaphore
-			}()
-		} else {
-			processVote()
+			}
+		}
+		wg.Wait()
+		if pageDone != nil {
+			if err := pageDone(); err != nil {
+				return err
+			}
+		}
+		if len(txRefs) < walkEnvelopesPageSize {
+			return nil
 		}
+		last := txRefs[len(txRefs)-1]
+		params.AfterHeight, params.AfterTxIndex = last.Height, last.TxIndex
 	}
-
-	wg.Wait()
-	return nil
 }
 
 // GetEnvelopes retrieves all envelope metadata for a ProcessId.
@@ -311,6 +344,28 @@ func unmarshalVote(VotePackage []byte, keys []string) (*vochain.VotePackage, err
 	return &vote, nil
 }
 
+// decryptVote decodes the vote package of a vote of the process, decrypting it
+// with the process private keys if the votes are encrypted.
+func decryptVote(p *indexertypes.Process, vote *models.StateDBVote) (*vochain.VotePackage, error) {
+	if !p.Envelope.EncryptedVotes {
+		return unmarshalVote(vote.VotePackage, []string{})
+	}
+	if len(p.PrivateKeys) < len(vote.EncryptionKeyIndexes) {
+		return nil, fmt.Errorf("encryptionKeyIndexes has too many fields")
+	}
+	keys := []string{}
+	for _, k := range vote.EncryptionKeyIndexes {
+		if k >= types.KeyKeeperMaxKeyIndex {
+			return nil, fmt.Errorf("key index overflow")
+		}
+		keys = append(keys, p.PrivateKeys[k])
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys provided or wrong index")
+	}
+	return unmarshalVote(vote.VotePackage, keys)
+}
+
 // addLiveVote adds the envelope vote to the results. It does not commit to the database.
 // This method is triggered by OnVote callback for each vote added to the blockchain.
 // If encrypted vote, only weight will be updated.
@@ -478,29 +533,7 @@ func (s *Indexer) computeFinalResults(p *indexertypes.Process) (*results.Results
 	lock := sync.Mutex{}
 
 	if err = s.WalkEnvelopes(p.ID, true, func(vote *models.StateDBVote) {
-		var vp *vochain.VotePackage
-		var err error
-		if p.Envelope.EncryptedVotes {
-			if len(p.PrivateKeys) < len(vote.EncryptionKeyIndexes) {
-				log.Error("encryptionKeyIndexes has too many fields")
-				return
-			}
-			keys := []string{}
-			for _, k := range vote.EncryptionKeyIndexes {
-				if k >= types.KeyKeeperMaxKeyIndex {
-					log.Warn("key index overflow")
-					return
-				}
-				keys = append(keys, p.PrivateKeys[k])
-			}
-			if len(keys) == 0 {
-				log.Warn("no keys provided or wrong index")
-				return
-			}
-			vp, err = unmarshalVote(vote.VotePackage, keys)
-		} else {
-			vp, err = unmarshalVote(vote.VotePackage, []string{})
-		}
+		vp, err := decryptVote(p, vote)
 		if err != nil {
 			log.Debugf("vote invalid: %v", err)
 			return
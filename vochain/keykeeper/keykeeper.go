@@ -188,6 +188,24 @@ func (k *KeyKeeper) Rollback() {
 	k.blockPool = make(map[string]int64)
 }
 
+// Checkpoint returns a copy of the keykeeper database, which can be restored
+// with RestoreCheckpoint.
+func (k *KeyKeeper) Checkpoint() ([]db.KeyValue, error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	return db.ReadAll(k.storage)
+}
+
+// RestoreCheckpoint replaces the keykeeper database with a copy returned by
+// Checkpoint and discards the keys of the current block.
+func (k *KeyKeeper) RestoreCheckpoint(data []db.KeyValue) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.keyPool = make(map[string]*processKeys)
+	k.blockPool = make(map[string]int64)
+	return db.ReplaceAll(k.storage, data)
+}
+
 // OnProcess creates the keys and add them to the pool queue, if the process requires it
 func (k *KeyKeeper) OnProcess(pid, eid []byte, censusRoot, censusURI string, txindex int32) {
 	p, err := k.vochain.State.Process(pid, false)
@@ -502,3 +520,6 @@ func (k *KeyKeeper) OnSetAccount(addr []byte, account *state.Account) {}
 
 // OnTransferTokens does nothing
 func (k *KeyKeeper) OnTransferTokens(tx *vochaintx.TokenTransfer) {}
+
+// OnTokenVesting does nothing
+func (k *KeyKeeper) OnTokenVesting(v *vochaintx.TokenVesting) {}
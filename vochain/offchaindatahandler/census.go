@@ -30,7 +30,9 @@ func (d *OffChainDataHandler) enqueueOffchainCensus(root, uri string) {
 		log.Warnf("census URI or root not valid: (%s,%s)", uri, root)
 		return
 	}
-	d.storage.AddToQueue(uri, d.importExternalCensus, true)
+	if err := d.storage.Enqueue(uri, downloadHandlerCensus, nil, true); err != nil {
+		log.Warnf("cannot enqueue census %s: %v", uri, err)
+	}
 }
 
 // importRollingCensus imports a rolling census (zkIndexed) from a remote URI into the censusDB storage.
@@ -1,19 +1,94 @@
 package offchaindatahandler
 
 import (
+	"encoding/json"
 	"strings"
 
 	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/types/metadata"
+	"go.vocdoni.io/dvote/vochain/indexer"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
 )
 
-// enqueueMetadata enqueue a election metadata for download.
-// (safe for concurrent use, simply pushes an item to a channel)
-func (d *OffChainDataHandler) enqueueMetadata(uri string) {
-	if !strings.HasPrefix(uri, d.storage.RemoteStorage.URIprefix()) {
-		log.Warnf("metadata URI not valid: %s", uri)
+// enqueueMetadata enqueue a election or account metadata for download.
+func (d *OffChainDataHandler) enqueueMetadata(item importItem) {
+	if !strings.HasPrefix(item.uri, d.storage.RemoteStorage.URIprefix()) {
+		log.Warnf("metadata URI not valid: %s", item.uri)
 		return
 	}
-	d.storage.AddToQueue(uri, func(s string, b []byte) {
-		log.Infof("metadata downloaded successfully from %s (%d bytes)", s, len(b))
-	}, true)
+	handler, ref := downloadHandlerElectionMetadata, item.pid
+	if item.itemType == itemTypeAccountMetadata {
+		handler, ref = downloadHandlerAccountMetadata, item.address
+	}
+	if err := d.storage.Enqueue(item.uri, handler, ref, true); err != nil {
+		log.Warnf("cannot enqueue metadata %s: %v", item.uri, err)
+	}
+}
+
+// indexMetadata validates the downloaded election or account metadata and
+// stores it in the indexer, normalised if valid, or as is along with the
+// validation errors otherwise. The texts of valid metadata are indexed, so
+// they can be searched.
+func (d *OffChainDataHandler) indexMetadata(item importItem, data []byte) {
+	log.Infof("metadata downloaded successfully from %s (%d bytes)", item.uri, len(data))
+	if d.indexer == nil {
+		return
+	}
+	stored := &indexertypes.Metadata{URI: item.uri}
+	var normalised interface{}
+	var err error
+	switch item.itemType {
+	case itemTypeElectionMetadata:
+		var m *metadata.Election
+		if m, err = metadata.ParseElection(data); err == nil {
+			stored.Version = m.Version
+			normalised = m
+		}
+	case itemTypeAccountMetadata:
+		var m *metadata.Account
+		if m, err = metadata.ParseAccount(data); err == nil {
+			stored.Version = m.Version
+			normalised = m
+		}
+	default:
+		return
+	}
+	if err != nil {
+		log.Warnw("invalid metadata", "uri", item.uri, "error", err)
+		stored.Errors = metadata.Errors(err)
+		if json.Valid(data) {
+			stored.Data = data
+		}
+	} else if stored.Data, err = json.Marshal(normalised); err != nil {
+		log.Warnf("cannot marshal metadata from %s: %v", item.uri, err)
+		return
+	} else {
+		stored.Valid = true
+	}
+
+	if item.itemType == itemTypeElectionMetadata {
+		err = d.indexer.SetElectionMetadata(item.pid, stored)
+	} else {
+		err = d.indexer.SetAccountMetadata(item.address, stored)
+	}
+	if err != nil {
+		log.Warnf("cannot store metadata from %s: %v", item.uri, err)
+	}
+	if !stored.Valid {
+		return
+	}
+	if item.itemType == itemTypeElectionMetadata {
+		var texts map[string]*indexertypes.MetadataText
+		if texts, err = indexer.ElectionMetadataTexts(stored.Data); err == nil {
+			err = d.indexer.IndexElectionMetadata(item.pid, texts)
+		}
+	} else {
+		var texts map[string]*indexertypes.MetadataText
+		if texts, err = indexer.AccountMetadataTexts(stored.Data); err == nil {
+			err = d.indexer.IndexAccountMetadata(item.address, texts)
+		}
+	}
+	if err != nil {
+		log.Warnf("cannot index metadata from %s: %v", item.uri, err)
+	}
 }
@@ -6,8 +6,10 @@ import (
 	"go.vocdoni.io/dvote/api/censusdb"
 	"go.vocdoni.io/dvote/data/downloader"
 	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/types/metadata"
 	"go.vocdoni.io/dvote/util"
 	"go.vocdoni.io/dvote/vochain"
+	"go.vocdoni.io/dvote/vochain/indexer"
 	"go.vocdoni.io/dvote/vochain/state"
 	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
 	"go.vocdoni.io/proto/build/go/models"
@@ -21,11 +23,19 @@ const (
 	itemTypeAccountMetadata
 )
 
+// names of the download handlers registered by the OffChainDataHandler
+const (
+	downloadHandlerCensus           = "census"
+	downloadHandlerElectionMetadata = "electionMetadata"
+	downloadHandlerAccountMetadata  = "accountMetadata"
+)
+
 type importItem struct {
 	itemType   int
 	uri        string
 	censusRoot string
 	pid        []byte
+	address    []byte
 }
 
 // TBD: A startup process for importing on-going process census
@@ -37,6 +47,7 @@ type OffChainDataHandler struct {
 	vochain       *vochain.BaseApplication
 	census        *censusdb.CensusDB
 	storage       *downloader.Downloader
+	indexer       *indexer.Indexer
 	queue         []importItem
 	queueLock     sync.RWMutex
 	importOnlyNew bool
@@ -45,15 +56,41 @@ type OffChainDataHandler struct {
 
 // NewOffChainDataHandler creates a new instance of the off chain data downloader daemon.
 // It will subscribe to Vochain events and perform data import.
+// If the indexer is not nil, the downloaded election and account metadata is
+// stored on it for the full-text search.
 func NewOffChainDataHandler(v *vochain.BaseApplication, d *downloader.Downloader,
-	c *censusdb.CensusDB, importOnlyNew bool) *OffChainDataHandler {
+	c *censusdb.CensusDB, idx *indexer.Indexer, importOnlyNew bool) *OffChainDataHandler {
 	od := OffChainDataHandler{
 		vochain:       v,
 		census:        c,
 		storage:       d,
+		indexer:       idx,
 		importOnlyNew: importOnlyNew,
 		queue:         make([]importItem, 0),
 	}
+	d.RegisterHandler(downloadHandlerCensus, &downloader.Handler{
+		Priority:     downloader.PriorityHigh,
+		ContentTypes: []string{downloader.ContentTypeJSON},
+		Callback: func(uri string, _ []byte, data []byte) {
+			od.importExternalCensus(uri, data)
+		},
+	})
+	d.RegisterHandler(downloadHandlerElectionMetadata, &downloader.Handler{
+		Priority:     downloader.PriorityNormal,
+		MaxSize:      metadata.MaxSize,
+		ContentTypes: []string{downloader.ContentTypeJSON},
+		Callback: func(uri string, pid []byte, data []byte) {
+			od.indexMetadata(importItem{itemType: itemTypeElectionMetadata, uri: uri, pid: pid}, data)
+		},
+	})
+	d.RegisterHandler(downloadHandlerAccountMetadata, &downloader.Handler{
+		Priority:     downloader.PriorityNormal,
+		MaxSize:      metadata.MaxSize,
+		ContentTypes: []string{downloader.ContentTypeJSON},
+		Callback: func(uri string, address []byte, data []byte) {
+			od.indexMetadata(importItem{itemType: itemTypeAccountMetadata, uri: uri, address: address}, data)
+		},
+	})
 	v.State.AddEventListener(&od)
 	return &od
 }
@@ -74,11 +111,10 @@ func (d *OffChainDataHandler) Commit(height uint32) error {
 		switch item.itemType {
 		case itemTypeExternalCensus:
 			log.Infow("importing data", "type", "external census", "uri", item.uri)
-			// AddToQueue() writes to a channel that might be full, so we don't want to block the main thread.
-			go d.enqueueOffchainCensus(item.censusRoot, item.uri)
+			d.enqueueOffchainCensus(item.censusRoot, item.uri)
 		case itemTypeElectionMetadata, itemTypeAccountMetadata:
 			log.Infow("importing data", "type", "election metadata", "uri", item.uri)
-			go d.enqueueMetadata(item.uri)
+			d.enqueueMetadata(item)
 		case itemTypeRollingCensus:
 			log.Infow("importing data", "type", "rolling census", "uri", item.uri)
 			d.importRollingCensus(item.pid)
@@ -106,6 +142,7 @@ func (d *OffChainDataHandler) OnProcess(pid, eid []byte, censusRoot, censusURI s
 			d.queue = append(d.queue, importItem{
 				uri:      m,
 				itemType: itemTypeElectionMetadata,
+				pid:      pid,
 			})
 		}
 		// enqueue for download external census if needs to be imported
@@ -151,6 +188,7 @@ func (d *OffChainDataHandler) OnSetAccount(addr []byte, account *state.Account)
 			d.queue = append(d.queue, importItem{
 				uri:      m,
 				itemType: itemTypeAccountMetadata,
+				address:  addr,
 			})
 		}
 	}
@@ -165,5 +203,6 @@ func (d *OffChainDataHandler) OnRevealKeys(pid []byte, priv string, txindex int3
 func (d *OffChainDataHandler) OnProcessStatusChange(pid []byte, status models.ProcessStatus, txindex int32) {
 }
 func (d *OffChainDataHandler) OnTransferTokens(tx *vochaintx.TokenTransfer) {}
+func (d *OffChainDataHandler) OnTokenVesting(v *vochaintx.TokenVesting)     {}
 func (d *OffChainDataHandler) OnProcessResults(pid []byte, results *models.ProcessResult, txindex int32) {
 }
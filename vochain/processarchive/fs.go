@@ -0,0 +1,107 @@
+package processarchive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+)
+
+// FSStorage is a Storage keeping the archived processes as JSON files in a
+// local directory, which can be published via IPNS.
+type FSStorage struct {
+	*jsonStorage
+	// Datadir is the directory holding the archive files.
+	Datadir string
+}
+
+// NewFSStorage opens the archive stored at datadir, creating the directory
+// if it does not exist. The index is rebuilt from the process files.
+func NewFSStorage(datadir string) (*FSStorage, error) {
+	if err := os.MkdirAll(datadir, 0o750); err != nil {
+		return nil, err
+	}
+	js := &jsonStorage{files: fsFiles(datadir)}
+	if err := js.buildIndex(); err != nil {
+		return nil, err
+	}
+	return &FSStorage{jsonStorage: js, Datadir: datadir}, nil
+}
+
+// NewJsonStorage opens the archive stored at datadir, see NewFSStorage.
+//
+// Deprecated: use NewFSStorage.
+func NewJsonStorage(datadir string) (*FSStorage, error) {
+	return NewFSStorage(datadir)
+}
+
+// BuildIndex scans the archive directory and builds the JSON storage index
+func BuildIndex(datadir string) (*Index, error) {
+	js := &jsonStorage{files: fsFiles(datadir)}
+	if err := js.buildIndex(); err != nil {
+		return nil, err
+	}
+	return js.index, nil
+}
+
+// fsFiles is a fileStore on a local directory.
+type fsFiles string
+
+func (dir fsFiles) read(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(string(dir), name))
+}
+
+func (dir fsFiles) write(name string, data []byte) error {
+	// TO-DO: use https://github.com/google/renameio
+	return os.WriteFile(filepath.Join(string(dir), name), data, 0o644)
+}
+
+func (dir fsFiles) exists(name string) (bool, error) {
+	if _, err := os.Stat(filepath.Join(string(dir), name)); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (dir fsFiles) list() ([]string, error) {
+	entries, err := os.ReadDir(string(dir))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+// readVersion returns the file with the hash of its contents as version.
+func (dir fsFiles) readVersion(name string) ([]byte, string, error) {
+	data, err := dir.read(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+	hash := sha256.Sum256(data)
+	return data, hex.EncodeToString(hash[:]), nil
+}
+
+// writeVersion checks the version and writes the file. Unlike in S3, both
+// steps are not atomic, but a directory is only written by its own node.
+func (dir fsFiles) writeVersion(name string, data []byte, version string) error {
+	_, current, err := dir.readVersion(name)
+	if err != nil {
+		return err
+	}
+	if current != version {
+		return errVersionConflict
+	}
+	return dir.write(name, data)
+}
@@ -66,7 +66,7 @@ func (pa *ProcessArchive) publishLoop() {
 			log.Infof("publishing process archive")
 			start := time.Now()
 			ctx, cancel := context.WithTimeout(context.Background(), publishInterval)
-			ipnsentry, err := pa.ipfs.PublishIPNSpath(ctx, pa.storage.datadir, ipnsKeyAlias)
+			ipnsentry, err := pa.ipfs.PublishIPNSpath(ctx, pa.datadir, ipnsKeyAlias)
 			defer cancel()
 			if err != nil {
 				log.Warnf("could not publish to IPFS: %v", err)
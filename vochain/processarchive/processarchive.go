@@ -1,12 +1,7 @@
 package processarchive
 
 import (
-	"encoding/json"
 	"errors"
-	"fmt"
-	"os"
-	"path/filepath"
-	"sync"
 	"time"
 
 	"go.vocdoni.io/dvote/data"
@@ -21,9 +16,10 @@ import (
 type ProcessArchive struct {
 	indexer    *indexer.Indexer
 	ipfs       *data.IPFSHandle
-	storage    *jsonStorage
+	storage    Storage
 	publish    chan (bool)
 	lastUpdate time.Time
+	datadir    string // published via IPNS if not empty
 	close      chan (bool)
 }
 
@@ -43,136 +39,19 @@ type IndexProcess struct {
 	ProcessID types.HexBytes `json:"processId"`
 }
 
-type jsonStorage struct {
-	datadir string
-	lock    sync.RWMutex
-	index   *Index
-}
-
-// NewJsonStorage opens a new jsonStorage file at the location provided by datadir
-func NewJsonStorage(datadir string) (*jsonStorage, error) {
-	err := os.MkdirAll(datadir, 0o750)
-	if err != nil {
-		return nil, err
-	}
-	i, err := BuildIndex(datadir)
-	return &jsonStorage{datadir: datadir, index: i}, err
-}
-
-// AddProcess adds an entire process to js
-func (js *jsonStorage) AddProcess(p *Process) error {
-	if p == nil || p.ProcessInfo == nil || len(p.ProcessInfo.ID) != types.ProcessIDsize {
-		return fmt.Errorf("process not valid")
-	}
-	procData, err := json.MarshalIndent(p, " ", " ")
-	if err != nil {
-		return err
-	}
-	js.lock.Lock()
-	defer js.lock.Unlock()
-	procPath := filepath.Join(js.datadir, fmt.Sprintf("%x", p.ProcessInfo.ID))
-
-	// If process file does not exist it means it is a new process, so we add it to the index
-	if _, err := os.Stat(procPath); errors.Is(err, os.ErrNotExist) {
-		eid := fmt.Sprintf("%x", p.ProcessInfo.EntityID)
-		if js.index.Entities[eid] == nil {
-			js.index.Entities[eid] = []*IndexProcess{}
-		}
-		js.index.Entities[eid] = append(js.index.Entities[eid], &IndexProcess{ProcessID: p.ProcessInfo.ID})
-		indexData, err := json.MarshalIndent(js.index, " ", " ")
-		if err != nil {
-			return err
-		}
-		if err := os.WriteFile(filepath.Join(js.datadir, "index.json"), indexData, 0o644); err != nil {
-			return err
-		}
-	} else if err != nil {
-		return err
-	}
-	// TO-DO: use https://github.com/google/renameio
-	return os.WriteFile(procPath, procData, 0o644)
-}
-
-// GetProcess retrieves a process from the js storage
-func (js *jsonStorage) GetProcess(pid []byte) (*Process, error) {
-	if len(pid) != types.ProcessIDsize {
-		return nil, fmt.Errorf("process not valid")
-	}
-	js.lock.Lock()
-	defer js.lock.Unlock()
-	data, err := os.ReadFile(filepath.Join(js.datadir, fmt.Sprintf("%x", pid)))
-	if err != nil {
-		return nil, err
-	}
-	p := &Process{}
-	return p, json.Unmarshal(data, p)
-}
-
-// ProcessExist returns true if a process already existin in the storage
-func (js *jsonStorage) ProcessExist(pid []byte) (bool, error) {
-	js.lock.Lock()
-	defer js.lock.Unlock()
-	if _, err := os.Stat(filepath.Join(js.datadir, fmt.Sprintf("%x", pid))); err != nil {
-		if os.IsNotExist(err) {
-			return false, nil
-		}
-		return false, err
-	}
-	return true, nil
-}
-
-// BuildIndex scans the archive directory and builds the JSON storage index
-func BuildIndex(datadir string) (*Index, error) {
-	i := &Index{
-		Entities: make(map[string][]*IndexProcess),
-	}
-	count := 0
-	if err := filepath.Walk(datadir, func(path string, info os.FileInfo, err error) error {
-		if len(info.Name()) == 64 {
-			content, err := os.ReadFile(path)
-			if err != nil {
-				return err
-			}
-			if content == nil {
-				log.Warnf("archive file %s is empty", path)
-				return nil
-			}
-			count++
-			p := &Process{}
-			if err := json.Unmarshal(content, p); err != nil {
-				return err
-			}
-			eid := fmt.Sprintf("%x", p.ProcessInfo.EntityID)
-			i.Entities[eid] = append(i.Entities[eid], &IndexProcess{ProcessID: p.ProcessInfo.ID})
-		}
-		return nil
-	}); err != nil {
-		return nil, err
-	}
-	indexData, err := json.MarshalIndent(i, " ", " ")
-	if err != nil {
-		return nil, err
-	}
-	log.Infof("archive index build with %d processes", count)
-	return i, os.WriteFile(filepath.Join(datadir, "index.json"), indexData, 0o644)
-}
-
 // NewProcessArchive creates a new instance of the process archiver.
 // It will subscribe to Vochain events and perform the process archival.
-// JSON files (one per process) will be stored within datadir.
+// The processes are stored as JSON files (one per process) in the storage.
 // The key parameter must be either a valid IPFS base64 encoded private key
 // or empty (a new key will be generated).
-// If ipfs is nil, only JSON archive storage will be performed.
+// If ipfs is nil or the storage is not a FSStorage, the archive is not
+// published via IPNS.
 func NewProcessArchive(s *indexer.Indexer, ipfs *data.IPFSHandle,
-	datadir, key string) (*ProcessArchive, error) {
-	js, err := NewJsonStorage(datadir)
-	if err != nil {
-		return nil, fmt.Errorf("could not create process archive: %w", err)
-	}
+	storage Storage, key string) (*ProcessArchive, error) {
 	ir := &ProcessArchive{
 		indexer: s,
 		ipfs:    ipfs,
-		storage: js,
+		storage: storage,
 		publish: make(chan (bool), 1),
 		close:   make(chan (bool), 1), // TO-DO: use a context
 	}
@@ -186,7 +65,8 @@ func NewProcessArchive(s *indexer.Indexer, ipfs *data.IPFSHandle,
 		return nil, err
 	}
 
-	if ipfs != nil {
+	if fs, ok := storage.(*FSStorage); ok && ipfs != nil {
+		ir.datadir = fs.Datadir
 		if err := ir.AddKey(key); err != nil {
 			return nil, err
 		}
@@ -279,7 +159,7 @@ func (pa *ProcessArchive) OnComputeResults(results *results.Results,
 	// Get the process (if exist)
 	jsProc, err := pa.storage.GetProcess(results.ProcessID)
 	if err != nil {
-		if os.IsNotExist(err) { // if it does not exist yet, we create it
+		if errors.Is(err, ErrProcessNotFound) { // if it does not exist yet, we create it
 			jsProc = &Process{
 				ProcessInfo: proc,
 				Results:     results,
@@ -312,7 +192,7 @@ func (pa *ProcessArchive) OnComputeResults(results *results.Results,
 func (pa *ProcessArchive) OnOracleResults(oracleResults *models.ProcessResult, pid []byte, height uint32) {
 	jsProc, err := pa.storage.GetProcess(pid)
 	if err != nil {
-		if os.IsNotExist(err) { // if it does not exist yet, we create it
+		if errors.Is(err, ErrProcessNotFound) { // if it does not exist yet, we create it
 			proc, err := pa.indexer.ProcessInfo(pid)
 			if err != nil {
 				log.Errorf("cannot get process info %x from indexer: %v", pid, err)
@@ -7,6 +7,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	qt "github.com/frankban/quicktest"
 	"go.vocdoni.io/dvote/test/testcommon/testutil"
+	"go.vocdoni.io/dvote/types"
 	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
 )
 
@@ -58,3 +59,49 @@ func TestJsonStorage(t *testing.T) {
 	qt.Assert(t, err, qt.IsNil)
 	qt.Assert(t, string(pr.ProcessInfo.EntityID), qt.DeepEquals, string(EID3))
 }
+
+func TestFSStorage(t *testing.T) {
+	PID1 := testutil.Hex2byte(t, "6265b1acebd3f5fe203bff097460112984edb3481a5e5ee0f16051f6c1e22098")
+	PID2 := testutil.Hex2byte(t, "fb05ff28e37bbdd0e51bc78838bbfa9b7aba46ec077d8dfed7fda2603801ce5b")
+	PID3 := testutil.Hex2byte(t, "c42549adc01f463317dcd24ee5267390f8a65eb2247890d98fc98445cc8f452f")
+	EID3 := common.BytesToAddress(PID3).Bytes()
+
+	dir := t.TempDir()
+	fs, err := NewFSStorage(dir)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, fs.Datadir, qt.Equals, dir)
+	for _, pid := range [][]byte{PID1, PID2, PID3} {
+		p := &Process{ProcessInfo: &indexertypes.Process{ID: pid, EntityID: common.BytesToAddress(pid).Bytes()}}
+		qt.Assert(t, fs.AddProcess(p), qt.IsNil)
+	}
+	testStorageList(t, fs, PID1, PID2, PID3, EID3)
+
+	// the index is rebuilt from the files when opening the storage again
+	fs2, err := NewFSStorage(dir)
+	qt.Assert(t, err, qt.IsNil)
+	testStorageList(t, fs2, PID1, PID2, PID3, EID3)
+}
+
+// testStorageList checks the lists and lookups of a storage holding the
+// three processes, each one created by a different entity.
+func testStorageList(t *testing.T, s Storage, pid1, pid2, pid3, eid3 []byte) {
+	pids, err := s.ProcessList(nil, 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	// sorted by id
+	qt.Assert(t, pids, qt.DeepEquals, []types.HexBytes{pid1, pid3, pid2})
+	pids, err = s.ProcessList(nil, 1, 1)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, pids, qt.DeepEquals, []types.HexBytes{pid3})
+	pids, err = s.ProcessList(nil, 3, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, pids, qt.HasLen, 0)
+	pids, err = s.ProcessList(eid3, 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, pids, qt.DeepEquals, []types.HexBytes{pid3})
+
+	info, err := s.ProcessInfo(pid2)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, []byte(info.ID), qt.DeepEquals, pid2)
+	_, err = s.GetProcess(make([]byte, types.ProcessIDsize))
+	qt.Assert(t, err, qt.Equals, ErrProcessNotFound)
+}
@@ -0,0 +1,379 @@
+package processarchive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// s3Timeout is the maximum duration of a request to the S3 endpoint.
+	s3Timeout = time.Minute
+	// s3IndexTTL is the time after which the index object is reloaded.
+	s3IndexTTL = time.Minute
+)
+
+// S3Config holds the parameters to access an S3-compatible bucket.
+type S3Config struct {
+	// Endpoint is the URL of the S3 service, such as https://s3.eu-west-1.amazonaws.com
+	// or http://localhost:9000 for a local MinIO.
+	Endpoint string
+	// Region is the region of the bucket, used to sign the requests.
+	Region string
+	// Bucket is the name of the bucket, accessed with path-style URLs.
+	Bucket string
+	// Prefix is prepended to the name of the archive objects, i.e. "archive/".
+	Prefix    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3Storage is a Storage keeping the archived processes as JSON objects in
+// an S3-compatible bucket, so several gateways can share the archive.
+type S3Storage struct {
+	*jsonStorage
+}
+
+// NewS3Storage opens the archive stored in the bucket. The index object is
+// loaded if it exists; otherwise it is built from the process objects. The
+// index is shared with the other nodes using the bucket, so it is reloaded
+// every s3IndexTTL.
+func NewS3Storage(cfg *S3Config) (*S3Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3 endpoint and bucket are required")
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	js := &jsonStorage{
+		files: &s3Files{
+			cfg:      cfg,
+			endpoint: endpoint,
+			client:   &http.Client{Timeout: s3Timeout},
+		},
+		indexTTL: s3IndexTTL,
+	}
+	found, err := js.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		if err := js.buildIndex(); err != nil {
+			return nil, err
+		}
+	}
+	return &S3Storage{jsonStorage: js}, nil
+}
+
+// s3Files is a fileStore on an S3 bucket, using the REST API with AWS
+// signature version 4.
+type s3Files struct {
+	cfg      *S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// listBucketResult is the response of ListObjectsV2.
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *s3Files) read(name string) ([]byte, error) {
+	resp, err := s.do(http.MethodGet, s.cfg.Prefix+name, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	if err := s3Error(resp); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (s *s3Files) write(name string, data []byte) error {
+	resp, err := s.do(http.MethodPut, s.cfg.Prefix+name, nil, data, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return s3Error(resp)
+}
+
+// readVersion returns the object with its ETag as version.
+func (s *s3Files) readVersion(name string) ([]byte, string, error) {
+	resp, err := s.do(http.MethodGet, s.cfg.Prefix+name, nil, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", nil
+	}
+	if err := s3Error(resp); err != nil {
+		return nil, "", err
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return nil, "", fmt.Errorf("S3 object %s has no ETag", name)
+	}
+	return data, etag, nil
+}
+
+// writeVersion puts the object with a conditional write, which S3 rejects
+// if the ETag of the object is not version anymore, or if the object already
+// exists when version is empty.
+// See https://docs.aws.amazon.com/AmazonS3/latest/userguide/conditional-writes.html
+func (s *s3Files) writeVersion(name string, data []byte, version string) error {
+	header := http.Header{}
+	if version == "" {
+		header.Set("If-None-Match", "*")
+	} else {
+		header.Set("If-Match", version)
+	}
+	resp, err := s.do(http.MethodPut, s.cfg.Prefix+name, nil, data, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// 409 is returned if a concurrent conditional write of the object
+	// succeeded first
+	if resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict {
+		return errVersionConflict
+	}
+	return s3Error(resp)
+}
+
+func (s *s3Files) exists(name string) (bool, error) {
+	resp, err := s.do(http.MethodHead, s.cfg.Prefix+name, nil, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return true, s3Error(resp)
+}
+
+func (s *s3Files) list() ([]string, error) {
+	names := []string{}
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.cfg.Prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		result := &listBucketResult{}
+		err = s3Error(resp)
+		if err == nil {
+			err = xml.NewDecoder(resp.Body).Decode(result)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot list S3 objects: %w", err)
+		}
+		for _, obj := range result.Contents {
+			name := strings.TrimPrefix(obj.Key, s.cfg.Prefix)
+			if name != "" && !strings.Contains(name, "/") {
+				names = append(names, name)
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return names, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// do sends a signed request for the object key of the bucket, with the
+// additional headers.
+func (s *s3Files) do(method, key string, query url.Values, body []byte, header http.Header) (*http.Response, error) {
+	u := *s.endpoint
+	u.Path = u.Path + "/" + s.cfg.Bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawQuery = query.Encode()
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body == nil {
+		req.Body = http.NoBody
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	signS3Request(req, body, s.cfg.Region, s.cfg.AccessKey, s.cfg.SecretKey, time.Now())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody cancels the request context once the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// s3Error returns an error if the response status is not successful.
+func s3Error(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("S3 request %s %s failed with status %d: %s",
+		resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, bytes.TrimSpace(msg))
+}
+
+// signS3Request adds the AWS signature version 4 to the request, signing the
+// host and all the headers of the request.
+// See https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func signS3Request(req *http.Request, body []byte, region, accessKey, secretKey string, now time.Time) {
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", now.UTC().Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	signV4(req, hex.EncodeToString(payloadHash[:]), "s3", region, accessKey, secretKey, now)
+}
+
+// amzDateFormat is the format of the request date in the AWS signature.
+const amzDateFormat = "20060102T150405Z"
+
+// signV4 sets the Authorization header of the request to the AWS signature
+// version 4 of the service, signing the host and all the headers of the
+// request. The date of the request, either in the X-Amz-Date or in the Date
+// header, must be already set to now.
+// As done by S3, the path of the request is not normalised, and its segments
+// are encoded only once.
+// See https://docs.aws.amazon.com/IAM/latest/UserGuide/create-signed-request.html
+func signV4(req *http.Request, payloadHash, service, region, accessKey, secretKey string, now time.Time) {
+	amzDate := now.UTC().Format(amzDateFormat)
+	date := amzDate[:8]
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		// the sequential spaces of the values are converted to a single one
+		headers[strings.ToLower(k)] = strings.Join(strings.Fields(strings.Join(v, ",")), " ")
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	// the parameters are sorted by encoded name and then by encoded value
+	pairs := [][2]string{}
+	for k, values := range req.URL.Query() {
+		for _, v := range values {
+			pairs = append(pairs, [2]string{awsURIEncode(k), awsURIEncode(v)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	params := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		params = append(params, p[0]+"="+p[1])
+	}
+	segments := strings.Split(req.URL.Path, "/")
+	for i, segment := range segments {
+		segments[i] = awsURIEncode(segment)
+	}
+	path := strings.Join(segments, "/")
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsURIEncode escapes the string as required by the AWS signature, which
+// only keeps the unreserved characters of RFC 3986.
+func awsURIEncode(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
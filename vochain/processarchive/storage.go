@@ -0,0 +1,303 @@
+package processarchive
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/vochain/indexer/indexertypes"
+)
+
+// ErrProcessNotFound is returned if the process is not archived.
+var ErrProcessNotFound = fmt.Errorf("process not found in the archive")
+
+// indexFile is the name of the archive file with the Index.
+const indexFile = "index.json"
+
+// indexWriteAttempts is the number of times the index is merged and written
+// again if another node changed it meanwhile.
+const indexWriteAttempts = 10
+
+// errVersionConflict is returned by fileStore.writeVersion if the file
+// changed since its version was read.
+var errVersionConflict = fmt.Errorf("archive file changed meanwhile")
+
+// Storage is the backend where the archived processes are stored.
+type Storage interface {
+	// AddProcess stores the process, replacing it if already archived.
+	AddProcess(p *Process) error
+	// GetProcess returns the archived process, or ErrProcessNotFound.
+	GetProcess(pid []byte) (*Process, error)
+	// ProcessExist returns true if the process is archived.
+	ProcessExist(pid []byte) (bool, error)
+	// ProcessList returns the ids of the archived processes, sorted and
+	// paginated by from and max. If entityID is not nil, only the processes
+	// created by the entity are returned, in the order they were archived.
+	ProcessList(entityID []byte, from, max int) ([]types.HexBytes, error)
+	// ProcessInfo returns the indexer information of the archived process,
+	// or ErrProcessNotFound.
+	ProcessInfo(pid []byte) (*indexertypes.Process, error)
+}
+
+// fileStore holds the archive files by name. Each process is stored as a
+// JSON file named after its hexadecimal id, along with the index file.
+type fileStore interface {
+	// read returns the file contents, or an error wrapping os.ErrNotExist.
+	read(name string) ([]byte, error)
+	write(name string, data []byte) error
+	exists(name string) (bool, error)
+	// list returns the names of all the files.
+	list() ([]string, error)
+	// readVersion returns the file contents along with its version, which
+	// is empty if the file does not exist.
+	readVersion(name string) ([]byte, string, error)
+	// writeVersion writes the file only if its version did not change, or if
+	// it still does not exist when version is empty. Otherwise, it returns
+	// errVersionConflict.
+	writeVersion(name string, data []byte, version string) error
+}
+
+// jsonStorage implements Storage on a fileStore, keeping an index of the
+// processes of each entity. The index may be shared by several nodes, so it
+// is merged with the stored one on each update and, if indexTTL is not zero,
+// reloaded when older than indexTTL.
+type jsonStorage struct {
+	files     fileStore
+	lock      sync.RWMutex
+	index     *Index
+	indexTime time.Time
+	indexTTL  time.Duration
+}
+
+// AddProcess adds an entire process to js
+func (js *jsonStorage) AddProcess(p *Process) error {
+	if p == nil || p.ProcessInfo == nil || len(p.ProcessInfo.ID) != types.ProcessIDsize {
+		return fmt.Errorf("process not valid")
+	}
+	procData, err := json.MarshalIndent(p, " ", " ")
+	if err != nil {
+		return err
+	}
+	js.lock.Lock()
+	defer js.lock.Unlock()
+	if err := js.files.write(fmt.Sprintf("%x", p.ProcessInfo.ID), procData); err != nil {
+		return err
+	}
+	// the process is added to the index once its file is stored, so the
+	// index never holds a process which can't be retrieved
+	eid := fmt.Sprintf("%x", p.ProcessInfo.EntityID)
+	if js.index.contains(eid, p.ProcessInfo.ID) {
+		return nil
+	}
+	return js.addToIndex(eid, p.ProcessInfo.ID)
+}
+
+// GetProcess retrieves a process from the js storage
+func (js *jsonStorage) GetProcess(pid []byte) (*Process, error) {
+	if len(pid) != types.ProcessIDsize {
+		return nil, fmt.Errorf("process not valid")
+	}
+	js.lock.RLock()
+	defer js.lock.RUnlock()
+	data, err := js.files.read(fmt.Sprintf("%x", pid))
+	if err != nil {
+		if isNotExist(err) {
+			return nil, ErrProcessNotFound
+		}
+		return nil, err
+	}
+	p := &Process{}
+	return p, json.Unmarshal(data, p)
+}
+
+// ProcessExist returns true if a process already existin in the storage
+func (js *jsonStorage) ProcessExist(pid []byte) (bool, error) {
+	js.lock.RLock()
+	defer js.lock.RUnlock()
+	return js.files.exists(fmt.Sprintf("%x", pid))
+}
+
+// ProcessList returns the ids of the archived processes. See Storage.
+func (js *jsonStorage) ProcessList(entityID []byte, from, max int) ([]types.HexBytes, error) {
+	if from < 0 || max < 0 {
+		return nil, fmt.Errorf("invalid pagination: from %d max %d", from, max)
+	}
+	if err := js.refreshIndex(); err != nil {
+		return nil, err
+	}
+	js.lock.RLock()
+	defer js.lock.RUnlock()
+	pids := []types.HexBytes{}
+	if entityID != nil {
+		for _, p := range js.index.Entities[fmt.Sprintf("%x", entityID)] {
+			pids = append(pids, p.ProcessID)
+		}
+	} else {
+		for _, procs := range js.index.Entities {
+			for _, p := range procs {
+				pids = append(pids, p.ProcessID)
+			}
+		}
+		sort.Slice(pids, func(i, j int) bool { return pids[i].String() < pids[j].String() })
+	}
+	if from >= len(pids) {
+		return []types.HexBytes{}, nil
+	}
+	pids = pids[from:]
+	if len(pids) > max {
+		pids = pids[:max]
+	}
+	return pids, nil
+}
+
+// ProcessInfo returns the indexer information of the archived process.
+func (js *jsonStorage) ProcessInfo(pid []byte) (*indexertypes.Process, error) {
+	p, err := js.GetProcess(pid)
+	if err != nil {
+		return nil, err
+	}
+	if p.ProcessInfo == nil {
+		return nil, ErrProcessNotFound
+	}
+	return p.ProcessInfo, nil
+}
+
+func (js *jsonStorage) writeIndex() error {
+	indexData, err := json.MarshalIndent(js.index, " ", " ")
+	if err != nil {
+		return err
+	}
+	return js.files.write(indexFile, indexData)
+}
+
+// addToIndex adds the process to the stored index, which is read again and
+// written only if no other node changed it meanwhile, so the processes added
+// by the other nodes sharing the archive are not lost. The merged index
+// replaces the one in memory.
+func (js *jsonStorage) addToIndex(eid string, pid types.HexBytes) error {
+	for i := 0; i < indexWriteAttempts; i++ {
+		data, version, err := js.files.readVersion(indexFile)
+		if err != nil {
+			return err
+		}
+		index := &Index{Entities: make(map[string][]*IndexProcess)}
+		if data != nil {
+			if err := json.Unmarshal(data, index); err != nil {
+				return fmt.Errorf("cannot decode archive index: %w", err)
+			}
+			if index.Entities == nil {
+				index.Entities = make(map[string][]*IndexProcess)
+			}
+		}
+		if !index.contains(eid, pid) {
+			index.Entities[eid] = append(index.Entities[eid], &IndexProcess{ProcessID: pid})
+		}
+		indexData, err := json.MarshalIndent(index, " ", " ")
+		if err != nil {
+			return err
+		}
+		err = js.files.writeVersion(indexFile, indexData, version)
+		if errors.Is(err, errVersionConflict) {
+			log.Debugf("archive index changed meanwhile, merging it again")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		js.index = index
+		js.indexTime = time.Now()
+		return nil
+	}
+	return fmt.Errorf("cannot update archive index: %w", errVersionConflict)
+}
+
+// refreshIndex reloads the index if it is older than indexTTL, to get the
+// processes added by the other nodes sharing the archive.
+func (js *jsonStorage) refreshIndex() error {
+	js.lock.Lock()
+	defer js.lock.Unlock()
+	if js.indexTTL == 0 || time.Since(js.indexTime) < js.indexTTL {
+		return nil
+	}
+	_, err := js.loadIndex()
+	return err
+}
+
+// loadIndex reads the index file, returning false if it does not exist.
+func (js *jsonStorage) loadIndex() (bool, error) {
+	data, err := js.files.read(indexFile)
+	if err != nil {
+		if isNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	i := &Index{}
+	if err := json.Unmarshal(data, i); err != nil {
+		return false, fmt.Errorf("cannot decode archive index: %w", err)
+	}
+	if i.Entities == nil {
+		i.Entities = make(map[string][]*IndexProcess)
+	}
+	js.index = i
+	js.indexTime = time.Now()
+	return true, nil
+}
+
+// buildIndex reads all the archived processes and writes the index.
+func (js *jsonStorage) buildIndex() error {
+	i := &Index{
+		Entities: make(map[string][]*IndexProcess),
+	}
+	names, err := js.files.list()
+	if err != nil {
+		return err
+	}
+	count := 0
+	for _, name := range names {
+		if len(name) != types.ProcessIDsize*2 {
+			continue
+		}
+		content, err := js.files.read(name)
+		if err != nil {
+			return err
+		}
+		if len(content) == 0 {
+			log.Warnf("archive file %s is empty", name)
+			continue
+		}
+		count++
+		p := &Process{}
+		if err := json.Unmarshal(content, p); err != nil {
+			return err
+		}
+		eid := fmt.Sprintf("%x", p.ProcessInfo.EntityID)
+		i.Entities[eid] = append(i.Entities[eid], &IndexProcess{ProcessID: p.ProcessInfo.ID})
+	}
+	log.Infof("archive index build with %d processes", count)
+	js.index = i
+	js.indexTime = time.Now()
+	return js.writeIndex()
+}
+
+// contains returns true if the process of the entity is in the index.
+func (i *Index) contains(eid string, pid types.HexBytes) bool {
+	for _, p := range i.Entities[eid] {
+		if bytes.Equal(p.ProcessID, pid) {
+			return true
+		}
+	}
+	return false
+}
+
+// isNotExist returns true if the error means the file does not exist.
+func isNotExist(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
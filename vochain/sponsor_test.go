@@ -0,0 +1,236 @@
+package vochain
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain/state"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
+	"go.vocdoni.io/proto/build/go/models"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSponsoredTx(t *testing.T) {
+	app, signers, err := setupTestBaseApplicationAndSigners(t, 2)
+	qt.Assert(t, err, qt.IsNil)
+	sponsor, user := signers[0], signers[1]
+	qt.Assert(t, app.State.SetAccount(sponsor.Address(), &state.Account{
+		Account: models.Account{Balance: 2000},
+	}), qt.IsNil)
+	qt.Assert(t, app.State.SetTxCost(models.TxType_SET_PROCESS_STATUS, 100), qt.IsNil)
+	qt.Assert(t, app.State.SetTxCost(models.TxType_SET_SPONSOR_LIMITS, 100), qt.IsNil)
+	app.Commit()
+
+	// the sponsor pays the account creation, without a faucet package
+	qt.Assert(t, testSponsoredSetAccountTx(t, app, user, sponsor, nil, 0, true), qt.IsNil)
+	userAcc, err := app.State.GetAccount(user.Address(), false)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, userAcc, qt.IsNotNil)
+	qt.Assert(t, userAcc.Balance, qt.Equals, uint64(0))
+	testCheckBalance(t, app, sponsor, 2000-100, 0)
+
+	// and the transactions of the user, whose nonce is incremented
+	qt.Assert(t, testSponsoredSetAccountTx(t, app, user, sponsor, nil, 0, false), qt.IsNil)
+	testCheckBalance(t, app, sponsor, 1800, 0)
+	testCheckBalance(t, app, user, 0, 1)
+
+	// without the sponsor, the user cannot pay
+	qt.Assert(t, testSponsoredSetAccountTx(t, app, user, nil, nil, 1, false), qt.IsNotNil)
+
+	// a limit of 250 for the user and one of 100 for an election of the user,
+	// each one paid by the sponsor
+	censusURI := ipfsUrl
+	electionID := util.RandomBytes(types.ProcessIDsize)
+	qt.Assert(t, app.State.AddProcess(&models.Process{
+		ProcessId:     electionID,
+		StartBlock:    0,
+		EnvelopeType:  &models.EnvelopeType{EncryptedVotes: false},
+		Mode:          &models.ProcessMode{Interruptible: true},
+		VoteOptions:   &models.ProcessVoteOptions{MaxCount: 16, MaxValue: 16},
+		Status:        models.ProcessStatus_READY,
+		EntityId:      user.Address().Bytes(),
+		CensusRoot:    util.RandomBytes(32),
+		CensusURI:     &censusURI,
+		CensusOrigin:  models.CensusOrigin_OFF_CHAIN_TREE,
+		BlockCount:    1024,
+		MaxCensusSize: 100,
+	}), qt.IsNil)
+	qt.Assert(t, testSetSponsorLimitsTx(t, app, sponsor, user.Address().Bytes(), 250, 0), qt.IsNil)
+	qt.Assert(t, testSetSponsorLimitsTx(t, app, sponsor, electionID, 100, 1), qt.IsNil)
+	testCheckBalance(t, app, sponsor, 1600, 2)
+	limit, err := app.State.SponsorLimit(sponsor.Address(), user.Address().Bytes(), true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, limit, qt.DeepEquals, &state.SponsorLimit{Limit: 250})
+	scopes, err := app.State.SponsorScopes(sponsor.Address(), true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, scopes, qt.Equals, uint32(2))
+
+	// the election limit only applies to the transactions of the election
+	qt.Assert(t, testSponsoredSetAccountTx(t, app, user, sponsor, electionID, 1, false), qt.ErrorMatches,
+		".*does not match the transaction election.*")
+	status := models.ProcessStatus_PAUSED
+	qt.Assert(t, testSponsoredSetProcessStatusTx(t, app, user, sponsor, electionID, electionID, status, 1), qt.IsNil)
+	qt.Assert(t, testSponsoredSetProcessStatusTx(t, app, user, sponsor, util.RandomBytes(types.ProcessIDsize),
+		electionID, models.ProcessStatus_READY, 2), qt.ErrorMatches, ".*does not match the transaction election.*")
+	// the election limit is reached
+	qt.Assert(t, testSponsoredSetProcessStatusTx(t, app, user, sponsor, electionID, electionID,
+		models.ProcessStatus_READY, 2), qt.ErrorMatches, ".*sponsor spending limit reached.*")
+	qt.Assert(t, testSponsoredSetAccountTx(t, app, user, sponsor, nil, 2, false), qt.IsNil)
+	// and then the account limit
+	qt.Assert(t, testSponsoredSetAccountTx(t, app, user, sponsor, nil, 3, false), qt.ErrorMatches,
+		".*sponsor spending limit reached.*")
+	limit, err = app.State.SponsorLimit(sponsor.Address(), user.Address().Bytes(), true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, limit, qt.DeepEquals, &state.SponsorLimit{Limit: 250, Spent: 200})
+	limit, err = app.State.SponsorLimit(sponsor.Address(), electionID, true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, limit, qt.DeepEquals, &state.SponsorLimit{Limit: 100, Spent: 100})
+	testCheckBalance(t, app, sponsor, 1400, 2)
+	testCheckBalance(t, app, user, 0, 3)
+
+	// raising the limit keeps the amount spent
+	qt.Assert(t, testSetSponsorLimitsTx(t, app, sponsor, user.Address().Bytes(), 300, 2), qt.IsNil)
+	qt.Assert(t, testSponsoredSetAccountTx(t, app, user, sponsor, nil, 3, false), qt.IsNil)
+	qt.Assert(t, testSponsoredSetAccountTx(t, app, user, sponsor, nil, 4, false), qt.IsNotNil)
+	testCheckBalance(t, app, sponsor, 1200, 3)
+
+	// removing the election limit frees its scope
+	qt.Assert(t, testRemoveSponsorLimitTx(t, app, sponsor, electionID, 3), qt.IsNil)
+	limit, err = app.State.SponsorLimit(sponsor.Address(), electionID, true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, limit, qt.IsNil)
+	scopes, err = app.State.SponsorScopes(sponsor.Address(), true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, scopes, qt.Equals, uint32(1))
+	testCheckBalance(t, app, sponsor, 1100, 4)
+	// and a missing limit cannot be removed
+	qt.Assert(t, testRemoveSponsorLimitTx(t, app, sponsor, electionID, 4), qt.ErrorMatches,
+		".*sponsor spending limit not found.*")
+
+	// the sponsor pays the limits like any other transaction
+	qt.Assert(t, app.State.SetAccount(sponsor.Address(), &state.Account{
+		Account: models.Account{Balance: 50, Nonce: 4},
+	}), qt.IsNil)
+	app.Commit()
+	qt.Assert(t, testSetSponsorLimitsTx(t, app, sponsor, electionID, 100, 4), qt.ErrorMatches,
+		".*not enough balance.*")
+
+	// the faucet transactions cannot be sponsored
+	faucetPkg, err := GenerateFaucetPackage(sponsor, user.Address(), 10)
+	qt.Assert(t, err, qt.IsNil)
+	stx := &models.SignedTx{}
+	stx.Tx, err = proto.Marshal(&models.Tx{Payload: &models.Tx_CollectFaucet{
+		CollectFaucet: &models.CollectFaucetTx{FaucetPackage: faucetPkg, Nonce: 4},
+	}})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, vochaintx.SponsorSignedTx(stx, sponsor, nil, app.chainID), qt.IsNil)
+	qt.Assert(t, sendTx(app, user, stx), qt.ErrorMatches, ".*cannot be sponsored.*")
+}
+
+func TestSponsorScopesLimit(t *testing.T) {
+	app, signers, err := setupTestBaseApplicationAndSigners(t, 1)
+	qt.Assert(t, err, qt.IsNil)
+	sponsor := signers[0].Address()
+	for i := 0; i < state.MaxSponsorScopes; i++ {
+		qt.Assert(t, app.State.CheckSetSponsorLimit(sponsor, util.RandomBytes(20), false), qt.IsNil)
+		qt.Assert(t, app.State.SetSponsorLimit(sponsor, util.RandomBytes(20), 100), qt.IsNil)
+	}
+	// a new scope is not allowed, but the limits can still be updated
+	scope := util.RandomBytes(20)
+	qt.Assert(t, app.State.CheckSetSponsorLimit(sponsor, scope, false), qt.ErrorIs, state.ErrTooManySponsorScopes)
+	qt.Assert(t, app.State.SetSponsorLimit(sponsor, scope, 100), qt.ErrorIs, state.ErrTooManySponsorScopes)
+}
+
+func testSponsoredSetAccountTx(t *testing.T, app *BaseApplication, signer, sponsor *ethereum.SignKeys,
+	electionID []byte, nonce uint32, create bool) error {
+	infoURI := "ipfs://" + util.RandomHex(16)
+	tx := &models.SetAccountTx{
+		Nonce:   &nonce,
+		Txtype:  models.TxType_SET_ACCOUNT_INFO_URI,
+		InfoURI: &infoURI,
+	}
+	if create {
+		tx.Txtype = models.TxType_CREATE_ACCOUNT
+	}
+	var err error
+	stx := &models.SignedTx{}
+	if stx.Tx, err = proto.Marshal(&models.Tx{Payload: &models.Tx_SetAccount{SetAccount: tx}}); err != nil {
+		t.Fatal(err)
+	}
+	if sponsor != nil {
+		if err := vochaintx.SponsorSignedTx(stx, sponsor, electionID, app.chainID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sendTx(app, signer, stx); err != nil {
+		return err
+	}
+	app.Commit()
+	return nil
+}
+
+func testSponsoredSetProcessStatusTx(t *testing.T, app *BaseApplication, signer, sponsor *ethereum.SignKeys,
+	sponsoredID, electionID []byte, status models.ProcessStatus, nonce uint32) error {
+	tx := &models.SetProcessTx{
+		Txtype:    models.TxType_SET_PROCESS_STATUS,
+		Nonce:     nonce,
+		ProcessId: electionID,
+		Status:    &status,
+	}
+	var err error
+	stx := &models.SignedTx{}
+	if stx.Tx, err = proto.Marshal(&models.Tx{Payload: &models.Tx_SetProcess{SetProcess: tx}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := vochaintx.SponsorSignedTx(stx, sponsor, sponsoredID, app.chainID); err != nil {
+		t.Fatal(err)
+	}
+	if err := sendTx(app, signer, stx); err != nil {
+		return err
+	}
+	app.Commit()
+	return nil
+}
+
+func testSetSponsorLimitsTx(t *testing.T, app *BaseApplication, sponsor *ethereum.SignKeys,
+	scope []byte, limit uint64, nonce uint32) error {
+	return testSendSponsorLimitsTx(t, app, sponsor, scope, &models.SetSponsorLimitsTx{Nonce: nonce, Limit: limit})
+}
+
+func testRemoveSponsorLimitTx(t *testing.T, app *BaseApplication, sponsor *ethereum.SignKeys,
+	scope []byte, nonce uint32) error {
+	return testSendSponsorLimitsTx(t, app, sponsor, scope, &models.SetSponsorLimitsTx{Nonce: nonce, Remove: true})
+}
+
+func testSendSponsorLimitsTx(t *testing.T, app *BaseApplication, sponsor *ethereum.SignKeys,
+	scope []byte, payload *models.SetSponsorLimitsTx) error {
+	payload.Txtype = models.TxType_SET_SPONSOR_LIMITS
+	if len(scope) == types.ProcessIDsize {
+		payload.ElectionId = scope
+	} else {
+		payload.Account = scope
+	}
+	var err error
+	stx := &models.SignedTx{}
+	if stx.Tx, err = proto.Marshal(&models.Tx{
+		Payload: &models.Tx_SetSponsorLimits{SetSponsorLimits: payload},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sendTx(app, sponsor, stx); err != nil {
+		return err
+	}
+	app.Commit()
+	return nil
+}
+
+func testCheckBalance(t *testing.T, app *BaseApplication, signer *ethereum.SignKeys, balance uint64, nonce uint32) {
+	acc, err := app.State.GetAccount(signer.Address(), false)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, acc, qt.IsNotNil)
+	qt.Assert(t, acc.Balance, qt.Equals, balance)
+	qt.Assert(t, acc.Nonce, qt.Equals, nonce)
+}
@@ -194,7 +194,7 @@ func newTendermint(app *BaseApplication,
 	tconfig.TxIndex = &tmcfg.TxIndexConfig{Indexer: []string{"null"}}
 	// mempool config
 	tconfig.Mempool.Size = localConfig.MempoolSize
-	tconfig.Mempool.Recheck = false
+	tconfig.Mempool.Recheck = localConfig.MempoolRecheck
 	tconfig.Mempool.KeepInvalidTxsInCache = false
 	tconfig.Mempool.MaxTxBytes = 1024 * 100 // 100 KiB
 	tconfig.Mempool.MaxTxsBytes = int64(tconfig.Mempool.Size * tconfig.Mempool.MaxTxBytes)
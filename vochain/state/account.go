@@ -10,6 +10,7 @@ import (
 	"go.vocdoni.io/dvote/log"
 	"go.vocdoni.io/dvote/tree/arbo"
 	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
 	"go.vocdoni.io/proto/build/go/models"
 	"google.golang.org/protobuf/proto"
 )
@@ -88,11 +89,16 @@ func (a *Account) DelDelegate(addr common.Address) error {
 // Returns a nil account and no error if the account does not exist.
 // Committed is relative to the state on which the function is executed.
 func (v *State) GetAccount(address common.Address, committed bool) (*Account, error) {
-	var acc Account
 	if !committed {
 		v.Tx.RLock()
 		defer v.Tx.RUnlock()
 	}
+	return v.account(address, committed)
+}
+
+// account is GetAccount without locking v.Tx.
+func (v *State) account(address common.Address, committed bool) (*Account, error) {
+	var acc Account
 	raw, err := v.mainTreeViewer(committed).DeepGet(address.Bytes(), StateTreeCfg(TreeAccounts))
 	if errors.Is(err, arbo.ErrKeyNotFound) {
 		return nil, nil
@@ -192,6 +198,13 @@ func (v *State) CreateAccount(accountAddress common.Address, infoURI string, del
 
 // SetAccount sets the given account data to the state
 func (v *State) SetAccount(accountAddress common.Address, account *Account) error {
+	v.Tx.Lock()
+	defer v.Tx.Unlock()
+	return v.setAccount(accountAddress, account)
+}
+
+// setAccount is SetAccount without locking v.Tx.
+func (v *State) setAccount(accountAddress common.Address, account *Account) error {
 	accBytes, err := proto.Marshal(account)
 	if err != nil {
 		return err
@@ -215,18 +228,23 @@ func (v *State) SetAccount(accountAddress common.Address, account *Account) erro
 			},
 		})
 	}
-	v.Tx.Lock()
-	defer v.Tx.Unlock()
 	return v.Tx.DeepSet(accountAddress.Bytes(), accBytes, StateTreeCfg(TreeAccounts))
 }
 
-// BurnTxCostIncrementNonce reduces the transaction cost from the account balance and increments nonce
-func (v *State) BurnTxCostIncrementNonce(accountAddress common.Address, txType models.TxType) error {
+// BurnTxCostIncrementNonce reduces the transaction cost from the account balance and increments nonce.
+// If the transaction is sponsored, the cost is reduced from the sponsor balance instead.
+func (v *State) BurnTxCostIncrementNonce(accountAddress common.Address, txType models.TxType,
+	sponsor *vochaintx.Sponsorship) error {
 	// get tx cost
 	cost, err := v.TxCost(txType, false)
 	if err != nil {
 		return fmt.Errorf("burnTxCostIncrementNonce: %w", err)
 	}
+	if sponsor != nil {
+		if err := v.BurnSponsoredTxCost(sponsor, accountAddress, cost); err != nil {
+			return fmt.Errorf("burnTxCostIncrementNonce: %w", err)
+		}
+	}
 	// get account
 	acc, err := v.GetAccount(accountAddress, false)
 	if err != nil {
@@ -235,7 +253,7 @@ func (v *State) BurnTxCostIncrementNonce(accountAddress common.Address, txType m
 	if acc == nil {
 		return ErrAccountNotExist
 	}
-	if cost != 0 {
+	if cost != 0 && sponsor == nil {
 		// send cost to burn address
 		burnAcc, err := v.GetAccount(BurnAddress, false)
 		if err != nil {
@@ -34,6 +34,8 @@ var (
 		models.TxType_ADD_DELEGATE_FOR_ACCOUNT:   "c_addDelegateForAccount",
 		models.TxType_DEL_DELEGATE_FOR_ACCOUNT:   "c_delDelegateForAccount",
 		models.TxType_COLLECT_FAUCET:             "c_collectFaucet",
+		models.TxType_SET_SPONSOR_LIMITS:         "c_setSponsorLimits",
+		models.TxType_SEND_VESTING_TOKENS:        "c_sendVestingTokens",
 	}
 	ErrTxCostNotFound = fmt.Errorf("transaction cost is not set")
 )
@@ -0,0 +1,64 @@
+package state
+
+import (
+	"fmt"
+
+	"go.vocdoni.io/dvote/db"
+)
+
+// Checkpoint is an in-memory copy of the committed state database, including
+// the data that is not part of the state trees. It is meant to be restored
+// with RestoreCheckpoint on the same State, so it is only suitable for small
+// states such as the ones of test chains.
+type Checkpoint struct {
+	version uint32
+	data    []db.KeyValue
+}
+
+// Version returns the state version (height) of the checkpoint.
+func (c *Checkpoint) Version() uint32 {
+	return c.version
+}
+
+// Checkpoint creates a copy of the last committed state. The changes of the
+// current (not saved) transaction are not included.
+func (v *State) Checkpoint() (*Checkpoint, error) {
+	v.Tx.RLock()
+	defer v.Tx.RUnlock()
+	version, err := v.Store.Version()
+	if err != nil {
+		return nil, err
+	}
+	data, err := db.ReadAll(v.db)
+	if err != nil {
+		return nil, fmt.Errorf("cannot copy state database: %w", err)
+	}
+	return &Checkpoint{version: version, data: data}, nil
+}
+
+// RestoreCheckpoint rollbacks the state to the given checkpoint. The current
+// transaction is discarded and the event listeners are notified with Rollback.
+func (v *State) RestoreCheckpoint(c *Checkpoint) error {
+	for _, l := range v.eventListeners {
+		l.Rollback()
+	}
+	v.Tx.Lock()
+	defer v.Tx.Unlock()
+	v.Tx.Discard()
+	if err := db.ReplaceAll(v.db, c.data); err != nil {
+		return fmt.Errorf("cannot restore state database: %w", err)
+	}
+	var err error
+	if v.Tx.TreeTx, err = v.Store.BeginTx(); err != nil {
+		return fmt.Errorf("cannot begin statedb tx: %w", err)
+	}
+	mainTreeView, err := v.Store.TreeView(nil)
+	if err != nil {
+		return fmt.Errorf("cannot get statedb mainTreeView: %w", err)
+	}
+	v.setMainTreeView(mainTreeView)
+	v.voteCache.Purge()
+	v.txCounter.Store(0)
+	v.SetHeight(c.version)
+	return nil
+}
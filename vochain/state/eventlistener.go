@@ -29,6 +29,7 @@ type EventListener interface {
 	OnProcessesStart(pids [][]byte)
 	OnSetAccount(addr []byte, account *Account)
 	OnTransferTokens(tx *vochaintx.TokenTransfer)
+	OnTokenVesting(v *vochaintx.TokenVesting)
 	Commit(height uint32) (err error)
 	Rollback()
 }
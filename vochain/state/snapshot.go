@@ -276,11 +276,29 @@ func (s *StateSnapshot) Write(b []byte) (int, error) {
 // Snapshot performs a snapshot of the last committed state for all trees.
 // The snapshot is stored in disk and the file path is returned.
 func (v *State) Snapshot() (string, error) {
-	t := v.MainTreeView()
 	height, err := v.LastHeight()
 	if err != nil {
 		return "", err
 	}
+	return v.snapshot(v.MainTreeView(), height)
+}
+
+// SnapshotAt performs a snapshot of the state committed at the given height,
+// which must be still available in the database.
+// The snapshot is stored in disk and the file path is returned.
+func (v *State) SnapshotAt(height uint32) (string, error) {
+	root, err := v.Store.VersionRoot(height)
+	if err != nil {
+		return "", fmt.Errorf("cannot get the state root at height %d: %w", height, err)
+	}
+	t, err := v.Store.TreeView(root)
+	if err != nil {
+		return "", err
+	}
+	return v.snapshot(t, height)
+}
+
+func (v *State) snapshot(t statedb.TreeViewer, height uint32) (string, error) {
 	root, err := t.Root()
 	if err != nil {
 		return "", err
@@ -320,13 +338,17 @@ func (v *State) Snapshot() (string, error) {
 	}
 
 	// dump main tree
-	if err := dumpTree("Main", "", v.mainTreeViewer(true)); err != nil {
+	if err := dumpTree("Main", "", t); err != nil {
 		return "", err
 	}
 
 	// dump main subtrees
 	for k := range MainTrees {
-		t, err := v.mainTreeViewer(true).SubTree(StateTreeCfg(k))
+		t, err := t.SubTree(StateTreeCfg(k))
+		if errors.Is(err, statedb.ErrEmptyTree) {
+			// empty trees are not dumped, they are empty on a new state
+			continue
+		}
 		if err != nil {
 			return "", err
 		}
@@ -336,18 +358,21 @@ func (v *State) Snapshot() (string, error) {
 	}
 
 	// dump child trees that depend on process
-	pids, err := v.ListProcessIDs(true)
+	processTree, err := t.SubTree(StateTreeCfg(TreeProcess))
 	if err != nil {
+		return "", fmt.Errorf("cannot load process tree: %w", err)
+	}
+	var pids [][]byte
+	if err := processTree.Iterate(func(key []byte, value []byte) bool {
+		pids = append(pids, bytes.Clone(key))
+		return false
+	}); err != nil {
 		return "", err
 	}
 	log.Debugf("found %d processes", len(pids))
 	for name := range ChildTrees {
 		for _, p := range pids {
 			childTreeCfg := StateChildTreeCfg(name)
-			processTree, err := v.mainTreeViewer(true).SubTree(StateTreeCfg(TreeProcess))
-			if err != nil {
-				return "", fmt.Errorf("cannot load process tree: %w", err)
-			}
 			childTree, err := processTree.SubTree(childTreeCfg.WithKey(p))
 			if err != nil {
 				// key might not exist (i.e process does not have census)
@@ -366,22 +391,117 @@ func (v *State) Snapshot() (string, error) {
 	return snap.Path(), snap.Save()
 }
 
-/*
-// TODO install Snapshot
-func (v *State) installSnapshot(height uint32) error {
+// childTreeRoots contains the functions to get the root of each child tree
+// from a process leaf.
+var childTreeRoots = map[string]func(value []byte) ([]byte, error){
+	ChildTreeCensus:                processGetCensusRoot,
+	ChildTreeCensusPoseidon:        processGetCensusRoot,
+	ChildTreePreRegisterNullifiers: processGetPreRegisterNullifiersRoot,
+	ChildTreeVotes:                 processGetVotesRoot,
+}
+
+// InstallSnapshot replaces the state trees with the ones of the snapshot
+// file, and commits them as the state version of the snapshot height. The
+// state must not have been modified yet, since the snapshot trees are imported
+// over the existing ones. The auxiliary data that is not part of the state
+// hash, such as the global vote count, is not included in the snapshot.
+// The header of the snapshot is returned.
+func (v *State) InstallSnapshot(filePath string) (*SnapshotHeader, error) {
 	var snap StateSnapshot
-	if err := snap.Open(filepath.Join(
-		v.dataDir,
-		storageDirectory,
-		snapshotsDirectory,
-		fmt.Sprintf("%d", height),
-	)); err != nil {
-		return err
+	if err := snap.Open(filePath); err != nil {
+		return nil, err
+	}
+	defer snap.file.Close()
+	header := snap.Header()
+	log.Infow("installing state snapshot", "height", header.Height,
+		"chainID", header.ChainID, "root", fmt.Sprintf("%x", header.Root))
+
+	v.Tx.Lock()
+	defer v.Tx.Unlock()
+	// childTrees holds the processes of each child tree, by child tree root
+	childTrees := make(map[string][][]byte)
+	for i := range header.Trees {
+		if i > 0 {
+			if err := snap.FetchNextTree(); err != nil {
+				return nil, err
+			}
+		}
+		tree := snap.TreeHeader()
+		switch {
+		case tree.Name == "Main" && tree.Parent == "":
+			if err := v.Tx.Import(&snap); err != nil {
+				return nil, fmt.Errorf("cannot import main tree: %w", err)
+			}
+		case tree.Parent == "":
+			if err := v.Tx.ImportSubTree(StateTreeCfg(tree.Name), &snap); err != nil {
+				return nil, fmt.Errorf("cannot import tree %s: %w", tree.Name, err)
+			}
+			if tree.Name != TreeProcess {
+				continue
+			}
+			// find the processes of each child tree, to import them
+			processes, err := v.Tx.SubTree(StateTreeCfg(TreeProcess))
+			if err != nil {
+				return nil, err
+			}
+			if err := processes.Iterate(func(pid, value []byte) bool {
+				for name, getRoot := range childTreeRoots {
+					if root, err := getRoot(value); err == nil {
+						key := name + string(root)
+						childTrees[key] = append(childTrees[key], bytes.Clone(pid))
+					}
+				}
+				return false
+			}); err != nil {
+				return nil, err
+			}
+		case tree.Parent == TreeProcess:
+			processes, err := v.Tx.SubTree(StateTreeCfg(TreeProcess))
+			if err != nil {
+				return nil, err
+			}
+			dump, err := io.ReadAll(&snap)
+			if err != nil {
+				return nil, err
+			}
+			// processes with the same child tree root have the same leaves
+			for _, pid := range childTrees[tree.Name+string(tree.Root)] {
+				if err := processes.ImportSubTree(StateChildTreeCfg(tree.Name).WithKey(pid),
+					bytes.NewReader(dump)); err != nil {
+					// the census trees share the root of the process leaf,
+					// so only one of them can be imported
+					if tree.Name == ChildTreeCensus || tree.Name == ChildTreeCensusPoseidon {
+						continue
+					}
+					return nil, fmt.Errorf("cannot import tree %s of process %x: %w", tree.Name, pid, err)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unknown snapshot tree %s with parent %s", tree.Name, tree.Parent)
+		}
+	}
+
+	root, err := v.Tx.Root()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(root, header.Root) {
+		return nil, fmt.Errorf("installed state root %x does not match the snapshot root %x", root, header.Root)
+	}
+	if err := v.Tx.Commit(header.Height); err != nil {
+		return nil, fmt.Errorf("cannot commit statedb tx: %w", err)
+	}
+	if v.Tx.TreeTx, err = v.Store.BeginTx(); err != nil {
+		return nil, fmt.Errorf("cannot begin statedb tx: %w", err)
+	}
+	mainTreeView, err := v.Store.TreeView(nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get statedb mainTreeView: %w", err)
 	}
-	log.Infof("installing snapshot %+v", snap.Header())
-	return fmt.Errorf("unimplemented")
+	v.setMainTreeView(mainTreeView)
+	v.SetHeight(header.Height)
+	return header, nil
 }
-*/
 
 type diskSnapshotInfo struct {
 	ModTime time.Time
@@ -0,0 +1,248 @@
+package state
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/tree/arbo"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
+)
+
+const (
+	// sponsorLimitKeyPrefix is the prefix of the sponsor limit entries on the
+	// Extra subtree, whose key is hash(prefix, sponsor address, scope) where
+	// the scope is an election ID or an account address.
+	sponsorLimitKeyPrefix = "sponsor_"
+	// sponsorScopesKeyPrefix is the prefix of the number of spending limits
+	// of each sponsor on the Extra subtree, whose key is hash(prefix, sponsor
+	// address).
+	sponsorScopesKeyPrefix = "sponsorScopes_"
+
+	// MaxSponsorScopes is the maximum number of spending limits of a sponsor,
+	// to bound the state each sponsor can use.
+	MaxSponsorScopes = 256
+)
+
+var (
+	// ErrSponsorLimitReached is returned if a sponsored transaction exceeds a
+	// spending limit of its sponsor.
+	ErrSponsorLimitReached = fmt.Errorf("sponsor spending limit reached")
+	// ErrTooManySponsorScopes is returned if a sponsor sets a new spending
+	// limit when it already has MaxSponsorScopes.
+	ErrTooManySponsorScopes = fmt.Errorf("too many sponsor spending limits, the maximum is %d", MaxSponsorScopes)
+	// ErrSponsorLimitNotFound is returned when removing a spending limit
+	// which does not exist.
+	ErrSponsorLimitNotFound = fmt.Errorf("sponsor spending limit not found")
+)
+
+// SponsorLimit is the maximum amount a sponsor pays for the transactions of
+// an election or signed by an account, along with the amount already spent.
+type SponsorLimit struct {
+	Limit uint64
+	Spent uint64
+}
+
+func sponsorLimitKey(sponsor common.Address, scope []byte) []byte {
+	key := append([]byte(sponsorLimitKeyPrefix), sponsor.Bytes()...)
+	return ethereum.HashRaw(append(key, scope...))
+}
+
+// SponsorLimit returns the spending limit of the sponsor for the scope, which
+// is an election ID or an account address. It returns nil if the sponsor has
+// no limit for the scope.
+// When committed is false, the operation is executed also on not yet commited
+// data from the currently open StateDB transaction.
+// When committed is true, the operation is executed on the last commited version.
+func (v *State) SponsorLimit(sponsor common.Address, scope []byte, committed bool) (*SponsorLimit, error) {
+	if !committed {
+		v.Tx.RLock()
+		defer v.Tx.RUnlock()
+	}
+	raw, err := v.mainTreeViewer(committed).DeepGet(sponsorLimitKey(sponsor, scope), StateTreeCfg(TreeExtra))
+	if errors.Is(err, arbo.ErrKeyNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(raw) != 16 {
+		return nil, fmt.Errorf("invalid sponsor limit entry for %s", sponsor)
+	}
+	return &SponsorLimit{
+		Limit: binary.LittleEndian.Uint64(raw[:8]),
+		Spent: binary.LittleEndian.Uint64(raw[8:]),
+	}, nil
+}
+
+func sponsorScopesKey(sponsor common.Address) []byte {
+	return ethereum.HashRaw(append([]byte(sponsorScopesKeyPrefix), sponsor.Bytes()...))
+}
+
+// SponsorScopes returns the number of spending limits of the sponsor.
+// When committed is false, the operation is executed also on not yet commited
+// data from the currently open StateDB transaction.
+// When committed is true, the operation is executed on the last commited version.
+func (v *State) SponsorScopes(sponsor common.Address, committed bool) (uint32, error) {
+	if !committed {
+		v.Tx.RLock()
+		defer v.Tx.RUnlock()
+	}
+	raw, err := v.mainTreeViewer(committed).DeepGet(sponsorScopesKey(sponsor), StateTreeCfg(TreeExtra))
+	if errors.Is(err, arbo.ErrKeyNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if len(raw) != 4 {
+		return 0, fmt.Errorf("invalid sponsor scopes entry for %s", sponsor)
+	}
+	return binary.LittleEndian.Uint32(raw), nil
+}
+
+// CheckSetSponsorLimit checks that the spending limit of the sponsor for the
+// scope can be set, or removed if remove is true. The check is executed also
+// on not yet commited data.
+func (v *State) CheckSetSponsorLimit(sponsor common.Address, scope []byte, remove bool) error {
+	current, err := v.SponsorLimit(sponsor, scope, false)
+	if err != nil {
+		return err
+	}
+	if remove {
+		if current == nil {
+			return fmt.Errorf("%w for %x", ErrSponsorLimitNotFound, scope)
+		}
+		return nil
+	}
+	if current != nil {
+		return nil
+	}
+	scopes, err := v.SponsorScopes(sponsor, false)
+	if err != nil {
+		return err
+	}
+	if scopes >= MaxSponsorScopes {
+		return ErrTooManySponsorScopes
+	}
+	return nil
+}
+
+// SetSponsorLimit sets the spending limit of the sponsor for the scope, which
+// is an election ID or an account address, keeping the amount already spent.
+// A sponsor can have up to MaxSponsorScopes limits.
+func (v *State) SetSponsorLimit(sponsor common.Address, scope []byte, limit uint64) error {
+	if err := v.CheckSetSponsorLimit(sponsor, scope, false); err != nil {
+		return err
+	}
+	current, err := v.SponsorLimit(sponsor, scope, false)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		current = &SponsorLimit{}
+		if err := v.addSponsorScopes(sponsor, 1); err != nil {
+			return err
+		}
+	}
+	current.Limit = limit
+	log.Debugf("setting sponsor %s limit %d for %x", sponsor, limit, scope)
+	return v.setSponsorLimit(sponsor, scope, current)
+}
+
+// RemoveSponsorLimit removes the spending limit of the sponsor for the scope,
+// so the transactions of the scope are only limited by the sponsor balance.
+func (v *State) RemoveSponsorLimit(sponsor common.Address, scope []byte) error {
+	if err := v.CheckSetSponsorLimit(sponsor, scope, true); err != nil {
+		return err
+	}
+	if err := v.addSponsorScopes(sponsor, -1); err != nil {
+		return err
+	}
+	log.Debugf("removing sponsor %s limit for %x", sponsor, scope)
+	v.Tx.Lock()
+	defer v.Tx.Unlock()
+	return v.Tx.DeepDel(sponsorLimitKey(sponsor, scope), StateTreeCfg(TreeExtra))
+}
+
+// addSponsorScopes adds delta to the number of spending limits of the sponsor.
+func (v *State) addSponsorScopes(sponsor common.Address, delta int) error {
+	scopes, err := v.SponsorScopes(sponsor, false)
+	if err != nil {
+		return err
+	}
+	raw := make([]byte, 4)
+	binary.LittleEndian.PutUint32(raw, uint32(int(scopes)+delta))
+	v.Tx.Lock()
+	defer v.Tx.Unlock()
+	return v.Tx.DeepSet(sponsorScopesKey(sponsor), raw, StateTreeCfg(TreeExtra))
+}
+
+func (v *State) setSponsorLimit(sponsor common.Address, scope []byte, l *SponsorLimit) error {
+	raw := make([]byte, 16)
+	binary.LittleEndian.PutUint64(raw[:8], l.Limit)
+	binary.LittleEndian.PutUint64(raw[8:], l.Spent)
+	v.Tx.Lock()
+	defer v.Tx.Unlock()
+	return v.Tx.DeepSet(sponsorLimitKey(sponsor, scope), raw, StateTreeCfg(TreeExtra))
+}
+
+// sponsorScopes returns the scopes of the spending limits that apply to a
+// transaction of the account: its election, if any, and the account.
+func sponsorScopes(sponsor *vochaintx.Sponsorship, account common.Address) [][]byte {
+	if len(sponsor.ElectionID) > 0 {
+		return [][]byte{sponsor.ElectionID, account.Bytes()}
+	}
+	return [][]byte{account.Bytes()}
+}
+
+// CheckSponsorship checks that the sponsor can pay the cost of a transaction
+// signed by the account, without exceeding its balance nor its spending
+// limits. The check is executed also on not yet commited data.
+func (v *State) CheckSponsorship(sponsor *vochaintx.Sponsorship, account common.Address, cost uint64) error {
+	acc, err := v.GetAccount(sponsor.Address, false)
+	if err != nil {
+		return fmt.Errorf("cannot get sponsor account: %w", err)
+	}
+	if acc == nil {
+		return fmt.Errorf("sponsor %s: %w", sponsor.Address, ErrAccountNotExist)
+	}
+	if acc.Balance < cost {
+		return fmt.Errorf("sponsor %s: %w", sponsor.Address, ErrNotEnoughBalance)
+	}
+	for _, scope := range sponsorScopes(sponsor, account) {
+		l, err := v.SponsorLimit(sponsor.Address, scope, false)
+		if err != nil {
+			return err
+		}
+		if l != nil && l.Spent+cost > l.Limit {
+			return fmt.Errorf("%w for %x (%d/%d)", ErrSponsorLimitReached, scope, l.Spent, l.Limit)
+		}
+	}
+	return nil
+}
+
+// BurnSponsoredTxCost burns the cost of a transaction signed by the account
+// from the sponsor balance, accounting it to the spending limits of the
+// sponsor.
+func (v *State) BurnSponsoredTxCost(sponsor *vochaintx.Sponsorship, account common.Address, cost uint64) error {
+	if err := v.CheckSponsorship(sponsor, account, cost); err != nil {
+		return err
+	}
+	for _, scope := range sponsorScopes(sponsor, account) {
+		l, err := v.SponsorLimit(sponsor.Address, scope, false)
+		if err != nil {
+			return err
+		}
+		if l == nil {
+			continue
+		}
+		l.Spent += cost
+		if err := v.setSponsorLimit(sponsor.Address, scope, l); err != nil {
+			return err
+		}
+	}
+	log.Debugf("sponsor %s paying fee %d for account %s", sponsor.Address, cost, account)
+	return v.BurnTxCost(sponsor.Address, cost)
+}
@@ -94,6 +94,8 @@ type State struct {
 	txCounter         atomic.Int32
 	// currentHeight is the height of the current started block
 	currentHeight atomic.Uint32
+	// currentTimestamp is the unix timestamp of the current started block
+	currentTimestamp atomic.Int64
 	// chainID identifies the blockchain
 	chainID string
 }
@@ -498,13 +500,17 @@ func (v *State) setRollingCensusSize(pids [][]byte) error {
 	return nil
 }
 
-// Save persistent save of vochain mem trees. It returns the new root hash. It also notifies the event listeners.
+// Save persistent save of vochain mem trees. It returns the new root hash. It also unlocks the
+// tokens vested at the current height and notifies the event listeners.
 func (v *State) Save() ([]byte, error) {
 	height := v.CurrentHeight()
 	var pidsStartNextBlock [][]byte
 	v.Tx.Lock()
 	err := func() error {
 		var err error
+		if err := v.unlockVestedTokens(height); err != nil {
+			return fmt.Errorf("cannot unlock vested tokens: %w", err)
+		}
 		pidsStartNextBlock, err = v.processIDsByStartBlock(height + 1)
 		if err != nil {
 			return fmt.Errorf("cannot get processIDs by StartBlock: %w", err)
@@ -596,6 +602,16 @@ func (v *State) SetHeight(height uint32) {
 	v.currentHeight.Store(height)
 }
 
+// CurrentTimestamp returns the unix timestamp of the current block.
+func (v *State) CurrentTimestamp() int64 {
+	return v.currentTimestamp.Load()
+}
+
+// SetTimestamp sets the unix timestamp of the current block.
+func (v *State) SetTimestamp(timestamp int64) {
+	v.currentTimestamp.Store(timestamp)
+}
+
 // WorkingHash returns the hash of the vochain StateDB (mainTree.Root)
 func (v *State) WorkingHash() []byte {
 	v.Tx.RLock()
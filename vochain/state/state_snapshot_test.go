@@ -1,16 +1,19 @@
 package state
 
 import (
+	"fmt"
 	"io"
 	"path/filepath"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	qt "github.com/frankban/quicktest"
 	"go.vocdoni.io/dvote/db"
 	"go.vocdoni.io/dvote/db/metadb"
 	"go.vocdoni.io/dvote/test/testcommon/testutil"
 	"go.vocdoni.io/dvote/tree"
 	"go.vocdoni.io/dvote/tree/arbo"
+	"go.vocdoni.io/proto/build/go/models"
 )
 
 func TestStateSnapshot(t *testing.T) {
@@ -98,6 +101,84 @@ func TestStateSnapshot(t *testing.T) {
 
 }
 
+func TestStateInstallSnapshot(t *testing.T) {
+	rng := testutil.NewRandom(0)
+	s, err := NewState(db.TypePebble, t.TempDir())
+	qt.Assert(t, err, qt.IsNil)
+	defer s.Close()
+
+	var pids [][]byte
+	for i := 0; i < 5; i++ {
+		s.SetHeight(uint32(i))
+		pids = append(pids, rng.RandomBytes(32))
+		censusURI := "ipfs://foobar"
+		qt.Assert(t, s.AddProcess(&models.Process{
+			EntityId:  rng.RandomBytes(20),
+			CensusURI: &censusURI,
+			ProcessId: pids[i],
+		}), qt.IsNil)
+		// the last process has no votes
+		for j := 0; j < 4-i; j++ {
+			qt.Assert(t, s.AddVote(&Vote{
+				ProcessID:   pids[i],
+				Nullifier:   rng.RandomBytes(32),
+				VotePackage: []byte(fmt.Sprintf("%d%d", i, j)),
+			}), qt.IsNil)
+		}
+		qt.Assert(t, s.SetAccount(common.BytesToAddress(rng.RandomBytes(20)),
+			&Account{Account: models.Account{Balance: uint64(i)}}), qt.IsNil)
+		_, err := s.Save()
+		qt.Assert(t, err, qt.IsNil)
+	}
+	root2, err := s.Store.VersionRoot(2)
+	qt.Assert(t, err, qt.IsNil)
+	root4, err := s.Store.Hash()
+	qt.Assert(t, err, qt.IsNil)
+
+	// the last committed state is installed on a new state
+	snapPath, err := s.Snapshot()
+	qt.Assert(t, err, qt.IsNil)
+	s2, err := NewState(db.TypePebble, t.TempDir())
+	qt.Assert(t, err, qt.IsNil)
+	defer s2.Close()
+	header, err := s2.InstallSnapshot(snapPath)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, header.Height, qt.Equals, uint32(4))
+	hash, err := s2.Store.Hash()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, hash, qt.DeepEquals, root4)
+	version, err := s2.Store.Version()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, version, qt.Equals, uint32(4))
+	for i, pid := range pids {
+		votes, err := s2.CountVotes(pid, false)
+		qt.Assert(t, err, qt.IsNil)
+		qt.Assert(t, votes, qt.Equals, uint64(4-i))
+	}
+	// the installed state can be updated
+	qt.Assert(t, s2.AddVote(&Vote{ProcessID: pids[4], Nullifier: rng.RandomBytes(32)}), qt.IsNil)
+	s2.SetHeight(5)
+	_, err = s2.Save()
+	qt.Assert(t, err, qt.IsNil)
+	votes, err := s2.CountVotes(pids[4], true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, votes, qt.Equals, uint64(1))
+
+	// a past state is installed as well
+	snapPath, err = s.SnapshotAt(2)
+	qt.Assert(t, err, qt.IsNil)
+	s3, err := NewState(db.TypePebble, t.TempDir())
+	qt.Assert(t, err, qt.IsNil)
+	defer s3.Close()
+	_, err = s3.InstallSnapshot(snapPath)
+	qt.Assert(t, err, qt.IsNil)
+	hash, err = s3.Store.Hash()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, hash, qt.DeepEquals, root2)
+	_, err = s3.Process(pids[3], true)
+	qt.Assert(t, err, qt.ErrorIs, ErrProcessNotFound)
+}
+
 func newTreeForTest(t *testing.T, rndGenerator int64) *tree.Tree {
 	tree := newEmptyTreeForTest(t)
 	rnd := testutil.NewRandom(rndGenerator)
@@ -125,3 +206,50 @@ func newEmptyTreeForTest(t *testing.T) *tree.Tree {
 	qt.Assert(t, err, qt.IsNil)
 	return tr
 }
+
+func TestStateCheckpoint(t *testing.T) {
+	rng := testutil.NewRandom(0)
+	s, err := NewState(db.TypePebble, t.TempDir())
+	qt.Assert(t, err, qt.IsNil)
+	defer s.Close()
+
+	addr := common.BytesToAddress(rng.RandomBytes(20))
+	qt.Assert(t, s.SetAccount(addr, &Account{Account: models.Account{Balance: 10}}), qt.IsNil)
+	_, err = s.Save()
+	qt.Assert(t, err, qt.IsNil)
+	root, err := s.Store.Hash()
+	qt.Assert(t, err, qt.IsNil)
+
+	checkpoint, err := s.Checkpoint()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, checkpoint.Version(), qt.Equals, uint32(0))
+
+	// modify the state on the next blocks, leaving some changes unsaved
+	pid := rng.RandomBytes(32)
+	s.SetHeight(1)
+	qt.Assert(t, s.AddProcess(&models.Process{
+		EntityId:  addr.Bytes(),
+		ProcessId: pid,
+	}), qt.IsNil)
+	qt.Assert(t, s.SetAccount(addr, &Account{Account: models.Account{Balance: 5}}), qt.IsNil)
+	_, err = s.Save()
+	qt.Assert(t, err, qt.IsNil)
+	s.SetHeight(2)
+	qt.Assert(t, s.SetAccount(addr, &Account{Account: models.Account{Balance: 1}}), qt.IsNil)
+
+	qt.Assert(t, s.RestoreCheckpoint(checkpoint), qt.IsNil)
+	qt.Assert(t, s.CurrentHeight(), qt.Equals, uint32(0))
+	version, err := s.Store.Version()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, version, qt.Equals, uint32(0))
+	restoredRoot, err := s.Store.Hash()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, restoredRoot, qt.DeepEquals, root)
+	for _, committed := range []bool{true, false} {
+		acc, err := s.GetAccount(addr, committed)
+		qt.Assert(t, err, qt.IsNil)
+		qt.Assert(t, acc.Balance, qt.Equals, uint64(10))
+		_, err = s.Process(pid, committed)
+		qt.Assert(t, err, qt.ErrorIs, ErrProcessNotFound)
+	}
+}
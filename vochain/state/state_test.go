@@ -182,6 +182,8 @@ func (l *Listener) OnSetAccount(addr []byte, account *Account) {
 }
 func (l *Listener) OnTransferTokens(tx *vochaintx.TokenTransfer) {
 }
+func (l *Listener) OnTokenVesting(v *vochaintx.TokenVesting) {
+}
 func (l *Listener) OnProcessesStart(pids [][]byte) {
 	l.processStart = append(l.processStart, pids)
 }
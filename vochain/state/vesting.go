@@ -0,0 +1,457 @@
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/tree/arbo"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
+)
+
+const (
+	// vestingKeyPrefix is the prefix of the vesting schedules on the Extra
+	// subtree, whose key is hash(prefix, transaction hash).
+	vestingKeyPrefix = "vesting_"
+	// vestingLockedKeyPrefix is the prefix of the amount locked on each
+	// account by its vesting schedules on the Extra subtree, whose key is
+	// hash(prefix, account address).
+	vestingLockedKeyPrefix = "vlocked_"
+	// vestingCountKeyPrefix is the prefix of the number of vesting schedules
+	// from a sender to a recipient on the Extra subtree, whose key is
+	// hash(prefix, recipient address, sender address).
+	vestingCountKeyPrefix = "vcount_"
+	// vestingDueKeyPrefix is the prefix of the vesting schedules to unlock
+	// at each height on the Extra subtree, whose key is hash(prefix, height)
+	// and whose value is the list of their transaction hashes.
+	vestingDueKeyPrefix = "vestingDue_"
+	// vestingScheduleSize is the size of an encoded vesting schedule.
+	vestingScheduleSize = common.AddressLength*2 + 8 + 8 + 4 + 4 + 4
+
+	// MaxVestingSchedules is the maximum number of vesting schedules not
+	// completely released from a sender to a recipient.
+	MaxVestingSchedules = 32
+	// MaxVestingUnlocks is the maximum number of times the tokens of a
+	// vesting schedule are unlocked, so long schedules unlock in steps of
+	// several blocks.
+	MaxVestingUnlocks = 100
+	// MaxVestingUnlocksPerBlock is the maximum number of vesting schedules
+	// unlocked on each block. The schedules due on a full block are unlocked
+	// on the following ones.
+	MaxVestingUnlocksPerBlock = 256
+	// MinVestingAmount is the minimum amount of a vesting transfer.
+	MinVestingAmount = 100
+	// MaxVestingBlocks is the maximum length of a vesting schedule, about four
+	// years with blocks of 10 seconds.
+	MaxVestingBlocks = 12614400
+)
+
+var (
+	// ErrTooManyVestingSchedules is returned if a vesting transfer exceeds
+	// MaxVestingSchedules.
+	ErrTooManyVestingSchedules = fmt.Errorf("too many vesting schedules")
+)
+
+// VestingSchedule holds the tokens of a vesting transfer that are locked on
+// the recipient account. Nothing is unlocked before the cliff height, and
+// then the amount vests linearly from the start height to the end height.
+type VestingSchedule struct {
+	TxHash      []byte
+	From        common.Address
+	To          common.Address
+	Amount      uint64
+	Released    uint64
+	StartHeight uint32
+	CliffHeight uint32
+	EndHeight   uint32
+}
+
+// Vested returns the amount of the schedule vested at the height, including
+// the amount already released.
+func (s *VestingSchedule) Vested(height uint32) uint64 {
+	switch {
+	case height < s.CliffHeight || height <= s.StartHeight:
+		return 0
+	case height >= s.EndHeight:
+		return s.Amount
+	}
+	vested := new(big.Int).SetUint64(s.Amount)
+	vested.Mul(vested, big.NewInt(int64(height-s.StartHeight)))
+	vested.Div(vested, big.NewInt(int64(s.EndHeight-s.StartHeight)))
+	return vested.Uint64()
+}
+
+// Locked returns the amount of the schedule not released yet.
+func (s *VestingSchedule) Locked() uint64 {
+	return s.Amount - s.Released
+}
+
+// nextUnlock returns the height, after the given one, at which the tokens of
+// the schedule are unlocked again. The schedule is unlocked at most
+// MaxVestingUnlocks times.
+func (s *VestingSchedule) nextUnlock(height uint32) uint32 {
+	step := (s.EndHeight - s.StartHeight) / MaxVestingUnlocks
+	if step == 0 {
+		step = 1
+	}
+	next := height + step
+	if next < s.CliffHeight {
+		next = s.CliffHeight
+	}
+	if next > s.EndHeight {
+		next = s.EndHeight
+	}
+	return next
+}
+
+func (s *VestingSchedule) tokenVesting() *vochaintx.TokenVesting {
+	return &vochaintx.TokenVesting{
+		TxHash:      s.TxHash,
+		FromAddress: s.From,
+		ToAddress:   s.To,
+		Amount:      s.Amount,
+		Released:    s.Released,
+		StartHeight: s.StartHeight,
+		CliffHeight: s.CliffHeight,
+		EndHeight:   s.EndHeight,
+	}
+}
+
+func vestingKey(txHash []byte) []byte {
+	return ethereum.HashRaw(append([]byte(vestingKeyPrefix), txHash...))
+}
+
+func vestingLockedKey(address common.Address) []byte {
+	return ethereum.HashRaw(append([]byte(vestingLockedKeyPrefix), address.Bytes()...))
+}
+
+func vestingCountKey(to, from common.Address) []byte {
+	key := append([]byte(vestingCountKeyPrefix), to.Bytes()...)
+	return ethereum.HashRaw(append(key, from.Bytes()...))
+}
+
+func vestingDueKey(height uint32) []byte {
+	return ethereum.HashRaw(binary.LittleEndian.AppendUint32([]byte(vestingDueKeyPrefix), height))
+}
+
+func encodeVestingSchedule(s *VestingSchedule) []byte {
+	raw := make([]byte, 0, vestingScheduleSize)
+	raw = append(raw, s.From.Bytes()...)
+	raw = append(raw, s.To.Bytes()...)
+	raw = binary.LittleEndian.AppendUint64(raw, s.Amount)
+	raw = binary.LittleEndian.AppendUint64(raw, s.Released)
+	raw = binary.LittleEndian.AppendUint32(raw, s.StartHeight)
+	raw = binary.LittleEndian.AppendUint32(raw, s.CliffHeight)
+	return binary.LittleEndian.AppendUint32(raw, s.EndHeight)
+}
+
+func decodeVestingSchedule(txHash, raw []byte) (*VestingSchedule, error) {
+	if len(raw) != vestingScheduleSize {
+		return nil, fmt.Errorf("invalid vesting schedule entry for %x", txHash)
+	}
+	s := &VestingSchedule{TxHash: bytes.Clone(txHash)}
+	s.From = common.BytesToAddress(raw[:common.AddressLength])
+	raw = raw[common.AddressLength:]
+	s.To = common.BytesToAddress(raw[:common.AddressLength])
+	raw = raw[common.AddressLength:]
+	s.Amount = binary.LittleEndian.Uint64(raw[:8])
+	s.Released = binary.LittleEndian.Uint64(raw[8:16])
+	s.StartHeight = binary.LittleEndian.Uint32(raw[16:20])
+	s.CliffHeight = binary.LittleEndian.Uint32(raw[20:24])
+	s.EndHeight = binary.LittleEndian.Uint32(raw[24:28])
+	return s, nil
+}
+
+// VestingSchedule returns the vesting schedule created by the transaction, or
+// nil if it does not exist or its tokens are completely released.
+// When committed is false, the operation is executed also on not yet commited
+// data from the currently open StateDB transaction.
+// When committed is true, the operation is executed on the last commited version.
+func (v *State) VestingSchedule(txHash []byte, committed bool) (*VestingSchedule, error) {
+	if !committed {
+		v.Tx.RLock()
+		defer v.Tx.RUnlock()
+	}
+	return v.vestingSchedule(txHash, committed)
+}
+
+// vestingSchedule is VestingSchedule without locking v.Tx.
+func (v *State) vestingSchedule(txHash []byte, committed bool) (*VestingSchedule, error) {
+	raw, err := v.mainTreeViewer(committed).DeepGet(vestingKey(txHash), StateTreeCfg(TreeExtra))
+	if errors.Is(err, arbo.ErrKeyNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return decodeVestingSchedule(txHash, raw)
+}
+
+// LockedBalance returns the amount of tokens locked on the account by its
+// vesting schedules.
+// When committed is false, the operation is executed also on not yet commited
+// data from the currently open StateDB transaction.
+// When committed is true, the operation is executed on the last commited version.
+func (v *State) LockedBalance(address common.Address, committed bool) (uint64, error) {
+	if !committed {
+		v.Tx.RLock()
+		defer v.Tx.RUnlock()
+	}
+	return v.lockedBalance(address, committed)
+}
+
+// lockedBalance is LockedBalance without locking v.Tx.
+func (v *State) lockedBalance(address common.Address, committed bool) (uint64, error) {
+	raw, err := v.mainTreeViewer(committed).DeepGet(vestingLockedKey(address), StateTreeCfg(TreeExtra))
+	if errors.Is(err, arbo.ErrKeyNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if len(raw) != 8 {
+		return 0, fmt.Errorf("invalid locked balance entry for %s", address)
+	}
+	return binary.LittleEndian.Uint64(raw), nil
+}
+
+// vestingCount returns the number of vesting schedules, not completely
+// released, from the sender to the recipient. v.Tx must be locked.
+func (v *State) vestingCount(to, from common.Address) (uint32, error) {
+	raw, err := v.Tx.DeepGet(vestingCountKey(to, from), StateTreeCfg(TreeExtra))
+	if errors.Is(err, arbo.ErrKeyNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if len(raw) != 4 {
+		return 0, fmt.Errorf("invalid vesting count entry for %s", to)
+	}
+	return binary.LittleEndian.Uint32(raw), nil
+}
+
+// vestingDue returns the transaction hashes of the vesting schedules to
+// unlock at the height. v.Tx must be locked.
+func (v *State) vestingDue(height uint32) ([][]byte, error) {
+	raw, err := v.Tx.DeepGet(vestingDueKey(height), StateTreeCfg(TreeExtra))
+	if errors.Is(err, arbo.ErrKeyNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(raw)%32 != 0 {
+		return nil, fmt.Errorf("invalid vesting due entry for height %d", height)
+	}
+	txHashes := make([][]byte, 0, len(raw)/32)
+	for ; len(raw) > 0; raw = raw[32:] {
+		txHashes = append(txHashes, bytes.Clone(raw[:32]))
+	}
+	return txHashes, nil
+}
+
+// setExtra sets the key on the Extra subtree, or deletes it if the value is
+// empty. v.Tx must be locked.
+func (v *State) setExtra(key, value []byte) error {
+	if len(value) == 0 {
+		return v.Tx.DeepDel(key, StateTreeCfg(TreeExtra))
+	}
+	return v.Tx.DeepSet(key, value, StateTreeCfg(TreeExtra))
+}
+
+// setLockedBalance sets the amount locked on the account by its vesting
+// schedules. v.Tx must be locked.
+func (v *State) setLockedBalance(address common.Address, locked uint64) error {
+	if locked == 0 {
+		return v.setExtra(vestingLockedKey(address), nil)
+	}
+	return v.setExtra(vestingLockedKey(address), binary.LittleEndian.AppendUint64(nil, locked))
+}
+
+// addVestingCount adds delta to the number of vesting schedules from the
+// sender to the recipient. v.Tx must be locked.
+func (v *State) addVestingCount(to, from common.Address, delta int) error {
+	count, err := v.vestingCount(to, from)
+	if err != nil {
+		return err
+	}
+	count = uint32(int(count) + delta)
+	if count == 0 {
+		return v.setExtra(vestingCountKey(to, from), nil)
+	}
+	return v.setExtra(vestingCountKey(to, from), binary.LittleEndian.AppendUint32(nil, count))
+}
+
+// addVestingDue schedules the unlock of the vesting schedule at the height, or
+// at the first following one with less than MaxVestingUnlocksPerBlock
+// schedules due. v.Tx must be locked.
+func (v *State) addVestingDue(height uint32, txHash []byte) error {
+	for {
+		txHashes, err := v.vestingDue(height)
+		if err != nil {
+			return err
+		}
+		if len(txHashes) < MaxVestingUnlocksPerBlock {
+			return v.setExtra(vestingDueKey(height), append(bytes.Join(txHashes, nil), txHash...))
+		}
+		height++
+	}
+}
+
+// CheckVestingTransfer checks that a new vesting schedule from the sender can
+// be added to the recipient, without exceeding MaxVestingSchedules.
+func (v *State) CheckVestingTransfer(from, to common.Address) error {
+	v.Tx.RLock()
+	defer v.Tx.RUnlock()
+	return v.checkVestingTransfer(from, to)
+}
+
+// checkVestingTransfer is CheckVestingTransfer without locking v.Tx.
+func (v *State) checkVestingTransfer(from, to common.Address) error {
+	count, err := v.vestingCount(to, from)
+	if err != nil {
+		return err
+	}
+	if count >= MaxVestingSchedules {
+		return fmt.Errorf("%w from %s to %s", ErrTooManyVestingSchedules, from, to)
+	}
+	return nil
+}
+
+// TransferVestingTokens moves the amount of the schedule from its origin
+// account, locking it on the destination account until it vests.
+func (v *State) TransferVestingTokens(s *VestingSchedule) error {
+	if s.Amount < MinVestingAmount {
+		return fmt.Errorf("vesting amount %d is lower than the minimum %d", s.Amount, MinVestingAmount)
+	}
+	if s.EndHeight <= s.StartHeight || s.CliffHeight > s.EndHeight ||
+		s.EndHeight-s.StartHeight > MaxVestingBlocks {
+		return fmt.Errorf("invalid vesting schedule")
+	}
+	v.Tx.Lock()
+	defer v.Tx.Unlock()
+	if err := v.checkVestingTransfer(s.From, s.To); err != nil {
+		return err
+	}
+	accFrom, err := v.account(s.From, false)
+	if err != nil {
+		return err
+	}
+	if accFrom == nil {
+		return ErrAccountNotExist
+	}
+	accTo, err := v.account(s.To, false)
+	if err != nil {
+		return err
+	}
+	if accTo == nil {
+		return ErrAccountNotExist
+	}
+	if accFrom.Balance < s.Amount {
+		return ErrNotEnoughBalance
+	}
+	locked, err := v.lockedBalance(s.To, false)
+	if err != nil {
+		return err
+	}
+	if accTo.Balance+locked+s.Amount < accTo.Balance+locked {
+		return ErrBalanceOverflow
+	}
+	accFrom.Balance -= s.Amount
+	if err := v.setAccount(s.From, accFrom); err != nil {
+		return err
+	}
+	log.Debugw("transferring vesting tokens",
+		"from", s.From.Hex(),
+		"to", s.To.Hex(),
+		"amount", fmt.Sprintf("%d", s.Amount),
+		"cliff", s.CliffHeight,
+		"end", s.EndHeight,
+	)
+	if err := v.setLockedBalance(s.To, locked+s.Amount); err != nil {
+		return err
+	}
+	if err := v.addVestingCount(s.To, s.From, 1); err != nil {
+		return err
+	}
+	if err := v.setExtra(vestingKey(s.TxHash), encodeVestingSchedule(s)); err != nil {
+		return err
+	}
+	if err := v.addVestingDue(s.nextUnlock(s.StartHeight), s.TxHash); err != nil {
+		return err
+	}
+	for _, l := range v.eventListeners {
+		l.OnTokenVesting(s.tokenVesting())
+	}
+	return nil
+}
+
+// unlockVestedTokens moves the tokens vested at the height from the vesting
+// schedules due at the height to the balance of their accounts. The schedules
+// completely released are removed, and the others are scheduled for their
+// next unlock. v.Tx must be locked.
+func (v *State) unlockVestedTokens(height uint32) error {
+	txHashes, err := v.vestingDue(height)
+	if err != nil {
+		return fmt.Errorf("cannot get vesting schedules due: %w", err)
+	}
+	if len(txHashes) == 0 {
+		return nil
+	}
+	if err := v.setExtra(vestingDueKey(height), nil); err != nil {
+		return err
+	}
+	for _, txHash := range txHashes {
+		s, err := v.vestingSchedule(txHash, false)
+		if err != nil {
+			return err
+		}
+		if s == nil {
+			return fmt.Errorf("vesting schedule %x not found", txHash)
+		}
+		amount := s.Vested(height) - s.Released
+		if amount > 0 {
+			acc, err := v.account(s.To, false)
+			if err != nil {
+				return err
+			}
+			if acc == nil {
+				return fmt.Errorf("vesting account %s: %w", s.To, ErrAccountNotExist)
+			}
+			acc.Balance += amount
+			s.Released += amount
+			log.Debugw("unlocking vested tokens", "account", s.To.Hex(), "amount", amount, "height", height)
+			if err := v.setAccount(s.To, acc); err != nil {
+				return err
+			}
+			locked, err := v.lockedBalance(s.To, false)
+			if err != nil {
+				return err
+			}
+			if err := v.setLockedBalance(s.To, locked-amount); err != nil {
+				return err
+			}
+			for _, l := range v.eventListeners {
+				l.OnTokenVesting(s.tokenVesting())
+			}
+		}
+		if s.Locked() == 0 {
+			if err := v.setExtra(vestingKey(txHash), nil); err != nil {
+				return err
+			}
+			if err := v.addVestingCount(s.To, s.From, -1); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := v.setExtra(vestingKey(txHash), encodeVestingSchedule(s)); err != nil {
+			return err
+		}
+		if err := v.addVestingDue(s.nextUnlock(height), txHash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
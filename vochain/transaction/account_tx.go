@@ -52,7 +52,16 @@ func (t *TransactionHandler) CreateAccountTxCheck(vtx *vochaintx.VochainTx) erro
 	if err != nil {
 		return fmt.Errorf("cannot get tx cost: %w", err)
 	}
-	if txCost == 0 {
+	if vtx.Sponsor != nil {
+		// the sponsor pays the cost, the faucet package is optional
+		if err := t.state.CheckSponsorship(vtx.Sponsor, txSenderAddress, txCost); err != nil {
+			return err
+		}
+		if tx.FaucetPackage == nil {
+			return nil
+		}
+		txCost = 0
+	} else if txCost == 0 {
 		return nil
 	}
 	if tx.FaucetPackage == nil {
@@ -139,8 +148,8 @@ func (t *TransactionHandler) SetAccountDelegateTxCheck(vtx *vochaintx.VochainTx)
 	if err != nil {
 		return fmt.Errorf("cannot get tx cost: %w", err)
 	}
-	if txSenderAccount.Balance < cost {
-		return vstate.ErrNotEnoughBalance
+	if err := t.checkTxCost(vtx, *txSenderAddress, txSenderAccount.Balance, cost); err != nil {
+		return err
 	}
 	switch tx.Txtype {
 	case models.TxType_ADD_DELEGATE_FOR_ACCOUNT:
@@ -207,8 +216,8 @@ func (t *TransactionHandler) SetAccountInfoTxCheck(vtx *vochaintx.VochainTx) err
 		return fmt.Errorf("cannot get tx cost: %w", err)
 	}
 	// check tx sender balance
-	if txSenderAccount.Balance < costSetAccountInfoURI {
-		return fmt.Errorf("unauthorized: %s", vstate.ErrNotEnoughBalance)
+	if err := t.checkTxCost(vtx, txSenderAddress, txSenderAccount.Balance, costSetAccountInfoURI); err != nil {
+		return fmt.Errorf("unauthorized: %w", err)
 	}
 	// check info URI
 	infoURI := tx.GetInfoURI()
@@ -87,8 +87,8 @@ func (t *TransactionHandler) NewProcessTxCheck(vtx *vochaintx.VochainTx,
 		return nil, ethereum.Address{}, fmt.Errorf("cannot get account from vtx.Signature, nil result")
 	}
 	// check balance and nonce
-	if acc.Balance < cost {
-		return nil, ethereum.Address{}, vstate.ErrNotEnoughBalance
+	if err := t.checkTxCost(vtx, *addr, acc.Balance, cost); err != nil {
+		return nil, ethereum.Address{}, err
 	}
 	if acc.Nonce != tx.Nonce {
 		return nil, ethereum.Address{}, vstate.ErrAccountNonceInvalid
@@ -187,8 +187,8 @@ func (t *TransactionHandler) SetProcessTxCheck(vtx *vochaintx.VochainTx, forComm
 		return ethereum.Address{}, err
 	}
 	// check balance and nonce
-	if acc.Balance < cost {
-		return ethereum.Address{}, vstate.ErrNotEnoughBalance
+	if err := t.checkTxCost(vtx, *addr, acc.Balance, cost); err != nil {
+		return ethereum.Address{}, err
 	}
 	if acc.Nonce != tx.Nonce {
 		return ethereum.Address{}, vstate.ErrAccountNonceInvalid
@@ -228,8 +228,8 @@ func (t *TransactionHandler) SetProcessTxCheck(vtx *vochaintx.VochainTx, forComm
 		if !isOracle {
 			return ethereum.Address{}, fmt.Errorf("only oracles can execute set process results transaction")
 		}
-		if acc.Balance < cost {
-			return ethereum.Address{}, vstate.ErrNotEnoughBalance
+		if err := t.checkTxCost(vtx, *addr, acc.Balance, cost); err != nil {
+			return ethereum.Address{}, err
 		}
 		if acc.Nonce != tx.Nonce {
 			return ethereum.Address{}, vstate.ErrAccountNonceInvalid
@@ -0,0 +1,91 @@
+package transaction
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.vocdoni.io/dvote/types"
+	vstate "go.vocdoni.io/dvote/vochain/state"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+// checkTxCost checks that the cost of a transaction of the sender can be paid,
+// by the sponsor if the transaction is sponsored or by the sender otherwise.
+// Balance is the sender balance available for the cost.
+func (t *TransactionHandler) checkTxCost(vtx *vochaintx.VochainTx, sender common.Address,
+	balance, cost uint64) error {
+	if vtx.Sponsor != nil {
+		return t.state.CheckSponsorship(vtx.Sponsor, sender, cost)
+	}
+	if balance < cost {
+		return vstate.ErrNotEnoughBalance
+	}
+	return nil
+}
+
+// isSponsorable returns true if the cost of the transaction can be paid by a
+// sponsor. The transactions of the treasurer and the faucet are not.
+func isSponsorable(tx *models.Tx) bool {
+	switch tx.Payload.(type) {
+	case *models.Tx_NewProcess, *models.Tx_SetProcess, *models.Tx_SetAccount, *models.Tx_SendTokens:
+		return true
+	}
+	return false
+}
+
+// checkSponsoredElection checks that the election the sponsor accounts the
+// cost of the transaction to, if any, is the one of the transaction. The
+// transactions without election cannot be accounted to one.
+func checkSponsoredElection(vtx *vochaintx.VochainTx, electionID []byte) error {
+	if vtx.Sponsor == nil || len(vtx.Sponsor.ElectionID) == 0 {
+		return nil
+	}
+	if !bytes.Equal(vtx.Sponsor.ElectionID, electionID) {
+		return fmt.Errorf("sponsored election %x does not match the transaction election %x",
+			vtx.Sponsor.ElectionID, electionID)
+	}
+	return nil
+}
+
+// SetSponsorLimitsTxCheck checks if a SetSponsorLimitsTx is valid, and returns
+// the sponsor address and the scope of the limit.
+func (t *TransactionHandler) SetSponsorLimitsTxCheck(vtx *vochaintx.VochainTx) (common.Address, []byte, error) {
+	if vtx == nil || vtx.Signature == nil || vtx.SignedBody == nil || vtx.Tx == nil {
+		return common.Address{}, nil, ErrNilTx
+	}
+	tx := vtx.Tx.GetSetSponsorLimits()
+	if tx == nil {
+		return common.Address{}, nil, fmt.Errorf("invalid tx")
+	}
+	var scope []byte
+	switch {
+	case len(tx.ElectionId) > 0 && len(tx.Account) > 0:
+		return common.Address{}, nil, fmt.Errorf("either the election or the account must be set")
+	case len(tx.ElectionId) == types.ProcessIDsize:
+		scope = tx.ElectionId
+	case len(tx.Account) == common.AddressLength:
+		scope = tx.Account
+	default:
+		return common.Address{}, nil, fmt.Errorf("invalid election or account")
+	}
+	addr, acc, err := t.state.AccountFromSignature(vtx.SignedBody, vtx.Signature)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	if acc.Nonce != tx.Nonce {
+		return common.Address{}, nil, fmt.Errorf("invalid nonce, expected %d got %d", acc.Nonce, tx.Nonce)
+	}
+	cost, err := t.state.TxCost(models.TxType_SET_SPONSOR_LIMITS, false)
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("cannot get tx cost: %w", err)
+	}
+	if acc.Balance < cost {
+		return common.Address{}, nil, vstate.ErrNotEnoughBalance
+	}
+	if err := t.state.CheckSetSponsorLimit(*addr, scope, tx.Remove); err != nil {
+		return common.Address{}, nil, err
+	}
+	return *addr, scope, nil
+}
@@ -155,10 +155,10 @@ func (t *TransactionHandler) SendTokensTxCheck(vtx *vochaintx.VochainTx) error {
 	if err != nil {
 		return err
 	}
-	if (tx.Value + cost) > acc.Balance {
+	if tx.Value > acc.Balance {
 		return vstate.ErrNotEnoughBalance
 	}
-	return nil
+	return t.checkTxCost(vtx, txSenderAddress, acc.Balance-tx.Value, cost)
 }
 
 // CollectFaucetTxCheck checks if a CollectFaucetTx and its data are valid
@@ -22,6 +22,9 @@ var (
 	// ErrorAlreadyExistInCache is returned if the transaction has been already processed
 	// and stored in the vote cache.
 	ErrorAlreadyExistInCache = fmt.Errorf("vote already exist in cache")
+	// ErrTxExpired is returned if the transaction cannot be included in a
+	// block after its expiry.
+	ErrTxExpired = fmt.Errorf("transaction expired")
 )
 
 // TransactionResponse is the response of a transaction check.
@@ -60,6 +63,67 @@ func (t *TransactionHandler) LoadZkCircuit(configTag string) error {
 	return nil
 }
 
+// CheckTxExpiry checks that the transaction has not expired. If forCommit is
+// false, the transaction is checked for inclusion in the next block.
+func (t *TransactionHandler) CheckTxExpiry(vtx *vochaintx.VochainTx, forCommit bool) error {
+	if vtx.Expiry == nil {
+		return nil
+	}
+	height := t.state.CurrentHeight()
+	if !forCommit {
+		height++
+	}
+	if vtx.Expiry.Expired(height, t.state.CurrentTimestamp()) {
+		return fmt.Errorf("%w (valid until height %d, timestamp %d)",
+			ErrTxExpired, vtx.Expiry.Height, vtx.Expiry.Timestamp)
+	}
+	return nil
+}
+
+// CheckTxNonce checks that the nonce of a transaction signed by an account is
+// still the nonce of the account, so it has not been used by another transaction.
+// Transactions without an account nonce, or whose sender account does not exist,
+// are not checked.
+func (t *TransactionHandler) CheckTxNonce(vtx *vochaintx.VochainTx) error {
+	var nonce uint32
+	switch payload := vtx.Tx.GetPayload().(type) {
+	case *models.Tx_NewProcess:
+		nonce = payload.NewProcess.GetNonce()
+	case *models.Tx_SetProcess:
+		nonce = payload.SetProcess.GetNonce()
+	case *models.Tx_SendTokens:
+		nonce = payload.SendTokens.GetNonce()
+	case *models.Tx_CollectFaucet:
+		nonce = payload.CollectFaucet.GetNonce()
+	case *models.Tx_SetSponsorLimits:
+		nonce = payload.SetSponsorLimits.GetNonce()
+	case *models.Tx_SendVestingTokens:
+		nonce = payload.SendVestingTokens.GetNonce()
+	case *models.Tx_SetAccount:
+		if payload.SetAccount.Nonce == nil {
+			return nil
+		}
+		nonce = payload.SetAccount.GetNonce()
+	default:
+		return nil
+	}
+	addr, err := ethereum.AddrFromSignature(vtx.SignedBody, vtx.Signature)
+	if err != nil {
+		return fmt.Errorf("cannot get address from signature: %w", err)
+	}
+	acc, err := t.state.GetAccount(addr, false)
+	if err != nil {
+		return fmt.Errorf("cannot get account: %w", err)
+	}
+	if acc == nil {
+		return nil
+	}
+	if acc.Nonce != nonce {
+		return fmt.Errorf("invalid nonce, expected %d got %d", acc.Nonce, nonce)
+	}
+	return nil
+}
+
 // CheckTx check the validity of a transaction and adds it to the state if forCommit=true.
 // It returns a bytes value which depends on the transaction type:
 //
@@ -69,9 +133,23 @@ func (t *TransactionHandler) CheckTx(vtx *vochaintx.VochainTx, forCommit bool) (
 	if vtx.Tx == nil || vtx.Tx.Payload == nil {
 		return nil, fmt.Errorf("transaction is empty")
 	}
+	if err := t.CheckTxExpiry(vtx, forCommit); err != nil {
+		return nil, err
+	}
 	response := &TransactionResponse{
 		TxHash: vtx.TxID[:],
 	}
+	if vtx.Sponsor != nil {
+		if !isSponsorable(vtx.Tx) {
+			return nil, fmt.Errorf("%s transaction cannot be sponsored", vtx.TxModelType)
+		}
+		// the election of a new process is checked once its id is built
+		if vtx.Tx.GetNewProcess() == nil {
+			if err := checkSponsoredElection(vtx, vtx.Tx.GetSetProcess().GetProcessId()); err != nil {
+				return nil, err
+			}
+		}
+	}
 	switch vtx.Tx.Payload.(type) {
 	case *models.Tx_Vote:
 		v, err := t.VoteTxCheck(vtx, forCommit)
@@ -121,6 +199,9 @@ func (t *TransactionHandler) CheckTx(vtx *vochaintx.VochainTx, forCommit bool) (
 		if err != nil {
 			return nil, fmt.Errorf("newProcessTx: %w", err)
 		}
+		if err := checkSponsoredElection(vtx, p.ProcessId); err != nil {
+			return nil, fmt.Errorf("newProcessTx: %w", err)
+		}
 		response.Data = p.ProcessId
 		if forCommit {
 			tx := vtx.Tx.GetNewProcess()
@@ -134,7 +215,7 @@ func (t *TransactionHandler) CheckTx(vtx *vochaintx.VochainTx, forCommit bool) (
 			if err := t.state.IncrementAccountProcessIndex(entityAddr); err != nil {
 				return nil, fmt.Errorf("newProcessTx: cannot increment process index: %w", err)
 			}
-			return response, t.state.BurnTxCostIncrementNonce(common.Address(txSender), models.TxType_NEW_PROCESS)
+			return response, t.state.BurnTxCostIncrementNonce(common.Address(txSender), models.TxType_NEW_PROCESS, vtx.Sponsor)
 		}
 
 	case *models.Tx_SetProcess:
@@ -169,7 +250,7 @@ func (t *TransactionHandler) CheckTx(vtx *vochaintx.VochainTx, forCommit bool) (
 			default:
 				return nil, fmt.Errorf("unknown set process tx type")
 			}
-			return response, t.state.BurnTxCostIncrementNonce(common.Address(txSender), tx.Txtype)
+			return response, t.state.BurnTxCostIncrementNonce(common.Address(txSender), tx.Txtype, vtx.Sponsor)
 		}
 
 	case *models.Tx_RegisterKey:
@@ -225,16 +306,21 @@ func (t *TransactionHandler) CheckTx(vtx *vochaintx.VochainTx, forCommit bool) (
 				); err != nil {
 					return nil, fmt.Errorf("setAccountTx: createAccount %w", err)
 				}
+				txCost, err := t.state.TxCost(models.TxType_CREATE_ACCOUNT, false)
+				if err != nil {
+					return nil, fmt.Errorf("createAccountTx: txCost %w", err)
+				}
+				if vtx.Sponsor != nil {
+					if err := t.state.BurnSponsoredTxCost(vtx.Sponsor, txSenderAddress, txCost); err != nil {
+						return nil, fmt.Errorf("setAccountTx: burnSponsoredTxCost %w", err)
+					}
+				}
 				if tx.FaucetPackage != nil {
 					faucetIssuerAddress, err := ethereum.AddrFromSignature(tx.FaucetPackage.Payload, tx.FaucetPackage.Signature)
 					if err != nil {
 						return nil, fmt.Errorf("createAccountTx: faucetIssuerAddress %w", err)
 					}
-					txCost, err := t.state.TxCost(models.TxType_CREATE_ACCOUNT, false)
-					if err != nil {
-						return nil, fmt.Errorf("createAccountTx: txCost %w", err)
-					}
-					if txCost != 0 {
+					if txCost != 0 && vtx.Sponsor == nil {
 						if err := t.state.BurnTxCost(faucetIssuerAddress, txCost); err != nil {
 							return nil, fmt.Errorf("setAccountTx: burnTxCost %w", err)
 						}
@@ -271,6 +357,7 @@ func (t *TransactionHandler) CheckTx(vtx *vochaintx.VochainTx, forCommit bool) (
 				if err := t.state.BurnTxCostIncrementNonce(
 					txSenderAddress,
 					models.TxType_SET_ACCOUNT_INFO_URI,
+					vtx.Sponsor,
 				); err != nil {
 					return nil, fmt.Errorf("setAccountTx: burnCostIncrementNonce %w", err)
 				}
@@ -294,6 +381,7 @@ func (t *TransactionHandler) CheckTx(vtx *vochaintx.VochainTx, forCommit bool) (
 				if err := t.state.BurnTxCostIncrementNonce(
 					txSenderAddress,
 					models.TxType_ADD_DELEGATE_FOR_ACCOUNT,
+					vtx.Sponsor,
 				); err != nil {
 					return nil, fmt.Errorf("setAccountDelegateTx: burnTxCostIncrementNonce %w", err)
 				}
@@ -312,6 +400,7 @@ func (t *TransactionHandler) CheckTx(vtx *vochaintx.VochainTx, forCommit bool) (
 				if err := t.state.BurnTxCostIncrementNonce(
 					txSenderAddress,
 					models.TxType_DEL_DELEGATE_FOR_ACCOUNT,
+					vtx.Sponsor,
 				); err != nil {
 					return nil, fmt.Errorf("setAccountDelegate: burnTxCostIncrementNonce %w", err)
 				}
@@ -369,7 +458,7 @@ func (t *TransactionHandler) CheckTx(vtx *vochaintx.VochainTx, forCommit bool) (
 		if forCommit {
 			tx := vtx.Tx.GetSendTokens()
 			from, to := common.BytesToAddress(tx.From), common.BytesToAddress(tx.To)
-			err := t.state.BurnTxCostIncrementNonce(from, models.TxType_SEND_TOKENS)
+			err := t.state.BurnTxCostIncrementNonce(from, models.TxType_SEND_TOKENS, vtx.Sponsor)
 			if err != nil {
 				return nil, fmt.Errorf("sendTokensTx: burnTxCostIncrementNonce %w", err)
 			}
@@ -395,7 +484,7 @@ func (t *TransactionHandler) CheckTx(vtx *vochaintx.VochainTx, forCommit bool) (
 			if err != nil {
 				return nil, fmt.Errorf("collectFaucetTx: cannot get issuerAddress %w", err)
 			}
-			if err := t.state.BurnTxCostIncrementNonce(issuerAddress, models.TxType_COLLECT_FAUCET); err != nil {
+			if err := t.state.BurnTxCostIncrementNonce(issuerAddress, models.TxType_COLLECT_FAUCET, nil); err != nil {
 				return nil, fmt.Errorf("collectFaucetTx: burnTxCost %w", err)
 			}
 			faucetPayload := &models.FaucetPayload{}
@@ -423,6 +512,52 @@ func (t *TransactionHandler) CheckTx(vtx *vochaintx.VochainTx, forCommit bool) (
 			return response, nil
 		}
 
+	case *models.Tx_SetSponsorLimits:
+		sponsor, scope, err := t.SetSponsorLimitsTxCheck(vtx)
+		if err != nil {
+			return nil, fmt.Errorf("setSponsorLimitsTx: %w", err)
+		}
+		if forCommit {
+			tx := vtx.Tx.GetSetSponsorLimits()
+			if tx.Remove {
+				err = t.state.RemoveSponsorLimit(sponsor, scope)
+			} else {
+				err = t.state.SetSponsorLimit(sponsor, scope, tx.Limit)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("setSponsorLimitsTx: %w", err)
+			}
+			if err := t.state.BurnTxCostIncrementNonce(sponsor, models.TxType_SET_SPONSOR_LIMITS, nil); err != nil {
+				return nil, fmt.Errorf("setSponsorLimitsTx: burnTxCostIncrementNonce %w", err)
+			}
+			return response, nil
+		}
+
+	case *models.Tx_SendVestingTokens:
+		from, to, err := t.SendVestingTokensTxCheck(vtx)
+		if err != nil {
+			return nil, fmt.Errorf("sendVestingTokensTx: %w", err)
+		}
+		if forCommit {
+			tx := vtx.Tx.GetSendVestingTokens()
+			if err := t.state.BurnTxCostIncrementNonce(from, models.TxType_SEND_VESTING_TOKENS, nil); err != nil {
+				return nil, fmt.Errorf("sendVestingTokensTx: burnTxCostIncrementNonce %w", err)
+			}
+			height := t.state.CurrentHeight()
+			if err := t.state.TransferVestingTokens(&vstate.VestingSchedule{
+				TxHash:      vtx.TxID[:],
+				From:        from,
+				To:          to,
+				Amount:      tx.Value,
+				StartHeight: height,
+				CliffHeight: height + tx.CliffBlocks,
+				EndHeight:   height + tx.VestingBlocks,
+			}); err != nil {
+				return nil, fmt.Errorf("sendVestingTokensTx: %w", err)
+			}
+			return response, nil
+		}
+
 	default:
 		return nil, fmt.Errorf("invalid transaction type")
 	}
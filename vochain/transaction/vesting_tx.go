@@ -0,0 +1,67 @@
+package transaction
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	vstate "go.vocdoni.io/dvote/vochain/state"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+// SendVestingTokensTxCheck checks if a SendVestingTokensTx is valid, and
+// returns the sender and the recipient addresses.
+func (t *TransactionHandler) SendVestingTokensTxCheck(vtx *vochaintx.VochainTx) (common.Address, common.Address, error) {
+	if vtx.Signature == nil || vtx.SignedBody == nil || vtx.Tx == nil {
+		return common.Address{}, common.Address{}, ErrNilTx
+	}
+	tx := vtx.Tx.GetSendVestingTokens()
+	if tx == nil {
+		return common.Address{}, common.Address{}, fmt.Errorf("invalid tx")
+	}
+	if tx.Value < vstate.MinVestingAmount {
+		return common.Address{}, common.Address{}, fmt.Errorf(
+			"invalid value, the minimum vesting amount is %d", vstate.MinVestingAmount)
+	}
+	if len(tx.To) != common.AddressLength {
+		return common.Address{}, common.Address{}, fmt.Errorf("invalid to address")
+	}
+	if tx.VestingBlocks == 0 || tx.CliffBlocks > tx.VestingBlocks {
+		return common.Address{}, common.Address{}, fmt.Errorf(
+			"invalid vesting schedule, cliff %d and vesting %d blocks", tx.CliffBlocks, tx.VestingBlocks)
+	}
+	if tx.VestingBlocks > vstate.MaxVestingBlocks ||
+		uint64(t.state.CurrentHeight())+uint64(tx.VestingBlocks) > uint64(^uint32(0)) {
+		return common.Address{}, common.Address{}, fmt.Errorf(
+			"vesting period too long, the maximum is %d blocks", vstate.MaxVestingBlocks)
+	}
+	from, acc, err := t.state.AccountFromSignature(vtx.SignedBody, vtx.Signature)
+	if err != nil {
+		return common.Address{}, common.Address{}, err
+	}
+	if tx.Nonce != acc.Nonce {
+		return common.Address{}, common.Address{}, fmt.Errorf("invalid nonce, expected %d got %d", acc.Nonce, tx.Nonce)
+	}
+	to := common.BytesToAddress(tx.To)
+	if to == *from {
+		return common.Address{}, common.Address{}, fmt.Errorf("cannot send vesting tokens to the same account")
+	}
+	toAcc, err := t.state.GetAccount(to, false)
+	if err != nil {
+		return common.Address{}, common.Address{}, fmt.Errorf("cannot get to account: %w", err)
+	}
+	if toAcc == nil {
+		return common.Address{}, common.Address{}, vstate.ErrAccountNotExist
+	}
+	if err := t.state.CheckVestingTransfer(*from, to); err != nil {
+		return common.Address{}, common.Address{}, err
+	}
+	cost, err := t.state.TxCost(models.TxType_SEND_VESTING_TOKENS, false)
+	if err != nil {
+		return common.Address{}, common.Address{}, err
+	}
+	if tx.Value > acc.Balance || acc.Balance-tx.Value < cost {
+		return common.Address{}, common.Address{}, vstate.ErrNotEnoughBalance
+	}
+	return *from, to, nil
+}
@@ -0,0 +1,48 @@
+package vochaintx
+
+import (
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+// Expiry is the validity window of a transaction, which cannot be included in
+// a block after the expiry. If both the height and the timestamp are set,
+// both are enforced. Since the expiry is part of the signed transaction body,
+// it cannot be modified by a relayer.
+type Expiry struct {
+	// Height is the last block height the transaction can be included in,
+	// zero if unset.
+	Height uint32
+	// Timestamp is the last block timestamp, in unix seconds, the transaction
+	// can be included in, zero if unset.
+	Timestamp uint64
+}
+
+// Expired returns true if a transaction with the expiry cannot be included in
+// a block of the given height and timestamp.
+func (e *Expiry) Expired(height uint32, timestamp int64) bool {
+	if e.Height > 0 && height > e.Height {
+		return true
+	}
+	return e.Timestamp > 0 && timestamp > 0 && uint64(timestamp) > e.Timestamp
+}
+
+// SetTxExpiry sets the expiry of the transaction, replacing the previous one.
+// A nil expiry removes it. The transaction must be signed afterwards.
+func SetTxExpiry(tx *models.Tx, e *Expiry) {
+	if e == nil {
+		e = &Expiry{}
+	}
+	tx.ValidUntilHeight = e.Height
+	tx.ValidUntilTimestamp = e.Timestamp
+}
+
+// GetTxExpiry returns the expiry of the transaction, or nil if it has none.
+func GetTxExpiry(tx *models.Tx) *Expiry {
+	if tx.GetValidUntilHeight() == 0 && tx.GetValidUntilTimestamp() == 0 {
+		return nil
+	}
+	return &Expiry{
+		Height:    tx.GetValidUntilHeight(),
+		Timestamp: tx.GetValidUntilTimestamp(),
+	}
+}
@@ -0,0 +1,63 @@
+package vochaintx
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+// Sponsorship is the sponsorship of a transaction, whose cost is paid by the
+// sponsor instead of the transaction signer.
+type Sponsorship struct {
+	// Address is the sponsor address, recovered from the signature.
+	Address common.Address
+	// ElectionID is the optional election the cost is accounted to, for the
+	// spending limits of the sponsor.
+	ElectionID types.HexBytes
+	// Signature is the signature of the sponsor over the transaction, the
+	// election and the chain.
+	Signature types.HexBytes
+}
+
+// SponsorSignedTx adds the sponsorship of the given sponsor to the signed
+// transaction. The electionID is optional.
+func SponsorSignedTx(stx *models.SignedTx, sponsor *ethereum.SignKeys, electionID []byte, chainID string) error {
+	if stx.Sponsorship != nil {
+		return errors.New("transaction already sponsored")
+	}
+	signature, err := sponsor.SignVocdoniSponsoredTx(stx.GetTx(), electionID, chainID)
+	if err != nil {
+		return err
+	}
+	stx.Sponsorship = &models.Sponsorship{
+		Signature:  signature,
+		ElectionId: electionID,
+	}
+	return nil
+}
+
+// GetSponsorship returns the sponsorship of the signed transaction, or nil if
+// it is not sponsored.
+func GetSponsorship(stx *models.SignedTx, chainID string) (*Sponsorship, error) {
+	if stx.GetSponsorship() == nil {
+		return nil, nil
+	}
+	s := &Sponsorship{
+		ElectionID: stx.Sponsorship.GetElectionId(),
+		Signature:  stx.Sponsorship.GetSignature(),
+	}
+	if len(s.ElectionID) > 0 && len(s.ElectionID) != types.ProcessIDsize {
+		return nil, fmt.Errorf("invalid sponsorship election id %x", s.ElectionID)
+	}
+	var err error
+	s.Address, err = ethereum.AddrFromSignature(
+		ethereum.BuildVocdoniSponsoredTransaction(stx.GetTx(), s.ElectionID, chainID), s.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sponsor signature: %w", err)
+	}
+	return s, nil
+}
@@ -18,6 +18,10 @@ type VochainTx struct {
 	Signature   []byte
 	TxID        [32]byte
 	TxModelType string
+	// Sponsor is the sponsorship of the transaction, nil if it is not sponsored.
+	Sponsor *Sponsorship
+	// Expiry is the validity window of the transaction, nil if it never expires.
+	Expiry *Expiry
 }
 
 // Unmarshal unarshal the content of a bytes serialized transaction.
@@ -33,7 +37,14 @@ func (tx *VochainTx) Unmarshal(content []byte, chainID string) error {
 		return err
 	}
 
-	tx.TxModelType = string(tx.Tx.ProtoReflect().WhichOneof(tx.Tx.ProtoReflect().Descriptor().Oneofs().Get(0)).Name())
+	if tx.Tx.Payload != nil {
+		tx.TxModelType = string(tx.Tx.ProtoReflect().WhichOneof(tx.Tx.ProtoReflect().Descriptor().Oneofs().Get(0)).Name())
+	}
+	var err error
+	if tx.Sponsor, err = GetSponsorship(stx, chainID); err != nil {
+		return err
+	}
+	tx.Expiry = GetTxExpiry(tx.Tx)
 	tx.Signature = stx.GetSignature()
 	tx.TxID = TxKey(content)
 	tx.SignedBody = ethereum.BuildVocdoniTransaction(stx.GetTx(), chainID)
@@ -52,3 +63,16 @@ type TokenTransfer struct {
 	Amount      uint64
 	TxHash      []byte
 }
+
+// TokenVesting wraps information about a vesting schedule, created by a
+// vesting transfer and updated each time its vested tokens are unlocked.
+type TokenVesting struct {
+	TxHash      []byte
+	FromAddress common.Address
+	ToAddress   common.Address
+	Amount      uint64
+	Released    uint64
+	StartHeight uint32
+	CliffHeight uint32
+	EndHeight   uint32
+}
@@ -3,8 +3,11 @@ package vochain
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	qt "github.com/frankban/quicktest"
+	abcitypes "github.com/tendermint/tendermint/abci/types"
+	"go.vocdoni.io/dvote/db/metadb"
 	"go.vocdoni.io/dvote/vochain/genesis"
 	"go.vocdoni.io/proto/build/go/models"
 )
@@ -23,6 +26,8 @@ func TestTransactionCostsAsMap(t *testing.T) {
 		DelDelegateForAccount:   1000,
 		CollectFaucet:           1100,
 		CreateAccount:           1200,
+		SetSponsorLimits:        1300,
+		SendVestingTokens:       1400,
 	}
 	txCostsBytes := txCosts.AsMap()
 
@@ -39,9 +44,41 @@ func TestTransactionCostsAsMap(t *testing.T) {
 		models.TxType_DEL_DELEGATE_FOR_ACCOUNT:   1000,
 		models.TxType_COLLECT_FAUCET:             1100,
 		models.TxType_CREATE_ACCOUNT:             1200,
+		models.TxType_SET_SPONSOR_LIMITS:         1300,
+		models.TxType_SEND_VESTING_TOKENS:        1400,
 	}
 	qt.Assert(t, txCostsBytes, qt.DeepEquals, expected)
+
+	// the costs added later are not set when unset
+	txCosts.SetSponsorLimits = 0
+	txCosts.SendVestingTokens = 0
+	delete(expected, models.TxType_SET_SPONSOR_LIMITS)
+	delete(expected, models.TxType_SEND_VESTING_TOKENS)
+	qt.Assert(t, txCosts.AsMap(), qt.DeepEquals, expected)
 }
+
+func TestTransactionCostsGenesis(t *testing.T) {
+	app, err := NewBaseApplication(metadb.ForTest(), t.TempDir())
+	qt.Assert(t, err, qt.IsNil)
+	app.SetTestingMethods()
+	t.Cleanup(func() { qt.Assert(t, app.State.Close(), qt.IsNil) })
+	app.InitChain(abcitypes.RequestInitChain{
+		Time:          time.Now(),
+		ChainId:       "test",
+		AppStateBytes: []byte(`{"tx_cost":{"Tx_NewProcess":10,"Tx_SetSponsorLimits":20,"Tx_SendVestingTokens":30}}`),
+	})
+
+	cost, err := app.State.TxCost(models.TxType_NEW_PROCESS, false)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, cost, qt.Equals, uint64(10))
+	cost, err = app.State.TxCost(models.TxType_SET_SPONSOR_LIMITS, false)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, cost, qt.Equals, uint64(20))
+	cost, err = app.State.TxCost(models.TxType_SEND_VESTING_TOKENS, false)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, cost, qt.Equals, uint64(30))
+}
+
 func TestTxCostNameToTxType(t *testing.T) {
 	fields := map[string]models.TxType{
 		"SetProcessStatus":        models.TxType_SET_PROCESS_STATUS,
@@ -56,6 +93,8 @@ func TestTxCostNameToTxType(t *testing.T) {
 		"DelDelegateForAccount":   models.TxType_DEL_DELEGATE_FOR_ACCOUNT,
 		"CollectFaucet":           models.TxType_COLLECT_FAUCET,
 		"CreateAccount":           models.TxType_CREATE_ACCOUNT,
+		"SetSponsorLimits":        models.TxType_SET_SPONSOR_LIMITS,
+		"SendVestingTokens":       models.TxType_SEND_VESTING_TOKENS,
 	}
 	for k, v := range fields {
 		qt.Assert(t, genesis.TxCostNameToTxType(k), qt.Equals, v)
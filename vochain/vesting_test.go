@@ -0,0 +1,238 @@
+package vochain
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain/state"
+	"go.vocdoni.io/dvote/vochain/transaction/vochaintx"
+	"go.vocdoni.io/proto/build/go/models"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSendVestingTokens(t *testing.T) {
+	app, signers, err := setupTestBaseApplicationAndSigners(t, 2)
+	qt.Assert(t, err, qt.IsNil)
+	treasurer, grantee := signers[0], signers[1]
+	qt.Assert(t, app.State.SetTxCost(models.TxType_SEND_VESTING_TOKENS, 10), qt.IsNil)
+	qt.Assert(t, app.State.SetAccount(treasurer.Address(), &state.Account{
+		Account: models.Account{Balance: 10000},
+	}), qt.IsNil)
+	qt.Assert(t, app.State.SetAccount(grantee.Address(), &state.Account{}), qt.IsNil)
+	app.AdvanceTestBlock()
+
+	// invalid schedules and transfers
+	_, err = testSendVestingTokensTx(t, app, treasurer, grantee, 1000, 20, 10, 0)
+	qt.Assert(t, err, qt.ErrorMatches, ".*invalid vesting schedule.*")
+	_, err = testSendVestingTokensTx(t, app, treasurer, grantee, 1000, 0, 0, 0)
+	qt.Assert(t, err, qt.ErrorMatches, ".*invalid vesting schedule.*")
+	_, err = testSendVestingTokensTx(t, app, treasurer, grantee, 1000, 0, state.MaxVestingBlocks+1, 0)
+	qt.Assert(t, err, qt.ErrorMatches, ".*vesting period too long.*")
+	_, err = testSendVestingTokensTx(t, app, treasurer, grantee, state.MinVestingAmount-1, 0, 10, 0)
+	qt.Assert(t, err, qt.ErrorMatches, ".*minimum vesting amount.*")
+	_, err = testSendVestingTokensTx(t, app, treasurer, grantee, 10000, 0, 10, 0)
+	qt.Assert(t, err, qt.ErrorMatches, ".*not enough balance.*")
+	_, err = testSendVestingTokensTx(t, app, treasurer, treasurer, 1000, 0, 10, 0)
+	qt.Assert(t, err, qt.ErrorMatches, ".*same account.*")
+
+	// 1000 tokens with a cliff of 4 blocks, vesting in 10 blocks
+	start := app.State.CurrentHeight()
+	txHash, err := testSendVestingTokensTx(t, app, treasurer, grantee, 1000, 4, 10, 0)
+	qt.Assert(t, err, qt.IsNil)
+	testCheckBalance(t, app, treasurer, 10000-1000-10, 1)
+	testCheckBalance(t, app, grantee, 0, 0)
+	locked, err := app.State.LockedBalance(grantee.Address(), true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, locked, qt.Equals, uint64(1000))
+	schedule, err := app.State.VestingSchedule(txHash, true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, schedule, qt.DeepEquals, &state.VestingSchedule{
+		TxHash:      txHash,
+		From:        treasurer.Address(),
+		To:          grantee.Address(),
+		Amount:      1000,
+		StartHeight: start,
+		CliffHeight: start + 4,
+		EndHeight:   start + 10,
+	})
+
+	// nothing is unlocked during the cliff
+	for app.State.CurrentHeight() < start+4 {
+		app.AdvanceTestBlock()
+	}
+	testCheckBalance(t, app, grantee, 0, 0)
+	// and then the vested amount is unlocked on each commit
+	app.AdvanceTestBlock()
+	testCheckBalance(t, app, grantee, 400, 0)
+	app.AdvanceTestBlock()
+	testCheckBalance(t, app, grantee, 500, 0)
+	locked, err = app.State.LockedBalance(grantee.Address(), true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, locked, qt.Equals, uint64(500))
+
+	// the locked tokens cannot be spent
+	stx := &models.SignedTx{}
+	stx.Tx, err = proto.Marshal(&models.Tx{Payload: &models.Tx_SendTokens{SendTokens: &models.SendTokensTx{
+		Txtype: models.TxType_SEND_TOKENS,
+		From:   grantee.Address().Bytes(),
+		To:     treasurer.Address().Bytes(),
+		Value:  600,
+	}}})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, sendTx(app, grantee, stx), qt.ErrorMatches, ".*not enough balance.*")
+
+	// a second schedule without cliff
+	txHash2, err := testSendVestingTokensTx(t, app, treasurer, grantee, 100, 0, 2, 1)
+	qt.Assert(t, err, qt.IsNil)
+	locked, err = app.State.LockedBalance(grantee.Address(), true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, locked, qt.Equals, uint64(400+100))
+
+	// the schedules completely released are removed
+	for app.State.CurrentHeight() < start+12 {
+		app.AdvanceTestBlock()
+	}
+	testCheckBalance(t, app, grantee, 1100, 0)
+	testCheckBalance(t, app, treasurer, 10000-1100-20, 2)
+	locked, err = app.State.LockedBalance(grantee.Address(), true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, locked, qt.Equals, uint64(0))
+	for _, h := range [][]byte{txHash, txHash2} {
+		schedule, err = app.State.VestingSchedule(h, true)
+		qt.Assert(t, err, qt.IsNil)
+		qt.Assert(t, schedule, qt.IsNil)
+	}
+}
+
+func TestVestingSchedulesLimit(t *testing.T) {
+	app, signers, err := setupTestBaseApplicationAndSigners(t, 3)
+	qt.Assert(t, err, qt.IsNil)
+	treasurer, grantee, other := signers[0], signers[1], signers[2]
+	qt.Assert(t, app.State.SetTxCost(models.TxType_SEND_VESTING_TOKENS, 10), qt.IsNil)
+	for _, s := range signers {
+		qt.Assert(t, app.State.SetAccount(s.Address(), &state.Account{
+			Account: models.Account{Balance: 100000},
+		}), qt.IsNil)
+	}
+	app.AdvanceTestBlock()
+
+	// the schedules are limited for each sender and recipient
+	for i := uint32(0); i < state.MaxVestingSchedules; i++ {
+		_, err := testSendVestingTokensTx(t, app, treasurer, grantee, 100, 0, 1000, i)
+		qt.Assert(t, err, qt.IsNil)
+	}
+	_, err = testSendVestingTokensTx(t, app, treasurer, grantee, 100, 0, 1000, state.MaxVestingSchedules)
+	qt.Assert(t, err, qt.ErrorMatches, ".*too many vesting schedules.*")
+	_, err = testSendVestingTokensTx(t, app, other, grantee, 100, 0, 1000, 0)
+	qt.Assert(t, err, qt.IsNil)
+	_, err = testSendVestingTokensTx(t, app, treasurer, other, 100, 0, 1000, state.MaxVestingSchedules)
+	qt.Assert(t, err, qt.IsNil)
+}
+
+func TestVestingUnlocks(t *testing.T) {
+	app, signers, err := setupTestBaseApplicationAndSigners(t, 1)
+	qt.Assert(t, err, qt.IsNil)
+	treasurer := signers[0]
+	qt.Assert(t, app.State.SetAccount(treasurer.Address(), &state.Account{
+		Account: models.Account{Balance: 1000000},
+	}), qt.IsNil)
+	app.AdvanceTestBlock()
+
+	// the long schedules are unlocked in steps
+	start := app.State.CurrentHeight()
+	grantee := common.BytesToAddress(util.RandomBytes(common.AddressLength))
+	qt.Assert(t, app.State.SetAccount(grantee, &state.Account{}), qt.IsNil)
+	qt.Assert(t, app.State.TransferVestingTokens(&state.VestingSchedule{
+		TxHash:      util.RandomBytes(32),
+		From:        treasurer.Address(),
+		To:          grantee,
+		Amount:      1000,
+		StartHeight: start,
+		EndHeight:   start + 100*state.MaxVestingUnlocks,
+	}), qt.IsNil)
+	app.AdvanceTestBlock()
+	for app.State.CurrentHeight() < start+100 {
+		app.AdvanceTestBlock()
+	}
+	testCheckAccountBalance(t, app, grantee, 0)
+	app.AdvanceTestBlock()
+	testCheckAccountBalance(t, app, grantee, 10)
+
+	// the schedules due on a full block are unlocked on the next one
+	start = app.State.CurrentHeight()
+	var grantees []common.Address
+	for i := 0; i < state.MaxVestingUnlocksPerBlock+1; i++ {
+		grantee := common.BytesToAddress(util.RandomBytes(common.AddressLength))
+		qt.Assert(t, app.State.SetAccount(grantee, &state.Account{}), qt.IsNil)
+		qt.Assert(t, app.State.TransferVestingTokens(&state.VestingSchedule{
+			TxHash:      util.RandomBytes(32),
+			From:        treasurer.Address(),
+			To:          grantee,
+			Amount:      100,
+			StartHeight: start,
+			EndHeight:   start + 1,
+		}), qt.IsNil)
+		grantees = append(grantees, grantee)
+	}
+	app.AdvanceTestBlock()
+	app.AdvanceTestBlock()
+	for _, grantee := range grantees[:state.MaxVestingUnlocksPerBlock] {
+		testCheckAccountBalance(t, app, grantee, 100)
+	}
+	testCheckAccountBalance(t, app, grantees[state.MaxVestingUnlocksPerBlock], 0)
+	app.AdvanceTestBlock()
+	testCheckAccountBalance(t, app, grantees[state.MaxVestingUnlocksPerBlock], 100)
+}
+
+func TestVestingScheduleVested(t *testing.T) {
+	s := &state.VestingSchedule{Amount: 1000, StartHeight: 100, CliffHeight: 150, EndHeight: 300}
+	for height, vested := range map[uint32]uint64{
+		0: 0, 100: 0, 149: 0, 150: 250, 151: 255, 299: 995, 300: 1000, 1000: 1000,
+	} {
+		qt.Assert(t, s.Vested(height), qt.Equals, vested, qt.Commentf("height %d", height))
+	}
+	// the vested amount does not overflow
+	s = &state.VestingSchedule{Amount: ^uint64(0), StartHeight: 0, EndHeight: 4}
+	qt.Assert(t, s.Vested(2), qt.Equals, ^uint64(0)/2)
+}
+
+// testSendVestingTokensTx sends a SendVestingTokensTx and commits the block,
+// returning the transaction hash.
+func testSendVestingTokensTx(t *testing.T, app *BaseApplication, signer, to *ethereum.SignKeys,
+	value uint64, cliff, vesting, nonce uint32) ([]byte, error) {
+	var err error
+	stx := &models.SignedTx{}
+	if stx.Tx, err = proto.Marshal(&models.Tx{
+		Payload: &models.Tx_SendVestingTokens{
+			SendVestingTokens: &models.SendVestingTokensTx{
+				Txtype:        models.TxType_SEND_VESTING_TOKENS,
+				Nonce:         nonce,
+				To:            to.Address().Bytes(),
+				Value:         value,
+				CliffBlocks:   cliff,
+				VestingBlocks: vesting,
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sendTx(app, signer, stx); err != nil {
+		return nil, err
+	}
+	stxBytes, err := proto.Marshal(stx)
+	qt.Assert(t, err, qt.IsNil)
+	app.AdvanceTestBlock()
+	txHash := vochaintx.TxKey(stxBytes)
+	return txHash[:], nil
+}
+
+// testCheckAccountBalance checks the committed balance of the account.
+func testCheckAccountBalance(t *testing.T, app *BaseApplication, address common.Address, balance uint64) {
+	acc, err := app.State.GetAccount(address, true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, acc, qt.IsNotNil)
+	qt.Assert(t, acc.Balance, qt.Equals, balance)
+}
@@ -30,8 +30,12 @@ type VochainInfo struct {
 	avg360          int32
 	avg1440         int32
 	vnode           *vochain.BaseApplication
-	close           chan bool
-	lock            sync.RWMutex
+	// now and blockTime replace the local time and the measured block times
+	// on the estimations, if a clock is set with SetClock
+	now       func() time.Time
+	blockTime time.Duration
+	close     chan bool
+	lock      sync.RWMutex
 }
 
 // NewVochainInfo creates a new VochainInfo type
@@ -57,14 +61,37 @@ func (vi *VochainInfo) BlockTimes() *[5]int32 {
 	return &[5]int32{vi.avg1, vi.avg10, vi.avg60, vi.avg360, vi.avg1440}
 }
 
+// SetClock makes the height and time estimations use the given clock and a
+// fixed block time, instead of the local time and the measured block times.
+// It is meant for chains whose blocks and time are driven by the caller,
+// such as a vocone in test mode.
+func (vi *VochainInfo) SetClock(now func() time.Time, blockTime time.Duration) {
+	vi.lock.Lock()
+	defer vi.lock.Unlock()
+	vi.now = now
+	vi.blockTime = blockTime
+}
+
+// estimationBase returns the current time, the block times in milliseconds
+// and the current height on which the estimations are based.
+func (vi *VochainInfo) estimationBase() (time.Time, *[5]int32, int64) {
+	vi.lock.RLock()
+	now, blockTime := vi.now, vi.blockTime
+	vi.lock.RUnlock()
+	if now == nil {
+		return time.Now(), vi.BlockTimes(), vi.Height()
+	}
+	t := int32(blockTime.Milliseconds())
+	return now(), &[5]int32{t, t, t, t, t}, int64(vi.vnode.Height())
+}
+
 // EstimateBlockHeight provides an estimation time for a future blockchain height number.
 func (vi *VochainInfo) EstimateBlockHeight(target time.Time) (uint32, error) {
-	currentTime := time.Now()
+	currentTime, times, height := vi.estimationBase()
 	// diff time in seconds
 	diffTime := target.Unix() - currentTime.Unix()
 
 	// block time in ms
-	times := vi.BlockTimes()
 	getMaxTimeFrom := func(i int) uint32 {
 		for ; i >= 0; i-- {
 			if times[i] != 0 {
@@ -92,7 +119,7 @@ func (vi *VochainInfo) EstimateBlockHeight(target time.Time) (uint32, error) {
 	}
 	// Multiply by 1000 because t is represented in seconds, not ms.
 	// Dividing t first can floor the integer, leading to divide-by-zero
-	currentHeight := uint32(vi.Height())
+	currentHeight := uint32(height)
 	blockDiff := (uint32(absDiff*1000) / t)
 	if inPast {
 		if blockDiff > currentHeight {
@@ -106,8 +133,7 @@ func (vi *VochainInfo) EstimateBlockHeight(target time.Time) (uint32, error) {
 // HeightTime estimates the UTC time for a future height or returns the
 // block timestamp if height is in the past.
 func (vi *VochainInfo) HeightTime(height int64) time.Time {
-	times := vi.BlockTimes()
-	currentHeight := vi.Height()
+	currentTime, times, currentHeight := vi.estimationBase()
 	diffHeight := height - currentHeight
 
 	if diffHeight < 0 {
@@ -140,7 +166,7 @@ func (vi *VochainInfo) HeightTime(height int64) time.Time {
 	case diffHeight >= 1000:
 		t = getMaxTimeFrom(4)
 	}
-	return time.Now().Add(time.Duration(diffHeight*t) * time.Millisecond)
+	return currentTime.Add(time.Duration(diffHeight*t) * time.Millisecond)
 }
 
 // Sync returns true if the Vochain is considered up-to-date
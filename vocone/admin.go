@@ -0,0 +1,160 @@
+package vocone
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/apirest"
+)
+
+// maxAdvanceBlocks is the maximum number of blocks produced by a single
+// request to the blocks/advance endpoint.
+const maxAdvanceBlocks = 10000
+
+// ClockResponse is the height of the last block and the current time of the
+// vocone clock.
+type ClockResponse struct {
+	Height int64     `json:"height"`
+	Time   time.Time `json:"time"`
+}
+
+// AdvanceBlocksRequest is the number of blocks to produce.
+type AdvanceBlocksRequest struct {
+	Blocks int `json:"blocks"`
+}
+
+// AdvanceClockRequest moves the vocone clock forward by the given seconds
+// or, if Time is set, to the given time.
+type AdvanceClockRequest struct {
+	Seconds int64      `json:"seconds,omitempty"`
+	Time    *time.Time `json:"time,omitempty"`
+}
+
+// AttachAdminAPI attaches the test-mode endpoints of the vocone to the given
+// http apirest router, which must have an admin token configured.
+// The path prefix is used to define the base path in which the endpoint methods will be registered.
+// For example, if the pathPrefix is "/vocone", the resulting endpoints are /vocone/clock,
+// /vocone/clock/advance, /vocone/blocks/commit and /vocone/blocks/advance.
+func (vc *Vocone) AttachAdminAPI(a *apirest.API, pathPrefix string) error {
+	if err := a.RegisterMethod(
+		fmt.Sprintf("%s/clock", pathPrefix),
+		"GET",
+		apirest.MethodAccessTypeAdmin,
+		vc.clockHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.RegisterMethod(
+		fmt.Sprintf("%s/clock/advance", pathPrefix),
+		"POST",
+		apirest.MethodAccessTypeAdmin,
+		vc.advanceClockHandler,
+	); err != nil {
+		return err
+	}
+	if err := a.RegisterMethod(
+		fmt.Sprintf("%s/blocks/commit", pathPrefix),
+		"POST",
+		apirest.MethodAccessTypeAdmin,
+		vc.commitBlockHandler,
+	); err != nil {
+		return err
+	}
+	return a.RegisterMethod(
+		fmt.Sprintf("%s/blocks/advance", pathPrefix),
+		"POST",
+		apirest.MethodAccessTypeAdmin,
+		vc.advanceBlocksHandler,
+	)
+}
+
+// clockHandler
+//
+//	@Summary		Vocone clock
+//	@Description	Returns the height of the last block and the current time of the vocone clock.
+//	@Description	Requires the admin bearer token.
+//	@Tags			Vocone
+//	@Produce		json
+//	@Success		200	{object}	vocone.ClockResponse
+//	@Router			/vocone/clock [get]
+func (vc *Vocone) clockHandler(_ *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	return vc.sendClock(ctx)
+}
+
+// advanceClockHandler
+//
+//	@Summary		Advance the vocone clock
+//	@Description	Moves the vocone clock forward, so the timestamp of the next block jumps ahead.
+//	@Description	The clock cannot be moved back. Requires the admin bearer token.
+//	@Tags			Vocone
+//	@Accept			json
+//	@Produce		json
+//	@Param			transaction	body		vocone.AdvanceClockRequest	true	"Seconds to advance or time to set"
+//	@Success		200			{object}	vocone.ClockResponse
+//	@Router			/vocone/clock/advance [post]
+func (vc *Vocone) advanceClockHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	req := &AdvanceClockRequest{}
+	if err := json.Unmarshal(msg.Data, req); err != nil {
+		return api.ErrCantParseDataAsJSON.WithErr(err)
+	}
+	var err error
+	if req.Time != nil {
+		err = vc.SetTime(*req.Time)
+	} else {
+		err = vc.AdvanceTime(time.Duration(req.Seconds) * time.Second)
+	}
+	if err != nil {
+		return api.ErrCantMoveClockBack.WithErr(err)
+	}
+	return vc.sendClock(ctx)
+}
+
+// commitBlockHandler
+//
+//	@Summary		Commit a block
+//	@Description	Produces a new block with the transactions of the mempool. Requires the admin bearer token.
+//	@Tags			Vocone
+//	@Produce		json
+//	@Success		200	{object}	vocone.ClockResponse
+//	@Router			/vocone/blocks/commit [post]
+func (vc *Vocone) commitBlockHandler(_ *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	vc.CommitBlock()
+	return vc.sendClock(ctx)
+}
+
+// advanceBlocksHandler
+//
+//	@Summary		Advance blocks
+//	@Description	Produces the given number of blocks, moving the vocone clock forward by the block time
+//	@Description	before each one. Requires the admin bearer token.
+//	@Tags			Vocone
+//	@Accept			json
+//	@Produce		json
+//	@Param			transaction	body		vocone.AdvanceBlocksRequest	true	"Number of blocks"
+//	@Success		200			{object}	vocone.ClockResponse
+//	@Router			/vocone/blocks/advance [post]
+func (vc *Vocone) advanceBlocksHandler(msg *apirest.APIdata, ctx *httprouter.HTTPContext) error {
+	req := &AdvanceBlocksRequest{}
+	if err := json.Unmarshal(msg.Data, req); err != nil {
+		return api.ErrCantParseDataAsJSON.WithErr(err)
+	}
+	if req.Blocks < 1 || req.Blocks > maxAdvanceBlocks {
+		return api.ErrInvalidBlockCount.Withf("%d, must be between 1 and %d", req.Blocks, maxAdvanceBlocks)
+	}
+	vc.AdvanceBlocks(req.Blocks)
+	return vc.sendClock(ctx)
+}
+
+func (vc *Vocone) sendClock(ctx *httprouter.HTTPContext) error {
+	data, err := json.Marshal(&ClockResponse{
+		Height: int64(vc.app.Height()),
+		Time:   vc.Now(),
+	})
+	if err != nil {
+		return api.ErrMarshalingServerJSONFailed.WithErr(err)
+	}
+	return ctx.Send(data, apirest.HTTPstatusOK)
+}
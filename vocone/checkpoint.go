@@ -0,0 +1,113 @@
+package vocone
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	abcitypes "github.com/tendermint/tendermint/abci/types"
+	"go.vocdoni.io/dvote/db"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/vochain/state"
+)
+
+// checkpointsDirectory is the data directory subfolder where the indexer
+// database copies of the checkpoints are stored.
+const checkpointsDirectory = "checkpoints"
+
+// checkpoint is a copy of the vocone databases and chain status, which is
+// created by Checkpoint and restored by Restore.
+type checkpoint struct {
+	height      int64
+	appHeight   uint32
+	stateHeight uint32
+	timeOffset  int64
+	state       *state.Checkpoint
+	keykeeper   []db.KeyValue
+	blockStore  []db.KeyValue
+	indexer     string
+}
+
+// Checkpoint saves a copy of the current vocone chain, which includes the
+// state, the indexer database, the keykeeper database and the block store.
+// It returns the checkpoint id to be used with Restore, so a prepared chain
+// can be reused by many test scenarios. The copies are kept in memory, except
+// for the indexer database which is copied to the data directory, so the
+// checkpoints are only suitable for small chains and are lost on restart.
+func (vc *Vocone) Checkpoint() (int, error) {
+	vc.vcMtx.Lock()
+	defer vc.vcMtx.Unlock()
+	dir := filepath.Join(vc.dataDir, checkpointsDirectory)
+	if len(vc.checkpoints) == 0 {
+		// remove the indexer copies of a previous run
+		if err := os.RemoveAll(dir); err != nil {
+			return 0, err
+		}
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return 0, err
+		}
+	}
+	id := len(vc.checkpoints)
+	cp := &checkpoint{
+		height:      vc.height.Load(),
+		appHeight:   vc.app.Height(),
+		stateHeight: vc.app.State.CurrentHeight(),
+		timeOffset:  vc.timeOffset.Load(),
+		indexer:     filepath.Join(dir, fmt.Sprintf("indexer-%d.sqlite", id)),
+	}
+	var err error
+	if cp.state, err = vc.app.State.Checkpoint(); err != nil {
+		return 0, fmt.Errorf("cannot checkpoint state: %w", err)
+	}
+	if cp.keykeeper, err = vc.kk.Checkpoint(); err != nil {
+		return 0, fmt.Errorf("cannot checkpoint keykeeper: %w", err)
+	}
+	if cp.blockStore, err = db.ReadAll(vc.blockStore); err != nil {
+		return 0, fmt.Errorf("cannot checkpoint blockstore: %w", err)
+	}
+	if err := vc.sc.Backup(cp.indexer); err != nil {
+		return 0, fmt.Errorf("cannot checkpoint indexer: %w", err)
+	}
+	vc.checkpoints = append(vc.checkpoints, cp)
+	log.Infow("created checkpoint", "id", id, "height", cp.height)
+	return id, nil
+}
+
+// Restore rollbacks the vocone chain to the given checkpoint, discarding the
+// transactions of the mempool. A checkpoint can be restored many times, and
+// the checkpoints created after it are kept.
+func (vc *Vocone) Restore(id int) error {
+	vc.vcMtx.Lock()
+	defer vc.vcMtx.Unlock()
+	if id < 0 || id >= len(vc.checkpoints) {
+		return fmt.Errorf("checkpoint %d not found", id)
+	}
+	cp := vc.checkpoints[id]
+txLoop:
+	for {
+		select {
+		case <-vc.mempool:
+		default:
+			break txLoop
+		}
+	}
+	if err := vc.app.State.RestoreCheckpoint(cp.state); err != nil {
+		return fmt.Errorf("cannot restore state: %w", err)
+	}
+	if err := vc.kk.RestoreCheckpoint(cp.keykeeper); err != nil {
+		return fmt.Errorf("cannot restore keykeeper: %w", err)
+	}
+	if err := db.ReplaceAll(vc.blockStore, cp.blockStore); err != nil {
+		return fmt.Errorf("cannot restore blockstore: %w", err)
+	}
+	if err := vc.sc.RestoreBackup(cp.indexer); err != nil {
+		return fmt.Errorf("cannot restore indexer: %w", err)
+	}
+	vc.app.PurgeBlockCache()
+	vc.height.Store(cp.height)
+	vc.timeOffset.Store(cp.timeOffset)
+	vc.app.EndBlock(abcitypes.RequestEndBlock{Height: int64(cp.appHeight)})
+	vc.app.State.SetHeight(cp.stateHeight)
+	log.Infow("restored checkpoint", "id", id, "height", cp.height)
+	return nil
+}
@@ -0,0 +1,117 @@
+package vocone
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.vocdoni.io/dvote/db"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/vochain/state"
+)
+
+// ForkConfig defines the state a vocone is forked from, either a state
+// snapshot file or a copy of the data directory of a vochain node.
+type ForkConfig struct {
+	// Snapshot is the path of a state snapshot file, as created by
+	// state.Snapshot.
+	Snapshot string
+	// DataDir is the path of a copy of the data directory of a vochain node.
+	// It must not be in use by a running node.
+	DataDir string
+	// Height is the height of the state loaded from DataDir. If zero, the
+	// last committed state is loaded.
+	Height uint32
+}
+
+// fork installs the forked state and removes its oracles, so only the local
+// ones are used. It returns false if the state was already initialized, so
+// the fork is skipped.
+func (vc *Vocone) fork(fork *ForkConfig) (bool, error) {
+	version, err := vc.app.State.Store.Version()
+	if err != nil {
+		return false, err
+	}
+	if version > 0 {
+		log.Warnw("vocone state already initialized, skipping fork", "height", version)
+		return false, nil
+	}
+	snapshot := fork.Snapshot
+	if fork.DataDir != "" {
+		if snapshot, err = snapshotDataDir(fork.DataDir, fork.Height); err != nil {
+			return false, err
+		}
+	}
+	if snapshot == "" {
+		return false, fmt.Errorf("no snapshot or data directory to fork from")
+	}
+	header, err := vc.app.State.InstallSnapshot(snapshot)
+	if err != nil {
+		return false, err
+	}
+	log.Infow("forked state", "height", header.Height, "chainID", header.ChainID,
+		"root", fmt.Sprintf("%x", header.Root))
+
+	oracles, err := vc.app.State.Oracles(true)
+	if err != nil {
+		return false, err
+	}
+	for _, o := range oracles {
+		if err := vc.app.State.RemoveOracle(o); err != nil {
+			return false, err
+		}
+	}
+	if _, err := vc.app.State.Save(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// snapshotDataDir creates a snapshot of the state stored in a vochain node
+// data directory, at the given height or the last one if zero. The state is
+// looked up on the data directory and on its vochain data subdirectories.
+func snapshotDataDir(dataDir string, height uint32) (string, error) {
+	stateDir := ""
+	for _, dir := range []string{
+		dataDir,
+		filepath.Join(dataDir, "data"),
+		filepath.Join(dataDir, "vochain", "data"),
+	} {
+		// the state database is stored in the vcstate directory
+		if _, err := os.Stat(filepath.Join(dir, "vcstate")); err == nil {
+			stateDir = dir
+			break
+		}
+	}
+	if stateDir == "" {
+		return "", fmt.Errorf("no vochain state found on %s", dataDir)
+	}
+	log.Infow("creating state snapshot", "dir", stateDir, "height", height)
+	st, err := state.NewState(db.TypePebble, stateDir)
+	if err != nil {
+		return "", err
+	}
+	defer st.Close()
+	if height == 0 {
+		return st.Snapshot()
+	}
+	return st.SnapshotAt(height)
+}
+
+// indexProcesses adds the processes of the forked state to the indexer, and
+// recovers the live results of the ones that are not finished.
+func (vc *Vocone) indexProcesses() error {
+	pids, err := vc.app.State.ListProcessIDs(true)
+	if err != nil {
+		return err
+	}
+	for _, pid := range pids {
+		vc.sc.OnProcess(pid, nil, "", "", 0)
+	}
+	if err := vc.sc.Commit(vc.app.State.CurrentHeight()); err != nil {
+		return err
+	}
+	log.Infow("indexed forked processes", "count", len(pids))
+	go vc.sc.AfterSyncBootstrap()
+	return nil
+}
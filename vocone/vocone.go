@@ -1,6 +1,7 @@
 package vocone
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -57,9 +58,17 @@ type Vocone struct {
 	app             *vochain.BaseApplication
 	storage         data.Storage
 	censusdb        *censusdb.CensusDB
+	downloader      *downloader.Downloader
 	lastBlockTime   time.Time
 	blockTimeTarget time.Duration
 	txsPerBlock     int
+	// timeOffset is the duration, in nanoseconds, the vocone clock is ahead
+	// of the local time. It only grows, see AdvanceTime, unless a checkpoint
+	// is restored.
+	timeOffset atomic.Int64
+	// checkpoints are the copies of the chain created by Checkpoint, indexed
+	// by their id.
+	checkpoints []*checkpoint
 	// vcMtx is a lock on modification to the app state.
 	// this enables direct calls to vochain functions from the vocone
 	//  without causing race conditions
@@ -68,6 +77,18 @@ type Vocone struct {
 
 // NewVocone returns a ready Vocone instance.
 func NewVocone(dataDir string, keymanager *ethereum.SignKeys) (*Vocone, error) {
+	return newVocone(dataDir, keymanager, nil)
+}
+
+// NewForkedVocone returns a ready Vocone instance whose state is forked from
+// the given snapshot or node data directory. The validators, oracles and
+// keykeeper of the forked state are replaced by the keymanager key. If the
+// vocone data directory already contains a state, the fork is skipped.
+func NewForkedVocone(dataDir string, keymanager *ethereum.SignKeys, fork *ForkConfig) (*Vocone, error) {
+	return newVocone(dataDir, keymanager, fork)
+}
+
+func newVocone(dataDir string, keymanager *ethereum.SignKeys, fork *ForkConfig) (*Vocone, error) {
 	vc := &Vocone{}
 	var err error
 	vc.dataDir = dataDir
@@ -75,6 +96,12 @@ func NewVocone(dataDir string, keymanager *ethereum.SignKeys) (*Vocone, error) {
 	if err != nil {
 		return nil, err
 	}
+	forked := false
+	if fork != nil {
+		if forked, err = vc.fork(fork); err != nil {
+			return nil, fmt.Errorf("cannot fork state: %w", err)
+		}
+	}
 	vc.mempool = make(chan []byte, mempoolSize)
 	vc.blockTimeTarget = DefaultBlockTimeTarget
 	vc.txsPerBlock = DefaultTxsPerBlock
@@ -97,9 +124,15 @@ func NewVocone(dataDir string, keymanager *ethereum.SignKeys) (*Vocone, error) {
 	}
 
 	// Create burn account
-	if err := vc.CreateAccount(state.BurnAddress, &state.Account{}); err != nil {
+	burnAcc, err := vc.app.State.GetAccount(state.BurnAddress, true)
+	if err != nil {
 		return nil, err
 	}
+	if burnAcc == nil {
+		if err := vc.CreateAccount(state.BurnAddress, &state.Account{}); err != nil {
+			return nil, err
+		}
+	}
 
 	// Create indexer
 	if vc.sc, err = indexer.NewIndexer(
@@ -109,6 +142,11 @@ func NewVocone(dataDir string, keymanager *ethereum.SignKeys) (*Vocone, error) {
 	); err != nil {
 		return nil, err
 	}
+	if forked {
+		if err := vc.indexProcesses(); err != nil {
+			return nil, err
+		}
+	}
 
 	// Create key keeper
 	if err := vc.SetKeyKeeper(keymanager); err != nil {
@@ -126,6 +164,7 @@ func NewVocone(dataDir string, keymanager *ethereum.SignKeys) (*Vocone, error) {
 
 	// Create vochain metrics collector
 	vc.appInfo = vochaininfo.NewVochainInfo(vc.app)
+	vc.appInfo.SetClock(vc.Now, vc.blockTimeTarget)
 	go vc.appInfo.Start(10)
 
 	// Create the IPFS storage layer (we use the Vocdoni general service)
@@ -144,12 +183,22 @@ func NewVocone(dataDir string, keymanager *ethereum.SignKeys) (*Vocone, error) {
 	vc.censusdb = censusdb.NewCensusDB(cdb)
 
 	// Create the data downloader and offchain data handler
+	ddb, err := metadb.New(db.TypePebble, filepath.Join(dataDir, "downloader"))
+	if err != nil {
+		return nil, err
+	}
+	vc.downloader, err = downloader.NewDownloader(vc.storage, ddb)
+	if err != nil {
+		return nil, err
+	}
 	offchaindatahandler.NewOffChainDataHandler(
 		vc.app,
-		downloader.NewDownloader(vc.storage),
+		vc.downloader,
 		vc.censusdb,
+		vc.sc,
 		false,
 	)
+	vc.downloader.Start()
 
 	return vc, err
 }
@@ -182,26 +231,14 @@ func (vc *Vocone) EnableAPI(host string, port int, URLpath string) (*api.API, er
 }
 
 // Start initializes the block production. This method should be run async.
+// Blocks can also be produced on demand with CommitBlock and AdvanceBlocks,
+// without calling Start, which makes the block production deterministic.
 func (vc *Vocone) Start() {
 	vc.lastBlockTime = time.Now()
 	go vochainPrintInfo(10, vc.appInfo)
 
 	for {
-		// Begin block
-		vc.vcMtx.Lock()
-		bblock := abcitypes.RequestBeginBlock{
-			Header: tmprototypes.Header{
-				Time:   time.Now(),
-				Height: vc.height.Load(),
-			},
-		}
-		vc.app.BeginBlock(bblock)
-		// Commit block
-		vc.commitBlock()
-		comres := vc.app.Commit()
-		log.Debugf("commit hash for block %d: %x", bblock.Header.Height, comres.Data)
-		vc.app.EndBlock(abcitypes.RequestEndBlock{Height: bblock.Header.Height})
-		vc.vcMtx.Unlock()
+		vc.CommitBlock()
 
 		// Waiting time
 		sinceLast := time.Since(vc.lastBlockTime)
@@ -209,13 +246,71 @@ func (vc *Vocone) Start() {
 			time.Sleep(vc.blockTimeTarget - sinceLast)
 		}
 		vc.lastBlockTime = time.Now()
-		vc.height.Add(1)
 	}
 }
 
+// CommitBlock produces a new block with the transactions of the mempool, up
+// to the block size, and the current time of the vocone clock. It returns the
+// height of the committed block.
+func (vc *Vocone) CommitBlock() int64 {
+	vc.vcMtx.Lock()
+	defer vc.vcMtx.Unlock()
+	// Begin block
+	bblock := abcitypes.RequestBeginBlock{
+		Header: tmprototypes.Header{
+			Time:   vc.Now(),
+			Height: vc.height.Load(),
+		},
+	}
+	vc.app.BeginBlock(bblock)
+	// Commit block
+	vc.commitBlock(bblock.Header.Time)
+	comres := vc.app.Commit()
+	log.Debugf("commit hash for block %d: %x", bblock.Header.Height, comres.Data)
+	vc.app.EndBlock(abcitypes.RequestEndBlock{Height: bblock.Header.Height})
+	vc.height.Add(1)
+	return bblock.Header.Height
+}
+
+// AdvanceBlocks produces n blocks, moving the vocone clock forward by the
+// block time target before each one, as if they were produced by Start.
+// It returns the height of the last committed block.
+func (vc *Vocone) AdvanceBlocks(n int) int64 {
+	height := vc.height.Load() - 1
+	for i := 0; i < n; i++ {
+		vc.timeOffset.Add(int64(vc.blockTimeTarget))
+		height = vc.CommitBlock()
+	}
+	return height
+}
+
+// Now returns the current time of the vocone clock, used as the timestamp
+// of the new blocks. It is the local time unless the clock was moved forward
+// with AdvanceTime, SetTime or AdvanceBlocks.
+func (vc *Vocone) Now() time.Time {
+	return time.Now().Add(time.Duration(vc.timeOffset.Load()))
+}
+
+// AdvanceTime moves the vocone clock forward by d, so the timestamp of the
+// next block jumps ahead. The clock cannot go back, since the block
+// timestamps must be monotonic.
+func (vc *Vocone) AdvanceTime(d time.Duration) error {
+	if d < 0 {
+		return fmt.Errorf("cannot move the clock back %s", -d)
+	}
+	vc.timeOffset.Add(int64(d))
+	return nil
+}
+
+// SetTime moves the vocone clock forward to t. See AdvanceTime.
+func (vc *Vocone) SetTime(t time.Time) error {
+	return vc.AdvanceTime(t.Sub(vc.Now()))
+}
+
 // SetBlockTimeTarget configures the time window in which blocks will be created.
 func (vc *Vocone) SetBlockTimeTarget(targetTime time.Duration) {
 	vc.blockTimeTarget = targetTime
+	vc.appInfo.SetClock(vc.Now, targetTime)
 }
 
 // SetBlockSize configures the maximum number of transactions per block.
@@ -390,6 +485,7 @@ func (vc *Vocone) setDefaultMethods() {
 	vc.app.SetFnGetBlockByHeight(vc.getBlock)
 	vc.app.SetFnGetTxHash(vc.getTxWithHash)
 	vc.app.SetFnMempoolSize(vc.mempoolSize)
+	vc.app.SetFnTime(vc.Now)
 }
 
 func (vc *Vocone) addTx(tx []byte) (*tmcoretypes.ResultBroadcastTx, error) {
@@ -413,9 +509,14 @@ func (vc *Vocone) addTx(tx []byte) (*tmcoretypes.ResultBroadcastTx, error) {
 	}, nil
 }
 
-func (vc *Vocone) commitBlock() {
+func (vc *Vocone) commitBlock(timestamp time.Time) {
 	blockStoreTx := vc.blockStore.WriteTx()
 	defer blockStoreTx.Discard()
+	blockTime := make([]byte, 8)
+	binary.BigEndian.PutUint64(blockTime, uint64(timestamp.UnixNano()))
+	if err := blockStoreTx.Set(blockTimeKey(vc.height.Load()), blockTime); err != nil {
+		log.Errorf("cannot store block time: %v", err)
+	}
 	var txCount int
 txLoop:
 	for txCount = 0; txCount < vc.txsPerBlock; {
@@ -439,15 +540,26 @@ txLoop:
 	}
 	if txCount > 0 {
 		log.Infof("stored %d transactions on block %d", txCount, vc.height.Load())
-		if err := blockStoreTx.Commit(); err != nil {
-			log.Errorf("cannot commit to blockstore: %v", err)
-		}
 	}
+	if err := blockStoreTx.Commit(); err != nil {
+		log.Errorf("cannot commit to blockstore: %v", err)
+	}
+}
+
+// blockTimeKey is the blockstore key of the block timestamp.
+func blockTimeKey(height int64) []byte {
+	return []byte(fmt.Sprintf("time_%d", height))
 }
 
 // TO-DO: improve this function
 func (vc *Vocone) getBlock(height int64) *tmtypes.Block {
 	blk := new(tmtypes.Block)
+	blk.Header.Height = height
+	rtx := vc.blockStore.ReadTx()
+	if blockTime, err := rtx.Get(blockTimeKey(height)); err == nil {
+		blk.Header.Time = time.Unix(0, int64(binary.BigEndian.Uint64(blockTime)))
+	}
+	rtx.Discard()
 	for i := int32(0); ; i++ {
 		tx, err := vc.getTx(uint32(height), i)
 		if err != nil {
@@ -2,18 +2,25 @@ package vocone
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	qt "github.com/frankban/quicktest"
 	"github.com/google/uuid"
+	"go.vocdoni.io/dvote/api"
 	"go.vocdoni.io/dvote/apiclient"
 	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/db"
+	"go.vocdoni.io/dvote/test/testcommon/testutil"
 	"go.vocdoni.io/dvote/test/testcommon/testvoteproof"
 	"go.vocdoni.io/dvote/types"
 	"go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain/indexer"
 	"go.vocdoni.io/dvote/vochain/state"
 	"go.vocdoni.io/proto/build/go/models"
 )
@@ -149,3 +156,298 @@ func testCSPvote(cli *apiclient.HTTPclient) error {
 	}
 	return nil
 }
+
+// TestVoconeCheckpoint prepares a fixture chain and restores it after
+// running the same scenario twice.
+func TestVoconeCheckpoint(t *testing.T) {
+	keymng := ethereum.SignKeys{}
+	qt.Assert(t, keymng.Generate(), qt.IsNil)
+	account := ethereum.SignKeys{}
+	qt.Assert(t, account.Generate(), qt.IsNil)
+	vc, err := NewVocone(t.TempDir(), &keymng)
+	qt.Assert(t, err, qt.IsNil)
+	t.Cleanup(func() { vc.storage.Stop() })
+	qt.Assert(t, vc.SetBulkTxCosts(0, true), qt.IsNil)
+
+	port := 13000 + util.RandomInt(0, 2000)
+	_, err = vc.EnableAPI("127.0.0.1", port, "/api")
+	qt.Assert(t, err, qt.IsNil)
+	u, err := url.Parse(fmt.Sprintf("http://127.0.0.1:%d/api", port))
+	qt.Assert(t, err, qt.IsNil)
+	cli, err := apiclient.NewHTTPclient(u, nil)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, cli.SetAccount(fmt.Sprintf("%x", account.PrivateKey())), qt.IsNil)
+
+	// the fixture is an account created with a transaction
+	txHash, err := cli.AccountBootstrap(nil, nil)
+	qt.Assert(t, err, qt.IsNil)
+	height := vc.CommitBlock()
+	id, err := vc.Checkpoint()
+	qt.Assert(t, err, qt.IsNil)
+
+	for i := 0; i < 2; i++ {
+		electionID, err := cli.NewElectionRaw(&models.Process{
+			EntityId:      account.Address().Bytes(),
+			Status:        models.ProcessStatus_READY,
+			CensusRoot:    keymng.PublicKey(),
+			CensusOrigin:  models.CensusOrigin_OFF_CHAIN_CA,
+			EnvelopeType:  &models.EnvelopeType{},
+			VoteOptions:   &models.ProcessVoteOptions{MaxCount: 1, MaxValue: 1},
+			Mode:          &models.ProcessMode{AutoStart: true, Interruptible: true},
+			BlockCount:    100,
+			MaxCensusSize: 10,
+		})
+		qt.Assert(t, err, qt.IsNil)
+		qt.Assert(t, vc.CommitBlock(), qt.Equals, height+1)
+		_, err = vc.sc.ProcessInfo(electionID)
+		qt.Assert(t, err, qt.IsNil)
+		_, err = vc.getTx(uint32(height+1), 0)
+		qt.Assert(t, err, qt.IsNil)
+
+		// the election is removed from the state, the indexer and the blockstore
+		qt.Assert(t, vc.Restore(id), qt.IsNil)
+		qt.Assert(t, int64(vc.app.Height()), qt.Equals, height)
+		_, err = vc.app.State.Process(electionID, true)
+		qt.Assert(t, err, qt.ErrorIs, state.ErrProcessNotFound)
+		_, err = vc.sc.ProcessInfo(electionID)
+		qt.Assert(t, err, qt.ErrorIs, indexer.ErrProcessNotFound)
+		_, err = vc.getTx(uint32(height+1), 0)
+		qt.Assert(t, err, qt.IsNotNil)
+
+		// while the fixture is kept
+		acc, err := cli.Account("")
+		qt.Assert(t, err, qt.IsNil)
+		qt.Assert(t, acc.Nonce, qt.Equals, uint32(0))
+		_, err = cli.TransactionReference(txHash)
+		qt.Assert(t, err, qt.IsNil)
+	}
+	qt.Assert(t, vc.Restore(id+1), qt.IsNotNil)
+}
+
+// TestVoconeManualBlocks produces blocks on demand and moves the clock
+// forward, both with the Go methods and the admin API.
+func TestVoconeManualBlocks(t *testing.T) {
+	keymng := ethereum.SignKeys{}
+	qt.Assert(t, keymng.Generate(), qt.IsNil)
+	vc, err := NewVocone(t.TempDir(), &keymng)
+	qt.Assert(t, err, qt.IsNil)
+	t.Cleanup(func() { vc.storage.Stop() })
+	vc.SetBlockTimeTarget(time.Minute)
+
+	// the blocks are stored with the time of the vocone clock
+	start := vc.Now()
+	height := vc.CommitBlock()
+	qt.Assert(t, int64(vc.app.Height()), qt.Equals, height)
+	qt.Assert(t, vc.AdvanceTime(time.Hour), qt.IsNil)
+	qt.Assert(t, vc.CommitBlock(), qt.Equals, height+1)
+	qt.Assert(t, vc.AdvanceBlocks(10), qt.Equals, height+11)
+	qt.Assert(t, vc.AdvanceTime(-time.Second), qt.Not(qt.IsNil))
+
+	blockTime := vc.app.TimestampFromBlock(height + 1)
+	qt.Assert(t, blockTime, qt.Not(qt.IsNil))
+	qt.Assert(t, blockTime.Sub(start) >= time.Hour, qt.IsTrue)
+	qt.Assert(t, vc.app.TimestampFromBlock(height+11).Unix()-blockTime.Unix() >= 600, qt.IsTrue)
+
+	// the height estimations follow the vocone clock and the block time target
+	estimated, err := vc.appInfo.EstimateBlockHeight(vc.Now().Add(30 * time.Minute))
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, int64(estimated), qt.Equals, height+11+30)
+	qt.Assert(t, vc.appInfo.HeightTime(height+1).Equal(*blockTime), qt.IsTrue)
+
+	// the same operations are available on the admin API
+	port := 13000 + util.RandomInt(0, 2000)
+	uAPI, err := vc.EnableAPI("127.0.0.1", port, "/api")
+	qt.Assert(t, err, qt.IsNil)
+	token := uuid.New()
+	uAPI.RouterHandler().SetAdminToken(token.String())
+	qt.Assert(t, vc.AttachAdminAPI(uAPI.RouterHandler(), "/vocone"), qt.IsNil)
+	u, err := url.Parse(fmt.Sprintf("http://127.0.0.1:%d/api", port))
+	qt.Assert(t, err, qt.IsNil)
+	c := testutil.NewTestHTTPclient(t, u, &token)
+
+	clock := &ClockResponse{}
+	resp, code := c.Request("POST", nil, "vocone", "blocks", "commit")
+	qt.Assert(t, code, qt.Equals, 200)
+	qt.Assert(t, json.Unmarshal(resp, clock), qt.IsNil)
+	qt.Assert(t, clock.Height, qt.Equals, height+12)
+
+	resp, code = c.Request("POST", &AdvanceBlocksRequest{Blocks: 5}, "vocone", "blocks", "advance")
+	qt.Assert(t, code, qt.Equals, 200)
+	qt.Assert(t, json.Unmarshal(resp, clock), qt.IsNil)
+	qt.Assert(t, clock.Height, qt.Equals, height+17)
+	_, code = c.Request("POST", &AdvanceBlocksRequest{Blocks: 0}, "vocone", "blocks", "advance")
+	qt.Assert(t, code, qt.Equals, api.ErrInvalidBlockCount.HTTPstatus)
+
+	target := vc.Now().Add(24 * time.Hour)
+	resp, code = c.Request("POST", &AdvanceClockRequest{Time: &target}, "vocone", "clock", "advance")
+	qt.Assert(t, code, qt.Equals, 200)
+	qt.Assert(t, json.Unmarshal(resp, clock), qt.IsNil)
+	qt.Assert(t, clock.Time.Before(target), qt.IsFalse)
+	_, code = c.Request("POST", &AdvanceClockRequest{Seconds: -1}, "vocone", "clock", "advance")
+	qt.Assert(t, code, qt.Equals, api.ErrCantMoveClockBack.HTTPstatus)
+
+	// dateToBlock uses the vocone clock
+	resp, code = c.Request("GET", nil, "chain", "dateToBlock",
+		fmt.Sprintf("%d", vc.Now().Add(time.Hour).Unix()))
+	qt.Assert(t, code, qt.Equals, 200)
+	dateToBlock := &struct {
+		Height uint32 `json:"height"`
+	}{}
+	qt.Assert(t, json.Unmarshal(resp, dateToBlock), qt.IsNil)
+	qt.Assert(t, int64(dateToBlock.Height), qt.Equals, height+17+60)
+
+	// without the admin token, the endpoints are not allowed
+	_, code = testutil.NewTestHTTPclient(t, u, nil).Request("POST", nil, "vocone", "blocks", "commit")
+	qt.Assert(t, code, qt.Not(qt.Equals), 200)
+}
+
+// TestVoconeFork forks the state of a node data directory, replacing its
+// oracles and validators.
+func TestVoconeFork(t *testing.T) {
+	// the state of a node, with an oracle, an account and a process
+	nodeDir := filepath.Join(t.TempDir(), "data")
+	st, err := state.NewState(db.TypePebble, nodeDir)
+	qt.Assert(t, err, qt.IsNil)
+	oracle := ethereum.SignKeys{}
+	qt.Assert(t, oracle.Generate(), qt.IsNil)
+	qt.Assert(t, st.AddOracle(oracle.Address()), qt.IsNil)
+	account := ethereum.SignKeys{}
+	qt.Assert(t, account.Generate(), qt.IsNil)
+	qt.Assert(t, st.SetAccount(account.Address(),
+		&state.Account{Account: models.Account{Balance: 500}}), qt.IsNil)
+	pid := util.RandomBytes(types.ProcessIDsize)
+	qt.Assert(t, st.AddProcess(&models.Process{
+		ProcessId:    pid,
+		EntityId:     account.Address().Bytes(),
+		StartBlock:   1,
+		BlockCount:   100,
+		Status:       models.ProcessStatus_READY,
+		EnvelopeType: &models.EnvelopeType{},
+		Mode:         &models.ProcessMode{},
+		VoteOptions:  &models.ProcessVoteOptions{MaxCount: 1, MaxValue: 1},
+	}), qt.IsNil)
+	for i := uint32(1); i <= 3; i++ {
+		st.SetHeight(i)
+		_, err := st.Save()
+		qt.Assert(t, err, qt.IsNil)
+	}
+	qt.Assert(t, st.Close(), qt.IsNil)
+
+	keymng := ethereum.SignKeys{}
+	qt.Assert(t, keymng.Generate(), qt.IsNil)
+	dir := t.TempDir()
+	vc, err := NewForkedVocone(dir, &keymng, &ForkConfig{DataDir: filepath.Dir(nodeDir), Height: 2})
+	qt.Assert(t, err, qt.IsNil)
+	t.Cleanup(func() { vc.storage.Stop() })
+
+	qt.Assert(t, vc.height.Load(), qt.Equals, int64(2))
+	acc, err := vc.app.State.GetAccount(account.Address(), true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, acc.Balance, qt.Equals, uint64(500))
+	oracles, err := vc.app.State.Oracles(true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, oracles, qt.DeepEquals, []common.Address{keymng.Address()})
+	validators, err := vc.app.State.Validators(true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, validators, qt.HasLen, 1)
+	for _, v := range validators {
+		qt.Assert(t, v.Address, qt.DeepEquals, keymng.Address().Bytes())
+	}
+	process, err := vc.sc.ProcessInfo(pid)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, process.EndBlock, qt.Equals, uint32(101))
+
+	// the forked chain produces new blocks
+	qt.Assert(t, vc.CommitBlock(), qt.Equals, int64(2))
+	qt.Assert(t, vc.CommitBlock(), qt.Equals, int64(3))
+	acc, err = vc.app.State.GetAccount(account.Address(), true)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, acc.Balance, qt.Equals, uint64(500))
+}
+
+// TestVoconeOfflineTxs builds a batch of transactions, signs it without the
+// API client and sends it.
+func TestVoconeOfflineTxs(t *testing.T) {
+	keymng := ethereum.SignKeys{}
+	qt.Assert(t, keymng.Generate(), qt.IsNil)
+	treasurer := ethereum.SignKeys{}
+	qt.Assert(t, treasurer.Generate(), qt.IsNil)
+	receiver := ethereum.SignKeys{}
+	qt.Assert(t, receiver.Generate(), qt.IsNil)
+	vc, err := NewVocone(t.TempDir(), &keymng)
+	qt.Assert(t, err, qt.IsNil)
+	t.Cleanup(func() { vc.storage.Stop() })
+	qt.Assert(t, vc.SetBulkTxCosts(0, true), qt.IsNil)
+	qt.Assert(t, vc.SetTreasurer(treasurer.Address()), qt.IsNil)
+	qt.Assert(t, vc.CreateAccount(treasurer.Address(), &state.Account{
+		Account: models.Account{Balance: 100},
+	}), qt.IsNil)
+	qt.Assert(t, vc.CreateAccount(receiver.Address(), &state.Account{}), qt.IsNil)
+	vc.SetBlockTimeTarget(time.Millisecond * 500)
+	go vc.Start()
+	port := 13000 + util.RandomInt(0, 2000)
+	_, err = vc.EnableAPI("127.0.0.1", port, "/api")
+	qt.Assert(t, err, qt.IsNil)
+	time.Sleep(time.Second)
+	u, err := url.Parse(fmt.Sprintf("http://127.0.0.1:%d/api", port))
+	qt.Assert(t, err, qt.IsNil)
+	// the client has no account, the transactions are signed apart
+	cli, err := apiclient.NewHTTPclient(u, nil)
+	qt.Assert(t, err, qt.IsNil)
+
+	// two transfers and a mint, with the account and treasurer nonces
+	builder, err := cli.NewOfflineTxBuilder(nil)
+	qt.Assert(t, err, qt.IsNil)
+	for _, value := range []uint64{10, 20} {
+		qt.Assert(t, builder.Add(treasurer.Address(), &models.Tx{
+			Payload: &models.Tx_SendTokens{SendTokens: &models.SendTokensTx{
+				Txtype: models.TxType_SEND_TOKENS,
+				From:   treasurer.Address().Bytes(),
+				To:     receiver.Address().Bytes(),
+				Value:  value,
+			}},
+		}), qt.IsNil)
+	}
+	qt.Assert(t, builder.Add(treasurer.Address(), &models.Tx{
+		Payload: &models.Tx_MintTokens{MintTokens: &models.MintTokensTx{
+			Txtype: models.TxType_MINT_TOKENS,
+			To:     receiver.Address().Bytes(),
+			Value:  5,
+		}},
+	}), qt.IsNil)
+	txFile := filepath.Join(t.TempDir(), "txs.json")
+	qt.Assert(t, builder.File().Save(txFile), qt.IsNil)
+
+	// the file is signed offline
+	file, err := apiclient.LoadOfflineTxFile(txFile)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, file.Transactions, qt.HasLen, 3)
+	tx, err := file.Transactions[1].Decode()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, tx.GetSendTokens().GetNonce(), qt.Equals, uint32(1))
+	tx, err = file.Transactions[2].Decode()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, tx.GetMintTokens().GetNonce(), qt.Equals, uint32(0))
+	signed, err := file.Sign(&receiver)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, signed, qt.Equals, 0)
+	_, err = cli.SendOfflineTxs(context.Background(), file)
+	qt.Assert(t, err, qt.IsNotNil)
+	signed, err = file.Sign(&treasurer)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, signed, qt.Equals, 3)
+	ok, err := file.Transactions[0].Verify(file.ChainID)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, ok, qt.IsTrue)
+	_, err = file.Transactions[0].Verify("other-chain")
+	qt.Assert(t, err, qt.IsNotNil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+	hashes, err := cli.SendOfflineTxs(ctx, file)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, hashes, qt.HasLen, 3)
+	acc, err := cli.Account(receiver.Address().Hex())
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, acc.Balance, qt.Equals, uint64(35))
+}